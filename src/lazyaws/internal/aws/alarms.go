@@ -0,0 +1,296 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// Alarm types
+const (
+	AlarmTypeMetric    = "Metric"
+	AlarmTypeComposite = "Composite"
+)
+
+// Kinds of resource an alarm can watch
+const (
+	AlarmResourceEC2Instance = "ec2-instance"
+	AlarmResourceEKSCluster  = "eks-cluster"
+)
+
+// alarmHistoryLimit caps the state transitions fetched for one alarm
+const alarmHistoryLimit = 100
+
+// Alarm represents a CloudWatch metric or composite alarm
+type Alarm struct {
+	Name           string
+	ARN            string
+	Type           string
+	State          string
+	StateReason    string
+	StateUpdated   time.Time
+	ActionsEnabled bool
+	Description    string
+	// Metric alarms only
+	Namespace  string
+	MetricName string
+	Dimensions map[string]string
+	// Composite alarms only
+	AlarmRule string
+	Resource  AlarmResource
+}
+
+// AlarmResource is the resource an alarm watches, derived from its metric
+// dimensions. Kind is empty when lazyaws can't browse the resource.
+type AlarmResource struct {
+	Kind string
+	ID   string
+}
+
+// String formats the resource for display
+func (r AlarmResource) String() string {
+	switch r.Kind {
+	case AlarmResourceEC2Instance:
+		return "ec2:" + r.ID
+	case AlarmResourceEKSCluster:
+		return "eks:" + r.ID
+	}
+	return r.ID
+}
+
+// AlarmHistoryItem is one state transition of an alarm
+type AlarmHistoryItem struct {
+	Timestamp time.Time
+	OldState  string
+	NewState  string
+	Summary   string
+	Reason    string
+}
+
+// ListAlarms lists every metric and composite alarm, sorted with alarms in
+// the ALARM state first
+func (c *Client) ListAlarms(ctx context.Context) ([]Alarm, error) {
+	input := &cloudwatch.DescribeAlarmsInput{
+		AlarmTypes: []types.AlarmType{types.AlarmTypeMetricAlarm, types.AlarmTypeCompositeAlarm},
+	}
+
+	var alarms []Alarm
+	for {
+		output, err := c.CloudWatch.DescribeAlarms(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe alarms: %w", err)
+		}
+		for _, alarm := range output.MetricAlarms {
+			alarms = append(alarms, metricAlarm(alarm))
+		}
+		for _, alarm := range output.CompositeAlarms {
+			alarms = append(alarms, compositeAlarm(alarm))
+		}
+		if output.NextToken == nil || *output.NextToken == "" {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+
+	sort.SliceStable(alarms, func(i, j int) bool {
+		if alarmStateRank(alarms[i].State) != alarmStateRank(alarms[j].State) {
+			return alarmStateRank(alarms[i].State) < alarmStateRank(alarms[j].State)
+		}
+		return alarms[i].Name < alarms[j].Name
+	})
+	return alarms, nil
+}
+
+// GetAlarmHistory returns an alarm's state transitions, newest first
+func (c *Client) GetAlarmHistory(ctx context.Context, alarmName string) ([]AlarmHistoryItem, error) {
+	input := &cloudwatch.DescribeAlarmHistoryInput{
+		AlarmName:       &alarmName,
+		HistoryItemType: types.HistoryItemTypeStateUpdate,
+		ScanBy:          types.ScanByTimestampDescending,
+	}
+
+	var items []AlarmHistoryItem
+	for len(items) < alarmHistoryLimit {
+		output, err := c.CloudWatch.DescribeAlarmHistory(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get history of alarm %s: %w", alarmName, err)
+		}
+		for _, item := range output.AlarmHistoryItems {
+			items = append(items, alarmHistoryItem(item))
+		}
+		if output.NextToken == nil || *output.NextToken == "" {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+
+	if len(items) > alarmHistoryLimit {
+		items = items[:alarmHistoryLimit]
+	}
+	return items, nil
+}
+
+// SetAlarmActionsEnabled enables or disables the actions of an alarm
+func (c *Client) SetAlarmActionsEnabled(ctx context.Context, alarmName string, enabled bool) error {
+	if enabled {
+		_, err := c.CloudWatch.EnableAlarmActions(ctx, &cloudwatch.EnableAlarmActionsInput{
+			AlarmNames: []string{alarmName},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to enable actions of alarm %s: %w", alarmName, err)
+		}
+		return nil
+	}
+
+	_, err := c.CloudWatch.DisableAlarmActions(ctx, &cloudwatch.DisableAlarmActionsInput{
+		AlarmNames: []string{alarmName},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to disable actions of alarm %s: %w", alarmName, err)
+	}
+	return nil
+}
+
+// SetAlarmState sets an alarm's state until its next evaluation, which is
+// useful to test the alarm's actions
+func (c *Client) SetAlarmState(ctx context.Context, alarmName, state, reason string) error {
+	_, err := c.CloudWatch.SetAlarmState(ctx, &cloudwatch.SetAlarmStateInput{
+		AlarmName:   &alarmName,
+		StateValue:  types.StateValue(state),
+		StateReason: &reason,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set state of alarm %s: %w", alarmName, err)
+	}
+	return nil
+}
+
+// metricAlarm converts an SDK metric alarm
+func metricAlarm(alarm types.MetricAlarm) Alarm {
+	result := Alarm{
+		Name:        getString(alarm.AlarmName),
+		ARN:         getString(alarm.AlarmArn),
+		Type:        AlarmTypeMetric,
+		State:       string(alarm.StateValue),
+		StateReason: getString(alarm.StateReason),
+		Description: getString(alarm.AlarmDescription),
+		Namespace:   getString(alarm.Namespace),
+		MetricName:  getString(alarm.MetricName),
+		Dimensions:  make(map[string]string),
+	}
+	if alarm.ActionsEnabled != nil {
+		result.ActionsEnabled = *alarm.ActionsEnabled
+	}
+	if alarm.StateUpdatedTimestamp != nil {
+		result.StateUpdated = *alarm.StateUpdatedTimestamp
+	}
+	for _, dim := range alarm.Dimensions {
+		result.Dimensions[getString(dim.Name)] = getString(dim.Value)
+	}
+
+	// Metric math alarms keep their dimensions in the queries
+	if result.MetricName == "" {
+		for _, query := range alarm.Metrics {
+			if query.MetricStat == nil || query.MetricStat.Metric == nil {
+				continue
+			}
+			for _, dim := range query.MetricStat.Metric.Dimensions {
+				if _, ok := result.Dimensions[getString(dim.Name)]; !ok {
+					result.Dimensions[getString(dim.Name)] = getString(dim.Value)
+				}
+			}
+			if result.Namespace == "" {
+				result.Namespace = getString(query.MetricStat.Metric.Namespace)
+			}
+		}
+	}
+
+	result.Resource = alarmResource(result.Namespace, result.Dimensions)
+	return result
+}
+
+// compositeAlarm converts an SDK composite alarm
+func compositeAlarm(alarm types.CompositeAlarm) Alarm {
+	result := Alarm{
+		Name:        getString(alarm.AlarmName),
+		ARN:         getString(alarm.AlarmArn),
+		Type:        AlarmTypeComposite,
+		State:       string(alarm.StateValue),
+		StateReason: getString(alarm.StateReason),
+		Description: getString(alarm.AlarmDescription),
+		AlarmRule:   getString(alarm.AlarmRule),
+	}
+	if alarm.ActionsEnabled != nil {
+		result.ActionsEnabled = *alarm.ActionsEnabled
+	}
+	if alarm.StateUpdatedTimestamp != nil {
+		result.StateUpdated = *alarm.StateUpdatedTimestamp
+	}
+	return result
+}
+
+// alarmResource works out the resource behind a metric from its dimensions
+func alarmResource(namespace string, dimensions map[string]string) AlarmResource {
+	if id, ok := dimensions["InstanceId"]; ok {
+		return AlarmResource{Kind: AlarmResourceEC2Instance, ID: id}
+	}
+	if name, ok := dimensions["ClusterName"]; ok && (namespace == "ContainerInsights" || namespace == "AWS/EKS") {
+		return AlarmResource{Kind: AlarmResourceEKSCluster, ID: name}
+	}
+
+	// Fall back to the first dimension for display
+	var names []string
+	for name := range dimensions {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return AlarmResource{}
+	}
+	sort.Strings(names)
+	return AlarmResource{ID: names[0] + "=" + dimensions[names[0]]}
+}
+
+// alarmHistoryData is the JSON document of a state update history item
+type alarmHistoryData struct {
+	OldState struct {
+		StateValue string `json:"stateValue"`
+	} `json:"oldState"`
+	NewState struct {
+		StateValue  string `json:"stateValue"`
+		StateReason string `json:"stateReason"`
+	} `json:"newState"`
+}
+
+// alarmHistoryItem converts an SDK history item, reading the states from
+// its JSON data when present
+func alarmHistoryItem(item types.AlarmHistoryItem) AlarmHistoryItem {
+	result := AlarmHistoryItem{Summary: getString(item.HistorySummary)}
+	if item.Timestamp != nil {
+		result.Timestamp = *item.Timestamp
+	}
+
+	var data alarmHistoryData
+	if item.HistoryData != nil && json.Unmarshal([]byte(*item.HistoryData), &data) == nil {
+		result.OldState = data.OldState.StateValue
+		result.NewState = data.NewState.StateValue
+		result.Reason = data.NewState.StateReason
+	}
+	return result
+}
+
+// alarmStateRank orders alarm states by urgency
+func alarmStateRank(state string) int {
+	switch types.StateValue(state) {
+	case types.StateValueAlarm:
+		return 0
+	case types.StateValueInsufficientData:
+		return 1
+	default:
+		return 2
+	}
+}
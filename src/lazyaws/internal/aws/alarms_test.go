@@ -0,0 +1,118 @@
+package aws
+
+import (
+	"context"
+	"testing"
+
+	sdkaws "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+func newAlarmBackend() *FakeBackend {
+	backend := NewFakeBackend()
+	backend.CloudWatch.PageSize = 2
+	backend.CloudWatch.MetricAlarms = []types.MetricAlarm{
+		{
+			AlarmName:      sdkaws.String("high-cpu"),
+			StateValue:     types.StateValueOk,
+			ActionsEnabled: sdkaws.Bool(true),
+			Namespace:      sdkaws.String("AWS/EC2"),
+			MetricName:     sdkaws.String("CPUUtilization"),
+			Dimensions:     []types.Dimension{{Name: sdkaws.String("InstanceId"), Value: sdkaws.String("i-0001")}},
+		},
+		{
+			AlarmName:   sdkaws.String("node-count"),
+			StateValue:  types.StateValueAlarm,
+			StateReason: sdkaws.String("Threshold Crossed"),
+			Namespace:   sdkaws.String("ContainerInsights"),
+			MetricName:  sdkaws.String("cluster_node_count"),
+			Dimensions:  []types.Dimension{{Name: sdkaws.String("ClusterName"), Value: sdkaws.String("prod")}},
+		},
+		{
+			AlarmName:  sdkaws.String("queue-depth"),
+			StateValue: types.StateValueInsufficientData,
+			Namespace:  sdkaws.String("AWS/SQS"),
+			MetricName: sdkaws.String("ApproximateNumberOfMessagesVisible"),
+			Dimensions: []types.Dimension{{Name: sdkaws.String("QueueName"), Value: sdkaws.String("jobs")}},
+		},
+	}
+	backend.CloudWatch.CompositeAlarms = []types.CompositeAlarm{
+		{
+			AlarmName:  sdkaws.String("service-down"),
+			StateValue: types.StateValueOk,
+			AlarmRule:  sdkaws.String(`ALARM("high-cpu") AND ALARM("node-count")`),
+		},
+	}
+	return backend
+}
+
+func TestListAlarms(t *testing.T) {
+	backend := newAlarmBackend()
+	client := backend.Client("us-east-1")
+
+	alarms, err := client.ListAlarms(context.Background())
+	if err != nil {
+		t.Fatalf("ListAlarms returned error: %v", err)
+	}
+	if len(alarms) != 4 {
+		t.Fatalf("Expected 4 alarms across pages, got %d", len(alarms))
+	}
+
+	// Alarms in the ALARM state come first, then INSUFFICIENT_DATA, then OK by name
+	want := []string{"node-count", "queue-depth", "high-cpu", "service-down"}
+	for i, name := range want {
+		if alarms[i].Name != name {
+			t.Errorf("Expected alarm %d to be %s, got %s", i, name, alarms[i].Name)
+		}
+	}
+
+	if r := alarms[0].Resource; r.Kind != AlarmResourceEKSCluster || r.ID != "prod" {
+		t.Errorf("Expected node-count to watch EKS cluster prod, got %+v", r)
+	}
+	if r := alarms[1].Resource; r.Kind != "" || r.String() != "QueueName=jobs" {
+		t.Errorf("Expected queue-depth to show its dimension, got %+v", r)
+	}
+	if r := alarms[2].Resource; r.Kind != AlarmResourceEC2Instance || r.ID != "i-0001" {
+		t.Errorf("Expected high-cpu to watch instance i-0001, got %+v", r)
+	}
+	if alarms[3].Type != AlarmTypeComposite || alarms[3].AlarmRule == "" {
+		t.Errorf("Expected service-down to be a composite alarm with a rule, got %+v", alarms[3])
+	}
+}
+
+func TestAlarmActionsAndState(t *testing.T) {
+	backend := newAlarmBackend()
+	client := backend.Client("us-east-1")
+	ctx := context.Background()
+
+	if err := client.SetAlarmActionsEnabled(ctx, "high-cpu", false); err != nil {
+		t.Fatalf("SetAlarmActionsEnabled returned error: %v", err)
+	}
+	if *backend.CloudWatch.MetricAlarms[0].ActionsEnabled {
+		t.Error("Expected actions of high-cpu to be disabled")
+	}
+
+	if err := client.SetAlarmState(ctx, "high-cpu", "ALARM", "Testing"); err != nil {
+		t.Fatalf("SetAlarmState returned error: %v", err)
+	}
+	if err := client.SetAlarmState(ctx, "high-cpu", "OK", "Done testing"); err != nil {
+		t.Fatalf("SetAlarmState returned error: %v", err)
+	}
+	if err := client.SetAlarmState(ctx, "missing", "OK", "x"); err == nil {
+		t.Error("Expected an error for an unknown alarm")
+	}
+
+	history, err := client.GetAlarmHistory(ctx, "high-cpu")
+	if err != nil {
+		t.Fatalf("GetAlarmHistory returned error: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("Expected 2 state transitions, got %d", len(history))
+	}
+	if history[0].OldState != "ALARM" || history[0].NewState != "OK" || history[0].Reason != "Done testing" {
+		t.Errorf("Expected the newest transition ALARM -> OK first, got %+v", history[0])
+	}
+	if history[1].OldState != "OK" || history[1].NewState != "ALARM" {
+		t.Errorf("Expected OK -> ALARM second, got %+v", history[1])
+	}
+}
@@ -3,9 +3,11 @@ package aws
 import (
 	"context"
 
+	sdkaws "github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/eks"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
@@ -16,17 +18,24 @@ import (
 
 // Client wraps AWS service clients
 type Client struct {
-	EC2         *ec2.Client
-	S3          *s3.Client
-	EKS         *eks.Client
-	SSM         *ssm.Client
-	CloudWatch  *cloudwatch.Client
-	STS         *sts.Client
+	EC2         EC2API
+	S3          S3API
+	S3Presign   S3PresignAPI
+	EKS         EKSAPI
+	SSM         SSMAPI
+	CloudWatch  CloudWatchAPI
+	Logs        LogsAPI
+	STS         STSAPI
+	STSPresign  STSPresignAPI
 	Region      string
 	AccountID   string
 	AccountName string
 }
 
+// PageProgressCallback receives the number of items loaded so far while a
+// paginated listing is still fetching pages
+type PageProgressCallback func(loaded int)
+
 // NewClient creates a new AWS client with the default configuration
 func NewClient(ctx context.Context, appConfig *config.Config) (*Client, error) {
 	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(appConfig.Region))
@@ -34,15 +43,7 @@ func NewClient(ctx context.Context, appConfig *config.Config) (*Client, error) {
 		return nil, err
 	}
 
-	client := &Client{
-		EC2:        ec2.NewFromConfig(cfg),
-		S3:         s3.NewFromConfig(cfg),
-		EKS:        eks.NewFromConfig(cfg),
-		SSM:        ssm.NewFromConfig(cfg),
-		CloudWatch: cloudwatch.NewFromConfig(cfg),
-		STS:        sts.NewFromConfig(cfg),
-		Region:     cfg.Region,
-	}
+	client := newClientFromConfig(cfg)
 
 	// Try to get account identity
 	_ = client.loadAccountIdentity(ctx)
@@ -52,22 +53,25 @@ func NewClient(ctx context.Context, appConfig *config.Config) (*Client, error) {
 
 // NewClientWithProfile creates a new AWS client with a specific profile
 func NewClientWithProfile(ctx context.Context, profile string) (*Client, error) {
-	cfg, err := awsconfig.LoadDefaultConfig(ctx,
+	return NewClientWithProfileAndRegion(ctx, profile, "")
+}
+
+// NewClientWithProfileAndRegion creates a new AWS client with a specific
+// profile, using region instead of the profile's region when it is set
+func NewClientWithProfileAndRegion(ctx context.Context, profile, region string) (*Client, error) {
+	optFns := []func(*awsconfig.LoadOptions) error{
 		awsconfig.WithSharedConfigProfile(profile),
-	)
+	}
+	if region != "" {
+		optFns = append(optFns, awsconfig.WithRegion(region))
+	}
+
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, optFns...)
 	if err != nil {
 		return nil, err
 	}
 
-	client := &Client{
-		EC2:        ec2.NewFromConfig(cfg),
-		S3:         s3.NewFromConfig(cfg),
-		EKS:        eks.NewFromConfig(cfg),
-		SSM:        ssm.NewFromConfig(cfg),
-		CloudWatch: cloudwatch.NewFromConfig(cfg),
-		STS:        sts.NewFromConfig(cfg),
-		Region:     cfg.Region,
-	}
+	client := newClientFromConfig(cfg)
 
 	// Try to get account identity
 	_ = client.loadAccountIdentity(ctx)
@@ -89,16 +93,8 @@ func NewClientWithSSOCredentials(ctx context.Context, creds *SSOCredentials, reg
 		return nil, err
 	}
 
-	client := &Client{
-		EC2:         ec2.NewFromConfig(cfg),
-		S3:          s3.NewFromConfig(cfg),
-		EKS:         eks.NewFromConfig(cfg),
-		SSM:         ssm.NewFromConfig(cfg),
-		CloudWatch:  cloudwatch.NewFromConfig(cfg),
-		STS:         sts.NewFromConfig(cfg),
-		Region:      cfg.Region,
-		AccountName: accountName,
-	}
+	client := newClientFromConfig(cfg)
+	client.AccountName = accountName
 
 	// Get account identity
 	_ = client.loadAccountIdentity(ctx)
@@ -106,6 +102,24 @@ func NewClientWithSSOCredentials(ctx context.Context, creds *SSOCredentials, reg
 	return client, nil
 }
 
+// newClientFromConfig builds a Client backed by the real SDK service clients
+func newClientFromConfig(cfg sdkaws.Config) *Client {
+	s3Client := s3.NewFromConfig(cfg)
+	stsClient := sts.NewFromConfig(cfg)
+	return &Client{
+		EC2:        ec2.NewFromConfig(cfg),
+		S3:         s3Client,
+		S3Presign:  s3.NewPresignClient(s3Client),
+		EKS:        eks.NewFromConfig(cfg),
+		SSM:        ssm.NewFromConfig(cfg),
+		CloudWatch: cloudwatch.NewFromConfig(cfg),
+		Logs:       cloudwatchlogs.NewFromConfig(cfg),
+		STS:        stsClient,
+		STSPresign: sts.NewPresignClient(stsClient),
+		Region:     cfg.Region,
+	}
+}
+
 // GetRegion returns the configured AWS region
 func (c *Client) GetRegion() string {
 	return c.Region
@@ -3,6 +3,8 @@ package aws
 import (
 	"context"
 	"fmt"
+	"math"
+	"sort"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
@@ -11,124 +13,276 @@ import (
 
 // InstanceMetrics represents CloudWatch metrics for an EC2 instance
 type InstanceMetrics struct {
-	InstanceID       string
-	CPUUtilization   float64
-	NetworkIn        float64
-	NetworkOut       float64
-	DiskReadBytes    float64
-	DiskWriteBytes   float64
+	InstanceID        string
+	CPUUtilization    float64
+	NetworkIn         float64
+	NetworkOut        float64
+	DiskReadBytes     float64
+	DiskWriteBytes    float64
 	StatusCheckFailed int
-	Period           string
+	Period            string
 }
 
-// GetInstanceMetrics retrieves CloudWatch metrics for an EC2 instance
-func (c *Client) GetInstanceMetrics(ctx context.Context, instanceID string) (*InstanceMetrics, error) {
-	metrics := &InstanceMetrics{
-		InstanceID: instanceID,
-		Period:     "Last 5 minutes",
+// InstanceMetric describes one EC2 metric shown on the details screen
+type InstanceMetric struct {
+	Name  string // CloudWatch metric name
+	Label string
+	Unit  string // "Percent", "Bytes" or "Count"
+	// Stat is the statistic that summarizes one period
+	Stat string
+}
+
+// InstanceMetricList is every EC2 metric lazyaws charts, in display order
+var InstanceMetricList = []InstanceMetric{
+	{Name: "CPUUtilization", Label: "CPU Utilization", Unit: "Percent", Stat: "Average"},
+	{Name: "NetworkIn", Label: "Network In", Unit: "Bytes", Stat: "Sum"},
+	{Name: "NetworkOut", Label: "Network Out", Unit: "Bytes", Stat: "Sum"},
+	{Name: "DiskReadBytes", Label: "Disk Read", Unit: "Bytes", Stat: "Sum"},
+	{Name: "DiskWriteBytes", Label: "Disk Write", Unit: "Bytes", Stat: "Sum"},
+	{Name: "StatusCheckFailed", Label: "Status Check Failed", Unit: "Count", Stat: "Maximum"},
+}
+
+// Statistics fetched for every charted metric
+const (
+	MetricStatAverage = "Average"
+	MetricStatMinimum = "Minimum"
+	MetricStatMaximum = "Maximum"
+	MetricStatP99     = "p99"
+)
+
+// MetricHistoryStats are the statistics charted as overlays
+var MetricHistoryStats = []string{MetricStatAverage, MetricStatMinimum, MetricStatMaximum, MetricStatP99}
+
+// MetricRange is a window of metric history
+type MetricRange struct {
+	Label    string
+	Duration time.Duration
+}
+
+// MetricRanges are the selectable chart windows
+var MetricRanges = []MetricRange{
+	{Label: "1h", Duration: time.Hour},
+	{Label: "6h", Duration: 6 * time.Hour},
+	{Label: "24h", Duration: 24 * time.Hour},
+	{Label: "7d", Duration: 7 * 24 * time.Hour},
+}
+
+// maxMetricPoints caps the datapoints per series; charts are narrower than
+// this, so finer periods would only cost more
+const maxMetricPoints = 360
+
+// metricPeriods are the periods CloudWatch keeps data at, finest first
+var metricPeriods = []int32{60, 300, 900, 3600, 21600, 86400}
+
+// MetricPeriod picks the finest period that keeps window under
+// maxMetricPoints datapoints
+func MetricPeriod(window time.Duration) int32 {
+	for _, period := range metricPeriods {
+		if int64(window/time.Second)/int64(period) <= maxMetricPoints {
+			return period
+		}
 	}
+	return metricPeriods[len(metricPeriods)-1]
+}
 
+// MetricHistory is one metric's datapoints for every charted statistic.
+// Values are aligned with Timestamps; a statistic without a datapoint at a
+// timestamp is NaN.
+type MetricHistory struct {
+	Metric     InstanceMetric
+	Timestamps []time.Time
+	Stats      map[string][]float64
+}
+
+// InstanceMetricHistory is the metric history of an instance over a range
+type InstanceMetricHistory struct {
+	InstanceID string
+	Range      MetricRange
+	Period     int32
+	Start      time.Time
+	End        time.Time
+	Metrics    []MetricHistory
+}
+
+// GetInstanceMetrics retrieves the latest CloudWatch metrics for an EC2
+// instance
+func (c *Client) GetInstanceMetrics(ctx context.Context, instanceID string) (*InstanceMetrics, error) {
 	// Time range: last 5 minutes
 	endTime := time.Now()
 	startTime := endTime.Add(-5 * time.Minute)
 
-	// Helper function to get metric statistics
-	getMetric := func(metricName string, stat string) (float64, error) {
-		namespace := "AWS/EC2"
-		dimensionName := "InstanceId"
-		input := &cloudwatch.GetMetricStatisticsInput{
-			Namespace:  &namespace,
-			MetricName: &metricName,
-			Dimensions: []types.Dimension{
-				{
-					Name:  &dimensionName,
-					Value: &instanceID,
-				},
-			},
-			StartTime:  &startTime,
-			EndTime:    &endTime,
-			Period:     getInt32Ptr(300), // 5 minutes
-			Statistics: []types.Statistic{types.Statistic(stat)},
-		}
-
-		result, err := c.CloudWatch.GetMetricStatistics(ctx, input)
-		if err != nil {
-			return 0, err
-		}
+	var queries []types.MetricDataQuery
+	for i, metric := range InstanceMetricList {
+		queries = append(queries, instanceMetricQuery(fmt.Sprintf("m%d", i), instanceID, metric.Name, metric.Stat, 300))
+	}
 
-		if len(result.Datapoints) == 0 {
-			return 0, nil
-		}
+	results, err := c.getMetricData(ctx, queries, startTime, endTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metrics: %w", err)
+	}
 
-		// Return the most recent datapoint
-		var latestDatapoint types.Datapoint
+	// Use the most recent datapoint of each metric
+	latest := func(i int) float64 {
+		result := results[fmt.Sprintf("m%d", i)]
+		var value float64
 		var latestTime time.Time
-		for _, dp := range result.Datapoints {
-			if dp.Timestamp != nil && dp.Timestamp.After(latestTime) {
-				latestTime = *dp.Timestamp
-				latestDatapoint = dp
+		for j, ts := range result.Timestamps {
+			if j < len(result.Values) && ts.After(latestTime) {
+				latestTime = ts
+				value = result.Values[j]
 			}
 		}
+		return value
+	}
 
-		switch stat {
-		case "Average":
-			if latestDatapoint.Average != nil {
-				return *latestDatapoint.Average, nil
-			}
-		case "Sum":
-			if latestDatapoint.Sum != nil {
-				return *latestDatapoint.Sum, nil
-			}
-		case "Maximum":
-			if latestDatapoint.Maximum != nil {
-				return *latestDatapoint.Maximum, nil
-			}
-		}
+	return &InstanceMetrics{
+		InstanceID:        instanceID,
+		Period:            "Last 5 minutes",
+		CPUUtilization:    latest(0),
+		NetworkIn:         latest(1),
+		NetworkOut:        latest(2),
+		DiskReadBytes:     latest(3),
+		DiskWriteBytes:    latest(4),
+		StatusCheckFailed: int(latest(5)),
+	}, nil
+}
+
+// GetInstanceMetricHistory retrieves every charted metric and statistic for
+// an instance over r in one batched GetMetricData request. The period is
+// picked from the range.
+func (c *Client) GetInstanceMetricHistory(ctx context.Context, instanceID string, r MetricRange) (*InstanceMetricHistory, error) {
+	end := time.Now().Truncate(time.Minute)
+	start := end.Add(-r.Duration)
+	period := MetricPeriod(r.Duration)
 
-		return 0, nil
+	queryID := func(metric, stat int) string {
+		return fmt.Sprintf("m%d_s%d", metric, stat)
 	}
 
-	// Get CPU utilization
-	cpu, err := getMetric("CPUUtilization", "Average")
-	if err != nil {
-		return nil, fmt.Errorf("failed to get CPU metrics: %w", err)
+	var queries []types.MetricDataQuery
+	for i, metric := range InstanceMetricList {
+		for j, stat := range MetricHistoryStats {
+			queries = append(queries, instanceMetricQuery(queryID(i, j), instanceID, metric.Name, stat, period))
+		}
 	}
-	metrics.CPUUtilization = cpu
 
-	// Get network metrics
-	networkIn, err := getMetric("NetworkIn", "Sum")
+	results, err := c.getMetricData(ctx, queries, start, end)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get network in metrics: %w", err)
+		return nil, fmt.Errorf("failed to get metric history: %w", err)
 	}
-	metrics.NetworkIn = networkIn
 
-	networkOut, err := getMetric("NetworkOut", "Sum")
-	if err != nil {
-		return nil, fmt.Errorf("failed to get network out metrics: %w", err)
+	history := &InstanceMetricHistory{
+		InstanceID: instanceID,
+		Range:      r,
+		Period:     period,
+		Start:      start,
+		End:        end,
 	}
-	metrics.NetworkOut = networkOut
+	for i, metric := range InstanceMetricList {
+		byStat := make(map[string]types.MetricDataResult)
+		for j, stat := range MetricHistoryStats {
+			byStat[stat] = results[queryID(i, j)]
+		}
+		history.Metrics = append(history.Metrics, alignMetricHistory(metric, byStat))
+	}
+	return history, nil
+}
 
-	// Get disk metrics
-	diskRead, err := getMetric("DiskReadBytes", "Sum")
-	if err != nil {
-		return nil, fmt.Errorf("failed to get disk read metrics: %w", err)
+// instanceMetricQuery builds a query for one statistic of an EC2 metric
+func instanceMetricQuery(id, instanceID, metricName, stat string, period int32) types.MetricDataQuery {
+	namespace := "AWS/EC2"
+	dimensionName := "InstanceId"
+	returnData := true
+	return types.MetricDataQuery{
+		Id: &id,
+		MetricStat: &types.MetricStat{
+			Metric: &types.Metric{
+				Namespace:  &namespace,
+				MetricName: &metricName,
+				Dimensions: []types.Dimension{
+					{
+						Name:  &dimensionName,
+						Value: &instanceID,
+					},
+				},
+			},
+			Period: getInt32Ptr(period),
+			Stat:   &stat,
+		},
+		ReturnData: &returnData,
 	}
-	metrics.DiskReadBytes = diskRead
+}
 
-	diskWrite, err := getMetric("DiskWriteBytes", "Sum")
-	if err != nil {
-		return nil, fmt.Errorf("failed to get disk write metrics: %w", err)
+// getMetricData runs queries, following pagination, and returns the
+// results by query ID
+func (c *Client) getMetricData(ctx context.Context, queries []types.MetricDataQuery, start, end time.Time) (map[string]types.MetricDataResult, error) {
+	results := make(map[string]types.MetricDataResult)
+	input := &cloudwatch.GetMetricDataInput{
+		MetricDataQueries: queries,
+		StartTime:         &start,
+		EndTime:           &end,
+		ScanBy:            types.ScanByTimestampAscending,
 	}
-	metrics.DiskWriteBytes = diskWrite
 
-	// Get status check failed metric
-	statusCheck, err := getMetric("StatusCheckFailed", "Maximum")
-	if err != nil {
-		return nil, fmt.Errorf("failed to get status check metrics: %w", err)
+	for {
+		output, err := c.CloudWatch.GetMetricData(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		// Later pages continue the series of earlier ones
+		for _, result := range output.MetricDataResults {
+			id := getString(result.Id)
+			merged := results[id]
+			merged.Id = result.Id
+			merged.Timestamps = append(merged.Timestamps, result.Timestamps...)
+			merged.Values = append(merged.Values, result.Values...)
+			results[id] = merged
+		}
+		if output.NextToken == nil || *output.NextToken == "" {
+			return results, nil
+		}
+		input.NextToken = output.NextToken
 	}
-	metrics.StatusCheckFailed = int(statusCheck)
+}
 
-	return metrics, nil
+// alignMetricHistory merges the statistics of one metric onto a shared,
+// sorted list of timestamps
+func alignMetricHistory(metric InstanceMetric, byStat map[string]types.MetricDataResult) MetricHistory {
+	seen := make(map[int64]bool)
+	var timestamps []time.Time
+	for _, result := range byStat {
+		for _, ts := range result.Timestamps {
+			if !seen[ts.Unix()] {
+				seen[ts.Unix()] = true
+				timestamps = append(timestamps, ts)
+			}
+		}
+	}
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i].Before(timestamps[j]) })
+
+	index := make(map[int64]int, len(timestamps))
+	for i, ts := range timestamps {
+		index[ts.Unix()] = i
+	}
+
+	history := MetricHistory{
+		Metric:     metric,
+		Timestamps: timestamps,
+		Stats:      make(map[string][]float64, len(byStat)),
+	}
+	for stat, result := range byStat {
+		values := make([]float64, len(timestamps))
+		for i := range values {
+			values[i] = math.NaN()
+		}
+		for i, ts := range result.Timestamps {
+			if i < len(result.Values) {
+				values[index[ts.Unix()]] = result.Values[i]
+			}
+		}
+		history.Stats[stat] = values
+	}
+	return history
 }
 
 // Helper function to get int32 pointer
@@ -0,0 +1,117 @@
+package aws
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	sdkaws "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+func TestGetInstanceMetricsWithFakeBackend(t *testing.T) {
+	now := time.Now()
+	older := now.Add(-4 * time.Minute)
+	newer := now.Add(-1 * time.Minute)
+
+	backend := NewFakeBackend()
+	backend.CloudWatch.Datapoints = map[string][]types.Datapoint{
+		"CPUUtilization": {
+			{Timestamp: &older, Average: sdkaws.Float64(10)},
+			{Timestamp: &newer, Average: sdkaws.Float64(42.5)},
+		},
+		"NetworkIn":         {{Timestamp: &newer, Sum: sdkaws.Float64(2048)}},
+		"StatusCheckFailed": {{Timestamp: &newer, Maximum: sdkaws.Float64(1)}},
+	}
+	client := backend.Client("us-east-1")
+
+	metrics, err := client.GetInstanceMetrics(context.Background(), "i-0001")
+	if err != nil {
+		t.Fatalf("GetInstanceMetrics returned error: %v", err)
+	}
+
+	if metrics.CPUUtilization != 42.5 {
+		t.Errorf("Expected latest CPU utilization 42.5, got %.2f", metrics.CPUUtilization)
+	}
+
+	if metrics.NetworkIn != 2048 {
+		t.Errorf("Expected network in 2048, got %.2f", metrics.NetworkIn)
+	}
+
+	if metrics.NetworkOut != 0 {
+		t.Errorf("Expected network out 0 without datapoints, got %.2f", metrics.NetworkOut)
+	}
+
+	if metrics.StatusCheckFailed != 1 {
+		t.Errorf("Expected status check failed 1, got %d", metrics.StatusCheckFailed)
+	}
+}
+
+func TestMetricPeriod(t *testing.T) {
+	tests := map[string]int32{
+		"1h":  60,
+		"6h":  60,
+		"24h": 300,
+		"7d":  3600,
+	}
+	for _, r := range MetricRanges {
+		if got := MetricPeriod(r.Duration); got != tests[r.Label] {
+			t.Errorf("MetricPeriod(%s) = %d, want %d", r.Label, got, tests[r.Label])
+		}
+	}
+}
+
+func TestGetInstanceMetricHistoryBatchesQueries(t *testing.T) {
+	now := time.Now().Truncate(time.Minute)
+	first := now.Add(-3 * time.Minute)
+	second := now.Add(-2 * time.Minute)
+	third := now.Add(-1 * time.Minute)
+
+	backend := NewFakeBackend()
+	backend.CloudWatch.PageSize = 2
+	backend.CloudWatch.Datapoints = map[string][]types.Datapoint{
+		"CPUUtilization": {
+			{Timestamp: &third, Average: sdkaws.Float64(30), Minimum: sdkaws.Float64(20), Maximum: sdkaws.Float64(40)},
+			{Timestamp: &first, Average: sdkaws.Float64(10), Minimum: sdkaws.Float64(5), Maximum: sdkaws.Float64(15),
+				ExtendedStatistics: map[string]float64{"p99": 14}},
+			{Timestamp: &second, Average: sdkaws.Float64(20), Minimum: sdkaws.Float64(10), Maximum: sdkaws.Float64(30)},
+		},
+	}
+	client := backend.Client("us-east-1")
+
+	history, err := client.GetInstanceMetricHistory(context.Background(), "i-0001", MetricRanges[0])
+	if err != nil {
+		t.Fatalf("GetInstanceMetricHistory returned error: %v", err)
+	}
+
+	// Three datapoints in pages of two: one request and one continuation
+	if backend.CloudWatch.MetricDataCalls != 2 {
+		t.Errorf("Expected one paginated GetMetricData request, got %d calls", backend.CloudWatch.MetricDataCalls)
+	}
+	if history.Period != 60 || len(history.Metrics) != len(InstanceMetricList) {
+		t.Fatalf("Unexpected history: period %d, %d metrics", history.Period, len(history.Metrics))
+	}
+
+	cpu := history.Metrics[0]
+	if len(cpu.Timestamps) != 3 || !cpu.Timestamps[0].Equal(first) {
+		t.Fatalf("Expected 3 timestamps in ascending order, got %v", cpu.Timestamps)
+	}
+	averages := cpu.Stats[MetricStatAverage]
+	if averages[0] != 10 || averages[1] != 20 || averages[2] != 30 {
+		t.Errorf("Expected averages [10 20 30], got %v", averages)
+	}
+	if maxima := cpu.Stats[MetricStatMaximum]; maxima[2] != 40 {
+		t.Errorf("Expected maximum 40 at the last timestamp, got %v", maxima)
+	}
+
+	// Statistics missing at a timestamp are gaps
+	p99 := cpu.Stats[MetricStatP99]
+	if p99[0] != 14 || !math.IsNaN(p99[1]) || !math.IsNaN(p99[2]) {
+		t.Errorf("Expected p99 [14 NaN NaN], got %v", p99)
+	}
+
+	if len(history.Metrics[1].Timestamps) != 0 {
+		t.Errorf("Expected no network datapoints, got %d", len(history.Metrics[1].Timestamps))
+	}
+}
@@ -84,33 +84,57 @@ type NetworkInterface struct {
 
 // ListInstances retrieves all EC2 instances
 func (c *Client) ListInstances(ctx context.Context) ([]Instance, error) {
-	input := &ec2.DescribeInstancesInput{}
-	result, err := c.EC2.DescribeInstances(ctx, input)
-	if err != nil {
-		return nil, fmt.Errorf("failed to describe instances: %w", err)
-	}
+	return c.ListInstancesWithProgress(ctx, nil)
+}
+
+// ListInstancesWithProgress retrieves all EC2 instances, following every
+// page of results and reporting the running total after each page
+func (c *Client) ListInstancesWithProgress(ctx context.Context, progressCallback PageProgressCallback) ([]Instance, error) {
+	return c.ListFilteredInstances(ctx, InstanceFilter{}, progressCallback)
+}
+
+// ListFilteredInstances retrieves the EC2 instances the filter selects,
+// filtering server-side, like ListInstancesWithProgress
+func (c *Client) ListFilteredInstances(ctx context.Context, filter InstanceFilter, progressCallback PageProgressCallback) ([]Instance, error) {
+	input := &ec2.DescribeInstancesInput{Filters: filter.Filters}
+	paginator := ec2.NewDescribeInstancesPaginator(c.EC2, input)
 
 	var instances []Instance
-	for _, reservation := range result.Reservations {
-		for _, inst := range reservation.Instances {
-			instance := Instance{
-				ID:           getString(inst.InstanceId),
-				State:        string(inst.State.Name),
-				InstanceType: string(inst.InstanceType),
-				AZ:           getString(inst.Placement.AvailabilityZone),
-				PublicIP:     getString(inst.PublicIpAddress),
-				PrivateIP:    getString(inst.PrivateIpAddress),
-			}
+	for paginator.HasMorePages() {
+		result, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe instances: %w", err)
+		}
+
+		for _, reservation := range result.Reservations {
+			for _, inst := range reservation.Instances {
+				instance := Instance{
+					ID:           getString(inst.InstanceId),
+					InstanceType: string(inst.InstanceType),
+					PublicIP:     getString(inst.PublicIpAddress),
+					PrivateIP:    getString(inst.PrivateIpAddress),
+				}
+				if inst.State != nil {
+					instance.State = string(inst.State.Name)
+				}
+				if inst.Placement != nil {
+					instance.AZ = getString(inst.Placement.AvailabilityZone)
+				}
+
+				// Extract Name tag
+				instance.Name = getNameTag(inst.Tags)
 
-			// Extract Name tag
-			instance.Name = getNameTag(inst.Tags)
+				// Extract all tags
+				for _, tag := range inst.Tags {
+					instance.Tags = append(instance.Tags, Tag{Key: getString(tag.Key), Value: getString(tag.Value)})
+				}
 
-			// Extract all tags
-			for _, tag := range inst.Tags {
-				instance.Tags = append(instance.Tags, Tag{Key: getString(tag.Key), Value: getString(tag.Value)})
+				instances = append(instances, instance)
 			}
+		}
 
-			instances = append(instances, instance)
+		if progressCallback != nil {
+			progressCallback(len(instances))
 		}
 	}
 
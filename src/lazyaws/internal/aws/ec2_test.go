@@ -1,7 +1,12 @@
 package aws
 
 import (
+	"context"
+	"errors"
 	"testing"
+
+	sdkaws "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 )
 
 func TestInstanceTypeInfo(t *testing.T) {
@@ -92,3 +97,126 @@ func TestInstanceDetailsWithoutTypeInfo(t *testing.T) {
 		t.Errorf("Expected instance ID 'i-1234567890', got '%s'", details.ID)
 	}
 }
+
+func TestListInstancesWithFakeBackend(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.EC2.Instances = []types.Instance{
+		{
+			InstanceId:       sdkaws.String("i-0001"),
+			InstanceType:     types.InstanceTypeT3Micro,
+			State:            &types.InstanceState{Name: types.InstanceStateNameRunning},
+			Placement:        &types.Placement{AvailabilityZone: sdkaws.String("us-east-1a")},
+			PrivateIpAddress: sdkaws.String("10.0.0.1"),
+			Tags: []types.Tag{
+				{Key: sdkaws.String("Name"), Value: sdkaws.String("web")},
+				{Key: sdkaws.String("env"), Value: sdkaws.String("prod")},
+			},
+		},
+		{
+			InstanceId:   sdkaws.String("i-0002"),
+			InstanceType: types.InstanceTypeM5Large,
+			State:        &types.InstanceState{Name: types.InstanceStateNameStopped},
+			Placement:    &types.Placement{AvailabilityZone: sdkaws.String("us-east-1b")},
+		},
+	}
+	client := backend.Client("us-east-1")
+
+	instances, err := client.ListInstances(context.Background())
+	if err != nil {
+		t.Fatalf("ListInstances returned error: %v", err)
+	}
+
+	if len(instances) != 2 {
+		t.Fatalf("Expected 2 instances, got %d", len(instances))
+	}
+
+	if instances[0].Name != "web" {
+		t.Errorf("Expected name 'web', got '%s'", instances[0].Name)
+	}
+
+	if instances[0].State != "running" {
+		t.Errorf("Expected state 'running', got '%s'", instances[0].State)
+	}
+
+	if len(instances[0].Tags) != 2 {
+		t.Errorf("Expected 2 tags, got %d", len(instances[0].Tags))
+	}
+
+	if instances[1].AZ != "us-east-1b" {
+		t.Errorf("Expected AZ 'us-east-1b', got '%s'", instances[1].AZ)
+	}
+}
+
+func TestListInstancesError(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.EC2.Err = errors.New("access denied")
+	client := backend.Client("us-east-1")
+
+	if _, err := client.ListInstances(context.Background()); err == nil {
+		t.Error("Expected error from ListInstances, got nil")
+	}
+}
+
+func TestStopInstanceWithFakeBackend(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.EC2.Instances = []types.Instance{
+		{
+			InstanceId: sdkaws.String("i-0001"),
+			State:      &types.InstanceState{Name: types.InstanceStateNameRunning},
+			Placement:  &types.Placement{},
+		},
+	}
+	client := backend.Client("us-east-1")
+
+	if err := client.StopInstance(context.Background(), "i-0001"); err != nil {
+		t.Fatalf("StopInstance returned error: %v", err)
+	}
+
+	instances, err := client.ListInstances(context.Background())
+	if err != nil {
+		t.Fatalf("ListInstances returned error: %v", err)
+	}
+
+	if instances[0].State != "stopped" {
+		t.Errorf("Expected state 'stopped', got '%s'", instances[0].State)
+	}
+}
+
+func TestListInstancesFollowsPages(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.EC2.PageSize = 2
+	for _, id := range []string{"i-0001", "i-0002", "i-0003", "i-0004", "i-0005"} {
+		backend.EC2.Instances = append(backend.EC2.Instances, types.Instance{
+			InstanceId: sdkaws.String(id),
+			State:      &types.InstanceState{Name: types.InstanceStateNameRunning},
+		})
+	}
+	client := backend.Client("us-east-1")
+
+	var progress []int
+	instances, err := client.ListInstancesWithProgress(context.Background(), func(loaded int) {
+		progress = append(progress, loaded)
+	})
+	if err != nil {
+		t.Fatalf("ListInstancesWithProgress returned error: %v", err)
+	}
+
+	if len(instances) != 5 {
+		t.Fatalf("Expected 5 instances across pages, got %d", len(instances))
+	}
+
+	if instances[4].ID != "i-0005" {
+		t.Errorf("Expected last instance 'i-0005', got '%s'", instances[4].ID)
+	}
+
+	expected := []int{2, 4, 5}
+	if len(progress) != len(expected) {
+		t.Fatalf("Expected progress %v, got %v", expected, progress)
+	}
+	for i := range expected {
+		if progress[i] != expected[i] {
+			t.Errorf("Expected progress %v, got %v", expected, progress)
+			break
+		}
+	}
+}
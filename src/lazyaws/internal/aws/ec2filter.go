@@ -0,0 +1,197 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	sdkaws "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// instanceFilterAliases are the short names accepted for common
+// DescribeInstances filters
+var instanceFilterAliases = map[string]string{
+	"state":  "instance-state-name",
+	"type":   "instance-type",
+	"vpc":    "vpc-id",
+	"subnet": "subnet-id",
+	"az":     "availability-zone",
+	"id":     "instance-id",
+	"name":   "tag:Name",
+	"ip":     "private-ip-address",
+}
+
+// filterName matches the names of DescribeInstances filters, which are
+// passed through as they are
+var filterName = regexp.MustCompile(`^[a-z][a-z0-9.-]*$`)
+
+// InstanceFilter selects instances server-side with DescribeInstances
+// filters. Terms are kept as typed, to show and save them.
+type InstanceFilter struct {
+	Terms   []string
+	Filters []types.Filter
+}
+
+// ParseInstanceFilter parses filter terms. Values may use * and ? as
+// wildcards, and a comma-separated list matches any of its values:
+//
+//	state=running,stopped  instance state
+//	type=m5.*              instance type
+//	vpc=ID subnet=ID       VPC or subnet
+//	az=us-east-1a          availability zone
+//	id=ID name=NAME ip=IP  instance ID, Name tag or private IP
+//	tag:KEY=VALUE tag:KEY  tag with a value, or with any value
+//	NAME=VALUE             any other DescribeInstances filter, e.g. image-id=ami-123
+func ParseInstanceFilter(terms []string) (InstanceFilter, error) {
+	filter := InstanceFilter{Terms: terms}
+	values := make(map[string][]string)
+	var names []string
+	add := func(name string, vals ...string) {
+		if _, ok := values[name]; !ok {
+			names = append(names, name)
+		}
+		values[name] = append(values[name], vals...)
+	}
+
+	for _, term := range terms {
+		key, value, hasValue := strings.Cut(term, "=")
+		if strings.HasPrefix(key, "tag:") && !hasValue {
+			if key == "tag:" {
+				return filter, fmt.Errorf("expected tag:KEY=VALUE or tag:KEY, got %q", term)
+			}
+			add("tag-key", strings.TrimPrefix(key, "tag:"))
+			continue
+		}
+		if !hasValue || value == "" {
+			return filter, fmt.Errorf("expected NAME=VALUE, got %q", term)
+		}
+		name := key
+		if alias, ok := instanceFilterAliases[key]; ok {
+			name = alias
+		} else if !strings.HasPrefix(key, "tag:") && !filterName.MatchString(key) {
+			return filter, fmt.Errorf("invalid filter name %q", key)
+		}
+		vals := strings.Split(value, ",")
+		if name == "instance-state-name" {
+			for i, state := range vals {
+				vals[i] = strings.ToLower(state)
+				if !isInstanceState(vals[i]) && !strings.ContainsAny(state, "*?") {
+					return filter, fmt.Errorf("unknown instance state %q", state)
+				}
+			}
+		}
+		add(name, vals...)
+	}
+
+	for _, name := range names {
+		filter.Filters = append(filter.Filters, types.Filter{Name: sdkaws.String(name), Values: values[name]})
+	}
+	return filter, nil
+}
+
+// String formats the filter as typed
+func (f InstanceFilter) String() string {
+	return strings.Join(f.Terms, " ")
+}
+
+// Empty reports whether the filter selects every instance
+func (f InstanceFilter) Empty() bool {
+	return len(f.Filters) == 0
+}
+
+func isInstanceState(state string) bool {
+	for _, known := range types.InstanceStateName("").Values() {
+		if state == string(known) {
+			return true
+		}
+	}
+	return false
+}
+
+// InstanceViews are named instance filters, saved to a file
+type InstanceViews struct {
+	path  string
+	views map[string][]string // Filter terms by view name
+}
+
+// GetInstanceViewsPath returns the path of the saved instance views
+func GetInstanceViewsPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	configDir := filepath.Join(home, ".lazyaws")
+	if err := os.MkdirAll(configDir, 0700); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(configDir, "ec2-views.json"), nil
+}
+
+// LoadInstanceViews reads the views saved at path. A missing file has no
+// views.
+func LoadInstanceViews(path string) (*InstanceViews, error) {
+	v := &InstanceViews{path: path, views: make(map[string][]string)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return v, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &v.views); err != nil {
+		return nil, fmt.Errorf("invalid instance views file %s: %w", path, err)
+	}
+	return v, nil
+}
+
+// Names returns the names of the saved views in order
+func (v *InstanceViews) Names() []string {
+	names := make([]string, 0, len(v.views))
+	for name := range v.views {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the filter of a saved view
+func (v *InstanceViews) Get(name string) (InstanceFilter, error) {
+	terms, ok := v.views[name]
+	if !ok {
+		return InstanceFilter{}, fmt.Errorf("no view named %q", name)
+	}
+	return ParseInstanceFilter(terms)
+}
+
+// Save saves a filter as a view, replacing any view of the same name
+func (v *InstanceViews) Save(name string, filter InstanceFilter) error {
+	v.views[name] = filter.Terms
+	return v.save()
+}
+
+// Delete removes a saved view
+func (v *InstanceViews) Delete(name string) error {
+	if _, ok := v.views[name]; !ok {
+		return fmt.Errorf("no view named %q", name)
+	}
+	delete(v.views, name)
+	return v.save()
+}
+
+func (v *InstanceViews) save() error {
+	data, err := json.MarshalIndent(v.views, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(v.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to save instance views: %w", err)
+	}
+	return nil
+}
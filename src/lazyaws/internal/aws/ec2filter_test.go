@@ -0,0 +1,121 @@
+package aws
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	sdkaws "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+func TestParseInstanceFilter(t *testing.T) {
+	terms := []string{"state=Running,stopped", "tag:env=prod", "vpc=vpc-123", "type=m5.*", "tag:team", "az=us-east-1a", "image-id=ami-1", "state=pending"}
+	filter, err := ParseInstanceFilter(terms)
+	if err != nil {
+		t.Fatalf("ParseInstanceFilter returned error: %v", err)
+	}
+	expected := map[string][]string{
+		"instance-state-name": {"running", "stopped", "pending"},
+		"tag:env":             {"prod"},
+		"vpc-id":              {"vpc-123"},
+		"instance-type":       {"m5.*"},
+		"tag-key":             {"team"},
+		"availability-zone":   {"us-east-1a"},
+		"image-id":            {"ami-1"},
+	}
+	got := make(map[string][]string)
+	for _, f := range filter.Filters {
+		got[*f.Name] = f.Values
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected filters %v, got %v", expected, got)
+	}
+	if filter.String() != "state=Running,stopped tag:env=prod vpc=vpc-123 type=m5.* tag:team az=us-east-1a image-id=ami-1 state=pending" {
+		t.Errorf("Expected the terms as typed, got %q", filter.String())
+	}
+
+	for _, term := range []string{"state=sleeping", "running", "tag:", "Bad Name=x", "vpc="} {
+		if _, err := ParseInstanceFilter([]string{term}); err == nil {
+			t.Errorf("Expected %q to fail", term)
+		}
+	}
+	if filter, _ := ParseInstanceFilter(nil); !filter.Empty() {
+		t.Error("Expected no terms to select every instance")
+	}
+}
+
+func TestListFilteredInstances(t *testing.T) {
+	backend := NewFakeBackend()
+	instance := func(id, state, instanceType, env string) types.Instance {
+		return types.Instance{
+			InstanceId:   sdkaws.String(id),
+			State:        &types.InstanceState{Name: types.InstanceStateName(state)},
+			InstanceType: types.InstanceType(instanceType),
+			VpcId:        sdkaws.String("vpc-123"),
+			Tags:         []types.Tag{{Key: sdkaws.String("env"), Value: sdkaws.String(env)}},
+		}
+	}
+	backend.EC2.Instances = []types.Instance{
+		instance("i-1", "running", "m5.large", "prod"),
+		instance("i-2", "stopped", "m5.xlarge", "prod"),
+		instance("i-3", "running", "t3.micro", "prod"),
+		instance("i-4", "running", "m5.large", "dev"),
+	}
+	client := backend.Client("us-east-1")
+
+	filter, _ := ParseInstanceFilter([]string{"state=running", "tag:env=prod", "vpc=vpc-123", "type=m5.*"})
+	instances, err := client.ListFilteredInstances(context.Background(), filter, nil)
+	if err != nil {
+		t.Fatalf("ListFilteredInstances returned error: %v", err)
+	}
+	if len(instances) != 1 || instances[0].ID != "i-1" {
+		t.Errorf("Expected only i-1, got %+v", instances)
+	}
+
+	filter, _ = ParseInstanceFilter([]string{"state=running,stopped", "tag:env"})
+	if instances, _ := client.ListFilteredInstances(context.Background(), filter, nil); len(instances) != 4 {
+		t.Errorf("Expected every instance, got %d", len(instances))
+	}
+}
+
+func TestInstanceViews(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ec2-views.json")
+	views, err := LoadInstanceViews(path)
+	if err != nil || len(views.Names()) != 0 {
+		t.Fatalf("Expected no views from a missing file, got %v (%v)", views, err)
+	}
+
+	prod, _ := ParseInstanceFilter([]string{"state=running", "tag:env=prod"})
+	dev, _ := ParseInstanceFilter([]string{"tag:env=dev"})
+	if err := views.Save("prod", prod); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	if err := views.Save("dev", dev); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	// Views survive a reload
+	views, err = LoadInstanceViews(path)
+	if err != nil {
+		t.Fatalf("LoadInstanceViews returned error: %v", err)
+	}
+	if names := views.Names(); !reflect.DeepEqual(names, []string{"dev", "prod"}) {
+		t.Errorf("Expected views dev and prod, got %v", names)
+	}
+	filter, err := views.Get("prod")
+	if err != nil || !reflect.DeepEqual(filter, prod) {
+		t.Errorf("Expected the prod filter back, got %+v (%v)", filter, err)
+	}
+
+	if err := views.Delete("dev"); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	if _, err := views.Get("dev"); err == nil {
+		t.Error("Expected the deleted view to be gone")
+	}
+	if err := views.Delete("dev"); err == nil {
+		t.Error("Expected deleting a missing view to fail")
+	}
+}
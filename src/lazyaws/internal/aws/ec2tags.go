@@ -0,0 +1,287 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	sdkaws "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// maxEC2Tags is the most tags an EC2 resource can have
+const maxEC2Tags = 50
+
+// InstanceTags are the tags of an instance, with the volumes and network
+// interfaces attached to it
+type InstanceTags struct {
+	InstanceID          string
+	Name                string
+	Tags                map[string]string
+	VolumeIDs           []string
+	NetworkInterfaceIDs []string
+}
+
+// InstanceTagsForm is the tag editor form for one or more instances. Tags
+// the instances disagree on, or that only some of them have, show
+// FormVaries.
+type InstanceTagsForm struct {
+	Tags map[string]string
+	// Also write the instance's tags to its volumes and network interfaces
+	PropagateToVolumes           bool
+	PropagateToNetworkInterfaces bool
+}
+
+// InstanceTagChange is what an edit does to one instance
+type InstanceTagChange struct {
+	Instance InstanceTags
+	After    map[string]string
+	// Resources the instance's tags are copied to
+	Propagate []string
+}
+
+// GetInstanceTags reads the tags and attachments of instances, in the
+// order given
+func (c *Client) GetInstanceTags(ctx context.Context, instanceIDs []string) ([]InstanceTags, error) {
+	found := make(map[string]InstanceTags)
+	paginator := ec2.NewDescribeInstancesPaginator(c.EC2, &ec2.DescribeInstancesInput{InstanceIds: instanceIDs})
+	for paginator.HasMorePages() {
+		result, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe instances: %w", err)
+		}
+		for _, reservation := range result.Reservations {
+			for _, inst := range reservation.Instances {
+				instance := InstanceTags{
+					InstanceID: getString(inst.InstanceId),
+					Name:       getNameTag(inst.Tags),
+					Tags:       make(map[string]string),
+				}
+				for _, tag := range inst.Tags {
+					instance.Tags[getString(tag.Key)] = getString(tag.Value)
+				}
+				for _, bd := range inst.BlockDeviceMappings {
+					if bd.Ebs != nil && bd.Ebs.VolumeId != nil {
+						instance.VolumeIDs = append(instance.VolumeIDs, *bd.Ebs.VolumeId)
+					}
+				}
+				for _, ni := range inst.NetworkInterfaces {
+					if ni.NetworkInterfaceId != nil {
+						instance.NetworkInterfaceIDs = append(instance.NetworkInterfaceIDs, *ni.NetworkInterfaceId)
+					}
+				}
+				found[instance.InstanceID] = instance
+			}
+		}
+	}
+
+	instances := make([]InstanceTags, 0, len(instanceIDs))
+	for _, id := range instanceIDs {
+		instance, ok := found[id]
+		if !ok {
+			return nil, fmt.Errorf("instance %s not found", id)
+		}
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
+// NewInstanceTagsForm fills the tag editor form with the tags of instances
+func NewInstanceTagsForm(instances []InstanceTags) InstanceTagsForm {
+	form := InstanceTagsForm{Tags: make(map[string]string)}
+	for i, instance := range instances {
+		mergeCommonValues(form.Tags, instance.Tags, i)
+	}
+	return form
+}
+
+// ApplyTemplate sets every tag of a tag set in the form
+func (f *InstanceTagsForm) ApplyTemplate(tags map[string]string) {
+	for k, v := range tags {
+		f.Tags[k] = v
+	}
+}
+
+// Marshal formats the form as indented JSON for editing
+func (f InstanceTagsForm) Marshal() string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(f)
+	return buf.String()
+}
+
+// ParseInstanceTagsForm parses and checks an edited form
+func ParseInstanceTagsForm(doc string) (InstanceTagsForm, error) {
+	var form InstanceTagsForm
+	decoder := json.NewDecoder(strings.NewReader(doc))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&form); err != nil {
+		return form, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if form.Tags == nil {
+		form.Tags = make(map[string]string)
+	}
+	for k, v := range form.Tags {
+		if err := ValidateEC2Tag(k, v); err != nil {
+			return form, err
+		}
+	}
+	return form, nil
+}
+
+// ValidateEC2Tag checks a tag key and value against the EC2 limits
+func ValidateEC2Tag(key, value string) error {
+	if key == "" || len(key) > 128 || len(value) > 256 {
+		return fmt.Errorf("invalid tag %q=%q (keys are 1-128 characters, values up to 256)", key, value)
+	}
+	return nil
+}
+
+// PlanInstanceTagChanges works out what the edit from original to edited
+// does to each instance. Tags left as they were in the form are left alone
+// on every instance. Instances the edit doesn't change are left out, unless
+// their tags are propagated.
+func PlanInstanceTagChanges(instances []InstanceTags, original, edited InstanceTagsForm) ([]InstanceTagChange, error) {
+	set, remove, err := mapChanges("Tags", original.Tags, edited.Tags)
+	if err != nil {
+		return nil, err
+	}
+	for k := range set {
+		if isReservedTag(k) {
+			return nil, fmt.Errorf("tag %q: keys starting with aws: are reserved", k)
+		}
+	}
+	for _, k := range remove {
+		if isReservedTag(k) {
+			return nil, fmt.Errorf("tag %q: keys starting with aws: are reserved", k)
+		}
+	}
+
+	var changes []InstanceTagChange
+	for _, instance := range instances {
+		change := InstanceTagChange{
+			Instance: instance,
+			After:    applyMapChanges(instance.Tags, set, remove),
+		}
+		if len(change.After) > maxEC2Tags {
+			return nil, fmt.Errorf("%s would have %d tags, instances can have at most %d", instance.InstanceID, len(change.After), maxEC2Tags)
+		}
+		if edited.PropagateToVolumes {
+			change.Propagate = append(change.Propagate, instance.VolumeIDs...)
+		}
+		if edited.PropagateToNetworkInterfaces {
+			change.Propagate = append(change.Propagate, instance.NetworkInterfaceIDs...)
+		}
+		if len(change.Describe()) > 0 {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+// Describe lists the changes to the instance, one per line, marked like
+// ObjectPropertyChange.Describe
+func (c InstanceTagChange) Describe() []string {
+	lines := describeMapChanges("tag", c.Instance.Tags, c.After)
+	if len(c.Propagate) > 0 {
+		lines = append(lines, fmt.Sprintf("~ copy tags to %s", strings.Join(c.Propagate, ", ")))
+	}
+	return lines
+}
+
+// ApplyInstanceTagChanges makes the planned changes, carrying on past
+// instances that fail. Propagated resources get every tag of the instance
+// except reserved ones, and lose the tags removed from it.
+func (c *Client) ApplyInstanceTagChanges(ctx context.Context, changes []InstanceTagChange, progress BatchProgressCallback) error {
+	var errs []error
+	for i, change := range changes {
+		if err := c.applyInstanceTagChange(ctx, change); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", change.Instance.InstanceID, err))
+		}
+		if progress != nil {
+			progress(i+1, len(changes))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed for %d of %d instances: %w", len(errs), len(changes), errors.Join(errs...))
+	}
+	return nil
+}
+
+func (c *Client) applyInstanceTagChange(ctx context.Context, change InstanceTagChange) error {
+	set := make(map[string]string)
+	for k, v := range change.After {
+		if before, ok := change.Instance.Tags[k]; !ok || before != v {
+			set[k] = v
+		}
+	}
+	var remove []string
+	for k := range change.Instance.Tags {
+		if _, ok := change.After[k]; !ok {
+			remove = append(remove, k)
+		}
+	}
+
+	instance := []string{change.Instance.InstanceID}
+	if err := c.createTags(ctx, instance, set); err != nil {
+		return err
+	}
+	if err := c.deleteTags(ctx, instance, remove); err != nil {
+		return err
+	}
+	if len(change.Propagate) == 0 {
+		return nil
+	}
+	propagated := make(map[string]string)
+	for k, v := range change.After {
+		if !isReservedTag(k) {
+			propagated[k] = v
+		}
+	}
+	if err := c.createTags(ctx, change.Propagate, propagated); err != nil {
+		return err
+	}
+	return c.deleteTags(ctx, change.Propagate, remove)
+}
+
+func (c *Client) createTags(ctx context.Context, resources []string, tags map[string]string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	input := &ec2.CreateTagsInput{Resources: resources}
+	for _, k := range sortedKeys(tags) {
+		input.Tags = append(input.Tags, types.Tag{Key: sdkaws.String(k), Value: sdkaws.String(tags[k])})
+	}
+	if _, err := c.EC2.CreateTags(ctx, input); err != nil {
+		return fmt.Errorf("failed to create tags: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) deleteTags(ctx context.Context, resources []string, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	sort.Strings(keys)
+	input := &ec2.DeleteTagsInput{Resources: resources}
+	for _, k := range keys {
+		input.Tags = append(input.Tags, types.Tag{Key: sdkaws.String(k)})
+	}
+	if _, err := c.EC2.DeleteTags(ctx, input); err != nil {
+		return fmt.Errorf("failed to delete tags: %w", err)
+	}
+	return nil
+}
+
+// isReservedTag reports whether a tag key belongs to AWS, which lets it be
+// read but not set or removed
+func isReservedTag(key string) bool {
+	return strings.HasPrefix(strings.ToLower(key), "aws:")
+}
@@ -0,0 +1,172 @@
+package aws
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	sdkaws "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+func TestInstanceTagsForm(t *testing.T) {
+	instances := []InstanceTags{
+		{InstanceID: "i-1", Tags: map[string]string{"Name": "web-1", "env": "prod", "team": "web"}},
+		{InstanceID: "i-2", Tags: map[string]string{"Name": "web-2", "env": "prod"}},
+	}
+	form := NewInstanceTagsForm(instances)
+	expected := map[string]string{"Name": FormVaries, "env": "prod", "team": FormVaries}
+	if !reflect.DeepEqual(form.Tags, expected) {
+		t.Fatalf("Expected tags %v, got %v", expected, form.Tags)
+	}
+
+	form.ApplyTemplate(map[string]string{"env": "staging", "owner": "platform"})
+	if form.Tags["env"] != "staging" || form.Tags["owner"] != "platform" || form.Tags["Name"] != FormVaries {
+		t.Errorf("Expected the template to set its tags only, got %v", form.Tags)
+	}
+
+	parsed, err := ParseInstanceTagsForm(form.Marshal())
+	if err != nil || !reflect.DeepEqual(parsed, form) {
+		t.Fatalf("Expected the form to round-trip, got %+v (%v)", parsed, err)
+	}
+	for _, doc := range []string{
+		`{"Tags": {"": "x"}}`,
+		`{"Tags": {"a": "x"}, "Owner": "me"}`,
+		`not json`,
+	} {
+		if _, err := ParseInstanceTagsForm(doc); err == nil {
+			t.Errorf("Expected %s to fail", doc)
+		}
+	}
+}
+
+func TestPlanInstanceTagChanges(t *testing.T) {
+	instances := []InstanceTags{
+		{InstanceID: "i-1", Tags: map[string]string{"Name": "web-1", "env": "prod", "aws:autoscaling:groupName": "web"},
+			VolumeIDs: []string{"vol-1"}, NetworkInterfaceIDs: []string{"eni-1"}},
+		{InstanceID: "i-2", Tags: map[string]string{"Name": "web-2", "env": "dev"}},
+	}
+	original := NewInstanceTagsForm(instances)
+
+	edited := NewInstanceTagsForm(instances)
+	edited.Tags["owner"] = "platform"
+	delete(edited.Tags, "env")
+	changes, err := PlanInstanceTagChanges(instances, original, edited)
+	if err != nil || len(changes) != 2 {
+		t.Fatalf("Expected both instances to change, got %d (%v)", len(changes), err)
+	}
+	expected := []string{"- tag env=prod", "+ tag owner=platform"}
+	if lines := changes[0].Describe(); !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Expected changes %q, got %q", expected, lines)
+	}
+	if changes[1].After["Name"] != "web-2" {
+		t.Errorf("Expected tags left as they were to be kept, got %v", changes[1].After)
+	}
+
+	// Propagating alone changes the instances with attachments
+	edited = NewInstanceTagsForm(instances)
+	edited.PropagateToVolumes = true
+	edited.PropagateToNetworkInterfaces = true
+	changes, err = PlanInstanceTagChanges(instances, original, edited)
+	if err != nil || len(changes) != 1 || !reflect.DeepEqual(changes[0].Propagate, []string{"vol-1", "eni-1"}) {
+		t.Errorf("Expected i-1 to copy its tags to vol-1 and eni-1, got %+v (%v)", changes, err)
+	}
+
+	for _, edit := range []func(*InstanceTagsForm){
+		func(f *InstanceTagsForm) { f.Tags["new"] = FormVaries },
+		func(f *InstanceTagsForm) { delete(f.Tags, "aws:autoscaling:groupName") },
+		func(f *InstanceTagsForm) { f.Tags["aws:owner"] = "me" },
+	} {
+		edited := NewInstanceTagsForm(instances)
+		edit(&edited)
+		if _, err := PlanInstanceTagChanges(instances, original, edited); err == nil {
+			t.Errorf("Expected the edit to %v to fail", edited.Tags)
+		}
+	}
+}
+
+func TestApplyInstanceTagChanges(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.EC2.Instances = []types.Instance{
+		{
+			InstanceId: sdkaws.String("i-1"),
+			Tags: []types.Tag{
+				{Key: sdkaws.String("Name"), Value: sdkaws.String("web-1")},
+				{Key: sdkaws.String("env"), Value: sdkaws.String("prod")},
+				{Key: sdkaws.String("aws:cloudformation:stack-name"), Value: sdkaws.String("web")},
+			},
+			BlockDeviceMappings: []types.InstanceBlockDeviceMapping{
+				{DeviceName: sdkaws.String("/dev/xvda"), Ebs: &types.EbsInstanceBlockDevice{VolumeId: sdkaws.String("vol-1")}},
+			},
+			NetworkInterfaces: []types.InstanceNetworkInterface{{NetworkInterfaceId: sdkaws.String("eni-1")}},
+		},
+		{
+			InstanceId: sdkaws.String("i-2"),
+			Tags:       []types.Tag{{Key: sdkaws.String("env"), Value: sdkaws.String("prod")}},
+		},
+	}
+	backend.EC2.Volumes = []types.Volume{
+		{VolumeId: sdkaws.String("vol-1"), Tags: []types.Tag{{Key: sdkaws.String("env"), Value: sdkaws.String("prod")}}},
+	}
+	client := backend.Client("us-east-1")
+	ctx := context.Background()
+
+	instances, err := client.GetInstanceTags(ctx, []string{"i-2", "i-1"})
+	if err != nil || len(instances) != 2 || instances[0].InstanceID != "i-2" {
+		t.Fatalf("Expected both instances in order, got %+v (%v)", instances, err)
+	}
+	if instances[1].Name != "web-1" || !reflect.DeepEqual(instances[1].VolumeIDs, []string{"vol-1"}) || !reflect.DeepEqual(instances[1].NetworkInterfaceIDs, []string{"eni-1"}) {
+		t.Errorf("Unexpected instance %+v", instances[1])
+	}
+
+	original := NewInstanceTagsForm(instances)
+	edited := NewInstanceTagsForm(instances)
+	edited.Tags["env"] = "staging"
+	edited.Tags["owner"] = "platform"
+	edited.PropagateToVolumes = true
+	edited.PropagateToNetworkInterfaces = true
+	changes, err := PlanInstanceTagChanges(instances, original, edited)
+	if err != nil {
+		t.Fatalf("PlanInstanceTagChanges returned error: %v", err)
+	}
+	if err := client.ApplyInstanceTagChanges(ctx, changes, nil); err != nil {
+		t.Fatalf("ApplyInstanceTagChanges returned error: %v", err)
+	}
+
+	instances, _ = client.GetInstanceTags(ctx, []string{"i-1", "i-2"})
+	for _, instance := range instances {
+		if instance.Tags["env"] != "staging" || instance.Tags["owner"] != "platform" {
+			t.Errorf("%s: expected the new tags, got %v", instance.InstanceID, instance.Tags)
+		}
+	}
+	expected := map[string]string{"Name": "web-1", "env": "staging", "owner": "platform"}
+	volumeTags := make(map[string]string)
+	for _, tag := range backend.EC2.Volumes[0].Tags {
+		volumeTags[*tag.Key] = *tag.Value
+	}
+	if !reflect.DeepEqual(volumeTags, expected) {
+		t.Errorf("Expected the volume to get the instance's tags but reserved ones, got %v", volumeTags)
+	}
+	if !reflect.DeepEqual(backend.EC2.InterfaceTags["eni-1"], expected) {
+		t.Errorf("Expected the interface to get the instance's tags, got %v", backend.EC2.InterfaceTags["eni-1"])
+	}
+
+	// Removing a tag removes it from propagated resources too
+	original = NewInstanceTagsForm(instances[:1])
+	edited = NewInstanceTagsForm(instances[:1])
+	delete(edited.Tags, "owner")
+	edited.PropagateToVolumes = true
+	changes, _ = PlanInstanceTagChanges(instances[:1], original, edited)
+	if err := client.ApplyInstanceTagChanges(ctx, changes, nil); err != nil {
+		t.Fatalf("ApplyInstanceTagChanges returned error: %v", err)
+	}
+	for _, tag := range backend.EC2.Volumes[0].Tags {
+		if *tag.Key == "owner" {
+			t.Errorf("Expected owner to be removed from the volume, got %v", backend.EC2.Volumes[0].Tags)
+		}
+	}
+
+	if _, err := client.GetInstanceTags(ctx, []string{"i-missing"}); err == nil {
+		t.Error("Expected a missing instance to fail")
+	}
+}
@@ -3,10 +3,9 @@ package aws
 import (
 	"context"
 	"fmt"
-	"os"
-	"os/exec"
-	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/eks"
 	"github.com/aws/aws-sdk-go-v2/service/eks/types"
@@ -22,6 +21,10 @@ type EKSCluster struct {
 	CreatedAt string
 	NodeCount int
 	Arn       string
+
+	// DetailsLoaded is false for placeholder entries that only carry the
+	// cluster name while DescribeCluster is still in flight
+	DetailsLoaded bool
 }
 
 // EKSClusterDetails contains detailed information about an EKS cluster
@@ -68,54 +71,161 @@ type EKSAddon struct {
 	ServiceAccountRole string
 }
 
+// Defaults for describing clusters when listing them
+const (
+	DefaultEKSDescribeConcurrency = 8
+	DefaultEKSDescribeTimeout     = 15 * time.Second
+)
+
+// EKSDescribeOptions controls how cluster details are fetched while listing
+type EKSDescribeOptions struct {
+	Concurrency int           // Maximum number of clusters described at once
+	Timeout     time.Duration // Time limit for describing a single cluster
+}
+
+// DefaultEKSDescribeOptions returns the default describe options
+func DefaultEKSDescribeOptions() EKSDescribeOptions {
+	return EKSDescribeOptions{
+		Concurrency: DefaultEKSDescribeConcurrency,
+		Timeout:     DefaultEKSDescribeTimeout,
+	}
+}
+
 // ListEKSClusters retrieves all EKS clusters in the current region
 func (c *Client) ListEKSClusters(ctx context.Context) ([]EKSCluster, error) {
-	input := &eks.ListClustersInput{}
-	result, err := c.EKS.ListClusters(ctx, input)
+	names, err := c.ListEKSClusterNames(ctx, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list EKS clusters: %w", err)
+		return nil, err
 	}
+	return c.DescribeEKSClusters(ctx, names, DefaultEKSDescribeOptions(), nil), nil
+}
 
-	var clusters []EKSCluster
-	for _, clusterName := range result.Clusters {
-		// Get detailed information for each cluster
-		details, err := c.GetEKSClusterDetails(ctx, clusterName)
+// ListEKSClusterNames retrieves the names of all EKS clusters in the current
+// region, following every page of results and reporting the running total
+// after each page
+func (c *Client) ListEKSClusterNames(ctx context.Context, progressCallback PageProgressCallback) ([]string, error) {
+	input := &eks.ListClustersInput{}
+	paginator := eks.NewListClustersPaginator(c.EKS, input)
+
+	var names []string
+	for paginator.HasMorePages() {
+		result, err := paginator.NextPage(ctx)
 		if err != nil {
-			// If we can't get details, still add the cluster with basic info
-			clusters = append(clusters, EKSCluster{
-				Name:   clusterName,
-				Status: "unknown",
-				Region: c.Region,
-			})
-			continue
+			return nil, fmt.Errorf("failed to list EKS clusters: %w", err)
 		}
 
-		// Count node groups for this cluster
-		nodeCount, _ := c.countNodeGroupNodes(ctx, clusterName)
+		names = append(names, result.Clusters...)
 
-		// Extract region from ARN if available
-		// ARN format: arn:aws:eks:REGION:ACCOUNT:cluster/NAME
-		clusterRegion := details.Region
-		if details.Arn != "" {
-			arnParts := strings.Split(details.Arn, ":")
-			if len(arnParts) >= 4 {
-				clusterRegion = arnParts[3]
-			}
+		if progressCallback != nil {
+			progressCallback(len(names))
 		}
+	}
+
+	return names, nil
+}
 
+// BasicEKSClusters returns placeholder entries for clusters whose details
+// haven't been fetched yet
+func (c *Client) BasicEKSClusters(names []string) []EKSCluster {
+	clusters := make([]EKSCluster, 0, len(names))
+	for _, name := range names {
 		clusters = append(clusters, EKSCluster{
-			Name:      details.Name,
-			Version:   details.Version,
-			Status:    details.Status,
-			Endpoint:  details.Endpoint,
-			Region:    clusterRegion,
-			CreatedAt: details.CreatedAt,
-			NodeCount: nodeCount,
-			Arn:       details.Arn,
+			Name:   name,
+			Region: c.Region,
 		})
 	}
+	return clusters
+}
+
+// DescribeEKSClusters fetches details for the named clusters using a bounded
+// pool of workers. The result keeps the order of names, and onResult (if
+// set) is called from the worker goroutines as each cluster completes.
+func (c *Client) DescribeEKSClusters(ctx context.Context, names []string, opts EKSDescribeOptions, onResult func(index int, cluster EKSCluster)) []EKSCluster {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultEKSDescribeConcurrency
+	}
+	if concurrency > len(names) {
+		concurrency = len(names)
+	}
+
+	clusters := make([]EKSCluster, len(names))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				clusters[i] = c.describeEKSClusterWithTimeout(ctx, names[i], opts.Timeout)
+
+				if onResult != nil {
+					onResult(i, clusters[i])
+				}
+			}
+		}()
+	}
 
-	return clusters, nil
+	for i := range names {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return clusters
+}
+
+// describeEKSClusterWithTimeout describes a single cluster, giving up on any
+// calls still outstanding once the timeout expires
+func (c *Client) describeEKSClusterWithTimeout(ctx context.Context, clusterName string, timeout time.Duration) EKSCluster {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return c.describeEKSCluster(ctx, clusterName)
+}
+
+// describeEKSCluster builds the list entry for a single cluster, falling back
+// to basic info if the cluster can't be described
+func (c *Client) describeEKSCluster(ctx context.Context, clusterName string) EKSCluster {
+	// Get detailed information for the cluster
+	details, err := c.GetEKSClusterDetails(ctx, clusterName)
+	if err != nil {
+		// If we can't get details, still add the cluster with basic info
+		return EKSCluster{
+			Name:          clusterName,
+			Status:        "unknown",
+			Region:        c.Region,
+			DetailsLoaded: true,
+		}
+	}
+
+	// Count node groups for this cluster
+	nodeCount, _ := c.countNodeGroupNodes(ctx, clusterName)
+
+	// Extract region from ARN if available
+	// ARN format: arn:aws:eks:REGION:ACCOUNT:cluster/NAME
+	clusterRegion := details.Region
+	if details.Arn != "" {
+		arnParts := strings.Split(details.Arn, ":")
+		if len(arnParts) >= 4 {
+			clusterRegion = arnParts[3]
+		}
+	}
+
+	return EKSCluster{
+		Name:      details.Name,
+		Version:   details.Version,
+		Status:    details.Status,
+		Endpoint:  details.Endpoint,
+		Region:    clusterRegion,
+		CreatedAt: details.CreatedAt,
+		NodeCount: nodeCount,
+		Arn:       details.Arn,
+
+		DetailsLoaded: true,
+	}
 }
 
 // GetEKSClusterDetails retrieves detailed information about an EKS cluster
@@ -189,23 +299,26 @@ func (c *Client) ListNodeGroups(ctx context.Context, clusterName string) ([]EKSN
 		ClusterName: &clusterName,
 	}
 
-	result, err := c.EKS.ListNodegroups(ctx, input)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list node groups: %w", err)
-	}
-
 	var nodeGroups []EKSNodeGroup
-	for _, ngName := range result.Nodegroups {
-		ng, err := c.GetNodeGroupDetails(ctx, clusterName, ngName)
+	paginator := eks.NewListNodegroupsPaginator(c.EKS, input)
+	for paginator.HasMorePages() {
+		result, err := paginator.NextPage(ctx)
 		if err != nil {
-			// If we can't get details, still add the node group with basic info
-			nodeGroups = append(nodeGroups, EKSNodeGroup{
-				Name:   ngName,
-				Status: "unknown",
-			})
-			continue
+			return nil, fmt.Errorf("failed to list node groups: %w", err)
+		}
+
+		for _, ngName := range result.Nodegroups {
+			ng, err := c.GetNodeGroupDetails(ctx, clusterName, ngName)
+			if err != nil {
+				// If we can't get details, still add the node group with basic info
+				nodeGroups = append(nodeGroups, EKSNodeGroup{
+					Name:   ngName,
+					Status: "unknown",
+				})
+				continue
+			}
+			nodeGroups = append(nodeGroups, *ng)
 		}
-		nodeGroups = append(nodeGroups, *ng)
 	}
 
 	return nodeGroups, nil
@@ -270,23 +383,26 @@ func (c *Client) ListAddons(ctx context.Context, clusterName string) ([]EKSAddon
 		ClusterName: &clusterName,
 	}
 
-	result, err := c.EKS.ListAddons(ctx, input)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list add-ons: %w", err)
-	}
-
 	var addons []EKSAddon
-	for _, addonName := range result.Addons {
-		addon, err := c.GetAddonDetails(ctx, clusterName, addonName)
+	paginator := eks.NewListAddonsPaginator(c.EKS, input)
+	for paginator.HasMorePages() {
+		result, err := paginator.NextPage(ctx)
 		if err != nil {
-			// If we can't get details, still add the add-on with basic info
-			addons = append(addons, EKSAddon{
-				Name:   addonName,
-				Status: "unknown",
-			})
-			continue
+			return nil, fmt.Errorf("failed to list add-ons: %w", err)
+		}
+
+		for _, addonName := range result.Addons {
+			addon, err := c.GetAddonDetails(ctx, clusterName, addonName)
+			if err != nil {
+				// If we can't get details, still add the add-on with basic info
+				addons = append(addons, EKSAddon{
+					Name:   addonName,
+					Status: "unknown",
+				})
+				continue
+			}
+			addons = append(addons, *addon)
 		}
-		addons = append(addons, *addon)
 	}
 
 	return addons, nil
@@ -338,82 +454,63 @@ func (c *Client) GetAddonDetails(ctx context.Context, clusterName, addonName str
 	return details, nil
 }
 
-// UpdateKubeconfig updates the local kubeconfig file with the cluster configuration
-func (c *Client) UpdateKubeconfig(ctx context.Context, clusterName string, clusterRegion string, ssoCredentials *SSOCredentials) error {
-	// Get the kubeconfig path
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return fmt.Errorf("failed to get home directory: %w", err)
-	}
-	kubeconfigPath := filepath.Join(homeDir, ".kube", "config")
-
-	// Ensure .kube directory exists
-	kubeDir := filepath.Join(homeDir, ".kube")
-	if err := os.MkdirAll(kubeDir, 0755); err != nil {
-		return fmt.Errorf("failed to create .kube directory: %w", err)
-	}
-
-	// Use the cluster's region if provided, otherwise fall back to client region
-	region := clusterRegion
-	if region == "" {
-		region = c.Region
-	}
-
-	// Use aws eks update-kubeconfig command
-	cmd := exec.CommandContext(ctx, "aws", "eks", "update-kubeconfig",
-		"--name", clusterName,
-		"--region", region,
-		"--kubeconfig", kubeconfigPath)
-	
-	// Start with inherited environment variables
-	cmd.Env = os.Environ()
-	
-	// If SSO credentials are provided, use them explicitly
-	if ssoCredentials != nil {
-		// Remove any existing AWS credential env vars to avoid conflicts
-		var cleanEnv []string
-		for _, env := range cmd.Env {
-			if !strings.HasPrefix(env, "AWS_ACCESS_KEY_ID=") &&
-				!strings.HasPrefix(env, "AWS_SECRET_ACCESS_KEY=") &&
-				!strings.HasPrefix(env, "AWS_SESSION_TOKEN=") &&
-				!strings.HasPrefix(env, "AWS_PROFILE=") {
-				cleanEnv = append(cleanEnv, env)
-			}
-		}
-		cmd.Env = cleanEnv
-		
-		// Add SSO credentials
-		cmd.Env = append(cmd.Env,
-			fmt.Sprintf("AWS_ACCESS_KEY_ID=%s", ssoCredentials.AccessKeyID),
-			fmt.Sprintf("AWS_SECRET_ACCESS_KEY=%s", ssoCredentials.SecretAccessKey),
-			fmt.Sprintf("AWS_SESSION_TOKEN=%s", ssoCredentials.SessionToken),
-		)
-	} else {
-		// No SSO credentials - check if AWS_PROFILE is set, if not use account ID as profile name
-		hasProfile := false
-		for _, env := range cmd.Env {
-			if strings.HasPrefix(env, "AWS_PROFILE=") {
-				hasProfile = true
-				break
+// eksLogStreamPrefixes maps each control plane log type to the name
+// prefixes of its streams. The audit prefix is listed before the api one,
+// which it would otherwise match.
+var eksLogStreamPrefixes = []struct {
+	logType  types.LogType
+	prefixes []string
+}{
+	{types.LogTypeAudit, []string{"kube-apiserver-audit-"}},
+	{types.LogTypeApi, []string{"kube-apiserver-"}},
+	{types.LogTypeAuthenticator, []string{"authenticator-"}},
+	{types.LogTypeControllerManager, []string{"kube-controller-manager-", "cloud-controller-manager-"}},
+	{types.LogTypeScheduler, []string{"kube-scheduler-"}},
+}
+
+// EKSControlPlaneLogGroup returns the log group EKS writes a cluster's
+// control plane logs to
+func EKSControlPlaneLogGroup(clusterName string) string {
+	return fmt.Sprintf("/aws/eks/%s/cluster", clusterName)
+}
+
+// EKSLogType returns the control plane log type a stream belongs to, or ""
+// if the stream name isn't recognized
+func EKSLogType(streamName string) types.LogType {
+	for _, entry := range eksLogStreamPrefixes {
+		for _, prefix := range entry.prefixes {
+			if strings.HasPrefix(streamName, prefix) {
+				return entry.logType
 			}
 		}
-		
-		// If no profile is set, return error - profile must be provided during login
-		if !hasProfile {
-			return fmt.Errorf("Não foi possível efetuar o login, profile incorreto ou não encontrado")
-		}
 	}
+	return ""
+}
 
-	output, err := cmd.CombinedOutput()
+// GetClusterLogStreams returns the control plane log streams of a cluster
+// that belong to the given log types
+func (c *Client) GetClusterLogStreams(ctx context.Context, clusterName string, logTypes []string) ([]string, error) {
+	streams, err := c.ListLogStreams(ctx, EKSControlPlaneLogGroup(clusterName))
 	if err != nil {
-		return fmt.Errorf("failed to update kubeconfig: %w\nOutput: %s", err, string(output))
+		return nil, err
 	}
 
-	return nil
+	var names []string
+	for _, stream := range streams {
+		logType := EKSLogType(stream.Name)
+		for _, lt := range logTypes {
+			if string(logType) == lt {
+				names = append(names, stream.Name)
+				break
+			}
+		}
+	}
+	return names, nil
 }
 
-// GetClusterLogs retrieves cluster logs (requires CloudWatch Logs integration)
-func (c *Client) GetClusterLogs(ctx context.Context, clusterName string, logType types.LogType) ([]string, error) {
+// GetClusterLogs retrieves the control plane log events of one log type
+// from the last hour
+func (c *Client) GetClusterLogs(ctx context.Context, clusterName string, logType types.LogType) ([]LogEvent, error) {
 	// First, check if the log type is enabled
 	details, err := c.GetEKSClusterDetails(ctx, clusterName)
 	if err != nil {
@@ -432,13 +529,14 @@ func (c *Client) GetClusterLogs(ctx context.Context, clusterName string, logType
 		return nil, fmt.Errorf("log type %s is not enabled for cluster %s", logType, clusterName)
 	}
 
-	// Note: Actual log retrieval would require CloudWatch Logs integration
-	// This is a placeholder that returns the log group name
-	logGroupName := fmt.Sprintf("/aws/eks/%s/cluster", clusterName)
-	return []string{
-		fmt.Sprintf("Logs are available in CloudWatch Logs group: %s", logGroupName),
-		fmt.Sprintf("Log type: %s", logType),
-	}, nil
+	streams, err := c.GetClusterLogStreams(ctx, clusterName, []string{string(logType)})
+	if err != nil {
+		return nil, err
+	}
+	if len(streams) == 0 {
+		return nil, nil
+	}
+	return c.NewLogTail(EKSControlPlaneLogGroup(clusterName), streams, "", time.Hour).Poll(ctx)
 }
 
 // countNodeGroupNodes counts the total number of nodes across all node groups
@@ -1,7 +1,13 @@
 package aws
 
 import (
+	"context"
+	"sync"
 	"testing"
+	"time"
+
+	sdkaws "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
 )
 
 func TestEKSCluster(t *testing.T) {
@@ -201,3 +207,167 @@ func TestEKSNodeGroupScaling(t *testing.T) {
 		}
 	}
 }
+
+func TestListEKSClustersWithFakeBackend(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.EKS.Clusters = []types.Cluster{
+		{
+			Name:    sdkaws.String("prod"),
+			Version: sdkaws.String("1.29"),
+			Status:  types.ClusterStatusActive,
+			Arn:     sdkaws.String("arn:aws:eks:eu-west-1:123456789012:cluster/prod"),
+		},
+		{
+			Name:    sdkaws.String("staging"),
+			Version: sdkaws.String("1.28"),
+			Status:  types.ClusterStatusCreating,
+		},
+	}
+	backend.EKS.Nodegroups = map[string][]types.Nodegroup{
+		"prod": {
+			{NodegroupName: sdkaws.String("ng-a"), ScalingConfig: &types.NodegroupScalingConfig{DesiredSize: sdkaws.Int32(3)}},
+			{NodegroupName: sdkaws.String("ng-b"), ScalingConfig: &types.NodegroupScalingConfig{DesiredSize: sdkaws.Int32(2)}},
+		},
+	}
+	client := backend.Client("us-east-1")
+
+	clusters, err := client.ListEKSClusters(context.Background())
+	if err != nil {
+		t.Fatalf("ListEKSClusters returned error: %v", err)
+	}
+
+	if len(clusters) != 2 {
+		t.Fatalf("Expected 2 clusters, got %d", len(clusters))
+	}
+
+	if clusters[0].NodeCount != 5 {
+		t.Errorf("Expected node count 5, got %d", clusters[0].NodeCount)
+	}
+
+	if clusters[0].Region != "eu-west-1" {
+		t.Errorf("Expected region from ARN 'eu-west-1', got '%s'", clusters[0].Region)
+	}
+
+	if clusters[1].Region != "us-east-1" {
+		t.Errorf("Expected client region 'us-east-1', got '%s'", clusters[1].Region)
+	}
+
+	if clusters[1].Status != "CREATING" {
+		t.Errorf("Expected status 'CREATING', got '%s'", clusters[1].Status)
+	}
+}
+
+func TestListEKSClustersFollowsPages(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.EKS.PageSize = 1
+	backend.EKS.Clusters = []types.Cluster{
+		{Name: sdkaws.String("a"), Status: types.ClusterStatusActive},
+		{Name: sdkaws.String("b"), Status: types.ClusterStatusActive},
+		{Name: sdkaws.String("c"), Status: types.ClusterStatusActive},
+	}
+	backend.EKS.Nodegroups = map[string][]types.Nodegroup{
+		"a": {
+			{NodegroupName: sdkaws.String("ng-1"), ScalingConfig: &types.NodegroupScalingConfig{DesiredSize: sdkaws.Int32(1)}},
+			{NodegroupName: sdkaws.String("ng-2"), ScalingConfig: &types.NodegroupScalingConfig{DesiredSize: sdkaws.Int32(2)}},
+		},
+	}
+	backend.EKS.Addons = map[string][]types.Addon{
+		"a": {
+			{AddonName: sdkaws.String("vpc-cni"), Status: types.AddonStatusActive},
+			{AddonName: sdkaws.String("coredns"), Status: types.AddonStatusActive},
+		},
+	}
+	client := backend.Client("us-east-1")
+
+	progressCalls := 0
+	names, err := client.ListEKSClusterNames(context.Background(), func(loaded int) {
+		progressCalls++
+	})
+	if err != nil {
+		t.Fatalf("ListEKSClusterNames returned error: %v", err)
+	}
+
+	if len(names) != 3 {
+		t.Fatalf("Expected 3 cluster names across pages, got %d", len(names))
+	}
+
+	if progressCalls != 3 {
+		t.Errorf("Expected 3 progress updates, got %d", progressCalls)
+	}
+
+	clusters, err := client.ListEKSClusters(context.Background())
+	if err != nil {
+		t.Fatalf("ListEKSClusters returned error: %v", err)
+	}
+
+	if clusters[0].NodeCount != 3 {
+		t.Errorf("Expected node count 3 across node group pages, got %d", clusters[0].NodeCount)
+	}
+
+	addons, err := client.ListAddons(context.Background(), "a")
+	if err != nil {
+		t.Fatalf("ListAddons returned error: %v", err)
+	}
+
+	if len(addons) != 2 {
+		t.Errorf("Expected 2 add-ons across pages, got %d", len(addons))
+	}
+}
+
+func TestDescribeEKSClustersKeepsOrder(t *testing.T) {
+	backend := NewFakeBackend()
+	var names []string
+	for _, name := range []string{"alpha", "bravo", "charlie", "delta", "echo"} {
+		names = append(names, name)
+		backend.EKS.Clusters = append(backend.EKS.Clusters, types.Cluster{
+			Name:    sdkaws.String(name),
+			Version: sdkaws.String("1.29"),
+			Status:  types.ClusterStatusActive,
+		})
+	}
+	client := backend.Client("us-east-1")
+
+	basic := client.BasicEKSClusters(names)
+	if len(basic) != 5 || basic[0].DetailsLoaded {
+		t.Fatalf("Expected 5 placeholder clusters without details, got %+v", basic)
+	}
+
+	var mu sync.Mutex
+	seen := make(map[int]bool)
+	opts := EKSDescribeOptions{Concurrency: 2, Timeout: time.Second}
+	clusters := client.DescribeEKSClusters(context.Background(), names, opts, func(index int, cluster EKSCluster) {
+		mu.Lock()
+		defer mu.Unlock()
+		seen[index] = true
+		if cluster.Name != names[index] {
+			t.Errorf("Expected cluster '%s' at index %d, got '%s'", names[index], index, cluster.Name)
+		}
+	})
+
+	if len(seen) != 5 {
+		t.Errorf("Expected 5 results reported, got %d", len(seen))
+	}
+
+	for i, cluster := range clusters {
+		if cluster.Name != names[i] {
+			t.Errorf("Expected cluster '%s' at index %d, got '%s'", names[i], i, cluster.Name)
+		}
+		if !cluster.DetailsLoaded || cluster.Version != "1.29" {
+			t.Errorf("Expected loaded details for '%s', got %+v", cluster.Name, cluster)
+		}
+	}
+}
+
+func TestDescribeEKSClustersFallsBackOnError(t *testing.T) {
+	backend := NewFakeBackend()
+	client := backend.Client("us-east-1")
+
+	clusters := client.DescribeEKSClusters(context.Background(), []string{"missing"}, DefaultEKSDescribeOptions(), nil)
+	if len(clusters) != 1 {
+		t.Fatalf("Expected 1 cluster, got %d", len(clusters))
+	}
+
+	if clusters[0].Status != "unknown" || clusters[0].Region != "us-east-1" {
+		t.Errorf("Expected basic info with status 'unknown', got %+v", clusters[0])
+	}
+}
@@ -0,0 +1,82 @@
+package aws
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+const (
+	// eksTokenPrefix marks a bearer token as a presigned STS request
+	eksTokenPrefix = "k8s-aws-v1."
+	// eksClusterIDHeader binds the presigned request to one cluster
+	eksClusterIDHeader = "x-k8s-aws-id"
+	// eksTokenLifetime is how long the API server accepts a token. The
+	// reported expiration is a minute earlier so clients refresh in time.
+	eksTokenLifetime = 15 * time.Minute
+)
+
+// EKSToken is a bearer token for an EKS cluster's API server
+type EKSToken struct {
+	Token      string
+	Expiration time.Time
+}
+
+// ExecCredential is the object a kubeconfig exec credential plugin prints
+type ExecCredential struct {
+	APIVersion string                 `json:"apiVersion"`
+	Kind       string                 `json:"kind"`
+	Spec       map[string]interface{} `json:"spec"`
+	Status     ExecCredentialStatus   `json:"status"`
+}
+
+// ExecCredentialStatus holds the token handed to kubectl
+type ExecCredentialStatus struct {
+	ExpirationTimestamp string `json:"expirationTimestamp"`
+	Token               string `json:"token"`
+}
+
+// GetEKSToken creates a token for clusterName from the client's credentials,
+// the same way "aws eks get-token" does: a presigned STS GetCallerIdentity
+// URL that carries the cluster name in a signed header
+func (c *Client) GetEKSToken(ctx context.Context, clusterName string) (*EKSToken, error) {
+	if c.STSPresign == nil {
+		return nil, fmt.Errorf("no STS presign client configured")
+	}
+
+	now := time.Now()
+	request, err := c.STSPresign.PresignGetCallerIdentity(ctx, &sts.GetCallerIdentityInput{}, func(opts *sts.PresignOptions) {
+		opts.ClientOptions = append(opts.ClientOptions, func(o *sts.Options) {
+			o.APIOptions = append(o.APIOptions,
+				smithyhttp.AddHeaderValue(eksClusterIDHeader, clusterName),
+				smithyhttp.AddHeaderValue("X-Amz-Expires", "60"),
+			)
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign STS request: %w", err)
+	}
+
+	return &EKSToken{
+		Token:      eksTokenPrefix + base64.RawURLEncoding.EncodeToString([]byte(request.URL)),
+		Expiration: now.Add(eksTokenLifetime - time.Minute),
+	}, nil
+}
+
+// ExecCredential wraps the token in the object kubectl expects from an exec
+// credential plugin
+func (t *EKSToken) ExecCredential() ExecCredential {
+	return ExecCredential{
+		APIVersion: execCredentialAPIVersion,
+		Kind:       "ExecCredential",
+		Spec:       map[string]interface{}{},
+		Status: ExecCredentialStatus{
+			ExpirationTimestamp: t.Expiration.UTC().Format(time.RFC3339),
+			Token:               t.Token,
+		},
+	}
+}
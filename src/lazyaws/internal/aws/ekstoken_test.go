@@ -0,0 +1,52 @@
+package aws
+
+import (
+	"context"
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetEKSToken(t *testing.T) {
+	client := NewFakeBackend().Client("eu-west-1")
+
+	token, err := client.GetEKSToken(context.Background(), "prod")
+	if err != nil {
+		t.Fatalf("GetEKSToken returned error: %v", err)
+	}
+
+	if !strings.HasPrefix(token.Token, "k8s-aws-v1.") {
+		t.Fatalf("Expected token prefix 'k8s-aws-v1.', got '%s'", token.Token)
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(token.Token, "k8s-aws-v1."))
+	if err != nil {
+		t.Fatalf("Token is not base64url encoded: %v", err)
+	}
+	presigned, err := url.Parse(string(decoded))
+	if err != nil {
+		t.Fatalf("Token does not contain a URL: %v", err)
+	}
+
+	if presigned.Host != "sts.eu-west-1.amazonaws.com" {
+		t.Errorf("Expected regional STS host, got '%s'", presigned.Host)
+	}
+	query := presigned.Query()
+	if query.Get("Action") != "GetCallerIdentity" {
+		t.Errorf("Expected Action=GetCallerIdentity, got '%s'", query.Get("Action"))
+	}
+	if !strings.Contains(query.Get("X-Amz-SignedHeaders"), "x-k8s-aws-id") {
+		t.Errorf("Expected x-k8s-aws-id to be signed, got '%s'", query.Get("X-Amz-SignedHeaders"))
+	}
+
+	if remaining := time.Until(token.Expiration); remaining <= 13*time.Minute || remaining > 14*time.Minute {
+		t.Errorf("Expected expiration about 14 minutes ahead, got %v", remaining)
+	}
+
+	credential := token.ExecCredential()
+	if credential.APIVersion != execCredentialAPIVersion || credential.Status.Token != token.Token {
+		t.Errorf("Unexpected ExecCredential: %+v", credential)
+	}
+}
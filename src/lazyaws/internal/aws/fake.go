@@ -0,0 +1,2435 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	sdkaws "github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	logstypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
+)
+
+// FakeBackend bundles in-memory implementations of every service API used
+// by Client. It is meant for unit tests and for running the TUI offline.
+type FakeBackend struct {
+	EC2        *FakeEC2
+	S3         *FakeS3
+	EKS        *FakeEKS
+	SSM        *FakeSSM
+	CloudWatch *FakeCloudWatch
+	Logs       *FakeLogs
+	STS        *FakeSTS
+}
+
+// NewFakeBackend creates an empty fake backend
+func NewFakeBackend() *FakeBackend {
+	return &FakeBackend{
+		EC2:        &FakeEC2{},
+		S3:         NewFakeS3(),
+		EKS:        &FakeEKS{},
+		SSM:        &FakeSSM{},
+		CloudWatch: &FakeCloudWatch{},
+		Logs:       &FakeLogs{},
+		STS:        &FakeSTS{Account: "123456789012"},
+	}
+}
+
+// Client returns a Client wired to the fake services
+func (b *FakeBackend) Client(region string) *Client {
+	return &Client{
+		EC2:        b.EC2,
+		S3:         b.S3,
+		S3Presign:  &FakeS3Presign{Region: region},
+		EKS:        b.EKS,
+		SSM:        b.SSM,
+		CloudWatch: b.CloudWatch,
+		Logs:       b.Logs,
+		STS:        b.STS,
+		STSPresign: newFakeSTSPresign(region),
+		Region:     region,
+		AccountID:  b.STS.Account,
+	}
+}
+
+// Compile-time checks that the fakes satisfy the service interfaces
+var (
+	_ EC2API        = (*FakeEC2)(nil)
+	_ S3API         = (*FakeS3)(nil)
+	_ S3PresignAPI  = (*FakeS3Presign)(nil)
+	_ EKSAPI        = (*FakeEKS)(nil)
+	_ SSMAPI        = (*FakeSSM)(nil)
+	_ CloudWatchAPI = (*FakeCloudWatch)(nil)
+	_ LogsAPI       = (*FakeLogs)(nil)
+	_ STSAPI        = (*FakeSTS)(nil)
+)
+
+// FakeEC2 is an in-memory EC2API. Set PageSize to split DescribeInstances
+// results across pages, and Err to make every call fail. Tags of network
+// interfaces, which the fake doesn't otherwise model, are kept in
+// InterfaceTags by interface ID.
+type FakeEC2 struct {
+	mu            sync.Mutex
+	Instances     []ec2types.Instance
+	Volumes       []ec2types.Volume
+	InstanceTypes []ec2types.InstanceTypeInfo
+	InterfaceTags map[string]map[string]string
+	PageSize      int
+	Err           error
+}
+
+func (f *FakeEC2) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	var instances []ec2types.Instance
+	for _, inst := range f.Instances {
+		if len(params.InstanceIds) > 0 && !containsString(params.InstanceIds, getString(inst.InstanceId)) {
+			continue
+		}
+		matches, err := matchesInstanceFilters(inst, params.Filters)
+		if err != nil {
+			return nil, err
+		}
+		if matches {
+			instances = append(instances, inst)
+		}
+	}
+
+	start, end, next, err := fakePage(len(instances), params.NextToken, sdkaws.ToInt32(params.MaxResults), f.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &ec2.DescribeInstancesOutput{NextToken: next}
+	if end > start {
+		output.Reservations = []ec2types.Reservation{{Instances: instances[start:end]}}
+	}
+	return output, nil
+}
+
+// matchesInstanceFilters applies the DescribeInstances filters the fake
+// supports: every filter has to match one of its values, which may use *
+// and ? wildcards
+func matchesInstanceFilters(inst ec2types.Instance, filters []ec2types.Filter) (bool, error) {
+	for _, filter := range filters {
+		name := getString(filter.Name)
+		var fields []string
+		switch {
+		case name == "instance-state-name" && inst.State != nil:
+			fields = []string{string(inst.State.Name)}
+		case name == "instance-type":
+			fields = []string{string(inst.InstanceType)}
+		case name == "vpc-id":
+			fields = []string{getString(inst.VpcId)}
+		case name == "subnet-id":
+			fields = []string{getString(inst.SubnetId)}
+		case name == "availability-zone" && inst.Placement != nil:
+			fields = []string{getString(inst.Placement.AvailabilityZone)}
+		case name == "instance-id":
+			fields = []string{getString(inst.InstanceId)}
+		case name == "private-ip-address":
+			fields = []string{getString(inst.PrivateIpAddress)}
+		case name == "tag-key":
+			for _, tag := range inst.Tags {
+				fields = append(fields, getString(tag.Key))
+			}
+		case strings.HasPrefix(name, "tag:"):
+			for _, tag := range inst.Tags {
+				if getString(tag.Key) == strings.TrimPrefix(name, "tag:") {
+					fields = append(fields, getString(tag.Value))
+				}
+			}
+		case name == "instance-state-name" || name == "availability-zone":
+		default:
+			return false, fmt.Errorf("InvalidParameterValue: The filter '%s' is invalid", name)
+		}
+
+		matched := false
+		for _, value := range filter.Values {
+			for _, field := range fields {
+				matched = matched || globRegexp(value).MatchString(field)
+			}
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (f *FakeEC2) DescribeInstanceStatus(ctx context.Context, params *ec2.DescribeInstanceStatusInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceStatusOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	output := &ec2.DescribeInstanceStatusOutput{}
+	for _, inst := range f.Instances {
+		if len(params.InstanceIds) > 0 && !containsString(params.InstanceIds, getString(inst.InstanceId)) {
+			continue
+		}
+		output.InstanceStatuses = append(output.InstanceStatuses, ec2types.InstanceStatus{
+			InstanceId:     inst.InstanceId,
+			InstanceState:  inst.State,
+			SystemStatus:   &ec2types.InstanceStatusSummary{Status: ec2types.SummaryStatusOk},
+			InstanceStatus: &ec2types.InstanceStatusSummary{Status: ec2types.SummaryStatusOk},
+		})
+	}
+	return output, nil
+}
+
+func (f *FakeEC2) DescribeInstanceTypes(ctx context.Context, params *ec2.DescribeInstanceTypesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceTypesOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	output := &ec2.DescribeInstanceTypesOutput{}
+	for _, info := range f.InstanceTypes {
+		for _, t := range params.InstanceTypes {
+			if info.InstanceType == t {
+				output.InstanceTypes = append(output.InstanceTypes, info)
+			}
+		}
+	}
+	return output, nil
+}
+
+func (f *FakeEC2) DescribeVolumes(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	output := &ec2.DescribeVolumesOutput{}
+	for _, vol := range f.Volumes {
+		if len(params.VolumeIds) > 0 && !containsString(params.VolumeIds, getString(vol.VolumeId)) {
+			continue
+		}
+		output.Volumes = append(output.Volumes, vol)
+	}
+	return output, nil
+}
+
+func (f *FakeEC2) StartInstances(ctx context.Context, params *ec2.StartInstancesInput, optFns ...func(*ec2.Options)) (*ec2.StartInstancesOutput, error) {
+	if err := f.setState(params.InstanceIds, ec2types.InstanceStateNameRunning); err != nil {
+		return nil, err
+	}
+	return &ec2.StartInstancesOutput{}, nil
+}
+
+func (f *FakeEC2) StopInstances(ctx context.Context, params *ec2.StopInstancesInput, optFns ...func(*ec2.Options)) (*ec2.StopInstancesOutput, error) {
+	if err := f.setState(params.InstanceIds, ec2types.InstanceStateNameStopped); err != nil {
+		return nil, err
+	}
+	return &ec2.StopInstancesOutput{}, nil
+}
+
+func (f *FakeEC2) RebootInstances(ctx context.Context, params *ec2.RebootInstancesInput, optFns ...func(*ec2.Options)) (*ec2.RebootInstancesOutput, error) {
+	if err := f.setState(params.InstanceIds, ec2types.InstanceStateNameRunning); err != nil {
+		return nil, err
+	}
+	return &ec2.RebootInstancesOutput{}, nil
+}
+
+func (f *FakeEC2) TerminateInstances(ctx context.Context, params *ec2.TerminateInstancesInput, optFns ...func(*ec2.Options)) (*ec2.TerminateInstancesOutput, error) {
+	if err := f.setState(params.InstanceIds, ec2types.InstanceStateNameTerminated); err != nil {
+		return nil, err
+	}
+	return &ec2.TerminateInstancesOutput{}, nil
+}
+
+func (f *FakeEC2) CreateTags(ctx context.Context, params *ec2.CreateTagsInput, optFns ...func(*ec2.Options)) (*ec2.CreateTagsOutput, error) {
+	err := f.updateTags(params.Resources, func(tags []ec2types.Tag) []ec2types.Tag {
+		for _, tag := range params.Tags {
+			tags = removeEC2Tag(tags, getString(tag.Key))
+			tags = append(tags, ec2types.Tag{Key: tag.Key, Value: sdkaws.String(getString(tag.Value))})
+		}
+		return tags
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ec2.CreateTagsOutput{}, nil
+}
+
+func (f *FakeEC2) DeleteTags(ctx context.Context, params *ec2.DeleteTagsInput, optFns ...func(*ec2.Options)) (*ec2.DeleteTagsOutput, error) {
+	err := f.updateTags(params.Resources, func(tags []ec2types.Tag) []ec2types.Tag {
+		for _, tag := range params.Tags {
+			// A value only deletes the tag when it matches
+			for _, existing := range tags {
+				if getString(existing.Key) == getString(tag.Key) && (tag.Value == nil || getString(existing.Value) == *tag.Value) {
+					tags = removeEC2Tag(tags, getString(tag.Key))
+					break
+				}
+			}
+		}
+		return tags
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ec2.DeleteTagsOutput{}, nil
+}
+
+// updateTags rewrites the tags of instances, volumes and network
+// interfaces by ID, failing before any change if one isn't found
+func (f *FakeEC2) updateTags(resources []string, update func([]ec2types.Tag) []ec2types.Tag) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return f.Err
+	}
+
+	targets := make(map[string]*[]ec2types.Tag)
+	for _, id := range resources {
+		for i := range f.Instances {
+			if getString(f.Instances[i].InstanceId) == id {
+				targets[id] = &f.Instances[i].Tags
+			}
+		}
+		for i := range f.Volumes {
+			if getString(f.Volumes[i].VolumeId) == id {
+				targets[id] = &f.Volumes[i].Tags
+			}
+		}
+		if strings.HasPrefix(id, "eni-") {
+			var tags []ec2types.Tag
+			for k, v := range f.InterfaceTags[id] {
+				tags = append(tags, ec2types.Tag{Key: sdkaws.String(k), Value: sdkaws.String(v)})
+			}
+			targets[id] = &tags
+		}
+		if targets[id] == nil {
+			return fmt.Errorf("InvalidID: %s not found", id)
+		}
+	}
+	for id, tags := range targets {
+		*tags = update(*tags)
+		if strings.HasPrefix(id, "eni-") {
+			if f.InterfaceTags == nil {
+				f.InterfaceTags = make(map[string]map[string]string)
+			}
+			f.InterfaceTags[id] = make(map[string]string)
+			for _, tag := range *tags {
+				f.InterfaceTags[id][getString(tag.Key)] = getString(tag.Value)
+			}
+		}
+	}
+	return nil
+}
+
+func removeEC2Tag(tags []ec2types.Tag, key string) []ec2types.Tag {
+	var kept []ec2types.Tag
+	for _, tag := range tags {
+		if getString(tag.Key) != key {
+			kept = append(kept, tag)
+		}
+	}
+	return kept
+}
+
+// setState moves the given instances to a new state
+func (f *FakeEC2) setState(instanceIDs []string, state ec2types.InstanceStateName) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return f.Err
+	}
+
+	for _, id := range instanceIDs {
+		found := false
+		for i := range f.Instances {
+			if getString(f.Instances[i].InstanceId) == id {
+				f.Instances[i].State = &ec2types.InstanceState{Name: state}
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("InvalidInstanceID.NotFound: %s", id)
+		}
+	}
+	return nil
+}
+
+// FakeS3Object is an object stored in FakeS3
+type FakeS3Object struct {
+	Data         []byte
+	LastModified time.Time
+	ContentType  string
+	StorageClass s3types.StorageClass
+	Metadata     map[string]string
+	Tags         map[string]string
+	// ETag overrides the MD5 ETag, as for multipart uploads
+	ETag string
+	// ServerSideEncryption is reported by HeadObject; empty for SSE-S3
+	ServerSideEncryption s3types.ServerSideEncryption
+	// ACL is the canned ACL of the object; empty for private
+	ACL string
+	// Restore state of an archived object
+	RestoreOngoing bool
+	RestoreDays    int32
+	RestoreExpiry  time.Time
+}
+
+// readable reports whether GetObject may return the object's data
+func (o *FakeS3Object) readable() bool {
+	archived := o.StorageClass == s3types.StorageClassGlacier || o.StorageClass == s3types.StorageClassDeepArchive
+	return !archived || (!o.RestoreOngoing && !o.RestoreExpiry.IsZero())
+}
+
+// etag returns the object's ETag as S3 reports it
+func (o *FakeS3Object) etag() string {
+	if o.ETag != "" {
+		return o.ETag
+	}
+	return fakeETag(o.Data)
+}
+
+// FakeS3Version is a version of an object, or a delete marker, kept by a
+// bucket with versioning enabled
+type FakeS3Version struct {
+	VersionID    string
+	Object       *FakeS3Object // nil for a delete marker
+	LastModified time.Time
+}
+
+// FakeS3Bucket is a bucket stored in FakeS3
+type FakeS3Bucket struct {
+	Region       string
+	CreationDate time.Time
+	Policy       string
+	Versioning   s3types.BucketVersioningStatus
+	Objects      map[string]*FakeS3Object
+	// Configuration documents, nil when not set
+	CORS              []s3types.CORSRule
+	Lifecycle         []s3types.LifecycleRule
+	Encryption        *s3types.ServerSideEncryptionConfiguration
+	PublicAccessBlock *s3types.PublicAccessBlockConfiguration
+	Tags              []s3types.Tag
+	// ACLsDisabled is Object Ownership "BucketOwnerEnforced", which makes
+	// object ACL calls fail
+	ACLsDisabled bool
+	// Versions holds the history of each key, newest first, from when
+	// versioning was enabled
+	Versions map[string][]*FakeS3Version
+}
+
+type fakeMultipartUpload struct {
+	bucket string
+	key    string
+	parts  map[int32][]byte
+	// The object being created
+	object FakeS3Object
+}
+
+// FakeS3 is an in-memory S3API. Set Err to make every call fail.
+type FakeS3 struct {
+	mu      sync.Mutex
+	Buckets map[string]*FakeS3Bucket
+	Err     error
+	// PartUploads counts UploadPart calls
+	PartUploads int
+	// DeleteBatches counts DeleteObjects calls
+	DeleteBatches int
+	// DeleteErrors maps keys that DeleteObjects fails to delete to the
+	// error code it reports for them
+	DeleteErrors map[string]string
+
+	uploads     map[string]*fakeMultipartUpload
+	nextUpload  int
+	nextVersion int
+}
+
+// NewFakeS3 creates an empty FakeS3
+func NewFakeS3() *FakeS3 {
+	return &FakeS3{
+		Buckets: make(map[string]*FakeS3Bucket),
+		uploads: make(map[string]*fakeMultipartUpload),
+	}
+}
+
+// AddBucket creates a bucket in the given region
+func (f *FakeS3) AddBucket(name, region string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Buckets[name] = &FakeS3Bucket{
+		Region:       region,
+		CreationDate: time.Now(),
+		Objects:      make(map[string]*FakeS3Object),
+	}
+}
+
+// AddObject stores an object, creating the bucket if needed
+func (f *FakeS3) AddObject(bucket, key string, data []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	b, ok := f.Buckets[bucket]
+	if !ok {
+		b = &FakeS3Bucket{CreationDate: time.Now(), Objects: make(map[string]*FakeS3Object)}
+		f.Buckets[bucket] = b
+	}
+	f.put(b, key, &FakeS3Object{
+		Data:         append([]byte(nil), data...),
+		LastModified: time.Now(),
+		StorageClass: s3types.StorageClassStandard,
+	})
+}
+
+// put stores obj as the current version of key; callers must hold f.mu
+func (f *FakeS3) put(b *FakeS3Bucket, key string, obj *FakeS3Object) {
+	f.addVersion(b, key, obj)
+	b.Objects[key] = obj
+}
+
+// remove deletes key, leaving a delete marker if versioning is enabled;
+// callers must hold f.mu
+func (f *FakeS3) remove(b *FakeS3Bucket, key string) {
+	f.addVersion(b, key, nil)
+	delete(b.Objects, key)
+}
+
+// addVersion records a new version of key if versioning is enabled, or a
+// delete marker if obj is nil. An object stored before versioning was
+// enabled becomes the "null" version. Callers must hold f.mu.
+func (f *FakeS3) addVersion(b *FakeS3Bucket, key string, obj *FakeS3Object) {
+	if b.Versioning != s3types.BucketVersioningStatusEnabled {
+		return
+	}
+	if b.Versions == nil {
+		b.Versions = make(map[string][]*FakeS3Version)
+	}
+	history := b.Versions[key]
+	if current, ok := b.Objects[key]; ok && len(history) == 0 {
+		history = []*FakeS3Version{{VersionID: "null", Object: current, LastModified: current.LastModified}}
+	}
+	f.nextVersion++
+	version := &FakeS3Version{VersionID: fmt.Sprintf("v%d", f.nextVersion), Object: obj, LastModified: time.Now()}
+	b.Versions[key] = append([]*FakeS3Version{version}, history...)
+}
+
+// objectVersion returns a version of an object, or the current version if
+// versionID is empty; callers must hold f.mu
+func (f *FakeS3) objectVersion(bucket, key *string, versionID string) (*FakeS3Object, error) {
+	b, err := f.bucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+	if versionID == "" || (versionID == "null" && len(b.Versions[getString(key)]) == 0) {
+		return f.object(bucket, key)
+	}
+	for _, version := range b.Versions[getString(key)] {
+		if version.VersionID != versionID {
+			continue
+		}
+		if version.Object == nil {
+			return nil, errors.New("MethodNotAllowed: the specified method is not allowed against a delete marker")
+		}
+		return version.Object, nil
+	}
+	return nil, fmt.Errorf("NoSuchVersion: %s has no version %s", getString(key), versionID)
+}
+
+// bucket returns the named bucket; callers must hold f.mu
+func (f *FakeS3) bucket(name *string) (*FakeS3Bucket, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	b, ok := f.Buckets[getString(name)]
+	if !ok {
+		return nil, &s3types.NoSuchBucket{Message: sdkaws.String(getString(name))}
+	}
+	return b, nil
+}
+
+// object returns the named object; callers must hold f.mu
+func (f *FakeS3) object(bucket, key *string) (*FakeS3Object, error) {
+	b, err := f.bucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := b.Objects[getString(key)]
+	if !ok {
+		return nil, &s3types.NoSuchKey{Message: sdkaws.String(getString(key))}
+	}
+	return obj, nil
+}
+
+func (f *FakeS3) ListBuckets(ctx context.Context, params *s3.ListBucketsInput, optFns ...func(*s3.Options)) (*s3.ListBucketsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	names := make([]string, 0, len(f.Buckets))
+	for name := range f.Buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	output := &s3.ListBucketsOutput{}
+	for _, name := range names {
+		created := f.Buckets[name].CreationDate
+		output.Buckets = append(output.Buckets, s3types.Bucket{
+			Name:         sdkaws.String(name),
+			CreationDate: &created,
+		})
+	}
+	return output, nil
+}
+
+func (f *FakeS3) CreateBucket(ctx context.Context, params *s3.CreateBucketInput, optFns ...func(*s3.Options)) (*s3.CreateBucketOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	name := getString(params.Bucket)
+	if _, exists := f.Buckets[name]; exists {
+		return nil, &s3types.BucketAlreadyOwnedByYou{Message: sdkaws.String(name)}
+	}
+	region := ""
+	if params.CreateBucketConfiguration != nil {
+		region = string(params.CreateBucketConfiguration.LocationConstraint)
+	}
+	f.Buckets[name] = &FakeS3Bucket{
+		Region:       region,
+		CreationDate: time.Now(),
+		Objects:      make(map[string]*FakeS3Object),
+	}
+	return &s3.CreateBucketOutput{}, nil
+}
+
+func (f *FakeS3) DeleteBucket(ctx context.Context, params *s3.DeleteBucketInput, optFns ...func(*s3.Options)) (*s3.DeleteBucketOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	b, err := f.bucket(params.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	if len(b.Objects) > 0 {
+		return nil, fmt.Errorf("BucketNotEmpty: %s", getString(params.Bucket))
+	}
+	delete(f.Buckets, getString(params.Bucket))
+	return &s3.DeleteBucketOutput{}, nil
+}
+
+func (f *FakeS3) GetBucketLocation(ctx context.Context, params *s3.GetBucketLocationInput, optFns ...func(*s3.Options)) (*s3.GetBucketLocationOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	b, err := f.bucket(params.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	return &s3.GetBucketLocationOutput{LocationConstraint: s3types.BucketLocationConstraint(b.Region)}, nil
+}
+
+func (f *FakeS3) GetBucketPolicy(ctx context.Context, params *s3.GetBucketPolicyInput, optFns ...func(*s3.Options)) (*s3.GetBucketPolicyOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	b, err := f.bucket(params.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	if b.Policy == "" {
+		return nil, fakeNotSet("NoSuchBucketPolicy", params.Bucket)
+	}
+	return &s3.GetBucketPolicyOutput{Policy: sdkaws.String(b.Policy)}, nil
+}
+
+// fakeNotSet is the error S3 returns for a configuration a bucket doesn't
+// have
+func fakeNotSet(code string, bucket *string) error {
+	return &smithy.GenericAPIError{Code: code, Message: "not set on " + getString(bucket)}
+}
+
+// updateBucket runs fn on a bucket, holding f.mu
+func (f *FakeS3) updateBucket(name *string, fn func(b *FakeS3Bucket) error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	b, err := f.bucket(name)
+	if err != nil {
+		return err
+	}
+	return fn(b)
+}
+
+func (f *FakeS3) PutBucketPolicy(ctx context.Context, params *s3.PutBucketPolicyInput, optFns ...func(*s3.Options)) (*s3.PutBucketPolicyOutput, error) {
+	err := f.updateBucket(params.Bucket, func(b *FakeS3Bucket) error {
+		b.Policy = getString(params.Policy)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3.PutBucketPolicyOutput{}, nil
+}
+
+func (f *FakeS3) DeleteBucketPolicy(ctx context.Context, params *s3.DeleteBucketPolicyInput, optFns ...func(*s3.Options)) (*s3.DeleteBucketPolicyOutput, error) {
+	err := f.updateBucket(params.Bucket, func(b *FakeS3Bucket) error {
+		b.Policy = ""
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3.DeleteBucketPolicyOutput{}, nil
+}
+
+func (f *FakeS3) GetBucketCors(ctx context.Context, params *s3.GetBucketCorsInput, optFns ...func(*s3.Options)) (*s3.GetBucketCorsOutput, error) {
+	output := &s3.GetBucketCorsOutput{}
+	err := f.updateBucket(params.Bucket, func(b *FakeS3Bucket) error {
+		if b.CORS == nil {
+			return fakeNotSet("NoSuchCORSConfiguration", params.Bucket)
+		}
+		output.CORSRules = b.CORS
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (f *FakeS3) PutBucketCors(ctx context.Context, params *s3.PutBucketCorsInput, optFns ...func(*s3.Options)) (*s3.PutBucketCorsOutput, error) {
+	err := f.updateBucket(params.Bucket, func(b *FakeS3Bucket) error {
+		b.CORS = params.CORSConfiguration.CORSRules
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3.PutBucketCorsOutput{}, nil
+}
+
+func (f *FakeS3) DeleteBucketCors(ctx context.Context, params *s3.DeleteBucketCorsInput, optFns ...func(*s3.Options)) (*s3.DeleteBucketCorsOutput, error) {
+	err := f.updateBucket(params.Bucket, func(b *FakeS3Bucket) error {
+		b.CORS = nil
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3.DeleteBucketCorsOutput{}, nil
+}
+
+func (f *FakeS3) GetBucketLifecycleConfiguration(ctx context.Context, params *s3.GetBucketLifecycleConfigurationInput, optFns ...func(*s3.Options)) (*s3.GetBucketLifecycleConfigurationOutput, error) {
+	output := &s3.GetBucketLifecycleConfigurationOutput{}
+	err := f.updateBucket(params.Bucket, func(b *FakeS3Bucket) error {
+		if b.Lifecycle == nil {
+			return fakeNotSet("NoSuchLifecycleConfiguration", params.Bucket)
+		}
+		output.Rules = b.Lifecycle
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (f *FakeS3) PutBucketLifecycleConfiguration(ctx context.Context, params *s3.PutBucketLifecycleConfigurationInput, optFns ...func(*s3.Options)) (*s3.PutBucketLifecycleConfigurationOutput, error) {
+	err := f.updateBucket(params.Bucket, func(b *FakeS3Bucket) error {
+		b.Lifecycle = params.LifecycleConfiguration.Rules
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3.PutBucketLifecycleConfigurationOutput{}, nil
+}
+
+func (f *FakeS3) DeleteBucketLifecycle(ctx context.Context, params *s3.DeleteBucketLifecycleInput, optFns ...func(*s3.Options)) (*s3.DeleteBucketLifecycleOutput, error) {
+	err := f.updateBucket(params.Bucket, func(b *FakeS3Bucket) error {
+		b.Lifecycle = nil
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3.DeleteBucketLifecycleOutput{}, nil
+}
+
+func (f *FakeS3) GetBucketEncryption(ctx context.Context, params *s3.GetBucketEncryptionInput, optFns ...func(*s3.Options)) (*s3.GetBucketEncryptionOutput, error) {
+	output := &s3.GetBucketEncryptionOutput{}
+	err := f.updateBucket(params.Bucket, func(b *FakeS3Bucket) error {
+		if b.Encryption == nil {
+			return fakeNotSet("ServerSideEncryptionConfigurationNotFoundError", params.Bucket)
+		}
+		output.ServerSideEncryptionConfiguration = b.Encryption
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (f *FakeS3) PutBucketEncryption(ctx context.Context, params *s3.PutBucketEncryptionInput, optFns ...func(*s3.Options)) (*s3.PutBucketEncryptionOutput, error) {
+	err := f.updateBucket(params.Bucket, func(b *FakeS3Bucket) error {
+		b.Encryption = params.ServerSideEncryptionConfiguration
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3.PutBucketEncryptionOutput{}, nil
+}
+
+func (f *FakeS3) DeleteBucketEncryption(ctx context.Context, params *s3.DeleteBucketEncryptionInput, optFns ...func(*s3.Options)) (*s3.DeleteBucketEncryptionOutput, error) {
+	err := f.updateBucket(params.Bucket, func(b *FakeS3Bucket) error {
+		b.Encryption = nil
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3.DeleteBucketEncryptionOutput{}, nil
+}
+
+func (f *FakeS3) GetPublicAccessBlock(ctx context.Context, params *s3.GetPublicAccessBlockInput, optFns ...func(*s3.Options)) (*s3.GetPublicAccessBlockOutput, error) {
+	output := &s3.GetPublicAccessBlockOutput{}
+	err := f.updateBucket(params.Bucket, func(b *FakeS3Bucket) error {
+		if b.PublicAccessBlock == nil {
+			return fakeNotSet("NoSuchPublicAccessBlockConfiguration", params.Bucket)
+		}
+		output.PublicAccessBlockConfiguration = b.PublicAccessBlock
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (f *FakeS3) PutPublicAccessBlock(ctx context.Context, params *s3.PutPublicAccessBlockInput, optFns ...func(*s3.Options)) (*s3.PutPublicAccessBlockOutput, error) {
+	err := f.updateBucket(params.Bucket, func(b *FakeS3Bucket) error {
+		b.PublicAccessBlock = params.PublicAccessBlockConfiguration
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3.PutPublicAccessBlockOutput{}, nil
+}
+
+func (f *FakeS3) DeletePublicAccessBlock(ctx context.Context, params *s3.DeletePublicAccessBlockInput, optFns ...func(*s3.Options)) (*s3.DeletePublicAccessBlockOutput, error) {
+	err := f.updateBucket(params.Bucket, func(b *FakeS3Bucket) error {
+		b.PublicAccessBlock = nil
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3.DeletePublicAccessBlockOutput{}, nil
+}
+
+func (f *FakeS3) GetBucketTagging(ctx context.Context, params *s3.GetBucketTaggingInput, optFns ...func(*s3.Options)) (*s3.GetBucketTaggingOutput, error) {
+	output := &s3.GetBucketTaggingOutput{}
+	err := f.updateBucket(params.Bucket, func(b *FakeS3Bucket) error {
+		if b.Tags == nil {
+			return fakeNotSet("NoSuchTagSet", params.Bucket)
+		}
+		output.TagSet = b.Tags
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (f *FakeS3) PutBucketTagging(ctx context.Context, params *s3.PutBucketTaggingInput, optFns ...func(*s3.Options)) (*s3.PutBucketTaggingOutput, error) {
+	err := f.updateBucket(params.Bucket, func(b *FakeS3Bucket) error {
+		b.Tags = params.Tagging.TagSet
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3.PutBucketTaggingOutput{}, nil
+}
+
+func (f *FakeS3) DeleteBucketTagging(ctx context.Context, params *s3.DeleteBucketTaggingInput, optFns ...func(*s3.Options)) (*s3.DeleteBucketTaggingOutput, error) {
+	err := f.updateBucket(params.Bucket, func(b *FakeS3Bucket) error {
+		b.Tags = nil
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3.DeleteBucketTaggingOutput{}, nil
+}
+
+func (f *FakeS3) GetBucketVersioning(ctx context.Context, params *s3.GetBucketVersioningInput, optFns ...func(*s3.Options)) (*s3.GetBucketVersioningOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	b, err := f.bucket(params.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	return &s3.GetBucketVersioningOutput{Status: b.Versioning}, nil
+}
+
+func (f *FakeS3) PutBucketVersioning(ctx context.Context, params *s3.PutBucketVersioningInput, optFns ...func(*s3.Options)) (*s3.PutBucketVersioningOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	b, err := f.bucket(params.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	if params.VersioningConfiguration != nil {
+		b.Versioning = params.VersioningConfiguration.Status
+	}
+	return &s3.PutBucketVersioningOutput{}, nil
+}
+
+func (f *FakeS3) ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	b, err := f.bucket(params.Bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := getString(params.Prefix)
+	delimiter := getString(params.Delimiter)
+
+	keys := make([]string, 0, len(b.Objects))
+	for key := range b.Objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	// Build the ordered list of entries (objects and common prefixes) so
+	// that pagination can simply walk it by index
+	type entry struct {
+		key      string
+		isPrefix bool
+	}
+	var entries []entry
+	seenPrefixes := make(map[string]bool)
+	for _, key := range keys {
+		if delimiter != "" {
+			rest := strings.TrimPrefix(key, prefix)
+			if idx := strings.Index(rest, delimiter); idx >= 0 {
+				commonPrefix := prefix + rest[:idx+len(delimiter)]
+				if !seenPrefixes[commonPrefix] {
+					seenPrefixes[commonPrefix] = true
+					entries = append(entries, entry{key: commonPrefix, isPrefix: true})
+				}
+				continue
+			}
+		}
+		entries = append(entries, entry{key: key})
+	}
+
+	start := 0
+	if params.ContinuationToken != nil {
+		start, err = strconv.Atoi(*params.ContinuationToken)
+		if err != nil {
+			return nil, fmt.Errorf("invalid continuation token: %s", *params.ContinuationToken)
+		}
+	}
+	maxKeys := 1000
+	if params.MaxKeys != nil && *params.MaxKeys > 0 {
+		maxKeys = int(*params.MaxKeys)
+	}
+	end := start + maxKeys
+	if end > len(entries) {
+		end = len(entries)
+	}
+
+	output := &s3.ListObjectsV2Output{
+		Name:        params.Bucket,
+		Prefix:      params.Prefix,
+		IsTruncated: sdkaws.Bool(end < len(entries)),
+	}
+	for _, e := range entries[start:end] {
+		if e.isPrefix {
+			output.CommonPrefixes = append(output.CommonPrefixes, s3types.CommonPrefix{Prefix: sdkaws.String(e.key)})
+			continue
+		}
+		obj := b.Objects[e.key]
+		modified := obj.LastModified
+		output.Contents = append(output.Contents, s3types.Object{
+			Key:          sdkaws.String(e.key),
+			Size:         sdkaws.Int64(int64(len(obj.Data))),
+			LastModified: &modified,
+			ETag:         sdkaws.String(obj.etag()),
+			StorageClass: s3types.ObjectStorageClass(obj.StorageClass),
+		})
+	}
+	output.KeyCount = sdkaws.Int32(int32(end - start))
+	if end < len(entries) {
+		output.NextContinuationToken = sdkaws.String(strconv.Itoa(end))
+	}
+	return output, nil
+}
+
+func (f *FakeS3) ListObjectVersions(ctx context.Context, params *s3.ListObjectVersionsInput, optFns ...func(*s3.Options)) (*s3.ListObjectVersionsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	b, err := f.bucket(params.Bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := getString(params.Prefix)
+	var keys []string
+	for key := range b.Objects {
+		if _, versioned := b.Versions[key]; strings.HasPrefix(key, prefix) && !versioned {
+			keys = append(keys, key)
+		}
+	}
+	for key := range b.Versions {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	output := &s3.ListObjectVersionsOutput{}
+	for _, key := range keys {
+		history := b.Versions[key]
+		if len(history) == 0 {
+			history = []*FakeS3Version{{VersionID: "null", Object: b.Objects[key], LastModified: b.Objects[key].LastModified}}
+		}
+		for i, version := range history {
+			modified := version.LastModified
+			if version.Object == nil {
+				output.DeleteMarkers = append(output.DeleteMarkers, s3types.DeleteMarkerEntry{
+					Key:          sdkaws.String(key),
+					VersionId:    sdkaws.String(version.VersionID),
+					IsLatest:     sdkaws.Bool(i == 0),
+					LastModified: &modified,
+				})
+				continue
+			}
+			output.Versions = append(output.Versions, s3types.ObjectVersion{
+				Key:          sdkaws.String(key),
+				VersionId:    sdkaws.String(version.VersionID),
+				IsLatest:     sdkaws.Bool(i == 0),
+				Size:         sdkaws.Int64(int64(len(version.Object.Data))),
+				LastModified: &modified,
+				ETag:         sdkaws.String(version.Object.etag()),
+				StorageClass: s3types.ObjectVersionStorageClass(version.Object.StorageClass),
+			})
+		}
+	}
+	return output, nil
+}
+
+func (f *FakeS3) HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	obj, err := f.objectVersion(params.Bucket, params.Key, getString(params.VersionId))
+	if err != nil {
+		var noSuchKey *s3types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, &s3types.NotFound{Message: noSuchKey.Message}
+		}
+		return nil, err
+	}
+
+	modified := obj.LastModified
+	output := &s3.HeadObjectOutput{
+		ContentLength: sdkaws.Int64(int64(len(obj.Data))),
+		ContentType:   nilIfEmpty(obj.ContentType),
+		ETag:          sdkaws.String(obj.etag()),
+		LastModified:  &modified,
+		Metadata:      obj.Metadata,
+		StorageClass:  obj.StorageClass,
+
+		ServerSideEncryption: obj.ServerSideEncryption,
+	}
+	if obj.RestoreOngoing {
+		output.Restore = sdkaws.String(`ongoing-request="true"`)
+	} else if !obj.RestoreExpiry.IsZero() {
+		output.Restore = sdkaws.String(fmt.Sprintf(`ongoing-request="false", expiry-date="%s"`, obj.RestoreExpiry.UTC().Format(http.TimeFormat)))
+	}
+	return output, nil
+}
+
+func (f *FakeS3) RestoreObject(ctx context.Context, params *s3.RestoreObjectInput, optFns ...func(*s3.Options)) (*s3.RestoreObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	obj, err := f.object(params.Bucket, params.Key)
+	if err != nil {
+		return nil, err
+	}
+	if obj.readable() && obj.RestoreExpiry.IsZero() {
+		return nil, &s3types.InvalidObjectState{Message: sdkaws.String("object is not archived")}
+	}
+	if obj.RestoreOngoing {
+		return nil, &smithy.GenericAPIError{Code: "RestoreAlreadyInProgress", Message: "restore already in progress"}
+	}
+	if params.RestoreRequest != nil && params.RestoreRequest.Days != nil {
+		obj.RestoreDays = *params.RestoreRequest.Days
+	}
+	if obj.RestoreExpiry.IsZero() {
+		obj.RestoreOngoing = true
+	} else {
+		// Restoring a restored object again only moves its expiry
+		obj.RestoreExpiry = time.Now().AddDate(0, 0, int(obj.RestoreDays))
+	}
+	return &s3.RestoreObjectOutput{}, nil
+}
+
+// CompleteRestore finishes the pending restore of an archived object, as S3
+// does hours after RestoreObject
+func (f *FakeS3) CompleteRestore(bucket, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	obj, err := f.object(&bucket, &key)
+	if err != nil {
+		return err
+	}
+	if !obj.RestoreOngoing {
+		return fmt.Errorf("no restore of %s in progress", key)
+	}
+	obj.RestoreOngoing = false
+	obj.RestoreExpiry = time.Now().AddDate(0, 0, int(obj.RestoreDays))
+	return nil
+}
+
+func (f *FakeS3) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	obj, err := f.objectVersion(params.Bucket, params.Key, getString(params.VersionId))
+	if err != nil {
+		return nil, err
+	}
+
+	if match := getString(params.IfMatch); match != "" && match != obj.etag() {
+		return nil, errors.New("PreconditionFailed: at least one of the pre-conditions you specified did not hold")
+	}
+	if !obj.readable() {
+		return nil, &s3types.InvalidObjectState{Message: sdkaws.String("the operation is not valid for the object's storage class"), StorageClass: obj.StorageClass}
+	}
+
+	data := obj.Data
+	total := int64(len(data))
+	output := &s3.GetObjectOutput{
+		ContentType:  nilIfEmpty(obj.ContentType),
+		ETag:         sdkaws.String(obj.etag()),
+		Metadata:     obj.Metadata,
+		StorageClass: obj.StorageClass,
+	}
+	modified := obj.LastModified
+	output.LastModified = &modified
+
+	// Support the "bytes=start-end" ranges issued by the transfer manager
+	if r := getString(params.Range); r != "" && total > 0 {
+		start, end, err := parseByteRange(r, total)
+		if err != nil {
+			return nil, err
+		}
+		data = data[start : end+1]
+		output.ContentRange = sdkaws.String(fmt.Sprintf("bytes %d-%d/%d", start, end, total))
+	}
+
+	output.ContentLength = sdkaws.Int64(int64(len(data)))
+	output.Body = io.NopCloser(bytes.NewReader(append([]byte(nil), data...)))
+	return output, nil
+}
+
+func (f *FakeS3) GetObjectTagging(ctx context.Context, params *s3.GetObjectTaggingInput, optFns ...func(*s3.Options)) (*s3.GetObjectTaggingOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	obj, err := f.objectVersion(params.Bucket, params.Key, getString(params.VersionId))
+	if err != nil {
+		return nil, err
+	}
+
+	output := &s3.GetObjectTaggingOutput{}
+	for k, v := range obj.Tags {
+		output.TagSet = append(output.TagSet, s3types.Tag{Key: sdkaws.String(k), Value: sdkaws.String(v)})
+	}
+	return output, nil
+}
+
+func (f *FakeS3) PutObjectTagging(ctx context.Context, params *s3.PutObjectTaggingInput, optFns ...func(*s3.Options)) (*s3.PutObjectTaggingOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	obj, err := f.object(params.Bucket, params.Key)
+	if err != nil {
+		return nil, err
+	}
+	if params.Tagging == nil || len(params.Tagging.TagSet) > 10 {
+		return nil, errors.New("BadRequest: object tags cannot be greater than 10")
+	}
+
+	obj.Tags = make(map[string]string)
+	for _, tag := range params.Tagging.TagSet {
+		obj.Tags[getString(tag.Key)] = getString(tag.Value)
+	}
+	return &s3.PutObjectTaggingOutput{}, nil
+}
+
+// fakeOwnerID is the canonical user owning every fake object
+const fakeOwnerID = "fake-owner"
+
+var errACLsDisabled = &smithy.GenericAPIError{Code: "AccessControlListNotSupported", Message: "The bucket does not allow ACLs"}
+
+func (f *FakeS3) GetObjectAcl(ctx context.Context, params *s3.GetObjectAclInput, optFns ...func(*s3.Options)) (*s3.GetObjectAclOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	obj, err := f.object(params.Bucket, params.Key)
+	if err != nil {
+		return nil, err
+	}
+	if f.Buckets[getString(params.Bucket)].ACLsDisabled {
+		return nil, errACLsDisabled
+	}
+
+	owner := &s3types.Owner{ID: sdkaws.String(fakeOwnerID)}
+	grant := func(uri string, permission s3types.Permission) s3types.Grant {
+		return s3types.Grant{Grantee: &s3types.Grantee{Type: s3types.TypeGroup, URI: sdkaws.String(uri)}, Permission: permission}
+	}
+	grants := []s3types.Grant{{
+		Grantee:    &s3types.Grantee{Type: s3types.TypeCanonicalUser, ID: owner.ID},
+		Permission: s3types.PermissionFullControl,
+	}}
+	switch s3types.ObjectCannedACL(obj.ACL) {
+	case s3types.ObjectCannedACLPublicRead:
+		grants = append(grants, grant(allUsersURI, s3types.PermissionRead))
+	case s3types.ObjectCannedACLPublicReadWrite:
+		grants = append(grants, grant(allUsersURI, s3types.PermissionRead), grant(allUsersURI, s3types.PermissionWrite))
+	case s3types.ObjectCannedACLAuthenticatedRead:
+		grants = append(grants, grant(authenticatedUsersURI, s3types.PermissionRead))
+	}
+	return &s3.GetObjectAclOutput{Owner: owner, Grants: grants}, nil
+}
+
+func (f *FakeS3) PutObjectAcl(ctx context.Context, params *s3.PutObjectAclInput, optFns ...func(*s3.Options)) (*s3.PutObjectAclOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	obj, err := f.object(params.Bucket, params.Key)
+	if err != nil {
+		return nil, err
+	}
+	if f.Buckets[getString(params.Bucket)].ACLsDisabled {
+		return nil, errACLsDisabled
+	}
+	if params.AccessControlPolicy != nil {
+		obj.ACL = cannedACL(&s3.GetObjectAclOutput{Owner: params.AccessControlPolicy.Owner, Grants: params.AccessControlPolicy.Grants})
+	} else {
+		obj.ACL = string(params.ACL)
+	}
+	if obj.ACL == string(s3types.ObjectCannedACLPrivate) {
+		obj.ACL = ""
+	}
+	return &s3.PutObjectAclOutput{}, nil
+}
+
+func (f *FakeS3) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	var data []byte
+	if params.Body != nil {
+		var err error
+		data, err = io.ReadAll(params.Body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	b, err := f.bucket(params.Bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	storageClass := params.StorageClass
+	if storageClass == "" {
+		storageClass = s3types.StorageClassStandard
+	}
+	f.put(b, getString(params.Key), &FakeS3Object{
+		Data:         data,
+		LastModified: time.Now(),
+		ContentType:  getString(params.ContentType),
+		StorageClass: storageClass,
+		Metadata:     params.Metadata,
+	})
+	return &s3.PutObjectOutput{ETag: sdkaws.String(fakeETag(data))}, nil
+}
+
+// copySource returns the object named by a CopySource, which may name a
+// version; callers must hold f.mu
+func (f *FakeS3) copySource(copySource *string) (*FakeS3Object, error) {
+	source, versionID, _ := strings.Cut(getString(copySource), "?versionId=")
+	if unescaped, err := url.PathUnescape(source); err == nil {
+		source = unescaped
+	}
+	source = strings.TrimPrefix(source, "/")
+	parts := strings.SplitN(source, "/", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid copy source: %s", getString(copySource))
+	}
+	if unescaped, err := url.QueryUnescape(versionID); err == nil {
+		versionID = unescaped
+	}
+	return f.objectVersion(&parts[0], &parts[1], versionID)
+}
+
+// checkRegion fails like S3 does when a request for a bucket is sent to
+// another region than the bucket's
+func checkRegion(b *FakeS3Bucket, optFns []func(*s3.Options)) error {
+	var opts s3.Options
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+	if opts.Region != "" && b.Region != "" && opts.Region != b.Region {
+		return fmt.Errorf("PermanentRedirect: the bucket is in %s, not %s", b.Region, opts.Region)
+	}
+	return nil
+}
+
+func (f *FakeS3) CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	src, err := f.copySource(params.CopySource)
+	if err != nil {
+		return nil, err
+	}
+	dest, err := f.bucket(params.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRegion(dest, optFns); err != nil {
+		return nil, err
+	}
+	if dest.Objects[getString(params.Key)] == src && params.StorageClass == "" && params.MetadataDirective != s3types.MetadataDirectiveReplace {
+		return nil, errors.New("InvalidRequest: this copy request is illegal because it is trying to copy an object to itself without changing the object's metadata or storage class")
+	}
+
+	copied := *src
+	copied.Data = append([]byte(nil), src.Data...)
+	copied.LastModified = time.Now()
+	copied.ETag = ""
+	copied.ACL = string(params.ACL)
+	if params.StorageClass != "" {
+		copied.StorageClass = params.StorageClass
+	}
+	if params.MetadataDirective == s3types.MetadataDirectiveReplace {
+		copied.Metadata = params.Metadata
+		copied.ContentType = getString(params.ContentType)
+	}
+	f.put(dest, getString(params.Key), &copied)
+	return &s3.CopyObjectOutput{}, nil
+}
+
+func (f *FakeS3) DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	b, err := f.bucket(params.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	key := getString(params.Key)
+	versionID := getString(params.VersionId)
+	if versionID == "" {
+		f.remove(b, key)
+		return &s3.DeleteObjectOutput{}, nil
+	}
+
+	// Deleting a version removes it for good, and the version before it
+	// becomes current
+	history := b.Versions[key]
+	if len(history) == 0 && versionID == "null" {
+		delete(b.Objects, key)
+		return &s3.DeleteObjectOutput{VersionId: params.VersionId}, nil
+	}
+	for i, version := range history {
+		if version.VersionID != versionID {
+			continue
+		}
+		history = append(history[:i:i], history[i+1:]...)
+		b.Versions[key] = history
+		if i == 0 {
+			if len(history) > 0 && history[0].Object != nil {
+				b.Objects[key] = history[0].Object
+			} else {
+				delete(b.Objects, key)
+			}
+		}
+		return &s3.DeleteObjectOutput{VersionId: params.VersionId, DeleteMarker: sdkaws.Bool(version.Object == nil)}, nil
+	}
+	return nil, fmt.Errorf("NoSuchVersion: %s has no version %s", key, versionID)
+}
+
+func (f *FakeS3) DeleteObjects(ctx context.Context, params *s3.DeleteObjectsInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	b, err := f.bucket(params.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	if params.Delete == nil || len(params.Delete.Objects) > 1000 {
+		return nil, errors.New("MalformedXML: a request may delete between 1 and 1000 objects")
+	}
+	f.DeleteBatches++
+
+	output := &s3.DeleteObjectsOutput{}
+	for _, obj := range params.Delete.Objects {
+		key := getString(obj.Key)
+		if code, ok := f.DeleteErrors[key]; ok {
+			output.Errors = append(output.Errors, s3types.Error{Key: obj.Key, Code: sdkaws.String(code), Message: sdkaws.String(code)})
+			continue
+		}
+		f.remove(b, key)
+		if params.Delete.Quiet == nil || !*params.Delete.Quiet {
+			output.Deleted = append(output.Deleted, s3types.DeletedObject{Key: obj.Key})
+		}
+	}
+	return output, nil
+}
+
+func (f *FakeS3) CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, err := f.bucket(params.Bucket); err != nil {
+		return nil, err
+	}
+
+	storageClass := params.StorageClass
+	if storageClass == "" {
+		storageClass = s3types.StorageClassStandard
+	}
+	var tags map[string]string
+	if tagging := getString(params.Tagging); tagging != "" {
+		values, err := url.ParseQuery(tagging)
+		if err != nil {
+			return nil, fmt.Errorf("InvalidArgument: %v", err)
+		}
+		tags = make(map[string]string)
+		for k := range values {
+			tags[k] = values.Get(k)
+		}
+	}
+
+	f.nextUpload++
+	uploadID := fmt.Sprintf("upload-%d", f.nextUpload)
+	f.uploads[uploadID] = &fakeMultipartUpload{
+		bucket: getString(params.Bucket),
+		key:    getString(params.Key),
+		parts:  make(map[int32][]byte),
+		object: FakeS3Object{
+			ContentType:  getString(params.ContentType),
+			StorageClass: storageClass,
+			Metadata:     params.Metadata,
+			Tags:         tags,
+		},
+	}
+	return &s3.CreateMultipartUploadOutput{
+		Bucket:   params.Bucket,
+		Key:      params.Key,
+		UploadId: sdkaws.String(uploadID),
+	}, nil
+}
+
+func (f *FakeS3) UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error) {
+	var data []byte
+	if params.Body != nil {
+		var err error
+		data, err = io.ReadAll(params.Body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	upload, ok := f.uploads[getString(params.UploadId)]
+	if !ok {
+		return nil, &s3types.NoSuchUpload{Message: params.UploadId}
+	}
+	upload.parts[sdkaws.ToInt32(params.PartNumber)] = data
+	f.PartUploads++
+	return &s3.UploadPartOutput{ETag: sdkaws.String(fakeETag(data))}, nil
+}
+
+func (f *FakeS3) UploadPartCopy(ctx context.Context, params *s3.UploadPartCopyInput, optFns ...func(*s3.Options)) (*s3.UploadPartCopyOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	src, err := f.copySource(params.CopySource)
+	if err != nil {
+		return nil, err
+	}
+	upload, ok := f.uploads[getString(params.UploadId)]
+	if !ok {
+		return nil, &s3types.NoSuchUpload{Message: params.UploadId}
+	}
+	if err := checkRegion(f.Buckets[upload.bucket], optFns); err != nil {
+		return nil, err
+	}
+
+	data := src.Data
+	if r := getString(params.CopySourceRange); r != "" {
+		var first, last int
+		if _, err := fmt.Sscanf(r, "bytes=%d-%d", &first, &last); err != nil || first > last || last >= len(data) {
+			return nil, fmt.Errorf("InvalidRange: %s", r)
+		}
+		data = data[first : last+1]
+	}
+	upload.parts[sdkaws.ToInt32(params.PartNumber)] = append([]byte(nil), data...)
+	return &s3.UploadPartCopyOutput{CopyPartResult: &s3types.CopyPartResult{ETag: sdkaws.String(fakeETag(data))}}, nil
+}
+
+func (f *FakeS3) CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	upload, ok := f.uploads[getString(params.UploadId)]
+	if !ok {
+		return nil, &s3types.NoSuchUpload{Message: params.UploadId}
+	}
+
+	var partNumbers []int32
+	if params.MultipartUpload != nil {
+		for _, part := range params.MultipartUpload.Parts {
+			partNumbers = append(partNumbers, sdkaws.ToInt32(part.PartNumber))
+		}
+	} else {
+		for n := range upload.parts {
+			partNumbers = append(partNumbers, n)
+		}
+	}
+	sort.Slice(partNumbers, func(i, j int) bool { return partNumbers[i] < partNumbers[j] })
+
+	var data []byte
+	var sums []byte
+	for _, n := range partNumbers {
+		part, ok := upload.parts[n]
+		if !ok {
+			return nil, fmt.Errorf("InvalidPart: %d", n)
+		}
+		data = append(data, part...)
+		sum := md5.Sum(part)
+		sums = append(sums, sum[:]...)
+	}
+	// Multipart ETags are the MD5 of the part MD5s and the part count
+	sum := md5.Sum(sums)
+	etag := fmt.Sprintf("\"%s-%d\"", hex.EncodeToString(sum[:]), len(partNumbers))
+
+	b, err := f.bucket(&upload.bucket)
+	if err != nil {
+		return nil, err
+	}
+	obj := upload.object
+	obj.Data = data
+	obj.LastModified = time.Now()
+	obj.ETag = etag
+	f.put(b, upload.key, &obj)
+	delete(f.uploads, getString(params.UploadId))
+	return &s3.CompleteMultipartUploadOutput{
+		Bucket: sdkaws.String(upload.bucket),
+		Key:    sdkaws.String(upload.key),
+		ETag:   sdkaws.String(etag),
+	}, nil
+}
+
+func (f *FakeS3) AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	delete(f.uploads, getString(params.UploadId))
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+func (f *FakeS3) ListParts(ctx context.Context, params *s3.ListPartsInput, optFns ...func(*s3.Options)) (*s3.ListPartsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	upload, ok := f.uploads[getString(params.UploadId)]
+	if !ok {
+		return nil, &s3types.NoSuchUpload{Message: params.UploadId}
+	}
+
+	output := &s3.ListPartsOutput{IsTruncated: sdkaws.Bool(false)}
+	for n, data := range upload.parts {
+		output.Parts = append(output.Parts, s3types.Part{
+			PartNumber: sdkaws.Int32(n),
+			Size:       sdkaws.Int64(int64(len(data))),
+			ETag:       sdkaws.String(fakeETag(data)),
+		})
+	}
+	sort.Slice(output.Parts, func(i, j int) bool { return *output.Parts[i].PartNumber < *output.Parts[j].PartNumber })
+	return output, nil
+}
+
+// FakeS3Presign is an S3PresignAPI that returns predictable, unsigned URLs
+type FakeS3Presign struct {
+	Region string
+}
+
+func (f *FakeS3Presign) PresignGetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.PresignOptions)) (*v4.PresignedHTTPRequest, error) {
+	return f.presign("GET", getString(params.Bucket), getString(params.Key), optFns), nil
+}
+
+func (f *FakeS3Presign) PresignPutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.PresignOptions)) (*v4.PresignedHTTPRequest, error) {
+	request := f.presign("PUT", getString(params.Bucket), getString(params.Key), optFns)
+	request.SignedHeader = http.Header{"Host": {getString(params.Bucket) + ".s3." + f.Region + ".amazonaws.com"}}
+	if params.ContentType != nil {
+		request.SignedHeader.Set("Content-Type", *params.ContentType)
+	}
+	return request, nil
+}
+
+// PresignPostObject returns the conditions as a readable JSON policy
+// rather than base64, with a fake signature
+func (f *FakeS3Presign) PresignPostObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.PresignPostOptions)) (*s3.PresignedPostRequest, error) {
+	var opts s3.PresignPostOptions
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+	policy, err := json.Marshal(map[string]interface{}{
+		"expiration": time.Now().Add(opts.Expires).UTC().Format(time.RFC3339),
+		"conditions": opts.Conditions,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3.PresignedPostRequest{
+		URL: fmt.Sprintf("https://%s.s3.%s.amazonaws.com", getString(params.Bucket), f.Region),
+		Values: map[string]string{
+			"key":              getString(params.Key),
+			"policy":           string(policy),
+			"x-amz-algorithm":  "AWS4-HMAC-SHA256",
+			"x-amz-credential": "AKIAFAKE/" + f.Region + "/s3/aws4_request",
+			"x-amz-signature":  "fake",
+		},
+	}, nil
+}
+
+func (f *FakeS3Presign) presign(method, bucket, key string, optFns []func(*s3.PresignOptions)) *v4.PresignedHTTPRequest {
+	var opts s3.PresignOptions
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+	return &v4.PresignedHTTPRequest{
+		URL: fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s?X-Amz-Expires=%d",
+			bucket, f.Region, key, int(opts.Expires.Seconds())),
+		Method: method,
+	}
+}
+
+// FakeEKS is an in-memory EKSAPI. Node groups and add-ons are keyed by
+// cluster name. Set PageSize to split list results across pages, and Err to
+// make every call fail.
+type FakeEKS struct {
+	mu         sync.Mutex
+	Clusters   []ekstypes.Cluster
+	Nodegroups map[string][]ekstypes.Nodegroup
+	Addons     map[string][]ekstypes.Addon
+	PageSize   int
+	Err        error
+}
+
+func (f *FakeEKS) ListClusters(ctx context.Context, params *eks.ListClustersInput, optFns ...func(*eks.Options)) (*eks.ListClustersOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	start, end, next, err := fakePage(len(f.Clusters), params.NextToken, sdkaws.ToInt32(params.MaxResults), f.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &eks.ListClustersOutput{NextToken: next}
+	for _, cluster := range f.Clusters[start:end] {
+		output.Clusters = append(output.Clusters, getString(cluster.Name))
+	}
+	return output, nil
+}
+
+func (f *FakeEKS) DescribeCluster(ctx context.Context, params *eks.DescribeClusterInput, optFns ...func(*eks.Options)) (*eks.DescribeClusterOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	for _, cluster := range f.Clusters {
+		if getString(cluster.Name) == getString(params.Name) {
+			c := cluster
+			return &eks.DescribeClusterOutput{Cluster: &c}, nil
+		}
+	}
+	return nil, &ekstypes.ResourceNotFoundException{Message: sdkaws.String("cluster not found: " + getString(params.Name))}
+}
+
+func (f *FakeEKS) ListNodegroups(ctx context.Context, params *eks.ListNodegroupsInput, optFns ...func(*eks.Options)) (*eks.ListNodegroupsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	nodegroups := f.Nodegroups[getString(params.ClusterName)]
+	start, end, next, err := fakePage(len(nodegroups), params.NextToken, sdkaws.ToInt32(params.MaxResults), f.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &eks.ListNodegroupsOutput{NextToken: next}
+	for _, ng := range nodegroups[start:end] {
+		output.Nodegroups = append(output.Nodegroups, getString(ng.NodegroupName))
+	}
+	return output, nil
+}
+
+func (f *FakeEKS) DescribeNodegroup(ctx context.Context, params *eks.DescribeNodegroupInput, optFns ...func(*eks.Options)) (*eks.DescribeNodegroupOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	for _, ng := range f.Nodegroups[getString(params.ClusterName)] {
+		if getString(ng.NodegroupName) == getString(params.NodegroupName) {
+			n := ng
+			return &eks.DescribeNodegroupOutput{Nodegroup: &n}, nil
+		}
+	}
+	return nil, &ekstypes.ResourceNotFoundException{Message: sdkaws.String("node group not found: " + getString(params.NodegroupName))}
+}
+
+func (f *FakeEKS) ListAddons(ctx context.Context, params *eks.ListAddonsInput, optFns ...func(*eks.Options)) (*eks.ListAddonsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	addons := f.Addons[getString(params.ClusterName)]
+	start, end, next, err := fakePage(len(addons), params.NextToken, sdkaws.ToInt32(params.MaxResults), f.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &eks.ListAddonsOutput{NextToken: next}
+	for _, addon := range addons[start:end] {
+		output.Addons = append(output.Addons, getString(addon.AddonName))
+	}
+	return output, nil
+}
+
+func (f *FakeEKS) DescribeAddon(ctx context.Context, params *eks.DescribeAddonInput, optFns ...func(*eks.Options)) (*eks.DescribeAddonOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	for _, addon := range f.Addons[getString(params.ClusterName)] {
+		if getString(addon.AddonName) == getString(params.AddonName) {
+			a := addon
+			return &eks.DescribeAddonOutput{Addon: &a}, nil
+		}
+	}
+	return nil, &ekstypes.ResourceNotFoundException{Message: sdkaws.String("add-on not found: " + getString(params.AddonName))}
+}
+
+// FakeSSM is an in-memory SSMAPI. StartSession hands out StreamURL, which
+// tests point at a local websocket server standing in for the agent. Set Err
+// to make every call fail.
+type FakeSSM struct {
+	mu                  sync.Mutex
+	InstanceInformation []ssmtypes.InstanceInformation
+	StreamURL           string
+	Sessions            []ssm.StartSessionInput
+	Terminated          []string
+	Err                 error
+}
+
+func (f *FakeSSM) StartSession(ctx context.Context, params *ssm.StartSessionInput, optFns ...func(*ssm.Options)) (*ssm.StartSessionOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	f.Sessions = append(f.Sessions, *params)
+	sessionID := fmt.Sprintf("lazyaws-%d", len(f.Sessions))
+	return &ssm.StartSessionOutput{
+		SessionId:  sdkaws.String(sessionID),
+		StreamUrl:  sdkaws.String(f.StreamURL),
+		TokenValue: sdkaws.String("token-" + sessionID),
+	}, nil
+}
+
+func (f *FakeSSM) TerminateSession(ctx context.Context, params *ssm.TerminateSessionInput, optFns ...func(*ssm.Options)) (*ssm.TerminateSessionOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	f.Terminated = append(f.Terminated, getString(params.SessionId))
+	return &ssm.TerminateSessionOutput{SessionId: params.SessionId}, nil
+}
+
+func (f *FakeSSM) DescribeInstanceInformation(ctx context.Context, params *ssm.DescribeInstanceInformationInput, optFns ...func(*ssm.Options)) (*ssm.DescribeInstanceInformationOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	var instanceIDs []string
+	for _, filter := range params.Filters {
+		if getString(filter.Key) == "InstanceIds" {
+			instanceIDs = append(instanceIDs, filter.Values...)
+		}
+	}
+
+	output := &ssm.DescribeInstanceInformationOutput{}
+	for _, info := range f.InstanceInformation {
+		if len(instanceIDs) > 0 && !containsString(instanceIDs, getString(info.InstanceId)) {
+			continue
+		}
+		output.InstanceInformationList = append(output.InstanceInformationList, info)
+	}
+	return output, nil
+}
+
+// FakeCloudWatch is an in-memory CloudWatchAPI. Datapoints are keyed by
+// metric name and filtered by the requested time range; percentile
+// statistics are read from ExtendedStatistics. Alarm history is keyed by
+// alarm name, newest first. Set Err to make every call fail.
+type FakeCloudWatch struct {
+	mu              sync.Mutex
+	Datapoints      map[string][]cwtypes.Datapoint
+	MetricAlarms    []cwtypes.MetricAlarm
+	CompositeAlarms []cwtypes.CompositeAlarm
+	AlarmHistory    map[string][]cwtypes.AlarmHistoryItem
+	Err             error
+
+	// PageSize splits GetMetricData results into pages of this many
+	// datapoints per query, and alarm listings into pages of this many
+	// alarms, when set
+	PageSize int
+	// MetricDataCalls counts GetMetricData requests, pages included
+	MetricDataCalls int
+}
+
+func (f *FakeCloudWatch) GetMetricData(ctx context.Context, params *cloudwatch.GetMetricDataInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.GetMetricDataOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.MetricDataCalls++
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	offset := 0
+	if params.NextToken != nil {
+		offset, _ = strconv.Atoi(*params.NextToken)
+	}
+
+	output := &cloudwatch.GetMetricDataOutput{}
+	more := false
+	for _, query := range params.MetricDataQueries {
+		if query.MetricStat == nil || query.MetricStat.Metric == nil {
+			continue
+		}
+		stat := getString(query.MetricStat.Stat)
+
+		var datapoints []cwtypes.Datapoint
+		for _, dp := range f.Datapoints[getString(query.MetricStat.Metric.MetricName)] {
+			if dp.Timestamp != nil {
+				if params.StartTime != nil && dp.Timestamp.Before(*params.StartTime) {
+					continue
+				}
+				if params.EndTime != nil && !dp.Timestamp.Before(*params.EndTime) {
+					continue
+				}
+			}
+			datapoints = append(datapoints, dp)
+		}
+		sort.Slice(datapoints, func(i, j int) bool {
+			return getTime(datapoints[i].Timestamp).Before(getTime(datapoints[j].Timestamp))
+		})
+
+		result := cwtypes.MetricDataResult{Id: query.Id, StatusCode: cwtypes.StatusCodeComplete}
+		for _, dp := range datapoints {
+			var value *float64
+			switch stat {
+			case "Average":
+				value = dp.Average
+			case "Sum":
+				value = dp.Sum
+			case "Minimum":
+				value = dp.Minimum
+			case "Maximum":
+				value = dp.Maximum
+			case "SampleCount":
+				value = dp.SampleCount
+			default:
+				if v, ok := dp.ExtendedStatistics[stat]; ok {
+					value = &v
+				}
+			}
+			if value != nil {
+				result.Timestamps = append(result.Timestamps, getTime(dp.Timestamp))
+				result.Values = append(result.Values, *value)
+			}
+		}
+
+		if f.PageSize > 0 {
+			end := offset + f.PageSize
+			if end < len(result.Values) {
+				more = true
+			} else {
+				end = len(result.Values)
+			}
+			start := offset
+			if start > end {
+				start = end
+			}
+			result.Timestamps = result.Timestamps[start:end]
+			result.Values = result.Values[start:end]
+		}
+		output.MetricDataResults = append(output.MetricDataResults, result)
+	}
+
+	if more {
+		output.NextToken = sdkaws.String(strconv.Itoa(offset + f.PageSize))
+	}
+	return output, nil
+}
+
+func (f *FakeCloudWatch) DescribeAlarms(ctx context.Context, params *cloudwatch.DescribeAlarmsInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.DescribeAlarmsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	// Metric alarms are paged first, then composite alarms
+	total := len(f.MetricAlarms) + len(f.CompositeAlarms)
+	start, end, next, err := fakePage(total, params.NextToken, sdkaws.ToInt32(params.MaxRecords), f.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &cloudwatch.DescribeAlarmsOutput{NextToken: next}
+	for i := start; i < end; i++ {
+		if i < len(f.MetricAlarms) {
+			output.MetricAlarms = append(output.MetricAlarms, f.MetricAlarms[i])
+		} else {
+			output.CompositeAlarms = append(output.CompositeAlarms, f.CompositeAlarms[i-len(f.MetricAlarms)])
+		}
+	}
+	return output, nil
+}
+
+func (f *FakeCloudWatch) DescribeAlarmHistory(ctx context.Context, params *cloudwatch.DescribeAlarmHistoryInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.DescribeAlarmHistoryOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	var items []cwtypes.AlarmHistoryItem
+	for _, item := range f.AlarmHistory[getString(params.AlarmName)] {
+		if params.HistoryItemType != "" && item.HistoryItemType != params.HistoryItemType {
+			continue
+		}
+		items = append(items, item)
+	}
+
+	start, end, next, err := fakePage(len(items), params.NextToken, sdkaws.ToInt32(params.MaxRecords), f.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &cloudwatch.DescribeAlarmHistoryOutput{AlarmHistoryItems: items[start:end], NextToken: next}, nil
+}
+
+func (f *FakeCloudWatch) EnableAlarmActions(ctx context.Context, params *cloudwatch.EnableAlarmActionsInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.EnableAlarmActionsOutput, error) {
+	return &cloudwatch.EnableAlarmActionsOutput{}, f.setActionsEnabled(params.AlarmNames, true)
+}
+
+func (f *FakeCloudWatch) DisableAlarmActions(ctx context.Context, params *cloudwatch.DisableAlarmActionsInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.DisableAlarmActionsOutput, error) {
+	return &cloudwatch.DisableAlarmActionsOutput{}, f.setActionsEnabled(params.AlarmNames, false)
+}
+
+func (f *FakeCloudWatch) setActionsEnabled(names []string, enabled bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return f.Err
+	}
+
+	for i := range f.MetricAlarms {
+		if containsString(names, getString(f.MetricAlarms[i].AlarmName)) {
+			f.MetricAlarms[i].ActionsEnabled = sdkaws.Bool(enabled)
+		}
+	}
+	for i := range f.CompositeAlarms {
+		if containsString(names, getString(f.CompositeAlarms[i].AlarmName)) {
+			f.CompositeAlarms[i].ActionsEnabled = sdkaws.Bool(enabled)
+		}
+	}
+	return nil
+}
+
+// SetAlarmState updates the alarm and records the transition in its history
+func (f *FakeCloudWatch) SetAlarmState(ctx context.Context, params *cloudwatch.SetAlarmStateInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.SetAlarmStateOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	name := getString(params.AlarmName)
+	now := time.Now()
+	var oldState cwtypes.StateValue
+	found := false
+	for i := range f.MetricAlarms {
+		if getString(f.MetricAlarms[i].AlarmName) == name {
+			oldState = f.MetricAlarms[i].StateValue
+			f.MetricAlarms[i].StateValue = params.StateValue
+			f.MetricAlarms[i].StateReason = params.StateReason
+			f.MetricAlarms[i].StateUpdatedTimestamp = &now
+			found = true
+		}
+	}
+	for i := range f.CompositeAlarms {
+		if getString(f.CompositeAlarms[i].AlarmName) == name {
+			oldState = f.CompositeAlarms[i].StateValue
+			f.CompositeAlarms[i].StateValue = params.StateValue
+			f.CompositeAlarms[i].StateReason = params.StateReason
+			f.CompositeAlarms[i].StateUpdatedTimestamp = &now
+			found = true
+		}
+	}
+	if !found {
+		return nil, &cwtypes.ResourceNotFound{Message: sdkaws.String("alarm not found: " + name)}
+	}
+
+	data := fmt.Sprintf(`{"oldState":{"stateValue":%q},"newState":{"stateValue":%q,"stateReason":%q}}`,
+		oldState, params.StateValue, getString(params.StateReason))
+	item := cwtypes.AlarmHistoryItem{
+		AlarmName:       params.AlarmName,
+		HistoryItemType: cwtypes.HistoryItemTypeStateUpdate,
+		HistorySummary:  sdkaws.String(fmt.Sprintf("Alarm updated from %s to %s", oldState, params.StateValue)),
+		HistoryData:     &data,
+		Timestamp:       &now,
+	}
+	if f.AlarmHistory == nil {
+		f.AlarmHistory = make(map[string][]cwtypes.AlarmHistoryItem)
+	}
+	f.AlarmHistory[name] = append([]cwtypes.AlarmHistoryItem{item}, f.AlarmHistory[name]...)
+	return &cloudwatch.SetAlarmStateOutput{}, nil
+}
+
+// FakeLogs is an in-memory LogsAPI. Streams and events are keyed by log
+// group name. Every Insights query returns QueryResults. Set PageSize to
+// split listings and events across pages, and Err to make every call fail.
+type FakeLogs struct {
+	mu               sync.Mutex
+	Groups           []logstypes.LogGroup
+	Streams          map[string][]logstypes.LogStream
+	Events           map[string][]logstypes.FilteredLogEvent
+	QueryResults     [][]logstypes.ResultField
+	QueryDefinitions []logstypes.QueryDefinition
+	PageSize         int
+	Err              error
+
+	// Queries records the query string of every started query by ID
+	Queries map[string]string
+	// StoppedQueries lists the IDs of stopped queries
+	StoppedQueries []string
+}
+
+// AddEvent appends an event to a stream, creating the group and stream
+// if needed
+func (f *FakeLogs) AddEvent(group, stream string, timestamp time.Time, message string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Streams == nil {
+		f.Streams = make(map[string][]logstypes.LogStream)
+		f.Events = make(map[string][]logstypes.FilteredLogEvent)
+	}
+	found := false
+	for _, g := range f.Groups {
+		found = found || getString(g.LogGroupName) == group
+	}
+	if !found {
+		f.Groups = append(f.Groups, logstypes.LogGroup{LogGroupName: sdkaws.String(group)})
+	}
+
+	ms := timestamp.UnixMilli()
+	found = false
+	for i := range f.Streams[group] {
+		if getString(f.Streams[group][i].LogStreamName) == stream {
+			f.Streams[group][i].LastEventTimestamp = sdkaws.Int64(ms)
+			found = true
+		}
+	}
+	if !found {
+		f.Streams[group] = append(f.Streams[group], logstypes.LogStream{
+			LogStreamName:       sdkaws.String(stream),
+			FirstEventTimestamp: sdkaws.Int64(ms),
+			LastEventTimestamp:  sdkaws.Int64(ms),
+		})
+	}
+
+	f.Events[group] = append(f.Events[group], logstypes.FilteredLogEvent{
+		EventId:       sdkaws.String(fmt.Sprintf("%s-%d", stream, len(f.Events[group]))),
+		LogStreamName: sdkaws.String(stream),
+		Timestamp:     sdkaws.Int64(ms),
+		Message:       sdkaws.String(message),
+	})
+}
+
+func (f *FakeLogs) DescribeLogGroups(ctx context.Context, params *cloudwatchlogs.DescribeLogGroupsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogGroupsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	var groups []logstypes.LogGroup
+	for _, group := range f.Groups {
+		if strings.HasPrefix(getString(group.LogGroupName), getString(params.LogGroupNamePrefix)) {
+			groups = append(groups, group)
+		}
+	}
+
+	start, end, next, err := fakePage(len(groups), params.NextToken, sdkaws.ToInt32(params.Limit), f.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &cloudwatchlogs.DescribeLogGroupsOutput{LogGroups: groups[start:end], NextToken: next}, nil
+}
+
+func (f *FakeLogs) DescribeLogStreams(ctx context.Context, params *cloudwatchlogs.DescribeLogStreamsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogStreamsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	streams := append([]logstypes.LogStream(nil), f.Streams[getString(params.LogGroupName)]...)
+	if params.OrderBy == logstypes.OrderByLastEventTime {
+		sort.SliceStable(streams, func(i, j int) bool {
+			if sdkaws.ToBool(params.Descending) {
+				return sdkaws.ToInt64(streams[i].LastEventTimestamp) > sdkaws.ToInt64(streams[j].LastEventTimestamp)
+			}
+			return sdkaws.ToInt64(streams[i].LastEventTimestamp) < sdkaws.ToInt64(streams[j].LastEventTimestamp)
+		})
+	}
+
+	start, end, next, err := fakePage(len(streams), params.NextToken, sdkaws.ToInt32(params.Limit), f.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &cloudwatchlogs.DescribeLogStreamsOutput{LogStreams: streams[start:end], NextToken: next}, nil
+}
+
+// FilterLogEvents matches events by time range and stream. A filter pattern
+// matches events containing every one of its space-separated terms.
+func (f *FakeLogs) FilterLogEvents(ctx context.Context, params *cloudwatchlogs.FilterLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.FilterLogEventsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	terms := strings.Fields(strings.Trim(getString(params.FilterPattern), `"`))
+	var events []logstypes.FilteredLogEvent
+	for _, event := range f.Events[getString(params.LogGroupName)] {
+		ts := sdkaws.ToInt64(event.Timestamp)
+		if params.StartTime != nil && ts < *params.StartTime {
+			continue
+		}
+		if params.EndTime != nil && ts > *params.EndTime {
+			continue
+		}
+		stream := getString(event.LogStreamName)
+		if len(params.LogStreamNames) > 0 && !containsString(params.LogStreamNames, stream) {
+			continue
+		}
+		if !strings.HasPrefix(stream, getString(params.LogStreamNamePrefix)) {
+			continue
+		}
+		matched := true
+		for _, term := range terms {
+			matched = matched && strings.Contains(getString(event.Message), term)
+		}
+		if matched {
+			events = append(events, event)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return sdkaws.ToInt64(events[i].Timestamp) < sdkaws.ToInt64(events[j].Timestamp)
+	})
+
+	start, end, next, err := fakePage(len(events), params.NextToken, sdkaws.ToInt32(params.Limit), f.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &cloudwatchlogs.FilterLogEventsOutput{Events: events[start:end], NextToken: next}, nil
+}
+
+func (f *FakeLogs) StartQuery(ctx context.Context, params *cloudwatchlogs.StartQueryInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.StartQueryOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	if f.Queries == nil {
+		f.Queries = make(map[string]string)
+	}
+	id := fmt.Sprintf("query-%d", len(f.Queries)+1)
+	f.Queries[id] = getString(params.QueryString)
+	return &cloudwatchlogs.StartQueryOutput{QueryId: &id}, nil
+}
+
+func (f *FakeLogs) GetQueryResults(ctx context.Context, params *cloudwatchlogs.GetQueryResultsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.GetQueryResultsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	id := getString(params.QueryId)
+	if _, ok := f.Queries[id]; !ok {
+		return nil, &logstypes.ResourceNotFoundException{Message: sdkaws.String("query not found: " + id)}
+	}
+	status := logstypes.QueryStatusComplete
+	if containsString(f.StoppedQueries, id) {
+		status = logstypes.QueryStatusCancelled
+	}
+	return &cloudwatchlogs.GetQueryResultsOutput{
+		Results: f.QueryResults,
+		Status:  status,
+		Statistics: &logstypes.QueryStatistics{
+			RecordsMatched: float64(len(f.QueryResults)),
+			RecordsScanned: float64(len(f.QueryResults)),
+		},
+	}, nil
+}
+
+func (f *FakeLogs) StopQuery(ctx context.Context, params *cloudwatchlogs.StopQueryInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.StopQueryOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	f.StoppedQueries = append(f.StoppedQueries, getString(params.QueryId))
+	return &cloudwatchlogs.StopQueryOutput{Success: true}, nil
+}
+
+func (f *FakeLogs) DescribeQueryDefinitions(ctx context.Context, params *cloudwatchlogs.DescribeQueryDefinitionsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeQueryDefinitionsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	start, end, next, err := fakePage(len(f.QueryDefinitions), params.NextToken, sdkaws.ToInt32(params.MaxResults), f.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &cloudwatchlogs.DescribeQueryDefinitionsOutput{QueryDefinitions: f.QueryDefinitions[start:end], NextToken: next}, nil
+}
+
+// PutQueryDefinition updates the definition with the given ID, or adds a new
+// one when no ID is set
+func (f *FakeLogs) PutQueryDefinition(ctx context.Context, params *cloudwatchlogs.PutQueryDefinitionInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutQueryDefinitionOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	def := logstypes.QueryDefinition{
+		QueryDefinitionId: params.QueryDefinitionId,
+		Name:              params.Name,
+		QueryString:       params.QueryString,
+		LogGroupNames:     params.LogGroupNames,
+	}
+	if def.QueryDefinitionId == nil {
+		def.QueryDefinitionId = sdkaws.String(fmt.Sprintf("query-def-%d", len(f.QueryDefinitions)+1))
+		f.QueryDefinitions = append(f.QueryDefinitions, def)
+		return &cloudwatchlogs.PutQueryDefinitionOutput{QueryDefinitionId: def.QueryDefinitionId}, nil
+	}
+
+	for i := range f.QueryDefinitions {
+		if getString(f.QueryDefinitions[i].QueryDefinitionId) == getString(def.QueryDefinitionId) {
+			f.QueryDefinitions[i] = def
+			return &cloudwatchlogs.PutQueryDefinitionOutput{QueryDefinitionId: def.QueryDefinitionId}, nil
+		}
+	}
+	return nil, &logstypes.ResourceNotFoundException{Message: sdkaws.String("query definition not found: " + getString(def.QueryDefinitionId))}
+}
+
+// FakeSTS is an in-memory STSAPI
+type FakeSTS struct {
+	Account string
+	Err     error
+}
+
+func (f *FakeSTS) GetCallerIdentity(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	return &sts.GetCallerIdentityOutput{
+		Account: sdkaws.String(f.Account),
+		Arn:     sdkaws.String(fmt.Sprintf("arn:aws:iam::%s:user/lazyaws", f.Account)),
+		UserId:  sdkaws.String("AIDAFAKEUSER"),
+	}, nil
+}
+
+// newFakeSTSPresign returns a real STS presign client with fixed
+// credentials. Presigning is done locally, so it never reaches AWS.
+func newFakeSTSPresign(region string) *sts.PresignClient {
+	return sts.NewPresignClient(sts.New(sts.Options{
+		Region:      region,
+		Credentials: credentials.NewStaticCredentialsProvider("AKIAFAKEACCESSKEY", "fake-secret-key", ""),
+	}))
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// getTime dereferences a timestamp, returning the zero time for nil
+func getTime(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+// nilIfEmpty returns nil for an empty string, otherwise a pointer to it
+func nilIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// fakePage works out the [start, end) window of a paginated listing of total
+// items. Tokens are plain offsets; the page size is the smaller non-zero value
+// of the request's MaxResults and the fake's own page size.
+func fakePage(total int, token *string, maxResults int32, pageSize int) (int, int, *string, error) {
+	start := 0
+	if token != nil {
+		n, err := strconv.Atoi(*token)
+		if err != nil || n < 0 || n > total {
+			return 0, 0, nil, fmt.Errorf("invalid pagination token: %s", *token)
+		}
+		start = n
+	}
+
+	size := pageSize
+	if maxResults > 0 && (size <= 0 || int(maxResults) < size) {
+		size = int(maxResults)
+	}
+
+	end := total
+	if size > 0 && start+size < total {
+		end = start + size
+	}
+
+	var next *string
+	if end < total {
+		next = sdkaws.String(strconv.Itoa(end))
+	}
+	return start, end, next, nil
+}
+
+// fakeETag returns the ETag S3 gives data uploaded in a single part
+func fakeETag(data []byte) string {
+	sum := md5.Sum(data)
+	return "\"" + hex.EncodeToString(sum[:]) + "\""
+}
+
+// parseByteRange parses an HTTP "bytes=start-end" range against a total size
+func parseByteRange(r string, total int64) (int64, int64, error) {
+	spec := strings.TrimPrefix(r, "bytes=")
+	parts := strings.SplitN(spec, "-", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid range: %s", r)
+	}
+	start, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid range: %s", r)
+	}
+	end := total - 1
+	if parts[1] != "" {
+		end, err = strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid range: %s", r)
+		}
+	}
+	if end >= total {
+		end = total - 1
+	}
+	if start > end {
+		return 0, 0, fmt.Errorf("InvalidRange: %s", r)
+	}
+	return start, end, nil
+}
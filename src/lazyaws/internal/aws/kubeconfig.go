@@ -0,0 +1,299 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fuziontech/lazyaws/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// execCredentialAPIVersion is the client.authentication.k8s.io version used
+// by the exec plugins written into kubeconfig users
+const execCredentialAPIVersion = "client.authentication.k8s.io/v1beta1"
+
+// KubeconfigOptions controls how UpdateKubeconfig writes a cluster entry
+type KubeconfigOptions struct {
+	// Path is the kubeconfig file to update; a leading ~/ is expanded. Empty
+	// means the first entry of $KUBECONFIG, or ~/.kube/config.
+	Path string
+	// ContextAlias names the context. Empty means the cluster ARN.
+	ContextAlias string
+	// ExecPlugin is the credential plugin the user entry runs, one of the
+	// config.KubeExecPlugin values. Empty means lazyaws itself.
+	ExecPlugin string
+	// Profile is passed to the plugin as AWS_PROFILE when set
+	Profile string
+	// Region is the cluster's region. Empty means the client's region.
+	Region string
+}
+
+// kubeconfig is the subset of a kubeconfig file lazyaws edits. Fields it
+// doesn't know about are kept in the inline maps so merging never drops them.
+type kubeconfig struct {
+	APIVersion     string                 `yaml:"apiVersion"`
+	Kind           string                 `yaml:"kind"`
+	Preferences    map[string]interface{} `yaml:"preferences"`
+	Clusters       []kubeconfigEntry      `yaml:"clusters"`
+	Contexts       []kubeconfigEntry      `yaml:"contexts"`
+	Users          []kubeconfigEntry      `yaml:"users"`
+	CurrentContext string                 `yaml:"current-context"`
+	Extra          map[string]interface{} `yaml:",inline"`
+}
+
+// kubeconfigEntry is a named cluster, context or user
+type kubeconfigEntry struct {
+	Name   string                 `yaml:"name"`
+	Fields map[string]interface{} `yaml:",inline"`
+}
+
+// DefaultKubeconfigPath returns the kubeconfig kubectl uses by default: the
+// first file in $KUBECONFIG, or ~/.kube/config
+func DefaultKubeconfigPath() (string, error) {
+	if env := os.Getenv("KUBECONFIG"); env != "" {
+		for _, path := range filepath.SplitList(env) {
+			if path != "" {
+				return path, nil
+			}
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".kube", "config"), nil
+}
+
+// UpdateKubeconfig writes the cluster, context and user entries for an EKS
+// cluster into a kubeconfig file, replacing entries with the same names and
+// keeping everything else. It makes the new context current and returns its
+// name.
+func (c *Client) UpdateKubeconfig(ctx context.Context, clusterName string, opts KubeconfigOptions) (string, error) {
+	if opts.Region == "" {
+		opts.Region = c.Region
+	}
+	if opts.ExecPlugin == "" {
+		opts.ExecPlugin = config.KubeExecPluginLazyAWS
+	}
+	if opts.Path == "" {
+		path, err := DefaultKubeconfigPath()
+		if err != nil {
+			return "", err
+		}
+		opts.Path = path
+	} else if strings.HasPrefix(opts.Path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		opts.Path = filepath.Join(home, opts.Path[2:])
+	}
+
+	details, err := c.GetEKSClusterDetails(ctx, clusterName)
+	if err != nil {
+		return "", err
+	}
+	if details.Endpoint == "" || details.CertificateAuthority == "" {
+		return "", fmt.Errorf("cluster %s has no endpoint or certificate authority yet (status %s)", clusterName, details.Status)
+	}
+
+	user, err := kubeconfigUser(clusterName, opts)
+	if err != nil {
+		return "", err
+	}
+
+	kc, mode, err := readKubeconfig(opts.Path)
+	if err != nil {
+		return "", err
+	}
+
+	entryName := details.Arn
+	if entryName == "" {
+		entryName = clusterName
+	}
+	contextName := opts.ContextAlias
+	if contextName == "" {
+		contextName = entryName
+	}
+
+	kc.Clusters = upsertKubeconfigEntry(kc.Clusters, kubeconfigEntry{
+		Name: entryName,
+		Fields: map[string]interface{}{
+			"cluster": map[string]interface{}{
+				"server":                     details.Endpoint,
+				"certificate-authority-data": details.CertificateAuthority,
+			},
+		},
+	})
+	kc.Users = upsertKubeconfigEntry(kc.Users, kubeconfigEntry{
+		Name:   entryName,
+		Fields: map[string]interface{}{"user": user},
+	})
+	kc.Contexts = upsertKubeconfigEntry(kc.Contexts, kubeconfigEntry{
+		Name: contextName,
+		Fields: map[string]interface{}{
+			"context": map[string]interface{}{
+				"cluster": entryName,
+				"user":    entryName,
+			},
+		},
+	})
+	kc.CurrentContext = contextName
+
+	if err := writeKubeconfig(opts.Path, kc, mode); err != nil {
+		return "", err
+	}
+	return contextName, nil
+}
+
+// kubeconfigUser builds the user entry that runs the exec credential plugin
+func kubeconfigUser(clusterName string, opts KubeconfigOptions) (map[string]interface{}, error) {
+	var command string
+	var args []string
+	switch opts.ExecPlugin {
+	case config.KubeExecPluginLazyAWS:
+		command = lazyawsExecutable()
+		args = []string{"eks", "token", "--cluster", clusterName, "--region", opts.Region}
+	case config.KubeExecPluginAWS:
+		command = "aws"
+		args = []string{"--region", opts.Region, "eks", "get-token", "--cluster-name", clusterName, "--output", "json"}
+	case config.KubeExecPluginIAMAuthenticator:
+		command = "aws-iam-authenticator"
+		args = []string{"token", "-i", clusterName}
+	default:
+		return nil, fmt.Errorf("unknown exec plugin %q", opts.ExecPlugin)
+	}
+
+	exec := map[string]interface{}{
+		"apiVersion":      execCredentialAPIVersion,
+		"command":         command,
+		"args":            args,
+		"interactiveMode": "IfAvailable",
+	}
+	if opts.Profile != "" {
+		exec["env"] = []map[string]interface{}{
+			{"name": "AWS_PROFILE", "value": opts.Profile},
+		}
+	}
+	return map[string]interface{}{"exec": exec}, nil
+}
+
+// lazyawsExecutable returns the absolute path of the running lazyaws binary,
+// so kubectl finds it even when it isn't on PATH
+func lazyawsExecutable() string {
+	path, err := os.Executable()
+	if err != nil {
+		return "lazyaws"
+	}
+	return path
+}
+
+// upsertKubeconfigEntry replaces the entry with the same name, or appends it
+func upsertKubeconfigEntry(entries []kubeconfigEntry, entry kubeconfigEntry) []kubeconfigEntry {
+	for i := range entries {
+		if entries[i].Name == entry.Name {
+			entries[i] = entry
+			return entries
+		}
+	}
+	return append(entries, entry)
+}
+
+// readKubeconfig parses the kubeconfig at path and returns its file mode. A
+// missing or empty file yields an empty config; a file that can't be parsed
+// is an error so it is never overwritten.
+func readKubeconfig(path string) (*kubeconfig, fs.FileMode, error) {
+	kc := &kubeconfig{APIVersion: "v1", Kind: "Config"}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return kc, 0600, nil
+		}
+		return nil, 0, fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := yaml.Unmarshal(data, kc); err != nil {
+			return nil, 0, fmt.Errorf("refusing to update %s, it is not a valid kubeconfig: %w", path, err)
+		}
+	}
+	if kc.APIVersion == "" {
+		kc.APIVersion = "v1"
+	}
+	if kc.Kind == "" {
+		kc.Kind = "Config"
+	}
+	return kc, info.Mode().Perm(), nil
+}
+
+// writeKubeconfig writes kc to a temporary file next to path and renames it
+// into place, so readers never see a partially written config
+func writeKubeconfig(path string, kc *kubeconfig, mode fs.FileMode) error {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(kc); err != nil {
+		return fmt.Errorf("failed to encode kubeconfig: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("failed to encode kubeconfig: %w", err)
+	}
+
+	// Replace the file a symlinked kubeconfig points to, not the link
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create kubeconfig directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write kubeconfig: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write kubeconfig: %w", err)
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write kubeconfig: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write kubeconfig: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to write kubeconfig: %w", err)
+	}
+	return nil
+}
+
+// KubeconfigOptionsFromConfig returns the kubeconfig path, exec plugin and
+// context alias configured in settings for a cluster
+func KubeconfigOptionsFromConfig(cfg *config.Config, clusterName string) KubeconfigOptions {
+	if cfg == nil {
+		return KubeconfigOptions{}
+	}
+	return KubeconfigOptions{
+		Path:         cfg.Kubeconfig,
+		ContextAlias: strings.TrimSpace(cfg.KubeContextAliases[clusterName]),
+		ExecPlugin:   cfg.KubeExecPlugin,
+	}
+}
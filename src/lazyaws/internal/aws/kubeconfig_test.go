@@ -0,0 +1,170 @@
+package aws
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	sdkaws "github.com/aws/aws-sdk-go-v2/aws"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"gopkg.in/yaml.v3"
+
+	"github.com/fuziontech/lazyaws/internal/config"
+)
+
+const existingKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: minikube
+  cluster:
+    server: https://192.168.49.2:8443
+    insecure-skip-tls-verify: true
+contexts:
+- name: minikube
+  context:
+    cluster: minikube
+    user: minikube
+    namespace: dev
+users:
+- name: minikube
+  user:
+    token: secret
+current-context: minikube
+x-custom: keep-me
+`
+
+func newKubeconfigBackend() *FakeBackend {
+	backend := NewFakeBackend()
+	backend.EKS.Clusters = []ekstypes.Cluster{
+		{
+			Name:                 sdkaws.String("prod"),
+			Arn:                  sdkaws.String("arn:aws:eks:us-east-1:123456789012:cluster/prod"),
+			Endpoint:             sdkaws.String("https://prod.eks.amazonaws.com"),
+			CertificateAuthority: &ekstypes.Certificate{Data: sdkaws.String("Q0EtREFUQQ==")},
+			Status:               ekstypes.ClusterStatusActive,
+		},
+	}
+	return backend
+}
+
+func readKubeconfigFile(t *testing.T, path string) map[string]interface{} {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read kubeconfig: %v", err)
+	}
+	var kc map[string]interface{}
+	if err := yaml.Unmarshal(data, &kc); err != nil {
+		t.Fatalf("Failed to parse kubeconfig: %v", err)
+	}
+	return kc
+}
+
+func TestUpdateKubeconfigMergesIntoExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(existingKubeconfig), 0640); err != nil {
+		t.Fatalf("Failed to write kubeconfig: %v", err)
+	}
+	client := newKubeconfigBackend().Client("us-east-1")
+
+	contextName, err := client.UpdateKubeconfig(context.Background(), "prod", KubeconfigOptions{
+		Path:         path,
+		ContextAlias: "prod-admin",
+		Profile:      "work",
+	})
+	if err != nil {
+		t.Fatalf("UpdateKubeconfig returned error: %v", err)
+	}
+	if contextName != "prod-admin" {
+		t.Errorf("Expected context 'prod-admin', got '%s'", contextName)
+	}
+
+	kc := readKubeconfigFile(t, path)
+	if kc["current-context"] != "prod-admin" {
+		t.Errorf("Expected current-context 'prod-admin', got %v", kc["current-context"])
+	}
+	if kc["x-custom"] != "keep-me" {
+		t.Errorf("Expected unknown top-level field to be kept, got %v", kc["x-custom"])
+	}
+	for _, section := range []string{"clusters", "contexts", "users"} {
+		if entries := kc[section].([]interface{}); len(entries) != 2 {
+			t.Errorf("Expected 2 %s, got %d", section, len(entries))
+		}
+	}
+
+	minikube := kc["contexts"].([]interface{})[0].(map[string]interface{})["context"].(map[string]interface{})
+	if minikube["namespace"] != "dev" {
+		t.Errorf("Expected existing context fields to be kept, got %v", minikube)
+	}
+
+	user := kc["users"].([]interface{})[1].(map[string]interface{})
+	exec := user["user"].(map[string]interface{})["exec"].(map[string]interface{})
+	// The default plugin is lazyaws itself
+	if args := exec["args"].([]interface{}); args[0] != "eks" || args[1] != "token" {
+		t.Errorf("Expected exec args 'eks token ...', got %v", args)
+	}
+	if !strings.Contains(string(mustMarshal(t, exec["env"])), "work") {
+		t.Errorf("Expected AWS_PROFILE=work in exec env, got %v", exec["env"])
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat kubeconfig: %v", err)
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("Expected file mode 0640 to be kept, got %o", info.Mode().Perm())
+	}
+}
+
+func TestUpdateKubeconfigReplacesExistingEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".kube", "config")
+	client := newKubeconfigBackend().Client("us-east-1")
+
+	for _, plugin := range []string{config.KubeExecPluginAWS, config.KubeExecPluginIAMAuthenticator} {
+		if _, err := client.UpdateKubeconfig(context.Background(), "prod", KubeconfigOptions{Path: path, ExecPlugin: plugin}); err != nil {
+			t.Fatalf("UpdateKubeconfig returned error: %v", err)
+		}
+	}
+
+	kc := readKubeconfigFile(t, path)
+	users := kc["users"].([]interface{})
+	if len(users) != 1 {
+		t.Fatalf("Expected 1 user after updating twice, got %d", len(users))
+	}
+	exec := users[0].(map[string]interface{})["user"].(map[string]interface{})["exec"].(map[string]interface{})
+	if exec["command"] != "aws-iam-authenticator" {
+		t.Errorf("Expected exec command 'aws-iam-authenticator', got %v", exec["command"])
+	}
+	if kc["current-context"] != "arn:aws:eks:us-east-1:123456789012:cluster/prod" {
+		t.Errorf("Expected the cluster ARN as context, got %v", kc["current-context"])
+	}
+}
+
+func TestUpdateKubeconfigRefusesInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	original := "clusters: [this is: not valid"
+	if err := os.WriteFile(path, []byte(original), 0600); err != nil {
+		t.Fatalf("Failed to write kubeconfig: %v", err)
+	}
+	client := newKubeconfigBackend().Client("us-east-1")
+
+	if _, err := client.UpdateKubeconfig(context.Background(), "prod", KubeconfigOptions{Path: path}); err == nil {
+		t.Fatal("Expected error for an invalid kubeconfig, got nil")
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != original {
+		t.Errorf("Expected invalid kubeconfig to be left untouched, got %q", data)
+	}
+}
+
+func mustMarshal(t *testing.T, value interface{}) []byte {
+	t.Helper()
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	return data
+}
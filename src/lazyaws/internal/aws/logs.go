@@ -0,0 +1,377 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	sdkaws "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// Limits on how much CloudWatch Logs data is fetched at once
+const (
+	logStreamLimit     = 200 // Most recently active streams listed per group
+	logTailStreamLimit = 100 // FilterLogEvents accepts at most 100 stream names
+	logTailPageLimit   = 5   // Pages read per tail poll; the rest is read next time
+)
+
+// logTailIngestionDelay is how late an event may be ingested and still be
+// picked up by a tail
+const logTailIngestionDelay = 2 * time.Minute
+
+// LogGroup represents a CloudWatch Logs log group
+type LogGroup struct {
+	Name          string
+	ARN           string
+	StoredBytes   int64
+	RetentionDays int32 // 0 means never expire
+	CreationTime  time.Time
+}
+
+// LogStream represents a stream within a log group
+type LogStream struct {
+	Name           string
+	FirstEventTime time.Time
+	LastEventTime  time.Time
+	StoredBytes    int64
+}
+
+// LogEvent is one log line
+type LogEvent struct {
+	ID         string
+	Timestamp  time.Time
+	StreamName string
+	Message    string
+}
+
+// InsightsQueryResult is the state and results of a Logs Insights query
+type InsightsQueryResult struct {
+	QueryID        string
+	Status         string
+	Fields         []string // Columns in the order they first appear, @ptr excluded
+	Rows           []map[string]string
+	RecordsMatched float64
+	RecordsScanned float64
+	BytesScanned   float64
+}
+
+// Done reports whether the query has stopped running
+func (r *InsightsQueryResult) Done() bool {
+	switch types.QueryStatus(r.Status) {
+	case types.QueryStatusScheduled, types.QueryStatusRunning, types.QueryStatusUnknown:
+		return false
+	}
+	return true
+}
+
+// SavedQuery is a Logs Insights query definition saved in the account
+type SavedQuery struct {
+	ID        string
+	Name      string
+	Query     string
+	LogGroups []string
+}
+
+// ListLogGroups lists the log groups whose name starts with prefix
+func (c *Client) ListLogGroups(ctx context.Context, prefix string) ([]LogGroup, error) {
+	input := &cloudwatchlogs.DescribeLogGroupsInput{}
+	if prefix != "" {
+		input.LogGroupNamePrefix = &prefix
+	}
+
+	var groups []LogGroup
+	for {
+		output, err := c.Logs.DescribeLogGroups(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe log groups: %w", err)
+		}
+		for _, group := range output.LogGroups {
+			groups = append(groups, LogGroup{
+				Name:          getString(group.LogGroupName),
+				ARN:           getString(group.Arn),
+				StoredBytes:   getInt64(group.StoredBytes),
+				RetentionDays: sdkaws.ToInt32(group.RetentionInDays),
+				CreationTime:  millisToTime(group.CreationTime),
+			})
+		}
+		if output.NextToken == nil || *output.NextToken == "" {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+	return groups, nil
+}
+
+// ListLogStreams lists the most recently active streams of a log group,
+// newest first
+func (c *Client) ListLogStreams(ctx context.Context, groupName string) ([]LogStream, error) {
+	descending := true
+	input := &cloudwatchlogs.DescribeLogStreamsInput{
+		LogGroupName: &groupName,
+		OrderBy:      types.OrderByLastEventTime,
+		Descending:   &descending,
+	}
+
+	var streams []LogStream
+	for len(streams) < logStreamLimit {
+		output, err := c.Logs.DescribeLogStreams(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe streams of %s: %w", groupName, err)
+		}
+		for _, stream := range output.LogStreams {
+			streams = append(streams, LogStream{
+				Name:           getString(stream.LogStreamName),
+				FirstEventTime: millisToTime(stream.FirstEventTimestamp),
+				LastEventTime:  millisToTime(stream.LastEventTimestamp),
+				StoredBytes:    getInt64(stream.StoredBytes),
+			})
+		}
+		if output.NextToken == nil || *output.NextToken == "" {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+
+	if len(streams) > logStreamLimit {
+		streams = streams[:logStreamLimit]
+	}
+	return streams, nil
+}
+
+// LogTail follows new events of a log group by polling FilterLogEvents.
+// Poll must not be called concurrently.
+type LogTail struct {
+	GroupName     string
+	StreamNames   []string // Empty tails every stream of the group
+	FilterPattern string
+
+	client *Client
+	// start is the timestamp, in milliseconds, reads begin at. Pages aren't
+	// in time order across streams and events are ingested late, so start
+	// only moves once a read has reached its last page, and then to
+	// logTailIngestionDelay before that read began. Events read since start
+	// are remembered, so each is returned once.
+	start     int64
+	nextToken *string          // Page the unfinished read continues from
+	readStart time.Time        // When the unfinished read began
+	seen      map[string]int64 // Timestamps of the events returned, by ID
+}
+
+// NewLogTail starts a tail of groupName that first returns the events of
+// the last since. Only the first 100 streamNames are followed.
+func (c *Client) NewLogTail(groupName string, streamNames []string, filterPattern string, since time.Duration) *LogTail {
+	if len(streamNames) > logTailStreamLimit {
+		streamNames = streamNames[:logTailStreamLimit]
+	}
+	return &LogTail{
+		GroupName:     groupName,
+		StreamNames:   streamNames,
+		FilterPattern: filterPattern,
+		client:        c,
+		start:         time.Now().Add(-since).UnixMilli(),
+		seen:          make(map[string]int64),
+	}
+}
+
+// Poll returns the events that arrived since the previous poll, oldest
+// first. A read that doesn't fit in logTailPageLimit pages is continued by
+// the next poll.
+func (t *LogTail) Poll(ctx context.Context) ([]LogEvent, error) {
+	if t.nextToken == nil {
+		t.readStart = time.Now()
+	}
+	input := &cloudwatchlogs.FilterLogEventsInput{
+		LogGroupName:   &t.GroupName,
+		LogStreamNames: t.StreamNames,
+		StartTime:      &t.start,
+		NextToken:      t.nextToken,
+	}
+	if t.FilterPattern != "" {
+		input.FilterPattern = &t.FilterPattern
+	}
+
+	var events []LogEvent
+	found := make(map[string]bool)
+	var next *string
+	for page := 0; page < logTailPageLimit; page++ {
+		output, err := t.client.Logs.FilterLogEvents(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to filter events of %s: %w", t.GroupName, err)
+		}
+		for _, event := range output.Events {
+			id := getString(event.EventId)
+			if _, ok := t.seen[id]; ok || found[id] {
+				continue
+			}
+			found[id] = true
+			events = append(events, LogEvent{
+				ID:         id,
+				Timestamp:  millisToTime(event.Timestamp),
+				StreamName: getString(event.LogStreamName),
+				Message:    strings.TrimRight(getString(event.Message), "\n"),
+			})
+		}
+		if output.NextToken == nil || *output.NextToken == "" {
+			next = nil
+			break
+		}
+		next = output.NextToken
+		input.NextToken = output.NextToken
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Timestamp.Before(events[j].Timestamp) })
+	for _, event := range events {
+		t.seen[event.ID] = event.Timestamp.UnixMilli()
+	}
+	t.nextToken = next
+
+	// Once the read is complete, move the window forward, forgetting the
+	// events that fell out of it
+	if next == nil {
+		if start := t.readStart.Add(-logTailIngestionDelay).UnixMilli(); start > t.start {
+			t.start = start
+			for id, timestamp := range t.seen {
+				if timestamp < start {
+					delete(t.seen, id)
+				}
+			}
+		}
+	}
+	return events, nil
+}
+
+// StartInsightsQuery starts a Logs Insights query over groupNames for the
+// window ending now and returns its ID
+func (c *Client) StartInsightsQuery(ctx context.Context, groupNames []string, query string, window time.Duration) (string, error) {
+	end := time.Now()
+	start := end.Add(-window)
+	output, err := c.Logs.StartQuery(ctx, &cloudwatchlogs.StartQueryInput{
+		LogGroupNames: groupNames,
+		QueryString:   &query,
+		StartTime:     getInt64Ptr(start.Unix()),
+		EndTime:       getInt64Ptr(end.Unix()),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to start query: %w", err)
+	}
+	return getString(output.QueryId), nil
+}
+
+// GetInsightsQueryResults returns the status and results so far of a query
+func (c *Client) GetInsightsQueryResults(ctx context.Context, queryID string) (*InsightsQueryResult, error) {
+	output, err := c.Logs.GetQueryResults(ctx, &cloudwatchlogs.GetQueryResultsInput{QueryId: &queryID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get query results: %w", err)
+	}
+
+	result := &InsightsQueryResult{
+		QueryID: queryID,
+		Status:  string(output.Status),
+	}
+	if output.Statistics != nil {
+		result.RecordsMatched = output.Statistics.RecordsMatched
+		result.RecordsScanned = output.Statistics.RecordsScanned
+		result.BytesScanned = output.Statistics.BytesScanned
+	}
+
+	seen := make(map[string]bool)
+	for _, fields := range output.Results {
+		row := make(map[string]string, len(fields))
+		for _, field := range fields {
+			name := getString(field.Field)
+			// @ptr only identifies the record for GetLogRecord
+			if name == "@ptr" {
+				continue
+			}
+			row[name] = getString(field.Value)
+			if !seen[name] {
+				seen[name] = true
+				result.Fields = append(result.Fields, name)
+			}
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	return result, nil
+}
+
+// StopInsightsQuery stops a running query
+func (c *Client) StopInsightsQuery(ctx context.Context, queryID string) error {
+	_, err := c.Logs.StopQuery(ctx, &cloudwatchlogs.StopQueryInput{QueryId: &queryID})
+	if err != nil {
+		return fmt.Errorf("failed to stop query: %w", err)
+	}
+	return nil
+}
+
+// ListSavedQueries lists the Logs Insights query definitions of the account
+func (c *Client) ListSavedQueries(ctx context.Context) ([]SavedQuery, error) {
+	input := &cloudwatchlogs.DescribeQueryDefinitionsInput{}
+
+	var queries []SavedQuery
+	for {
+		output, err := c.Logs.DescribeQueryDefinitions(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe saved queries: %w", err)
+		}
+		for _, def := range output.QueryDefinitions {
+			queries = append(queries, SavedQuery{
+				ID:        getString(def.QueryDefinitionId),
+				Name:      getString(def.Name),
+				Query:     getString(def.QueryString),
+				LogGroups: def.LogGroupNames,
+			})
+		}
+		if output.NextToken == nil || *output.NextToken == "" {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+
+	sort.Slice(queries, func(i, j int) bool { return queries[i].Name < queries[j].Name })
+	return queries, nil
+}
+
+// SaveQuery saves a query definition, updating the one with the same name
+// if it exists, and returns its ID
+func (c *Client) SaveQuery(ctx context.Context, name, query string, groupNames []string) (string, error) {
+	input := &cloudwatchlogs.PutQueryDefinitionInput{
+		Name:          &name,
+		QueryString:   &query,
+		LogGroupNames: groupNames,
+	}
+
+	existing, err := c.ListSavedQueries(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, saved := range existing {
+		if saved.Name == name {
+			input.QueryDefinitionId = &saved.ID
+			break
+		}
+	}
+
+	output, err := c.Logs.PutQueryDefinition(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("failed to save query %s: %w", name, err)
+	}
+	return getString(output.QueryDefinitionId), nil
+}
+
+// millisToTime converts an epoch timestamp in milliseconds, returning the
+// zero time for nil
+func millisToTime(ms *int64) time.Time {
+	if ms == nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(*ms)
+}
+
+// Helper function to get int64 pointer
+func getInt64Ptr(i int64) *int64 {
+	return &i
+}
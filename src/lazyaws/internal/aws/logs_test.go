@@ -0,0 +1,207 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	sdkaws "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func TestListLogStreamsNewestFirst(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.Logs.PageSize = 1
+	client := backend.Client("us-east-1")
+
+	now := time.Now()
+	backend.Logs.AddEvent("/app", "old", now.Add(-time.Hour), "first")
+	backend.Logs.AddEvent("/app", "new", now, "second")
+	backend.Logs.AddEvent("/other", "x", now, "third")
+
+	groups, err := client.ListLogGroups(context.Background(), "/app")
+	if err != nil {
+		t.Fatalf("ListLogGroups returned error: %v", err)
+	}
+	if len(groups) != 1 || groups[0].Name != "/app" {
+		t.Fatalf("Expected only /app to match the prefix, got %+v", groups)
+	}
+
+	streams, err := client.ListLogStreams(context.Background(), "/app")
+	if err != nil {
+		t.Fatalf("ListLogStreams returned error: %v", err)
+	}
+	if len(streams) != 2 || streams[0].Name != "new" || streams[1].Name != "old" {
+		t.Errorf("Expected streams [new old], got %+v", streams)
+	}
+}
+
+func TestLogTailReturnsOnlyNewEvents(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.Logs.PageSize = 2
+	client := backend.Client("us-east-1")
+	ctx := context.Background()
+
+	base := time.Now().Add(-time.Minute)
+	backend.Logs.AddEvent("/app", "a", base, "GET /health 200")
+	backend.Logs.AddEvent("/app", "b", base, "GET /users 500")
+	backend.Logs.AddEvent("/app", "a", base.Add(time.Second), "GET /users 200")
+	backend.Logs.AddEvent("/app", "a", base.Add(-time.Hour), "too old")
+
+	tail := client.NewLogTail("/app", nil, "", 10*time.Minute)
+	events, err := tail.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events from the last 10 minutes, got %d", len(events))
+	}
+	if events[2].Message != "GET /users 200" {
+		t.Errorf("Expected events oldest first, got %+v", events)
+	}
+
+	// Nothing new yet
+	events, err = tail.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("Expected no repeated events, got %+v", events)
+	}
+
+	// An event at the same millisecond as the last one is still picked up
+	backend.Logs.AddEvent("/app", "b", base.Add(time.Second), "GET /orders 200")
+	events, err = tail.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+	if len(events) != 1 || events[0].Message != "GET /orders 200" {
+		t.Errorf("Expected the late event only, got %+v", events)
+	}
+
+	// Filter patterns and stream names narrow the tail
+	filtered := client.NewLogTail("/app", []string{"a"}, "users", 10*time.Minute)
+	events, err = filtered.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+	if len(events) != 1 || events[0].Message != "GET /users 200" {
+		t.Errorf("Expected one matching event in stream a, got %+v", events)
+	}
+}
+
+func TestLogTailReadsPastPageLimit(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.Logs.PageSize = 1
+	client := backend.Client("us-east-1")
+	ctx := context.Background()
+
+	base := time.Now().Add(-time.Minute)
+	for i := 0; i < 8; i++ {
+		backend.Logs.AddEvent("/app", "a", base.Add(time.Duration(i)*time.Second), fmt.Sprintf("event %d", i))
+	}
+
+	tail := client.NewLogTail("/app", nil, "", 10*time.Minute)
+	events, err := tail.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+	if len(events) != logTailPageLimit {
+		t.Fatalf("Expected the first %d pages, got %d events", logTailPageLimit, len(events))
+	}
+
+	// The next poll carries on where the last page left off
+	events, err = tail.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+	if len(events) != 3 || events[0].Message != "event 5" {
+		t.Fatalf("Expected the remaining 3 events, got %+v", events)
+	}
+
+	// An event ingested late, older than the newest one shown, still turns
+	// up, and only once
+	backend.Logs.AddEvent("/app", "b", base.Add(500*time.Millisecond), "late")
+	var late []LogEvent
+	for i := 0; i < 4; i++ {
+		events, err := tail.Poll(ctx)
+		if err != nil {
+			t.Fatalf("Poll returned error: %v", err)
+		}
+		late = append(late, events...)
+	}
+	if len(late) != 1 || late[0].Message != "late" {
+		t.Errorf("Expected only the late event, got %+v", late)
+	}
+}
+
+func TestInsightsQueryAndSavedQueries(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.Logs.QueryResults = [][]types.ResultField{
+		{
+			{Field: sdkaws.String("@timestamp"), Value: sdkaws.String("2024-01-01 00:00:00.000")},
+			{Field: sdkaws.String("@message"), Value: sdkaws.String("boom")},
+			{Field: sdkaws.String("@ptr"), Value: sdkaws.String("abc")},
+		},
+		{
+			{Field: sdkaws.String("@timestamp"), Value: sdkaws.String("2024-01-01 00:00:01.000")},
+			{Field: sdkaws.String("level"), Value: sdkaws.String("error")},
+		},
+	}
+	client := backend.Client("us-east-1")
+	ctx := context.Background()
+
+	id, err := client.StartInsightsQuery(ctx, []string{"/app"}, "fields @timestamp, @message", time.Hour)
+	if err != nil {
+		t.Fatalf("StartInsightsQuery returned error: %v", err)
+	}
+	result, err := client.GetInsightsQueryResults(ctx, id)
+	if err != nil {
+		t.Fatalf("GetInsightsQueryResults returned error: %v", err)
+	}
+	if !result.Done() || len(result.Rows) != 2 {
+		t.Fatalf("Expected a finished query with 2 rows, got %+v", result)
+	}
+	want := []string{"@timestamp", "@message", "level"}
+	if len(result.Fields) != len(want) {
+		t.Fatalf("Expected fields %v, got %v", want, result.Fields)
+	}
+	for i := range want {
+		if result.Fields[i] != want[i] {
+			t.Errorf("Expected fields %v, got %v", want, result.Fields)
+		}
+	}
+
+	if _, err := client.SaveQuery(ctx, "errors", "filter level = 'error'", []string{"/app"}); err != nil {
+		t.Fatalf("SaveQuery returned error: %v", err)
+	}
+	// Saving under the same name updates the definition
+	if _, err := client.SaveQuery(ctx, "errors", "filter level = 'ERROR'", nil); err != nil {
+		t.Fatalf("SaveQuery returned error: %v", err)
+	}
+	saved, err := client.ListSavedQueries(ctx)
+	if err != nil {
+		t.Fatalf("ListSavedQueries returned error: %v", err)
+	}
+	if len(saved) != 1 || saved[0].Query != "filter level = 'ERROR'" {
+		t.Errorf("Expected one updated saved query, got %+v", saved)
+	}
+}
+
+func TestEKSLogType(t *testing.T) {
+	tests := map[string]string{
+		"kube-apiserver-0123":           "api",
+		"kube-apiserver-audit-0123":     "audit",
+		"authenticator-0123":            "authenticator",
+		"kube-controller-manager-0123":  "controllerManager",
+		"cloud-controller-manager-0123": "controllerManager",
+		"kube-scheduler-0123":           "scheduler",
+		"something-else":                "",
+	}
+	for stream, want := range tests {
+		if got := string(EKSLogType(stream)); got != want {
+			t.Errorf("EKSLogType(%q) = %q, want %q", stream, got, want)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// PortForwardDefinition is a saved port forward. Definitions are restored
+// when a client for their account and region is initialized.
+type PortForwardDefinition struct {
+	AccountID  string `json:"account_id,omitempty"`
+	Region     string `json:"region"`
+	InstanceID string `json:"instance_id"`
+	RemoteHost string `json:"remote_host,omitempty"`
+	RemotePort int    `json:"remote_port"`
+	LocalPort  int    `json:"local_port"`
+	// Stopped definitions are listed on restore but not started
+	Stopped bool `json:"stopped,omitempty"`
+}
+
+// Options returns the options that start the defined forward
+func (d PortForwardDefinition) Options() PortForwardOptions {
+	return PortForwardOptions{
+		InstanceID: d.InstanceID,
+		RemoteHost: d.RemoteHost,
+		RemotePort: d.RemotePort,
+		LocalPort:  d.LocalPort,
+	}
+}
+
+// PortForwardInfo describes one managed forward
+type PortForwardInfo struct {
+	ID         int
+	Definition PortForwardDefinition
+	Running    bool
+	// Err is why the forward last failed to start or stopped on its own
+	Err   error
+	Stats PortForwardStats
+}
+
+// managedPortForward is a forward and the client that runs it
+type managedPortForward struct {
+	id         int
+	definition PortForwardDefinition
+	client     *Client
+	forward    *PortForward
+	err        error
+}
+
+// PortForwardManager owns port forwards running in the background and keeps
+// their definitions saved, so they outlive the screen that started them
+type PortForwardManager struct {
+	path string
+	ctx  context.Context
+
+	mu       sync.Mutex
+	nextID   int
+	forwards []*managedPortForward
+	// dormant holds saved definitions not restored yet
+	dormant []PortForwardDefinition
+}
+
+// GetPortForwardsPath returns the path to the saved port forwards file
+func GetPortForwardsPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	configDir := filepath.Join(home, ".lazyaws")
+	if err := os.MkdirAll(configDir, 0700); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(configDir, "port-forwards.json"), nil
+}
+
+// NewPortForwardManager creates a manager that saves definitions to path and
+// loads the ones already saved there
+func NewPortForwardManager(ctx context.Context, path string) (*PortForwardManager, error) {
+	m := &PortForwardManager{path: path, ctx: ctx, nextID: 1}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &m.dormant); err != nil {
+		return nil, fmt.Errorf("invalid port forwards file %s: %w", path, err)
+	}
+	return m, nil
+}
+
+// Start starts a forward with client and saves its definition
+func (m *PortForwardManager) Start(client *Client, opts PortForwardOptions) (PortForwardInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := m.add(client, PortForwardDefinition{
+		AccountID:  client.GetAccountID(),
+		Region:     client.GetRegion(),
+		InstanceID: opts.InstanceID,
+		RemoteHost: opts.RemoteHost,
+		RemotePort: opts.RemotePort,
+		LocalPort:  opts.LocalPort,
+	})
+	if err := m.start(entry); err != nil {
+		m.remove(entry.id)
+		return PortForwardInfo{}, err
+	}
+	return entry.info(), m.save()
+}
+
+// Restore adds the saved definitions for client's account and region,
+// starting the ones that were running. It returns the number restored.
+func (m *PortForwardManager) Restore(client *Client) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var restored []*managedPortForward
+	var remaining []PortForwardDefinition
+	for _, def := range m.dormant {
+		if def.Region != client.GetRegion() || (def.AccountID != "" && def.AccountID != client.GetAccountID()) {
+			remaining = append(remaining, def)
+			continue
+		}
+		restored = append(restored, m.add(client, def))
+	}
+	m.dormant = remaining
+
+	var errs []error
+	for _, entry := range restored {
+		if entry.definition.Stopped {
+			continue
+		}
+		if err := m.start(entry); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return len(restored), fmt.Errorf("failed to restore %d of %d port forwards: %w", len(errs), len(restored), errs[0])
+	}
+	return len(restored), nil
+}
+
+// Stop stops a forward and keeps its definition
+func (m *PortForwardManager) Stop(id int) error {
+	m.mu.Lock()
+	entry, err := m.get(id)
+	if err != nil {
+		m.mu.Unlock()
+		return err
+	}
+	forward := entry.forward
+	entry.forward = nil
+	entry.err = nil
+	entry.definition.Stopped = true
+	err = m.save()
+	m.mu.Unlock()
+
+	// Stop waits for the forwarded connections to close, so it runs outside
+	// the lock where List and the other methods are not held up
+	if forward != nil {
+		forward.Stop()
+	}
+	return err
+}
+
+// Restart stops a forward if it is running and starts it again on the same
+// local port
+func (m *PortForwardManager) Restart(id int) (PortForwardInfo, error) {
+	m.mu.Lock()
+	entry, err := m.get(id)
+	if err != nil {
+		m.mu.Unlock()
+		return PortForwardInfo{}, err
+	}
+	forward := entry.forward
+	entry.forward = nil
+	m.mu.Unlock()
+
+	if forward != nil {
+		forward.Stop()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// The forward may have been removed or started again while it stopped
+	entry, err = m.get(id)
+	if err != nil {
+		return PortForwardInfo{}, err
+	}
+	if entry.forward != nil {
+		return entry.info(), nil
+	}
+	startErr := m.start(entry)
+	if err := m.save(); err != nil {
+		return entry.info(), err
+	}
+	return entry.info(), startErr
+}
+
+// Duplicate starts a copy of a forward on a new free local port
+func (m *PortForwardManager) Duplicate(id int) (PortForwardInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	source, err := m.get(id)
+	if err != nil {
+		return PortForwardInfo{}, err
+	}
+	def := source.definition
+	def.LocalPort = 0
+	def.Stopped = false
+
+	entry := m.add(source.client, def)
+	if err := m.start(entry); err != nil {
+		m.remove(entry.id)
+		return PortForwardInfo{}, err
+	}
+	return entry.info(), m.save()
+}
+
+// Remove stops a forward and deletes its definition
+func (m *PortForwardManager) Remove(id int) error {
+	m.mu.Lock()
+	entry, err := m.get(id)
+	if err != nil {
+		m.mu.Unlock()
+		return err
+	}
+	m.remove(id)
+	err = m.save()
+	m.mu.Unlock()
+
+	if entry.forward != nil {
+		entry.forward.Stop()
+	}
+	return err
+}
+
+// List returns every managed forward in the order they were added
+func (m *PortForwardManager) List() []PortForwardInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	infos := make([]PortForwardInfo, 0, len(m.forwards))
+	for _, entry := range m.forwards {
+		infos = append(infos, entry.info())
+	}
+	return infos
+}
+
+// StopAll stops every forward without changing the saved definitions, so
+// they are restored on the next start
+func (m *PortForwardManager) StopAll() {
+	m.mu.Lock()
+	var forwards []*PortForward
+	for _, entry := range m.forwards {
+		if entry.forward != nil {
+			forwards = append(forwards, entry.forward)
+			entry.forward = nil
+		}
+	}
+	m.mu.Unlock()
+
+	for _, forward := range forwards {
+		forward.Stop()
+	}
+}
+
+// add appends a stopped entry for def
+func (m *PortForwardManager) add(client *Client, def PortForwardDefinition) *managedPortForward {
+	entry := &managedPortForward{id: m.nextID, definition: def, client: client}
+	m.nextID++
+	m.forwards = append(m.forwards, entry)
+	return entry
+}
+
+// start starts entry's forward, recording the local port it picked
+func (m *PortForwardManager) start(entry *managedPortForward) error {
+	forward, err := entry.client.StartPortForward(m.ctx, entry.definition.Options())
+	if err != nil {
+		entry.err = err
+		return err
+	}
+	entry.forward = forward
+	entry.err = nil
+	entry.definition.LocalPort = forward.LocalPort()
+	entry.definition.Stopped = false
+	return nil
+}
+
+func (m *PortForwardManager) get(id int) (*managedPortForward, error) {
+	for _, entry := range m.forwards {
+		if entry.id == id {
+			return entry, nil
+		}
+	}
+	return nil, fmt.Errorf("no port forward with ID %d", id)
+}
+
+func (m *PortForwardManager) remove(id int) {
+	for i, entry := range m.forwards {
+		if entry.id == id {
+			m.forwards = append(m.forwards[:i], m.forwards[i+1:]...)
+			return
+		}
+	}
+}
+
+// save writes the managed and dormant definitions to disk
+func (m *PortForwardManager) save() error {
+	defs := make([]PortForwardDefinition, 0, len(m.forwards)+len(m.dormant))
+	for _, entry := range m.forwards {
+		defs = append(defs, entry.definition)
+	}
+	defs = append(defs, m.dormant...)
+
+	data, err := json.MarshalIndent(defs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(m.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to save port forwards: %w", err)
+	}
+	return nil
+}
+
+// info snapshots the entry. A forward that stopped on its own is reported
+// with the error that stopped it.
+func (e *managedPortForward) info() PortForwardInfo {
+	info := PortForwardInfo{ID: e.id, Definition: e.definition, Err: e.err}
+	if e.forward == nil {
+		return info
+	}
+	info.Stats = e.forward.Stats()
+	select {
+	case <-e.forward.Done():
+		info.Err = e.forward.Err()
+	default:
+		info.Running = true
+	}
+	return info
+}
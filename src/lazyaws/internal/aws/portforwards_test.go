@@ -0,0 +1,180 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestParsePortForwardSpec(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    PortForwardOptions
+		wantErr bool
+	}{
+		{spec: "80", want: PortForwardOptions{InstanceID: "i-1", RemotePort: 80}},
+		{spec: "8080:80", want: PortForwardOptions{InstanceID: "i-1", LocalPort: 8080, RemotePort: 80}},
+		{spec: "db.internal:5432", want: PortForwardOptions{InstanceID: "i-1", RemoteHost: "db.internal", RemotePort: 5432}},
+		{spec: "15432:db.internal:5432", want: PortForwardOptions{InstanceID: "i-1", LocalPort: 15432, RemoteHost: "db.internal", RemotePort: 5432}},
+		{spec: "", wantErr: true},
+		{spec: "0", wantErr: true},
+		{spec: "8080:http", wantErr: true},
+		{spec: "x:db:5432", wantErr: true},
+		{spec: "1:2:3:4", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParsePortForwardSpec("i-1", tt.spec)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParsePortForwardSpec(%q) expected an error, got %+v", tt.spec, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParsePortForwardSpec(%q) returned error: %v", tt.spec, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParsePortForwardSpec(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestPortForwardManagerLifecycle(t *testing.T) {
+	client, _, _ := newSSMTestClient(t)
+	path := filepath.Join(t.TempDir(), "port-forwards.json")
+
+	manager, err := NewPortForwardManager(context.Background(), path)
+	if err != nil {
+		t.Fatalf("NewPortForwardManager returned error: %v", err)
+	}
+	defer manager.StopAll()
+
+	info, err := manager.Start(client, PortForwardOptions{InstanceID: "i-0001", RemotePort: 80})
+	if err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	if !info.Running || info.Definition.LocalPort == 0 {
+		t.Fatalf("Expected a running forward with a picked port, got %+v", info)
+	}
+	if info.Definition.AccountID != client.AccountID || info.Definition.Region != "us-east-1" {
+		t.Errorf("Expected the definition to record the client's account and region, got %+v", info.Definition)
+	}
+
+	// Traffic through the forward is counted both ways
+	conn, err := net.Dial("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(info.Definition.LocalPort)))
+	if err != nil {
+		t.Fatalf("Failed to connect to the forward: %v", err)
+	}
+	conn.Write([]byte("hello"))
+	if got := readExactly(t, conn, 5); got != "hello" {
+		t.Fatalf("Expected 'hello' through the forward, got %q", got)
+	}
+	stats := manager.List()[0].Stats
+	if stats.BytesSent != 5 || stats.BytesReceived != 5 || stats.Connections != 1 {
+		t.Errorf("Expected 5 bytes each way on one connection, got %+v", stats)
+	}
+	conn.Close()
+
+	duplicate, err := manager.Duplicate(info.ID)
+	if err != nil {
+		t.Fatalf("Duplicate returned error: %v", err)
+	}
+	if duplicate.ID == info.ID || duplicate.Definition.LocalPort == info.Definition.LocalPort {
+		t.Errorf("Expected the duplicate to get its own ID and port, got %+v", duplicate)
+	}
+
+	if err := manager.Stop(info.ID); err != nil {
+		t.Fatalf("Stop returned error: %v", err)
+	}
+	if manager.List()[0].Running {
+		t.Error("Expected the forward to be stopped")
+	}
+
+	restarted, err := manager.Restart(info.ID)
+	if err != nil {
+		t.Fatalf("Restart returned error: %v", err)
+	}
+	if !restarted.Running || restarted.Definition.LocalPort != info.Definition.LocalPort {
+		t.Errorf("Expected the forward to run again on port %d, got %+v", info.Definition.LocalPort, restarted)
+	}
+
+	if err := manager.Remove(duplicate.ID); err != nil {
+		t.Fatalf("Remove returned error: %v", err)
+	}
+	if len(manager.List()) != 1 {
+		t.Errorf("Expected one forward after remove, got %d", len(manager.List()))
+	}
+
+	var saved []PortForwardDefinition
+	data, _ := os.ReadFile(path)
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("Saved file is not valid JSON: %v", err)
+	}
+	if len(saved) != 1 || saved[0].LocalPort != info.Definition.LocalPort || saved[0].Stopped {
+		t.Errorf("Expected the running forward to be saved, got %+v", saved)
+	}
+}
+
+func TestPortForwardManagerRestore(t *testing.T) {
+	client, _, _ := newSSMTestClient(t)
+	path := filepath.Join(t.TempDir(), "port-forwards.json")
+
+	saved := []PortForwardDefinition{
+		{AccountID: client.AccountID, Region: "us-east-1", InstanceID: "i-0001", RemotePort: 80},
+		{Region: "us-east-1", InstanceID: "i-0002", RemotePort: 22, Stopped: true},
+		{AccountID: "999999999999", Region: "us-east-1", InstanceID: "i-0003", RemotePort: 443},
+		{Region: "eu-west-1", InstanceID: "i-0004", RemotePort: 443},
+	}
+	data, _ := json.Marshal(saved)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	manager, err := NewPortForwardManager(context.Background(), path)
+	if err != nil {
+		t.Fatalf("NewPortForwardManager returned error: %v", err)
+	}
+	defer manager.StopAll()
+
+	restored, err := manager.Restore(client)
+	if err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+	if restored != 2 {
+		t.Fatalf("Expected 2 definitions for this account and region, got %d", restored)
+	}
+
+	forwards := manager.List()
+	if !forwards[0].Running || forwards[0].Definition.InstanceID != "i-0001" {
+		t.Errorf("Expected i-0001 to be running, got %+v", forwards[0])
+	}
+	if forwards[1].Running || forwards[1].Definition.InstanceID != "i-0002" {
+		t.Errorf("Expected i-0002 to be listed but stopped, got %+v", forwards[1])
+	}
+
+	// Restoring again must not add the same definitions twice
+	if restored, _ := manager.Restore(client); restored != 0 {
+		t.Errorf("Expected nothing left to restore, got %d", restored)
+	}
+
+	// Saving keeps the definitions for other accounts and regions
+	if err := manager.Stop(forwards[0].ID); err != nil {
+		t.Fatalf("Stop returned error: %v", err)
+	}
+	var after []PortForwardDefinition
+	data, _ = os.ReadFile(path)
+	json.Unmarshal(data, &after)
+	if len(after) != 4 {
+		t.Errorf("Expected all 4 definitions to stay saved, got %+v", after)
+	}
+
+	if _, err := NewPortForwardManager(context.Background(), filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Errorf("Expected a missing file to be an empty manager, got %v", err)
+	}
+}
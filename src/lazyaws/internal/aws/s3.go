@@ -5,11 +5,10 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
-	"strings"
+	"sort"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	sdkaws "github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
@@ -30,6 +29,7 @@ type S3Object struct {
 	LastModified string
 	StorageClass string
 	IsFolder     bool
+	VersionId    string // Version to act on, or empty for the current one
 }
 
 // S3ListResult contains the result of listing objects with pagination support
@@ -49,6 +49,7 @@ type S3ObjectDetails struct {
 	ETag         string
 	Metadata     map[string]string
 	Tags         map[string]string
+	Restore      ObjectRestoreStatus
 }
 
 // ProgressCallback is a function that receives progress updates
@@ -71,23 +72,6 @@ func (pw *progressWriter) Write(p []byte) (int, error) {
 	return n, err
 }
 
-// progressReader wraps an io.Reader to track progress
-type progressReader struct {
-	reader   io.Reader
-	total    int64
-	read     int64
-	callback ProgressCallback
-}
-
-func (pr *progressReader) Read(p []byte) (int, error) {
-	n, err := pr.reader.Read(p)
-	pr.read += int64(n)
-	if pr.callback != nil {
-		pr.callback(pr.read, pr.total)
-	}
-	return n, err
-}
-
 // ListBuckets retrieves all S3 buckets
 func (c *Client) ListBuckets(ctx context.Context) ([]Bucket, error) {
 	input := &s3.ListBucketsInput{}
@@ -176,25 +160,7 @@ func (c *Client) ListObjects(ctx context.Context, bucketName, prefix string, con
 			continue
 		}
 
-		storageClass := ""
-		if obj.StorageClass != "" {
-			storageClass = string(obj.StorageClass)
-		} else {
-			storageClass = string(types.ObjectStorageClassStandard)
-		}
-
-		lastModified := ""
-		if obj.LastModified != nil {
-			lastModified = obj.LastModified.Format("2006-01-02 15:04:05")
-		}
-
-		objects = append(objects, S3Object{
-			Key:          key,
-			Size:         getInt64(obj.Size),
-			LastModified: lastModified,
-			StorageClass: storageClass,
-			IsFolder:     false,
-		})
+		objects = append(objects, s3ObjectFromListing(obj))
 	}
 
 	return &S3ListResult{
@@ -232,64 +198,9 @@ func (c *Client) DownloadObject(ctx context.Context, bucketName, key, localPath
 
 // DownloadObjectWithProgress downloads an S3 object with progress tracking
 func (c *Client) DownloadObjectWithProgress(ctx context.Context, bucketName, key, localPath string, progressCallback ProgressCallback) error {
-	// Get object size first for progress tracking
-	headInput := &s3.HeadObjectInput{
-		Bucket: &bucketName,
-		Key:    &key,
-	}
-	headResult, err := c.S3.HeadObject(ctx, headInput)
-	if err != nil {
-		return fmt.Errorf("failed to get object metadata: %w", err)
-	}
-
-	objectSize := getInt64(headResult.ContentLength)
-
-	// Create the file
-	file, err := os.Create(localPath)
-	if err != nil {
-		return fmt.Errorf("failed to create local file: %w", err)
-	}
-	defer file.Close()
-
-	// Download the object
-	downloader := manager.NewDownloader(c.S3)
-
-	// Wrap the file writer with progress tracking
-	var writer io.WriterAt = file
-	if progressCallback != nil {
-		writer = &progressWriterAt{
-			writer:   file,
-			total:    objectSize,
-			callback: progressCallback,
-		}
-	}
-
-	_, err = downloader.Download(ctx, writer, &s3.GetObjectInput{
-		Bucket: &bucketName,
-		Key:    &key,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to download object: %w", err)
-	}
-
-	return nil
-}
-
-// progressWriterAt wraps an io.WriterAt to track progress
-type progressWriterAt struct {
-	writer   io.WriterAt
-	total    int64
-	written  int64
-	callback ProgressCallback
-}
-
-func (pw *progressWriterAt) WriteAt(p []byte, off int64) (int, error) {
-	n, err := pw.writer.WriteAt(p, off)
-	pw.written += int64(n)
-	if pw.callback != nil {
-		pw.callback(pw.written, pw.total)
-	}
-	return n, err
+	transfers := NewTransferManager(ctx, 1, progressCallback)
+	transfers.Enqueue(c, TransferRequest{Kind: TransferDownload, Bucket: bucketName, Key: key, LocalPath: localPath})
+	return transfers.Wait()
 }
 
 // UploadObject uploads a local file to S3
@@ -297,49 +208,13 @@ func (c *Client) UploadObject(ctx context.Context, bucketName, key, localPath st
 	return c.UploadObjectWithProgress(ctx, bucketName, key, localPath, nil)
 }
 
-// UploadObjectWithProgress uploads a local file to S3 with progress tracking
-// Automatically uses multipart upload for large files (>5MB)
+// UploadObjectWithProgress uploads a local file to S3 with progress tracking.
+// Large files are uploaded in parts, continuing an interrupted upload of the
+// same key.
 func (c *Client) UploadObjectWithProgress(ctx context.Context, bucketName, key, localPath string, progressCallback ProgressCallback) error {
-	// Open the file
-	file, err := os.Open(localPath)
-	if err != nil {
-		return fmt.Errorf("failed to open local file: %w", err)
-	}
-	defer file.Close()
-
-	// Get file size
-	fileInfo, err := file.Stat()
-	if err != nil {
-		return fmt.Errorf("failed to get file info: %w", err)
-	}
-	fileSize := fileInfo.Size()
-
-	// Create uploader with multipart support
-	uploader := manager.NewUploader(c.S3, func(u *manager.Uploader) {
-		// Use 10MB part size for multipart uploads
-		u.PartSize = 10 * 1024 * 1024
-	})
-
-	// Wrap reader with progress tracking
-	var reader io.Reader = file
-	if progressCallback != nil {
-		reader = &progressReader{
-			reader:   file,
-			total:    fileSize,
-			callback: progressCallback,
-		}
-	}
-
-	_, err = uploader.Upload(ctx, &s3.PutObjectInput{
-		Bucket: &bucketName,
-		Key:    &key,
-		Body:   reader,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to upload object: %w", err)
-	}
-
-	return nil
+	transfers := NewTransferManager(ctx, 1, progressCallback)
+	transfers.Enqueue(c, TransferRequest{Kind: TransferUpload, Bucket: bucketName, Key: key, LocalPath: localPath})
+	return transfers.Wait()
 }
 
 // GetObjectDetails retrieves detailed information about an S3 object
@@ -359,6 +234,7 @@ func (c *Client) GetObjectDetails(ctx context.Context, bucketName, key string) (
 		Key:      key,
 		Size:     getInt64(headResult.ContentLength),
 		Metadata: headResult.Metadata,
+		Restore:  restoreStatusFromHead(headResult),
 	}
 
 	if headResult.LastModified != nil {
@@ -398,6 +274,60 @@ func (c *Client) GetObjectDetails(ctx context.Context, bucketName, key string) (
 	return details, nil
 }
 
+// ObjectRangeReader reads parts of an S3 object with ranged GETs, so large
+// objects can be previewed without downloading them
+type ObjectRangeReader struct {
+	client *Client
+	ctx    context.Context
+	bucket string
+	key    string
+	etag   string
+	size   int64
+}
+
+// NewObjectRangeReader returns a reader of an object of the given size.
+// Reads fail if the object no longer has the given ETag, so a preview
+// never mixes two versions of an object.
+func (c *Client) NewObjectRangeReader(ctx context.Context, bucketName, key, etag string, size int64) *ObjectRangeReader {
+	return &ObjectRangeReader{client: c, ctx: ctx, bucket: bucketName, key: key, etag: etag, size: size}
+}
+
+// Size returns the size of the object
+func (r *ObjectRangeReader) Size() int64 {
+	return r.size
+}
+
+// ReadAt reads len(p) bytes from off with one ranged GET
+func (r *ObjectRangeReader) ReadAt(p []byte, off int64) (int, error) {
+	if off >= r.size || len(p) == 0 {
+		return 0, io.EOF
+	}
+	end := min(off+int64(len(p)), r.size) - 1
+	input := &s3.GetObjectInput{
+		Bucket: &r.bucket,
+		Key:    &r.key,
+		Range:  sdkaws.String(fmt.Sprintf("bytes=%d-%d", off, end)),
+	}
+	if r.etag != "" {
+		input.IfMatch = &r.etag
+	}
+
+	result, err := r.client.S3.GetObject(r.ctx, input)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read object: %w", err)
+	}
+	defer result.Body.Close()
+
+	n, err := io.ReadFull(result.Body, p[:end-off+1])
+	if err != nil {
+		return n, fmt.Errorf("failed to read object: %w", err)
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
 // DeleteObject deletes an S3 object
 func (c *Client) DeleteObject(ctx context.Context, bucketName, key string) error {
 	input := &s3.DeleteObjectInput{
@@ -472,6 +402,9 @@ func (c *Client) GetBucketPolicy(ctx context.Context, bucketName string) (string
 	}
 
 	result, err := c.S3.GetBucketPolicy(ctx, input)
+	if isErrorCode(err, notSetErrorCodes[BucketConfigPolicy]) {
+		return "", nil
+	}
 	if err != nil {
 		return "", fmt.Errorf("failed to get bucket policy: %w", err)
 	}
@@ -503,16 +436,13 @@ func (c *Client) GetBucketVersioning(ctx context.Context, bucketName string) (st
 
 // GeneratePresignedURL generates a presigned URL for an S3 object
 func (c *Client) GeneratePresignedURL(ctx context.Context, bucketName, key string, expirationSeconds int) (string, error) {
-	// Create a presign client
-	presignClient := s3.NewPresignClient(c.S3)
-
 	input := &s3.GetObjectInput{
 		Bucket: &bucketName,
 		Key:    &key,
 	}
 
 	// Generate presigned URL with expiration
-	presignResult, err := presignClient.PresignGetObject(ctx, input, func(opts *s3.PresignOptions) {
+	presignResult, err := c.S3Presign.PresignGetObject(ctx, input, func(opts *s3.PresignOptions) {
 		opts.Expires = time.Duration(expirationSeconds) * time.Second
 	})
 	if err != nil {
@@ -558,222 +488,144 @@ func (c *Client) GetBucketSize(ctx context.Context, bucketName string) (int64, i
 	return totalSize, objectCount, nil
 }
 
-// ListObjectsWithFilter retrieves objects in an S3 bucket with filtering support
-func (c *Client) ListObjectsWithFilter(ctx context.Context, bucketName, prefix, pattern string, continuationToken *string) (*S3ListResult, error) {
-	// First, get all objects with the given prefix
-	result, err := c.ListObjects(ctx, bucketName, prefix, continuationToken)
-	if err != nil {
-		return nil, err
-	}
-
-	// If no pattern specified, return all results
-	if pattern == "" {
-		return result, nil
-	}
-
-	// Filter objects by pattern (simple contains match for now)
-	var filteredObjects []S3Object
-	for _, obj := range result.Objects {
-		// Simple pattern matching - check if key contains the pattern
-		// Could be enhanced with regex or glob patterns
-		if containsIgnoreCase(obj.Key, pattern) {
-			filteredObjects = append(filteredObjects, obj)
-		}
+// ListObjectVersions lists all versions and delete markers of objects in a
+// bucket, by key and then newest first
+func (c *Client) ListObjectVersions(ctx context.Context, bucketName, prefix string) ([]S3ObjectVersion, error) {
+	input := &s3.ListObjectVersionsInput{
+		Bucket: &bucketName,
 	}
 
-	result.Objects = filteredObjects
-	return result, nil
-}
-
-// Helper function for case-insensitive string matching
-func containsIgnoreCase(s, substr string) bool {
-	s = toLower(s)
-	substr = toLower(substr)
-	return contains(s, substr)
-}
-
-func toLower(s string) string {
-	result := make([]byte, len(s))
-	for i := 0; i < len(s); i++ {
-		c := s[i]
-		if c >= 'A' && c <= 'Z' {
-			c = c + ('a' - 'A')
-		}
-		result[i] = c
+	if prefix != "" {
+		input.Prefix = &prefix
 	}
-	return string(result)
-}
 
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) && (s == substr || len(substr) == 0 || indexOfSubstring(s, substr) >= 0)
-}
-
-func indexOfSubstring(s, substr string) int {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		match := true
-		for j := 0; j < len(substr); j++ {
-			if s[i+j] != substr[j] {
-				match = false
-				break
-			}
-		}
-		if match {
-			return i
-		}
+	type entry struct {
+		version  S3ObjectVersion
+		modified time.Time
 	}
-	return -1
-}
-
-// SyncLocalToS3 syncs a local directory to an S3 bucket (like aws s3 sync)
-func (c *Client) SyncLocalToS3(ctx context.Context, localDir, bucketName, s3Prefix string, progressCallback ProgressCallback) error {
-	// Walk through local directory
-	return filepath.Walk(localDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Skip directories
-		if info.IsDir() {
-			return nil
-		}
-
-		// Calculate relative path and S3 key
-		relPath, err := filepath.Rel(localDir, path)
+	var entries []entry
+	for {
+		result, err := c.S3.ListObjectVersions(ctx, input)
 		if err != nil {
-			return fmt.Errorf("failed to get relative path: %w", err)
-		}
-
-		// Convert Windows paths to S3 format
-		s3Key := filepath.ToSlash(relPath)
-		if s3Prefix != "" {
-			s3Key = s3Prefix + "/" + s3Key
+			return nil, fmt.Errorf("failed to list object versions: %w", err)
 		}
 
-		// Check if object exists and compare modification time
-		shouldUpload := true
-		headInput := &s3.HeadObjectInput{
-			Bucket: &bucketName,
-			Key:    &s3Key,
-		}
-
-		headResult, err := c.S3.HeadObject(ctx, headInput)
-		if err == nil {
-			// Object exists, check if local file is newer
-			if headResult.LastModified != nil && !info.ModTime().After(*headResult.LastModified) {
-				shouldUpload = false
+		for _, version := range result.Versions {
+			e := entry{version: S3ObjectVersion{
+				Key:          getString(version.Key),
+				VersionId:    getString(version.VersionId),
+				IsLatest:     getBool(version.IsLatest),
+				Size:         getInt64(version.Size),
+				StorageClass: string(version.StorageClass),
+				ETag:         getString(version.ETag),
+			}}
+			if version.LastModified != nil {
+				e.modified = *version.LastModified
 			}
+			entries = append(entries, e)
 		}
-
-		if shouldUpload {
-			err = c.UploadObjectWithProgress(ctx, bucketName, s3Key, path, progressCallback)
-			if err != nil {
-				return fmt.Errorf("failed to upload %s: %w", path, err)
+		for _, marker := range result.DeleteMarkers {
+			e := entry{version: S3ObjectVersion{
+				Key:            getString(marker.Key),
+				VersionId:      getString(marker.VersionId),
+				IsLatest:       getBool(marker.IsLatest),
+				IsDeleteMarker: true,
+			}}
+			if marker.LastModified != nil {
+				e.modified = *marker.LastModified
 			}
+			entries = append(entries, e)
 		}
 
-		return nil
-	})
-}
-
-// SyncS3ToLocal syncs an S3 bucket prefix to a local directory
-func (c *Client) SyncS3ToLocal(ctx context.Context, bucketName, s3Prefix, localDir string, progressCallback ProgressCallback) error {
-	var continuationToken *string
-
-	for {
-		// List objects with prefix
-		result, err := c.ListObjects(ctx, bucketName, s3Prefix, continuationToken)
-		if err != nil {
-			return err
+		if !getBool(result.IsTruncated) {
+			break
 		}
+		input.KeyMarker = result.NextKeyMarker
+		input.VersionIdMarker = result.NextVersionIdMarker
+	}
 
-		for _, obj := range result.Objects {
-			// Skip folders
-			if obj.IsFolder {
-				continue
-			}
-
-			// Calculate local path
-			relKey := strings.TrimPrefix(obj.Key, s3Prefix)
-			relKey = strings.TrimPrefix(relKey, "/")
-			localPath := filepath.Join(localDir, filepath.FromSlash(relKey))
-
-			// Create directory if needed
-			localDir := filepath.Dir(localPath)
-			if err := os.MkdirAll(localDir, 0755); err != nil {
-				return fmt.Errorf("failed to create directory: %w", err)
-			}
-
-			// Check if local file exists and compare modification time
-			shouldDownload := true
-			if fileInfo, err := os.Stat(localPath); err == nil {
-				// Parse object's last modified time
-				objTime, _ := time.Parse("2006-01-02 15:04:05", obj.LastModified)
-				if !objTime.After(fileInfo.ModTime()) {
-					shouldDownload = false
-				}
-			}
-
-			if shouldDownload {
-				err = c.DownloadObjectWithProgress(ctx, bucketName, obj.Key, localPath, progressCallback)
-				if err != nil {
-					return fmt.Errorf("failed to download %s: %w", obj.Key, err)
-				}
-			}
+	// S3 lists versions and delete markers apart, so merge them back into
+	// the order they were made in
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.version.Key != b.version.Key {
+			return a.version.Key < b.version.Key
 		}
-
-		if !result.IsTruncated {
-			break
+		if a.version.IsLatest != b.version.IsLatest {
+			return a.version.IsLatest
 		}
+		return a.modified.After(b.modified)
+	})
 
-		continuationToken = result.NextContinuationToken
+	versions := make([]S3ObjectVersion, 0, len(entries))
+	for _, e := range entries {
+		if !e.modified.IsZero() {
+			e.version.LastModified = e.modified.Format("2006-01-02 15:04:05")
+		}
+		versions = append(versions, e.version)
 	}
-
-	return nil
+	return versions, nil
 }
 
-// ListObjectVersions lists all versions of objects in a bucket
-func (c *Client) ListObjectVersions(ctx context.Context, bucketName, prefix string) ([]S3ObjectVersion, error) {
-	input := &s3.ListObjectVersionsInput{
-		Bucket: &bucketName,
+// S3ObjectVersion represents a version of an S3 object, or a delete marker
+type S3ObjectVersion struct {
+	Key            string
+	VersionId      string
+	IsLatest       bool
+	IsDeleteMarker bool
+	Size           int64
+	LastModified   string
+	StorageClass   string
+	ETag           string
+}
+
+// GetObjectHistory lists the versions and delete markers of one object,
+// newest first
+func (c *Client) GetObjectHistory(ctx context.Context, bucketName, key string) ([]S3ObjectVersion, error) {
+	versions, err := c.ListObjectVersions(ctx, bucketName, key)
+	if err != nil {
+		return nil, err
 	}
 
-	if prefix != "" {
-		input.Prefix = &prefix
+	// The listing also has the keys that start with this one
+	var history []S3ObjectVersion
+	for _, version := range versions {
+		if version.Key == key {
+			history = append(history, version)
+		}
 	}
+	return history, nil
+}
 
-	result, err := c.S3.ListObjectVersions(ctx, input)
+// ReadObjectVersion returns the content of a version of an object, failing
+// if it is larger than limit bytes
+func (c *Client) ReadObjectVersion(ctx context.Context, bucketName, key, versionId string, limit int64) ([]byte, error) {
+	result, err := c.S3.GetObject(ctx, &s3.GetObjectInput{
+		Bucket:    &bucketName,
+		Key:       &key,
+		VersionId: &versionId,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to list object versions: %w", err)
+		return nil, fmt.Errorf("failed to get object version: %w", err)
 	}
+	defer result.Body.Close()
 
-	var versions []S3ObjectVersion
-	for _, version := range result.Versions {
-		v := S3ObjectVersion{
-			Key:          getString(version.Key),
-			VersionId:    getString(version.VersionId),
-			IsLatest:     getBool(version.IsLatest),
-			Size:         getInt64(version.Size),
-			StorageClass: string(version.StorageClass),
-		}
-
-		if version.LastModified != nil {
-			v.LastModified = version.LastModified.Format("2006-01-02 15:04:05")
-		}
-
-		versions = append(versions, v)
+	data, err := io.ReadAll(io.LimitReader(result.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object version: %w", err)
 	}
-
-	return versions, nil
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("version %s is larger than %d bytes", versionId, limit)
+	}
+	return data, nil
 }
 
-// S3ObjectVersion represents a version of an S3 object
-type S3ObjectVersion struct {
-	Key          string
-	VersionId    string
-	IsLatest     bool
-	Size         int64
-	LastModified string
-	StorageClass string
+// RestoreObjectVersion makes an earlier version of an object current again
+// by copying it on top. Nothing is deleted, so the restore shows up as a
+// new version and can itself be undone.
+func (c *Client) RestoreObjectVersion(ctx context.Context, bucketName, key, versionId string, size int64) error {
+	obj := S3Object{Key: key, Size: size, VersionId: versionId}
+	return c.copyObject(ctx, bucketName, obj, bucketName, key, "")
 }
 
 // GetObjectVersion downloads a specific version of an S3 object
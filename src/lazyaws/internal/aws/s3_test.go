@@ -1,6 +1,11 @@
 package aws
 
 import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -207,93 +212,151 @@ func TestProgressCallback(t *testing.T) {
 	}
 }
 
-func TestContainsIgnoreCase(t *testing.T) {
-	// Test case-insensitive string matching
-	tests := []struct {
-		str      string
-		substr   string
-		expected bool
-	}{
-		{"HelloWorld", "hello", true},
-		{"HelloWorld", "WORLD", true},
-		{"HelloWorld", "test", false},
-		{"test-file.txt", "FILE", true},
-		{"", "test", false},
-		{"test", "", true},
-	}
-
-	for _, tt := range tests {
-		result := containsIgnoreCase(tt.str, tt.substr)
-		if result != tt.expected {
-			t.Errorf("containsIgnoreCase(%q, %q) = %v, expected %v",
-				tt.str, tt.substr, result, tt.expected)
-		}
+func TestListObjectsWithFakeBackend(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.S3.AddBucket("test-bucket", "us-east-1")
+	backend.S3.AddObject("test-bucket", "readme.txt", []byte("hello"))
+	backend.S3.AddObject("test-bucket", "logs/a.log", []byte("a"))
+	backend.S3.AddObject("test-bucket", "logs/b.log", []byte("b"))
+	client := backend.Client("us-east-1")
+
+	result, err := client.ListObjects(context.Background(), "test-bucket", "", nil)
+	if err != nil {
+		t.Fatalf("ListObjects returned error: %v", err)
+	}
+
+	if len(result.Objects) != 2 {
+		t.Fatalf("Expected 2 entries (1 folder, 1 file), got %d", len(result.Objects))
+	}
+
+	if !result.Objects[0].IsFolder || result.Objects[0].Key != "logs/" {
+		t.Errorf("Expected folder 'logs/', got %+v", result.Objects[0])
+	}
+
+	if result.Objects[1].Key != "readme.txt" || result.Objects[1].Size != 5 {
+		t.Errorf("Expected file 'readme.txt' of size 5, got %+v", result.Objects[1])
+	}
+
+	if result.IsTruncated {
+		t.Errorf("Expected IsTruncated to be false, got true")
+	}
+
+	result, err = client.ListObjects(context.Background(), "test-bucket", "logs/", nil)
+	if err != nil {
+		t.Fatalf("ListObjects returned error: %v", err)
+	}
+
+	if len(result.Objects) != 2 {
+		t.Errorf("Expected 2 objects under 'logs/', got %d", len(result.Objects))
 	}
 }
 
-func TestToLower(t *testing.T) {
-	// Test toLower helper function
-	tests := []struct {
-		input    string
-		expected string
-	}{
-		{"HELLO", "hello"},
-		{"HeLLo", "hello"},
-		{"hello", "hello"},
-		{"TEST123", "test123"},
-		{"", ""},
-	}
-
-	for _, tt := range tests {
-		result := toLower(tt.input)
-		if result != tt.expected {
-			t.Errorf("toLower(%q) = %q, expected %q", tt.input, result, tt.expected)
-		}
+func TestUploadDownloadWithFakeBackend(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.S3.AddBucket("test-bucket", "us-east-1")
+	client := backend.Client("us-east-1")
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	if err := os.WriteFile(src, []byte("lazyaws"), 0644); err != nil {
+		t.Fatalf("Failed to write source file: %v", err)
+	}
+
+	if err := client.UploadObject(context.Background(), "test-bucket", "dir/src.txt", src); err != nil {
+		t.Fatalf("UploadObject returned error: %v", err)
+	}
+
+	dst := filepath.Join(dir, "dst.txt")
+	if err := client.DownloadObject(context.Background(), "test-bucket", "dir/src.txt", dst); err != nil {
+		t.Fatalf("DownloadObject returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatalf("Failed to read downloaded file: %v", err)
+	}
+
+	if string(data) != "lazyaws" {
+		t.Errorf("Expected downloaded content 'lazyaws', got '%s'", string(data))
 	}
 }
 
-func TestContains(t *testing.T) {
-	// Test contains helper function
-	tests := []struct {
-		str      string
-		substr   string
-		expected bool
-	}{
-		{"hello world", "world", true},
-		{"hello world", "test", false},
-		{"test", "test", true},
-		{"test", "", true},
-		{"", "test", false},
-	}
-
-	for _, tt := range tests {
-		result := contains(tt.str, tt.substr)
-		if result != tt.expected {
-			t.Errorf("contains(%q, %q) = %v, expected %v",
-				tt.str, tt.substr, result, tt.expected)
-		}
+func TestObjectRangeReader(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.S3.AddBucket("test-bucket", "us-east-1")
+	backend.S3.AddObject("test-bucket", "data.bin", []byte("0123456789"))
+	client := backend.Client("us-east-1")
+
+	details, err := client.GetObjectDetails(context.Background(), "test-bucket", "data.bin")
+	if err != nil {
+		t.Fatalf("GetObjectDetails returned error: %v", err)
+	}
+	r := client.NewObjectRangeReader(context.Background(), "test-bucket", "data.bin", details.ETag, details.Size)
+
+	buf := make([]byte, 4)
+	if n, err := r.ReadAt(buf, 3); n != 4 || err != nil || string(buf) != "3456" {
+		t.Errorf("Expected 3456, got %q (%d, %v)", buf[:n], n, err)
+	}
+	if n, err := r.ReadAt(buf, 8); n != 2 || err != io.EOF || string(buf[:n]) != "89" {
+		t.Errorf("Expected 89 and EOF at the end, got %q (%d, %v)", buf[:n], n, err)
+	}
+
+	// Reads stop once the object changes
+	backend.S3.AddObject("test-bucket", "data.bin", []byte("changed"))
+	if _, err := r.ReadAt(buf, 0); err == nil || !strings.Contains(err.Error(), "PreconditionFailed") {
+		t.Errorf("Expected a changed object to fail the read, got %v", err)
 	}
 }
 
-func TestIndexOfSubstring(t *testing.T) {
-	// Test indexOfSubstring helper function
-	tests := []struct {
-		str      string
-		substr   string
-		expected int
-	}{
-		{"hello world", "world", 6},
-		{"hello world", "hello", 0},
-		{"hello world", "test", -1},
-		{"test", "test", 0},
-		{"", "test", -1},
-	}
-
-	for _, tt := range tests {
-		result := indexOfSubstring(tt.str, tt.substr)
-		if result != tt.expected {
-			t.Errorf("indexOfSubstring(%q, %q) = %d, expected %d",
-				tt.str, tt.substr, result, tt.expected)
-		}
+func TestObjectVersionHistoryRestoreAndUndelete(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.S3.AddBucket("test-bucket", "us-east-1")
+	backend.S3.AddObject("test-bucket", "config.yaml", []byte("v: 1\n"))
+	backend.S3.AddObject("test-bucket", "config.yaml.bak", []byte("other key"))
+	client := backend.Client("us-east-1")
+	ctx := context.Background()
+
+	if err := client.EnableBucketVersioning(ctx, "test-bucket"); err != nil {
+		t.Fatalf("EnableBucketVersioning returned error: %v", err)
+	}
+	backend.S3.AddObject("test-bucket", "config.yaml", []byte("v: 2\n"))
+	if err := client.DeleteObject(ctx, "test-bucket", "config.yaml"); err != nil {
+		t.Fatalf("DeleteObject returned error: %v", err)
+	}
+
+	history, err := client.GetObjectHistory(ctx, "test-bucket", "config.yaml")
+	if err != nil {
+		t.Fatalf("GetObjectHistory returned error: %v", err)
+	}
+	if len(history) != 3 || !history[0].IsDeleteMarker || !history[0].IsLatest || history[2].VersionId != "null" {
+		t.Fatalf("Expected a delete marker over two versions, got %+v", history)
+	}
+
+	data, err := client.ReadObjectVersion(ctx, "test-bucket", "config.yaml", history[2].VersionId, 1024)
+	if err != nil || string(data) != "v: 1\n" {
+		t.Errorf("Expected the first version's content, got %q (%v)", data, err)
+	}
+	if _, err := client.ReadObjectVersion(ctx, "test-bucket", "config.yaml", history[2].VersionId, 2); err == nil {
+		t.Error("Expected a version over the limit to fail")
+	}
+
+	// Removing the delete marker brings the object back
+	if err := client.DeleteObjectVersion(ctx, "test-bucket", "config.yaml", history[0].VersionId); err != nil {
+		t.Fatalf("DeleteObjectVersion returned error: %v", err)
+	}
+	if obj := backend.S3.Buckets["test-bucket"].Objects["config.yaml"]; obj == nil || string(obj.Data) != "v: 2\n" {
+		t.Fatalf("Expected config.yaml to be undeleted, got %v", obj)
+	}
+
+	// Restoring copies the old version on top as a new version
+	if err := client.RestoreObjectVersion(ctx, "test-bucket", "config.yaml", history[2].VersionId, history[2].Size); err != nil {
+		t.Fatalf("RestoreObjectVersion returned error: %v", err)
+	}
+	if obj := backend.S3.Buckets["test-bucket"].Objects["config.yaml"]; string(obj.Data) != "v: 1\n" {
+		t.Errorf("Expected the first version to be current, got %q", obj.Data)
+	}
+	history, _ = client.GetObjectHistory(ctx, "test-bucket", "config.yaml")
+	if len(history) != 3 || history[0].Size != 5 || !history[0].IsLatest {
+		t.Errorf("Expected the restore to add a version, got %+v", history)
 	}
 }
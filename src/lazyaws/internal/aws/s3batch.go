@@ -0,0 +1,531 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	sdkaws "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	deleteBatchSize  = 1000 // Most keys DeleteObjects accepts per call
+	copyConcurrency  = 8    // Per-object requests run at once
+	maxObjectTags    = 10   // Most tags S3 allows on an object
+	batchErrorsShown = 3    // Failures quoted in a batch error
+)
+
+// Objects larger than maxCopyObjectSize can't be copied with one
+// CopyObject call and are copied in copyPartSize parts. They are variables
+// so tests can shrink them.
+var (
+	maxCopyObjectSize int64 = 5 << 30
+	copyPartSize      int64 = 512 << 20
+)
+
+// BatchProgressCallback receives the number of objects handled so far
+type BatchProgressCallback func(done, total int)
+
+// BatchFailure is an object a batch operation could not handle
+type BatchFailure struct {
+	Key string
+	Err error
+}
+
+// BatchResult summarises an operation over many objects
+type BatchResult struct {
+	Total     int
+	Succeeded int
+	Bytes     int64 // Size of the objects that succeeded
+	Failures  []BatchFailure
+}
+
+// Err returns nil when every object succeeded, or an error quoting the
+// first few failures
+func (r *BatchResult) Err() error {
+	if len(r.Failures) == 0 {
+		return nil
+	}
+	var quoted []string
+	for i, failure := range r.Failures {
+		if i == batchErrorsShown {
+			quoted = append(quoted, fmt.Sprintf("and %d more", len(r.Failures)-i))
+			break
+		}
+		quoted = append(quoted, fmt.Sprintf("%s: %v", failure.Key, failure.Err))
+	}
+	return fmt.Errorf("failed for %d of %d objects: %s", len(r.Failures), r.Total, strings.Join(quoted, "; "))
+}
+
+// ExpandS3Keys lists the objects a selection covers. Keys ending in "/" are
+// folders and stand for every object under them; other keys are objects.
+// The result is sorted by key.
+func (c *Client) ExpandS3Keys(ctx context.Context, bucketName string, keys []string) ([]S3Object, error) {
+	seen := make(map[string]bool)
+	var objects []S3Object
+	for _, key := range keys {
+		if !strings.HasSuffix(key, "/") {
+			if !seen[key] {
+				seen[key] = true
+				details, err := c.S3.HeadObject(ctx, &s3.HeadObjectInput{Bucket: &bucketName, Key: &key})
+				if err != nil {
+					return nil, fmt.Errorf("failed to get %s: %w", key, err)
+				}
+				objects = append(objects, S3Object{Key: key, Size: getInt64(details.ContentLength), StorageClass: string(details.StorageClass)})
+			}
+			continue
+		}
+
+		// List without a delimiter to reach every level below the folder,
+		// including folder placeholder objects
+		input := &s3.ListObjectsV2Input{Bucket: &bucketName, Prefix: &key}
+		for {
+			output, err := c.S3.ListObjectsV2(ctx, input)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list %s: %w", key, err)
+			}
+			for _, obj := range output.Contents {
+				objKey := getString(obj.Key)
+				if !seen[objKey] {
+					seen[objKey] = true
+					objects = append(objects, S3Object{Key: objKey, Size: getInt64(obj.Size), StorageClass: string(obj.StorageClass)})
+				}
+			}
+			if !getBool(output.IsTruncated) {
+				break
+			}
+			input.ContinuationToken = output.NextContinuationToken
+		}
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+// DeleteObjects deletes keys from a bucket, up to 1000 per request
+func (c *Client) DeleteObjects(ctx context.Context, bucketName string, objects []S3Object, progress BatchProgressCallback) *BatchResult {
+	result := &BatchResult{Total: len(objects)}
+	quiet := true
+	for start := 0; start < len(objects); start += deleteBatchSize {
+		end := start + deleteBatchSize
+		if end > len(objects) {
+			end = len(objects)
+		}
+		batch := objects[start:end]
+
+		if err := ctx.Err(); err != nil {
+			result.fail(batch, err)
+			continue
+		}
+
+		ids := make([]types.ObjectIdentifier, len(batch))
+		for i := range batch {
+			ids[i] = types.ObjectIdentifier{Key: &batch[i].Key}
+		}
+		output, err := c.S3.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: &bucketName,
+			Delete: &types.Delete{Objects: ids, Quiet: &quiet},
+		})
+		if err != nil {
+			result.fail(batch, fmt.Errorf("failed to delete objects: %w", err))
+		} else {
+			// Quiet mode only reports the keys that failed
+			failed := make(map[string]bool)
+			for _, e := range output.Errors {
+				key := getString(e.Key)
+				failed[key] = true
+				result.Failures = append(result.Failures, BatchFailure{
+					Key: key,
+					Err: fmt.Errorf("%s: %s", getString(e.Code), getString(e.Message)),
+				})
+			}
+			for _, obj := range batch {
+				if !failed[obj.Key] {
+					result.Succeeded++
+					result.Bytes += obj.Size
+				}
+			}
+		}
+
+		if progress != nil {
+			progress(end, len(objects))
+		}
+	}
+	return result
+}
+
+// CopyObjects copies objects server-side to destBucket, replacing the root
+// they are listed under with destPrefix, so copying "logs/" from root "" to
+// "archive/" creates "archive/logs/...". The destination may be in another
+// region.
+func (c *Client) CopyObjects(ctx context.Context, srcBucket, root string, objects []S3Object, destBucket, destPrefix string, progress BatchProgressCallback) *BatchResult {
+	result := &BatchResult{Total: len(objects)}
+
+	destRegion, err := c.GetBucketRegion(ctx, destBucket)
+	if err != nil {
+		result.fail(objects, err)
+		return result
+	}
+	regionOpt := withRegion(destRegion)
+
+	return forEachObject(ctx, objects, progress, func(obj S3Object) error {
+		destKey := destPrefix + strings.TrimPrefix(obj.Key, root)
+		// A move onto itself would copy and then delete the object
+		if srcBucket == destBucket && destKey == obj.Key {
+			return fmt.Errorf("source and destination are the same")
+		}
+		return c.copyObject(ctx, srcBucket, obj, destBucket, destKey, "", regionOpt)
+	})
+}
+
+// MoveObjects copies objects like CopyObjects and then deletes the sources
+// that were copied. Sources whose copy failed are kept.
+func (c *Client) MoveObjects(ctx context.Context, srcBucket, root string, objects []S3Object, destBucket, destPrefix string, progress BatchProgressCallback) *BatchResult {
+	// Copies and deletes each count for half of the progress
+	total := 2 * len(objects)
+	copied := c.CopyObjects(ctx, srcBucket, root, objects, destBucket, destPrefix, func(done, _ int) {
+		if progress != nil {
+			progress(done, total)
+		}
+	})
+
+	failed := make(map[string]bool)
+	for _, failure := range copied.Failures {
+		failed[failure.Key] = true
+	}
+	var moved []S3Object
+	for _, obj := range objects {
+		if !failed[obj.Key] {
+			moved = append(moved, obj)
+		}
+	}
+
+	deleted := c.DeleteObjects(ctx, srcBucket, moved, func(done, _ int) {
+		if progress != nil {
+			progress(len(objects)+done, total)
+		}
+	})
+	for i := range deleted.Failures {
+		deleted.Failures[i].Err = fmt.Errorf("copied but not removed: %w", deleted.Failures[i].Err)
+	}
+
+	result := &BatchResult{
+		Total:     len(objects),
+		Succeeded: deleted.Succeeded,
+		Bytes:     deleted.Bytes,
+		Failures:  append(copied.Failures, deleted.Failures...),
+	}
+	if progress != nil {
+		progress(total, total)
+	}
+	return result
+}
+
+// QueueDownloads queues the download of objects listed under root into
+// localDir, keeping their paths below root, and returns how many were
+// queued. Folder placeholder objects are skipped, and so are the keys
+// returned as unsafe, which would be written outside localDir.
+func (c *Client) QueueDownloads(transfers *TransferManager, bucketName, root string, objects []S3Object, localDir string) (queued int, unsafe []string) {
+	for _, obj := range objects {
+		if strings.HasSuffix(obj.Key, "/") {
+			continue
+		}
+		localPath, ok := localSyncPath(localDir, strings.TrimPrefix(obj.Key, root))
+		if !ok {
+			unsafe = append(unsafe, obj.Key)
+			continue
+		}
+		transfers.Enqueue(c, TransferRequest{
+			Kind:      TransferDownload,
+			Bucket:    bucketName,
+			Key:       obj.Key,
+			LocalPath: localPath,
+			Size:      obj.Size,
+		})
+		queued++
+	}
+	return queued, unsafe
+}
+
+// PresignedObject is a presigned GET URL for an object
+type PresignedObject struct {
+	Key string
+	URL string
+}
+
+// ParseStorageClass checks a storage class name, ignoring case
+func ParseStorageClass(name string) (string, error) {
+	for _, class := range types.StorageClass("").Values() {
+		if strings.EqualFold(name, string(class)) {
+			return string(class), nil
+		}
+	}
+	return "", fmt.Errorf("unknown storage class %q", name)
+}
+
+// ChangeStorageClass copies objects onto themselves in a new storage class,
+// keeping their metadata and tags. Objects already in the class are left
+// alone.
+func (c *Client) ChangeStorageClass(ctx context.Context, bucketName string, objects []S3Object, storageClass string, progress BatchProgressCallback) *BatchResult {
+	class := types.StorageClass(storageClass)
+	return forEachObject(ctx, objects, progress, func(obj S3Object) error {
+		current := obj.StorageClass
+		if current == "" {
+			// HeadObject leaves out the class of STANDARD objects
+			current = string(types.StorageClassStandard)
+		}
+		if current == storageClass {
+			return nil
+		}
+		return c.copyObject(ctx, bucketName, obj, bucketName, obj.Key, class)
+	})
+}
+
+// AddObjectTags merges tags into the existing tags of each object,
+// replacing the values of keys they already have
+func (c *Client) AddObjectTags(ctx context.Context, bucketName string, objects []S3Object, tags map[string]string, progress BatchProgressCallback) *BatchResult {
+	return forEachObject(ctx, objects, progress, func(obj S3Object) error {
+		key := obj.Key
+		existing, err := c.S3.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{Bucket: &bucketName, Key: &key})
+		if err != nil {
+			return fmt.Errorf("failed to get tags: %w", err)
+		}
+
+		merged := make(map[string]string)
+		for _, tag := range existing.TagSet {
+			merged[getString(tag.Key)] = getString(tag.Value)
+		}
+		for k, v := range tags {
+			merged[k] = v
+		}
+		if len(merged) > maxObjectTags {
+			return fmt.Errorf("objects can have at most %d tags, this one would have %d", maxObjectTags, len(merged))
+		}
+
+		_, err = c.S3.PutObjectTagging(ctx, &s3.PutObjectTaggingInput{
+			Bucket:  &bucketName,
+			Key:     &key,
+			Tagging: &types.Tagging{TagSet: tagSet(merged)},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to put tags: %w", err)
+		}
+		return nil
+	})
+}
+
+// PresignObjects generates presigned GET URLs for objects, skipping folder
+// placeholders. The URLs are in the order of objects.
+func (c *Client) PresignObjects(ctx context.Context, bucketName string, objects []S3Object, expirationSeconds int) ([]PresignedObject, *BatchResult) {
+	var files []S3Object
+	for _, obj := range objects {
+		if !strings.HasSuffix(obj.Key, "/") {
+			files = append(files, obj)
+		}
+	}
+
+	result := &BatchResult{Total: len(files)}
+	var urls []PresignedObject
+	for _, obj := range files {
+		presigned, err := c.GeneratePresignedURL(ctx, bucketName, obj.Key, expirationSeconds)
+		if err != nil {
+			result.Failures = append(result.Failures, BatchFailure{Key: obj.Key, Err: err})
+			continue
+		}
+		urls = append(urls, PresignedObject{Key: obj.Key, URL: presigned})
+		result.Succeeded++
+		result.Bytes += obj.Size
+	}
+	return urls, result
+}
+
+// copyObject copies one object server-side, in parts if it is too large
+// for CopyObject. An empty storageClass keeps S3's default of STANDARD.
+func (c *Client) copyObject(ctx context.Context, srcBucket string, obj S3Object, destBucket, destKey string, storageClass types.StorageClass, optFns ...func(*s3.Options)) error {
+	source := copySource(srcBucket, obj.Key, obj.VersionId)
+	if obj.Size <= maxCopyObjectSize {
+		_, err := c.S3.CopyObject(ctx, &s3.CopyObjectInput{
+			Bucket:       &destBucket,
+			Key:          &destKey,
+			CopySource:   &source,
+			StorageClass: storageClass,
+		}, optFns...)
+		if err != nil {
+			return fmt.Errorf("failed to copy object: %w", err)
+		}
+		return nil
+	}
+
+	// Unlike CopyObject, a multipart copy doesn't carry over the metadata
+	// and tags of the source
+	versionId := nilIfEmpty(obj.VersionId)
+	head, err := c.S3.HeadObject(ctx, &s3.HeadObjectInput{Bucket: &srcBucket, Key: &obj.Key, VersionId: versionId})
+	if err != nil {
+		return fmt.Errorf("failed to get object: %w", err)
+	}
+	tagging, err := c.S3.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{Bucket: &srcBucket, Key: &obj.Key, VersionId: versionId})
+	if err != nil {
+		return fmt.Errorf("failed to get tags: %w", err)
+	}
+	tags := url.Values{}
+	for _, tag := range tagging.TagSet {
+		tags.Set(getString(tag.Key), getString(tag.Value))
+	}
+	input := &s3.CreateMultipartUploadInput{
+		Bucket:             &destBucket,
+		Key:                &destKey,
+		StorageClass:       storageClass,
+		ContentType:        head.ContentType,
+		ContentEncoding:    head.ContentEncoding,
+		ContentDisposition: head.ContentDisposition,
+		CacheControl:       head.CacheControl,
+		Metadata:           head.Metadata,
+	}
+	if len(tags) > 0 {
+		encoded := tags.Encode()
+		input.Tagging = &encoded
+	}
+	return c.multipartCopy(ctx, source, obj.Size, input, optFns...)
+}
+
+// multipartCopy copies size bytes from source into the object input
+// creates, one UploadPartCopy of copyPartSize at a time
+func (c *Client) multipartCopy(ctx context.Context, source string, size int64, input *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) error {
+	destBucket, destKey := input.Bucket, input.Key
+	created, err := c.S3.CreateMultipartUpload(ctx, input, optFns...)
+	if err != nil {
+		return fmt.Errorf("failed to start multipart copy: %w", err)
+	}
+	uploadID := created.UploadId
+
+	var parts []types.CompletedPart
+	for offset, number := int64(0), int32(1); offset < size; offset, number = offset+copyPartSize, number+1 {
+		last := offset + copyPartSize - 1
+		if last >= size {
+			last = size - 1
+		}
+		byteRange := fmt.Sprintf("bytes=%d-%d", offset, last)
+		partNumber := number
+		output, err := c.S3.UploadPartCopy(ctx, &s3.UploadPartCopyInput{
+			Bucket:          destBucket,
+			Key:             destKey,
+			UploadId:        uploadID,
+			PartNumber:      &partNumber,
+			CopySource:      &source,
+			CopySourceRange: &byteRange,
+		}, optFns...)
+		if err != nil {
+			c.S3.AbortMultipartUpload(context.Background(), &s3.AbortMultipartUploadInput{Bucket: destBucket, Key: destKey, UploadId: uploadID}, optFns...)
+			return fmt.Errorf("failed to copy part %d: %w", number, err)
+		}
+		var etag *string
+		if output.CopyPartResult != nil {
+			etag = output.CopyPartResult.ETag
+		}
+		parts = append(parts, types.CompletedPart{ETag: etag, PartNumber: &partNumber})
+	}
+
+	_, err = c.S3.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          destBucket,
+		Key:             destKey,
+		UploadId:        uploadID,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	}, optFns...)
+	if err != nil {
+		c.S3.AbortMultipartUpload(context.Background(), &s3.AbortMultipartUploadInput{Bucket: destBucket, Key: destKey, UploadId: uploadID}, optFns...)
+		return fmt.Errorf("failed to complete multipart copy: %w", err)
+	}
+	return nil
+}
+
+// tagSet converts tags to a tag set sorted by key
+func tagSet(tags map[string]string) []types.Tag {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	set := make([]types.Tag, len(keys))
+	for i, k := range keys {
+		set[i] = types.Tag{Key: &keys[i], Value: sdkaws.String(tags[k])}
+	}
+	return set
+}
+
+// fail records the same error for every object of a batch
+func (r *BatchResult) fail(objects []S3Object, err error) {
+	for _, obj := range objects {
+		r.Failures = append(r.Failures, BatchFailure{Key: obj.Key, Err: err})
+	}
+}
+
+// forEachObject runs fn for every object, a few at a time, and collects
+// the failures sorted by key
+func forEachObject(ctx context.Context, objects []S3Object, progress BatchProgressCallback, fn func(S3Object) error) *BatchResult {
+	result := &BatchResult{Total: len(objects)}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, copyConcurrency)
+	done := 0
+	for _, obj := range objects {
+		obj := obj
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := ctx.Err()
+			if err == nil {
+				err = fn(obj)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.Failures = append(result.Failures, BatchFailure{Key: obj.Key, Err: err})
+			} else {
+				result.Succeeded++
+				result.Bytes += obj.Size
+			}
+			done++
+			if progress != nil {
+				progress(done, len(objects))
+			}
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(result.Failures, func(i, j int) bool { return result.Failures[i].Key < result.Failures[j].Key })
+	return result
+}
+
+// copySource formats a CopySource header, URL-encoding the key but keeping
+// its slashes. An empty versionId copies the current version.
+func copySource(bucket, key, versionId string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	source := bucket + "/" + strings.Join(segments, "/")
+	if versionId != "" {
+		source += "?versionId=" + url.QueryEscape(versionId)
+	}
+	return source
+}
+
+// withRegion sends a request to the region of the bucket it is for, which
+// S3 requires when that isn't the client's region
+func withRegion(region string) func(*s3.Options) {
+	return func(o *s3.Options) {
+		if region != "" {
+			o.Region = region
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDeleteObjectsInBatches(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.S3.AddBucket("bucket", "us-east-1")
+	for i := 0; i < 2500; i++ {
+		backend.S3.AddObject("bucket", fmt.Sprintf("logs/%04d.txt", i), []byte("x"))
+	}
+	backend.S3.AddObject("bucket", "logs/", nil)
+	backend.S3.AddObject("bucket", "keep.txt", []byte("keep"))
+	backend.S3.DeleteErrors = map[string]string{"logs/0042.txt": "AccessDenied"}
+	client := backend.Client("us-east-1")
+	ctx := context.Background()
+
+	objects, err := client.ExpandS3Keys(ctx, "bucket", []string{"logs/"})
+	if err != nil {
+		t.Fatalf("ExpandS3Keys returned error: %v", err)
+	}
+	if len(objects) != 2501 {
+		t.Fatalf("Expected 2500 objects and the folder placeholder, got %d", len(objects))
+	}
+
+	var lastDone int
+	result := client.DeleteObjects(ctx, "bucket", objects, func(done, total int) { lastDone = done })
+	if backend.S3.DeleteBatches != 3 {
+		t.Errorf("Expected 3 DeleteObjects calls, got %d", backend.S3.DeleteBatches)
+	}
+	if lastDone != 2501 {
+		t.Errorf("Expected progress to reach 2501, got %d", lastDone)
+	}
+	if result.Succeeded != 2500 || len(result.Failures) != 1 || result.Failures[0].Key != "logs/0042.txt" {
+		t.Errorf("Expected one failure for logs/0042.txt, got %+v", result.Failures)
+	}
+	if err := result.Err(); err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Errorf("Expected the error to quote AccessDenied, got %v", err)
+	}
+
+	remaining := backend.S3.Buckets["bucket"].Objects
+	if len(remaining) != 2 {
+		t.Errorf("Expected only keep.txt and the failed key to remain, got %d objects", len(remaining))
+	}
+}
+
+func TestMoveObjectsAcrossRegions(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.S3.AddBucket("src", "us-east-1")
+	backend.S3.AddBucket("dest", "eu-west-1")
+	backend.S3.AddObject("src", "photos/a.jpg", []byte("a"))
+	backend.S3.AddObject("src", "photos/2024/b.jpg", []byte(strings.Repeat("b", 10)))
+	big := backend.S3.Buckets["src"].Objects["photos/2024/b.jpg"]
+	big.ContentType = "image/jpeg"
+	big.Tags = map[string]string{"album": "2024"}
+	client := backend.Client("us-east-1")
+	ctx := context.Background()
+
+	// Copy the larger object in parts
+	maxCopyObjectSize, copyPartSize = 4, 4
+	defer func() { maxCopyObjectSize, copyPartSize = 5<<30, 512<<20 }()
+
+	objects, err := client.ExpandS3Keys(ctx, "src", []string{"photos/"})
+	if err != nil {
+		t.Fatalf("ExpandS3Keys returned error: %v", err)
+	}
+	result := client.MoveObjects(ctx, "src", "", objects, "dest", "archive/", nil)
+	if err := result.Err(); err != nil {
+		t.Fatalf("MoveObjects failed: %v", err)
+	}
+
+	dest := backend.S3.Buckets["dest"].Objects
+	if string(dest["archive/photos/2024/b.jpg"].Data) != strings.Repeat("b", 10) || string(dest["archive/photos/a.jpg"].Data) != "a" {
+		t.Errorf("Expected both objects under archive/photos/, got %v", dest)
+	}
+	if copied := dest["archive/photos/2024/b.jpg"]; copied.ContentType != "image/jpeg" || copied.Tags["album"] != "2024" {
+		t.Errorf("Expected the multipart copy to keep its content type and tags, got %q %v", copied.ContentType, copied.Tags)
+	}
+	if len(backend.S3.Buckets["src"].Objects) != 0 {
+		t.Errorf("Expected the sources to be removed after the move")
+	}
+
+	// Moving objects onto themselves fails instead of deleting them
+	backend.S3.AddObject("src", "x.txt", []byte("x"))
+	result = client.MoveObjects(ctx, "src", "", []S3Object{{Key: "x.txt", Size: 1}}, "src", "", nil)
+	if result.Err() == nil {
+		t.Error("Expected a move onto itself to fail")
+	}
+	if _, ok := backend.S3.Buckets["src"].Objects["x.txt"]; !ok {
+		t.Error("Expected x.txt to survive a move onto itself")
+	}
+}
+
+func TestQueueDownloadsOfPrefix(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.S3.AddBucket("bucket", "us-east-1")
+	backend.S3.AddObject("bucket", "data/", nil)
+	backend.S3.AddObject("bucket", "data/a.txt", []byte("alpha"))
+	backend.S3.AddObject("bucket", "data/sub/b.txt", []byte("bravo"))
+	backend.S3.AddObject("bucket", "data/../../escape.txt", []byte("escape"))
+	client := backend.Client("us-east-1")
+	ctx := context.Background()
+
+	objects, err := client.ExpandS3Keys(ctx, "bucket", []string{"data/"})
+	if err != nil {
+		t.Fatalf("ExpandS3Keys returned error: %v", err)
+	}
+	dir := filepath.Join(t.TempDir(), "downloads")
+	transfers := NewTransferManager(ctx, 2, nil)
+	queued, unsafe := client.QueueDownloads(transfers, "bucket", "", objects, dir)
+	if queued != 2 {
+		t.Fatalf("Expected 2 downloads to be queued, got %d", queued)
+	}
+	if len(unsafe) != 1 || unsafe[0] != "data/../../escape.txt" {
+		t.Errorf("Expected the key escaping the directory to be skipped, got %v", unsafe)
+	}
+	if err := transfers.Wait(); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+	for path, want := range map[string]string{"data/a.txt": "alpha", "data/sub/b.txt": "bravo"} {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(path)))
+		if err != nil || string(data) != want {
+			t.Errorf("Expected %s to contain %q, got %q (%v)", path, want, data, err)
+		}
+	}
+}
+
+func TestBulkStorageClassTagsAndPresign(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.S3.AddBucket("bucket", "us-east-1")
+	backend.S3.AddObject("bucket", "docs/", nil)
+	backend.S3.AddObject("bucket", "docs/a.txt", []byte("alpha"))
+	backend.S3.AddObject("bucket", "docs/b.txt", []byte("bravo"))
+	backend.S3.AddObject("bucket", "docs/full.txt", []byte("full"))
+	objs := backend.S3.Buckets["bucket"].Objects
+	objs["docs/a.txt"].Tags = map[string]string{"team": "web", "env": "dev"}
+	objs["docs/full.txt"].Tags = make(map[string]string)
+	for i := 0; i < 10; i++ {
+		objs["docs/full.txt"].Tags[fmt.Sprintf("tag%d", i)] = "x"
+	}
+	client := backend.Client("us-east-1")
+	ctx := context.Background()
+
+	objects, err := client.ExpandS3Keys(ctx, "bucket", []string{"docs/"})
+	if err != nil {
+		t.Fatalf("ExpandS3Keys returned error: %v", err)
+	}
+
+	class, err := ParseStorageClass("standard_ia")
+	if err != nil || class != "STANDARD_IA" {
+		t.Fatalf("Expected STANDARD_IA, got %q (%v)", class, err)
+	}
+	if _, err := ParseStorageClass("FAST"); err == nil {
+		t.Error("Expected an unknown storage class to be rejected")
+	}
+	if result := client.ChangeStorageClass(ctx, "bucket", objects, class, nil); result.Err() != nil {
+		t.Fatalf("ChangeStorageClass failed: %v", result.Err())
+	}
+	if got := objs["docs/a.txt"]; got.StorageClass != "STANDARD_IA" || got.Tags["team"] != "web" {
+		t.Errorf("Expected docs/a.txt in STANDARD_IA with its tags, got %s %v", got.StorageClass, got.Tags)
+	}
+	// Changing to the class the objects are already in copies nothing
+	objects, _ = client.ExpandS3Keys(ctx, "bucket", []string{"docs/"})
+	if result := client.ChangeStorageClass(ctx, "bucket", objects, class, nil); result.Err() != nil || result.Succeeded != 4 {
+		t.Errorf("Expected objects already in the class to succeed, got %+v", result)
+	}
+
+	result := client.AddObjectTags(ctx, "bucket", objects, map[string]string{"env": "prod", "owner": "ops"}, nil)
+	if result.Succeeded != 3 || len(result.Failures) != 1 || result.Failures[0].Key != "docs/full.txt" {
+		t.Errorf("Expected only docs/full.txt to fail, got %+v", result.Failures)
+	}
+	if tags := backend.S3.Buckets["bucket"].Objects["docs/a.txt"].Tags; tags["team"] != "web" || tags["env"] != "prod" || tags["owner"] != "ops" {
+		t.Errorf("Expected the tags to be merged, got %v", tags)
+	}
+
+	urls, result := client.PresignObjects(ctx, "bucket", objects, 600)
+	if result.Err() != nil || len(urls) != 3 {
+		t.Fatalf("Expected 3 presigned URLs without the folder placeholder, got %d (%v)", len(urls), result.Err())
+	}
+	if urls[0].Key != "docs/a.txt" || !strings.Contains(urls[0].URL, "X-Amz-Expires=600") {
+		t.Errorf("Expected a 10 minute URL for docs/a.txt, got %+v", urls[0])
+	}
+}
@@ -0,0 +1,324 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// Bucket configuration documents that can be edited
+const (
+	BucketConfigPolicy            = "policy"
+	BucketConfigCORS              = "cors"
+	BucketConfigLifecycle         = "lifecycle"
+	BucketConfigEncryption        = "encryption"
+	BucketConfigPublicAccessBlock = "public-access-block"
+	BucketConfigTagging           = "tagging"
+)
+
+// BucketConfigKinds lists the bucket configuration documents in the order
+// they are offered
+var BucketConfigKinds = []string{
+	BucketConfigPolicy,
+	BucketConfigCORS,
+	BucketConfigLifecycle,
+	BucketConfigEncryption,
+	BucketConfigPublicAccessBlock,
+	BucketConfigTagging,
+}
+
+// Limits S3 puts on bucket configuration documents
+const (
+	maxBucketPolicySize     = 20 << 10
+	maxCORSRules            = 100
+	maxLifecycleRules       = 1000
+	maxBucketTags           = 50
+	maxBucketTagKeyLength   = 128
+	maxBucketTagValueLength = 256
+)
+
+// notSetErrorCodes are the errors S3 returns when a bucket has no document
+// of a kind
+var notSetErrorCodes = map[string]string{
+	BucketConfigPolicy:            "NoSuchBucketPolicy",
+	BucketConfigCORS:              "NoSuchCORSConfiguration",
+	BucketConfigLifecycle:         "NoSuchLifecycleConfiguration",
+	BucketConfigEncryption:        "ServerSideEncryptionConfigurationNotFoundError",
+	BucketConfigPublicAccessBlock: "NoSuchPublicAccessBlockConfiguration",
+	BucketConfigTagging:           "NoSuchTagSet",
+}
+
+// ParseBucketConfigKind checks the name of a bucket configuration document
+func ParseBucketConfigKind(name string) (string, error) {
+	name = strings.ToLower(name)
+	for _, kind := range BucketConfigKinds {
+		if name == kind {
+			return kind, nil
+		}
+	}
+	return "", fmt.Errorf("unknown bucket configuration %q (want %s)", name, strings.Join(BucketConfigKinds, ", "))
+}
+
+// GetBucketConfig returns a bucket configuration document as indented JSON
+// in the shape the AWS CLI uses, or an empty string if none is set
+func (c *Client) GetBucketConfig(ctx context.Context, bucketName, kind string) (string, error) {
+	var doc any
+	var err error
+	switch kind {
+	case BucketConfigPolicy:
+		var result *s3.GetBucketPolicyOutput
+		if result, err = c.S3.GetBucketPolicy(ctx, &s3.GetBucketPolicyInput{Bucket: &bucketName}); err == nil {
+			// Keep the policy as written, only indented
+			var out bytes.Buffer
+			if json.Indent(&out, []byte(getString(result.Policy)), "", "  ") != nil {
+				return getString(result.Policy), nil
+			}
+			return out.String() + "\n", nil
+		}
+	case BucketConfigCORS:
+		var result *s3.GetBucketCorsOutput
+		if result, err = c.S3.GetBucketCors(ctx, &s3.GetBucketCorsInput{Bucket: &bucketName}); err == nil {
+			doc = types.CORSConfiguration{CORSRules: result.CORSRules}
+		}
+	case BucketConfigLifecycle:
+		var result *s3.GetBucketLifecycleConfigurationOutput
+		if result, err = c.S3.GetBucketLifecycleConfiguration(ctx, &s3.GetBucketLifecycleConfigurationInput{Bucket: &bucketName}); err == nil {
+			doc = types.BucketLifecycleConfiguration{Rules: result.Rules}
+		}
+	case BucketConfigEncryption:
+		var result *s3.GetBucketEncryptionOutput
+		if result, err = c.S3.GetBucketEncryption(ctx, &s3.GetBucketEncryptionInput{Bucket: &bucketName}); err == nil {
+			doc = result.ServerSideEncryptionConfiguration
+		}
+	case BucketConfigPublicAccessBlock:
+		var result *s3.GetPublicAccessBlockOutput
+		if result, err = c.S3.GetPublicAccessBlock(ctx, &s3.GetPublicAccessBlockInput{Bucket: &bucketName}); err == nil {
+			doc = result.PublicAccessBlockConfiguration
+		}
+	case BucketConfigTagging:
+		var result *s3.GetBucketTaggingOutput
+		if result, err = c.S3.GetBucketTagging(ctx, &s3.GetBucketTaggingInput{Bucket: &bucketName}); err == nil {
+			doc = types.Tagging{TagSet: result.TagSet}
+		}
+	default:
+		return "", fmt.Errorf("unknown bucket configuration %q", kind)
+	}
+	if isErrorCode(err, notSetErrorCodes[kind]) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get bucket %s: %w", kind, err)
+	}
+	return marshalBucketConfig(doc)
+}
+
+// ValidateBucketConfig checks an edited bucket configuration document
+// before it is sent, catching what S3 would reject with a less helpful
+// error. An empty document is valid and removes the configuration.
+func ValidateBucketConfig(kind, doc string) error {
+	if strings.TrimSpace(doc) == "" {
+		return nil
+	}
+	_, err := parseBucketConfig(kind, doc)
+	return err
+}
+
+// PutBucketConfig replaces a bucket configuration document, or removes the
+// configuration if the document is empty
+func (c *Client) PutBucketConfig(ctx context.Context, bucketName, kind, doc string) error {
+	if strings.TrimSpace(doc) == "" {
+		return c.deleteBucketConfig(ctx, bucketName, kind)
+	}
+	parsed, err := parseBucketConfig(kind, doc)
+	if err != nil {
+		return err
+	}
+
+	switch config := parsed.(type) {
+	case string:
+		_, err = c.S3.PutBucketPolicy(ctx, &s3.PutBucketPolicyInput{Bucket: &bucketName, Policy: &config})
+	case *types.CORSConfiguration:
+		_, err = c.S3.PutBucketCors(ctx, &s3.PutBucketCorsInput{Bucket: &bucketName, CORSConfiguration: config})
+	case *types.BucketLifecycleConfiguration:
+		_, err = c.S3.PutBucketLifecycleConfiguration(ctx, &s3.PutBucketLifecycleConfigurationInput{Bucket: &bucketName, LifecycleConfiguration: config})
+	case *types.ServerSideEncryptionConfiguration:
+		_, err = c.S3.PutBucketEncryption(ctx, &s3.PutBucketEncryptionInput{Bucket: &bucketName, ServerSideEncryptionConfiguration: config})
+	case *types.PublicAccessBlockConfiguration:
+		_, err = c.S3.PutPublicAccessBlock(ctx, &s3.PutPublicAccessBlockInput{Bucket: &bucketName, PublicAccessBlockConfiguration: config})
+	case *types.Tagging:
+		_, err = c.S3.PutBucketTagging(ctx, &s3.PutBucketTaggingInput{Bucket: &bucketName, Tagging: config})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to put bucket %s: %w", kind, err)
+	}
+	return nil
+}
+
+// deleteBucketConfig removes a bucket configuration document
+func (c *Client) deleteBucketConfig(ctx context.Context, bucketName, kind string) error {
+	var err error
+	switch kind {
+	case BucketConfigPolicy:
+		_, err = c.S3.DeleteBucketPolicy(ctx, &s3.DeleteBucketPolicyInput{Bucket: &bucketName})
+	case BucketConfigCORS:
+		_, err = c.S3.DeleteBucketCors(ctx, &s3.DeleteBucketCorsInput{Bucket: &bucketName})
+	case BucketConfigLifecycle:
+		_, err = c.S3.DeleteBucketLifecycle(ctx, &s3.DeleteBucketLifecycleInput{Bucket: &bucketName})
+	case BucketConfigEncryption:
+		_, err = c.S3.DeleteBucketEncryption(ctx, &s3.DeleteBucketEncryptionInput{Bucket: &bucketName})
+	case BucketConfigPublicAccessBlock:
+		_, err = c.S3.DeletePublicAccessBlock(ctx, &s3.DeletePublicAccessBlockInput{Bucket: &bucketName})
+	case BucketConfigTagging:
+		_, err = c.S3.DeleteBucketTagging(ctx, &s3.DeleteBucketTaggingInput{Bucket: &bucketName})
+	default:
+		return fmt.Errorf("unknown bucket configuration %q", kind)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete bucket %s: %w", kind, err)
+	}
+	return nil
+}
+
+// parseBucketConfig decodes and checks a bucket configuration document. A
+// policy is returned as its text; anything else as the SDK type it is put
+// with.
+func parseBucketConfig(kind, doc string) (any, error) {
+	if kind == BucketConfigPolicy {
+		var policy struct {
+			Version   string
+			Statement json.RawMessage
+		}
+		if err := json.Unmarshal([]byte(doc), &policy); err != nil {
+			return nil, fmt.Errorf("policy is not valid JSON: %w", err)
+		}
+		if len(policy.Statement) == 0 {
+			return nil, errors.New("policy has no Statement")
+		}
+		// The size limit doesn't count the indentation added for editing
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, []byte(doc)); err != nil {
+			return nil, fmt.Errorf("policy is not valid JSON: %w", err)
+		}
+		if compact.Len() > maxBucketPolicySize {
+			return nil, fmt.Errorf("policy is %d bytes, more than the %d S3 allows", compact.Len(), maxBucketPolicySize)
+		}
+		return compact.String(), nil
+	}
+
+	var config any
+	switch kind {
+	case BucketConfigCORS:
+		config = &types.CORSConfiguration{}
+	case BucketConfigLifecycle:
+		config = &types.BucketLifecycleConfiguration{}
+	case BucketConfigEncryption:
+		config = &types.ServerSideEncryptionConfiguration{}
+	case BucketConfigPublicAccessBlock:
+		config = &types.PublicAccessBlockConfiguration{}
+	case BucketConfigTagging:
+		config = &types.Tagging{}
+	default:
+		return nil, fmt.Errorf("unknown bucket configuration %q", kind)
+	}
+
+	// Misspelt fields would otherwise be dropped without a word
+	decoder := json.NewDecoder(strings.NewReader(doc))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(config); err != nil {
+		return nil, fmt.Errorf("%s is not valid: %w", kind, err)
+	}
+
+	switch config := config.(type) {
+	case *types.CORSConfiguration:
+		if len(config.CORSRules) == 0 || len(config.CORSRules) > maxCORSRules {
+			return nil, fmt.Errorf("cors needs 1 to %d CORSRules", maxCORSRules)
+		}
+		for i, rule := range config.CORSRules {
+			if len(rule.AllowedMethods) == 0 || len(rule.AllowedOrigins) == 0 {
+				return nil, fmt.Errorf("CORS rule %d needs AllowedMethods and AllowedOrigins", i+1)
+			}
+		}
+	case *types.BucketLifecycleConfiguration:
+		if len(config.Rules) == 0 || len(config.Rules) > maxLifecycleRules {
+			return nil, fmt.Errorf("lifecycle needs 1 to %d Rules", maxLifecycleRules)
+		}
+		for i, rule := range config.Rules {
+			if rule.Status != types.ExpirationStatusEnabled && rule.Status != types.ExpirationStatusDisabled {
+				return nil, fmt.Errorf("lifecycle rule %d needs a Status of Enabled or Disabled", i+1)
+			}
+		}
+	case *types.ServerSideEncryptionConfiguration:
+		if len(config.Rules) == 0 {
+			return nil, errors.New("encryption needs a rule")
+		}
+		for _, rule := range config.Rules {
+			if rule.ApplyServerSideEncryptionByDefault == nil || rule.ApplyServerSideEncryptionByDefault.SSEAlgorithm == "" {
+				return nil, errors.New("encryption rule needs ApplyServerSideEncryptionByDefault.SSEAlgorithm")
+			}
+		}
+	case *types.Tagging:
+		if len(config.TagSet) > maxBucketTags {
+			return nil, fmt.Errorf("tagging has %d tags, more than the %d S3 allows", len(config.TagSet), maxBucketTags)
+		}
+		for _, tag := range config.TagSet {
+			key := getString(tag.Key)
+			if key == "" || len(key) > maxBucketTagKeyLength || len(getString(tag.Value)) > maxBucketTagValueLength {
+				return nil, fmt.Errorf("tag %q needs a Key of 1 to %d characters and a Value of up to %d", key, maxBucketTagKeyLength, maxBucketTagValueLength)
+			}
+		}
+	}
+	return config, nil
+}
+
+// marshalBucketConfig formats an SDK configuration type as indented JSON,
+// leaving out the fields that aren't set
+func marshalBucketConfig(config any) (string, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return "", err
+	}
+	if doc == nil {
+		return "", nil
+	}
+	data, err = json.MarshalIndent(dropNulls(doc), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
+
+// dropNulls removes null fields from decoded JSON
+func dropNulls(doc any) any {
+	switch doc := doc.(type) {
+	case map[string]any:
+		for key, value := range doc {
+			if value == nil {
+				delete(doc, key)
+			} else {
+				doc[key] = dropNulls(value)
+			}
+		}
+	case []any:
+		for i, value := range doc {
+			doc[i] = dropNulls(value)
+		}
+	}
+	return doc
+}
+
+// isErrorCode reports whether err is an S3 error with the given code
+func isErrorCode(err error, code string) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == code
+}
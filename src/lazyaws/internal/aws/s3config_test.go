@@ -0,0 +1,89 @@
+package aws
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestBucketConfigRoundTrip(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.S3.AddBucket("test-bucket", "us-east-1")
+	client := backend.Client("us-east-1")
+	ctx := context.Background()
+
+	docs := map[string]string{
+		BucketConfigPolicy:            `{"Version": "2012-10-17", "Statement": [{"Effect": "Deny", "Principal": "*", "Action": "s3:*", "Resource": "arn:aws:s3:::test-bucket/*"}]}`,
+		BucketConfigCORS:              `{"CORSRules": [{"AllowedMethods": ["GET"], "AllowedOrigins": ["*"], "MaxAgeSeconds": 300}]}`,
+		BucketConfigLifecycle:         `{"Rules": [{"ID": "expire-logs", "Status": "Enabled", "Filter": {"Prefix": "logs/"}, "Expiration": {"Days": 30}}]}`,
+		BucketConfigEncryption:        `{"Rules": [{"ApplyServerSideEncryptionByDefault": {"SSEAlgorithm": "AES256"}}]}`,
+		BucketConfigPublicAccessBlock: `{"BlockPublicAcls": true, "BlockPublicPolicy": true}`,
+		BucketConfigTagging:           `{"TagSet": [{"Key": "team", "Value": "data"}]}`,
+	}
+
+	for _, kind := range BucketConfigKinds {
+		current, err := client.GetBucketConfig(ctx, "test-bucket", kind)
+		if err != nil || current != "" {
+			t.Errorf("%s: expected no document before it is set, got %q (%v)", kind, current, err)
+		}
+
+		if err := client.PutBucketConfig(ctx, "test-bucket", kind, docs[kind]); err != nil {
+			t.Fatalf("%s: PutBucketConfig returned error: %v", kind, err)
+		}
+		current, err = client.GetBucketConfig(ctx, "test-bucket", kind)
+		if err != nil {
+			t.Fatalf("%s: GetBucketConfig returned error: %v", kind, err)
+		}
+		if strings.Contains(current, "null") || !strings.HasSuffix(current, "\n") {
+			t.Errorf("%s: expected indented JSON without nulls, got %q", kind, current)
+		}
+
+		// What was read back can be put again unchanged
+		if err := ValidateBucketConfig(kind, current); err != nil {
+			t.Errorf("%s: document read back doesn't validate: %v", kind, err)
+		}
+
+		if err := client.PutBucketConfig(ctx, "test-bucket", kind, ""); err != nil {
+			t.Fatalf("%s: removing the document returned error: %v", kind, err)
+		}
+		if current, _ := client.GetBucketConfig(ctx, "test-bucket", kind); current != "" {
+			t.Errorf("%s: expected the document to be removed, got %q", kind, current)
+		}
+	}
+}
+
+func TestValidateBucketConfig(t *testing.T) {
+	tests := []struct {
+		kind  string
+		doc   string
+		valid bool
+	}{
+		{BucketConfigPolicy, "", true},
+		{BucketConfigPolicy, `{"Statement": [`, false},
+		{BucketConfigPolicy, `{"Version": "2012-10-17"}`, false},
+		{BucketConfigPolicy, `{"Statement": [{"Sid": "` + strings.Repeat("x", maxBucketPolicySize) + `"}]}`, false},
+		{BucketConfigCORS, `{"CORSRules": [{"AllowedMethod": ["GET"], "AllowedOrigins": ["*"]}]}`, false},
+		{BucketConfigCORS, `{"CORSRules": []}`, false},
+		{BucketConfigLifecycle, `{"Rules": [{"ID": "a", "Status": "On"}]}`, false},
+		{BucketConfigEncryption, `{"Rules": [{}]}`, false},
+		{BucketConfigTagging, `{"TagSet": [{"Key": "", "Value": "x"}]}`, false},
+		{BucketConfigTagging, `{"TagSet": []}`, true},
+		{"website", `{}`, false},
+	}
+
+	for _, tt := range tests {
+		err := ValidateBucketConfig(tt.kind, tt.doc)
+		if (err == nil) != tt.valid {
+			t.Errorf("ValidateBucketConfig(%s, %.40q) = %v, want valid %v", tt.kind, tt.doc, err, tt.valid)
+		}
+	}
+}
+
+func TestParseBucketConfigKind(t *testing.T) {
+	if kind, err := ParseBucketConfigKind("CORS"); err != nil || kind != BucketConfigCORS {
+		t.Errorf("Expected cors, got %q (%v)", kind, err)
+	}
+	if _, err := ParseBucketConfigKind("website"); err == nil {
+		t.Error("Expected an unknown configuration to fail")
+	}
+}
@@ -0,0 +1,272 @@
+package aws
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Limits of presigned uploads
+const (
+	DefaultPresignExpiry = time.Hour
+	MaxPresignExpiry     = 7 * 24 * time.Hour // Longest a SigV4 signature is valid
+	maxPostObjectSize    = 5 << 30            // Largest object a single POST can upload
+)
+
+// PresignPutOptions constrains what a presigned PUT URL accepts. The
+// uploader has to send the same Content-Type and checksum headers.
+type PresignPutOptions struct {
+	ContentType    string
+	ChecksumSHA256 string // Base64 SHA-256 of the body the URL accepts
+	Expiry         time.Duration
+}
+
+// PresignedPut is a URL that uploads one object with a PUT request
+type PresignedPut struct {
+	Key     string
+	URL     string
+	Headers map[string]string // Signed headers the request has to carry
+	Expires time.Time
+}
+
+// PresignPostOptions constrains what a presigned POST policy accepts
+type PresignPostOptions struct {
+	KeyPrefix string // Uploaded keys have to start with this
+	MinSize   int64
+	MaxSize   int64 // 0 for no limit below the 5 GB POST limit
+	Expiry    time.Duration
+}
+
+// PresignedPost is a POST policy for browser-style form uploads of any
+// number of objects below a prefix
+type PresignedPost struct {
+	KeyPrefix string
+	URL       string
+	Fields    map[string]string // Form fields to send before the file
+	Expires   time.Time
+}
+
+// ParsePresignPutOptions parses PUT URL options:
+//
+//	type=CONTENT-TYPE    Content-Type the upload has to send
+//	sha256=CHECKSUM      SHA-256 of the body, in hex or base64
+//	expires=DURATION     e.g. 15m, 12h or 7d; defaults to 1h
+func ParsePresignPutOptions(terms []string) (PresignPutOptions, error) {
+	opts := PresignPutOptions{Expiry: DefaultPresignExpiry}
+	for _, term := range terms {
+		name, value, _ := strings.Cut(term, "=")
+		var err error
+		switch name {
+		case "type":
+			opts.ContentType = value
+		case "sha256":
+			opts.ChecksumSHA256, err = ParseChecksumSHA256(value)
+		case "expires":
+			opts.Expiry, err = parsePresignExpiry(value)
+		default:
+			err = fmt.Errorf("unknown option %q (want type=, sha256= or expires=)", term)
+		}
+		if err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+// ParsePresignPostOptions parses POST policy options:
+//
+//	min=SIZE max=SIZE    content-length range, in B, KB, MB, GB or TB
+//	expires=DURATION     e.g. 15m, 12h or 7d; defaults to 1h
+func ParsePresignPostOptions(terms []string) (PresignPostOptions, error) {
+	opts := PresignPostOptions{Expiry: DefaultPresignExpiry}
+	for _, term := range terms {
+		name, value, _ := strings.Cut(term, "=")
+		var err error
+		switch name {
+		case "min":
+			opts.MinSize, err = parseSize(value)
+		case "max":
+			opts.MaxSize, err = parseSize(value)
+		case "expires":
+			opts.Expiry, err = parsePresignExpiry(value)
+		default:
+			err = fmt.Errorf("unknown option %q (want min=, max= or expires=)", term)
+		}
+		if err != nil {
+			return opts, err
+		}
+	}
+	if opts.MaxSize > 0 && opts.MinSize > opts.MaxSize {
+		return opts, fmt.Errorf("min size %d is larger than max size %d", opts.MinSize, opts.MaxSize)
+	}
+	if opts.MinSize > maxPostObjectSize || opts.MaxSize > maxPostObjectSize {
+		return opts, fmt.Errorf("a POST upload can't be larger than 5 GB")
+	}
+	return opts, nil
+}
+
+// ParseChecksumSHA256 accepts a SHA-256 digest in hex, as printed by
+// sha256sum, or base64, and returns the base64 form S3 expects
+func ParseChecksumSHA256(s string) (string, error) {
+	if digest, err := hex.DecodeString(s); err == nil && len(digest) == 32 {
+		return base64.StdEncoding.EncodeToString(digest), nil
+	}
+	if digest, err := base64.StdEncoding.DecodeString(s); err == nil && len(digest) == 32 {
+		return s, nil
+	}
+	return "", fmt.Errorf("invalid SHA-256 %q (want 64 hex digits or base64)", s)
+}
+
+// parsePresignExpiry parses an expiry such as 90s, 15m, 12h or 7d
+func parsePresignExpiry(s string) (time.Duration, error) {
+	expiry, err := time.ParseDuration(s)
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		var n int
+		n, err = strconv.Atoi(days)
+		expiry = time.Duration(n) * 24 * time.Hour
+	}
+	if err != nil || expiry <= 0 {
+		return 0, fmt.Errorf("invalid expiry %q (want e.g. 15m, 12h or 7d)", s)
+	}
+	if expiry > MaxPresignExpiry {
+		return 0, fmt.Errorf("expiry %s is longer than the 7 day limit", s)
+	}
+	return expiry, nil
+}
+
+// PresignPutObject presigns a PUT of one object. The URL only accepts
+// uploads that send the content type and checksum in opts.
+func (c *Client) PresignPutObject(ctx context.Context, bucketName, key string, opts PresignPutOptions) (*PresignedPut, error) {
+	if opts.Expiry <= 0 || opts.Expiry > MaxPresignExpiry {
+		return nil, fmt.Errorf("expiry must be between 1s and 7 days, got %s", opts.Expiry)
+	}
+	input := &s3.PutObjectInput{
+		Bucket:         &bucketName,
+		Key:            &key,
+		ContentType:    nilIfEmpty(opts.ContentType),
+		ChecksumSHA256: nilIfEmpty(opts.ChecksumSHA256),
+	}
+	request, err := c.S3Presign.PresignPutObject(ctx, input, func(o *s3.PresignOptions) {
+		o.Expires = opts.Expiry
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign upload: %w", err)
+	}
+
+	put := &PresignedPut{
+		Key:     key,
+		URL:     request.URL,
+		Headers: make(map[string]string),
+		Expires: time.Now().Add(opts.Expiry),
+	}
+	for name, values := range request.SignedHeader {
+		if !strings.EqualFold(name, "Host") && len(values) > 0 {
+			put.Headers[http.CanonicalHeaderKey(name)] = values[0]
+		}
+	}
+	// The signer may move x-amz-* headers into the query string, but S3
+	// only checks the checksum when it arrives as a header
+	if opts.ContentType != "" {
+		put.Headers["Content-Type"] = opts.ContentType
+	}
+	if opts.ChecksumSHA256 != "" {
+		put.Headers["X-Amz-Checksum-Sha256"] = opts.ChecksumSHA256
+	}
+	return put, nil
+}
+
+// PresignPostPolicy signs a POST policy that lets a form upload files below
+// opts.KeyPrefix, named after the uploaded file
+func (c *Client) PresignPostPolicy(ctx context.Context, bucketName string, opts PresignPostOptions) (*PresignedPost, error) {
+	if opts.Expiry <= 0 || opts.Expiry > MaxPresignExpiry {
+		return nil, fmt.Errorf("expiry must be between 1s and 7 days, got %s", opts.Expiry)
+	}
+	// A starts-with condition on the key replaces the exact key the SDK
+	// would otherwise require
+	key := opts.KeyPrefix + "${filename}"
+	conditions := []interface{}{
+		[]interface{}{"starts-with", "$key", opts.KeyPrefix},
+	}
+	if opts.MinSize > 0 || opts.MaxSize > 0 {
+		maxSize := opts.MaxSize
+		if maxSize == 0 {
+			maxSize = maxPostObjectSize
+		}
+		conditions = append(conditions, []interface{}{"content-length-range", opts.MinSize, maxSize})
+	}
+
+	request, err := c.S3Presign.PresignPostObject(ctx, &s3.PutObjectInput{Bucket: &bucketName, Key: &key}, func(o *s3.PresignPostOptions) {
+		o.Expires = opts.Expiry
+		o.Conditions = conditions
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign POST policy: %w", err)
+	}
+	return &PresignedPost{
+		KeyPrefix: opts.KeyPrefix,
+		URL:       request.URL,
+		Fields:    request.Values,
+		Expires:   time.Now().Add(opts.Expiry),
+	}, nil
+}
+
+// CurlCommand is a curl command that uploads a local file named after the
+// key to the URL
+func (p *PresignedPut) CurlCommand() string {
+	lines := []string{"curl -X PUT --upload-file " + shellQuote(path.Base(p.Key))}
+	for _, name := range sortedKeys(p.Headers) {
+		lines = append(lines, "-H "+shellQuote(name+": "+p.Headers[name]))
+	}
+	lines = append(lines, shellQuote(p.URL))
+	return strings.Join(lines, " \\\n  ")
+}
+
+// CurlCommand is a curl command that uploads FILE with the policy. S3
+// ignores form fields after the file, so it goes last.
+func (p *PresignedPost) CurlCommand() string {
+	lines := []string{"curl -X POST"}
+	for _, name := range sortedKeys(p.Fields) {
+		lines = append(lines, "-F "+shellQuote(name+"="+p.Fields[name]))
+	}
+	lines = append(lines, "-F 'file=@FILE'", shellQuote(p.URL))
+	return strings.Join(lines, " \\\n  ")
+}
+
+// HTMLForm is an upload form that posts a file chosen in the browser with
+// the policy
+func (p *PresignedPost) HTMLForm() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<form action=\"%s\" method=\"post\" enctype=\"multipart/form-data\">\n", html.EscapeString(p.URL))
+	for _, name := range sortedKeys(p.Fields) {
+		fmt.Fprintf(&b, "  <input type=\"hidden\" name=\"%s\" value=\"%s\">\n", html.EscapeString(name), html.EscapeString(p.Fields[name]))
+	}
+	b.WriteString("  <input type=\"file\" name=\"file\">\n")
+	b.WriteString("  <input type=\"submit\" value=\"Upload\">\n")
+	b.WriteString("</form>")
+	return b.String()
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
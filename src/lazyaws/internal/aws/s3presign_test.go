@@ -0,0 +1,134 @@
+package aws
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePresignOptions(t *testing.T) {
+	put, err := ParsePresignPutOptions([]string{
+		"type=text/csv",
+		"sha256=ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+		"expires=7d",
+	})
+	if err != nil {
+		t.Fatalf("ParsePresignPutOptions returned error: %v", err)
+	}
+	if put.ContentType != "text/csv" || put.ChecksumSHA256 != "ungWv48Bz+pBQUDeXa4iI7ADYaOWF3qctBD/YfIAFa0=" || put.Expiry != MaxPresignExpiry {
+		t.Errorf("Unexpected PUT options %+v", put)
+	}
+	if put, _ := ParsePresignPutOptions(nil); put.Expiry != DefaultPresignExpiry {
+		t.Errorf("Expected the default expiry, got %s", put.Expiry)
+	}
+
+	post, err := ParsePresignPostOptions([]string{"min=1KB", "max=10MB", "expires=15m"})
+	if err != nil {
+		t.Fatalf("ParsePresignPostOptions returned error: %v", err)
+	}
+	if post.MinSize != 1<<10 || post.MaxSize != 10<<20 || post.Expiry != 15*time.Minute {
+		t.Errorf("Unexpected POST options %+v", post)
+	}
+
+	for _, terms := range [][]string{
+		{"sha256=abc"},
+		{"expires=8d"},
+		{"expires=0s"},
+		{"acl=public-read"},
+	} {
+		if _, err := ParsePresignPutOptions(terms); err == nil {
+			t.Errorf("Expected PUT options %q to fail", terms)
+		}
+	}
+	for _, terms := range [][]string{
+		{"min=10MB", "max=1MB"},
+		{"max=6GB"},
+		{"type=text/csv"},
+	} {
+		if _, err := ParsePresignPostOptions(terms); err == nil {
+			t.Errorf("Expected POST options %q to fail", terms)
+		}
+	}
+}
+
+func TestPresignPutObject(t *testing.T) {
+	backend := NewFakeBackend()
+	client := backend.Client("us-east-1")
+
+	put, err := client.PresignPutObject(context.Background(), "test-bucket", "reports/q1 it's.csv", PresignPutOptions{
+		ContentType:    "text/csv",
+		ChecksumSHA256: "ungWv48Bz+pBQUDeXa4iI7ADYaOWF3qctBD/YfIAFa0=",
+		Expiry:         time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("PresignPutObject returned error: %v", err)
+	}
+	if !strings.Contains(put.URL, "X-Amz-Expires=3600") {
+		t.Errorf("Expected a one hour URL, got %s", put.URL)
+	}
+	if _, ok := put.Headers["Host"]; ok || len(put.Headers) != 2 {
+		t.Errorf("Expected the content type and checksum headers, got %v", put.Headers)
+	}
+
+	expected := `curl -X PUT --upload-file 'q1 it'\''s.csv' \
+  -H 'Content-Type: text/csv' \
+  -H 'X-Amz-Checksum-Sha256: ungWv48Bz+pBQUDeXa4iI7ADYaOWF3qctBD/YfIAFa0=' \
+  '` + put.URL + `'`
+	if cmd := put.CurlCommand(); cmd != expected {
+		t.Errorf("Expected curl command\n%s\ngot\n%s", expected, cmd)
+	}
+
+	if _, err := client.PresignPutObject(context.Background(), "test-bucket", "a", PresignPutOptions{Expiry: 8 * 24 * time.Hour}); err == nil {
+		t.Error("Expected an expiry over 7 days to fail")
+	}
+}
+
+func TestPresignPostPolicy(t *testing.T) {
+	backend := NewFakeBackend()
+	client := backend.Client("us-east-1")
+
+	post, err := client.PresignPostPolicy(context.Background(), "test-bucket", PresignPostOptions{
+		KeyPrefix: "uploads/",
+		MaxSize:   10 << 20,
+		Expiry:    time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("PresignPostPolicy returned error: %v", err)
+	}
+	if post.Fields["key"] != "uploads/${filename}" {
+		t.Errorf("Expected uploads named after the file, got key %q", post.Fields["key"])
+	}
+	policy := post.Fields["policy"]
+	for _, condition := range []string{`["starts-with","$key","uploads/"]`, `["content-length-range",0,10485760]`} {
+		if !strings.Contains(policy, condition) {
+			t.Errorf("Expected condition %s in policy %s", condition, policy)
+		}
+	}
+
+	cmd := post.CurlCommand()
+	if !strings.HasPrefix(cmd, "curl -X POST \\\n  -F 'key=uploads/${filename}'") || !strings.HasSuffix(cmd, "-F 'file=@FILE' \\\n  '"+post.URL+"'") {
+		t.Errorf("Expected the fields before the file, got\n%s", cmd)
+	}
+
+	form := post.HTMLForm()
+	for _, line := range []string{
+		`<form action="` + post.URL + `" method="post" enctype="multipart/form-data">`,
+		`  <input type="hidden" name="key" value="uploads/${filename}">`,
+		`  <input type="hidden" name="x-amz-signature" value="fake">`,
+		`  <input type="file" name="file">`,
+	} {
+		if !strings.Contains(form, line) {
+			t.Errorf("Expected %s in form\n%s", line, form)
+		}
+	}
+	if strings.Contains(form, `"conditions"`) {
+		t.Errorf("Expected the policy to be escaped, got\n%s", form)
+	}
+
+	// Without a size range the policy has no content-length condition
+	post, _ = client.PresignPostPolicy(context.Background(), "test-bucket", PresignPostOptions{Expiry: time.Hour})
+	if strings.Contains(post.Fields["policy"], "content-length-range") || !strings.Contains(post.Fields["policy"], `["starts-with","$key",""]`) {
+		t.Errorf("Unexpected policy %s", post.Fields["policy"])
+	}
+}
@@ -0,0 +1,590 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	// ACLCustom stands for grants that no canned ACL amounts to
+	ACLCustom = "custom"
+	// FormVaries is the value of a form field the objects disagree on
+	FormVaries = "(varies)"
+
+	allUsersURI           = "http://acs.amazonaws.com/groups/global/AllUsers"
+	authenticatedUsersURI = "http://acs.amazonaws.com/groups/global/AuthenticatedUsers"
+)
+
+// ObjectProperties are the editable properties of an object
+type ObjectProperties struct {
+	Key          string
+	Size         int64
+	StorageClass string
+	ContentType  string
+	Metadata     map[string]string
+	Tags         map[string]string
+	// ACL is the canned ACL the object's grants amount to, or ACLCustom.
+	// It is empty when the bucket has ACLs disabled.
+	ACL string
+
+	head *s3.HeadObjectOutput   // Headers a copy in place carries over
+	acl  *s3.GetObjectAclOutput // Grants a copy in place puts back, nil without ACLs
+}
+
+// ObjectPropertiesForm is the document edited to change the properties of
+// one or more objects. Fields the objects disagree on hold FormVaries, as
+// do metadata and tags only some of them have.
+type ObjectPropertiesForm struct {
+	StorageClass string
+	ContentType  string
+	ACL          string `json:",omitempty"` // Left out when ACLs are disabled
+	Metadata     map[string]string
+	Tags         map[string]string
+}
+
+// ObjectPropertyChange is what an edit does to one object
+type ObjectPropertyChange struct {
+	Before ObjectProperties
+	After  ObjectProperties
+}
+
+// ObjectACLs are the canned ACLs an object can be given
+func ObjectACLs() []string {
+	var acls []string
+	for _, acl := range types.ObjectCannedACL("").Values() {
+		acls = append(acls, string(acl))
+	}
+	return acls
+}
+
+// GetObjectProperties reads the properties of objects, leaving out folder
+// placeholders. The properties are in the order of objects.
+func (c *Client) GetObjectProperties(ctx context.Context, bucketName string, objects []S3Object, progress BatchProgressCallback) ([]ObjectProperties, error) {
+	var files []S3Object
+	for _, obj := range objects {
+		if !strings.HasSuffix(obj.Key, "/") {
+			files = append(files, obj)
+		}
+	}
+
+	var mu sync.Mutex
+	byKey := make(map[string]ObjectProperties)
+	result := forEachObject(ctx, files, progress, func(obj S3Object) error {
+		props, err := c.getObjectProperties(ctx, bucketName, obj.Key)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		byKey[obj.Key] = props
+		mu.Unlock()
+		return nil
+	})
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+
+	props := make([]ObjectProperties, len(files))
+	for i, obj := range files {
+		props[i] = byKey[obj.Key]
+	}
+	return props, nil
+}
+
+func (c *Client) getObjectProperties(ctx context.Context, bucketName, key string) (ObjectProperties, error) {
+	head, err := c.S3.HeadObject(ctx, &s3.HeadObjectInput{Bucket: &bucketName, Key: &key})
+	if err != nil {
+		return ObjectProperties{}, fmt.Errorf("failed to get object metadata: %w", err)
+	}
+	tagging, err := c.S3.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{Bucket: &bucketName, Key: &key})
+	if err != nil {
+		return ObjectProperties{}, fmt.Errorf("failed to get tags: %w", err)
+	}
+	// Buckets with Object Ownership "BucketOwnerEnforced", the default
+	// for new buckets, reject ACL calls
+	acl, err := c.S3.GetObjectAcl(ctx, &s3.GetObjectAclInput{Bucket: &bucketName, Key: &key})
+	if isErrorCode(err, "AccessControlListNotSupported") {
+		acl, err = nil, nil
+	}
+	if err != nil {
+		return ObjectProperties{}, fmt.Errorf("failed to get ACL: %w", err)
+	}
+
+	props := ObjectProperties{
+		Key:          key,
+		Size:         getInt64(head.ContentLength),
+		StorageClass: string(head.StorageClass),
+		ContentType:  getString(head.ContentType),
+		Metadata:     make(map[string]string),
+		Tags:         make(map[string]string),
+		head:         head,
+		acl:          acl,
+	}
+	if acl != nil {
+		props.ACL = cannedACL(acl)
+	}
+	if props.StorageClass == "" {
+		// HeadObject leaves out the class of STANDARD objects
+		props.StorageClass = string(types.StorageClassStandard)
+	}
+	for k, v := range head.Metadata {
+		props.Metadata[strings.ToLower(k)] = v
+	}
+	for _, tag := range tagging.TagSet {
+		props.Tags[getString(tag.Key)] = getString(tag.Value)
+	}
+	return props, nil
+}
+
+// NewObjectPropertiesForm fills a form with the properties the objects
+// share, and FormVaries where they differ
+func NewObjectPropertiesForm(props []ObjectProperties) ObjectPropertiesForm {
+	form := ObjectPropertiesForm{
+		Metadata: make(map[string]string),
+		Tags:     make(map[string]string),
+	}
+	common := func(field *string, value string, i int) {
+		if i == 0 {
+			*field = value
+		} else if *field != value {
+			*field = FormVaries
+		}
+	}
+	for i, p := range props {
+		common(&form.StorageClass, p.StorageClass, i)
+		common(&form.ContentType, p.ContentType, i)
+		common(&form.ACL, p.ACL, i)
+		mergeCommonValues(form.Metadata, p.Metadata, i)
+		mergeCommonValues(form.Tags, p.Tags, i)
+	}
+	return form
+}
+
+// mergeCommonValues merges the values of the i-th of several resources into
+// a form map, turning keys they disagree on or don't all have into
+// FormVaries
+func mergeCommonValues(form, values map[string]string, i int) {
+	for k, v := range values {
+		current, ok := form[k]
+		switch {
+		case !ok && i > 0:
+			// Missing from the resources before this one
+			form[k] = FormVaries
+		case !ok:
+			form[k] = v
+		case current != v:
+			form[k] = FormVaries
+		}
+	}
+	for k := range form {
+		if _, ok := values[k]; !ok {
+			form[k] = FormVaries
+		}
+	}
+}
+
+// Marshal formats the form as indented JSON for editing
+func (f ObjectPropertiesForm) Marshal() string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(f)
+	return buf.String()
+}
+
+// ParseObjectPropertiesForm parses and checks an edited form. Metadata
+// keys are lowercased, as S3 stores them.
+func ParseObjectPropertiesForm(doc string) (ObjectPropertiesForm, error) {
+	var form ObjectPropertiesForm
+	decoder := json.NewDecoder(strings.NewReader(doc))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&form); err != nil {
+		return form, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	if form.StorageClass != FormVaries {
+		class, err := ParseStorageClass(form.StorageClass)
+		if err != nil {
+			return form, err
+		}
+		form.StorageClass = class
+	}
+	if form.ACL != "" && form.ACL != FormVaries && form.ACL != ACLCustom {
+		found := false
+		for _, acl := range ObjectACLs() {
+			found = found || form.ACL == acl
+		}
+		if !found {
+			return form, fmt.Errorf("unknown ACL %q (want one of %s)", form.ACL, strings.Join(ObjectACLs(), ", "))
+		}
+	}
+
+	metadata := make(map[string]string)
+	for k, v := range form.Metadata {
+		key := strings.ToLower(k)
+		if key == "" || strings.ContainsAny(key, " :") {
+			return form, fmt.Errorf("invalid metadata key %q", k)
+		}
+		if _, ok := metadata[key]; ok {
+			return form, fmt.Errorf("metadata key %q is given twice", key)
+		}
+		metadata[key] = v
+	}
+	form.Metadata = metadata
+	if form.Tags == nil {
+		form.Tags = make(map[string]string)
+	}
+	for k, v := range form.Tags {
+		if k == "" || len(k) > 128 || len(v) > 256 {
+			return form, fmt.Errorf("invalid tag %q=%q (keys are 1-128 characters, values up to 256)", k, v)
+		}
+	}
+	return form, nil
+}
+
+// PlanObjectPropertyChanges works out what the edit from original to
+// edited does to each object. Fields left as they were in the form are
+// left alone on every object, so FormVaries only stays put. Objects the
+// edit doesn't change are left out.
+func PlanObjectPropertyChanges(props []ObjectProperties, original, edited ObjectPropertiesForm) ([]ObjectPropertyChange, error) {
+	changed := func(name, before, after string) (bool, error) {
+		if before == after {
+			return false, nil
+		}
+		if after == FormVaries {
+			return false, fmt.Errorf("%s: %s can only be left as it was", name, FormVaries)
+		}
+		return true, nil
+	}
+	setClass, err := changed("StorageClass", original.StorageClass, edited.StorageClass)
+	if err != nil {
+		return nil, err
+	}
+	setType, err := changed("ContentType", original.ContentType, edited.ContentType)
+	if err != nil {
+		return nil, err
+	}
+	setACL, err := changed("ACL", original.ACL, edited.ACL)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case setACL && original.ACL == "":
+		return nil, fmt.Errorf("ACL: the bucket has ACLs disabled")
+	case setACL && edited.ACL == "":
+		return nil, fmt.Errorf("ACL: can't be removed, pick a canned ACL")
+	case setACL && edited.ACL == ACLCustom:
+		return nil, fmt.Errorf("ACL: custom grants can't be set here, pick a canned ACL")
+	}
+	setMetadata, removeMetadata, err := mapChanges("Metadata", original.Metadata, edited.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	setTags, removeTags, err := mapChanges("Tags", original.Tags, edited.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []ObjectPropertyChange
+	for _, before := range props {
+		after := before
+		if setClass {
+			after.StorageClass = edited.StorageClass
+		}
+		if setType {
+			after.ContentType = edited.ContentType
+		}
+		if setACL {
+			after.ACL = edited.ACL
+		}
+		after.Metadata = applyMapChanges(before.Metadata, setMetadata, removeMetadata)
+		after.Tags = applyMapChanges(before.Tags, setTags, removeTags)
+		if len(after.Tags) > maxObjectTags {
+			return nil, fmt.Errorf("%s would have %d tags, objects can have at most %d", before.Key, len(after.Tags), maxObjectTags)
+		}
+
+		change := ObjectPropertyChange{Before: before, After: after}
+		if len(change.Describe()) > 0 {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+// mapChanges returns the keys an edit of a metadata or tag map sets and
+// removes
+func mapChanges(name string, original, edited map[string]string) (map[string]string, []string, error) {
+	set := make(map[string]string)
+	for k, v := range edited {
+		if before, ok := original[k]; ok && before == v {
+			continue
+		}
+		if v == FormVaries {
+			return nil, nil, fmt.Errorf("%s %q: %s can only be left as it was", name, k, FormVaries)
+		}
+		set[k] = v
+	}
+	var remove []string
+	for k := range original {
+		if _, ok := edited[k]; !ok {
+			remove = append(remove, k)
+		}
+	}
+	return set, remove, nil
+}
+
+func applyMapChanges(values, set map[string]string, remove []string) map[string]string {
+	result := make(map[string]string, len(values)+len(set))
+	for k, v := range values {
+		result[k] = v
+	}
+	for k, v := range set {
+		result[k] = v
+	}
+	for _, k := range remove {
+		delete(result, k)
+	}
+	return result
+}
+
+// Describe lists the changes to the object, one per line. Lines start with
+// "+" for an addition, "-" for a removal and "~" for a new value.
+func (c ObjectPropertyChange) Describe() []string {
+	var lines []string
+	field := func(name, before, after string) {
+		if before != after {
+			lines = append(lines, fmt.Sprintf("~ %s: %s -> %s", name, quoteEmpty(before), quoteEmpty(after)))
+		}
+	}
+	field("storage class", c.Before.StorageClass, c.After.StorageClass)
+	field("content type", c.Before.ContentType, c.After.ContentType)
+	field("acl", c.Before.ACL, c.After.ACL)
+	lines = append(lines, describeMapChanges("metadata", c.Before.Metadata, c.After.Metadata)...)
+	lines = append(lines, describeMapChanges("tag", c.Before.Tags, c.After.Tags)...)
+	return lines
+}
+
+// Copies reports whether the change rewrites the object by copying it onto
+// itself, which makes a new version in a versioned bucket
+func (c ObjectPropertyChange) Copies() bool {
+	return c.Before.StorageClass != c.After.StorageClass || c.Before.ContentType != c.After.ContentType ||
+		!equalMaps(c.Before.Metadata, c.After.Metadata)
+}
+
+func describeMapChanges(name string, before, after map[string]string) []string {
+	keys := make(map[string]bool)
+	for k := range before {
+		keys[k] = true
+	}
+	for k := range after {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var lines []string
+	for _, k := range sorted {
+		old, hadOld := before[k]
+		value, hasNew := after[k]
+		switch {
+		case !hadOld:
+			lines = append(lines, fmt.Sprintf("+ %s %s=%s", name, k, value))
+		case !hasNew:
+			lines = append(lines, fmt.Sprintf("- %s %s=%s", name, k, old))
+		case old != value:
+			lines = append(lines, fmt.Sprintf("~ %s %s: %s -> %s", name, k, quoteEmpty(old), quoteEmpty(value)))
+		}
+	}
+	return lines
+}
+
+func quoteEmpty(s string) string {
+	if s == "" {
+		return `""`
+	}
+	return s
+}
+
+// ApplyObjectPropertyChanges makes the planned changes. Storage class,
+// content type and metadata are rewritten by copying each object onto
+// itself, which keeps its tags, headers and encryption; the grants of an
+// object keeping its ACL are put back after the copy.
+func (c *Client) ApplyObjectPropertyChanges(ctx context.Context, bucketName string, changes []ObjectPropertyChange, progress BatchProgressCallback) *BatchResult {
+	objects := make([]S3Object, len(changes))
+	byKey := make(map[string]ObjectPropertyChange, len(changes))
+	for i, change := range changes {
+		objects[i] = S3Object{Key: change.Before.Key, Size: change.Before.Size}
+		byKey[change.Before.Key] = change
+	}
+
+	return forEachObject(ctx, objects, progress, func(obj S3Object) error {
+		change := byKey[obj.Key]
+		before, after := change.Before, change.After
+		key := obj.Key
+
+		copied := change.Copies()
+		if copied {
+			if err := c.copyInPlace(ctx, bucketName, change); err != nil {
+				return err
+			}
+		}
+
+		if !equalMaps(before.Tags, after.Tags) {
+			_, err := c.S3.PutObjectTagging(ctx, &s3.PutObjectTaggingInput{
+				Bucket:  &bucketName,
+				Key:     &key,
+				Tagging: &types.Tagging{TagSet: tagSet(after.Tags)},
+			})
+			if err != nil {
+				return fmt.Errorf("failed to put tags: %w", err)
+			}
+		}
+
+		input := &s3.PutObjectAclInput{Bucket: &bucketName, Key: &key}
+		switch {
+		case before.ACL != after.ACL:
+			input.ACL = types.ObjectCannedACL(after.ACL)
+		case copied && before.acl != nil && before.ACL != string(types.ObjectCannedACLPrivate):
+			// A copy starts out private. Without ACLs there is nothing to
+			// put back.
+			input.AccessControlPolicy = &types.AccessControlPolicy{Grants: before.acl.Grants, Owner: before.acl.Owner}
+		default:
+			return nil
+		}
+		if _, err := c.S3.PutObjectAcl(ctx, input); err != nil {
+			return fmt.Errorf("failed to put ACL: %w", err)
+		}
+		return nil
+	})
+}
+
+// copyInPlace copies an object onto itself with its new storage class,
+// content type and metadata
+func (c *Client) copyInPlace(ctx context.Context, bucketName string, change ObjectPropertyChange) error {
+	before, after := change.Before, change.After
+	head := before.head
+	if head == nil {
+		head = &s3.HeadObjectOutput{}
+	}
+	key := before.Key
+	source := copySource(bucketName, key, "")
+
+	var kmsKey *string
+	if head.ServerSideEncryption == types.ServerSideEncryptionAwsKms || head.ServerSideEncryption == types.ServerSideEncryptionAwsKmsDsse {
+		kmsKey = head.SSEKMSKeyId
+	}
+
+	if before.Size <= maxCopyObjectSize {
+		_, err := c.S3.CopyObject(ctx, &s3.CopyObjectInput{
+			Bucket:               &bucketName,
+			Key:                  &key,
+			CopySource:           &source,
+			CopySourceIfMatch:    head.ETag, // Don't overwrite a newer upload
+			MetadataDirective:    types.MetadataDirectiveReplace,
+			StorageClass:         types.StorageClass(after.StorageClass),
+			ContentType:          nilIfEmpty(after.ContentType),
+			Metadata:             after.Metadata,
+			CacheControl:         head.CacheControl,
+			ContentDisposition:   head.ContentDisposition,
+			ContentEncoding:      head.ContentEncoding,
+			ContentLanguage:      head.ContentLanguage,
+			Expires:              head.Expires,
+			ServerSideEncryption: head.ServerSideEncryption,
+			SSEKMSKeyId:          kmsKey,
+			BucketKeyEnabled:     head.BucketKeyEnabled,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to copy object: %w", err)
+		}
+		return nil
+	}
+
+	// A multipart copy carries nothing over, so set the tags too
+	input := &s3.CreateMultipartUploadInput{
+		Bucket:               &bucketName,
+		Key:                  &key,
+		StorageClass:         types.StorageClass(after.StorageClass),
+		ContentType:          nilIfEmpty(after.ContentType),
+		Metadata:             after.Metadata,
+		CacheControl:         head.CacheControl,
+		ContentDisposition:   head.ContentDisposition,
+		ContentEncoding:      head.ContentEncoding,
+		ContentLanguage:      head.ContentLanguage,
+		Expires:              head.Expires,
+		ServerSideEncryption: head.ServerSideEncryption,
+		SSEKMSKeyId:          kmsKey,
+		BucketKeyEnabled:     head.BucketKeyEnabled,
+	}
+	if len(before.Tags) > 0 {
+		tags := url.Values{}
+		for k, v := range before.Tags {
+			tags.Set(k, v)
+		}
+		encoded := tags.Encode()
+		input.Tagging = &encoded
+	}
+	return c.multipartCopy(ctx, source, before.Size, input)
+}
+
+// cannedACL names the canned ACL an object's grants amount to
+func cannedACL(output *s3.GetObjectAclOutput) string {
+	owner := ""
+	if output.Owner != nil {
+		owner = getString(output.Owner.ID)
+	}
+	var publicRead, publicWrite, authenticatedRead bool
+	for _, grant := range output.Grants {
+		if grant.Grantee == nil {
+			return ACLCustom
+		}
+		grantee := *grant.Grantee
+		switch {
+		case grantee.Type == types.TypeCanonicalUser && getString(grantee.ID) == owner && grant.Permission == types.PermissionFullControl:
+		case getString(grantee.URI) == allUsersURI && grant.Permission == types.PermissionRead:
+			publicRead = true
+		case getString(grantee.URI) == allUsersURI && grant.Permission == types.PermissionWrite:
+			publicWrite = true
+		case getString(grantee.URI) == authenticatedUsersURI && grant.Permission == types.PermissionRead:
+			authenticatedRead = true
+		default:
+			return ACLCustom
+		}
+	}
+	switch {
+	case authenticatedRead && (publicRead || publicWrite):
+		return ACLCustom
+	case authenticatedRead:
+		return string(types.ObjectCannedACLAuthenticatedRead)
+	case publicRead && publicWrite:
+		return string(types.ObjectCannedACLPublicReadWrite)
+	case publicRead:
+		return string(types.ObjectCannedACLPublicRead)
+	case publicWrite:
+		return ACLCustom
+	}
+	return string(types.ObjectCannedACLPrivate)
+}
+
+func equalMaps(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if other, ok := b[k]; !ok || other != v {
+			return false
+		}
+	}
+	return true
+}
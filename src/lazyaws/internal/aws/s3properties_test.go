@@ -0,0 +1,217 @@
+package aws
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestObjectPropertiesForm(t *testing.T) {
+	props := []ObjectProperties{
+		{Key: "a.txt", StorageClass: "STANDARD", ContentType: "text/plain", ACL: "private",
+			Metadata: map[string]string{"owner": "data", "source": "etl"}, Tags: map[string]string{"env": "prod"}},
+		{Key: "b.txt", StorageClass: "STANDARD_IA", ContentType: "text/plain", ACL: "private",
+			Metadata: map[string]string{"owner": "data"}, Tags: map[string]string{"env": "dev", "team": "x"}},
+	}
+	form := NewObjectPropertiesForm(props)
+	expected := ObjectPropertiesForm{
+		StorageClass: FormVaries,
+		ContentType:  "text/plain",
+		ACL:          "private",
+		Metadata:     map[string]string{"owner": "data", "source": FormVaries},
+		Tags:         map[string]string{"env": FormVaries, "team": FormVaries},
+	}
+	if !reflect.DeepEqual(form, expected) {
+		t.Fatalf("Expected form %+v, got %+v", expected, form)
+	}
+
+	// The marshalled form parses back unchanged
+	parsed, err := ParseObjectPropertiesForm(form.Marshal())
+	if err != nil || !reflect.DeepEqual(parsed, form) {
+		t.Fatalf("Expected the form to round-trip, got %+v (%v)", parsed, err)
+	}
+
+	for _, doc := range []string{
+		`{"StorageClass": "COLD"}`,
+		`{"StorageClass": "STANDARD", "ACL": "everyone"}`,
+		`{"StorageClass": "STANDARD", "ACL": "private", "Owner": "me"}`,
+		`{"StorageClass": "STANDARD", "ACL": "private", "Metadata": {"a b": "x"}}`,
+		`{"StorageClass": "STANDARD", "ACL": "private", "Tags": {"": "x"}}`,
+	} {
+		if _, err := ParseObjectPropertiesForm(doc); err == nil {
+			t.Errorf("Expected %s to fail", doc)
+		}
+	}
+}
+
+func TestPlanObjectPropertyChanges(t *testing.T) {
+	props := []ObjectProperties{
+		{Key: "a.txt", StorageClass: "STANDARD", ContentType: "text/plain", ACL: "private",
+			Metadata: map[string]string{"owner": "data", "source": "etl"}, Tags: map[string]string{"env": "prod"}},
+		{Key: "b.txt", StorageClass: "STANDARD_IA", ContentType: "text/plain", ACL: "custom",
+			Metadata: map[string]string{"owner": "data"}, Tags: map[string]string{"env": "dev"}},
+	}
+	original := NewObjectPropertiesForm(props)
+
+	edited := NewObjectPropertiesForm(props)
+	edited.ContentType = "text/csv"
+	edited.Metadata = map[string]string{"owner": "platform", "source": FormVaries}
+	edited.Tags = map[string]string{"team": "x"}
+	changes, err := PlanObjectPropertyChanges(props, original, edited)
+	if err != nil || len(changes) != 2 {
+		t.Fatalf("Expected both objects to change, got %d (%v)", len(changes), err)
+	}
+	if after := changes[0].After; after.StorageClass != "STANDARD" || after.Metadata["source"] != "etl" || after.ACL != "private" {
+		t.Errorf("Expected fields left alone to be kept, got %+v", after)
+	}
+	expected := []string{
+		"~ content type: text/plain -> text/csv",
+		"~ metadata owner: data -> platform",
+		"- tag env=dev",
+		"+ tag team=x",
+	}
+	if lines := changes[1].Describe(); !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Expected changes %q, got %q", expected, lines)
+	}
+
+	// Only the objects that actually change are planned
+	edited = NewObjectPropertiesForm(props)
+	edited.StorageClass = "STANDARD_IA"
+	if changes, err := PlanObjectPropertyChanges(props, original, edited); err != nil || len(changes) != 1 || changes[0].Before.Key != "a.txt" {
+		t.Errorf("Expected only a.txt to change class, got %+v (%v)", changes, err)
+	}
+
+	for _, edit := range []func(*ObjectPropertiesForm){
+		func(f *ObjectPropertiesForm) { f.ContentType = FormVaries },
+		func(f *ObjectPropertiesForm) { f.Tags["new"] = FormVaries },
+		func(f *ObjectPropertiesForm) { f.ACL = ACLCustom },
+	} {
+		edited := NewObjectPropertiesForm(props)
+		edit(&edited)
+		if _, err := PlanObjectPropertyChanges(props, original, edited); err == nil {
+			t.Errorf("Expected the edit to %+v to fail", edited)
+		}
+	}
+}
+
+func TestApplyObjectPropertyChanges(t *testing.T) {
+	maxCopyObjectSize, copyPartSize = 4, 4
+	defer func() { maxCopyObjectSize, copyPartSize = 5<<30, 512<<20 }()
+
+	backend := NewFakeBackend()
+	backend.S3.AddBucket("test-bucket", "us-east-1")
+	backend.S3.AddObject("test-bucket", "small.txt", []byte("abc"))
+	backend.S3.AddObject("test-bucket", "large.txt", []byte("0123456789"))
+	backend.S3.AddObject("test-bucket", "dir/", nil)
+	bucket := backend.S3.Buckets["test-bucket"]
+	for _, key := range []string{"small.txt", "large.txt"} {
+		bucket.Objects[key].ContentType = "text/plain"
+		bucket.Objects[key].Metadata = map[string]string{"owner": "data"}
+		bucket.Objects[key].Tags = map[string]string{"env": "prod"}
+		bucket.Objects[key].ACL = "public-read"
+	}
+	client := backend.Client("us-east-1")
+	ctx := context.Background()
+
+	objects := []S3Object{{Key: "small.txt", Size: 3}, {Key: "large.txt", Size: 10}, {Key: "dir/"}}
+	props, err := client.GetObjectProperties(ctx, "test-bucket", objects, nil)
+	if err != nil || len(props) != 2 {
+		t.Fatalf("Expected the properties of both files, got %d (%v)", len(props), err)
+	}
+	if props[0].ACL != "public-read" || props[1].Tags["env"] != "prod" || props[0].StorageClass != "STANDARD" {
+		t.Errorf("Unexpected properties: %+v", props)
+	}
+
+	original := NewObjectPropertiesForm(props)
+	edited := NewObjectPropertiesForm(props)
+	edited.StorageClass = "STANDARD_IA"
+	edited.ContentType = "text/csv"
+	edited.Metadata = map[string]string{"owner": "platform"}
+	changes, err := PlanObjectPropertyChanges(props, original, edited)
+	if err != nil {
+		t.Fatalf("PlanObjectPropertyChanges returned error: %v", err)
+	}
+	if result := client.ApplyObjectPropertyChanges(ctx, "test-bucket", changes, nil); result.Err() != nil || result.Succeeded != 2 {
+		t.Fatalf("Expected both objects to change, got %+v (%v)", result, result.Err())
+	}
+	for _, key := range []string{"small.txt", "large.txt"} {
+		obj := bucket.Objects[key]
+		if obj.StorageClass != s3types.StorageClassStandardIa || obj.ContentType != "text/csv" || obj.Metadata["owner"] != "platform" {
+			t.Errorf("%s: expected the new class, type and metadata, got %+v", key, obj)
+		}
+		// A copy in place keeps the tags and ACL
+		if obj.Tags["env"] != "prod" || obj.ACL != "public-read" {
+			t.Errorf("%s: expected tags and ACL to be kept, got %v and %q", key, obj.Tags, obj.ACL)
+		}
+	}
+	if string(bucket.Objects["large.txt"].Data) != "0123456789" {
+		t.Errorf("Expected the multipart copy to keep the data, got %q", bucket.Objects["large.txt"].Data)
+	}
+
+	// Tags and ACL alone don't copy the object
+	props, _ = client.GetObjectProperties(ctx, "test-bucket", objects[:1], nil)
+	modified := bucket.Objects["small.txt"].LastModified
+	original = NewObjectPropertiesForm(props)
+	edited = NewObjectPropertiesForm(props)
+	edited.ACL = "private"
+	edited.Tags = map[string]string{}
+	changes, _ = PlanObjectPropertyChanges(props, original, edited)
+	if result := client.ApplyObjectPropertyChanges(ctx, "test-bucket", changes, nil); result.Err() != nil {
+		t.Fatalf("ApplyObjectPropertyChanges returned error: %v", result.Err())
+	}
+	obj := bucket.Objects["small.txt"]
+	if obj.ACL != "" || len(obj.Tags) != 0 || !obj.LastModified.Equal(modified) {
+		t.Errorf("Expected a private, untagged object left in place, got ACL %q, tags %v", obj.ACL, obj.Tags)
+	}
+	if !strings.HasPrefix(changes[0].Describe()[0], "~ acl: public-read -> private") {
+		t.Errorf("Unexpected description %q", changes[0].Describe())
+	}
+}
+
+func TestObjectPropertiesWithACLsDisabled(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.S3.AddBucket("test-bucket", "us-east-1")
+	backend.S3.AddObject("test-bucket", "a.txt", []byte("abc"))
+	bucket := backend.S3.Buckets["test-bucket"]
+	bucket.ACLsDisabled = true
+	client := backend.Client("us-east-1")
+	ctx := context.Background()
+
+	objects := []S3Object{{Key: "a.txt", Size: 3}}
+	props, err := client.GetObjectProperties(ctx, "test-bucket", objects, nil)
+	if err != nil || len(props) != 1 {
+		t.Fatalf("Expected the properties without an ACL, got %+v (%v)", props, err)
+	}
+	if props[0].ACL != "" {
+		t.Errorf("Expected no ACL, got %q", props[0].ACL)
+	}
+
+	original := NewObjectPropertiesForm(props)
+	if strings.Contains(original.Marshal(), "ACL") {
+		t.Errorf("Expected the form to leave out the ACL, got %s", original.Marshal())
+	}
+
+	// Setting an ACL is refused, everything else still applies
+	edited := NewObjectPropertiesForm(props)
+	edited.ACL = "public-read"
+	if _, err := PlanObjectPropertyChanges(props, original, edited); err == nil {
+		t.Error("Expected setting an ACL to fail")
+	}
+	edited = NewObjectPropertiesForm(props)
+	edited.StorageClass = "STANDARD_IA"
+	edited.Tags = map[string]string{"env": "prod"}
+	changes, err := PlanObjectPropertyChanges(props, original, edited)
+	if err != nil {
+		t.Fatalf("PlanObjectPropertyChanges returned error: %v", err)
+	}
+	if result := client.ApplyObjectPropertyChanges(ctx, "test-bucket", changes, nil); result.Err() != nil {
+		t.Fatalf("ApplyObjectPropertyChanges returned error: %v", result.Err())
+	}
+	obj := bucket.Objects["a.txt"]
+	if obj.StorageClass != s3types.StorageClassStandardIa || obj.Tags["env"] != "prod" {
+		t.Errorf("Expected the new class and tags, got %+v", obj)
+	}
+}
@@ -0,0 +1,156 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// Defaults for restoring archived objects
+const (
+	DefaultRestoreTier = string(types.TierStandard)
+	DefaultRestoreDays = 7
+)
+
+// restoreHeader matches the fields of the x-amz-restore header, e.g.
+// ongoing-request="false", expiry-date="Fri, 21 Dec 2012 00:00:00 GMT"
+var restoreHeader = regexp.MustCompile(`([a-z-]+)="([^"]*)"`)
+
+// Restore states of an object
+const (
+	RestoreNone       = ""          // Readable, or archived with no restore requested
+	RestoreInProgress = "restoring" // A restore was requested and hasn't finished
+	RestoreAvailable  = "restored"  // A temporary copy is readable until Expiry
+)
+
+// ObjectRestoreStatus is how far an archived object is from being readable
+type ObjectRestoreStatus struct {
+	Archived bool   // Reading the object needs a restore
+	State    string // RestoreNone, RestoreInProgress or RestoreAvailable
+	Expiry   time.Time
+}
+
+// Readable reports whether the object can be downloaded now
+func (s ObjectRestoreStatus) Readable() bool {
+	return !s.Archived || s.State == RestoreAvailable
+}
+
+// IsArchiveStorageClass reports whether objects in a storage class have to
+// be restored before they can be read. Intelligent-Tiering objects only
+// need it once moved to an archive tier, which HeadObject reports.
+func IsArchiveStorageClass(storageClass string) bool {
+	switch types.StorageClass(storageClass) {
+	case types.StorageClassGlacier, types.StorageClassDeepArchive:
+		return true
+	}
+	return false
+}
+
+// ParseRestoreTier checks a retrieval tier name, ignoring case
+func ParseRestoreTier(name string) (string, error) {
+	for _, tier := range types.Tier("").Values() {
+		if strings.EqualFold(name, string(tier)) {
+			return string(tier), nil
+		}
+	}
+	return "", fmt.Errorf("unknown restore tier %q (want Expedited, Standard or Bulk)", name)
+}
+
+// GetRestoreStatus returns the restore status of an object
+func (c *Client) GetRestoreStatus(ctx context.Context, bucketName, key string) (ObjectRestoreStatus, error) {
+	head, err := c.S3.HeadObject(ctx, &s3.HeadObjectInput{Bucket: &bucketName, Key: &key})
+	if err != nil {
+		return ObjectRestoreStatus{}, fmt.Errorf("failed to get object metadata: %w", err)
+	}
+	return restoreStatusFromHead(head), nil
+}
+
+// GetRestoreStatuses returns the restore status of the archived objects
+// among objects, by key. Objects in other storage classes are left out.
+func (c *Client) GetRestoreStatuses(ctx context.Context, bucketName string, objects []S3Object) (map[string]ObjectRestoreStatus, error) {
+	var archived []S3Object
+	for _, obj := range objects {
+		if !obj.IsFolder && IsArchiveStorageClass(obj.StorageClass) {
+			archived = append(archived, obj)
+		}
+	}
+
+	var mu sync.Mutex
+	statuses := make(map[string]ObjectRestoreStatus)
+	result := forEachObject(ctx, archived, nil, func(obj S3Object) error {
+		status, err := c.GetRestoreStatus(ctx, bucketName, obj.Key)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		statuses[obj.Key] = status
+		mu.Unlock()
+		return nil
+	})
+	return statuses, result.Err()
+}
+
+// RestoreObjects requests a temporary copy of archived objects, readable
+// for days once the restore finishes. Objects that aren't archived are
+// left alone, and objects already being restored count as requested.
+func (c *Client) RestoreObjects(ctx context.Context, bucketName string, objects []S3Object, tier string, days int32, progress BatchProgressCallback) *BatchResult {
+	return forEachObject(ctx, objects, progress, func(obj S3Object) error {
+		if strings.HasSuffix(obj.Key, "/") {
+			return nil
+		}
+		key := obj.Key
+		request := &types.RestoreRequest{
+			Days:                 &days,
+			GlacierJobParameters: &types.GlacierJobParameters{Tier: types.Tier(tier)},
+		}
+		if !IsArchiveStorageClass(obj.StorageClass) {
+			if obj.StorageClass != string(types.StorageClassIntelligentTiering) {
+				return nil
+			}
+			head, err := c.S3.HeadObject(ctx, &s3.HeadObjectInput{Bucket: &bucketName, Key: &key})
+			if err != nil {
+				return fmt.Errorf("failed to get object metadata: %w", err)
+			}
+			if head.ArchiveStatus == "" {
+				return nil
+			}
+			// Archive tiers of Intelligent-Tiering move the object back
+			// for good, so a restore has no expiry
+			request.Days = nil
+		}
+
+		_, err := c.S3.RestoreObject(ctx, &s3.RestoreObjectInput{Bucket: &bucketName, Key: &key, RestoreRequest: request})
+		if err != nil && !isErrorCode(err, "RestoreAlreadyInProgress") {
+			return fmt.Errorf("failed to restore object: %w", err)
+		}
+		return nil
+	})
+}
+
+// restoreStatusFromHead reads the restore status from object metadata
+func restoreStatusFromHead(head *s3.HeadObjectOutput) ObjectRestoreStatus {
+	status := ObjectRestoreStatus{
+		Archived: IsArchiveStorageClass(string(head.StorageClass)) || head.ArchiveStatus != "",
+	}
+	fields := make(map[string]string)
+	for _, match := range restoreHeader.FindAllStringSubmatch(getString(head.Restore), -1) {
+		fields[match[1]] = match[2]
+	}
+	switch fields["ongoing-request"] {
+	case "true":
+		status.State = RestoreInProgress
+	case "false":
+		status.State = RestoreAvailable
+		if expiry, err := http.ParseTime(fields["expiry-date"]); err == nil {
+			status.Expiry = expiry
+		}
+	}
+	return status
+}
@@ -0,0 +1,92 @@
+package aws
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdkaws "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestRestoreStatusFromHead(t *testing.T) {
+	restore := func(header string) *string { return &header }
+	tests := []struct {
+		head     s3.HeadObjectOutput
+		state    string
+		readable bool
+	}{
+		{s3.HeadObjectOutput{}, RestoreNone, true},
+		{s3.HeadObjectOutput{StorageClass: s3types.StorageClassGlacier}, RestoreNone, false},
+		{s3.HeadObjectOutput{StorageClass: s3types.StorageClassDeepArchive, Restore: restore(`ongoing-request="true"`)}, RestoreInProgress, false},
+		{s3.HeadObjectOutput{StorageClass: s3types.StorageClassGlacier, Restore: restore(`ongoing-request="false", expiry-date="Fri, 21 Dec 2012 00:00:00 GMT"`)}, RestoreAvailable, true},
+		{s3.HeadObjectOutput{StorageClass: s3types.StorageClassIntelligentTiering, ArchiveStatus: s3types.ArchiveStatusArchiveAccess}, RestoreNone, false},
+	}
+	for i, tt := range tests {
+		status := restoreStatusFromHead(&tt.head)
+		if status.State != tt.state || status.Readable() != tt.readable {
+			t.Errorf("%d: expected state %q readable %v, got %+v", i, tt.state, tt.readable, status)
+		}
+	}
+
+	status := restoreStatusFromHead(&tests[3].head)
+	if !status.Expiry.Equal(time.Date(2012, 12, 21, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the expiry date to be parsed, got %v", status.Expiry)
+	}
+}
+
+func TestRestoreObjects(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.S3.AddBucket("test-bucket", "us-east-1")
+	backend.S3.AddObject("test-bucket", "archive/a.bin", []byte("a"))
+	backend.S3.AddObject("test-bucket", "archive/b.bin", []byte("b"))
+	backend.S3.AddObject("test-bucket", "hot.txt", []byte("hot"))
+	bucket := backend.S3.Buckets["test-bucket"]
+	bucket.Objects["archive/a.bin"].StorageClass = s3types.StorageClassGlacier
+	bucket.Objects["archive/b.bin"].StorageClass = s3types.StorageClassDeepArchive
+	client := backend.Client("us-east-1")
+	ctx := context.Background()
+
+	listed, err := client.ListObjects(ctx, "test-bucket", "archive/", nil)
+	if err != nil {
+		t.Fatalf("ListObjects returned error: %v", err)
+	}
+	objects := append(listed.Objects, S3Object{Key: "hot.txt", StorageClass: "STANDARD"})
+
+	statuses, err := client.GetRestoreStatuses(ctx, "test-bucket", objects)
+	if err != nil || len(statuses) != 2 || statuses["archive/a.bin"].Readable() {
+		t.Fatalf("Expected two unreadable archived objects, got %v (%v)", statuses, err)
+	}
+
+	if result := client.RestoreObjects(ctx, "test-bucket", objects, "Bulk", 3, nil); result.Err() != nil || result.Succeeded != 3 {
+		t.Fatalf("Expected every object to be handled, got %+v (%v)", result, result.Err())
+	}
+	// Asking again while the restore runs isn't an error
+	if result := client.RestoreObjects(ctx, "test-bucket", objects[:1], "Bulk", 3, nil); result.Err() != nil {
+		t.Errorf("Expected a repeated restore to succeed, got %v", result.Err())
+	}
+	if status, _ := client.GetRestoreStatus(ctx, "test-bucket", "archive/a.bin"); status.State != RestoreInProgress {
+		t.Errorf("Expected the restore to be in progress, got %+v", status)
+	}
+	if _, err := client.S3.GetObject(ctx, &s3.GetObjectInput{Bucket: sdkaws.String("test-bucket"), Key: sdkaws.String("archive/a.bin")}); err == nil {
+		t.Error("Expected reading an object being restored to fail")
+	}
+
+	if err := backend.S3.CompleteRestore("test-bucket", "archive/a.bin"); err != nil {
+		t.Fatalf("CompleteRestore returned error: %v", err)
+	}
+	status, err := client.GetRestoreStatus(ctx, "test-bucket", "archive/a.bin")
+	if err != nil || !status.Readable() || status.Expiry.Before(time.Now().AddDate(0, 0, 2)) {
+		t.Errorf("Expected a restored copy for 3 days, got %+v (%v)", status, err)
+	}
+}
+
+func TestParseRestoreTier(t *testing.T) {
+	if tier, err := ParseRestoreTier("expedited"); err != nil || tier != "Expedited" {
+		t.Errorf("Expected Expedited, got %q (%v)", tier, err)
+	}
+	if _, err := ParseRestoreTier("fast"); err == nil {
+		t.Error("Expected an unknown tier to fail")
+	}
+}
@@ -0,0 +1,321 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// maxS3SearchResults is the most matches a search collects before it stops
+const maxS3SearchResults = 10000
+
+// comparisonTerm is a size or modified search term, such as size>=10MB
+var comparisonTerm = regexp.MustCompile(`^(size|modified)(>=|<=|>|<|=)(.+)$`)
+
+// S3SearchQuery selects objects anywhere below a prefix. Fields left at
+// their zero value don't filter.
+type S3SearchQuery struct {
+	Glob           string         // Matched against the key below the prefix; "*" also matches "/"
+	Regex          *regexp.Regexp // Searched for in the key below the prefix
+	MinSize        int64
+	MaxSize        int64 // Exclusive; 0 for no limit
+	ModifiedAfter  time.Time
+	ModifiedBefore time.Time
+	StorageClasses []string          // Any of these classes
+	Tags           map[string]string // Tags the object must have; an empty value matches any
+}
+
+// S3SearchResult is what a search found
+type S3SearchResult struct {
+	Objects   []S3Object
+	Scanned   int  // Objects listed
+	Truncated bool // The search stopped at the result limit
+}
+
+// S3SearchProgressCallback receives the number of objects listed and
+// matched so far
+type S3SearchProgressCallback func(scanned, matched int)
+
+// ParseS3SearchQuery parses search terms:
+//
+//	GLOB                  key below the prefix, e.g. *.log or logs/2024-*
+//	re:REGEX              regular expression searched for in the key
+//	size>10MB size<=1G    size range, in B, KB, MB, GB or TB (powers of 1024)
+//	modified>2024-01-31   modified after a date, RFC 3339 time or age (7d, 12h)
+//	modified<30d          modified before
+//	class=GLACIER,DEEP_ARCHIVE
+//	tag:KEY=VALUE tag:KEY
+func ParseS3SearchQuery(terms []string, now time.Time) (S3SearchQuery, error) {
+	var q S3SearchQuery
+	for _, term := range terms {
+		var field, op, value string
+		if m := comparisonTerm.FindStringSubmatch(term); m != nil {
+			field, op, value = m[1], m[2], m[3]
+		}
+		switch {
+		case strings.HasPrefix(term, "re:"):
+			if q.Glob != "" || q.Regex != nil {
+				return q, fmt.Errorf("only one key pattern is allowed, got %q", term)
+			}
+			re, err := regexp.Compile(strings.TrimPrefix(term, "re:"))
+			if err != nil {
+				return q, fmt.Errorf("invalid regex: %w", err)
+			}
+			q.Regex = re
+
+		case field == "size":
+			size, err := parseSize(value)
+			if err != nil {
+				return q, fmt.Errorf("%s: %w", term, err)
+			}
+			switch op {
+			case ">":
+				q.MinSize = size + 1
+			case ">=":
+				q.MinSize = size
+			case "<":
+				q.MaxSize = size
+			case "<=":
+				q.MaxSize = size + 1
+			case "=":
+				q.MinSize, q.MaxSize = size, size+1
+			}
+			if q.MaxSize == 0 && (op == "<" || op == "<=") {
+				return q, fmt.Errorf("%s matches nothing", term)
+			}
+
+		case field == "modified":
+			t, err := parseSearchTime(value, now)
+			if err != nil {
+				return q, fmt.Errorf("%s: %w", term, err)
+			}
+			switch op {
+			case ">", ">=":
+				q.ModifiedAfter = t
+			case "<", "<=":
+				q.ModifiedBefore = t
+			default:
+				return q, fmt.Errorf("%s: use modified> or modified<", term)
+			}
+
+		case strings.HasPrefix(term, "class="):
+			for _, name := range strings.Split(strings.TrimPrefix(term, "class="), ",") {
+				class, err := ParseStorageClass(name)
+				if err != nil {
+					return q, err
+				}
+				q.StorageClasses = append(q.StorageClasses, class)
+			}
+
+		case strings.HasPrefix(term, "tag:"):
+			key, value, _ := strings.Cut(strings.TrimPrefix(term, "tag:"), "=")
+			if key == "" {
+				return q, fmt.Errorf("expected tag:KEY=VALUE, got %q", term)
+			}
+			if q.Tags == nil {
+				q.Tags = make(map[string]string)
+			}
+			q.Tags[key] = value
+
+		default:
+			if q.Glob != "" || q.Regex != nil {
+				return q, fmt.Errorf("only one key pattern is allowed, got %q", term)
+			}
+			q.Glob = term
+		}
+	}
+	return q, nil
+}
+
+// SearchObjects lists every object below prefix, page by page, and returns
+// those the query selects, in key order. Tag predicates cost a
+// GetObjectTagging request for each object that passes the other ones.
+func (c *Client) SearchObjects(ctx context.Context, bucketName, prefix string, q S3SearchQuery, progress S3SearchProgressCallback) (*S3SearchResult, error) {
+	var glob *regexp.Regexp
+	if q.Glob != "" {
+		glob = globRegexp(q.Glob)
+	}
+
+	result := &S3SearchResult{}
+	input := &s3.ListObjectsV2Input{Bucket: &bucketName, Prefix: &prefix}
+	for {
+		output, err := c.S3.ListObjectsV2(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", err)
+		}
+
+		var candidates []S3Object
+		for _, obj := range output.Contents {
+			result.Scanned++
+			key := getString(obj.Key)
+			rel := strings.TrimPrefix(key, prefix)
+			// Folder placeholders aren't objects anyone searches for
+			if rel == "" || strings.HasSuffix(key, "/") {
+				continue
+			}
+			if glob != nil && !glob.MatchString(rel) {
+				continue
+			}
+			if q.Regex != nil && !q.Regex.MatchString(rel) {
+				continue
+			}
+			if !q.matchesObject(obj) {
+				continue
+			}
+			candidates = append(candidates, s3ObjectFromListing(obj))
+		}
+
+		if len(q.Tags) > 0 {
+			if candidates, err = c.filterByTags(ctx, bucketName, candidates, q.Tags); err != nil {
+				return nil, err
+			}
+		}
+		for _, obj := range candidates {
+			if len(result.Objects) == maxS3SearchResults {
+				result.Truncated = true
+				break
+			}
+			result.Objects = append(result.Objects, obj)
+		}
+
+		if progress != nil {
+			progress(result.Scanned, len(result.Objects))
+		}
+		if result.Truncated || !getBool(output.IsTruncated) {
+			break
+		}
+		input.ContinuationToken = output.NextContinuationToken
+	}
+	return result, nil
+}
+
+// matchesObject applies the predicates a listing can answer
+func (q S3SearchQuery) matchesObject(obj types.Object) bool {
+	size := getInt64(obj.Size)
+	if size < q.MinSize || (q.MaxSize > 0 && size >= q.MaxSize) {
+		return false
+	}
+	if !q.ModifiedAfter.IsZero() || !q.ModifiedBefore.IsZero() {
+		if obj.LastModified == nil {
+			return false
+		}
+		if !q.ModifiedAfter.IsZero() && !obj.LastModified.After(q.ModifiedAfter) {
+			return false
+		}
+		if !q.ModifiedBefore.IsZero() && !obj.LastModified.Before(q.ModifiedBefore) {
+			return false
+		}
+	}
+	if len(q.StorageClasses) > 0 {
+		class := string(obj.StorageClass)
+		if class == "" {
+			class = string(types.ObjectStorageClassStandard)
+		}
+		found := false
+		for _, want := range q.StorageClasses {
+			found = found || class == want
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// filterByTags keeps the objects that have every tag, fetching their tags a
+// few at a time
+func (c *Client) filterByTags(ctx context.Context, bucketName string, objects []S3Object, tags map[string]string) ([]S3Object, error) {
+	var mu sync.Mutex
+	matched := make(map[string]bool)
+	result := forEachObject(ctx, objects, nil, func(obj S3Object) error {
+		key := obj.Key
+		output, err := c.S3.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{Bucket: &bucketName, Key: &key})
+		if err != nil {
+			return fmt.Errorf("failed to get tags: %w", err)
+		}
+		have := make(map[string]string)
+		for _, tag := range output.TagSet {
+			have[getString(tag.Key)] = getString(tag.Value)
+		}
+		for k, v := range tags {
+			value, ok := have[k]
+			if !ok || (v != "" && value != v) {
+				return nil
+			}
+		}
+		mu.Lock()
+		matched[key] = true
+		mu.Unlock()
+		return nil
+	})
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+
+	var kept []S3Object
+	for _, obj := range objects {
+		if matched[obj.Key] {
+			kept = append(kept, obj)
+		}
+	}
+	return kept, nil
+}
+
+// s3ObjectFromListing converts a listed object the way ListObjects does
+func s3ObjectFromListing(obj types.Object) S3Object {
+	storageClass := string(obj.StorageClass)
+	if storageClass == "" {
+		storageClass = string(types.ObjectStorageClassStandard)
+	}
+	lastModified := ""
+	if obj.LastModified != nil {
+		lastModified = obj.LastModified.Format("2006-01-02 15:04:05")
+	}
+	return S3Object{
+		Key:          getString(obj.Key),
+		Size:         getInt64(obj.Size),
+		LastModified: lastModified,
+		StorageClass: storageClass,
+	}
+}
+
+// parseSize parses a size such as 512, 10KB or 1.5G, in powers of 1024
+func parseSize(s string) (int64, error) {
+	upper := strings.ToUpper(s)
+	number := strings.TrimRight(upper, "KMGTIB")
+	unit := strings.TrimSuffix(strings.TrimSuffix(upper[len(number):], "B"), "I")
+	multiplier := map[string]float64{"": 1, "K": 1 << 10, "M": 1 << 20, "G": 1 << 30, "T": 1 << 40}[unit]
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || multiplier == 0 || value < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(math.Round(value * multiplier)), nil
+}
+
+// parseSearchTime parses a date, an RFC 3339 time, or an age such as 7d or
+// 12h counted back from now
+func parseSearchTime(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		return t, nil
+	}
+	if strings.HasSuffix(s, "d") {
+		if days, err := strconv.Atoi(strings.TrimSuffix(s, "d")); err == nil && days >= 0 {
+			return now.AddDate(0, 0, -days), nil
+		}
+	}
+	if age, err := time.ParseDuration(s); err == nil && age >= 0 {
+		return now.Add(-age), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (want 2024-01-31, an RFC 3339 time or an age like 7d)", s)
+}
@@ -0,0 +1,139 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestParseS3SearchQuery(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	q, err := ParseS3SearchQuery([]string{"*.log", "size>1KB", "size<=2M", "modified>7d", "class=glacier,STANDARD", "tag:env=prod", "tag:team"}, now)
+	if err != nil {
+		t.Fatalf("ParseS3SearchQuery returned error: %v", err)
+	}
+	if q.Glob != "*.log" || q.MinSize != 1025 || q.MaxSize != 2<<20+1 {
+		t.Errorf("Unexpected pattern or sizes: %+v", q)
+	}
+	if !q.ModifiedAfter.Equal(now.AddDate(0, 0, -7)) || !q.ModifiedBefore.IsZero() {
+		t.Errorf("Expected modified after %v, got %v", now.AddDate(0, 0, -7), q.ModifiedAfter)
+	}
+	if len(q.StorageClasses) != 2 || q.StorageClasses[0] != "GLACIER" {
+		t.Errorf("Unexpected storage classes: %v", q.StorageClasses)
+	}
+	if q.Tags["env"] != "prod" || q.Tags["team"] != "" || len(q.Tags) != 2 {
+		t.Errorf("Unexpected tags: %v", q.Tags)
+	}
+
+	// A key that happens to start like a term is still a pattern
+	if q, err := ParseS3SearchQuery([]string{"sizes/*.csv"}, now); err != nil || q.Glob != "sizes/*.csv" {
+		t.Errorf("Expected sizes/*.csv as the glob, got %+v (%v)", q, err)
+	}
+
+	for _, terms := range [][]string{
+		{"*.log", "re:log$"},
+		{"re:("},
+		{"size>lots"},
+		{"size<0"},
+		{"modified=2024-01-01"},
+		{"modified<yesterday"},
+		{"class=COLD"},
+		{"tag:=x"},
+	} {
+		if _, err := ParseS3SearchQuery(terms, now); err == nil {
+			t.Errorf("Expected %v to fail", terms)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"512", 512},
+		{"512B", 512},
+		{"10KB", 10 << 10},
+		{"1.5G", 3 << 29},
+		{"2MiB", 2 << 20},
+		{"1tb", 1 << 40},
+	}
+	for _, tt := range tests {
+		size, err := parseSize(tt.input)
+		if err != nil || size != tt.expected {
+			t.Errorf("parseSize(%q) = %d (%v), expected %d", tt.input, size, err, tt.expected)
+		}
+	}
+	if _, err := parseSize("10XB"); err == nil {
+		t.Error("Expected an unknown unit to fail")
+	}
+}
+
+func TestSearchObjects(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.S3.AddBucket("test-bucket", "us-east-1")
+	for i := 0; i < 2500; i++ {
+		backend.S3.AddObject("test-bucket", fmt.Sprintf("data/part-%04d.csv", i), []byte("x"))
+	}
+	backend.S3.AddObject("test-bucket", "data/", nil)
+	backend.S3.AddObject("test-bucket", "data/logs/app.log", make([]byte, 2048))
+	backend.S3.AddObject("test-bucket", "data/logs/old.log", make([]byte, 2048))
+	backend.S3.AddObject("test-bucket", "data/logs/tiny.log", []byte("x"))
+	backend.S3.AddObject("test-bucket", "other/app.log", make([]byte, 2048))
+
+	bucket := backend.S3.Buckets["test-bucket"]
+	bucket.Objects["data/logs/old.log"].LastModified = time.Now().AddDate(0, -1, 0)
+	bucket.Objects["data/logs/old.log"].StorageClass = s3types.StorageClassGlacier
+	bucket.Objects["data/logs/app.log"].Tags = map[string]string{"env": "prod"}
+	bucket.Objects["data/part-2000.csv"].Tags = map[string]string{"env": "dev"}
+
+	client := backend.Client("us-east-1")
+	ctx := context.Background()
+	search := func(terms ...string) []S3Object {
+		t.Helper()
+		q, err := ParseS3SearchQuery(terms, time.Now())
+		if err != nil {
+			t.Fatalf("ParseS3SearchQuery(%v) returned error: %v", terms, err)
+		}
+		result, err := client.SearchObjects(ctx, "test-bucket", "data/", q, nil)
+		if err != nil {
+			t.Fatalf("SearchObjects(%v) returned error: %v", terms, err)
+		}
+		return result.Objects
+	}
+
+	var calls, scanned int
+	q, _ := ParseS3SearchQuery([]string{"*.csv"}, time.Now())
+	result, err := client.SearchObjects(ctx, "test-bucket", "data/", q, func(s, _ int) {
+		calls++
+		scanned = s
+	})
+	if err != nil || len(result.Objects) != 2500 || result.Truncated {
+		t.Fatalf("Expected every CSV across pages, got %d (%v)", len(result.Objects), err)
+	}
+	if calls != 3 || scanned != 2504 || result.Scanned != 2504 {
+		t.Errorf("Expected progress after each of 3 pages up to 2504 objects, got %d calls, %d scanned", calls, scanned)
+	}
+
+	if objects := search("logs/*.log", "size>=1KB"); len(objects) != 2 || objects[0].Key != "data/logs/app.log" {
+		t.Errorf("Expected the two large logs below the prefix, got %v", objects)
+	}
+	if objects := search("re:(app|tiny)\\.log$"); len(objects) != 2 {
+		t.Errorf("Expected two regex matches, got %v", objects)
+	}
+	if objects := search("*.log", "modified<7d"); len(objects) != 1 || objects[0].StorageClass != "GLACIER" {
+		t.Errorf("Expected only the old log, got %v", objects)
+	}
+	if objects := search("class=STANDARD", "*.log"); len(objects) != 2 {
+		t.Errorf("Expected the two STANDARD logs, got %v", objects)
+	}
+	if objects := search("tag:env"); len(objects) != 2 {
+		t.Errorf("Expected the two tagged objects, got %v", objects)
+	}
+	if objects := search("tag:env=prod"); len(objects) != 1 || objects[0].Key != "data/logs/app.log" {
+		t.Errorf("Expected the prod object, got %v", objects)
+	}
+}
@@ -3,11 +3,15 @@ package aws
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
+	"os/signal"
+	"syscall"
 
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
 	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/muesli/cancelreader"
+	"golang.org/x/term"
 )
 
 // SSMConnectionStatus represents the SSM connectivity status of an instance
@@ -61,131 +65,61 @@ func (c *Client) CheckSSMConnectivity(ctx context.Context, instanceID string) (*
 	return status, nil
 }
 
-// LaunchSSMSession opens a new terminal window and starts an SSM session
-func (c *Client) LaunchSSMSession(instanceID string, region string) error {
-	// Detect terminal emulator
-	terminal := detectTerminal()
-	if terminal == "" {
-		return fmt.Errorf("could not detect terminal emulator")
-	}
-
-	// Build the AWS SSM command
-	ssmCommand := fmt.Sprintf("aws ssm start-session --target %s --region %s", instanceID, region)
-
-	// Build terminal command based on detected terminal
-	var cmd *exec.Cmd
-	switch terminal {
-	case "ghostty":
-		// Ghostty uses a similar syntax to alacritty/kitty
-		cmd = exec.Command("ghostty", "-e", "bash", "-c", ssmCommand+"; exec bash")
-	case "gnome-terminal":
-		cmd = exec.Command("gnome-terminal", "--", "bash", "-c", ssmCommand+"; exec bash")
-	case "xterm":
-		cmd = exec.Command("xterm", "-e", "bash -c '"+ssmCommand+"; exec bash'")
-	case "konsole":
-		cmd = exec.Command("konsole", "-e", "bash", "-c", ssmCommand+"; exec bash")
-	case "xfce4-terminal":
-		cmd = exec.Command("xfce4-terminal", "-e", "bash -c '"+ssmCommand+"; exec bash'")
-	case "alacritty":
-		cmd = exec.Command("alacritty", "-e", "bash", "-c", ssmCommand+"; exec bash")
-	case "kitty":
-		cmd = exec.Command("kitty", "bash", "-c", ssmCommand+"; exec bash")
-	case "terminator":
-		cmd = exec.Command("terminator", "-e", "bash -c '"+ssmCommand+"; exec bash'")
-	default:
-		return fmt.Errorf("unsupported terminal: %s", terminal)
-	}
-
-	// Start the terminal in the background
-	err := cmd.Start()
+// RunSSMShell starts an interactive shell on an instance and bridges it to
+// the terminal: stdin is put in raw mode, output is copied to stdout and
+// window size changes are forwarded to the instance
+func (c *Client) RunSSMShell(ctx context.Context, instanceID string, stdin *os.File, stdout io.Writer) error {
+	session, err := c.StartSSMSession(ctx, SSMSessionInput{Target: instanceID})
 	if err != nil {
-		return fmt.Errorf("failed to launch terminal: %w", err)
+		return err
 	}
+	defer session.Close()
 
-	return nil
-}
-
-// detectTerminal detects the user's terminal emulator
-func detectTerminal() string {
-	// Check for Ghostty first using TERM_PROGRAM environment variable
-	if os.Getenv("TERM_PROGRAM") == "ghostty" {
-		return "ghostty"
-	}
-
-	// Check common terminal emulators
-	terminals := []string{
-		"ghostty",
-		"gnome-terminal",
-		"konsole",
-		"xfce4-terminal",
-		"xterm",
-		"alacritty",
-		"kitty",
-		"terminator",
-	}
-
-	for _, term := range terminals {
-		if _, err := exec.LookPath(term); err == nil {
-			return term
+	fd := int(stdin.Fd())
+	if term.IsTerminal(fd) {
+		oldState, err := term.MakeRaw(fd)
+		if err != nil {
+			return fmt.Errorf("failed to set raw mode: %w", err)
 		}
-	}
-
-	// Check TERM environment variable as fallback
-	if term := os.Getenv("TERM"); term != "" {
-		return term
-	}
-
-	return ""
-}
-
-// StartPortForward starts an SSM port forwarding session
-func (c *Client) StartPortForward(instanceID string, region string, localPort int, remotePort int, remoteHost string) error {
-	// Detect terminal emulator
-	terminal := detectTerminal()
-	if terminal == "" {
-		return fmt.Errorf("could not detect terminal emulator")
-	}
-
-	// Build the AWS SSM port forward command
-	var ssmCommand string
-	if remoteHost == "" || remoteHost == "localhost" {
-		// Standard port forwarding
-		ssmCommand = fmt.Sprintf("aws ssm start-session --target %s --region %s --document-name AWS-StartPortForwardingSession --parameters 'localPortNumber=%d,portNumber=%d'",
-			instanceID, region, localPort, remotePort)
-	} else {
-		// Port forwarding to a remote host
-		ssmCommand = fmt.Sprintf("aws ssm start-session --target %s --region %s --document-name AWS-StartPortForwardingSessionToRemoteHost --parameters 'host=%s,localPortNumber=%d,portNumber=%d'",
-			instanceID, region, remoteHost, localPort, remotePort)
-	}
-
-	// Build terminal command based on detected terminal
-	var cmd *exec.Cmd
-	switch terminal {
-	case "ghostty":
-		cmd = exec.Command("ghostty", "-e", "bash", "-c", ssmCommand+"; echo 'Press Enter to close'; read")
-	case "gnome-terminal":
-		cmd = exec.Command("gnome-terminal", "--", "bash", "-c", ssmCommand+"; echo 'Press Enter to close'; read")
-	case "xterm":
-		cmd = exec.Command("xterm", "-e", "bash -c '"+ssmCommand+"; echo Press Enter to close; read'")
-	case "konsole":
-		cmd = exec.Command("konsole", "-e", "bash", "-c", ssmCommand+"; echo 'Press Enter to close'; read")
-	case "xfce4-terminal":
-		cmd = exec.Command("xfce4-terminal", "-e", "bash -c '"+ssmCommand+"; echo Press Enter to close; read'")
-	case "alacritty":
-		cmd = exec.Command("alacritty", "-e", "bash", "-c", ssmCommand+"; echo 'Press Enter to close'; read")
-	case "kitty":
-		cmd = exec.Command("kitty", "bash", "-c", ssmCommand+"; echo 'Press Enter to close'; read")
-	case "terminator":
-		cmd = exec.Command("terminator", "-e", "bash -c '"+ssmCommand+"; echo Press Enter to close; read'")
-	default:
-		return fmt.Errorf("unsupported terminal: %s", terminal)
-	}
-
-	// Start the terminal in the background
-	err := cmd.Start()
-	if err != nil {
-		return fmt.Errorf("failed to launch terminal: %w", err)
-	}
-
-	return nil
+		defer term.Restore(fd, oldState)
+
+		// Handle terminal resize signals
+		ch := make(chan os.Signal, 1)
+		signal.Notify(ch, syscall.SIGWINCH)
+		defer signal.Stop(ch)
+		go func() {
+			for {
+				select {
+				case <-ch:
+					if cols, rows, err := term.GetSize(fd); err == nil {
+						_ = session.Resize(cols, rows)
+					}
+				case <-session.Done():
+					return
+				}
+			}
+		}()
+		ch <- syscall.SIGWINCH // Initial resize
+	}
+
+	// Read stdin through a cancelable reader so the copy stops with the
+	// session, rather than taking the next keystroke meant for the TUI.
+	// Stdin that can't be polled, like a regular file, is read directly.
+	var input io.Reader = stdin
+	reader, err := cancelreader.NewReader(stdin)
+	if err == nil {
+		defer reader.Close()
+		input = reader
+	}
+	copied := make(chan struct{})
+	go func() {
+		defer close(copied)
+		io.Copy(session, input)
+	}()
+
+	_, err = io.Copy(stdout, session)
+	if reader != nil && reader.Cancel() {
+		<-copied
+	}
+	return err
 }
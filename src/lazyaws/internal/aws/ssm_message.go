@@ -0,0 +1,180 @@
+package aws
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Message types of the Session Manager data channel
+const (
+	ssmMessageInputStream      = "input_stream_data"
+	ssmMessageOutputStream     = "output_stream_data"
+	ssmMessageAcknowledge      = "acknowledge"
+	ssmMessageChannelClosed    = "channel_closed"
+	ssmMessageStartPublication = "start_publication"
+	ssmMessagePausePublication = "pause_publication"
+)
+
+// Payload types carried by stream data messages
+const (
+	ssmPayloadOutput            uint32 = 1
+	ssmPayloadError             uint32 = 2
+	ssmPayloadSize              uint32 = 3
+	ssmPayloadHandshakeRequest  uint32 = 5
+	ssmPayloadHandshakeResponse uint32 = 6
+	ssmPayloadHandshakeComplete uint32 = 7
+	ssmPayloadFlag              uint32 = 10
+)
+
+// Values of a flag payload exchanged during port forwarding
+const (
+	ssmFlagDisconnectToPort   uint32 = 1
+	ssmFlagTerminateSession   uint32 = 2
+	ssmFlagConnectToPortError uint32 = 3
+)
+
+// Layout of the binary message header. Every field is big-endian.
+const (
+	ssmHeaderLengthOffset   = 0
+	ssmMessageTypeOffset    = 4
+	ssmMessageTypeLength    = 32
+	ssmSchemaVersionOffset  = 36
+	ssmCreatedDateOffset    = 40
+	ssmSequenceNumberOffset = 48
+	ssmFlagsOffset          = 56
+	ssmMessageIDOffset      = 64
+	ssmPayloadDigestOffset  = 80
+	ssmPayloadTypeOffset    = 112
+	ssmPayloadLengthOffset  = 116
+	ssmPayloadOffset        = 120
+
+	// ssmHeaderLength is the value of the header length field, which counts
+	// every header byte before the payload length
+	ssmHeaderLength = ssmPayloadLengthOffset
+)
+
+// ssmMessage is one binary message on the data channel
+type ssmMessage struct {
+	MessageType    string
+	SchemaVersion  uint32
+	CreatedDate    time.Time
+	SequenceNumber int64
+	Flags          uint64
+	MessageID      ssmUUID
+	PayloadType    uint32
+	Payload        []byte
+}
+
+// marshal encodes the message, computing the payload digest
+func (m *ssmMessage) marshal() []byte {
+	buf := make([]byte, ssmPayloadOffset+len(m.Payload))
+
+	binary.BigEndian.PutUint32(buf[ssmHeaderLengthOffset:], ssmHeaderLength)
+
+	// The message type is padded with spaces to its fixed width
+	messageType := []byte(m.MessageType)
+	for i := 0; i < ssmMessageTypeLength; i++ {
+		if i < len(messageType) {
+			buf[ssmMessageTypeOffset+i] = messageType[i]
+		} else {
+			buf[ssmMessageTypeOffset+i] = ' '
+		}
+	}
+
+	binary.BigEndian.PutUint32(buf[ssmSchemaVersionOffset:], m.SchemaVersion)
+	binary.BigEndian.PutUint64(buf[ssmCreatedDateOffset:], uint64(m.CreatedDate.UnixMilli()))
+	binary.BigEndian.PutUint64(buf[ssmSequenceNumberOffset:], uint64(m.SequenceNumber))
+	binary.BigEndian.PutUint64(buf[ssmFlagsOffset:], m.Flags)
+	m.MessageID.put(buf[ssmMessageIDOffset:])
+
+	digest := sha256.Sum256(m.Payload)
+	copy(buf[ssmPayloadDigestOffset:], digest[:])
+
+	binary.BigEndian.PutUint32(buf[ssmPayloadTypeOffset:], m.PayloadType)
+	binary.BigEndian.PutUint32(buf[ssmPayloadLengthOffset:], uint32(len(m.Payload)))
+	copy(buf[ssmPayloadOffset:], m.Payload)
+	return buf
+}
+
+// unmarshalSSMMessage decodes a binary message and checks its payload digest
+func unmarshalSSMMessage(data []byte) (*ssmMessage, error) {
+	if len(data) < ssmPayloadOffset {
+		return nil, fmt.Errorf("message too short: %d bytes", len(data))
+	}
+
+	headerLength := binary.BigEndian.Uint32(data[ssmHeaderLengthOffset:])
+	if headerLength != ssmHeaderLength {
+		return nil, fmt.Errorf("unexpected header length %d", headerLength)
+	}
+
+	payloadLength := int(binary.BigEndian.Uint32(data[ssmPayloadLengthOffset:]))
+	if len(data) < ssmPayloadOffset+payloadLength {
+		return nil, fmt.Errorf("message truncated: payload length %d, got %d bytes", payloadLength, len(data)-ssmPayloadOffset)
+	}
+
+	m := &ssmMessage{
+		MessageType:    strings.TrimRight(string(data[ssmMessageTypeOffset:ssmMessageTypeOffset+ssmMessageTypeLength]), " \x00"),
+		SchemaVersion:  binary.BigEndian.Uint32(data[ssmSchemaVersionOffset:]),
+		CreatedDate:    time.UnixMilli(int64(binary.BigEndian.Uint64(data[ssmCreatedDateOffset:]))),
+		SequenceNumber: int64(binary.BigEndian.Uint64(data[ssmSequenceNumberOffset:])),
+		Flags:          binary.BigEndian.Uint64(data[ssmFlagsOffset:]),
+		MessageID:      getSSMUUID(data[ssmMessageIDOffset:]),
+		PayloadType:    binary.BigEndian.Uint32(data[ssmPayloadTypeOffset:]),
+		Payload:        data[ssmPayloadOffset : ssmPayloadOffset+payloadLength],
+	}
+
+	// Acknowledgements are sent without a digest
+	if m.MessageType != ssmMessageAcknowledge && payloadLength > 0 {
+		digest := sha256.Sum256(m.Payload)
+		if string(digest[:]) != string(data[ssmPayloadDigestOffset:ssmPayloadDigestOffset+sha256.Size]) {
+			return nil, fmt.Errorf("payload digest mismatch in %s message %d", m.MessageType, m.SequenceNumber)
+		}
+	}
+	return m, nil
+}
+
+// ssmUUID is a message ID
+type ssmUUID [16]byte
+
+// newSSMUUID returns a random version 4 UUID
+func newSSMUUID() ssmUUID {
+	var id ssmUUID
+	_, _ = rand.Read(id[:])
+	id[6] = (id[6] & 0x0f) | 0x40
+	id[8] = (id[8] & 0x3f) | 0x80
+	return id
+}
+
+// String formats the UUID in its canonical form
+func (id ssmUUID) String() string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], id[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], id[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], id[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], id[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], id[10:])
+	return string(buf[:])
+}
+
+// put writes the UUID in wire order: the least significant half first
+func (id ssmUUID) put(buf []byte) {
+	copy(buf[0:8], id[8:16])
+	copy(buf[8:16], id[0:8])
+}
+
+// getSSMUUID reads a UUID written by put
+func getSSMUUID(buf []byte) ssmUUID {
+	var id ssmUUID
+	copy(id[8:16], buf[0:8])
+	copy(id[0:8], buf[8:16])
+	return id
+}
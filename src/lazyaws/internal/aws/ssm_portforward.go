@@ -0,0 +1,314 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// portForwardLinger is how long a connection whose client has stopped
+// sending waits for more of the response before it is closed
+const portForwardLinger = time.Minute
+
+// PortForwardOptions describes a port forward through an instance
+type PortForwardOptions struct {
+	InstanceID string
+	// RemoteHost is reached from the instance. Empty or "localhost" forwards
+	// to a port on the instance itself.
+	RemoteHost string
+	RemotePort int
+	// LocalPort is the port to listen on; 0 picks a free one
+	LocalPort int
+}
+
+// ParsePortForwardSpec parses "[localPort:][host:]remotePort". Without a
+// local port a free one is picked; without a host the port is on the
+// instance itself.
+func ParsePortForwardSpec(instanceID, spec string) (PortForwardOptions, error) {
+	opts := PortForwardOptions{InstanceID: instanceID}
+	parts := strings.Split(strings.TrimSpace(spec), ":")
+
+	port := func(s string) (int, error) {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 || n > 65535 {
+			return 0, fmt.Errorf("invalid port %q", s)
+		}
+		return n, nil
+	}
+
+	var err error
+	switch len(parts) {
+	case 1:
+		opts.RemotePort, err = port(parts[0])
+	case 2:
+		// A numeric first part is a local port, anything else a host
+		if local, localErr := port(parts[0]); localErr == nil {
+			opts.LocalPort = local
+		} else {
+			opts.RemoteHost = parts[0]
+		}
+		opts.RemotePort, err = port(parts[1])
+	case 3:
+		if opts.LocalPort, err = port(parts[0]); err == nil {
+			opts.RemoteHost = parts[1]
+			opts.RemotePort, err = port(parts[2])
+		}
+	default:
+		err = fmt.Errorf("expected [localPort:][host:]remotePort, got %q", spec)
+	}
+	if err != nil {
+		return PortForwardOptions{}, err
+	}
+	if opts.RemotePort == 0 {
+		return PortForwardOptions{}, fmt.Errorf("remote port is required")
+	}
+	return opts, nil
+}
+
+// PortForwardStats is a snapshot of a forward's traffic
+type PortForwardStats struct {
+	StartedAt time.Time
+	// BytesSent counts bytes from local clients to the remote port
+	BytesSent     int64
+	BytesReceived int64
+	// Connections is the number of connections open now
+	Connections int
+	// LastError is why the most recent connection failed to open a session
+	LastError error
+}
+
+// PortForward listens on a local port and forwards every connection through
+// its own Session Manager session
+type PortForward struct {
+	Options PortForwardOptions
+
+	client   *Client
+	listener net.Listener
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+
+	startedAt     time.Time
+	bytesSent     atomic.Int64
+	bytesReceived atomic.Int64
+
+	mu      sync.Mutex
+	conns   map[net.Conn]struct{}
+	err     error
+	connErr error
+
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// StartPortForward binds the local port and starts forwarding connections
+// in the background. Stop the forward to close the listener and every open
+// connection.
+func (c *Client) StartPortForward(ctx context.Context, opts PortForwardOptions) (*PortForward, error) {
+	if opts.RemotePort < 1 || opts.RemotePort > 65535 {
+		return nil, fmt.Errorf("invalid remote port %d", opts.RemotePort)
+	}
+	if opts.LocalPort < 0 || opts.LocalPort > 65535 {
+		return nil, fmt.Errorf("invalid local port %d", opts.LocalPort)
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(opts.LocalPort)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on local port %d: %w", opts.LocalPort, err)
+	}
+	opts.LocalPort = listener.Addr().(*net.TCPAddr).Port
+
+	forwardCtx, cancel := context.WithCancel(ctx)
+	forward := &PortForward{
+		Options:   opts,
+		client:    c,
+		listener:  listener,
+		ctx:       forwardCtx,
+		cancel:    cancel,
+		startedAt: time.Now(),
+		conns:     make(map[net.Conn]struct{}),
+		done:      make(chan struct{}),
+	}
+
+	go forward.acceptLoop()
+	go func() {
+		<-forwardCtx.Done()
+		forward.Stop()
+	}()
+
+	return forward, nil
+}
+
+// LocalPort returns the port the forward listens on
+func (p *PortForward) LocalPort() int {
+	return p.Options.LocalPort
+}
+
+// Done is closed once the forward has stopped
+func (p *PortForward) Done() <-chan struct{} {
+	return p.done
+}
+
+// Err returns the error that stopped the forward, or nil
+func (p *PortForward) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+// Stats returns the forward's traffic so far
+func (p *PortForward) Stats() PortForwardStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return PortForwardStats{
+		StartedAt:     p.startedAt,
+		BytesSent:     p.bytesSent.Load(),
+		BytesReceived: p.bytesReceived.Load(),
+		Connections:   len(p.conns),
+		LastError:     p.connErr,
+	}
+}
+
+// Stop closes the listener and every forwarded connection
+func (p *PortForward) Stop() error {
+	p.stop(nil)
+	<-p.done
+	return nil
+}
+
+// stop shuts the forward down once, recording err as the reason
+func (p *PortForward) stop(err error) {
+	p.stopOnce.Do(func() {
+		// Cancelling under the lock keeps acceptLoop from adding connections
+		// after they have been closed
+		p.mu.Lock()
+		p.err = err
+		p.cancel()
+		for conn := range p.conns {
+			conn.Close()
+		}
+		p.mu.Unlock()
+
+		p.listener.Close()
+
+		go func() {
+			p.wg.Wait()
+			close(p.done)
+		}()
+	})
+}
+
+// acceptLoop forwards connections until the listener is closed
+func (p *PortForward) acceptLoop() {
+	for {
+		conn, err := p.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				p.stop(fmt.Errorf("failed to accept connection: %w", err))
+			}
+			return
+		}
+
+		p.mu.Lock()
+		if p.ctx.Err() != nil {
+			p.mu.Unlock()
+			conn.Close()
+			return
+		}
+		p.conns[conn] = struct{}{}
+		p.wg.Add(1)
+		p.mu.Unlock()
+
+		go p.forward(conn)
+	}
+}
+
+// forward copies one connection to and from a new session
+func (p *PortForward) forward(conn net.Conn) {
+	defer p.wg.Done()
+	defer func() {
+		conn.Close()
+		p.mu.Lock()
+		delete(p.conns, conn)
+		p.mu.Unlock()
+	}()
+
+	session, err := p.client.StartSSMSession(p.ctx, p.sessionInput())
+	if err != nil {
+		if p.ctx.Err() == nil {
+			p.mu.Lock()
+			p.connErr = err
+			p.mu.Unlock()
+		}
+		return
+	}
+	defer session.Close()
+
+	go func() {
+		select {
+		case <-p.ctx.Done():
+			session.Close()
+		case <-session.Done():
+		}
+	}()
+
+	// A client that half-closes its side still gets the response, so the
+	// connection lasts until the remote side ends the session. One that has
+	// gone away entirely is given up on once the remote side goes quiet.
+	var received atomic.Int64
+	go func() {
+		io.Copy(session, &countingReader{r: conn, n: &p.bytesSent})
+		session.CloseWrite()
+		last := received.Load()
+		for {
+			select {
+			case <-session.Done():
+				return
+			case <-time.After(portForwardLinger):
+			}
+			if n := received.Load(); n != last {
+				last = n
+				continue
+			}
+			session.Close()
+			return
+		}
+	}()
+	io.Copy(conn, &countingReader{r: &countingReader{r: session, n: &received}, n: &p.bytesReceived})
+}
+
+// countingReader adds the bytes read through it to n
+type countingReader struct {
+	r io.Reader
+	n *atomic.Int64
+}
+
+func (c *countingReader) Read(b []byte) (int, error) {
+	n, err := c.r.Read(b)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// sessionInput returns the session that forwards one connection
+func (p *PortForward) sessionInput() SSMSessionInput {
+	input := SSMSessionInput{
+		Target:       p.Options.InstanceID,
+		DocumentName: SSMDocumentPortForward,
+		Parameters: map[string][]string{
+			"portNumber":      {strconv.Itoa(p.Options.RemotePort)},
+			"localPortNumber": {strconv.Itoa(p.Options.LocalPort)},
+		},
+	}
+	if p.Options.RemoteHost != "" && p.Options.RemoteHost != "localhost" {
+		input.DocumentName = SSMDocumentPortForwardRemoteHost
+		input.Parameters["host"] = []string{p.Options.RemoteHost}
+	}
+	return input
+}
@@ -0,0 +1,595 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	sdkaws "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// ssmClientVersion is reported to the agent. Versions before 1.1.70 get
+	// a plain byte stream for port forwarding instead of a multiplexed one,
+	// which is what SSMSession implements.
+	ssmClientVersion = "1.0.0.0"
+
+	// ssmStreamChunkSize is the largest payload sent in one message
+	ssmStreamChunkSize = 1024
+	// ssmResendTimeout is how long an input message may go unacknowledged
+	// before it is sent again
+	ssmResendTimeout = 3 * time.Second
+	// ssmPingInterval keeps idle sessions from being closed by the service
+	ssmPingInterval = 5 * time.Minute
+)
+
+// ssmMaxBufferedOutput is how much output is held for a slow reader before
+// the session stops taking more from the agent. It is a variable so tests
+// can shrink it.
+var ssmMaxBufferedOutput = 1 << 20
+
+// Documents used to start sessions
+const (
+	SSMDocumentPortForward           = "AWS-StartPortForwardingSession"
+	SSMDocumentPortForwardRemoteHost = "AWS-StartPortForwardingSessionToRemoteHost"
+)
+
+// SSMSessionInput describes the session to start. An empty DocumentName
+// starts an interactive shell.
+type SSMSessionInput struct {
+	Target       string
+	DocumentName string
+	Parameters   map[string][]string
+}
+
+// SSMSession is an open Session Manager data channel. Reads return the
+// session's output and writes are sent as input, so a shell session can be
+// bridged to a terminal and a port forwarding session to a TCP connection.
+type SSMSession struct {
+	ID string
+
+	client *Client
+	conn   *websocket.Conn
+
+	writeMu sync.Mutex // serializes websocket writes
+
+	mu          sync.Mutex
+	cond        *sync.Cond
+	output      bytes.Buffer
+	nextOutSeq  int64
+	nextInSeq   int64
+	outOfOrder  map[int64]*ssmMessage
+	unacked     map[int64]*pendingSSMMessage
+	paused      bool
+	ready       bool
+	closed      bool
+	err         error
+	closeReason string
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// pendingSSMMessage is an input message waiting for an acknowledgement
+type pendingSSMMessage struct {
+	message *ssmMessage
+	sentAt  time.Time
+}
+
+// ssmOpenDataChannel is the first (text) message sent on the websocket
+type ssmOpenDataChannel struct {
+	MessageSchemaVersion string `json:"MessageSchemaVersion"`
+	RequestID            string `json:"RequestId"`
+	TokenValue           string `json:"TokenValue"`
+	ClientID             string `json:"ClientId"`
+	ClientVersion        string `json:"ClientVersion"`
+}
+
+// ssmAcknowledge is the payload of an acknowledge message
+type ssmAcknowledge struct {
+	AcknowledgedMessageType           string `json:"AcknowledgedMessageType"`
+	AcknowledgedMessageID             string `json:"AcknowledgedMessageId"`
+	AcknowledgedMessageSequenceNumber int64  `json:"AcknowledgedMessageSequenceNumber"`
+	IsSequentialMessage               bool   `json:"IsSequentialMessage"`
+}
+
+// ssmHandshakeRequest is sent by the agent before any session data
+type ssmHandshakeRequest struct {
+	AgentVersion           string `json:"AgentVersion"`
+	RequestedClientActions []struct {
+		ActionType       string          `json:"ActionType"`
+		ActionParameters json.RawMessage `json:"ActionParameters"`
+	} `json:"RequestedClientActions"`
+}
+
+// ssmHandshakeResponse reports which requested actions the client handled
+type ssmHandshakeResponse struct {
+	ClientVersion          string               `json:"ClientVersion"`
+	ProcessedClientActions []ssmProcessedAction `json:"ProcessedClientActions"`
+	Errors                 []string             `json:"Errors"`
+}
+
+// ssmProcessedAction is the result of one handshake action
+type ssmProcessedAction struct {
+	ActionType   string `json:"ActionType"`
+	ActionStatus int    `json:"ActionStatus"`
+	Error        string `json:"Error,omitempty"`
+}
+
+// Handshake action statuses
+const (
+	ssmActionSuccess     = 1
+	ssmActionUnsupported = 3
+)
+
+// ssmChannelClosed is the payload of a channel_closed message
+type ssmChannelClosed struct {
+	SessionID string `json:"SessionId"`
+	Output    string `json:"Output"`
+}
+
+// StartSSMSession starts a Session Manager session and opens its data
+// channel. Close the session to terminate it.
+func (c *Client) StartSSMSession(ctx context.Context, input SSMSessionInput) (*SSMSession, error) {
+	params := &ssm.StartSessionInput{
+		Target:     sdkaws.String(input.Target),
+		Parameters: input.Parameters,
+	}
+	if input.DocumentName != "" {
+		params.DocumentName = sdkaws.String(input.DocumentName)
+	}
+
+	result, err := c.SSM.StartSession(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start SSM session: %w", err)
+	}
+
+	session := &SSMSession{
+		ID:         getString(result.SessionId),
+		client:     c,
+		outOfOrder: make(map[int64]*ssmMessage),
+		unacked:    make(map[int64]*pendingSSMMessage),
+		done:       make(chan struct{}),
+	}
+	session.cond = sync.NewCond(&session.mu)
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, getString(result.StreamUrl), nil)
+	if err != nil {
+		session.terminate()
+		return nil, fmt.Errorf("failed to open SSM data channel: %w", err)
+	}
+	session.conn = conn
+
+	open := ssmOpenDataChannel{
+		MessageSchemaVersion: "1.0",
+		RequestID:            newSSMUUID().String(),
+		TokenValue:           getString(result.TokenValue),
+		ClientID:             newSSMUUID().String(),
+		ClientVersion:        ssmClientVersion,
+	}
+	if err := conn.WriteJSON(open); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("failed to open SSM data channel: %w", err)
+	}
+
+	go session.readLoop()
+	go session.resendLoop()
+
+	return session, nil
+}
+
+// Read returns session output, blocking until some is available. It returns
+// io.EOF once the session has ended and all output has been read.
+func (s *SSMSession) Read(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for s.output.Len() == 0 && !s.closed {
+		s.cond.Wait()
+	}
+	if s.output.Len() > 0 {
+		// Wake readLoop if it is waiting for room
+		s.cond.Broadcast()
+		return s.output.Read(p)
+	}
+	if s.err != nil {
+		return 0, s.err
+	}
+	return 0, io.EOF
+}
+
+// Write sends p as session input. It blocks until the agent has completed
+// the handshake.
+func (s *SSMSession) Write(p []byte) (int, error) {
+	written := 0
+	for written < len(p) {
+		end := written + ssmStreamChunkSize
+		if end > len(p) {
+			end = len(p)
+		}
+		if err := s.send(ssmPayloadOutput, p[written:end]); err != nil {
+			return written, err
+		}
+		written = end
+	}
+	return written, nil
+}
+
+// CloseWrite tells the agent no more input follows, once the input sent so
+// far has been acknowledged. Output keeps arriving until the agent ends the
+// session.
+func (s *SSMSession) CloseWrite() error {
+	s.mu.Lock()
+	for len(s.unacked) > 0 && !s.closed {
+		s.cond.Wait()
+	}
+	s.mu.Unlock()
+
+	payload := make([]byte, 4)
+	binary.BigEndian.PutUint32(payload, ssmFlagDisconnectToPort)
+	return s.send(ssmPayloadFlag, payload)
+}
+
+// Resize tells the agent the size of the local terminal
+func (s *SSMSession) Resize(cols, rows int) error {
+	payload, err := json.Marshal(map[string]int{"cols": cols, "rows": rows})
+	if err != nil {
+		return err
+	}
+	return s.send(ssmPayloadSize, payload)
+}
+
+// Done is closed when the session ends
+func (s *SSMSession) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the error that ended the session, or nil if it ended normally
+// or is still running
+func (s *SSMSession) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// CloseReason returns the message the agent sent when it closed the channel
+func (s *SSMSession) CloseReason() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closeReason
+}
+
+// Close terminates the session and closes the data channel
+func (s *SSMSession) Close() error {
+	s.finish(nil)
+	return nil
+}
+
+// finish ends the session once, recording err as the reason
+func (s *SSMSession) finish(err error) {
+	s.closeOnce.Do(func() {
+		s.mu.Lock()
+		s.closed = true
+		s.err = err
+		s.cond.Broadcast()
+		s.mu.Unlock()
+
+		if s.conn != nil {
+			s.writeMu.Lock()
+			_ = s.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+			s.writeMu.Unlock()
+			s.conn.Close()
+		}
+		s.terminate()
+		close(s.done)
+	})
+}
+
+// terminate ends the session on the service side
+func (s *SSMSession) terminate() {
+	if s.ID == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, _ = s.client.SSM.TerminateSession(ctx, &ssm.TerminateSessionInput{SessionId: sdkaws.String(s.ID)})
+}
+
+// send queues one input message, waiting for the handshake first
+func (s *SSMSession) send(payloadType uint32, payload []byte) error {
+	s.mu.Lock()
+	for (!s.ready || s.paused) && !s.closed {
+		s.cond.Wait()
+	}
+	if s.closed {
+		s.mu.Unlock()
+		return io.ErrClosedPipe
+	}
+	message := s.newInputMessage(payloadType, payload)
+	s.unacked[message.SequenceNumber] = &pendingSSMMessage{message: message, sentAt: time.Now()}
+	s.mu.Unlock()
+
+	return s.writeMessage(message)
+}
+
+// newInputMessage builds the next input message. Callers hold s.mu.
+func (s *SSMSession) newInputMessage(payloadType uint32, payload []byte) *ssmMessage {
+	message := &ssmMessage{
+		MessageType:    ssmMessageInputStream,
+		SchemaVersion:  1,
+		CreatedDate:    time.Now(),
+		SequenceNumber: s.nextOutSeq,
+		MessageID:      newSSMUUID(),
+		PayloadType:    payloadType,
+		Payload:        append([]byte(nil), payload...),
+	}
+	if message.SequenceNumber == 0 {
+		message.Flags = 1 // SYN marks the first message of the stream
+	}
+	s.nextOutSeq++
+	return message
+}
+
+// writeMessage writes a binary message to the websocket
+func (s *SSMSession) writeMessage(message *ssmMessage) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if err := s.conn.WriteMessage(websocket.BinaryMessage, message.marshal()); err != nil {
+		return fmt.Errorf("failed to send SSM message: %w", err)
+	}
+	return nil
+}
+
+// readLoop handles messages from the agent until the channel closes
+func (s *SSMSession) readLoop() {
+	for {
+		messageType, data, err := s.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				s.finish(nil)
+			} else {
+				s.finish(fmt.Errorf("SSM data channel closed: %w", err))
+			}
+			return
+		}
+		if messageType != websocket.BinaryMessage {
+			continue
+		}
+
+		message, err := unmarshalSSMMessage(data)
+		if err != nil {
+			s.finish(fmt.Errorf("invalid SSM message: %w", err))
+			return
+		}
+
+		if err := s.handleMessage(message); err != nil {
+			s.finish(err)
+			return
+		}
+	}
+}
+
+// handleMessage dispatches one message from the agent
+func (s *SSMSession) handleMessage(message *ssmMessage) error {
+	switch message.MessageType {
+	case ssmMessageOutputStream:
+		// A reader that has fallen behind holds back the agent: nothing is
+		// read or acknowledged until the buffered output drains
+		if !s.waitForRoom() {
+			return nil
+		}
+		if err := s.acknowledge(message); err != nil {
+			return err
+		}
+		return s.receiveOutput(message)
+
+	case ssmMessageAcknowledge:
+		var ack ssmAcknowledge
+		if err := json.Unmarshal(message.Payload, &ack); err != nil {
+			return fmt.Errorf("invalid acknowledge message: %w", err)
+		}
+		s.mu.Lock()
+		delete(s.unacked, ack.AcknowledgedMessageSequenceNumber)
+		// Wake CloseWrite if it is waiting for the input to be acknowledged
+		s.cond.Broadcast()
+		s.mu.Unlock()
+
+	case ssmMessageChannelClosed:
+		var closed ssmChannelClosed
+		_ = json.Unmarshal(message.Payload, &closed)
+		s.mu.Lock()
+		s.closeReason = closed.Output
+		s.mu.Unlock()
+		s.finish(nil)
+
+	case ssmMessagePausePublication, ssmMessageStartPublication:
+		s.mu.Lock()
+		s.paused = message.MessageType == ssmMessagePausePublication
+		s.cond.Broadcast()
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// waitForRoom blocks while the output buffer is full. It reports false if
+// the session ended meanwhile.
+func (s *SSMSession) waitForRoom() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.output.Len() >= ssmMaxBufferedOutput && !s.closed {
+		s.cond.Wait()
+	}
+	return !s.closed
+}
+
+// acknowledge confirms receipt of a stream message
+func (s *SSMSession) acknowledge(message *ssmMessage) error {
+	payload, err := json.Marshal(ssmAcknowledge{
+		AcknowledgedMessageType:           message.MessageType,
+		AcknowledgedMessageID:             message.MessageID.String(),
+		AcknowledgedMessageSequenceNumber: message.SequenceNumber,
+		IsSequentialMessage:               true,
+	})
+	if err != nil {
+		return err
+	}
+	return s.writeMessage(&ssmMessage{
+		MessageType:   ssmMessageAcknowledge,
+		SchemaVersion: 1,
+		CreatedDate:   time.Now(),
+		Flags:         3,
+		MessageID:     newSSMUUID(),
+		Payload:       payload,
+	})
+}
+
+// receiveOutput processes stream messages in sequence order, holding back
+// messages that arrive early and dropping duplicates
+func (s *SSMSession) receiveOutput(message *ssmMessage) error {
+	s.mu.Lock()
+	if message.SequenceNumber < s.nextInSeq {
+		s.mu.Unlock()
+		return nil
+	}
+	if message.SequenceNumber > s.nextInSeq {
+		s.outOfOrder[message.SequenceNumber] = message
+		s.mu.Unlock()
+		return nil
+	}
+
+	var inOrder []*ssmMessage
+	for message != nil {
+		inOrder = append(inOrder, message)
+		s.nextInSeq++
+		message = s.outOfOrder[s.nextInSeq]
+		delete(s.outOfOrder, s.nextInSeq)
+	}
+	s.mu.Unlock()
+
+	for _, m := range inOrder {
+		if err := s.handlePayload(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handlePayload acts on the payload of an in-order stream message
+func (s *SSMSession) handlePayload(message *ssmMessage) error {
+	switch message.PayloadType {
+	case ssmPayloadOutput, ssmPayloadError:
+		s.mu.Lock()
+		// Agents that predate the handshake start sending output directly
+		s.ready = true
+		s.output.Write(message.Payload)
+		s.cond.Broadcast()
+		s.mu.Unlock()
+
+	case ssmPayloadHandshakeRequest:
+		return s.handshake(message.Payload)
+
+	case ssmPayloadHandshakeComplete:
+		s.mu.Lock()
+		s.ready = true
+		s.cond.Broadcast()
+		s.mu.Unlock()
+
+	case ssmPayloadFlag:
+		if len(message.Payload) < 4 {
+			return nil
+		}
+		switch binary.BigEndian.Uint32(message.Payload) {
+		case ssmFlagConnectToPortError:
+			return errors.New("the instance could not connect to the remote port")
+		case ssmFlagTerminateSession:
+			s.finish(nil)
+		}
+	}
+	return nil
+}
+
+// handshake answers the agent's handshake request. Session types are
+// accepted; KMS encryption is reported as unsupported.
+func (s *SSMSession) handshake(payload []byte) error {
+	var request ssmHandshakeRequest
+	if err := json.Unmarshal(payload, &request); err != nil {
+		return fmt.Errorf("invalid handshake request: %w", err)
+	}
+
+	response := ssmHandshakeResponse{ClientVersion: ssmClientVersion, Errors: []string{}}
+	for _, action := range request.RequestedClientActions {
+		processed := ssmProcessedAction{ActionType: action.ActionType, ActionStatus: ssmActionSuccess}
+		if action.ActionType != "SessionType" {
+			processed.ActionStatus = ssmActionUnsupported
+			processed.Error = fmt.Sprintf("%s is not supported by lazyaws", action.ActionType)
+			response.Errors = append(response.Errors, processed.Error)
+		}
+		response.ProcessedClientActions = append(response.ProcessedClientActions, processed)
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	// The response goes out before the session is ready, so it can't use send
+	s.mu.Lock()
+	message := s.newInputMessage(ssmPayloadHandshakeResponse, data)
+	s.unacked[message.SequenceNumber] = &pendingSSMMessage{message: message, sentAt: time.Now()}
+	s.mu.Unlock()
+	return s.writeMessage(message)
+}
+
+// resendLoop resends input messages the agent hasn't acknowledged and keeps
+// the websocket alive
+func (s *SSMSession) resendLoop() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	lastPing := time.Now()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			s.mu.Lock()
+			var resend []*ssmMessage
+			if !s.paused {
+				for _, pending := range s.unacked {
+					if now.Sub(pending.sentAt) >= ssmResendTimeout {
+						pending.sentAt = now
+						resend = append(resend, pending.message)
+					}
+				}
+			}
+			s.mu.Unlock()
+
+			for _, message := range resend {
+				if err := s.writeMessage(message); err != nil {
+					s.finish(err)
+					return
+				}
+			}
+
+			if now.Sub(lastPing) >= ssmPingInterval {
+				lastPing = now
+				s.writeMu.Lock()
+				err := s.conn.WriteControl(websocket.PingMessage, []byte("keepalive"), now.Add(5*time.Second))
+				s.writeMu.Unlock()
+				if err != nil {
+					s.finish(fmt.Errorf("SSM data channel closed: %w", err))
+					return
+				}
+			}
+		}
+	}
+}
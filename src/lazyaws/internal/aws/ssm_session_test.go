@@ -0,0 +1,443 @@
+package aws
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeSSMAgent is a local websocket server that speaks the agent's side of
+// the data channel protocol. It answers the handshake, acknowledges input and
+// echoes output payloads back; "exit\n" closes the channel and a disconnect
+// flag ends the session.
+type fakeSSMAgent struct {
+	t      *testing.T
+	server *httptest.Server
+
+	// reorder sends each echo as two messages, the second half first
+	reorder bool
+
+	mu     sync.Mutex
+	tokens []string
+	sizes  []string
+	acks   int // Output messages the client acknowledged
+	// holdEcho holds the echo back until the client disconnects, like a
+	// server that answers once the whole request is in
+	holdEcho bool
+}
+
+func newFakeSSMAgent(t *testing.T) *fakeSSMAgent {
+	agent := &fakeSSMAgent{t: t}
+	agent.server = httptest.NewServer(http.HandlerFunc(agent.serve))
+	t.Cleanup(agent.server.Close)
+	return agent
+}
+
+// streamURL returns the websocket URL to hand out from StartSession
+func (a *fakeSSMAgent) streamURL() string {
+	return "ws" + strings.TrimPrefix(a.server.URL, "http")
+}
+
+func (a *fakeSSMAgent) serve(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	var open ssmOpenDataChannel
+	if err := conn.ReadJSON(&open); err != nil {
+		return
+	}
+	a.mu.Lock()
+	a.tokens = append(a.tokens, open.TokenValue)
+	a.mu.Unlock()
+
+	var seq int64
+	send := func(messageType string, payloadType uint32, payload []byte, sequence int64) {
+		message := &ssmMessage{
+			MessageType:    messageType,
+			SchemaVersion:  1,
+			CreatedDate:    time.Now(),
+			SequenceNumber: sequence,
+			MessageID:      newSSMUUID(),
+			PayloadType:    payloadType,
+			Payload:        payload,
+		}
+		_ = conn.WriteMessage(websocket.BinaryMessage, message.marshal())
+	}
+	output := func(payloadType uint32, payload []byte) {
+		send(ssmMessageOutputStream, payloadType, payload, seq)
+		seq++
+	}
+
+	output(ssmPayloadHandshakeRequest, []byte(`{"AgentVersion":"3.2.0.0","RequestedClientActions":[{"ActionType":"SessionType","ActionParameters":{"SessionType":"Standard_Stream"}}]}`))
+
+	seen := make(map[int64]bool)
+	var held []byte
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		message, err := unmarshalSSMMessage(data)
+		if err != nil {
+			a.t.Errorf("Agent received invalid message: %v", err)
+			return
+		}
+		if message.MessageType == ssmMessageAcknowledge {
+			a.mu.Lock()
+			a.acks++
+			a.mu.Unlock()
+		}
+		if message.MessageType != ssmMessageInputStream {
+			continue
+		}
+
+		ack, _ := json.Marshal(ssmAcknowledge{
+			AcknowledgedMessageType:           message.MessageType,
+			AcknowledgedMessageID:             message.MessageID.String(),
+			AcknowledgedMessageSequenceNumber: message.SequenceNumber,
+			IsSequentialMessage:               true,
+		})
+		send(ssmMessageAcknowledge, 0, ack, 0)
+		if seen[message.SequenceNumber] {
+			continue
+		}
+		seen[message.SequenceNumber] = true
+
+		switch message.PayloadType {
+		case ssmPayloadHandshakeResponse:
+			output(ssmPayloadHandshakeComplete, []byte(`{"HandshakeTimeToComplete":1000000,"CustomerMessage":""}`))
+		case ssmPayloadSize:
+			a.mu.Lock()
+			a.sizes = append(a.sizes, string(message.Payload))
+			a.mu.Unlock()
+		case ssmPayloadFlag:
+			if binary.BigEndian.Uint32(message.Payload) != ssmFlagDisconnectToPort {
+				continue
+			}
+			if len(held) > 0 {
+				output(ssmPayloadOutput, held)
+			}
+			flag := make([]byte, 4)
+			binary.BigEndian.PutUint32(flag, ssmFlagTerminateSession)
+			output(ssmPayloadFlag, flag)
+		case ssmPayloadOutput:
+			a.mu.Lock()
+			holdEcho := a.holdEcho
+			a.mu.Unlock()
+			if holdEcho {
+				held = append(held, message.Payload...)
+				continue
+			}
+			if string(message.Payload) == "exit\n" {
+				closed, _ := json.Marshal(ssmChannelClosed{Output: "Exiting session"})
+				send(ssmMessageChannelClosed, 0, closed, 0)
+				return
+			}
+			if a.reorder && len(message.Payload) > 1 {
+				half := len(message.Payload) / 2
+				send(ssmMessageOutputStream, ssmPayloadOutput, message.Payload[half:], seq+1)
+				send(ssmMessageOutputStream, ssmPayloadOutput, message.Payload[:half], seq)
+				// A duplicate must be dropped
+				send(ssmMessageOutputStream, ssmPayloadOutput, message.Payload[:half], seq)
+				seq += 2
+				continue
+			}
+			output(ssmPayloadOutput, message.Payload)
+		}
+	}
+}
+
+func newSSMTestClient(t *testing.T) (*Client, *FakeBackend, *fakeSSMAgent) {
+	agent := newFakeSSMAgent(t)
+	backend := NewFakeBackend()
+	backend.SSM.StreamURL = agent.streamURL()
+	return backend.Client("us-east-1"), backend, agent
+}
+
+// readExactly reads n bytes from r or fails after a timeout
+func readExactly(t *testing.T, r io.Reader, n int) string {
+	t.Helper()
+	result := make(chan string, 1)
+	go func() {
+		buf := make([]byte, n)
+		read, _ := io.ReadFull(r, buf)
+		result <- string(buf[:read])
+	}()
+	select {
+	case s := <-result:
+		return s
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out reading %d bytes", n)
+		return ""
+	}
+}
+
+func TestSSMMessageRoundTrip(t *testing.T) {
+	message := &ssmMessage{
+		MessageType:    ssmMessageInputStream,
+		SchemaVersion:  1,
+		CreatedDate:    time.UnixMilli(1700000000123),
+		SequenceNumber: 42,
+		Flags:          1,
+		MessageID:      newSSMUUID(),
+		PayloadType:    ssmPayloadOutput,
+		Payload:        []byte("ls -la\n"),
+	}
+
+	data := message.marshal()
+	decoded, err := unmarshalSSMMessage(data)
+	if err != nil {
+		t.Fatalf("unmarshalSSMMessage returned error: %v", err)
+	}
+	if decoded.MessageType != message.MessageType || decoded.SequenceNumber != 42 || decoded.Flags != 1 {
+		t.Errorf("Header fields not preserved: %+v", decoded)
+	}
+	if decoded.MessageID != message.MessageID {
+		t.Errorf("Expected message ID %s, got %s", message.MessageID, decoded.MessageID)
+	}
+	if !decoded.CreatedDate.Equal(message.CreatedDate) {
+		t.Errorf("Expected created date %v, got %v", message.CreatedDate, decoded.CreatedDate)
+	}
+	if string(decoded.Payload) != "ls -la\n" {
+		t.Errorf("Expected payload 'ls -la\\n', got %q", decoded.Payload)
+	}
+
+	data[len(data)-1] ^= 0xff
+	if _, err := unmarshalSSMMessage(data); err == nil {
+		t.Error("Expected digest mismatch for a corrupted payload")
+	}
+}
+
+func TestSSMSessionEchoesInput(t *testing.T) {
+	client, backend, agent := newSSMTestClient(t)
+
+	session, err := client.StartSSMSession(context.Background(), SSMSessionInput{Target: "i-0001"})
+	if err != nil {
+		t.Fatalf("StartSSMSession returned error: %v", err)
+	}
+
+	if err := session.Resize(120, 40); err != nil {
+		t.Fatalf("Resize returned error: %v", err)
+	}
+	if _, err := session.Write([]byte("hello")); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	if got := readExactly(t, session, 5); got != "hello" {
+		t.Errorf("Expected echo 'hello', got %q", got)
+	}
+
+	// Payloads larger than one message are split and reassembled
+	large := strings.Repeat("x", 3*ssmStreamChunkSize+10)
+	if _, err := session.Write([]byte(large)); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	if got := readExactly(t, session, len(large)); got != large {
+		t.Errorf("Expected %d echoed bytes, got %d", len(large), len(got))
+	}
+
+	session.Close()
+	<-session.Done()
+
+	agent.mu.Lock()
+	defer agent.mu.Unlock()
+	if len(agent.tokens) != 1 || agent.tokens[0] != "token-"+session.ID {
+		t.Errorf("Expected the session token to open the data channel, got %v", agent.tokens)
+	}
+	if len(agent.sizes) != 1 || agent.sizes[0] != `{"cols":120,"rows":40}` {
+		t.Errorf("Expected terminal size to be sent, got %v", agent.sizes)
+	}
+	if len(backend.SSM.Terminated) != 1 || backend.SSM.Terminated[0] != session.ID {
+		t.Errorf("Expected session %s to be terminated, got %v", session.ID, backend.SSM.Terminated)
+	}
+}
+
+func TestSSMSessionReordersOutput(t *testing.T) {
+	client, _, agent := newSSMTestClient(t)
+	agent.reorder = true
+
+	session, err := client.StartSSMSession(context.Background(), SSMSessionInput{Target: "i-0001"})
+	if err != nil {
+		t.Fatalf("StartSSMSession returned error: %v", err)
+	}
+	defer session.Close()
+
+	if _, err := session.Write([]byte("hello world")); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	if got := readExactly(t, session, len("hello world")); got != "hello world" {
+		t.Errorf("Expected output in sequence order, got %q", got)
+	}
+}
+
+func TestSSMSessionHoldsBackSlowReader(t *testing.T) {
+	maxBuffered := ssmMaxBufferedOutput
+	ssmMaxBufferedOutput = 8
+	defer func() { ssmMaxBufferedOutput = maxBuffered }()
+
+	client, _, agent := newSSMTestClient(t)
+	session, err := client.StartSSMSession(context.Background(), SSMSessionInput{Target: "i-0001"})
+	if err != nil {
+		t.Fatalf("StartSSMSession returned error: %v", err)
+	}
+	defer session.Close()
+
+	for i := 0; i < 6; i++ {
+		if _, err := session.Write([]byte("abcd")); err != nil {
+			t.Fatalf("Write returned error: %v", err)
+		}
+	}
+
+	buffered := func() int {
+		session.mu.Lock()
+		defer session.mu.Unlock()
+		return session.output.Len()
+	}
+	acks := func() int {
+		agent.mu.Lock()
+		defer agent.mu.Unlock()
+		return agent.acks
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for buffered() < 8 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for output, %d bytes buffered", buffered())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// The handshake and two echoes fill the buffer; the rest wait unacknowledged
+	if got := buffered(); got != 8 {
+		t.Errorf("Expected the buffer to stop at 8 bytes, got %d", got)
+	}
+	if got := acks(); got != 4 {
+		t.Errorf("Expected 4 acknowledged messages while the buffer is full, got %d", got)
+	}
+
+	if got := readExactly(t, session, 24); got != strings.Repeat("abcd", 6) {
+		t.Errorf("Expected every echo once read, got %q", got)
+	}
+}
+
+func TestSSMSessionChannelClosed(t *testing.T) {
+	client, _, _ := newSSMTestClient(t)
+
+	session, err := client.StartSSMSession(context.Background(), SSMSessionInput{Target: "i-0001"})
+	if err != nil {
+		t.Fatalf("StartSSMSession returned error: %v", err)
+	}
+
+	if _, err := session.Write([]byte("exit\n")); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+
+	select {
+	case <-session.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the session to end")
+	}
+
+	if _, err := session.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("Expected io.EOF after the channel closed, got %v", err)
+	}
+	if session.CloseReason() != "Exiting session" {
+		t.Errorf("Expected close reason 'Exiting session', got %q", session.CloseReason())
+	}
+}
+
+func TestPortForwardThroughSession(t *testing.T) {
+	client, backend, _ := newSSMTestClient(t)
+
+	forward, err := client.StartPortForward(context.Background(), PortForwardOptions{
+		InstanceID: "i-0001",
+		RemoteHost: "db.internal",
+		RemotePort: 5432,
+	})
+	if err != nil {
+		t.Fatalf("StartPortForward returned error: %v", err)
+	}
+	defer forward.Stop()
+
+	if forward.LocalPort() == 0 {
+		t.Fatal("Expected a local port to be picked")
+	}
+
+	conn, err := net.Dial("tcp", forward.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to connect to the forward: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	if got := readExactly(t, conn, 4); got != "ping" {
+		t.Errorf("Expected 'ping' through the forward, got %q", got)
+	}
+
+	backend.SSM.mu.Lock()
+	input := backend.SSM.Sessions[0]
+	backend.SSM.mu.Unlock()
+	if getString(input.DocumentName) != SSMDocumentPortForwardRemoteHost {
+		t.Errorf("Expected document %s, got %s", SSMDocumentPortForwardRemoteHost, getString(input.DocumentName))
+	}
+	if input.Parameters["host"][0] != "db.internal" || input.Parameters["portNumber"][0] != "5432" {
+		t.Errorf("Unexpected session parameters: %v", input.Parameters)
+	}
+
+	forward.Stop()
+	if _, err := net.Dial("tcp", forward.listener.Addr().String()); err == nil {
+		t.Error("Expected the listener to be closed after Stop")
+	}
+}
+
+func TestPortForwardHalfClose(t *testing.T) {
+	client, _, agent := newSSMTestClient(t)
+	agent.mu.Lock()
+	agent.holdEcho = true
+	agent.mu.Unlock()
+
+	forward, err := client.StartPortForward(context.Background(), PortForwardOptions{InstanceID: "i-0001", RemotePort: 80})
+	if err != nil {
+		t.Fatalf("StartPortForward returned error: %v", err)
+	}
+	defer forward.Stop()
+
+	conn, err := net.Dial("tcp", forward.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to connect to the forward: %v", err)
+	}
+	defer conn.Close()
+
+	// The response only comes once the client has closed its side
+	request := strings.Repeat("request ", 500)
+	if _, err := conn.Write([]byte(request)); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	if err := conn.(*net.TCPConn).CloseWrite(); err != nil {
+		t.Fatalf("CloseWrite returned error: %v", err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	response, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatalf("Failed to read the response: %v", err)
+	}
+	if string(response) != request {
+		t.Errorf("Expected the whole request echoed after the half-close, got %d bytes", len(response))
+	}
+}
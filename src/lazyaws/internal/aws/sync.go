@@ -0,0 +1,617 @@
+package aws
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// Sync actions
+const (
+	SyncUpload   = "upload"
+	SyncDownload = "download"
+	SyncDelete   = "delete"
+)
+
+// Reasons a file is copied
+const (
+	SyncReasonNew     = "new"
+	SyncReasonSize    = "size differs"
+	SyncReasonContent = "content differs"
+	// The ETag can't be checked against the file, as for encrypted objects,
+	// so the side modified last wins
+	SyncReasonNewer = "newer"
+)
+
+// Part sizes tried when checking a file against a multipart ETag, which
+// does not record the part size: ours, the AWS CLI's and the SDK
+// uploader's defaults, and a few other common choices
+var syncPartSizes = []int64{transferPartSize, 8 << 20, 5 << 20, 16 << 20, 64 << 20}
+
+// SyncFilter includes or excludes the files whose path relative to the sync
+// root matches Pattern. As in the AWS CLI, "*" also matches "/", and when
+// several filters match a path the last one wins.
+type SyncFilter struct {
+	Pattern string
+	Exclude bool
+}
+
+// SyncOptions control what a sync copies and deletes
+type SyncOptions struct {
+	// Delete removes destination files that are missing from the source
+	Delete  bool
+	Filters []SyncFilter
+}
+
+// Included reports whether the filters let path through. Paths use forward
+// slashes.
+func (o SyncOptions) Included(path string) bool {
+	return o.compile().included(path)
+}
+
+// compiledSyncFilter is a SyncFilter with its pattern compiled
+type compiledSyncFilter struct {
+	pattern *regexp.Regexp
+	exclude bool
+}
+
+// syncFilters are the filters of a sync, compiled once for a whole plan
+type syncFilters []compiledSyncFilter
+
+func (o SyncOptions) compile() syncFilters {
+	filters := make(syncFilters, len(o.Filters))
+	for i, filter := range o.Filters {
+		filters[i] = compiledSyncFilter{pattern: globRegexp(filter.Pattern), exclude: filter.Exclude}
+	}
+	return filters
+}
+
+func (f syncFilters) included(path string) bool {
+	included := true
+	for _, filter := range f {
+		if filter.pattern.MatchString(path) {
+			included = !filter.exclude
+		}
+	}
+	return included
+}
+
+// SyncAction is one file a sync copies or deletes
+type SyncAction struct {
+	Kind      string // SyncUpload, SyncDownload or SyncDelete
+	Path      string // Relative to the sync root, with forward slashes
+	LocalPath string
+	Bucket    string
+	Key       string
+	Size      int64
+	// Reason explains why a file is copied; empty for deletes
+	Reason string
+	// Local is set for deletes of local files rather than objects
+	Local bool
+}
+
+// Object formats the S3 side of the action as a URI
+func (a SyncAction) Object() string {
+	return fmt.Sprintf("s3://%s/%s", a.Bucket, a.Key)
+}
+
+// String describes the action the way the AWS CLI does, e.g.
+// "upload: dir/a.txt to s3://bucket/a.txt"
+func (a SyncAction) String() string {
+	switch {
+	case a.Kind == SyncUpload:
+		return fmt.Sprintf("upload: %s to %s", a.LocalPath, a.Object())
+	case a.Kind == SyncDownload:
+		return fmt.Sprintf("download: %s to %s", a.Object(), a.LocalPath)
+	case a.Local:
+		return fmt.Sprintf("delete: %s", a.LocalPath)
+	default:
+		return fmt.Sprintf("delete: %s", a.Object())
+	}
+}
+
+// SyncPlan lists what a sync will change, so it can be reviewed before it
+// runs
+type SyncPlan struct {
+	Source      string
+	Destination string
+	Actions     []SyncAction
+	Unchanged   int // Files already up to date
+	Excluded    int // Files skipped by the filters
+	// Unsafe lists the keys skipped because they would be written outside
+	// the destination directory, e.g. "a/../../.bashrc"
+	Unsafe []string
+}
+
+// Summary counts the actions of each kind and the bytes to copy
+func (p *SyncPlan) Summary() (copies, deletes int, bytes int64) {
+	for _, action := range p.Actions {
+		if action.Kind == SyncDelete {
+			deletes++
+		} else {
+			copies++
+			bytes += action.Size
+		}
+	}
+	return copies, deletes, bytes
+}
+
+// syncObject is an object under the remote sync root
+type syncObject struct {
+	Key          string
+	Size         int64
+	ETag         string
+	LastModified time.Time
+}
+
+// PlanSyncLocalToS3 compares a local directory with an S3 prefix and plans
+// the uploads, and with opts.Delete the deletes, that make the prefix match.
+// Files are compared by size and then by ETag.
+func (c *Client) PlanSyncLocalToS3(ctx context.Context, localDir, bucketName, s3Prefix string, opts SyncOptions) (*SyncPlan, error) {
+	root := syncRoot(s3Prefix)
+	remote, err := c.listSyncObjects(ctx, bucketName, root)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &SyncPlan{Source: localDir, Destination: fmt.Sprintf("s3://%s/%s", bucketName, root)}
+	filters := opts.compile()
+	seen := make(map[string]bool)
+	err = filepath.Walk(localDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(localDir, path)
+		if err != nil {
+			return fmt.Errorf("failed to get relative path: %w", err)
+		}
+		rel := filepath.ToSlash(relPath)
+		seen[rel] = true
+		if !filters.included(rel) {
+			plan.Excluded++
+			return nil
+		}
+
+		reason := SyncReasonNew
+		if obj, ok := remote[rel]; ok {
+			reason, err = c.compareSyncFile(ctx, bucketName, path, info, obj, true)
+			if err != nil {
+				return err
+			}
+		}
+		if reason == "" {
+			plan.Unchanged++
+			return nil
+		}
+		plan.Actions = append(plan.Actions, SyncAction{
+			Kind:      SyncUpload,
+			Path:      rel,
+			LocalPath: path,
+			Bucket:    bucketName,
+			Key:       root + rel,
+			Size:      info.Size(),
+			Reason:    reason,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Delete {
+		for rel, obj := range remote {
+			if seen[rel] || !filters.included(rel) {
+				continue
+			}
+			plan.Actions = append(plan.Actions, SyncAction{
+				Kind:   SyncDelete,
+				Path:   rel,
+				Bucket: bucketName,
+				Key:    obj.Key,
+				Size:   obj.Size,
+			})
+		}
+	}
+
+	sortSyncActions(plan.Actions)
+	return plan, nil
+}
+
+// PlanSyncS3ToLocal compares an S3 prefix with a local directory and plans
+// the downloads, and with opts.Delete the deletes, that make the directory
+// match. Files are compared by size and then by ETag.
+func (c *Client) PlanSyncS3ToLocal(ctx context.Context, bucketName, s3Prefix, localDir string, opts SyncOptions) (*SyncPlan, error) {
+	root := syncRoot(s3Prefix)
+	remote, err := c.listSyncObjects(ctx, bucketName, root)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &SyncPlan{Source: fmt.Sprintf("s3://%s/%s", bucketName, root), Destination: localDir}
+	filters := opts.compile()
+	for rel, obj := range remote {
+		if !filters.included(rel) {
+			plan.Excluded++
+			continue
+		}
+
+		localPath, ok := localSyncPath(localDir, rel)
+		if !ok {
+			plan.Unsafe = append(plan.Unsafe, obj.Key)
+			continue
+		}
+		reason := SyncReasonNew
+		info, err := os.Stat(localPath)
+		if err == nil && !info.IsDir() {
+			reason, err = c.compareSyncFile(ctx, bucketName, localPath, info, obj, false)
+			if err != nil {
+				return nil, err
+			}
+		} else if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to stat %s: %w", localPath, err)
+		}
+		if reason == "" {
+			plan.Unchanged++
+			continue
+		}
+		plan.Actions = append(plan.Actions, SyncAction{
+			Kind:      SyncDownload,
+			Path:      rel,
+			LocalPath: localPath,
+			Bucket:    bucketName,
+			Key:       obj.Key,
+			Size:      obj.Size,
+			Reason:    reason,
+		})
+	}
+
+	if opts.Delete {
+		err := filepath.Walk(localDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				// Nothing to delete in a directory that does not exist yet
+				if path == localDir && os.IsNotExist(err) {
+					return filepath.SkipDir
+				}
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			relPath, err := filepath.Rel(localDir, path)
+			if err != nil {
+				return fmt.Errorf("failed to get relative path: %w", err)
+			}
+			rel := filepath.ToSlash(relPath)
+			if _, ok := remote[rel]; ok || !filters.included(rel) {
+				return nil
+			}
+			plan.Actions = append(plan.Actions, SyncAction{
+				Kind:      SyncDelete,
+				Path:      rel,
+				LocalPath: path,
+				Size:      info.Size(),
+				Local:     true,
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sortSyncActions(plan.Actions)
+	sort.Strings(plan.Unsafe)
+	return plan, nil
+}
+
+// localSyncPath returns where the file at rel, relative to the sync root,
+// goes under localDir. It reports false for keys that would land outside
+// localDir, or that only differ from a safe key by a leading "/".
+func localSyncPath(localDir, rel string) (string, bool) {
+	if rel == "" || strings.HasPrefix(rel, "/") {
+		return "", false
+	}
+	for _, segment := range strings.Split(rel, "/") {
+		if segment == ".." {
+			return "", false
+		}
+	}
+	root := filepath.Clean(localDir)
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	within, err := filepath.Rel(root, path)
+	if err != nil || within == "." || within == ".." || strings.HasPrefix(within, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return path, true
+}
+
+// ExecuteSyncPlan queues the copies of a plan on transfers and runs its
+// deletes. It returns once the deletes are done; callers wait on transfers
+// for the copies.
+func (c *Client) ExecuteSyncPlan(ctx context.Context, plan *SyncPlan, transfers *TransferManager) error {
+	var deletes []SyncAction
+	for _, action := range plan.Actions {
+		switch action.Kind {
+		case SyncUpload:
+			transfers.Enqueue(c, TransferRequest{Kind: TransferUpload, Bucket: action.Bucket, Key: action.Key, LocalPath: action.LocalPath, Size: action.Size})
+		case SyncDownload:
+			transfers.Enqueue(c, TransferRequest{Kind: TransferDownload, Bucket: action.Bucket, Key: action.Key, LocalPath: action.LocalPath, Size: action.Size})
+		case SyncDelete:
+			deletes = append(deletes, action)
+		}
+	}
+
+	// Objects are deleted in batches, local files one by one
+	var failed []error
+	var objects []S3Object
+	bucketName := ""
+	for _, action := range deletes {
+		if !action.Local {
+			objects = append(objects, S3Object{Key: action.Key, Size: action.Size})
+			bucketName = action.Bucket
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := os.Remove(action.LocalPath); err != nil {
+			failed = append(failed, fmt.Errorf("%s: %w", action.Path, err))
+		}
+	}
+	if len(objects) > 0 {
+		result := c.DeleteObjects(ctx, bucketName, objects, nil)
+		for _, failure := range result.Failures {
+			failed = append(failed, fmt.Errorf("%s: %w", failure.Key, failure.Err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to delete %d of %d files: %w", len(failed), len(deletes), errors.Join(failed...))
+	}
+	return nil
+}
+
+// SyncLocalToS3 syncs a local directory to an S3 bucket (like aws s3 sync).
+// Files are uploaded a few at a time; progressCallback receives the
+// aggregate progress of every upload.
+func (c *Client) SyncLocalToS3(ctx context.Context, localDir, bucketName, s3Prefix string, opts SyncOptions, progressCallback ProgressCallback) (*SyncPlan, error) {
+	plan, err := c.PlanSyncLocalToS3(ctx, localDir, bucketName, s3Prefix, opts)
+	if err != nil {
+		return nil, err
+	}
+	return plan, c.runSyncPlan(ctx, plan, progressCallback)
+}
+
+// SyncS3ToLocal syncs an S3 bucket prefix to a local directory. Objects are
+// downloaded a few at a time; progressCallback receives the aggregate
+// progress of every download.
+func (c *Client) SyncS3ToLocal(ctx context.Context, bucketName, s3Prefix, localDir string, opts SyncOptions, progressCallback ProgressCallback) (*SyncPlan, error) {
+	plan, err := c.PlanSyncS3ToLocal(ctx, bucketName, s3Prefix, localDir, opts)
+	if err != nil {
+		return nil, err
+	}
+	return plan, c.runSyncPlan(ctx, plan, progressCallback)
+}
+
+// runSyncPlan executes a plan and waits for its copies
+func (c *Client) runSyncPlan(ctx context.Context, plan *SyncPlan, progressCallback ProgressCallback) error {
+	transfers := NewTransferManager(ctx, DefaultTransferConcurrency, progressCallback)
+	err := c.ExecuteSyncPlan(ctx, plan, transfers)
+
+	// Let the copies finish even if a delete failed
+	if waitErr := transfers.Wait(); err == nil {
+		err = waitErr
+	}
+	return err
+}
+
+// syncRoot turns a sync prefix into the key prefix of the files under it,
+// so that "backup" does not also match "backup2/..."
+func syncRoot(prefix string) string {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix == "" {
+		return ""
+	}
+	return prefix + "/"
+}
+
+// listSyncObjects lists every object under root, keyed by its path relative
+// to root. Folder placeholder objects are skipped.
+func (c *Client) listSyncObjects(ctx context.Context, bucketName, root string) (map[string]syncObject, error) {
+	input := &s3.ListObjectsV2Input{
+		Bucket: &bucketName,
+		Prefix: &root,
+	}
+
+	objects := make(map[string]syncObject)
+	for {
+		output, err := c.S3.ListObjectsV2(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", err)
+		}
+		for _, obj := range output.Contents {
+			key := getString(obj.Key)
+			if strings.HasSuffix(key, "/") {
+				continue
+			}
+			object := syncObject{
+				Key:  key,
+				Size: getInt64(obj.Size),
+				ETag: getString(obj.ETag),
+			}
+			if obj.LastModified != nil {
+				object.LastModified = *obj.LastModified
+			}
+			objects[strings.TrimPrefix(key, root)] = object
+		}
+		if !getBool(output.IsTruncated) {
+			break
+		}
+		input.ContinuationToken = output.NextContinuationToken
+	}
+	return objects, nil
+}
+
+// compareSyncFile returns why a local file needs copying to or from obj, or
+// "" if they hold the same data. When the ETag can't be checked against the
+// file, the copy is made if the source side was modified last; upload tells
+// which side that is.
+func (c *Client) compareSyncFile(ctx context.Context, bucketName, path string, info os.FileInfo, obj syncObject, upload bool) (string, error) {
+	if info.Size() != obj.Size {
+		return SyncReasonSize, nil
+	}
+	same, known, err := matchesETag(path, info.Size(), obj.ETag)
+	if err != nil {
+		return "", err
+	}
+	if same {
+		return "", nil
+	}
+	if known {
+		// A plain ETag is an MD5 of the content unless the object is
+		// encrypted with KMS or a customer key
+		opaque, err := c.hasOpaqueETag(ctx, bucketName, obj.Key)
+		if err != nil {
+			return "", err
+		}
+		if !opaque {
+			return SyncReasonContent, nil
+		}
+	}
+
+	if upload && info.ModTime().After(obj.LastModified) || !upload && obj.LastModified.After(info.ModTime()) {
+		return SyncReasonNewer, nil
+	}
+	return "", nil
+}
+
+// hasOpaqueETag reports whether an object's ETag is not an MD5 of its
+// content, as for SSE-KMS and SSE-C objects
+func (c *Client) hasOpaqueETag(ctx context.Context, bucketName, key string) (bool, error) {
+	head, err := c.S3.HeadObject(ctx, &s3.HeadObjectInput{Bucket: &bucketName, Key: &key})
+	if err != nil {
+		// SSE-C objects can't be read, or even headed, without their key
+		if isErrorCode(err, "BadRequest") {
+			return true, nil
+		}
+		return false, fmt.Errorf("failed to get %s: %w", key, err)
+	}
+	switch head.ServerSideEncryption {
+	case types.ServerSideEncryptionAwsKms, types.ServerSideEncryptionAwsKmsDsse:
+		return true, nil
+	}
+	return head.SSECustomerAlgorithm != nil, nil
+}
+
+// matchesETag reports whether a file has the content an ETag describes, and
+// whether a mismatch is known to mean different content. A single-part ETag
+// is the MD5 of the object; a multipart ETag is the MD5 of the part MD5s
+// followed by "-" and the part count, so each likely part size that gives
+// that count is tried. When none matches, the part size may just be one
+// not tried, so the mismatch is unknown.
+func matchesETag(path string, size int64, etag string) (same, known bool, err error) {
+	etag = strings.Trim(etag, `"`)
+	file, err := os.Open(path)
+	if err != nil {
+		return false, false, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	base, count, multipart := strings.Cut(etag, "-")
+	if !multipart {
+		sum, err := partMD5(file, 0, size)
+		if err != nil {
+			return false, false, err
+		}
+		return sum == etag, true, nil
+	}
+
+	parts, err := strconv.ParseInt(count, 10, 64)
+	if err != nil || parts < 1 {
+		return false, false, nil
+	}
+	// Uploads that split the file evenly use the smallest whole MiB that
+	// fits it in the part count
+	even := (size + parts - 1) / parts
+	even = (even + 1<<20 - 1) &^ (1<<20 - 1)
+
+	tried := make(map[int64]bool)
+	for _, partSize := range append([]int64{even}, syncPartSizes...) {
+		if partSize <= 0 || tried[partSize] || (size+partSize-1)/partSize != parts {
+			continue
+		}
+		tried[partSize] = true
+		sum, err := multipartMD5(file, size, partSize)
+		if err != nil {
+			return false, false, err
+		}
+		if sum == base {
+			return true, true, nil
+		}
+	}
+	return false, false, nil
+}
+
+// multipartMD5 returns the hex MD5 of the part MD5s of a file split into
+// partSize parts
+func multipartMD5(file *os.File, size, partSize int64) (string, error) {
+	sums := md5.New()
+	for offset := int64(0); offset < size; offset += partSize {
+		length := partSize
+		if offset+length > size {
+			length = size - offset
+		}
+		part := md5.New()
+		if _, err := io.Copy(part, io.NewSectionReader(file, offset, length)); err != nil {
+			return "", fmt.Errorf("failed to read part: %w", err)
+		}
+		sums.Write(part.Sum(nil))
+	}
+	return hex.EncodeToString(sums.Sum(nil)), nil
+}
+
+// globRegexp compiles a glob where "*" matches any run of characters,
+// including "/", and "?" matches one character
+func globRegexp(pattern string) *regexp.Regexp {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+	// Every other character is quoted, so the expression always compiles
+	return regexp.MustCompile(expr.String())
+}
+
+// sortSyncActions orders copies before deletes, each by path
+func sortSyncActions(actions []SyncAction) {
+	sort.Slice(actions, func(i, j int) bool {
+		if (actions[i].Kind == SyncDelete) != (actions[j].Kind == SyncDelete) {
+			return actions[j].Kind == SyncDelete
+		}
+		return actions[i].Path < actions[j].Path
+	})
+}
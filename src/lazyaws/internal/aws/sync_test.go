@@ -0,0 +1,285 @@
+package aws
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func writeSyncFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func planKinds(plan *SyncPlan) map[string]string {
+	kinds := make(map[string]string)
+	for _, action := range plan.Actions {
+		kinds[action.Path] = action.Kind + " " + action.Reason
+	}
+	return kinds
+}
+
+func TestPlanSyncLocalToS3ComparesContent(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.S3.AddBucket("bucket", "us-east-1")
+	backend.S3.AddObject("bucket", "backup/same.txt", []byte("same"))
+	backend.S3.AddObject("bucket", "backup/edited.txt", []byte("old!"))
+	backend.S3.AddObject("bucket", "backup/grown.txt", []byte("short"))
+	backend.S3.AddObject("bucket", "backup/gone.txt", []byte("gone"))
+	backend.S3.AddObject("bucket", "backup/skip.log", []byte("log"))
+	backend.S3.AddObject("bucket", "backup2/other.txt", []byte("not under the prefix"))
+	client := backend.Client("us-east-1")
+
+	dir := t.TempDir()
+	writeSyncFiles(t, dir, map[string]string{
+		"same.txt":    "same",
+		"edited.txt":  "new!",
+		"grown.txt":   "much longer",
+		"sub/new.txt": "new",
+		"keep.log":    "excluded",
+	})
+
+	opts := SyncOptions{Delete: true, Filters: []SyncFilter{{Pattern: "*.log", Exclude: true}}}
+	plan, err := client.PlanSyncLocalToS3(context.Background(), dir, "bucket", "backup", opts)
+	if err != nil {
+		t.Fatalf("PlanSyncLocalToS3 returned error: %v", err)
+	}
+
+	want := map[string]string{
+		"edited.txt":  "upload content differs",
+		"grown.txt":   "upload size differs",
+		"sub/new.txt": "upload new",
+		"gone.txt":    "delete ",
+	}
+	got := planKinds(plan)
+	if len(got) != len(want) {
+		t.Fatalf("Expected actions %v, got %v", want, got)
+	}
+	for path, kind := range want {
+		if got[path] != kind {
+			t.Errorf("Expected %s to be %q, got %q", path, kind, got[path])
+		}
+	}
+	if plan.Unchanged != 1 || plan.Excluded != 1 {
+		t.Errorf("Expected 1 unchanged and 1 excluded file, got %d and %d", plan.Unchanged, plan.Excluded)
+	}
+	if last := plan.Actions[len(plan.Actions)-1]; last.Kind != SyncDelete || last.Key != "backup/gone.txt" {
+		t.Errorf("Expected the delete of backup/gone.txt last, got %+v", last)
+	}
+
+	// Nothing is changed until the plan is executed
+	if _, ok := backend.S3.Buckets["bucket"].Objects["backup/gone.txt"]; !ok {
+		t.Fatal("Expected planning not to delete anything")
+	}
+	transfers := NewTransferManager(context.Background(), 2, nil)
+	if err := client.ExecuteSyncPlan(context.Background(), plan, transfers); err != nil {
+		t.Fatalf("ExecuteSyncPlan returned error: %v", err)
+	}
+	if err := transfers.Wait(); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+	objects := backend.S3.Buckets["bucket"].Objects
+	if _, ok := objects["backup/gone.txt"]; ok {
+		t.Error("Expected backup/gone.txt to be deleted")
+	}
+	if _, ok := objects["backup/skip.log"]; !ok {
+		t.Error("Expected the excluded backup/skip.log to be kept")
+	}
+	if string(objects["backup/edited.txt"].Data) != "new!" {
+		t.Errorf("Expected backup/edited.txt to be uploaded, got %q", objects["backup/edited.txt"].Data)
+	}
+
+	// A second plan finds nothing left to do
+	plan, err = client.PlanSyncLocalToS3(context.Background(), dir, "bucket", "backup", opts)
+	if err != nil {
+		t.Fatalf("PlanSyncLocalToS3 returned error: %v", err)
+	}
+	if len(plan.Actions) != 0 {
+		t.Errorf("Expected no actions after syncing, got %v", planKinds(plan))
+	}
+}
+
+func TestPlanSyncS3ToLocal(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.S3.AddBucket("bucket", "us-east-1")
+	backend.S3.AddObject("bucket", "data/a.txt", []byte("alpha"))
+	backend.S3.AddObject("bucket", "data/nested/b.txt", []byte("bravo"))
+	backend.S3.AddObject("bucket", "data/nested/c.tmp", []byte("temp"))
+	client := backend.Client("us-east-1")
+
+	dir := t.TempDir()
+	writeSyncFiles(t, dir, map[string]string{
+		"a.txt":     "alpha",
+		"stale.txt": "stale",
+		"keep.tmp":  "kept because it is filtered out",
+	})
+
+	opts := SyncOptions{Delete: true, Filters: []SyncFilter{{Pattern: "*.tmp", Exclude: true}}}
+	plan, err := client.SyncS3ToLocal(context.Background(), "bucket", "data/", dir, opts, nil)
+	if err != nil {
+		t.Fatalf("SyncS3ToLocal returned error: %v", err)
+	}
+
+	got := planKinds(plan)
+	if got["nested/b.txt"] != "download new" || got["stale.txt"] != "delete " || len(got) != 2 {
+		t.Errorf("Expected a download of nested/b.txt and a delete of stale.txt, got %v", got)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "nested", "b.txt")); err != nil || string(data) != "bravo" {
+		t.Errorf("Expected nested/b.txt to be downloaded, got %q (%v)", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "stale.txt")); !os.IsNotExist(err) {
+		t.Error("Expected stale.txt to be deleted")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "keep.tmp")); err != nil {
+		t.Error("Expected the excluded keep.tmp to be kept")
+	}
+}
+
+func TestMatchesMultipartETag(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "big.bin")
+	data := []byte(strings.Repeat("0123456789abcdef", (12<<20)/16))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The ETag of the file uploaded in 8 MiB parts, as the AWS CLI does
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum, err := multipartMD5(file, int64(len(data)), 8<<20)
+	file.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	same, _, err := matchesETag(path, int64(len(data)), `"`+sum+`-2"`)
+	if err != nil || !same {
+		t.Errorf("Expected the file to match its multipart ETag, got %v (%v)", same, err)
+	}
+	same, known, err := matchesETag(path, int64(len(data)), `"`+sum+`-3"`)
+	if err != nil || same || known {
+		t.Errorf("Expected a different part count to be an unknown mismatch, got %v, %v (%v)", same, known, err)
+	}
+}
+
+func TestSyncFallsBackToModTime(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.S3.AddBucket("bucket", "us-east-1")
+	for _, key := range []string{"kms/old.txt", "kms/new.txt", "multi/old.txt", "plain/changed.txt"} {
+		backend.S3.AddObject("bucket", key, []byte("remote"))
+	}
+	objects := backend.S3.Buckets["bucket"].Objects
+	hour := time.Now().Add(-time.Hour)
+	// KMS ETags aren't MD5s of the content
+	for _, key := range []string{"kms/old.txt", "kms/new.txt"} {
+		objects[key].ServerSideEncryption = s3types.ServerSideEncryptionAwsKms
+		objects[key].ETag = `"0123456789abcdef0123456789abcdef"`
+	}
+	objects["kms/old.txt"].LastModified = hour.Add(-time.Hour)
+	objects["kms/new.txt"].LastModified = time.Now().Add(time.Hour)
+	// Nothing we try splits the file into 7 parts
+	objects["multi/old.txt"].ETag = `"0123456789abcdef0123456789abcdef-7"`
+	objects["multi/old.txt"].LastModified = hour.Add(-time.Hour)
+	client := backend.Client("us-east-1")
+
+	dir := t.TempDir()
+	writeSyncFiles(t, dir, map[string]string{
+		"kms/old.txt":       "local!",
+		"kms/new.txt":       "local!",
+		"multi/old.txt":     "local!",
+		"plain/changed.txt": "local!",
+	})
+	for _, name := range []string{"kms/old.txt", "kms/new.txt", "multi/old.txt", "plain/changed.txt"} {
+		if err := os.Chtimes(filepath.Join(dir, filepath.FromSlash(name)), hour, hour); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	plan, err := client.PlanSyncLocalToS3(context.Background(), dir, "bucket", "", SyncOptions{})
+	if err != nil {
+		t.Fatalf("PlanSyncLocalToS3 returned error: %v", err)
+	}
+	want := map[string]string{
+		"kms/old.txt":       "upload newer",
+		"multi/old.txt":     "upload newer",
+		"plain/changed.txt": "upload content differs",
+	}
+	got := planKinds(plan)
+	if len(got) != len(want) {
+		t.Fatalf("Expected actions %v, got %v", want, got)
+	}
+	for path, kind := range want {
+		if got[path] != kind {
+			t.Errorf("Expected %s to be %q, got %q", path, kind, got[path])
+		}
+	}
+
+	// Downloading, only the object modified after the local file is copied
+	plan, err = client.PlanSyncS3ToLocal(context.Background(), "bucket", "kms", filepath.Join(dir, "kms"), SyncOptions{})
+	if err != nil {
+		t.Fatalf("PlanSyncS3ToLocal returned error: %v", err)
+	}
+	if got := planKinds(plan); len(got) != 1 || got["new.txt"] != "download newer" {
+		t.Errorf("Expected only new.txt to download, got %v", got)
+	}
+}
+
+func TestPlanSyncS3ToLocalSkipsUnsafeKeys(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.S3.AddBucket("bucket", "us-east-1")
+	backend.S3.AddObject("bucket", "data/ok.txt", []byte("ok"))
+	backend.S3.AddObject("bucket", "data/a/../../escape.txt", []byte("escape"))
+	backend.S3.AddObject("bucket", "data//abs.txt", []byte("abs"))
+	client := backend.Client("us-east-1")
+
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "target")
+	plan, err := client.SyncS3ToLocal(context.Background(), "bucket", "data", dir, SyncOptions{}, nil)
+	if err != nil {
+		t.Fatalf("SyncS3ToLocal returned error: %v", err)
+	}
+
+	if got := planKinds(plan); len(got) != 1 || got["ok.txt"] != "download new" {
+		t.Errorf("Expected only ok.txt to download, got %v", got)
+	}
+	if len(plan.Unsafe) != 2 || plan.Unsafe[0] != "data//abs.txt" || plan.Unsafe[1] != "data/a/../../escape.txt" {
+		t.Errorf("Expected both unsafe keys to be reported, got %v", plan.Unsafe)
+	}
+	if _, err := os.Stat(filepath.Join(parent, "escape.txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing to be written outside the target, got %v", err)
+	}
+}
+
+func TestSyncFilters(t *testing.T) {
+	opts := SyncOptions{Filters: []SyncFilter{
+		{Pattern: "*", Exclude: true},
+		{Pattern: "logs/*.txt"},
+		{Pattern: "logs/debug-?.txt", Exclude: true},
+	}}
+	tests := map[string]bool{
+		"a.txt":             false,
+		"logs/app.txt":      true,
+		"logs/nested/x.txt": true,
+		"logs/debug-1.txt":  false,
+		"logs/debug-10.txt": true,
+	}
+	for path, want := range tests {
+		if got := opts.Included(path); got != want {
+			t.Errorf("Included(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
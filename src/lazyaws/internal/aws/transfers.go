@@ -0,0 +1,925 @@
+package aws
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// DefaultTransferConcurrency is the number of transfers run at once when no
+// concurrency is configured
+const DefaultTransferConcurrency = 4
+
+const (
+	transferPartSize        = 10 * 1024 * 1024 // Files larger than this are uploaded in parts
+	transferPartConcurrency = 4                // Parts of one upload sent at once
+	maxUploadParts          = 10000            // The most parts S3 takes in one upload
+	// Throughput is measured over the progress of the last few seconds
+	transferRateWindow   = 5 * time.Second
+	transferSamplePeriod = 200 * time.Millisecond
+)
+
+// Transfer directions
+const (
+	TransferUpload   = "upload"
+	TransferDownload = "download"
+)
+
+// Transfer states
+const (
+	TransferQueued    = "queued"
+	TransferRunning   = "running"
+	TransferPaused    = "paused"
+	TransferDone      = "done"
+	TransferFailed    = "failed"
+	TransferCancelled = "cancelled"
+	TransferRestoring = "restoring" // Waiting for an archived object to be restored
+)
+
+// restorePollInterval is how often a download waiting for a restore checks
+// on it. It is a variable so tests can shorten it.
+var restorePollInterval = 5 * time.Minute
+
+// errRestoreInProgress stops a download whose object is still being
+// restored from an archive
+var errRestoreInProgress = errors.New("object is being restored from an archive")
+
+// TransferRequest describes one file to copy between S3 and the local disk
+type TransferRequest struct {
+	Kind      string // TransferUpload or TransferDownload
+	Bucket    string
+	Key       string
+	LocalPath string
+	// Size is the expected size, if known, so queued transfers count
+	// towards the totals before they start
+	Size int64
+}
+
+// Object formats the S3 side of the transfer as a URI
+func (r TransferRequest) Object() string {
+	return "s3://" + r.Bucket + "/" + r.Key
+}
+
+// TransferInfo is a snapshot of one queued transfer
+type TransferInfo struct {
+	ID      int
+	Request TransferRequest
+	State   string
+	Bytes   int64
+	Total   int64
+	// Throughput is in bytes per second over the last few seconds
+	Throughput float64
+	// ETA is zero when unknown
+	ETA time.Duration
+	Err error
+	// Resumed is the number of bytes reused from an interrupted upload
+	Resumed int64
+}
+
+// Finished reports whether the transfer will not run again unless retried
+func (i TransferInfo) Finished() bool {
+	switch i.State {
+	case TransferDone, TransferFailed, TransferCancelled:
+		return true
+	}
+	return false
+}
+
+// TransferTotals aggregates the transfers of a manager
+type TransferTotals struct {
+	Bytes      int64
+	Total      int64
+	Throughput float64
+	ETA        time.Duration
+	Queued     int
+	Running    int
+	Paused     int
+	Restoring  int
+	Done       int
+	Failed     int
+}
+
+// Active reports whether any transfer is queued or running
+func (t TransferTotals) Active() bool {
+	return t.Queued > 0 || t.Running > 0
+}
+
+// transferSample is the progress of a transfer at a point in time
+type transferSample struct {
+	at    time.Time
+	bytes int64
+}
+
+// managedTransfer is a transfer and the client that runs it
+type managedTransfer struct {
+	id      int
+	request TransferRequest
+	client  *Client
+	state   string
+	bytes   int64
+	total   int64
+	resumed int64
+	err     error
+	cancel  context.CancelFunc
+	samples []transferSample
+	// uploadID is the multipart upload to continue on resume, sent in parts
+	// of partSize
+	uploadID string
+	partSize int64
+	// restoreWait counts the waits for a restore, so a superseded wait
+	// stops
+	restoreWait int
+}
+
+// TransferManager runs uploads and downloads in the background, a few at a
+// time, so they don't block the screen that queued them
+type TransferManager struct {
+	ctx      context.Context
+	progress ProgressCallback
+	// partSize is a field so tests can use small parts
+	partSize int64
+	// journal, if set, keeps the uploads started so a later run can
+	// continue them
+	journal *UploadJournal
+
+	mu          sync.Mutex
+	changed     *sync.Cond // Broadcast when a transfer stops running
+	concurrency int
+	running     int
+	nextID      int
+	transfers   []*managedTransfer
+}
+
+// NewTransferManager creates a manager running up to concurrency transfers
+// at once. progress, if set, is called with the aggregate bytes transferred
+// and total of every transfer.
+func NewTransferManager(ctx context.Context, concurrency int, progress ProgressCallback) *TransferManager {
+	if concurrency < 1 {
+		concurrency = DefaultTransferConcurrency
+	}
+	m := &TransferManager{
+		ctx:         ctx,
+		progress:    progress,
+		partSize:    transferPartSize,
+		concurrency: concurrency,
+		nextID:      1,
+	}
+	m.changed = sync.NewCond(&m.mu)
+	return m
+}
+
+// SetUploadJournal makes the manager record the multipart uploads it
+// starts in journal, and continue the ones an earlier run recorded there
+func (m *TransferManager) SetUploadJournal(journal *UploadJournal) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.journal = journal
+}
+
+// Enqueue queues a transfer run with client and returns its ID
+func (m *TransferManager) Enqueue(client *Client, req TransferRequest) int {
+	if req.Kind == TransferUpload && req.Size == 0 {
+		if info, err := os.Stat(req.LocalPath); err == nil {
+			req.Size = info.Size()
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := &managedTransfer{
+		id:      m.nextID,
+		request: req,
+		client:  client,
+		state:   TransferQueued,
+		total:   req.Size,
+	}
+	m.nextID++
+	m.transfers = append(m.transfers, entry)
+	m.schedule()
+	return entry.id
+}
+
+// Pause stops a queued or running transfer so it can be resumed later.
+// Interrupted multipart uploads keep the parts already sent.
+func (m *TransferManager) Pause(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, err := m.get(id)
+	if err != nil {
+		return err
+	}
+	switch entry.state {
+	case TransferQueued, TransferRestoring:
+		entry.state = TransferPaused
+		m.changed.Broadcast()
+	case TransferRunning:
+		entry.state = TransferPaused
+		entry.cancel()
+	default:
+		return fmt.Errorf("transfer %d is %s", id, entry.state)
+	}
+	return nil
+}
+
+// Resume queues a paused transfer again
+func (m *TransferManager) Resume(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, err := m.get(id)
+	if err != nil {
+		return err
+	}
+	if entry.state != TransferPaused {
+		return fmt.Errorf("transfer %d is %s", id, entry.state)
+	}
+	entry.state = TransferQueued
+	m.schedule()
+	return nil
+}
+
+// Cancel stops a transfer for good, aborting its multipart upload. A
+// download never leaves a partly written file behind.
+func (m *TransferManager) Cancel(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, err := m.get(id)
+	if err != nil {
+		return err
+	}
+	switch entry.state {
+	case TransferQueued, TransferPaused, TransferRestoring:
+		entry.state = TransferCancelled
+		go m.cleanup(entry.client, entry.request, entry.uploadID)
+		entry.uploadID = ""
+		m.changed.Broadcast()
+	case TransferRunning:
+		// run cleans up once the transfer has stopped
+		entry.state = TransferCancelled
+		entry.cancel()
+	default:
+		return fmt.Errorf("transfer %d is %s", id, entry.state)
+	}
+	return nil
+}
+
+// Retry queues a failed or cancelled transfer again. A failed upload
+// continues from the parts it already sent.
+func (m *TransferManager) Retry(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, err := m.get(id)
+	if err != nil {
+		return err
+	}
+	if entry.state != TransferFailed && entry.state != TransferCancelled {
+		return fmt.Errorf("transfer %d is %s", id, entry.state)
+	}
+	entry.state = TransferQueued
+	entry.err = nil
+	m.schedule()
+	return nil
+}
+
+// Remove deletes a finished transfer from the queue
+func (m *TransferManager) Remove(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, err := m.get(id)
+	if err != nil {
+		return err
+	}
+	if !entry.info().Finished() {
+		return fmt.Errorf("transfer %d is still %s", id, entry.state)
+	}
+	for i, e := range m.transfers {
+		if e == entry {
+			m.transfers = append(m.transfers[:i], m.transfers[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// ClearDone removes every completed transfer and returns how many there were
+func (m *TransferManager) ClearDone() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kept := m.transfers[:0]
+	for _, entry := range m.transfers {
+		if entry.state != TransferDone {
+			kept = append(kept, entry)
+		}
+	}
+	removed := len(m.transfers) - len(kept)
+	m.transfers = kept
+	return removed
+}
+
+// SetConcurrency changes how many transfers run at once. Running transfers
+// beyond the new limit are left to finish.
+func (m *TransferManager) SetConcurrency(n int) {
+	if n < 1 {
+		n = 1
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.concurrency = n
+	m.schedule()
+}
+
+// Concurrency returns how many transfers run at once
+func (m *TransferManager) Concurrency() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.concurrency
+}
+
+// List returns every transfer in the order they were queued
+func (m *TransferManager) List() []TransferInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	infos := make([]TransferInfo, 0, len(m.transfers))
+	for _, entry := range m.transfers {
+		infos = append(infos, entry.info())
+	}
+	return infos
+}
+
+// Totals aggregates the progress of every transfer that hasn't been
+// cancelled
+func (m *TransferManager) Totals() TransferTotals {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.totals()
+}
+
+// Wait blocks until no transfer is queued or running and returns an error
+// describing the transfers that failed
+func (m *TransferManager) Wait() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for m.totals().Active() {
+		m.changed.Wait()
+	}
+
+	var failed []*managedTransfer
+	for _, entry := range m.transfers {
+		if entry.state == TransferFailed {
+			failed = append(failed, entry)
+		}
+	}
+	if len(failed) == 1 && len(m.transfers) == 1 {
+		return failed[0].err
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to %s %d of %d files: %s: %w", failed[0].request.Kind, len(failed), len(m.transfers), failed[0].request.Object(), failed[0].err)
+	}
+	if restoring := m.totals().Restoring; restoring > 0 {
+		return fmt.Errorf("%d of %d files are still being restored from an archive, download them once the restore finishes", restoring, len(m.transfers))
+	}
+	return nil
+}
+
+// schedule starts queued transfers while there is room; callers must hold
+// m.mu
+func (m *TransferManager) schedule() {
+	for _, entry := range m.transfers {
+		if m.running >= m.concurrency {
+			return
+		}
+		if entry.state != TransferQueued {
+			continue
+		}
+
+		ctx, cancel := context.WithCancel(m.ctx)
+		entry.state = TransferRunning
+		entry.cancel = cancel
+		entry.bytes = 0
+		entry.resumed = 0
+		entry.samples = nil
+		m.running++
+		go m.run(ctx, entry)
+	}
+}
+
+// run performs a transfer and records how it ended
+func (m *TransferManager) run(ctx context.Context, entry *managedTransfer) {
+	var err error
+	if entry.request.Kind == TransferUpload {
+		err = m.upload(ctx, entry)
+	} else {
+		err = m.download(ctx, entry)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry.cancel()
+	entry.cancel = nil
+	m.running--
+	switch {
+	case err == nil:
+		// Finished before a pause or cancel could stop it
+		entry.state = TransferDone
+		entry.bytes = entry.total
+	case entry.state == TransferPaused:
+		// Keep the upload ID to resume from
+	case entry.state == TransferCancelled:
+		go m.cleanup(entry.client, entry.request, entry.uploadID)
+		entry.uploadID = ""
+	case errors.Is(err, errRestoreInProgress):
+		// Start again by itself once the restore finishes
+		entry.state = TransferRestoring
+		entry.restoreWait++
+		go m.waitForRestore(entry, entry.restoreWait)
+	default:
+		entry.state = TransferFailed
+		entry.err = err
+	}
+	entry.samples = nil
+	m.schedule()
+	m.changed.Broadcast()
+}
+
+// waitForRestore queues a download again once the archived object it is
+// for has been restored, checking every restorePollInterval
+func (m *TransferManager) waitForRestore(entry *managedTransfer, wait int) {
+	ticker := time.NewTicker(restorePollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		m.mu.Lock()
+		waiting := entry.state == TransferRestoring && entry.restoreWait == wait
+		m.mu.Unlock()
+		if !waiting {
+			return
+		}
+
+		// Errors are retried on the next check
+		status, err := entry.client.GetRestoreStatus(m.ctx, entry.request.Bucket, entry.request.Key)
+		if err != nil || !status.Readable() {
+			continue
+		}
+
+		m.mu.Lock()
+		if entry.state == TransferRestoring && entry.restoreWait == wait {
+			entry.state = TransferQueued
+			m.schedule()
+		}
+		m.mu.Unlock()
+		return
+	}
+}
+
+// cleanup aborts the multipart upload a cancelled transfer left behind.
+// Downloads remove their own temporary file.
+func (m *TransferManager) cleanup(client *Client, req TransferRequest, uploadID string) {
+	if uploadID != "" {
+		client.S3.AbortMultipartUpload(m.ctx, &s3.AbortMultipartUploadInput{
+			Bucket:   &req.Bucket,
+			Key:      &req.Key,
+			UploadId: &uploadID,
+		})
+		m.journal.forget(req, uploadID)
+	}
+}
+
+// setTotal records the size of a transfer once it is known
+func (m *TransferManager) setTotal(entry *managedTransfer, total int64) {
+	m.mu.Lock()
+	entry.total = total
+	m.mu.Unlock()
+}
+
+// addProgress records n more bytes transferred and reports the new totals
+func (m *TransferManager) addProgress(entry *managedTransfer, n int64) {
+	m.mu.Lock()
+	entry.bytes += n
+	now := time.Now()
+	if len(entry.samples) == 0 || now.Sub(entry.samples[len(entry.samples)-1].at) >= transferSamplePeriod {
+		entry.samples = append(entry.samples, transferSample{at: now, bytes: entry.bytes})
+		for len(entry.samples) > 1 && now.Sub(entry.samples[0].at) > transferRateWindow {
+			entry.samples = entry.samples[1:]
+		}
+	}
+	totals := m.totals()
+	m.mu.Unlock()
+
+	if m.progress != nil {
+		m.progress(totals.Bytes, totals.Total)
+	}
+}
+
+// totals aggregates the transfers; callers must hold m.mu
+func (m *TransferManager) totals() TransferTotals {
+	var totals TransferTotals
+	for _, entry := range m.transfers {
+		info := entry.info()
+		switch info.State {
+		case TransferCancelled:
+			continue
+		case TransferQueued:
+			totals.Queued++
+		case TransferRunning:
+			totals.Running++
+		case TransferPaused:
+			totals.Paused++
+		case TransferRestoring:
+			totals.Restoring++
+		case TransferDone:
+			totals.Done++
+		case TransferFailed:
+			totals.Failed++
+		}
+		totals.Bytes += info.Bytes
+		totals.Total += info.Total
+		totals.Throughput += info.Throughput
+	}
+	if totals.Throughput > 0 && totals.Total > totals.Bytes {
+		totals.ETA = time.Duration(float64(totals.Total-totals.Bytes) / totals.Throughput * float64(time.Second))
+	}
+	return totals
+}
+
+func (m *TransferManager) get(id int) (*managedTransfer, error) {
+	for _, entry := range m.transfers {
+		if entry.id == id {
+			return entry, nil
+		}
+	}
+	return nil, fmt.Errorf("no transfer with ID %d", id)
+}
+
+// info snapshots the entry; callers must hold the manager's lock
+func (e *managedTransfer) info() TransferInfo {
+	info := TransferInfo{
+		ID:      e.id,
+		Request: e.request,
+		State:   e.state,
+		Bytes:   e.bytes,
+		Total:   e.total,
+		Err:     e.err,
+		Resumed: e.resumed,
+	}
+	if e.state == TransferRunning && len(e.samples) > 0 {
+		first := e.samples[0]
+		if elapsed := time.Since(first.at).Seconds(); elapsed > 0 {
+			info.Throughput = float64(e.bytes-first.bytes) / elapsed
+		}
+		if info.Throughput > 0 && e.total > e.bytes {
+			info.ETA = time.Duration(float64(e.total-e.bytes) / info.Throughput * float64(time.Second))
+		}
+	}
+	return info
+}
+
+// download writes an object to the local path, creating its directory
+func (m *TransferManager) download(ctx context.Context, entry *managedTransfer) error {
+	req := entry.request
+	head, err := entry.client.S3.HeadObject(ctx, &s3.HeadObjectInput{Bucket: &req.Bucket, Key: &req.Key})
+	if err != nil {
+		return fmt.Errorf("failed to get object metadata: %w", err)
+	}
+	m.setTotal(entry, getInt64(head.ContentLength))
+	if status := restoreStatusFromHead(head); !status.Readable() {
+		if status.State == RestoreInProgress {
+			return errRestoreInProgress
+		}
+		return fmt.Errorf("object is archived in %s, restore it before downloading", head.StorageClass)
+	}
+
+	if dir := filepath.Dir(req.LocalPath); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+	}
+
+	// Download next to the local file and replace it only once complete, so
+	// a failed, paused or cancelled download leaves the old file as it was
+	file, err := os.CreateTemp(filepath.Dir(req.LocalPath), "."+filepath.Base(req.LocalPath)+".lazyaws-*")
+	if err != nil {
+		return fmt.Errorf("failed to create local file: %w", err)
+	}
+	tempPath := file.Name()
+	done := false
+	defer func() {
+		file.Close()
+		if !done {
+			os.Remove(tempPath)
+		}
+	}()
+
+	downloader := manager.NewDownloader(entry.client.S3)
+	writer := &transferWriterAt{writer: file, add: func(n int64) { m.addProgress(entry, n) }}
+	_, err = downloader.Download(ctx, writer, &s3.GetObjectInput{Bucket: &req.Bucket, Key: &req.Key})
+	if err != nil {
+		return fmt.Errorf("failed to download object: %w", err)
+	}
+
+	// Keep the permissions of the file being replaced
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(req.LocalPath); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := file.Chmod(mode); err != nil {
+		return fmt.Errorf("failed to set file mode: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write local file: %w", err)
+	}
+	if err := os.Rename(tempPath, req.LocalPath); err != nil {
+		return fmt.Errorf("failed to replace local file: %w", err)
+	}
+	done = true
+	return nil
+}
+
+// upload sends a local file, in parts when it is larger than the part size
+func (m *TransferManager) upload(ctx context.Context, entry *managedTransfer) error {
+	req := entry.request
+	file, err := os.Open(req.LocalPath)
+	if err != nil {
+		return fmt.Errorf("failed to open local file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to get file info: %w", err)
+	}
+	size := info.Size()
+	m.setTotal(entry, size)
+
+	if size <= m.partSize {
+		body := &transferReader{
+			SectionReader: io.NewSectionReader(file, 0, size),
+			add:           func(n int64) { m.addProgress(entry, n) },
+		}
+		_, err := entry.client.S3.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:        &req.Bucket,
+			Key:           &req.Key,
+			Body:          body,
+			ContentLength: &size,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to upload object: %w", err)
+		}
+		return nil
+	}
+	return m.uploadParts(ctx, entry, file, size)
+}
+
+// uploadParts sends a file as a multipart upload. It continues the upload
+// the transfer was interrupted in, or one an earlier run recorded in the
+// journal for the same file, skipping the parts S3 already has.
+func (m *TransferManager) uploadParts(ctx context.Context, entry *managedTransfer, file *os.File, size int64) error {
+	req := entry.request
+	client := entry.client
+
+	m.mu.Lock()
+	uploadID, partSize := entry.uploadID, entry.partSize
+	journal := m.journal
+	m.mu.Unlock()
+
+	if uploadID == "" {
+		if upload, ok := journal.lookup(req); ok {
+			uploadID, partSize = upload.UploadID, upload.PartSize
+		}
+	}
+	uploaded := make(map[int32]types.Part)
+	if uploadID != "" {
+		parts, err := client.listUploadedParts(ctx, req.Bucket, req.Key, uploadID)
+		var noSuchUpload *types.NoSuchUpload
+		switch {
+		case errors.As(err, &noSuchUpload):
+			// Completed or aborted elsewhere; start a new upload
+			journal.forget(req, uploadID)
+			uploadID = ""
+		case err != nil:
+			return err
+		case partSize < 1 || (size+partSize-1)/partSize > maxUploadParts:
+			// The file grew past what the upload's parts can hold
+			m.cleanup(client, req, uploadID)
+			uploadID = ""
+		default:
+			uploaded = parts
+		}
+	}
+	if uploadID == "" {
+		output, err := client.S3.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{Bucket: &req.Bucket, Key: &req.Key})
+		if err != nil {
+			return fmt.Errorf("failed to start multipart upload: %w", err)
+		}
+		uploadID = getString(output.UploadId)
+		partSize = m.uploadPartSize(size)
+		if err := journal.record(req, uploadID, partSize); err != nil {
+			m.cleanup(client, req, uploadID)
+			return err
+		}
+	}
+	m.mu.Lock()
+	entry.uploadID = uploadID
+	entry.partSize = partSize
+	m.mu.Unlock()
+
+	partCount := int32((size + partSize - 1) / partSize)
+	completed := make([]types.CompletedPart, partCount)
+	var pending []int32
+	for n := int32(1); n <= partCount; n++ {
+		offset := int64(n-1) * partSize
+		length := min(partSize, size-offset)
+		if part, ok := uploaded[n]; ok && getInt64(part.Size) == length {
+			// Reuse the part if S3 has the same bytes
+			sum, err := partMD5(file, offset, length)
+			if err != nil {
+				return err
+			}
+			if strings.Trim(getString(part.ETag), "\"") == sum {
+				completed[n-1] = types.CompletedPart{ETag: part.ETag, PartNumber: &n}
+				m.mu.Lock()
+				entry.resumed += length
+				m.mu.Unlock()
+				m.addProgress(entry, length)
+				continue
+			}
+		}
+		pending = append(pending, n)
+	}
+
+	// Send the missing parts a few at a time, stopping at the first error
+	partCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+	var errMu sync.Mutex
+	var firstErr error
+	sem := make(chan struct{}, transferPartConcurrency)
+	for _, n := range pending {
+		sem <- struct{}{}
+		if partCtx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(n int32) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			offset := int64(n-1) * partSize
+			length := min(partSize, size-offset)
+			body := &transferReader{
+				SectionReader: io.NewSectionReader(file, offset, length),
+				add:           func(delta int64) { m.addProgress(entry, delta) },
+			}
+			output, err := client.S3.UploadPart(partCtx, &s3.UploadPartInput{
+				Bucket:        &req.Bucket,
+				Key:           &req.Key,
+				UploadId:      &uploadID,
+				PartNumber:    &n,
+				Body:          body,
+				ContentLength: &length,
+			})
+			if err != nil {
+				errMu.Lock()
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to upload part %d: %w", n, err)
+					cancel()
+				}
+				errMu.Unlock()
+				return
+			}
+			completed[n-1] = types.CompletedPart{ETag: output.ETag, PartNumber: &n}
+		}(n)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	_, err := client.S3.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          &req.Bucket,
+		Key:             &req.Key,
+		UploadId:        &uploadID,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: completed},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+
+	m.mu.Lock()
+	entry.uploadID = ""
+	entry.partSize = 0
+	m.mu.Unlock()
+	journal.forget(req, uploadID)
+	return nil
+}
+
+// uploadPartSize returns the part size for a new upload of size bytes:
+// the manager's part size, or larger parts rounded up to a MiB when the
+// file would need more than maxUploadParts
+func (m *TransferManager) uploadPartSize(size int64) int64 {
+	const mib = 1 << 20
+	partSize := m.partSize
+	if needed := (size + maxUploadParts - 1) / maxUploadParts; needed > partSize {
+		partSize = (needed + mib - 1) / mib * mib
+	}
+	return partSize
+}
+
+// listUploadedParts returns the parts S3 has received for an upload, by
+// part number
+func (c *Client) listUploadedParts(ctx context.Context, bucket, key, uploadID string) (map[int32]types.Part, error) {
+	input := &s3.ListPartsInput{Bucket: &bucket, Key: &key, UploadId: &uploadID}
+
+	parts := make(map[int32]types.Part)
+	for {
+		output, err := c.S3.ListParts(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list uploaded parts: %w", err)
+		}
+		for _, part := range output.Parts {
+			if part.PartNumber != nil {
+				parts[*part.PartNumber] = part
+			}
+		}
+		if !getBool(output.IsTruncated) {
+			break
+		}
+		input.PartNumberMarker = output.NextPartNumberMarker
+	}
+	return parts, nil
+}
+
+// partMD5 returns the hex MD5 of a section of a file, which is the ETag S3
+// gives a part uploaded without encryption
+func partMD5(file *os.File, offset, length int64) (string, error) {
+	hash := md5.New()
+	if _, err := io.Copy(hash, io.NewSectionReader(file, offset, length)); err != nil {
+		return "", fmt.Errorf("failed to read part: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// transferReader counts the bytes read from a section of a file. Seeking
+// back, as the SDK does to retry a request, takes the bytes back off.
+type transferReader struct {
+	*io.SectionReader
+	add  func(int64)
+	read int64
+}
+
+func (r *transferReader) Read(p []byte) (int, error) {
+	n, err := r.SectionReader.Read(p)
+	r.read += int64(n)
+	r.add(int64(n))
+	return n, err
+}
+
+func (r *transferReader) Seek(offset int64, whence int) (int64, error) {
+	pos, err := r.SectionReader.Seek(offset, whence)
+	if err == nil && pos < r.read {
+		r.add(pos - r.read)
+		r.read = pos
+	}
+	return pos, err
+}
+
+// transferWriterAt counts the bytes written by the concurrent parts of a
+// download
+type transferWriterAt struct {
+	writer io.WriterAt
+	add    func(int64)
+}
+
+func (w *transferWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	n, err := w.writer.WriteAt(p, off)
+	w.add(int64(n))
+	return n, err
+}
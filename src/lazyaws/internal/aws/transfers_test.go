@@ -0,0 +1,340 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestTransferManagerRunsQueue(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.S3.AddBucket("test-bucket", "us-east-1")
+	client := backend.Client("us-east-1")
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"a.txt": "alpha",
+		"b.txt": "bravo bravo",
+		"c.txt": "charlie charlie charlie",
+	}
+	var total int64
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		total += int64(len(content))
+	}
+
+	var mu sync.Mutex
+	var lastBytes, lastTotal int64
+	transfers := NewTransferManager(context.Background(), 2, func(bytes, total int64) {
+		mu.Lock()
+		defer mu.Unlock()
+		lastBytes, lastTotal = bytes, total
+	})
+	for name := range files {
+		transfers.Enqueue(client, TransferRequest{Kind: TransferUpload, Bucket: "test-bucket", Key: "up/" + name, LocalPath: filepath.Join(dir, name)})
+	}
+	if err := transfers.Wait(); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+
+	if lastBytes != total || lastTotal != total {
+		t.Errorf("Expected aggregate progress %d/%d, got %d/%d", total, total, lastBytes, lastTotal)
+	}
+	totals := transfers.Totals()
+	if totals.Done != 3 || totals.Active() {
+		t.Errorf("Expected 3 finished transfers, got %+v", totals)
+	}
+
+	for name := range files {
+		transfers.Enqueue(client, TransferRequest{Kind: TransferDownload, Bucket: "test-bucket", Key: "up/" + name, LocalPath: filepath.Join(dir, "down", name)})
+	}
+	if err := transfers.Wait(); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+	for name, content := range files {
+		data, err := os.ReadFile(filepath.Join(dir, "down", name))
+		if err != nil {
+			t.Fatalf("Failed to read downloaded %s: %v", name, err)
+		}
+		if string(data) != content {
+			t.Errorf("Expected %s to contain %q, got %q", name, content, data)
+		}
+	}
+
+	if removed := transfers.ClearDone(); removed != 6 || len(transfers.List()) != 0 {
+		t.Errorf("Expected ClearDone to remove all 6 transfers, removed %d", removed)
+	}
+}
+
+func TestTransferResumesInterruptedUpload(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.S3.AddBucket("test-bucket", "us-east-1")
+	client := backend.Client("us-east-1")
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "big.bin")
+	if err := os.WriteFile(path, []byte("0123456789"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	// An earlier run sent the first part intact and the second part with
+	// bytes that have since changed, and recorded the upload in the journal
+	bucket, key := "test-bucket", "big.bin"
+	created, err := backend.S3.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{Bucket: &bucket, Key: &key})
+	if err != nil {
+		t.Fatalf("CreateMultipartUpload returned error: %v", err)
+	}
+	journalPath := filepath.Join(t.TempDir(), "uploads.json")
+	journal, _ := LoadUploadJournal(journalPath)
+	req := TransferRequest{Kind: TransferUpload, Bucket: bucket, Key: key, LocalPath: path}
+	if err := journal.record(req, *created.UploadId, 4); err != nil {
+		t.Fatalf("record returned error: %v", err)
+	}
+	for n, data := range map[int32]string{1: "0123", 2: "xxxx"} {
+		part := n
+		if _, err := backend.S3.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket: &bucket, Key: &key, UploadId: created.UploadId, PartNumber: &part, Body: strings.NewReader(data),
+		}); err != nil {
+			t.Fatalf("UploadPart returned error: %v", err)
+		}
+	}
+	sentBefore := backend.S3.PartUploads
+
+	// The next run reads the journal from disk
+	journal, err = LoadUploadJournal(journalPath)
+	if err != nil {
+		t.Fatalf("LoadUploadJournal returned error: %v", err)
+	}
+	transfers := NewTransferManager(ctx, 1, nil)
+	transfers.partSize = 4
+	transfers.SetUploadJournal(journal)
+	id := transfers.Enqueue(client, req)
+	if err := transfers.Wait(); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+
+	if sent := backend.S3.PartUploads - sentBefore; sent != 2 {
+		t.Errorf("Expected only parts 2 and 3 to be sent, got %d part uploads", sent)
+	}
+	info := transfers.List()[0]
+	if info.ID != id || info.Resumed != 4 || info.Bytes != 10 {
+		t.Errorf("Expected 4 of 10 bytes to be resumed, got %+v", info)
+	}
+	if data := string(backend.S3.Buckets[bucket].Objects[key].Data); data != "0123456789" {
+		t.Errorf("Expected the completed object to be 0123456789, got %q", data)
+	}
+	if _, ok := journal.lookup(req); ok {
+		t.Error("Expected the completed upload to leave the journal")
+	}
+}
+
+func TestTransferLeavesOtherUploadsAlone(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.S3.AddBucket("test-bucket", "us-east-1")
+	client := backend.Client("us-east-1")
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "big.bin")
+	if err := os.WriteFile(path, []byte("0123456789"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	// Someone else is uploading the same key
+	bucket, key := "test-bucket", "big.bin"
+	other, err := backend.S3.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{Bucket: &bucket, Key: &key})
+	if err != nil {
+		t.Fatalf("CreateMultipartUpload returned error: %v", err)
+	}
+
+	journal, _ := LoadUploadJournal(filepath.Join(t.TempDir(), "uploads.json"))
+	transfers := NewTransferManager(ctx, 1, nil)
+	transfers.partSize = 4
+	transfers.SetUploadJournal(journal)
+	transfers.Enqueue(client, TransferRequest{Kind: TransferUpload, Bucket: bucket, Key: key, LocalPath: path})
+	if err := transfers.Wait(); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+
+	if info := transfers.List()[0]; info.Resumed != 0 {
+		t.Errorf("Expected a new upload, got %d bytes resumed", info.Resumed)
+	}
+	if _, err := backend.S3.ListParts(ctx, &s3.ListPartsInput{Bucket: &bucket, Key: &key, UploadId: other.UploadId}); err != nil {
+		t.Errorf("Expected the other upload to be left alone, got %v", err)
+	}
+}
+
+func TestUploadPartSize(t *testing.T) {
+	transfers := NewTransferManager(context.Background(), 1, nil)
+	if size := transfers.uploadPartSize(1 << 30); size != transferPartSize {
+		t.Errorf("Expected %d byte parts for 1 GiB, got %d", transferPartSize, size)
+	}
+
+	// 200 GiB needs parts of at least 20.48 MiB to fit in 10,000 parts
+	size := int64(200) << 30
+	partSize := transfers.uploadPartSize(size)
+	if partSize != 21<<20 || (size+partSize-1)/partSize > maxUploadParts {
+		t.Errorf("Expected 21 MiB parts for 200 GiB, got %d", partSize)
+	}
+}
+
+func TestTransferPauseCancelRetry(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.S3.AddBucket("test-bucket", "us-east-1")
+	backend.S3.AddObject("test-bucket", "file.txt", []byte("data"))
+	client := backend.Client("us-east-1")
+	dir := t.TempDir()
+
+	transfers := NewTransferManager(context.Background(), 1, nil)
+
+	// Hold the only slot so new transfers stay queued
+	transfers.mu.Lock()
+	transfers.running = 1
+	transfers.mu.Unlock()
+
+	id := transfers.Enqueue(client, TransferRequest{Kind: TransferDownload, Bucket: "test-bucket", Key: "file.txt", LocalPath: filepath.Join(dir, "file.txt")})
+	state := func() string { return transfers.List()[0].State }
+	if state() != TransferQueued {
+		t.Fatalf("Expected the transfer to be queued, got %s", state())
+	}
+	if err := transfers.Pause(id); err != nil || state() != TransferPaused {
+		t.Errorf("Expected Pause to pause the transfer, got %s (%v)", state(), err)
+	}
+	if err := transfers.Resume(id); err != nil || state() != TransferQueued {
+		t.Errorf("Expected Resume to queue the transfer, got %s (%v)", state(), err)
+	}
+	if err := transfers.Cancel(id); err != nil || state() != TransferCancelled {
+		t.Errorf("Expected Cancel to cancel the transfer, got %s (%v)", state(), err)
+	}
+	if err := transfers.Resume(id); err == nil {
+		t.Error("Expected Resume of a cancelled transfer to fail")
+	}
+
+	// Free the slot and fail the retried transfer
+	backend.S3.Err = errors.New("boom")
+	transfers.mu.Lock()
+	transfers.running = 0
+	transfers.mu.Unlock()
+	if err := transfers.Retry(id); err != nil {
+		t.Fatalf("Retry returned error: %v", err)
+	}
+	if err := transfers.Wait(); err == nil || state() != TransferFailed {
+		t.Fatalf("Expected the transfer to fail, got %s (%v)", state(), err)
+	}
+
+	backend.S3.Err = nil
+	if err := transfers.Retry(id); err != nil {
+		t.Fatalf("Retry returned error: %v", err)
+	}
+	if err := transfers.Wait(); err != nil || state() != TransferDone {
+		t.Fatalf("Expected the retried transfer to finish, got %s (%v)", state(), err)
+	}
+	if err := transfers.Remove(id); err != nil || len(transfers.List()) != 0 {
+		t.Errorf("Expected Remove to drop the finished transfer, got %v", err)
+	}
+}
+
+// failingGetObject is an S3API whose downloads fail
+type failingGetObject struct {
+	S3API
+}
+
+func (f failingGetObject) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	return nil, errors.New("connection reset")
+}
+
+func TestTransferFailedDownloadKeepsLocalFile(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.S3.AddBucket("test-bucket", "us-east-1")
+	backend.S3.AddObject("test-bucket", "file.txt", []byte("new data"))
+	client := backend.Client("us-east-1")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file.txt")
+	if err := os.WriteFile(path, []byte("old data"), 0600); err != nil {
+		t.Fatalf("Failed to write the local file: %v", err)
+	}
+
+	transfers := NewTransferManager(context.Background(), 1, nil)
+	req := TransferRequest{Kind: TransferDownload, Bucket: "test-bucket", Key: "file.txt", LocalPath: path}
+	client.S3 = failingGetObject{client.S3}
+	transfers.Enqueue(client, req)
+	if err := transfers.Wait(); err == nil {
+		t.Fatal("Expected the download to fail")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "old data" {
+		t.Errorf("Expected the local file to be left alone, got %q", data)
+	}
+
+	transfers = NewTransferManager(context.Background(), 1, nil)
+	transfers.Enqueue(backend.Client("us-east-1"), req)
+	if err := transfers.Wait(); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new data" {
+		t.Errorf("Expected the download to replace the file, got %q", data)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the file to keep mode 0600, got %v", info.Mode().Perm())
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Expected no temporary files left, got %d entries", len(entries))
+	}
+}
+
+func TestTransferWaitsForRestore(t *testing.T) {
+	interval := restorePollInterval
+	restorePollInterval = 10 * time.Millisecond
+	defer func() { restorePollInterval = interval }()
+
+	backend := NewFakeBackend()
+	backend.S3.AddBucket("test-bucket", "us-east-1")
+	backend.S3.AddObject("test-bucket", "cold.txt", []byte("frozen"))
+	backend.S3.AddObject("test-bucket", "colder.txt", []byte("frozen"))
+	bucket := backend.S3.Buckets["test-bucket"]
+	bucket.Objects["cold.txt"].StorageClass = s3types.StorageClassGlacier
+	bucket.Objects["colder.txt"].StorageClass = s3types.StorageClassDeepArchive
+	client := backend.Client("us-east-1")
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	if result := client.RestoreObjects(ctx, "test-bucket", []S3Object{{Key: "cold.txt", StorageClass: "GLACIER"}}, DefaultRestoreTier, DefaultRestoreDays, nil); result.Err() != nil {
+		t.Fatalf("RestoreObjects returned error: %v", result.Err())
+	}
+
+	transfers := NewTransferManager(ctx, 2, nil)
+	transfers.Enqueue(client, TransferRequest{Kind: TransferDownload, Bucket: "test-bucket", Key: "cold.txt", LocalPath: filepath.Join(dir, "cold.txt")})
+	transfers.Enqueue(client, TransferRequest{Kind: TransferDownload, Bucket: "test-bucket", Key: "colder.txt", LocalPath: filepath.Join(dir, "colder.txt")})
+	if err := transfers.Wait(); err == nil || !strings.Contains(err.Error(), "restore it before downloading") {
+		t.Fatalf("Expected the unrestored object to fail, got %v", err)
+	}
+	list := transfers.List()
+	if list[0].State != TransferRestoring || list[1].State != TransferFailed {
+		t.Fatalf("Expected restoring and failed transfers, got %s and %s", list[0].State, list[1].State)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "cold.txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected no local file while the restore runs, got %v", err)
+	}
+
+	if err := backend.S3.CompleteRestore("test-bucket", "cold.txt"); err != nil {
+		t.Fatalf("CompleteRestore returned error: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for transfers.List()[0].State != TransferDone {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the download to start once restored, still %s", transfers.List()[0].State)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "cold.txt")); err != nil || string(data) != "frozen" {
+		t.Errorf("Expected the restored object to download, got %q (%v)", data, err)
+	}
+}
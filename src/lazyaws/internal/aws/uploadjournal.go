@@ -0,0 +1,110 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// UploadJournal records the multipart uploads a TransferManager starts, so
+// a later run can continue them. Uploads started by anyone else are never
+// resumed, completed or aborted.
+type UploadJournal struct {
+	path string
+
+	mu      sync.Mutex
+	uploads map[string]journaledUpload // By S3 URI
+}
+
+// journaledUpload is an unfinished multipart upload started by lazyaws
+type journaledUpload struct {
+	UploadID  string `json:"upload_id"`
+	PartSize  int64  `json:"part_size"`
+	LocalPath string `json:"local_path"`
+}
+
+// GetUploadJournalPath returns the path of the multipart upload journal
+func GetUploadJournalPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	configDir := filepath.Join(home, ".lazyaws")
+	if err := os.MkdirAll(configDir, 0700); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(configDir, "uploads.json"), nil
+}
+
+// LoadUploadJournal reads the journal at path. A missing file has no
+// uploads.
+func LoadUploadJournal(path string) (*UploadJournal, error) {
+	j := &UploadJournal{path: path, uploads: make(map[string]journaledUpload)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return j, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &j.uploads); err != nil {
+		return nil, fmt.Errorf("invalid upload journal %s: %w", path, err)
+	}
+	return j, nil
+}
+
+// lookup returns the upload recorded for the same object and local file.
+// A nil journal has no uploads.
+func (j *UploadJournal) lookup(req TransferRequest) (journaledUpload, bool) {
+	if j == nil {
+		return journaledUpload{}, false
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	upload, ok := j.uploads[req.Object()]
+	if !ok || upload.LocalPath != req.LocalPath {
+		return journaledUpload{}, false
+	}
+	return upload, true
+}
+
+// record saves an upload just started for req
+func (j *UploadJournal) record(req TransferRequest, uploadID string, partSize int64) error {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.uploads[req.Object()] = journaledUpload{UploadID: uploadID, PartSize: partSize, LocalPath: req.LocalPath}
+	return j.save()
+}
+
+// forget drops an upload once it is completed or aborted
+func (j *UploadJournal) forget(req TransferRequest, uploadID string) error {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if upload, ok := j.uploads[req.Object()]; !ok || upload.UploadID != uploadID {
+		return nil
+	}
+	delete(j.uploads, req.Object())
+	return j.save()
+}
+
+// save writes the journal to disk; callers must hold j.mu
+func (j *UploadJournal) save() error {
+	data, err := json.MarshalIndent(j.uploads, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(j.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to save upload journal: %w", err)
+	}
+	return nil
+}
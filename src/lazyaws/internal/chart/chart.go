@@ -0,0 +1,169 @@
+// Package chart renders numeric series as terminal sparklines and braille
+// line charts
+package chart
+
+import (
+	"math"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// sparkBlocks are the sparkline levels, lowest first
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// Series is one line drawn on a braille chart
+type Series struct {
+	Values []float64 // NaN values are gaps
+	Style  lipgloss.Style
+}
+
+// Bounds returns the smallest and largest non-NaN value across series. An
+// empty or flat range is widened so values still map onto the chart.
+func Bounds(series ...[]float64) (lo, hi float64) {
+	lo, hi = math.Inf(1), math.Inf(-1)
+	for _, values := range series {
+		for _, v := range values {
+			if math.IsNaN(v) {
+				continue
+			}
+			lo = math.Min(lo, v)
+			hi = math.Max(hi, v)
+		}
+	}
+	if math.IsInf(lo, 1) {
+		return 0, 1
+	}
+	if lo == hi {
+		if hi == 0 {
+			return 0, 1
+		}
+		return math.Min(0, lo), math.Max(0, hi)
+	}
+	return lo, hi
+}
+
+// Sparkline renders values as width block characters between lo and hi.
+// Gaps are drawn as spaces.
+func Sparkline(values []float64, width int, lo, hi float64) string {
+	var b strings.Builder
+	for _, v := range Resample(values, width) {
+		if math.IsNaN(v) {
+			b.WriteRune(' ')
+			continue
+		}
+		level := int(math.Round(scale(v, lo, hi) * float64(len(sparkBlocks)-1)))
+		b.WriteRune(sparkBlocks[level])
+	}
+	return b.String()
+}
+
+// Braille renders series as a line chart of width by height cells. Every
+// cell holds 2x4 dots; a cell takes the style of the last series drawn
+// through it, so later series are drawn on top.
+func Braille(series []Series, width, height int, lo, hi float64) []string {
+	if width < 1 || height < 1 {
+		return nil
+	}
+	dotsX, dotsY := width*2, height*4
+
+	cells := make([][]rune, height)
+	owners := make([][]int, height)
+	for row := range cells {
+		cells[row] = make([]rune, width)
+		owners[row] = make([]int, width)
+		for col := range owners[row] {
+			owners[row][col] = -1
+		}
+	}
+
+	set := func(x, y, owner int) {
+		row, col := y/4, x/2
+		cells[row][col] |= brailleDot(x%2, y%4)
+		owners[row][col] = owner
+	}
+
+	for i, s := range series {
+		prev := -1
+		for x, v := range Resample(s.Values, dotsX) {
+			if math.IsNaN(v) {
+				prev = -1
+				continue
+			}
+			y := int(math.Round((1 - scale(v, lo, hi)) * float64(dotsY-1)))
+			// Join to the previous point with a vertical run in this column
+			from, to := y, y
+			if prev >= 0 {
+				from, to = min(prev, y), max(prev, y)
+			}
+			for dy := from; dy <= to; dy++ {
+				set(x, dy, i)
+			}
+			prev = y
+		}
+	}
+
+	lines := make([]string, height)
+	for row := range cells {
+		var b strings.Builder
+		for col, dots := range cells[row] {
+			if owners[row][col] < 0 {
+				b.WriteRune(' ')
+				continue
+			}
+			b.WriteString(series[owners[row][col]].Style.Render(string(0x2800 + dots)))
+		}
+		lines[row] = b.String()
+	}
+	return lines
+}
+
+// Resample maps values onto n columns, averaging the values that share a
+// column and repeating values when there are fewer than n. Columns without
+// a value are NaN.
+func Resample(values []float64, n int) []float64 {
+	result := make([]float64, n)
+	if len(values) == 0 {
+		for i := range result {
+			result[i] = math.NaN()
+		}
+		return result
+	}
+	for col := range result {
+		start := col * len(values) / n
+		end := (col + 1) * len(values) / n
+		if end <= start {
+			end = start + 1
+		}
+		sum, count := 0.0, 0
+		for _, v := range values[start:end] {
+			if !math.IsNaN(v) {
+				sum += v
+				count++
+			}
+		}
+		if count == 0 {
+			result[col] = math.NaN()
+		} else {
+			result[col] = sum / float64(count)
+		}
+	}
+	return result
+}
+
+// scale maps v into [0, 1] within lo..hi
+func scale(v, lo, hi float64) float64 {
+	if hi <= lo {
+		return 0
+	}
+	return math.Max(0, math.Min(1, (v-lo)/(hi-lo)))
+}
+
+// brailleDot returns the bit of the dot at column x (0-1) and row y (0-3)
+// of a braille cell
+func brailleDot(x, y int) rune {
+	if y == 3 {
+		return rune(0x40 << x)
+	}
+	return rune(1 << (y + 3*x))
+}
@@ -0,0 +1,69 @@
+package chart
+
+import (
+	"math"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestResample(t *testing.T) {
+	// Downsampling averages the values sharing a column
+	got := Resample([]float64{1, 3, 5, 7}, 2)
+	if got[0] != 2 || got[1] != 6 {
+		t.Errorf("Expected [2 6], got %v", got)
+	}
+
+	// Upsampling repeats values
+	got = Resample([]float64{1, 2}, 4)
+	if got[0] != 1 || got[1] != 1 || got[2] != 2 || got[3] != 2 {
+		t.Errorf("Expected [1 1 2 2], got %v", got)
+	}
+
+	// Gaps stay gaps
+	got = Resample([]float64{1, math.NaN()}, 2)
+	if got[0] != 1 || !math.IsNaN(got[1]) {
+		t.Errorf("Expected [1 NaN], got %v", got)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	got := Sparkline([]float64{0, 50, 100, math.NaN()}, 4, 0, 100)
+	if got != "▁▅█ " {
+		t.Errorf("Expected \"▁▅█ \", got %q", got)
+	}
+}
+
+func TestBounds(t *testing.T) {
+	lo, hi := Bounds([]float64{3, math.NaN(), 1}, []float64{7})
+	if lo != 1 || hi != 7 {
+		t.Errorf("Expected 1..7, got %v..%v", lo, hi)
+	}
+	if lo, hi := Bounds(); lo != 0 || hi != 1 {
+		t.Errorf("Expected 0..1 for no values, got %v..%v", lo, hi)
+	}
+	if lo, hi := Bounds([]float64{5, 5}); lo != 0 || hi != 5 {
+		t.Errorf("Expected a flat series to be widened to 0..5, got %v..%v", lo, hi)
+	}
+}
+
+func TestBraille(t *testing.T) {
+	style := lipgloss.NewStyle()
+
+	// A rising line from the bottom left to the top right of one cell
+	lines := Braille([]Series{{Values: []float64{0, 1}, Style: style}}, 1, 1, 0, 1)
+	if len(lines) != 1 {
+		t.Fatalf("Expected 1 line, got %d", len(lines))
+	}
+	// Left column bottom dot (0x40) plus the right column filled from the
+	// bottom up to the top (0x08 | 0x10 | 0x20 | 0x80)
+	if want := string(rune(0x2800 + 0x40 + 0x08 + 0x10 + 0x20 + 0x80)); lines[0] != want {
+		t.Errorf("Expected %q, got %q", want, lines[0])
+	}
+
+	// Cells without dots are blank
+	lines = Braille([]Series{{Values: []float64{1, 1, 1, 1}, Style: style}}, 2, 2, 0, 1)
+	if []rune(lines[1])[0] != ' ' || []rune(lines[0])[0] == ' ' {
+		t.Errorf("Expected a line along the top row only, got %q", lines)
+	}
+}
@@ -0,0 +1,218 @@
+// Package cli implements the non-interactive lazyaws subcommands, such as
+// "lazyaws ec2 ls", for use in scripts and CI jobs.
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fuziontech/lazyaws/internal/aws"
+	"github.com/fuziontech/lazyaws/internal/config"
+)
+
+// Exit codes returned by Run
+const (
+	ExitOK    = 0
+	ExitError = 1 // The command ran but failed
+	ExitUsage = 2 // The command line was invalid
+)
+
+// usageError marks errors caused by invalid arguments
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+// usagef returns a usage error, which makes Run exit with ExitUsage
+func usagef(format string, args ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// ClientOptions selects the credentials and region for a command
+type ClientOptions struct {
+	Profile string
+	Region  string
+}
+
+// App runs CLI commands against AWS
+type App struct {
+	Stdout io.Writer
+	Stderr io.Writer
+	Config *config.Config
+
+	// NewClient creates the AWS client for a command
+	NewClient func(ctx context.Context, opts ClientOptions) (*aws.Client, error)
+	// UploadJournalPath, if set, is where multipart uploads are recorded so
+	// an interrupted sync can continue them
+	UploadJournalPath string
+}
+
+// command is a subcommand such as "ec2 ls"
+type command struct {
+	service string
+	name    string
+	usage   string
+	run     func(a *App, ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{"ec2", "ls", "ec2 ls [--state STATE] [-o table|json|yaml]", runEC2List},
+	{"s3", "sync", "s3 sync SOURCE DESTINATION [--delete] [--exclude GLOB] [--include GLOB] [--dryrun] (one side is s3://bucket/prefix)", runS3Sync},
+	{"eks", "kubeconfig", "eks kubeconfig CLUSTER [--kubeconfig PATH] [--alias NAME] [--exec-plugin lazyaws|aws|aws-iam-authenticator]", runEKSKubeconfig},
+	{"eks", "token", "eks token --cluster CLUSTER", runEKSToken},
+	{"ssm", "connect", "ssm connect INSTANCE_ID", runSSMConnect},
+}
+
+// IsCommand reports whether args (without the program name) name a CLI
+// command rather than starting the TUI
+func IsCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	switch args[0] {
+	case "help", "-h", "--help":
+		return true
+	}
+	for _, cmd := range commands {
+		if cmd.service == args[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// NewApp creates an App that writes to the process's stdout and stderr
+func NewApp(cfg *config.Config) *App {
+	// Without a journal, uploads just aren't continued across runs
+	journalPath, _ := aws.GetUploadJournalPath()
+	return &App{
+		Stdout:            os.Stdout,
+		Stderr:            os.Stderr,
+		Config:            cfg,
+		NewClient:         newAWSClient,
+		UploadJournalPath: journalPath,
+	}
+}
+
+// Run executes the command in args (without the program name) and returns
+// the process exit code
+func (a *App) Run(ctx context.Context, args []string) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		a.printUsage(a.Stdout)
+		return ExitOK
+	}
+
+	if len(args) < 2 {
+		fmt.Fprintf(a.Stderr, "Error: missing command for %q\n\n", args[0])
+		a.printUsage(a.Stderr)
+		return ExitUsage
+	}
+
+	for _, cmd := range commands {
+		if cmd.service == args[0] && cmd.name == args[1] {
+			return a.exitCode(cmd.run(a, ctx, args[2:]))
+		}
+	}
+
+	fmt.Fprintf(a.Stderr, "Error: unknown command %q\n\n", args[0]+" "+args[1])
+	a.printUsage(a.Stderr)
+	return ExitUsage
+}
+
+// exitCode reports err and maps it to an exit code
+func (a *App) exitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		return ExitOK
+	}
+
+	fmt.Fprintf(a.Stderr, "Error: %v\n", err)
+	var usageErr *usageError
+	if errors.As(err, &usageErr) {
+		return ExitUsage
+	}
+	return ExitError
+}
+
+func (a *App) printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: lazyaws [COMMAND]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Without a command lazyaws starts the interactive TUI.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  lazyaws %s\n", cmd.usage)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Every command accepts --profile and --region.")
+}
+
+// newFlagSet creates a flag set with the flags shared by every command
+func (a *App) newFlagSet(name string, opts *ClientOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("lazyaws "+name, flag.ContinueOnError)
+	fs.SetOutput(a.Stderr)
+	fs.StringVar(&opts.Profile, "profile", "", "AWS profile to use")
+	fs.StringVar(&opts.Region, "region", "", "AWS region (defaults to the configured region)")
+	return fs
+}
+
+// parseArgs parses flags that may appear before or after positional
+// arguments and returns the positional arguments
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, &usageError{msg: err.Error()}
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// client creates the AWS client for a command. Missing options are filled
+// in place: the region defaults to the configured one, and without
+// --profile or AWS_PROFILE the profile saved by the TUI's auth setup is used.
+func (a *App) client(ctx context.Context, opts *ClientOptions) (*aws.Client, error) {
+	if opts.Region == "" && a.Config != nil {
+		opts.Region = a.Config.Region
+	}
+
+	if opts.Profile == "" && os.Getenv("AWS_PROFILE") == "" {
+		if authConfig, _ := aws.LoadAuthConfig(); authConfig != nil {
+			switch authConfig.Method {
+			case aws.AuthMethodProfile:
+				opts.Profile = authConfig.ProfileName
+			case aws.AuthMethodSSO:
+				if !aws.CheckEnvVarsAvailable() {
+					return nil, fmt.Errorf("SSO login is only available in the TUI; pass --profile or set AWS credentials in the environment")
+				}
+			}
+		}
+	}
+
+	return a.NewClient(ctx, *opts)
+}
+
+// newAWSClient creates a client backed by the real AWS services
+func newAWSClient(ctx context.Context, opts ClientOptions) (*aws.Client, error) {
+	if opts.Profile != "" {
+		return aws.NewClientWithProfileAndRegion(ctx, opts.Profile, opts.Region)
+	}
+	return aws.NewClient(ctx, &config.Config{Region: opts.Region})
+}
@@ -0,0 +1,276 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	sdkaws "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/fuziontech/lazyaws/internal/aws"
+	"github.com/fuziontech/lazyaws/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// newTestApp returns an App backed by the fake AWS backend, isolated from
+// the user's home directory and profile
+func newTestApp(t *testing.T, backend *aws.FakeBackend) (*App, *bytes.Buffer, *bytes.Buffer) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("AWS_PROFILE", "")
+
+	var stdout, stderr bytes.Buffer
+	app := &App{
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Config: config.DefaultConfig(),
+		NewClient: func(ctx context.Context, opts ClientOptions) (*aws.Client, error) {
+			return backend.Client(opts.Region), nil
+		},
+	}
+	return app, &stdout, &stderr
+}
+
+func newEC2Backend() *aws.FakeBackend {
+	backend := aws.NewFakeBackend()
+	backend.EC2.Instances = []types.Instance{
+		{
+			InstanceId:   sdkaws.String("i-0001"),
+			InstanceType: types.InstanceTypeT3Micro,
+			State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+			Tags:         []types.Tag{{Key: sdkaws.String("Name"), Value: sdkaws.String("web")}},
+		},
+		{
+			InstanceId:   sdkaws.String("i-0002"),
+			InstanceType: types.InstanceTypeT3Micro,
+			State:        &types.InstanceState{Name: types.InstanceStateNameStopped},
+		},
+	}
+	return backend
+}
+
+func TestEC2ListJSON(t *testing.T) {
+	app, stdout, _ := newTestApp(t, newEC2Backend())
+
+	code := app.Run(context.Background(), []string{"ec2", "ls", "--state", "running", "-o", "json"})
+	if code != ExitOK {
+		t.Fatalf("Expected exit code %d, got %d", ExitOK, code)
+	}
+
+	var instances []instanceOutput
+	if err := json.Unmarshal(stdout.Bytes(), &instances); err != nil {
+		t.Fatalf("Output is not valid JSON: %v\n%s", err, stdout.String())
+	}
+
+	if len(instances) != 1 || instances[0].ID != "i-0001" || instances[0].Name != "web" {
+		t.Errorf("Expected only running instance i-0001, got %+v", instances)
+	}
+}
+
+func TestEC2ListYAML(t *testing.T) {
+	app, stdout, _ := newTestApp(t, newEC2Backend())
+
+	if code := app.Run(context.Background(), []string{"ec2", "ls", "-o", "yaml"}); code != ExitOK {
+		t.Fatalf("Expected exit code %d, got %d", ExitOK, code)
+	}
+
+	var instances []instanceOutput
+	if err := yaml.Unmarshal(stdout.Bytes(), &instances); err != nil {
+		t.Fatalf("Output is not valid YAML: %v\n%s", err, stdout.String())
+	}
+
+	if len(instances) != 2 {
+		t.Errorf("Expected 2 instances, got %d", len(instances))
+	}
+}
+
+func TestEC2ListTable(t *testing.T) {
+	app, stdout, _ := newTestApp(t, newEC2Backend())
+
+	if code := app.Run(context.Background(), []string{"ec2", "ls"}); code != ExitOK {
+		t.Fatalf("Expected exit code %d, got %d", ExitOK, code)
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected header and 2 rows, got %d lines:\n%s", len(lines), stdout.String())
+	}
+
+	if !strings.HasPrefix(lines[0], "ID") || !strings.Contains(lines[1], "i-0001") {
+		t.Errorf("Unexpected table output:\n%s", stdout.String())
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"help", []string{"help"}, ExitOK},
+		{"unknown command", []string{"ec2", "explode"}, ExitUsage},
+		{"bad format", []string{"ec2", "ls", "-o", "xml"}, ExitUsage},
+		{"unknown flag", []string{"ec2", "ls", "--nope"}, ExitUsage},
+		{"sync needs two arguments", []string{"s3", "sync", "./dir"}, ExitUsage},
+		{"sync needs one s3 side", []string{"s3", "sync", "./a", "./b"}, ExitUsage},
+		{"kubeconfig needs a cluster", []string{"eks", "kubeconfig"}, ExitUsage},
+		{"token needs a cluster", []string{"eks", "token"}, ExitUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, _, _ := newTestApp(t, newEC2Backend())
+			if code := app.Run(context.Background(), tt.args); code != tt.code {
+				t.Errorf("Expected exit code %d, got %d", tt.code, code)
+			}
+		})
+	}
+}
+
+func TestHelpListsCommands(t *testing.T) {
+	app, stdout, _ := newTestApp(t, aws.NewFakeBackend())
+
+	if code := app.Run(context.Background(), []string{"help"}); code != ExitOK {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+	for _, want := range []string{
+		"ec2 ls [--state STATE] [-o table|json|yaml]",
+		"eks kubeconfig CLUSTER [--kubeconfig PATH] [--alias NAME] [--exec-plugin lazyaws|aws|aws-iam-authenticator]",
+		"eks token --cluster CLUSTER",
+	} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("Expected usage to contain %q, got:\n%s", want, stdout.String())
+		}
+	}
+}
+
+func TestEKSTokenPrintsExecCredential(t *testing.T) {
+	app, stdout, _ := newTestApp(t, aws.NewFakeBackend())
+
+	if code := app.Run(context.Background(), []string{"eks", "token", "--cluster", "prod", "--region", "eu-west-1"}); code != ExitOK {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+
+	var credential aws.ExecCredential
+	if err := json.Unmarshal(stdout.Bytes(), &credential); err != nil {
+		t.Fatalf("Output is not valid JSON: %v\n%s", err, stdout.String())
+	}
+	if credential.Kind != "ExecCredential" {
+		t.Errorf("Expected kind 'ExecCredential', got '%s'", credential.Kind)
+	}
+	if !strings.HasPrefix(credential.Status.Token, "k8s-aws-v1.") {
+		t.Errorf("Expected a k8s-aws-v1 token, got '%s'", credential.Status.Token)
+	}
+}
+
+func TestEC2ListAPIError(t *testing.T) {
+	backend := newEC2Backend()
+	backend.EC2.Err = os.ErrPermission
+	app, _, stderr := newTestApp(t, backend)
+
+	if code := app.Run(context.Background(), []string{"ec2", "ls"}); code != ExitError {
+		t.Errorf("Expected exit code %d, got %d", ExitError, code)
+	}
+
+	if !strings.Contains(stderr.String(), "Error:") {
+		t.Errorf("Expected error on stderr, got %q", stderr.String())
+	}
+}
+
+func TestS3SyncUpload(t *testing.T) {
+	backend := aws.NewFakeBackend()
+	backend.S3.AddBucket("bucket", "us-east-1")
+	app, stdout, stderr := newTestApp(t, backend)
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	code := app.Run(context.Background(), []string{"s3", "sync", dir, "s3://bucket/backup"})
+	if code != ExitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", ExitOK, code, stderr.String())
+	}
+
+	if _, ok := backend.S3.Buckets["bucket"].Objects["backup/a.txt"]; !ok {
+		t.Errorf("Expected backup/a.txt to be uploaded")
+	}
+
+	if !strings.Contains(stdout.String(), "Synced") {
+		t.Errorf("Expected sync summary, got %q", stdout.String())
+	}
+}
+
+func TestS3SyncWithoutConfig(t *testing.T) {
+	backend := aws.NewFakeBackend()
+	backend.S3.AddBucket("bucket", "us-east-1")
+	app, _, stderr := newTestApp(t, backend)
+	app.Config = nil
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if code := app.Run(context.Background(), []string{"s3", "sync", dir, "s3://bucket/backup"}); code != ExitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", ExitOK, code, stderr.String())
+	}
+	if _, ok := backend.S3.Buckets["bucket"].Objects["backup/a.txt"]; !ok {
+		t.Errorf("Expected backup/a.txt to be uploaded")
+	}
+}
+
+func TestS3SyncDryRunDelete(t *testing.T) {
+	backend := aws.NewFakeBackend()
+	backend.S3.AddBucket("bucket", "us-east-1")
+	backend.S3.AddObject("bucket", "backup/old.txt", []byte("old"))
+	app, stdout, stderr := newTestApp(t, backend)
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{"a.txt": "a", "skip.log": "log"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	args := []string{"s3", "sync", dir, "s3://bucket/backup", "--delete", "--exclude", "*.log", "--dryrun"}
+	if code := app.Run(context.Background(), args); code != ExitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", ExitOK, code, stderr.String())
+	}
+
+	want := "(dryrun) upload: " + filepath.Join(dir, "a.txt") + " to s3://bucket/backup/a.txt\n" +
+		"(dryrun) delete: s3://bucket/backup/old.txt\n"
+	if stdout.String() != want {
+		t.Errorf("Expected dry run output %q, got %q", want, stdout.String())
+	}
+	if len(backend.S3.Buckets["bucket"].Objects) != 1 {
+		t.Errorf("Expected a dry run to leave the bucket alone")
+	}
+}
+
+func TestSSMConnectUnregisteredInstance(t *testing.T) {
+	app, _, stderr := newTestApp(t, aws.NewFakeBackend())
+
+	if code := app.Run(context.Background(), []string{"ssm", "connect", "i-0001"}); code != ExitError {
+		t.Errorf("Expected exit code %d, got %d", ExitError, code)
+	}
+
+	if !strings.Contains(stderr.String(), "not registered with SSM") {
+		t.Errorf("Expected SSM registration error, got %q", stderr.String())
+	}
+}
+
+func TestParseS3URI(t *testing.T) {
+	bucket, prefix, ok := parseS3URI("s3://bucket/some/prefix/")
+	if !ok || bucket != "bucket" || prefix != "some/prefix" {
+		t.Errorf("Unexpected parse result: %q %q %v", bucket, prefix, ok)
+	}
+
+	if _, _, ok := parseS3URI("./local"); ok {
+		t.Errorf("Expected local path not to parse as S3 URI")
+	}
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"context"
+	"strings"
+
+	"github.com/fuziontech/lazyaws/internal/aws"
+)
+
+// instanceOutput is the JSON/YAML shape of an EC2 instance
+type instanceOutput struct {
+	ID           string            `json:"id" yaml:"id"`
+	Name         string            `json:"name" yaml:"name"`
+	State        string            `json:"state" yaml:"state"`
+	InstanceType string            `json:"instance_type" yaml:"instance_type"`
+	AZ           string            `json:"availability_zone" yaml:"availability_zone"`
+	PublicIP     string            `json:"public_ip,omitempty" yaml:"public_ip,omitempty"`
+	PrivateIP    string            `json:"private_ip,omitempty" yaml:"private_ip,omitempty"`
+	Tags         map[string]string `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// runEC2List implements "ec2 ls"
+func runEC2List(a *App, ctx context.Context, args []string) error {
+	var opts ClientOptions
+	fs := a.newFlagSet("ec2 ls", &opts)
+	state := fs.String("state", "", "only list instances in this state, e.g. running")
+	format := fs.String("o", FormatTable, "output format: table, json or yaml")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usagef("ec2 ls takes no arguments, got %q", strings.Join(positional, " "))
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	client, err := a.client(ctx, &opts)
+	if err != nil {
+		return err
+	}
+
+	instances, err := client.ListInstances(ctx)
+	if err != nil {
+		return err
+	}
+
+	output := []instanceOutput{}
+	var rows [][]string
+	for _, inst := range instances {
+		if *state != "" && !strings.EqualFold(inst.State, *state) {
+			continue
+		}
+		output = append(output, newInstanceOutput(inst))
+		rows = append(rows, []string{inst.ID, inst.Name, inst.State, inst.InstanceType, inst.AZ, inst.PublicIP, inst.PrivateIP})
+	}
+
+	headers := []string{"ID", "NAME", "STATE", "TYPE", "AZ", "PUBLIC IP", "PRIVATE IP"}
+	return writeOutput(a.Stdout, *format, output, headers, rows)
+}
+
+func newInstanceOutput(inst aws.Instance) instanceOutput {
+	out := instanceOutput{
+		ID:           inst.ID,
+		Name:         inst.Name,
+		State:        inst.State,
+		InstanceType: inst.InstanceType,
+		AZ:           inst.AZ,
+		PublicIP:     inst.PublicIP,
+		PrivateIP:    inst.PrivateIP,
+	}
+	if len(inst.Tags) > 0 {
+		out.Tags = make(map[string]string, len(inst.Tags))
+		for _, tag := range inst.Tags {
+			out.Tags[tag.Key] = tag.Value
+		}
+	}
+	return out
+}
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/fuziontech/lazyaws/internal/aws"
+	"github.com/fuziontech/lazyaws/internal/config"
+)
+
+// runEKSKubeconfig implements "eks kubeconfig"
+func runEKSKubeconfig(a *App, ctx context.Context, args []string) error {
+	var opts ClientOptions
+	fs := a.newFlagSet("eks kubeconfig", &opts)
+	path := fs.String("kubeconfig", "", "kubeconfig file to update (defaults to $KUBECONFIG or ~/.kube/config)")
+	alias := fs.String("alias", "", "context name (defaults to the cluster ARN)")
+	plugin := fs.String("exec-plugin", "", "credential plugin: lazyaws, aws or aws-iam-authenticator")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usagef("eks kubeconfig needs exactly one CLUSTER name")
+	}
+	clusterName := positional[0]
+
+	switch *plugin {
+	case "", config.KubeExecPluginLazyAWS, config.KubeExecPluginAWS, config.KubeExecPluginIAMAuthenticator:
+	default:
+		return usagef("unknown exec plugin %q (expected %s, %s or %s)", *plugin, config.KubeExecPluginLazyAWS, config.KubeExecPluginAWS, config.KubeExecPluginIAMAuthenticator)
+	}
+
+	client, err := a.client(ctx, &opts)
+	if err != nil {
+		return err
+	}
+
+	kubeOpts := aws.KubeconfigOptionsFromConfig(a.Config, clusterName)
+	kubeOpts.Profile = opts.Profile
+	if *path != "" {
+		kubeOpts.Path = *path
+	}
+	if *alias != "" {
+		kubeOpts.ContextAlias = *alias
+	}
+	if *plugin != "" {
+		kubeOpts.ExecPlugin = *plugin
+	}
+
+	contextName, err := client.UpdateKubeconfig(ctx, clusterName, kubeOpts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.Stdout, "Updated kubeconfig for cluster %s (context: %s)\n", clusterName, contextName)
+	return nil
+}
+
+// runEKSToken implements "eks token". It prints an ExecCredential so it can
+// be used as a kubeconfig exec credential plugin.
+func runEKSToken(a *App, ctx context.Context, args []string) error {
+	var opts ClientOptions
+	fs := a.newFlagSet("eks token", &opts)
+	clusterName := fs.String("cluster", "", "name of the EKS cluster")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usagef("eks token takes no arguments, use --cluster")
+	}
+	if *clusterName == "" {
+		return usagef("eks token needs --cluster")
+	}
+
+	client, err := a.client(ctx, &opts)
+	if err != nil {
+		return err
+	}
+
+	token, err := client.GetEKSToken(ctx, *clusterName)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(a.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(token.ExecCredential())
+}
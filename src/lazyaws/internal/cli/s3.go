@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fuziontech/lazyaws/internal/aws"
+)
+
+// parseS3URI splits s3://bucket/prefix into its bucket and prefix
+func parseS3URI(uri string) (string, string, bool) {
+	if !strings.HasPrefix(uri, "s3://") {
+		return "", "", false
+	}
+	bucket, prefix, _ := strings.Cut(strings.TrimPrefix(uri, "s3://"), "/")
+	if bucket == "" {
+		return "", "", false
+	}
+	return bucket, strings.TrimSuffix(prefix, "/"), true
+}
+
+// filterFlag appends an include or exclude filter each time it is set, so
+// the filters keep the order they were given in
+type filterFlag struct {
+	filters *[]aws.SyncFilter
+	exclude bool
+}
+
+func (f filterFlag) String() string {
+	return ""
+}
+
+func (f filterFlag) Set(pattern string) error {
+	*f.filters = append(*f.filters, aws.SyncFilter{Pattern: pattern, Exclude: f.exclude})
+	return nil
+}
+
+// runS3Sync implements "s3 sync"
+func runS3Sync(a *App, ctx context.Context, args []string) error {
+	var opts ClientOptions
+	var syncOpts aws.SyncOptions
+	fs := a.newFlagSet("s3 sync", &opts)
+	fs.BoolVar(&syncOpts.Delete, "delete", false, "delete destination files that are not in the source")
+	fs.Var(filterFlag{filters: &syncOpts.Filters, exclude: true}, "exclude", "skip files matching this glob (repeatable)")
+	fs.Var(filterFlag{filters: &syncOpts.Filters}, "include", "don't skip files matching this glob, overriding an earlier --exclude (repeatable)")
+	dryRun := fs.Bool("dryrun", false, "print what would change without changing anything")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return usagef("s3 sync needs a SOURCE and a DESTINATION")
+	}
+	source, destination := positional[0], positional[1]
+
+	srcBucket, srcPrefix, srcIsS3 := parseS3URI(source)
+	dstBucket, dstPrefix, dstIsS3 := parseS3URI(destination)
+	if srcIsS3 == dstIsS3 {
+		return usagef("exactly one of SOURCE and DESTINATION must be an s3://bucket/prefix URI")
+	}
+
+	if !srcIsS3 {
+		info, err := os.Stat(source)
+		if err != nil {
+			return fmt.Errorf("cannot read source directory: %w", err)
+		}
+		if !info.IsDir() {
+			return usagef("source %s is not a directory", source)
+		}
+	}
+
+	client, err := a.client(ctx, &opts)
+	if err != nil {
+		return err
+	}
+
+	var plan *aws.SyncPlan
+	if srcIsS3 {
+		plan, err = client.PlanSyncS3ToLocal(ctx, srcBucket, srcPrefix, destination, syncOpts)
+	} else {
+		plan, err = client.PlanSyncLocalToS3(ctx, source, dstBucket, dstPrefix, syncOpts)
+	}
+	if err != nil {
+		return err
+	}
+	for _, key := range plan.Unsafe {
+		fmt.Fprintf(a.Stderr, "skip: s3://%s/%s would be written outside %s\n", srcBucket, key, destination)
+	}
+
+	if *dryRun {
+		for _, action := range plan.Actions {
+			fmt.Fprintf(a.Stdout, "(dryrun) %s\n", action)
+		}
+		return nil
+	}
+
+	concurrency := aws.DefaultTransferConcurrency
+	if a.Config != nil {
+		concurrency = a.Config.TransferConcurrency
+	}
+	transfers := aws.NewTransferManager(ctx, concurrency, nil)
+	if a.UploadJournalPath != "" {
+		journal, err := aws.LoadUploadJournal(a.UploadJournalPath)
+		if err != nil {
+			return err
+		}
+		transfers.SetUploadJournal(journal)
+	}
+	err = client.ExecuteSyncPlan(ctx, plan, transfers)
+	if waitErr := transfers.Wait(); err == nil {
+		err = waitErr
+	}
+	if err != nil {
+		return err
+	}
+
+	copies, deletes, bytes := plan.Summary()
+	fmt.Fprintf(a.Stdout, "Synced %s to %s: %d copied (%d bytes), %d deleted, %d unchanged\n",
+		source, destination, copies, bytes, deletes, plan.Unchanged)
+	return nil
+}
@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// runSSMConnect implements "ssm connect"
+func runSSMConnect(a *App, ctx context.Context, args []string) error {
+	var opts ClientOptions
+	fs := a.newFlagSet("ssm connect", &opts)
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usagef("ssm connect needs exactly one INSTANCE_ID")
+	}
+	instanceID := positional[0]
+
+	client, err := a.client(ctx, &opts)
+	if err != nil {
+		return err
+	}
+
+	status, err := client.CheckSSMConnectivity(ctx, instanceID)
+	if err != nil {
+		return err
+	}
+	if !status.Connected {
+		return fmt.Errorf("instance %s is not registered with SSM", instanceID)
+	}
+
+	return client.RunSSMShell(ctx, instanceID, os.Stdin, a.Stdout)
+}
@@ -1,31 +1,266 @@
 package config
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Screens that can be used as the default screen
+const (
+	ScreenEC2 = "ec2"
+	ScreenS3  = "s3"
+	ScreenEKS = "eks"
+)
+
+// Exec credential plugins that generated kubeconfig users can call
+const (
+	KubeExecPluginLazyAWS          = "lazyaws"
+	KubeExecPluginAWS              = "aws"
+	KubeExecPluginIAMAuthenticator = "aws-iam-authenticator"
 )
 
 // Config holds the application configuration
 type Config struct {
-	Region  string   `json:"region"`
-	Regions []string `json:"regions"`
+	Region  string   `yaml:"region"`
+	Regions []string `yaml:"regions"`
+
+	// DefaultScreen is the screen shown after login: ec2, s3 or eks
+	DefaultScreen string `yaml:"default_screen"`
+	// RefreshInterval is the auto-refresh interval in seconds
+	RefreshInterval int `yaml:"refresh_interval"`
+	// Editor is the command used to edit S3 objects, falling back to $EDITOR
+	Editor string `yaml:"editor"`
+	// PageSize is the number of rows moved by VIM page navigation
+	PageSize int `yaml:"page_size"`
+	// Theme is the name of a built-in color theme
+	Theme string `yaml:"theme"`
+	// Keybindings overrides the default key of an action, e.g. "refresh": "ctrl+r"
+	Keybindings map[string]string `yaml:"keybindings"`
+
+	// Kubeconfig is the kubeconfig file to update, defaulting to $KUBECONFIG
+	// or ~/.kube/config
+	Kubeconfig string `yaml:"kubeconfig"`
+	// KubeExecPlugin is the credential plugin written into kubeconfig users
+	KubeExecPlugin string `yaml:"kube_exec_plugin"`
+	// KubeContextAliases maps cluster names to kubeconfig context names
+	KubeContextAliases map[string]string `yaml:"kube_context_aliases"`
+
+	// EKSConcurrency limits how many clusters are described at once
+	EKSConcurrency int `yaml:"eks_concurrency"`
+	// EKSDescribeTimeout is the per-cluster describe timeout in seconds
+	EKSDescribeTimeout int `yaml:"eks_describe_timeout"`
+
+	// TransferConcurrency limits how many S3 uploads and downloads run at once
+	TransferConcurrency int `yaml:"transfer_concurrency"`
+
+	// TagTemplates are named tag sets that can be applied to EC2 instances
+	TagTemplates map[string]map[string]string `yaml:"tag_templates"`
 }
 
-// LoadConfig loads the configuration from a file
-func LoadConfig() (*Config, error) {
-	// For now, we'll just use a default config
+// ValidationError lists every problem found in a config file
+type ValidationError struct {
+	Path     string
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid config %s:\n  - %s", e.Path, strings.Join(e.Problems, "\n  - "))
+}
+
+// DefaultConfig returns the configuration used when no config file exists
+func DefaultConfig() *Config {
 	return &Config{
-		Region:  GetDefaultRegion(),
-		Regions: []string{"us-east-1", "us-east-2", "us-west-1", "us-west-2", "eu-central-1", "eu-west-1", "eu-west-2", "ap-southeast-1", "ap-southeast-2", "ap-northeast-1"},
-	}, nil
+		Region:          GetDefaultRegion(),
+		Regions:         []string{"us-east-1", "us-east-2", "us-west-1", "us-west-2", "eu-central-1", "eu-west-1", "eu-west-2", "ap-southeast-1", "ap-southeast-2", "ap-northeast-1"},
+		DefaultScreen:   ScreenEC2,
+		RefreshInterval: 30,
+		PageSize:        20,
+		Theme:           DefaultThemeName,
+		KubeExecPlugin:  KubeExecPluginLazyAWS,
+
+		EKSConcurrency:     8,
+		EKSDescribeTimeout: 15,
+
+		TransferConcurrency: 4,
+	}
+}
+
+// GetConfigPath returns the path to the application config file
+func GetConfigPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".lazyaws", "config.yaml"), nil
+}
+
+// LoadConfig loads the configuration from ~/.lazyaws/config.yaml, using
+// the defaults when the file doesn't exist
+func LoadConfig() (*Config, error) {
+	path, err := GetConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	return LoadConfigFrom(path)
+}
+
+// LoadConfigFrom loads and validates the configuration at path. Settings
+// missing from the file keep their default values.
+func LoadConfigFrom(path string) (*Config, error) {
+	cfg := DefaultConfig()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	// An explicit region in the environment wins over the file
+	envRegion := lookupRegionEnv()
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	// An empty file has no settings
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	if envRegion != "" {
+		cfg.Region = envRegion
+	}
+
+	if err := cfg.Validate(); err != nil {
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			validationErr.Path = path
+		}
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// Validate checks the configuration and reports every problem at once
+func (c *Config) Validate() error {
+	var problems []string
+
+	if c.Region == "" {
+		problems = append(problems, "region must not be empty")
+	}
+	if len(c.Regions) == 0 {
+		problems = append(problems, "regions must list at least one region")
+	}
+	seen := make(map[string]bool)
+	for _, region := range c.Regions {
+		if strings.TrimSpace(region) == "" {
+			problems = append(problems, "regions must not contain empty entries")
+		} else if seen[region] {
+			problems = append(problems, fmt.Sprintf("regions lists %q more than once", region))
+		}
+		seen[region] = true
+	}
+
+	switch c.DefaultScreen {
+	case ScreenEC2, ScreenS3, ScreenEKS:
+	default:
+		problems = append(problems, fmt.Sprintf("default_screen %q is not one of ec2, s3, eks", c.DefaultScreen))
+	}
+
+	if c.RefreshInterval < 5 {
+		problems = append(problems, fmt.Sprintf("refresh_interval must be at least 5 seconds, got %d", c.RefreshInterval))
+	}
+	if c.Editor != "" && strings.TrimSpace(c.Editor) == "" {
+		problems = append(problems, "editor must not be blank")
+	}
+	if c.PageSize < 1 {
+		problems = append(problems, fmt.Sprintf("page_size must be positive, got %d", c.PageSize))
+	}
+	if _, ok := Themes[c.Theme]; !ok {
+		problems = append(problems, fmt.Sprintf("theme %q is not one of %s", c.Theme, strings.Join(ThemeNames(), ", ")))
+	}
+	problems = append(problems, validateKeybindings(c.Keybindings)...)
+
+	switch c.KubeExecPlugin {
+	case KubeExecPluginLazyAWS, KubeExecPluginAWS, KubeExecPluginIAMAuthenticator:
+	default:
+		problems = append(problems, fmt.Sprintf("kube_exec_plugin %q is not one of %s, %s, %s", c.KubeExecPlugin, KubeExecPluginLazyAWS, KubeExecPluginAWS, KubeExecPluginIAMAuthenticator))
+	}
+	for cluster, alias := range c.KubeContextAliases {
+		if strings.TrimSpace(alias) == "" {
+			problems = append(problems, fmt.Sprintf("kube_context_aliases: cluster %q has an empty alias", cluster))
+		}
+	}
+
+	if c.EKSConcurrency < 1 {
+		problems = append(problems, fmt.Sprintf("eks_concurrency must be positive, got %d", c.EKSConcurrency))
+	}
+	if c.EKSDescribeTimeout < 1 {
+		problems = append(problems, fmt.Sprintf("eks_describe_timeout must be positive, got %d", c.EKSDescribeTimeout))
+	}
+	if c.TransferConcurrency < 1 {
+		problems = append(problems, fmt.Sprintf("transfer_concurrency must be positive, got %d", c.TransferConcurrency))
+	}
+	for _, name := range c.TagTemplateNames() {
+		tags := c.TagTemplates[name]
+		if len(tags) == 0 {
+			problems = append(problems, fmt.Sprintf("tag_templates: %q has no tags", name))
+		}
+		for key, value := range tags {
+			if key == "" || len(key) > 128 || len(value) > 256 || strings.HasPrefix(strings.ToLower(key), "aws:") {
+				problems = append(problems, fmt.Sprintf("tag_templates: %q has an invalid tag %q=%q", name, key, value))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// TagTemplateNames returns the names of the tag templates in order
+func (c *Config) TagTemplateNames() []string {
+	names := make([]string, 0, len(c.TagTemplates))
+	for name := range c.TagTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetEditor returns the editor command, falling back to $EDITOR and then vi
+func (c *Config) GetEditor() string {
+	if c.Editor != "" {
+		return c.Editor
+	}
+	if editor := os.Getenv("EDITOR"); editor != "" {
+		return editor
+	}
+	return "vi"
 }
 
 // GetDefaultRegion returns the default AWS region
 func GetDefaultRegion() string {
-	if region, ok := os.LookupEnv("AWS_REGION"); ok {
+	if region := lookupRegionEnv(); region != "" {
 		return region
 	}
-	if region, ok := os.LookupEnv("AWS_DEFAULT_REGION"); ok {
+	return "us-east-1"
+}
+
+// lookupRegionEnv returns the region set in the environment, if any
+func lookupRegionEnv() string {
+	if region := os.Getenv("AWS_REGION"); region != "" {
 		return region
 	}
-	return "us-east-1"
+	return os.Getenv("AWS_DEFAULT_REGION")
 }
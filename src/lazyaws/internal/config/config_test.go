@@ -0,0 +1,190 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfigFromMissingFile(t *testing.T) {
+	cfg, err := LoadConfigFrom(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Fatalf("Expected defaults for missing file, got error: %v", err)
+	}
+
+	if cfg.RefreshInterval != 30 {
+		t.Errorf("Expected default refresh interval 30, got %d", cfg.RefreshInterval)
+	}
+
+	if cfg.DefaultScreen != ScreenEC2 {
+		t.Errorf("Expected default screen 'ec2', got '%s'", cfg.DefaultScreen)
+	}
+}
+
+func TestLoadConfigFromFile(t *testing.T) {
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+
+	path := writeConfig(t, `# lazyaws settings
+region: eu-west-1
+regions:
+  - eu-west-1
+  - us-east-1
+default_screen: eks
+refresh_interval: 60
+page_size: 40
+theme: light
+keybindings:
+  refresh: ctrl+r
+tag_templates:
+  prod:
+    env: prod
+    owner: platform
+  dev: {env: dev}
+`)
+
+	cfg, err := LoadConfigFrom(path)
+	if err != nil {
+		t.Fatalf("LoadConfigFrom returned error: %v", err)
+	}
+
+	if cfg.Region != "eu-west-1" {
+		t.Errorf("Expected region 'eu-west-1', got '%s'", cfg.Region)
+	}
+
+	if len(cfg.Regions) != 2 {
+		t.Errorf("Expected 2 regions, got %d", len(cfg.Regions))
+	}
+
+	if cfg.DefaultScreen != ScreenEKS || cfg.RefreshInterval != 60 || cfg.PageSize != 40 {
+		t.Errorf("Unexpected settings: %+v", cfg)
+	}
+
+	if cfg.GetTheme() != Themes["light"] {
+		t.Errorf("Expected light theme")
+	}
+
+	if names := cfg.TagTemplateNames(); len(names) != 2 || names[0] != "dev" || cfg.TagTemplates["prod"]["owner"] != "platform" {
+		t.Errorf("Unexpected tag templates: %v", cfg.TagTemplates)
+	}
+
+	// Unset settings keep their defaults
+	if cfg.EKSConcurrency != 8 {
+		t.Errorf("Expected default EKS concurrency 8, got %d", cfg.EKSConcurrency)
+	}
+}
+
+func TestLoadConfigFromEmptyFile(t *testing.T) {
+	cfg, err := LoadConfigFrom(writeConfig(t, "# nothing set yet\n"))
+	if err != nil {
+		t.Fatalf("Expected defaults for an empty file, got error: %v", err)
+	}
+	if cfg.PageSize != 20 {
+		t.Errorf("Expected default page size 20, got %d", cfg.PageSize)
+	}
+}
+
+func TestLoadConfigEnvRegionWins(t *testing.T) {
+	t.Setenv("AWS_REGION", "ap-south-1")
+	path := writeConfig(t, `region: eu-west-1`)
+
+	cfg, err := LoadConfigFrom(path)
+	if err != nil {
+		t.Fatalf("LoadConfigFrom returned error: %v", err)
+	}
+
+	if cfg.Region != "ap-south-1" {
+		t.Errorf("Expected region from environment 'ap-south-1', got '%s'", cfg.Region)
+	}
+}
+
+func TestLoadConfigRejectsUnknownField(t *testing.T) {
+	path := writeConfig(t, `refresh_intervall: 10`)
+
+	_, err := LoadConfigFrom(path)
+	if err == nil || !strings.Contains(err.Error(), "refresh_intervall") {
+		t.Errorf("Expected error naming the unknown field, got %v", err)
+	}
+}
+
+func TestLoadConfigReportsAllProblems(t *testing.T) {
+	path := writeConfig(t, `
+default_screen: rds
+refresh_interval: 1
+page_size: 0
+theme: neon
+keybindings:
+  explode: z
+  stop: s
+kube_exec_plugin: gcloud
+tag_templates:
+  empty: {}
+  reserved:
+    "aws:owner": me
+`)
+
+	_, err := LoadConfigFrom(path)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected ValidationError, got %v", err)
+	}
+
+	if validationErr.Path != path {
+		t.Errorf("Expected error path '%s', got '%s'", path, validationErr.Path)
+	}
+
+	// default_screen, refresh_interval, page_size, theme, unknown action, the "s" conflict, kube_exec_plugin
+	// and both tag templates
+	if len(validationErr.Problems) != 9 {
+		t.Errorf("Expected 9 problems, got %d: %v", len(validationErr.Problems), validationErr.Problems)
+	}
+}
+
+func TestKeybindingsRejectReservedKeys(t *testing.T) {
+	for _, key := range []string{"j", "G", "ctrl+d", "esc", "ctrl+c"} {
+		cfg := DefaultConfig()
+		cfg.Keybindings = map[string]string{"quit": key}
+		err := cfg.Validate()
+		if err == nil || !strings.Contains(err.Error(), "reserved") {
+			t.Errorf("Expected binding quit to %q to be rejected, got %v", key, err)
+		}
+	}
+}
+
+func TestKeymapResolve(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Keybindings = map[string]string{
+		"refresh": "ctrl+r",
+		"start":   "r",
+		"stop":    "s",
+	}
+
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Expected swapped bindings to be valid, got %v", err)
+	}
+
+	keymap := cfg.Keymap()
+	tests := map[string]string{
+		"ctrl+r": "r", // refresh moved to ctrl+r
+		"r":      "s", // start moved to r
+		"s":      "S", // stop moved to s
+		"S":      "",  // stop's default key is released
+		"t":      "t", // untouched keys pass through
+	}
+	for pressed, expected := range tests {
+		if got := keymap.Resolve(pressed); got != expected {
+			t.Errorf("Resolve(%q) = %q, expected %q", pressed, got, expected)
+		}
+	}
+}
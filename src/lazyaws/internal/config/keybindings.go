@@ -0,0 +1,148 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DefaultKeybindings maps each rebindable action to its default key
+var DefaultKeybindings = map[string]string{
+	"quit":            "q",
+	"search":          "/",
+	"next_match":      "n",
+	"prev_match":      "N",
+	"command":         ":",
+	"open":            "enter",
+	"cycle_region":    "c",
+	"switch_screen":   "tab",
+	"refresh":         "r",
+	"kubeconfig":      "K",
+	"k9s":             "9",
+	"parent":          "backspace",
+	"edit":            "e",
+	"download":        "d",
+	"upload":          "u",
+	"delete":          "D",
+	"policy":          "p",
+	"versioning":      "v",
+	"filter":          "f",
+	"select":          " ",
+	"select_range":    "V",
+	"auto_refresh":    "a",
+	"clear_selection": "x",
+	"copy":            "y",
+	"start":           "s",
+	"stop":            "S",
+	"reboot":          "R",
+	"terminate":       "t",
+	"port_forward":    "P",
+	"next_metric":     "m",
+	"prev_metric":     "M",
+	"metric_range":    "w",
+	"alarm_actions":   "A",
+	"alarm_state":     "T",
+	"open_resource":   "o",
+	"insights":        "I",
+	"logs":            "L",
+	"next_page":       "]",
+	"prev_page":       "[",
+	"properties":      "E",
+}
+
+// reservedKeys are handled by the TUI itself and can't be bound to an
+// action: navigation, ctrl+c and esc, the aliases i and h for open and
+// parent, + and - for transfer concurrency and C for SSM sessions
+var reservedKeys = map[string]bool{
+	"ctrl+c": true, "esc": true,
+	"k": true, "up": true, "j": true, "down": true,
+	"g": true, "G": true, "ctrl+g": true,
+	"ctrl+u": true, "ctrl+d": true, "ctrl+b": true, "ctrl+f": true,
+	"pgup": true, "pgdown": true,
+	"i": true, "h": true, "+": true, "-": true, "C": true,
+}
+
+// Keymap translates pressed keys into the default keys the TUI handles
+type Keymap struct {
+	remap map[string]string
+}
+
+// Keymap builds the key translation table from the keybinding overrides.
+// A rebound action no longer answers to its default key, unless another
+// action has been rebound to it.
+func (c *Config) Keymap() Keymap {
+	remap := make(map[string]string)
+
+	// Free the default keys of rebound actions first, so the new bindings
+	// below can reclaim them
+	for action, key := range c.Keybindings {
+		if defaultKey := DefaultKeybindings[action]; key != defaultKey {
+			remap[defaultKey] = ""
+		}
+	}
+	for action, key := range c.Keybindings {
+		if defaultKey := DefaultKeybindings[action]; key != defaultKey {
+			remap[key] = defaultKey
+		}
+	}
+	return Keymap{remap: remap}
+}
+
+// Resolve returns the default key for a pressed key, or "" if the key has
+// been unbound by an override
+func (k Keymap) Resolve(key string) string {
+	if mapped, ok := k.remap[key]; ok {
+		return mapped
+	}
+	return key
+}
+
+// validateKeybindings checks for unknown actions, empty or reserved keys
+// and keys bound to more than one action
+func validateKeybindings(bindings map[string]string) []string {
+	var problems []string
+
+	effective := make(map[string]string, len(DefaultKeybindings))
+	for action, key := range DefaultKeybindings {
+		effective[action] = key
+	}
+
+	actions := make([]string, 0, len(bindings))
+	for action := range bindings {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+
+	for _, action := range actions {
+		if _, ok := DefaultKeybindings[action]; !ok {
+			problems = append(problems, fmt.Sprintf("keybindings: unknown action %q", action))
+			continue
+		}
+		if bindings[action] == "" {
+			problems = append(problems, fmt.Sprintf("keybindings: action %q has an empty key", action))
+			continue
+		}
+		if reservedKeys[bindings[action]] {
+			problems = append(problems, fmt.Sprintf("keybindings: action %q can't use %q, the key is reserved", action, bindings[action]))
+			continue
+		}
+		effective[action] = bindings[action]
+	}
+
+	owners := make(map[string][]string)
+	for action, key := range effective {
+		owners[key] = append(owners[key], action)
+	}
+	keys := make([]string, 0, len(owners))
+	for key := range owners {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if len(owners[key]) > 1 {
+			sort.Strings(owners[key])
+			problems = append(problems, fmt.Sprintf("keybindings: key %q is bound to %v", key, owners[key]))
+		}
+	}
+
+	return problems
+}
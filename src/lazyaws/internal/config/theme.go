@@ -0,0 +1,70 @@
+package config
+
+import "sort"
+
+// DefaultThemeName is the theme used when none is configured
+const DefaultThemeName = "default"
+
+// Theme holds the 256-color palette codes used by the TUI
+type Theme struct {
+	Accent       string // Titles and the selected row background
+	SelectedText string // Text on the selected row
+	Header       string // Table headers
+	Muted        string // Hints and secondary text
+	Warning      string // Loading and warning messages
+	Error        string // Errors
+	Success      string // Success messages and healthy states
+	Search       string // Active search highlight
+}
+
+// Themes are the built-in color themes, keyed by name
+var Themes = map[string]Theme{
+	"default": {
+		Accent:       "51",
+		SelectedText: "0",
+		Header:       "255",
+		Muted:        "8",
+		Warning:      "3",
+		Error:        "1",
+		Success:      "2",
+		Search:       "201",
+	},
+	"light": {
+		Accent:       "25",
+		SelectedText: "255",
+		Header:       "235",
+		Muted:        "244",
+		Warning:      "130",
+		Error:        "160",
+		Success:      "28",
+		Search:       "127",
+	},
+	"monochrome": {
+		Accent:       "250",
+		SelectedText: "232",
+		Header:       "255",
+		Muted:        "244",
+		Warning:      "252",
+		Error:        "255",
+		Success:      "250",
+		Search:       "255",
+	},
+}
+
+// ThemeNames returns the names of the built-in themes in sorted order
+func ThemeNames() []string {
+	names := make([]string, 0, len(Themes))
+	for name := range Themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetTheme returns the configured theme, or the default theme if unknown
+func (c *Config) GetTheme() Theme {
+	if theme, ok := Themes[c.Theme]; ok {
+		return theme
+	}
+	return Themes[DefaultThemeName]
+}
@@ -0,0 +1,42 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package diff
+
+import "testing"
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{
+			// Example from Hunt and McIlroy, “An Algorithm for Differential File Comparison.”
+			name: "basic",
+			old:  "a\nb\nc\nd\ne\nf\ng\n",
+			new:  "w\na\nb\nx\ny\nz\ne\n",
+			want: "diff old new\n--- old\n+++ new\n@@ -1,7 +1,7 @@\n+w\n a\n b\n-c\n-d\n+x\n+y\n+z\n e\n-f\n-g\n",
+		},
+		{
+			name: "same",
+			old:  "hello world\n",
+			new:  "hello world\n",
+			want: "",
+		},
+		{
+			name: "eof",
+			old:  "a\nb\n",
+			new:  "a\nb",
+			want: "diff old new\n--- old\n+++ new\n@@ -1,2 +1,2 @@\n a\n-b\n+b\n\\ No newline at end of file\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(Diff("old", []byte(tt.old), "new", []byte(tt.new))); got != tt.want {
+				t.Errorf("Diff:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
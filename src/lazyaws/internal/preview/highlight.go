@@ -0,0 +1,301 @@
+package preview
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// language describes enough of a programming language to highlight it a
+// line at a time
+type language struct {
+	name     string
+	comments []string // Line comment markers
+	keywords map[string]bool
+}
+
+func words(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(s) {
+		set[word] = true
+	}
+	return set
+}
+
+var (
+	golang = &language{"go", []string{"//"}, words(`break case chan const continue default defer else
+		fallthrough for func go goto if import interface map package range return select struct
+		switch type var true false nil`)}
+	python = &language{"python", []string{"#"}, words(`and as assert async await break class continue def
+		del elif else except finally for from global if import in is lambda nonlocal not or pass
+		raise return try while with yield True False None`)}
+	javascript = &language{"javascript", []string{"//"}, words(`async await break case catch class const continue
+		default delete do else export extends finally for from function if import in instanceof
+		interface let new of return switch this throw try type typeof var void while yield true
+		false null undefined`)}
+	shell = &language{"shell", []string{"#"}, words(`case do done elif else esac export fi for function if
+		in local return then until while`)}
+	sql = &language{"sql", []string{"--"}, words(`select from where and or not insert into values update
+		set delete create table drop alter join left right inner outer on group by order having
+		limit as distinct union all null is in like between case when then else end with
+		SELECT FROM WHERE AND OR NOT INSERT INTO VALUES UPDATE SET DELETE CREATE TABLE DROP ALTER
+		JOIN LEFT RIGHT INNER OUTER ON GROUP BY ORDER HAVING LIMIT AS DISTINCT UNION ALL NULL IS
+		IN LIKE BETWEEN CASE WHEN THEN ELSE END WITH`)}
+	hcl = &language{"terraform", []string{"#", "//"}, words(`resource data variable output locals module
+		provider terraform for in if true false null`)}
+	java = &language{"java", []string{"//"}, words(`abstract boolean break byte case catch char class
+		continue default do double else enum extends final finally float for if implements import
+		instanceof int interface long new package private protected public return short static
+		super switch this throw throws try void while true false null`)}
+	rust = &language{"rust", []string{"//"}, words(`as async await break const continue crate else enum
+		extern fn for if impl in let loop match mod move mut pub ref return self Self static struct
+		trait type unsafe use where while true false`)}
+	ruby = &language{"ruby", []string{"#"}, words(`begin class def do else elsif end ensure if module next
+		nil raise require rescue return self then unless until when while yield true false`)}
+	clang = &language{"c", []string{"//"}, words(`auto break case char class const continue default
+		delete do double else enum extern float for if int long namespace new private protected
+		public return short signed sizeof static struct switch template this typedef union unsigned
+		using virtual void volatile while true false nullptr NULL`)}
+	toml = &language{"toml", []string{"#"}, words(`true false`)}
+)
+
+// languages maps file extensions to their languages
+var languages = map[string]*language{
+	".go":   golang,
+	".py":   python,
+	".js":   javascript,
+	".mjs":  javascript,
+	".jsx":  javascript,
+	".ts":   javascript,
+	".tsx":  javascript,
+	".sh":   shell,
+	".bash": shell,
+	".zsh":  shell,
+	".sql":  sql,
+	".tf":   hcl,
+	".hcl":  hcl,
+	".java": java,
+	".kt":   java,
+	".rs":   rust,
+	".rb":   ruby,
+	".c":    clang,
+	".h":    clang,
+	".cc":   clang,
+	".cpp":  clang,
+	".hpp":  clang,
+	".cs":   clang,
+	".toml": toml,
+	".ini":  toml,
+	".cfg":  toml,
+}
+
+// highlightCode highlights comments, strings, numbers and keywords. It
+// works a line at a time, so comments and strings spanning lines are only
+// highlighted on their first line.
+func highlightCode(lang *language, line string) Line {
+	var spans Line
+	runes := []rune(line)
+	for i := 0; i < len(runes); {
+		rest := string(runes[i:])
+		r := runes[i]
+		switch {
+		case hasAnyPrefix(rest, lang.comments):
+			return append(spans, Span{Text: rest, Class: Comment})
+		case r == '"' || r == '\'' || r == '`':
+			end := quoteEnd(runes, i)
+			spans = append(spans, Span{Text: string(runes[i:end]), Class: String})
+			i = end
+		case unicode.IsDigit(r):
+			end := i
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || runes[end] == '.' || runes[end] == '_') {
+				end++
+			}
+			spans = append(spans, Span{Text: string(runes[i:end]), Class: Number})
+			i = end
+		case unicode.IsLetter(r) || r == '_':
+			end := i
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || runes[end] == '_') {
+				end++
+			}
+			word := string(runes[i:end])
+			class := Plain
+			if lang.keywords[word] {
+				class = Keyword
+			}
+			spans = append(spans, Span{Text: word, Class: class})
+			i = end
+		case strings.ContainsRune("{}[]()<>=+-*/%!&|^~,;:.?", r):
+			spans = append(spans, Span{Text: string(r), Class: Punct})
+			i++
+		default:
+			spans = append(spans, Span{Text: string(r)})
+			i++
+		}
+	}
+	return merge(spans)
+}
+
+// highlightJSON highlights a line of JSON. Strings followed by a colon are
+// keys.
+func highlightJSON(line string) Line {
+	var spans Line
+	runes := []rune(line)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == '"':
+			end := quoteEnd(runes, i)
+			class := String
+			if strings.HasPrefix(strings.TrimLeft(string(runes[end:]), " "), ":") {
+				class = Key
+			}
+			spans = append(spans, Span{Text: string(runes[i:end]), Class: class})
+			i = end
+		case strings.ContainsRune("{}[],:", r):
+			spans = append(spans, Span{Text: string(r), Class: Punct})
+			i++
+		case r == '-' || unicode.IsDigit(r):
+			end := i + 1
+			for end < len(runes) && strings.ContainsRune("0123456789.eE+-", runes[end]) {
+				end++
+			}
+			spans = append(spans, Span{Text: string(runes[i:end]), Class: Number})
+			i = end
+		case unicode.IsLetter(r):
+			end := i
+			for end < len(runes) && unicode.IsLetter(runes[end]) {
+				end++
+			}
+			word := string(runes[i:end])
+			class := Plain
+			if word == "true" || word == "false" || word == "null" {
+				class = Keyword
+			}
+			spans = append(spans, Span{Text: word, Class: class})
+			i = end
+		default:
+			spans = append(spans, Span{Text: string(r)})
+			i++
+		}
+	}
+	return merge(spans)
+}
+
+// highlightYAML highlights a line of YAML: comments, keys, list markers
+// and scalar values
+func highlightYAML(line string) Line {
+	body := strings.TrimLeft(line, " ")
+	spans := Line{{Text: line[:len(line)-len(body)]}}
+
+	switch {
+	case strings.HasPrefix(body, "#"):
+		return merge(append(spans, Span{Text: body, Class: Comment}))
+	case body == "---" || body == "...":
+		return merge(append(spans, Span{Text: body, Class: Punct}))
+	}
+	for strings.HasPrefix(body, "- ") || body == "-" {
+		spans = append(spans, Span{Text: body[:min(2, len(body))], Class: Punct})
+		body = body[min(2, len(body)):]
+	}
+
+	if key, value, ok := yamlKey(body); ok {
+		spans = append(spans, Span{Text: key, Class: Key}, Span{Text: ":", Class: Punct})
+		body = value
+	}
+
+	value, comment := body, ""
+	if i := strings.Index(body, " #"); i >= 0 && !strings.ContainsAny(body[:i], `"'`) {
+		value, comment = body[:i], body[i:]
+	}
+	trimmed := strings.TrimSpace(value)
+	class := Plain
+	switch {
+	case trimmed == "":
+	case strings.HasPrefix(trimmed, `"`) || strings.HasPrefix(trimmed, "'"):
+		class = String
+	case isNumber(trimmed):
+		class = Number
+	case yamlKeywords[trimmed]:
+		class = Keyword
+	case strings.HasPrefix(trimmed, "&") || strings.HasPrefix(trimmed, "*") || strings.HasPrefix(trimmed, "!") || trimmed == "|" || trimmed == ">":
+		class = Punct
+	}
+	spans = append(spans, Span{Text: value, Class: class})
+	if comment != "" {
+		spans = append(spans, Span{Text: comment, Class: Comment})
+	}
+	return merge(spans)
+}
+
+var yamlKeywords = words("true false null yes no on off True False Null TRUE FALSE NULL ~")
+
+// yamlKey splits "key: value" into the key and what follows the colon
+func yamlKey(s string) (string, string, bool) {
+	if strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "'") {
+		end := quoteEnd([]rune(s), 0)
+		quoted := string([]rune(s)[:end])
+		rest := s[len(quoted):]
+		if strings.HasPrefix(rest, ":") {
+			return quoted, rest[1:], true
+		}
+		return "", "", false
+	}
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '#', '{', '[', '"', '\'':
+			return "", "", false
+		case ':':
+			if i > 0 && (i == len(s)-1 || s[i+1] == ' ') {
+				return s[:i], s[i+1:], true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// quoteEnd returns the index after the string starting at runes[start],
+// or the end of the line if it isn't closed
+func quoteEnd(runes []rune, start int) int {
+	quote := runes[start]
+	for i := start + 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			return i + 1
+		}
+	}
+	return len(runes)
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return err == nil && strings.TrimSpace(s) != ""
+}
+
+// merge joins adjacent spans of the same class and drops empty ones
+func merge(spans Line) Line {
+	var out Line
+	for _, span := range spans {
+		if span.Text == "" {
+			continue
+		}
+		if n := len(out); n > 0 && out[n-1].Class == span.Class {
+			out[n-1].Text += span.Text
+			continue
+		}
+		out = append(out, span)
+	}
+	return out
+}
@@ -0,0 +1,625 @@
+package preview
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fuziontech/lazyaws/internal/zstd"
+)
+
+const (
+	parquetMagic   = "PAR1"
+	parquetRows    = 20       // Rows shown under the schema
+	pageHeaderSize = 1 << 10  // Bytes first read for a page header
+	maxPageSize    = 64 << 20 // Largest page read for the preview
+)
+
+var (
+	physicalTypes = []string{"BOOLEAN", "INT32", "INT64", "INT96", "FLOAT", "DOUBLE", "BYTE_ARRAY", "FIXED_LEN_BYTE_ARRAY"}
+	repetitions   = []string{"REQUIRED", "OPTIONAL", "REPEATED"}
+	codecs        = []string{"UNCOMPRESSED", "SNAPPY", "GZIP", "LZO", "BROTLI", "LZ4", "ZSTD", "LZ4_RAW"}
+	encodings     = []string{"PLAIN", "GROUP_VAR_INT", "PLAIN_DICTIONARY", "RLE", "BIT_PACKED", "DELTA_BINARY_PACKED",
+		"DELTA_LENGTH_BYTE_ARRAY", "DELTA_BYTE_ARRAY", "RLE_DICTIONARY", "BYTE_STREAM_SPLIT"}
+
+	// Logical types by their field in the LogicalType union
+	logicalTypes = map[int16]string{1: "STRING", 2: "MAP", 3: "LIST", 4: "ENUM", 5: "DECIMAL", 6: "DATE", 7: "TIME",
+		8: "TIMESTAMP", 10: "INTEGER", 11: "NULL", 12: "JSON", 13: "BSON", 14: "UUID", 15: "FLOAT16"}
+	// Converted types, the older form of logical types
+	convertedTypes = map[int64]string{0: "STRING", 1: "MAP", 2: "MAP", 3: "LIST", 4: "ENUM", 5: "DECIMAL", 6: "DATE",
+		7: "TIME", 8: "TIME", 9: "TIMESTAMP", 10: "TIMESTAMP", 19: "JSON", 20: "BSON"}
+	timeUnits = map[int16]string{1: "MILLIS", 2: "MICROS", 3: "NANOS"}
+)
+
+// enumName returns the name of an enum value, or its number if it's unknown
+func enumName(names []string, v int64) string {
+	if v >= 0 && v < int64(len(names)) {
+		return names[v]
+	}
+	return strconv.FormatInt(v, 10)
+}
+
+// schemaNode is a field of a Parquet schema
+type schemaNode struct {
+	name       string
+	physical   int64 // -1 for groups
+	typeLength int64
+	repetition int64
+	annotation string // Logical type, e.g. STRING or TIMESTAMP
+	unit       string // Unit of TIME and TIMESTAMP
+	precision  int64
+	scale      int64
+	children   []*schemaNode
+
+	path           []string
+	maxDef, maxRep int // Levels of nesting that can be null or repeated
+}
+
+// typeName describes the node's type, e.g. "INT64 TIMESTAMP(MICROS)"
+func (n *schemaNode) typeName() string {
+	typ := "group"
+	if n.physical >= 0 {
+		typ = enumName(physicalTypes, n.physical)
+	}
+	switch n.annotation {
+	case "":
+		return typ
+	case "DECIMAL":
+		return fmt.Sprintf("%s DECIMAL(%d,%d)", typ, n.precision, n.scale)
+	case "TIME", "TIMESTAMP":
+		return fmt.Sprintf("%s %s(%s)", typ, n.annotation, n.unit)
+	}
+	return typ + " " + n.annotation
+}
+
+// renderParquet renders the schema and first rows of a Parquet file,
+// reading only its footer and the first pages of its first row group
+func renderParquet(r io.ReaderAt, size int64) ([]Line, error) {
+	if size < 12 {
+		return nil, errors.New("file is too small to be Parquet")
+	}
+	tail := make([]byte, 8)
+	if _, err := r.ReadAt(tail, size-8); err != nil && err != io.EOF {
+		return nil, err
+	}
+	footerLen := int64(binary.LittleEndian.Uint32(tail))
+	if string(tail[4:]) != parquetMagic || footerLen > size-12 {
+		return nil, errors.New("malformed Parquet footer")
+	}
+	footer := make([]byte, footerLen)
+	if _, err := r.ReadAt(footer, size-8-footerLen); err != nil && err != io.EOF {
+		return nil, err
+	}
+	meta, err := (&thriftReader{data: footer}).readStruct()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Parquet metadata: %w", err)
+	}
+	root, err := parseSchema(meta.list(2))
+	if err != nil {
+		return nil, err
+	}
+
+	rowGroups := meta.list(4)
+	summary := fmt.Sprintf("Parquet, %d rows in %d row groups", meta.int(3), len(rowGroups))
+	if createdBy := meta.str(6); createdBy != "" {
+		summary += ", created by " + cleanText(createdBy)
+	}
+	lines := []Line{{{Text: summary, Class: Muted}}, nil, {{Text: "Schema", Class: Key}}}
+	var leaves, nested []*schemaNode
+	var walk func(n *schemaNode, depth int)
+	walk = func(n *schemaNode, depth int) {
+		lines = append(lines, Line{
+			{Text: strings.Repeat("  ", depth)},
+			{Text: cleanText(n.name), Class: Key},
+			{Text: "  "},
+			{Text: n.typeName(), Class: Keyword},
+			{Text: "  " + enumName(repetitions, n.repetition), Class: Muted},
+		})
+		for _, child := range n.children {
+			walk(child, depth+1)
+		}
+		if len(n.children) == 0 && n.maxRep == 0 {
+			leaves = append(leaves, n)
+		} else if len(n.children) == 0 {
+			nested = append(nested, n)
+		}
+	}
+	for _, child := range root.children {
+		walk(child, 1)
+	}
+
+	if len(rowGroups) == 0 || len(leaves) == 0 {
+		return lines, nil
+	}
+	group, _ := rowGroups[0].(thriftStruct)
+	rows := int(min(group.int(3), parquetRows))
+	chunks := make(map[string]thriftStruct)
+	for _, c := range group.list(1) {
+		chunk, _ := c.(thriftStruct)
+		var path []string
+		for _, p := range chunk.sub(3).list(3) {
+			part, _ := p.([]byte)
+			path = append(path, string(part))
+		}
+		chunks[strings.Join(path, ".")] = chunk.sub(3)
+	}
+
+	header := make([]string, len(leaves))
+	table := make([][]string, rows)
+	for i := range table {
+		table[i] = make([]string, len(leaves))
+	}
+	var problems []Line
+	for i, leaf := range leaves {
+		header[i] = strings.Join(leaf.path, ".")
+		chunk, ok := chunks[header[i]]
+		var values []*string
+		if !ok {
+			err = errors.New("no column chunk")
+		} else {
+			values, err = readColumn(r, size, leaf, chunk, rows)
+		}
+		if err != nil {
+			problems = append(problems, Line{{Text: fmt.Sprintf("%s: %v", header[i], err), Class: Muted}})
+		}
+		for row := range table {
+			switch {
+			case row >= len(values):
+				table[row][i] = "?"
+			case values[row] == nil:
+				table[row][i] = "null"
+			default:
+				table[row][i] = *values[row]
+			}
+		}
+	}
+
+	lines = append(lines, nil, Line{{Text: fmt.Sprintf("First %d rows", rows), Class: Key}})
+	lines = append(lines, renderTable(header, table)...)
+	if len(nested) > 0 {
+		var paths []string
+		for _, n := range nested {
+			paths = append(paths, strings.Join(n.path, "."))
+		}
+		problems = append(problems, Line{{Text: "Repeated columns aren't shown: " + strings.Join(paths, ", "), Class: Muted}})
+	}
+	if len(problems) > 0 {
+		lines = append(append(lines, nil), problems...)
+	}
+	return lines, nil
+}
+
+// parseSchema builds the schema tree from its flattened elements
+func parseSchema(elements []any) (*schemaNode, error) {
+	next := 0
+	var build func(depth, maxDef, maxRep int, path []string) (*schemaNode, error)
+	build = func(depth, maxDef, maxRep int, path []string) (*schemaNode, error) {
+		if next >= len(elements) || depth > 64 {
+			return nil, errors.New("malformed Parquet schema")
+		}
+		e, _ := elements[next].(thriftStruct)
+		next++
+
+		n := &schemaNode{name: e.str(4), physical: -1, typeLength: e.int(2), repetition: e.int(3)}
+		if e.has(1) {
+			n.physical = e.int(1)
+		}
+		if logical := e.sub(10); len(logical) > 0 {
+			for id, v := range logical {
+				n.annotation = logicalTypes[id]
+				params, _ := v.(thriftStruct)
+				switch id {
+				case 5: // DECIMAL
+					n.scale, n.precision = params.int(1), params.int(2)
+				case 7, 8: // TIME and TIMESTAMP
+					for unit := range params.sub(2) {
+						n.unit = timeUnits[unit]
+					}
+				}
+			}
+		} else if e.has(6) {
+			n.annotation = convertedTypes[e.int(6)]
+			n.scale, n.precision = e.int(7), e.int(8)
+			switch e.int(6) {
+			case 7, 9:
+				n.unit = "MILLIS"
+			case 8, 10:
+				n.unit = "MICROS"
+			}
+		}
+
+		if depth > 0 {
+			switch n.repetition {
+			case 1:
+				maxDef++
+			case 2:
+				maxDef++
+				maxRep++
+			}
+			path = append(path[:len(path):len(path)], n.name)
+		}
+		n.path, n.maxDef, n.maxRep = path, maxDef, maxRep
+		for i := int64(0); i < e.int(5); i++ {
+			child, err := build(depth+1, maxDef, maxRep, path)
+			if err != nil {
+				return nil, err
+			}
+			n.children = append(n.children, child)
+		}
+		return n, nil
+	}
+	return build(0, 0, 0, nil)
+}
+
+// readColumn reads the first values of a flat column, nil for nulls. It
+// reads the column's pages one at a time and stops once it has enough.
+func readColumn(r io.ReaderAt, size int64, leaf *schemaNode, chunk thriftStruct, limit int) ([]*string, error) {
+	codec := chunk.int(4)
+	offset := chunk.int(9)
+	if dictOffset := chunk.int(11); dictOffset > 0 && dictOffset < offset {
+		offset = dictOffset
+	}
+	end := min(offset+chunk.int(7), size)
+
+	var dict []string
+	var values []*string
+	for offset < end && len(values) < limit {
+		header, headerLen, err := readPageHeader(r, offset, end)
+		if err != nil {
+			return values, err
+		}
+		compressedSize, uncompressedSize := header.int(3), header.int(2)
+		if compressedSize < 0 || compressedSize > maxPageSize || uncompressedSize < 0 || uncompressedSize > maxPageSize {
+			return values, errors.New("page is too large to preview")
+		}
+		body := make([]byte, compressedSize)
+		if _, err := r.ReadAt(body, offset+headerLen); err != nil && err != io.EOF {
+			return values, err
+		}
+		offset += headerLen + compressedSize
+
+		switch header.int(1) {
+		case 2: // Dictionary page
+			data, err := decompressPage(codec, body, uncompressedSize)
+			if err != nil {
+				return values, err
+			}
+			if dict, err = decodePlain(leaf, data, int(header.sub(7).int(1))); err != nil {
+				return values, err
+			}
+		case 0: // Data page
+			data, err := decompressPage(codec, body, uncompressedSize)
+			if err != nil {
+				return values, err
+			}
+			page := header.sub(5)
+			var levels []byte
+			if leaf.maxDef > 0 {
+				if len(data) < 4 {
+					return values, errors.New("truncated page")
+				}
+				n := int(binary.LittleEndian.Uint32(data))
+				if n > len(data)-4 {
+					return values, errors.New("truncated page")
+				}
+				levels, data = data[4:4+n], data[4+n:]
+			}
+			pageValues, err := decodePage(leaf, page.int(2), int(page.int(1)), levels, data, dict)
+			values = append(values, pageValues...)
+			if err != nil {
+				return values, err
+			}
+		case 3: // Data page v2, whose levels are never compressed
+			page := header.sub(8)
+			repLen, defLen := page.int(6), page.int(5)
+			if repLen < 0 || defLen < 0 || repLen+defLen > int64(len(body)) {
+				return values, errors.New("truncated page")
+			}
+			levels, data := body[repLen:repLen+defLen], body[repLen+defLen:]
+			if !page.has(7) || page.bool(7) {
+				if data, err = decompressPage(codec, data, uncompressedSize-repLen-defLen); err != nil {
+					return values, err
+				}
+			}
+			pageValues, err := decodePage(leaf, page.int(4), int(page.int(1)), levels, data, dict)
+			values = append(values, pageValues...)
+			if err != nil {
+				return values, err
+			}
+		}
+	}
+	if len(values) > limit {
+		values = values[:limit]
+	}
+	return values, nil
+}
+
+// readPageHeader reads the page header at offset, reading more of the file
+// if the header doesn't fit in what was read first
+func readPageHeader(r io.ReaderAt, offset, end int64) (thriftStruct, int64, error) {
+	for n := int64(pageHeaderSize); ; n *= 16 {
+		n = min(n, end-offset)
+		buf := make([]byte, n)
+		if _, err := r.ReadAt(buf, offset); err != nil && err != io.EOF {
+			return nil, 0, err
+		}
+		reader := &thriftReader{data: buf}
+		header, err := reader.readStruct()
+		if err == nil {
+			return header, int64(reader.pos), nil
+		}
+		if n >= end-offset || n >= maxPageSize {
+			return nil, 0, fmt.Errorf("failed to read page header: %w", err)
+		}
+	}
+}
+
+// decompressPage decompresses a page with the column's codec
+func decompressPage(codec int64, data []byte, size int64) ([]byte, error) {
+	switch codec {
+	case 0:
+		return data, nil
+	case 1:
+		return snappyDecode(data)
+	case 2:
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(io.LimitReader(gz, size))
+	case 6:
+		return io.ReadAll(io.LimitReader(zstd.NewReader(bytes.NewReader(data)), size))
+	}
+	return nil, fmt.Errorf("%s compression isn't supported", enumName(codecs, codec))
+}
+
+// decodePage decodes the values of a data page, placing nulls where the
+// definition levels say values are missing
+func decodePage(leaf *schemaNode, encoding int64, count int, levels, data []byte, dict []string) ([]*string, error) {
+	present := count
+	var defs []int
+	if leaf.maxDef > 0 {
+		var err error
+		if defs, err = decodeHybrid(levels, bits.Len(uint(leaf.maxDef)), count); err != nil {
+			return nil, err
+		}
+		present = 0
+		for _, def := range defs {
+			if def == leaf.maxDef {
+				present++
+			}
+		}
+	}
+
+	var decoded []string
+	var err error
+	switch encoding {
+	case 0: // PLAIN
+		decoded, err = decodePlain(leaf, data, present)
+	case 2, 8: // PLAIN_DICTIONARY and RLE_DICTIONARY
+		if dict == nil || len(data) == 0 {
+			return nil, errors.New("dictionary page missing")
+		}
+		var indexes []int
+		if indexes, err = decodeHybrid(data[1:], int(data[0]), present); err != nil {
+			return nil, err
+		}
+		for _, i := range indexes {
+			if i >= len(dict) {
+				return nil, errors.New("dictionary index out of range")
+			}
+			decoded = append(decoded, dict[i])
+		}
+	default:
+		return nil, fmt.Errorf("%s encoding isn't supported", enumName(encodings, encoding))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]*string, 0, count)
+	for i := 0; i < count; i++ {
+		if defs != nil && defs[i] != leaf.maxDef {
+			values = append(values, nil)
+			continue
+		}
+		values = append(values, &decoded[0])
+		decoded = decoded[1:]
+	}
+	return values, nil
+}
+
+// decodeHybrid decodes count values of the RLE and bit-packing hybrid
+// encoding Parquet uses for levels and dictionary indexes
+func decodeHybrid(data []byte, width, count int) ([]int, error) {
+	if width > 32 {
+		return nil, errors.New("malformed RLE data")
+	}
+	out := make([]int, 0, count)
+	for len(out) < count {
+		header, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, errors.New("truncated RLE data")
+		}
+		data = data[n:]
+		if header&1 == 1 { // Groups of 8 bit-packed values
+			values := int(header>>1) * 8
+			size := values * width / 8
+			if size > len(data) {
+				return nil, errors.New("truncated RLE data")
+			}
+			for i := 0; i < values; i++ {
+				v := 0
+				for b := 0; b < width; b++ {
+					bit := i*width + b
+					v |= int(data[bit/8]>>(bit%8)&1) << b
+				}
+				out = append(out, v)
+			}
+			data = data[size:]
+		} else { // A run of one value
+			size := (width + 7) / 8
+			if size > len(data) {
+				return nil, errors.New("truncated RLE data")
+			}
+			v := 0
+			for i := size - 1; i >= 0; i-- {
+				v = v<<8 | int(data[i])
+			}
+			for run := min(header>>1, uint64(count-len(out))); run > 0; run-- {
+				out = append(out, v)
+			}
+			data = data[size:]
+		}
+	}
+	return out[:count], nil
+}
+
+// decodePlain decodes count values in the PLAIN encoding and formats them
+func decodePlain(leaf *schemaNode, data []byte, count int) ([]string, error) {
+	values := make([]string, 0, count)
+	take := func(n int) ([]byte, error) {
+		if n < 0 || n > len(data) {
+			return nil, errors.New("truncated page")
+		}
+		b := data[:n]
+		data = data[n:]
+		return b, nil
+	}
+
+	for i := 0; i < count; i++ {
+		var value string
+		switch leaf.physical {
+		case 0: // BOOLEAN, packed 8 to a byte
+			if i/8 >= len(data) {
+				return nil, errors.New("truncated page")
+			}
+			value = strconv.FormatBool(data[i/8]>>(i%8)&1 == 1)
+		case 1, 2: // INT32 and INT64
+			b, err := take(4 * int(leaf.physical))
+			if err != nil {
+				return nil, err
+			}
+			if len(b) == 4 {
+				value = formatInt(leaf, int64(int32(binary.LittleEndian.Uint32(b))))
+			} else {
+				value = formatInt(leaf, int64(binary.LittleEndian.Uint64(b)))
+			}
+		case 3: // INT96, a legacy timestamp of nanoseconds and a Julian day
+			b, err := take(12)
+			if err != nil {
+				return nil, err
+			}
+			nanos := int64(binary.LittleEndian.Uint64(b))
+			day := int64(binary.LittleEndian.Uint32(b[8:]))
+			value = time.Unix((day-2440588)*86400, nanos).UTC().Format(time.RFC3339Nano)
+		case 4: // FLOAT
+			b, err := take(4)
+			if err != nil {
+				return nil, err
+			}
+			value = strconv.FormatFloat(float64(math.Float32frombits(binary.LittleEndian.Uint32(b))), 'g', -1, 32)
+		case 5: // DOUBLE
+			b, err := take(8)
+			if err != nil {
+				return nil, err
+			}
+			value = strconv.FormatFloat(math.Float64frombits(binary.LittleEndian.Uint64(b)), 'g', -1, 64)
+		case 6: // BYTE_ARRAY, each prefixed with its length
+			b, err := take(4)
+			if err != nil {
+				return nil, err
+			}
+			if b, err = take(int(binary.LittleEndian.Uint32(b))); err != nil {
+				return nil, err
+			}
+			value = formatBytes(leaf, b)
+		case 7: // FIXED_LEN_BYTE_ARRAY
+			b, err := take(int(leaf.typeLength))
+			if err != nil {
+				return nil, err
+			}
+			value = formatBytes(leaf, b)
+		default:
+			return nil, fmt.Errorf("unknown type %d", leaf.physical)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// formatInt formats an integer as its logical type
+func formatInt(leaf *schemaNode, v int64) string {
+	switch leaf.annotation {
+	case "DATE":
+		return time.Unix(v*86400, 0).UTC().Format("2006-01-02")
+	case "TIMESTAMP":
+		var t time.Time
+		switch leaf.unit {
+		case "MILLIS":
+			t = time.UnixMilli(v)
+		case "MICROS":
+			t = time.UnixMicro(v)
+		default:
+			t = time.Unix(0, v)
+		}
+		return t.UTC().Format(time.RFC3339Nano)
+	case "DECIMAL":
+		return formatDecimal(big.NewInt(v), leaf.scale)
+	}
+	return strconv.FormatInt(v, 10)
+}
+
+// formatBytes formats a byte array as its logical type, or as text if it
+// is text, or else in hex
+func formatBytes(leaf *schemaNode, b []byte) string {
+	switch leaf.annotation {
+	case "DECIMAL":
+		v := new(big.Int).SetBytes(b)
+		if len(b) > 0 && b[0]&0x80 != 0 {
+			// Two's complement
+			v.Sub(v, new(big.Int).Lsh(big.NewInt(1), uint(len(b)*8)))
+		}
+		return formatDecimal(v, leaf.scale)
+	case "UUID":
+		if len(b) == 16 {
+			h := hex.EncodeToString(b)
+			return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+		}
+	}
+	if leaf.annotation == "STRING" || leaf.annotation == "ENUM" || leaf.annotation == "JSON" || (utf8.Valid(b) && !isBinary(b)) {
+		return cleanText(string(b))
+	}
+	if len(b) > 32 {
+		return "0x" + hex.EncodeToString(b[:32]) + "…"
+	}
+	return "0x" + hex.EncodeToString(b)
+}
+
+// formatDecimal places the decimal point scale digits from the right
+func formatDecimal(v *big.Int, scale int64) string {
+	digits := new(big.Int).Abs(v).String()
+	if scale <= 0 {
+		return v.String()
+	}
+	if int64(len(digits)) <= scale {
+		digits = strings.Repeat("0", int(scale)-len(digits)+1) + digits
+	}
+	point := len(digits) - int(scale)
+	s := digits[:point] + "." + digits[point:]
+	if v.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
@@ -0,0 +1,217 @@
+package preview
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// thriftWriter encodes the thrift compact protocol for test files
+type thriftWriter struct {
+	bytes.Buffer
+	last int16
+}
+
+func (w *thriftWriter) field(id int16, typ byte) {
+	if delta := id - w.last; delta > 0 && delta <= 15 {
+		w.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		w.WriteByte(typ)
+		w.varint(int64(id))
+	}
+	w.last = id
+}
+
+func (w *thriftWriter) varint(v int64) {
+	w.Write(binary.AppendUvarint(nil, uint64(v<<1^v>>63)))
+}
+
+func (w *thriftWriter) int(id int16, v int64) {
+	w.field(id, compactI64)
+	w.varint(v)
+}
+
+func (w *thriftWriter) str(id int16, s string) {
+	w.field(id, compactBinary)
+	w.Write(binary.AppendUvarint(nil, uint64(len(s))))
+	w.WriteString(s)
+}
+
+func (w *thriftWriter) strList(id int16, items ...string) {
+	w.field(id, compactList)
+	w.WriteByte(byte(len(items))<<4 | compactBinary)
+	for _, s := range items {
+		w.Write(binary.AppendUvarint(nil, uint64(len(s))))
+		w.WriteString(s)
+	}
+}
+
+func (w *thriftWriter) body(fn func()) {
+	saved := w.last
+	w.last = 0
+	fn()
+	w.WriteByte(0)
+	w.last = saved
+}
+
+func (w *thriftWriter) sub(id int16, fn func()) {
+	w.field(id, compactStruct)
+	w.body(fn)
+}
+
+func (w *thriftWriter) structs(id int16, fns ...func()) {
+	w.field(id, compactList)
+	w.WriteByte(byte(len(fns))<<4 | compactStruct)
+	for _, fn := range fns {
+		w.body(fn)
+	}
+}
+
+// snappyLiteral encodes data as a snappy block of one literal
+func snappyLiteral(data []byte) []byte {
+	out := binary.AppendUvarint(nil, uint64(len(data)))
+	out = append(out, byte(len(data)-1)<<2)
+	return append(out, data...)
+}
+
+// pageHeader encodes the header of a data page, or of a dictionary page
+// if typ is 2
+func pageHeader(typ, encoding int64, uncompressed, compressed, values int) []byte {
+	var w thriftWriter
+	w.body(func() {
+		w.int(1, typ)
+		w.int(2, int64(uncompressed))
+		w.int(3, int64(compressed))
+		if typ == 2 {
+			w.sub(7, func() { w.int(1, int64(values)); w.int(2, encoding) })
+		} else {
+			w.sub(5, func() {
+				w.int(1, int64(values))
+				w.int(2, encoding)
+				w.int(3, 3)
+				w.int(4, 3)
+			})
+		}
+	})
+	return w.Bytes()
+}
+
+// testParquet writes a file with a required INT64 column of 1, 2 and 3, an
+// optional dictionary encoded and snappy compressed string column of
+// "alpha", null and "beta", and a list column
+func testParquet() []byte {
+	var file bytes.Buffer
+	file.WriteString(parquetMagic)
+
+	idOffset := file.Len()
+	ids := binary.LittleEndian.AppendUint64(nil, 1)
+	ids = binary.LittleEndian.AppendUint64(ids, 2)
+	ids = binary.LittleEndian.AppendUint64(ids, 3)
+	file.Write(pageHeader(0, 0, len(ids), len(ids), 3))
+	file.Write(ids)
+	idSize := file.Len() - idOffset
+
+	nameOffset := file.Len()
+	dict := []byte("\x05\x00\x00\x00alpha\x04\x00\x00\x00beta")
+	compressed := snappyLiteral(dict)
+	file.Write(pageHeader(2, 0, len(dict), len(compressed), 2))
+	file.Write(compressed)
+	dataOffset := file.Len()
+	// Definition levels 1, 0, 1 and dictionary indexes 0, 1, each one
+	// bit-packed group
+	page := []byte{2, 0, 0, 0, 3, 0b101, 1, 3, 0b10}
+	compressed = snappyLiteral(page)
+	file.Write(pageHeader(0, 8, len(page), len(compressed), 3))
+	file.Write(compressed)
+	nameSize := file.Len() - nameOffset
+
+	var w thriftWriter
+	w.body(func() {
+		w.int(1, 1)
+		w.structs(2,
+			func() { w.str(4, "schema"); w.int(5, 3) },
+			func() { w.int(1, 2); w.int(3, 0); w.str(4, "id") },
+			func() { w.int(1, 6); w.int(3, 1); w.str(4, "name"); w.int(6, 0) },
+			func() { w.int(3, 1); w.str(4, "tags"); w.int(5, 1); w.int(6, 3) },
+			func() { w.int(1, 6); w.int(3, 2); w.str(4, "element") },
+		)
+		w.int(3, 3)
+		w.structs(4, func() {
+			w.structs(1,
+				func() {
+					w.int(2, int64(idOffset))
+					w.sub(3, func() {
+						w.int(1, 2)
+						w.strList(3, "id")
+						w.int(4, 0)
+						w.int(5, 3)
+						w.int(6, int64(idSize))
+						w.int(7, int64(idSize))
+						w.int(9, int64(idOffset))
+					})
+				},
+				func() {
+					w.int(2, int64(nameOffset))
+					w.sub(3, func() {
+						w.int(1, 6)
+						w.strList(3, "name")
+						w.int(4, 1)
+						w.int(5, 3)
+						w.int(6, int64(nameSize))
+						w.int(7, int64(nameSize))
+						w.int(9, int64(dataOffset))
+						w.int(11, int64(nameOffset))
+					})
+				},
+			)
+			w.int(2, int64(file.Len()))
+			w.int(3, 3)
+		})
+		w.str(6, "lazyaws test")
+	})
+
+	file.Write(w.Bytes())
+	file.Write(binary.LittleEndian.AppendUint32(nil, uint32(w.Len())))
+	file.WriteString(parquetMagic)
+	return file.Bytes()
+}
+
+func TestParquetSchemaAndRows(t *testing.T) {
+	obj, _ := open(testParquet(), "part-0000.parquet", "")
+	page, err := obj.Page(0)
+	if err != nil {
+		t.Fatalf("Page returned error: %v", err)
+	}
+	text := pageText(page)
+	for _, want := range []string{
+		"Parquet, 3 rows in 1 row groups, created by lazyaws test",
+		"  id  INT64  REQUIRED",
+		"  name  BYTE_ARRAY STRING  OPTIONAL",
+		"  tags  group LIST  OPTIONAL",
+		"    element  BYTE_ARRAY  REPEATED",
+		"First 3 rows",
+		"1   alpha",
+		"2   null",
+		"3   beta",
+		"Repeated columns aren't shown: tags.element",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected the preview to contain %q, got:\n%s", want, text)
+		}
+	}
+	if page.Format != "parquet" {
+		t.Errorf("Expected parquet, got %q", page.Format)
+	}
+}
+
+func TestSnappyDecode(t *testing.T) {
+	// A literal "abc" then a copy of 9 bytes from 3 back
+	got, err := snappyDecode([]byte{12, 2 << 2, 'a', 'b', 'c', 1 | 5<<2, 3})
+	if err != nil || string(got) != "abcabcabcabc" {
+		t.Errorf("Expected abcabcabcabc, got %q (%v)", got, err)
+	}
+	if _, err := snappyDecode([]byte{12, 2 << 2, 'a', 'b', 'c', 1 | 5<<2, 9}); err == nil {
+		t.Error("Expected a copy from before the start to fail")
+	}
+}
@@ -0,0 +1,668 @@
+// Package preview renders objects for reading in the terminal, a page at a
+// time: text with syntax highlighting, JSON pretty-printed, CSV as a table,
+// Parquet as its schema and first rows, and anything else as a hex dump.
+// gzip and zstd content is decompressed on the fly, continuing from the
+// previous page when paging forward. Objects are read through an
+// io.ReaderAt, so only the bytes a page shows are fetched.
+package preview
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/fuziontech/lazyaws/internal/zstd"
+)
+
+const (
+	PageLines     = 200      // Lines shown per page
+	MaxLineWidth  = 200      // Longer lines are cut off
+	chunkSize     = 64 << 10 // Bytes read for a page of text
+	sniffSize     = 4 << 10  // Bytes read to detect the format
+	documentLimit = 1 << 20  // Largest JSON document pretty-printed as a whole
+	streamBuffer  = 1 << 20  // Read size when decompressing
+	hexWidth      = 16       // Bytes per hex dump line
+	maxCellWidth  = 30       // Widest CSV column
+)
+
+// Class is what a span of a highlighted line is
+type Class int
+
+const (
+	Plain Class = iota
+	Key         // JSON and YAML keys, table headers
+	String
+	Number
+	Keyword // Language keywords and true/false/null
+	Comment
+	Punct
+	Muted // Offsets, separators and other decoration
+)
+
+// Span is a run of text of one class
+type Span struct {
+	Text  string
+	Class Class
+}
+
+// Line is a highlighted line
+type Line []Span
+
+// Text returns the line without highlighting
+func (l Line) Text() string {
+	var b strings.Builder
+	for _, span := range l {
+		b.WriteString(span.Text)
+	}
+	return b.String()
+}
+
+// Page is a window of an object's rendered content
+type Page struct {
+	Format string // What the content was rendered as, e.g. "json (gzip)"
+	Lines  []Line
+	Unit   string // "bytes" of the decompressed content or rendered "lines"
+	Start  int64  // Position of the page in Unit
+	End    int64  // Position after the page
+	Total  int64  // Size of the content in Unit, or -1 if unknown
+	Next   int64  // Start of the next page, or -1 after the last one
+}
+
+// Object previews one object. It is safe for concurrent use.
+type Object struct {
+	r           io.ReaderAt
+	size        int64
+	name        string
+	contentType string
+
+	mu          sync.Mutex
+	detected    bool
+	compression string // "", "gzip" or "zstd"
+	format      string
+	language    *language
+	document    []Line   // Formats rendered as a whole
+	csvHeader   []string // Header row of CSV and TSV files
+	stream      *contentStream
+}
+
+// contentStream is a decompressor left where the last read of compressed
+// content stopped, so paging forward continues from there instead of
+// decompressing from the start again
+type contentStream struct {
+	r    io.Reader
+	pos  int64  // Position of data in the content
+	data []byte // Content read from pos, kept for reads that start in it
+	eof  bool
+}
+
+// New returns a preview of size bytes read from r. The name and content
+// type help tell the format; the content decides when they don't.
+func New(r io.ReaderAt, size int64, name, contentType string) *Object {
+	return &Object{r: r, size: size, name: name, contentType: contentType}
+}
+
+// Page renders the page starting at pos, which is 0 or the Next of an
+// earlier page
+func (o *Object) Page(pos int64) (*Page, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if !o.detected {
+		if err := o.detect(); err != nil {
+			return nil, err
+		}
+		o.detected = true
+	}
+
+	var page *Page
+	var err error
+	switch {
+	case o.document != nil:
+		page = o.documentPage(pos)
+	case o.format == "hex":
+		page, err = o.hexPage(pos)
+	case o.format == "csv" || o.format == "tsv":
+		page, err = o.csvPage(pos)
+	default:
+		page, err = o.textPage(pos)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	page.Format = o.format
+	if o.language != nil {
+		page.Format = o.language.name
+	}
+	if o.compression != "" {
+		page.Format += " (" + o.compression + ")"
+	}
+	for i, line := range page.Lines {
+		page.Lines[i] = truncateLine(line, MaxLineWidth)
+	}
+	return page, nil
+}
+
+// detect works out the compression and format of the object
+func (o *Object) detect() error {
+	head, err := o.readRaw(0, sniffSize)
+	if err != nil {
+		return err
+	}
+
+	name := strings.ToLower(o.name)
+	switch {
+	case bytes.HasPrefix(head, []byte{0x1f, 0x8b}):
+		o.compression = "gzip"
+	case bytes.HasPrefix(head, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		o.compression = "zstd"
+	}
+	if o.compression != "" {
+		for _, ext := range []string{".gz", ".gzip", ".zst", ".zstd"} {
+			name = strings.TrimSuffix(name, ext)
+		}
+		head, _, err = o.readContent(0, sniffSize)
+		if err != nil {
+			return err
+		}
+	}
+
+	if o.compression == "" && bytes.HasPrefix(head, []byte(parquetMagic)) {
+		o.format = "parquet"
+		o.document, err = renderParquet(o.r, o.size)
+		return err
+	}
+
+	o.format = formatFor(name, o.contentType)
+	if o.format == "code" {
+		o.language = languages[path.Ext(name)]
+	}
+	if o.format == "" {
+		switch trimmed := bytes.TrimSpace(head); {
+		case isBinary(head):
+			o.format = "hex"
+		case bytes.HasPrefix(trimmed, []byte("{")) || bytes.HasPrefix(trimmed, []byte("[")):
+			o.format = "json"
+		default:
+			o.format = "text"
+		}
+	}
+	if o.format != "hex" && isBinary(head) {
+		o.format, o.language = "hex", nil
+	}
+
+	if o.format == "json" {
+		o.document = o.prettyJSON()
+	}
+	return nil
+}
+
+// formatFor tells the format from a file name or content type, or returns
+// "" if neither says
+func formatFor(name, contentType string) string {
+	ext := path.Ext(name)
+	switch ext {
+	case ".json", ".geojson":
+		return "json"
+	case ".jsonl", ".ndjson":
+		return "jsonl"
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".csv":
+		return "csv"
+	case ".tsv":
+		return "tsv"
+	case ".txt", ".log", ".md":
+		return "text"
+	}
+	if languages[ext] != nil {
+		return "code"
+	}
+
+	mediaType, _, _ := strings.Cut(strings.ToLower(contentType), ";")
+	switch strings.TrimSpace(mediaType) {
+	case "application/json":
+		return "json"
+	case "application/x-ndjson":
+		return "jsonl"
+	case "application/yaml", "application/x-yaml", "text/yaml":
+		return "yaml"
+	case "text/csv":
+		return "csv"
+	case "text/tab-separated-values":
+		return "tsv"
+	}
+	return ""
+}
+
+// IsText reports whether data looks like text that can be shown and diffed
+// line by line
+func IsText(data []byte) bool {
+	return !isBinary(data)
+}
+
+// isBinary reports whether data looks like something other than text
+func isBinary(data []byte) bool {
+	control := 0
+	for i := 0; i < len(data); {
+		r, size := utf8.DecodeRune(data[i:])
+		switch {
+		case r == 0:
+			return true
+		case r == utf8.RuneError && size == 1:
+			// A multi-byte character may be cut off at the end
+			if len(data)-i >= utf8.UTFMax {
+				return true
+			}
+		case r < 0x20 && r != '\n' && r != '\r' && r != '\t' && r != '\f' && r != 0x1b:
+			control++
+		}
+		i += size
+	}
+	return control*10 > len(data)
+}
+
+// prettyJSON returns a JSON document indented and highlighted, or nil if
+// it is too large or not valid JSON, so it is shown as it is
+func (o *Object) prettyJSON() []Line {
+	if o.compression == "" && o.size > documentLimit {
+		return nil
+	}
+	data, end, err := o.readContent(0, documentLimit)
+	if err != nil || !end {
+		return nil
+	}
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, data, "", "  "); err != nil {
+		return nil
+	}
+	var lines []Line
+	for _, line := range strings.Split(strings.TrimRight(pretty.String(), "\n"), "\n") {
+		lines = append(lines, highlightJSON(line))
+	}
+	return lines
+}
+
+// readRaw reads up to n bytes of the object from pos
+func (o *Object) readRaw(pos int64, n int) ([]byte, error) {
+	if pos >= o.size {
+		return nil, nil
+	}
+	if remaining := o.size - pos; int64(n) > remaining {
+		n = int(remaining)
+	}
+	buf := make([]byte, n)
+	read, err := o.r.ReadAt(buf, pos)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return buf[:read], nil
+}
+
+// readContent reads up to n bytes of the decompressed content from pos and
+// reports whether the content ends there. Compressed content continues
+// from the previous read, or is decompressed from the start for a page
+// before it.
+func (o *Object) readContent(pos int64, n int) ([]byte, bool, error) {
+	if o.compression == "" {
+		data, err := o.readRaw(pos, n)
+		return data, pos+int64(len(data)) >= o.size, err
+	}
+
+	if o.stream == nil || pos < o.stream.pos {
+		raw := bufio.NewReaderSize(io.NewSectionReader(o.r, 0, o.size), streamBuffer)
+		var content io.Reader
+		if o.compression == "gzip" {
+			gz, err := gzip.NewReader(raw)
+			if err != nil {
+				return nil, false, fmt.Errorf("failed to decompress: %w", err)
+			}
+			content = gz
+		} else {
+			content = zstd.NewReader(raw)
+		}
+		o.stream = &contentStream{r: content}
+	}
+
+	data, end, err := o.stream.read(pos, n)
+	if err != nil {
+		o.stream = nil
+		return nil, false, fmt.Errorf("failed to decompress: %w", err)
+	}
+	return data, end, nil
+}
+
+// read returns up to n bytes from pos, which is at or after s.pos, and
+// reports whether the content ends there
+func (s *contentStream) read(pos int64, n int) ([]byte, bool, error) {
+	if skip := pos - s.pos; skip < int64(len(s.data)) {
+		s.data = s.data[skip:]
+	} else if !s.eof {
+		_, err := io.CopyN(io.Discard, s.r, skip-int64(len(s.data)))
+		s.data = nil
+		if err == io.EOF {
+			s.eof = true
+		} else if err != nil {
+			return nil, false, err
+		}
+	} else {
+		s.data = nil
+	}
+	s.pos = pos
+
+	// The extra byte shows whether more content follows
+	if len(s.data) < n+1 && !s.eof {
+		buf := make([]byte, n+1)
+		copy(buf, s.data)
+		read, err := io.ReadFull(s.r, buf[len(s.data):])
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			s.eof = true
+		} else if err != nil {
+			return nil, false, err
+		}
+		s.data = buf[:len(s.data)+read]
+	}
+	if len(s.data) > n {
+		return bytes.Clone(s.data[:n]), false, nil
+	}
+	return bytes.Clone(s.data), s.eof, nil
+}
+
+// textPage renders lines of text starting at byte pos
+func (o *Object) textPage(pos int64) (*Page, error) {
+	data, end, err := o.readContent(pos, chunkSize)
+	if err != nil {
+		return nil, err
+	}
+	lines, used := splitLines(data, end)
+
+	page := o.bytePage(pos, used, end && used == len(data))
+	for _, line := range lines {
+		page.Lines = append(page.Lines, o.highlight(cleanText(line)))
+	}
+	return page, nil
+}
+
+// bytePage returns a page of used bytes from pos
+func (o *Object) bytePage(pos int64, used int, last bool) *Page {
+	page := &Page{Unit: "bytes", Start: pos, End: pos + int64(used), Total: -1, Next: pos + int64(used)}
+	if o.compression == "" {
+		page.Total = o.size
+	}
+	if last {
+		page.Next = -1
+	}
+	return page
+}
+
+// splitLines takes up to PageLines lines from data and returns how many
+// bytes they span. A line cut off by the end of data is left for the next
+// page, unless the content ends there or the line fills all of data.
+func splitLines(data []byte, end bool) ([]string, int) {
+	var lines []string
+	used := 0
+	for used < len(data) && len(lines) < PageLines {
+		i := bytes.IndexByte(data[used:], '\n')
+		if i < 0 {
+			if !end && len(lines) > 0 {
+				break
+			}
+			lines = append(lines, string(data[used:]))
+			used = len(data)
+			break
+		}
+		lines = append(lines, strings.TrimSuffix(string(data[used:used+i]), "\r"))
+		used += i + 1
+	}
+	return lines, used
+}
+
+// highlight highlights a line of the object's format
+func (o *Object) highlight(line string) Line {
+	switch {
+	case o.format == "json" || o.format == "jsonl":
+		return highlightJSON(line)
+	case o.format == "yaml":
+		return highlightYAML(line)
+	case o.language != nil:
+		return highlightCode(o.language, line)
+	}
+	return Line{{Text: line}}
+}
+
+// hexPage renders a hex dump starting at byte pos
+func (o *Object) hexPage(pos int64) (*Page, error) {
+	data, end, err := o.readContent(pos, PageLines*hexWidth)
+	if err != nil {
+		return nil, err
+	}
+
+	page := o.bytePage(pos, len(data), end)
+	for i := 0; i < len(data); i += hexWidth {
+		row := data[i:min(i+hexWidth, len(data))]
+		var hex, ascii strings.Builder
+		for j := 0; j < hexWidth; j++ {
+			if j == hexWidth/2 {
+				hex.WriteByte(' ')
+			}
+			if j < len(row) {
+				fmt.Fprintf(&hex, "%02x ", row[j])
+			} else {
+				hex.WriteString("   ")
+			}
+		}
+		for _, b := range row {
+			if b >= 0x20 && b < 0x7f {
+				ascii.WriteByte(b)
+			} else {
+				ascii.WriteByte('.')
+			}
+		}
+		page.Lines = append(page.Lines, Line{
+			{Text: fmt.Sprintf("%08x  ", pos+int64(i)), Class: Muted},
+			{Text: hex.String(), Class: Number},
+			{Text: " |" + ascii.String() + "|", Class: String},
+		})
+	}
+	return page, nil
+}
+
+// csvPage renders rows of a CSV or TSV file as a table, starting at byte
+// pos
+func (o *Object) csvPage(pos int64) (*Page, error) {
+	if o.csvHeader == nil {
+		data, end, err := o.readContent(0, chunkSize)
+		if err != nil {
+			return nil, err
+		}
+		records, _ := o.parseCSV(data, end, 1)
+		o.csvHeader = []string{}
+		if len(records) > 0 {
+			o.csvHeader = records[0]
+		}
+	}
+
+	data, end, err := o.readContent(pos, chunkSize)
+	if err != nil {
+		return nil, err
+	}
+	skip := btoi(pos == 0)
+	records, used := o.parseCSV(data, end, PageLines+skip)
+	records = records[min(skip, len(records)):]
+
+	page := o.bytePage(pos, used, end && used == len(data))
+	page.Lines = renderTable(o.csvHeader, records)
+	return page, nil
+}
+
+// parseCSV parses up to limit records from data and returns how many bytes
+// they span. A record cut off by the end of data is left for the next
+// page, unless the content ends there or the record fills all of data.
+func (o *Object) parseCSV(data []byte, end bool, limit int) ([][]string, int) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	if o.format == "tsv" {
+		r.Comma = '\t'
+	}
+
+	var records [][]string
+	used := 0
+	for len(records) < limit {
+		record, err := r.Read()
+		if err != nil {
+			break
+		}
+		offset := int(r.InputOffset())
+		if offset == len(data) && !end && !bytes.HasSuffix(data, []byte("\n")) && len(records) > 0 {
+			break
+		}
+		records = append(records, record)
+		used = offset
+	}
+	return records, used
+}
+
+// renderTable lays out rows under a header in aligned columns
+func renderTable(header []string, rows [][]string) []Line {
+	columns := len(header)
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	widths := make([]int, columns)
+	measure := func(row []string) {
+		for i, cell := range row {
+			widths[i] = min(max(widths[i], utf8.RuneCountInString(cleanText(cell))), maxCellWidth)
+		}
+	}
+	measure(header)
+	for _, row := range rows {
+		measure(row)
+	}
+
+	cells := func(row []string, class func(string) Class) Line {
+		var line Line
+		for i := 0; i < columns; i++ {
+			cell := ""
+			if i < len(row) {
+				cell = cleanText(row[i])
+			}
+			text := padRight(truncateText(cell, widths[i]), widths[i])
+			if i < columns-1 {
+				text += "  "
+			}
+			line = append(line, Span{Text: text, Class: class(cell)})
+		}
+		return line
+	}
+
+	lines := []Line{cells(header, func(string) Class { return Key })}
+	var rule []string
+	for _, width := range widths {
+		rule = append(rule, strings.Repeat("─", width))
+	}
+	lines = append(lines, Line{{Text: strings.Join(rule, "  "), Class: Muted}})
+	for _, row := range rows {
+		lines = append(lines, cells(row, func(cell string) Class {
+			if isNumber(cell) {
+				return Number
+			}
+			return Plain
+		}))
+	}
+	return lines
+}
+
+// documentPage returns lines of a document rendered as a whole, starting
+// at line pos
+func (o *Object) documentPage(pos int64) *Page {
+	total := int64(len(o.document))
+	start := min(max(pos, 0), total)
+	end := min(start+PageLines, total)
+	page := &Page{
+		Lines: append([]Line(nil), o.document[start:end]...),
+		Unit:  "lines",
+		Start: start,
+		End:   end,
+		Total: total,
+		Next:  end,
+	}
+	if end >= total {
+		page.Next = -1
+	}
+	return page
+}
+
+// cleanText expands tabs and replaces control characters, which would
+// upset the terminal
+func cleanText(s string) string {
+	var b strings.Builder
+	column := 0
+	for _, r := range s {
+		switch {
+		case r == '\t':
+			spaces := 4 - column%4
+			b.WriteString(strings.Repeat(" ", spaces))
+			column += spaces
+			continue
+		case r < 0x20 || r == 0x7f || (r >= 0x80 && r < 0xa0):
+			r = '.'
+		case r == utf8.RuneError:
+			r = '?'
+		}
+		b.WriteRune(r)
+		column++
+	}
+	return b.String()
+}
+
+// truncateLine cuts a line off after width characters
+func truncateLine(line Line, width int) Line {
+	var out Line
+	remaining := width
+	for _, span := range line {
+		n := utf8.RuneCountInString(span.Text)
+		if n <= remaining {
+			out = append(out, span)
+			remaining -= n
+			continue
+		}
+		out = append(out, Span{Text: truncateText(span.Text, remaining), Class: span.Class})
+		break
+	}
+	return out
+}
+
+// truncateText cuts s off after width characters, marking the cut with …
+func truncateText(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	if width <= 0 {
+		return ""
+	}
+	runes := []rune(s)
+	return string(runes[:width-1]) + "…"
+}
+
+func padRight(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,241 @@
+package preview
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// countingReader counts the bytes read, to check pages only read what
+// they show
+type countingReader struct {
+	r    *bytes.Reader
+	read int64
+}
+
+func (c *countingReader) ReadAt(p []byte, off int64) (int, error) {
+	n, err := c.r.ReadAt(p, off)
+	c.read += int64(n)
+	return n, err
+}
+
+func open(data []byte, name, contentType string) (*Object, *countingReader) {
+	r := &countingReader{r: bytes.NewReader(data)}
+	return New(r, int64(len(data)), name, contentType), r
+}
+
+func pageText(page *Page) string {
+	var lines []string
+	for _, line := range page.Lines {
+		lines = append(lines, line.Text())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestJSONIsPrettyPrinted(t *testing.T) {
+	obj, _ := open([]byte(`{"name":"lazyaws","stars":42,"tags":["tui",null]}`), "config", "application/json")
+	page, err := obj.Page(0)
+	if err != nil {
+		t.Fatalf("Page returned error: %v", err)
+	}
+	want := "{\n  \"name\": \"lazyaws\",\n  \"stars\": 42,\n  \"tags\": [\n    \"tui\",\n    null\n  ]\n}"
+	if got := pageText(page); got != want {
+		t.Errorf("Expected pretty-printed JSON, got:\n%s", got)
+	}
+	if page.Format != "json" || page.Unit != "lines" || page.Next != -1 {
+		t.Errorf("Expected a single page of json lines, got %+v", page)
+	}
+	if line := page.Lines[1]; line[1].Class != Key || line[4].Class != String {
+		t.Errorf("Expected a key and a string, got %+v", line)
+	}
+	if line := page.Lines[2]; line[len(line)-2].Class != Number {
+		t.Errorf("Expected a number, got %+v", line)
+	}
+}
+
+func TestLargeTextIsReadInRanges(t *testing.T) {
+	var b strings.Builder
+	for i := 0; b.Len() < 10<<20; i++ {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	obj, r := open([]byte(b.String()), "app.log", "")
+
+	page, err := obj.Page(0)
+	if err != nil {
+		t.Fatalf("Page returned error: %v", err)
+	}
+	if len(page.Lines) != PageLines || page.Lines[0].Text() != "line 0" {
+		t.Fatalf("Expected %d lines starting at line 0, got %d", PageLines, len(page.Lines))
+	}
+	if r.read > sniffSize+chunkSize {
+		t.Errorf("Expected only the first chunk to be read, read %d bytes", r.read)
+	}
+
+	page, err = obj.Page(page.Next)
+	if err != nil {
+		t.Fatalf("Page returned error: %v", err)
+	}
+	if got := page.Lines[0].Text(); got != fmt.Sprintf("line %d", PageLines) {
+		t.Errorf("Expected the second page to continue where the first ended, got %q", got)
+	}
+	if page.Total != int64(b.Len()) || page.Unit != "bytes" {
+		t.Errorf("Expected the page to count bytes of the whole object, got %+v", page)
+	}
+}
+
+func TestCompressedContentIsDecompressed(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	for i := 0; i < 300; i++ {
+		fmt.Fprintf(gz, "key%d: value\n", i)
+	}
+	gz.Close()
+
+	obj, _ := open(buf.Bytes(), "values.yaml.gz", "application/gzip")
+	page, err := obj.Page(0)
+	if err != nil {
+		t.Fatalf("Page returned error: %v", err)
+	}
+	if page.Format != "yaml (gzip)" || page.Lines[0].Text() != "key0: value" || page.Total != -1 {
+		t.Fatalf("Expected decompressed yaml, got %q: %q", page.Format, page.Lines[0].Text())
+	}
+	if page.Lines[0][0].Class != Key {
+		t.Errorf("Expected the key to be highlighted, got %+v", page.Lines[0])
+	}
+	page, err = obj.Page(page.Next)
+	if err != nil || page.Lines[0].Text() != fmt.Sprintf("key%d: value", PageLines) || page.Next != -1 {
+		t.Errorf("Expected the last page to continue at key%d, got %+v (%v)", PageLines, page, err)
+	}
+
+	data, err := os.ReadFile("../zstd/testdata/gettysburg.txt-100x.zst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, _ = open(data, "speech.zst", "")
+	page, err = obj.Page(0)
+	if err != nil {
+		t.Fatalf("Page returned error: %v", err)
+	}
+	if page.Format != "text (zstd)" || !strings.HasPrefix(strings.TrimSpace(page.Lines[0].Text()), "Four score") {
+		t.Errorf("Expected decompressed text, got %q: %q", page.Format, page.Lines[0].Text())
+	}
+}
+
+func TestCompressedPagesContinueDecompressing(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	for i := 0; i < 100*PageLines; i++ {
+		fmt.Fprintf(gz, "line %d\n", i)
+	}
+	gz.Close()
+
+	obj, r := open(buf.Bytes(), "app.log.gz", "")
+	pages := 0
+	var page *Page
+	for pos := int64(0); pos >= 0; pos = page.Next {
+		var err error
+		if page, err = obj.Page(pos); err != nil {
+			t.Fatalf("Page returned error: %v", err)
+		}
+		if got, want := page.Lines[0].Text(), fmt.Sprintf("line %d", pages*PageLines); got != want {
+			t.Fatalf("Expected page %d to start with %q, got %q", pages, want, got)
+		}
+		pages++
+	}
+	if pages != 100 {
+		t.Errorf("Expected 100 pages, got %d", pages)
+	}
+	// Each page picks up where the last one stopped, so apart from sniffing
+	// the format the object is read once
+	if r.read > sniffSize+int64(buf.Len()) {
+		t.Errorf("Expected the object to be read once, read %d of %d bytes", r.read, buf.Len())
+	}
+
+	// Going back decompresses from the start again
+	page, err := obj.Page(0)
+	if err != nil || page.Lines[0].Text() != "line 0" {
+		t.Errorf("Expected the first page again, got %+v (%v)", page, err)
+	}
+}
+
+func TestCSVIsShownAsTable(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("id,name,note\n")
+	for i := 1; i <= 250; i++ {
+		fmt.Fprintf(&b, "%d,user%d,\"says \"\"hi\"\"\nover two lines\"\n", i, i)
+	}
+	obj, _ := open([]byte(b.String()), "users.csv", "")
+
+	page, err := obj.Page(0)
+	if err != nil {
+		t.Fatalf("Page returned error: %v", err)
+	}
+	if len(page.Lines) != PageLines+2 {
+		t.Fatalf("Expected the header, a rule and %d rows, got %d lines", PageLines, len(page.Lines))
+	}
+	if got := page.Lines[0].Text(); !strings.HasPrefix(got, "id   name     note") {
+		t.Errorf("Expected an aligned header, got %q", got)
+	}
+	if got := page.Lines[2].Text(); !strings.HasPrefix(got, `1    user1    says "hi".over two lines`) {
+		t.Errorf("Expected the first row, got %q", got)
+	}
+
+	page, err = obj.Page(page.Next)
+	if err != nil {
+		t.Fatalf("Page returned error: %v", err)
+	}
+	if got := page.Lines[2].Text(); !strings.HasPrefix(got, fmt.Sprintf("%d  user%d", PageLines+1, PageLines+1)) || page.Next != -1 {
+		t.Errorf("Expected the second page to start at row %d under the same header, got %q", PageLines+1, got)
+	}
+	if !strings.HasPrefix(page.Lines[0].Text(), "id") {
+		t.Errorf("Expected later pages to repeat the header, got %q", page.Lines[0].Text())
+	}
+}
+
+func TestBinaryFallsBackToHex(t *testing.T) {
+	data := append([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), make([]byte, 100)...)
+	obj, _ := open(data, "image.png", "image/png")
+	page, err := obj.Page(0)
+	if err != nil {
+		t.Fatalf("Page returned error: %v", err)
+	}
+	want := "00000000  89 50 4e 47 0d 0a 1a 0a  00 00 00 0d 49 48 44 52  |.PNG........IHDR|"
+	if page.Format != "hex" || page.Lines[0].Text() != want {
+		t.Errorf("Expected a hex dump, got %q: %q", page.Format, page.Lines[0].Text())
+	}
+	if len(page.Lines) != 8 || page.Next != -1 {
+		t.Errorf("Expected 8 lines on one page, got %d", len(page.Lines))
+	}
+}
+
+func TestCodeIsHighlighted(t *testing.T) {
+	obj, _ := open([]byte("func main() {\n\tfmt.Println(\"hi\", 42) // greet\n}\n"), "main.go", "")
+	page, err := obj.Page(0)
+	if err != nil {
+		t.Fatalf("Page returned error: %v", err)
+	}
+	if page.Format != "go" || page.Lines[1].Text() != `    fmt.Println("hi", 42) // greet` {
+		t.Fatalf("Expected go with tabs expanded, got %q: %q", page.Format, page.Lines[1].Text())
+	}
+	classes := make(map[string]Class)
+	for _, line := range page.Lines {
+		for _, span := range line {
+			classes[strings.TrimSpace(span.Text)] = span.Class
+		}
+	}
+	for text, want := range map[string]Class{"func": Keyword, `"hi"`: String, "42": Number, "// greet": Comment} {
+		if classes[text] != want {
+			t.Errorf("Expected %q to be class %d, got %d", text, want, classes[text])
+		}
+	}
+
+	// Escape sequences in the content can't reach the terminal
+	obj, _ = open([]byte("red \x1b[31mtext\n"), "notes.txt", "")
+	page, _ = obj.Page(0)
+	if got := page.Lines[0].Text(); got != "red .[31mtext" {
+		t.Errorf("Expected control characters to be replaced, got %q", got)
+	}
+}
@@ -300,6 +300,24 @@ const (
 	CmdEKS           = "eks"
 	CmdAccount       = "account"
 	CmdRegion        = "region"
+	CmdAlarms        = "alarms"
+	CmdLogs          = "logs"
+	CmdInsights      = "insights"
+	CmdPortForwards  = "pf"
+	CmdTransfers     = "transfers"
+	CmdSync          = "sync"
+	CmdCopy          = "cp"
+	CmdMove          = "mv"
+	CmdStorageClass  = "storageclass"
+	CmdTag           = "tag"
+	CmdVersions      = "versions"
+	CmdEdit          = "edit"
+	CmdFind          = "find"
+	CmdRestore       = "restore"
+	CmdPresign       = "presign"
+	CmdTagSet        = "tagset"
+	CmdFilter        = "filter"
+	CmdView          = "view"
 )
 
 // AllCommands returns a list of all available commands for completion
@@ -311,9 +329,13 @@ func AllCommands() []string {
 		"cf", "clearfilter",
 		"sa", "selectall",
 		"da", "deselectall",
-		"ec2", "s3", "eks",
+		"ec2", "s3", "eks", "alarms", "logs", "insights",
 		"account", "acc",
 		"region",
+		"pf", "portforwards",
+		"transfers", "sync", "cp", "mv", "storageclass", "tag", "versions",
+		"edit", "find", "restore", "presign", "tagset",
+		"filter", "view",
 	}
 }
 
@@ -0,0 +1,30 @@
+package zstd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestReader(t *testing.T) {
+	compressed := "\x28\xb5\x2f\xfd\x24\x0d\x69\x00\x00\x68\x65\x6c\x6c\x6f\x2c\x20\x77\x6f\x72\x6c\x64\x0a\x4c\x1f\xf9\xf1"
+	data, err := io.ReadAll(NewReader(strings.NewReader(compressed)))
+	if err != nil || string(data) != "hello, world\n" {
+		t.Errorf("Expected %q, got %q (%v)", "hello, world\n", data, err)
+	}
+
+	// 100 copies of the Gettysburg Address
+	compressedFile, err := os.ReadFile("testdata/gettysburg.txt-100x.zst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = io.ReadAll(NewReader(bytes.NewReader(compressedFile)))
+	if err != nil {
+		t.Fatalf("Failed to decompress: %v", err)
+	}
+	if n := bytes.Count(data, []byte("Four score and seven years ago")); n != 100 {
+		t.Errorf("Expected 100 copies of the address, got %d", n)
+	}
+}
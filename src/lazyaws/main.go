@@ -4,26 +4,45 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"os/exec"
 	"os/signal"
+	"path/filepath"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
+	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/creack/pty"
 	"github.com/fuziontech/lazyaws/internal/aws"
+	"github.com/fuziontech/lazyaws/internal/chart"
+	"github.com/fuziontech/lazyaws/internal/cli"
 	"github.com/fuziontech/lazyaws/internal/config"
+	"github.com/fuziontech/lazyaws/internal/diff"
+	"github.com/fuziontech/lazyaws/internal/preview"
 	"github.com/fuziontech/lazyaws/internal/vim"
 	"golang.org/x/term"
 )
 
 type screen int
 
+// theme is the active color theme, set from the config at startup
+var theme = config.Themes[config.DefaultThemeName]
+
+// selectedRowPrefix returns the raw ANSI codes that highlight a selected table
+// row: theme accent background, selected text foreground and bold. Rows use
+// ANSI codes directly to avoid lipgloss adding extra width.
+func selectedRowPrefix() string {
+	return fmt.Sprintf("\x1b[48;5;%sm\x1b[38;5;%sm\x1b[1m", theme.Accent, theme.SelectedText)
+}
+
 const (
 	authMethodScreen screen = iota
 	authProfileScreen
@@ -35,8 +54,18 @@ const (
 	s3Screen
 	s3BrowseScreen
 	s3ObjectDetailsScreen
+	s3VersionsScreen
 	eksScreen
 	eksDetailsScreen
+	alarmsScreen
+	alarmDetailsScreen
+	logGroupsScreen
+	logStreamsScreen
+	logTailScreen
+	logInsightsScreen
+	portForwardsScreen
+	transfersScreen
+	syncPlanScreen
 	helpScreen
 )
 
@@ -59,8 +88,13 @@ type model struct {
 	ec2SelectedInstances    map[string]bool // Multi-select support
 	ec2InstanceDetails      *aws.InstanceDetails
 	ec2InstanceStatus       *aws.InstanceStatus
-	ec2InstanceMetrics      *aws.InstanceMetrics
+	ec2MetricHistory        *aws.InstanceMetricHistory
+	ec2MetricRange          int // Index into aws.MetricRanges
+	ec2MetricIndex          int // Charted metric, index into aws.InstanceMetricList
 	ec2SSMStatus            *aws.SSMConnectionStatus
+	ec2Filter               aws.InstanceFilter // Server-side filter of the instance list
+	ec2View                 string             // Saved view the filter came from
+	ec2Views                *aws.InstanceViews
 	s3Buckets               []aws.Bucket
 	s3FilteredBuckets       []aws.Bucket // VIM-filtered view
 	s3SelectedIndex         int
@@ -71,17 +105,53 @@ type model struct {
 	s3ObjectSelectedIndex   int
 	s3NextContinuationToken *string
 	s3IsTruncated           bool
+	s3Search                string             // Terms of the bucket-wide search the listing shows
+	s3SearchID              int                // Tells the running search's messages from stale ones
+	s3SearchCancel          context.CancelFunc // Stops the running search, nil when none runs
+	s3SearchScanned         int
+	s3SearchMatched         int
 	s3ObjectDetails         *aws.S3ObjectDetails
+	s3Preview               *preview.Object // Preview of the object being viewed
+	s3PreviewPage           *preview.Page
+	s3PreviewHistory        []int64 // Starts of the pages before the current one
+	s3PreviewLoading        bool
+	s3PreviewErr            error
+	s3VersionsKey           string // Object whose versions are listed
+	s3Versions              []aws.S3ObjectVersion
+	s3VersionIndex          int
+	s3VersionMark           string // Version ID marked to diff against
+	s3VersionDiff           string // Diff being shown instead of the list
+	s3VersionsReturn        screen // Screen the versions go back to
 	s3Filter                string
 	s3FilterActive          bool
 	s3PresignedURL          string
+	s3PresignedUpload       string // curl command or HTML form of a presigned upload, or presigned URLs, shown until ESC
+	s3PresignedUploadTitle  string
 	s3BucketPolicy          string
 	s3BucketVersioning      string
 	s3ShowingInfo           bool   // For showing bucket policy/versioning
 	s3InfoType              string // "policy" or "versioning"
 	s3ConfirmDelete         bool
-	s3DeleteTarget          string // "object" or "bucket"
+	s3DeleteTarget          string // "object", "bucket", "buckets" or "batch"
 	s3DeleteKey             string
+	s3BatchOp               string         // "delete", "copy", "move" or "download" of a folder or selection
+	s3BatchObjects          []aws.S3Object // Objects the pending operation covers
+	s3BatchRoot             string         // Prefix the objects were selected under
+	s3BatchDestBucket       string         // Destination of a copy or move
+	s3BatchDestPrefix       string
+	s3BatchDone             int
+	s3BatchTotal            int               // Non-zero while an operation runs
+	s3BatchResult           *aws.BatchResult  // Last result with failures, listed under the objects
+	s3BatchStorageClass     string            // Target of a storage class change
+	s3BatchTags             map[string]string // Tags a tag operation adds
+	s3BatchRestoreTier      string            // Retrieval tier of a restore
+	s3BatchRestoreDays      int32             // Days a restored copy stays readable
+	s3SelectedObjects       map[string]bool   // Multi-select support, by key
+	s3SelectionRoot         string            // Bucket and prefix the selected objects are listed under
+	s3SelectedBuckets       map[string]bool
+	s3RestoreStatus         map[string]aws.ObjectRestoreStatus
+	s3RangeActive           bool            // A range selection is waiting for its last row
+	s3RangeAnchor           int             // Row the range selection started on
 	deleteConfirmInput      textinput.Model // For typing confirmation
 	eksClusters             []aws.EKSCluster
 	eksFilteredClusters     []aws.EKSCluster // VIM-filtered view
@@ -89,6 +159,8 @@ type model struct {
 	eksClusterDetails       *aws.EKSClusterDetails
 	eksNodeGroups           []aws.EKSNodeGroup
 	eksAddons               []aws.EKSAddon
+	ec2LoadedCount          int // Instances loaded so far while paging
+	eksLoadedCount          int // Clusters loaded so far while paging
 	loading                 bool
 	err                     error
 	config                  *config.Config
@@ -106,6 +178,7 @@ type model struct {
 	statusMessage           string
 	autoRefresh             bool
 	autoRefreshInterval     int // in seconds
+	keymap                  config.Keymap // Keybinding overrides from the config
 	copyToClipboard         string
 	vimState                *vim.State
 	pageSize                int      // For VIM page navigation
@@ -113,8 +186,13 @@ type model struct {
 	commandSuggestions      []string // Command suggestions for tab completion
 	ssmInstanceID           string   // Store instance ID for SSM session launch
 	ssmRegion               string   // Store region for SSM session launch
+	k9sCluster              string   // Store cluster name for k9s launch
 	s3EditBucket            string   // Store bucket for S3 edit operation
 	s3EditKey               string   // Store key for S3 edit operation
+	s3EditConfigKind        string   // Store bucket configuration to edit instead of an object
+	s3EditPropertyKeys      []string // Store objects and folders whose properties to edit instead
+	ec2EditTagInstances     []string // Store instances whose tags to edit in the editor
+	ec2EditTagTemplate      string   // Store tag template to fill the tag form with
 	s3NeedRestore           bool     // Flag to trigger S3 restore after edit
 	ec2NeedRestore          bool     // Flag to trigger EC2 restore after SSM
 	ssoAuthenticator        *aws.SSOAuthenticator
@@ -127,6 +205,51 @@ type model struct {
 	authConfig              *aws.AuthConfig
 	currentAccountID        string
 	currentAccountName      string
+	alarms                  []aws.Alarm
+	alarmsFiltered          []aws.Alarm // VIM-filtered view
+	alarmSelectedIndex      int
+	alarmDetails            *aws.Alarm
+	alarmHistory            []aws.AlarmHistoryItem
+	alarmStatePrompt        bool // Asking which state to set alarmDetails to
+	alarmStateInput         textinput.Model
+	logGroups               []aws.LogGroup
+	logGroupsFiltered       []aws.LogGroup // VIM-filtered view
+	logGroupIndex           int
+	logGroupName            string // Group whose streams are listed
+	logStreams              []aws.LogStream
+	logStreamIndex          int
+	logTail                 *aws.LogTail
+	logEvents               []aws.LogEvent
+	logEventIndex           int
+	logTailPaused           bool
+	logFilterPrompt         bool // Asking for the tail's filter pattern
+	logFilterInput          textinput.Model
+	logReturnScreen         screen // Screen the tail and Insights go back to
+	insightsGroups          []string
+	insightsQueryInput      textinput.Model
+	insightsEditing         bool
+	insightsRange           int // Index into aws.MetricRanges
+	insightsQueryID         string
+	insightsResult          *aws.InsightsQueryResult
+	insightsRowIndex        int
+	savedQueries            []aws.SavedQuery
+	savedQueryIndex         int
+	insightsSavePrompt      bool // Asking for the name to save the query under
+	insightsNameInput       textinput.Model
+	portForwards            *aws.PortForwardManager // Shared across TUI restarts
+	portForwardList         []aws.PortForwardInfo   // Snapshot shown in the panel
+	portForwardIndex        int
+	portForwardPrompt       bool // Asking for a forward spec for portForwardInstance
+	portForwardInstance     string
+	portForwardInput        textinput.Model
+	transfers               *aws.TransferManager // Shared across TUI restarts
+	transferList            []aws.TransferInfo   // Snapshot shown in the queue
+	transferTotals          aws.TransferTotals
+	transferIndex           int
+	s3UploadPrompt          bool // Asking for a local file to upload to s3CurrentPrefix
+	s3UploadInput           textinput.Model
+	syncPlan                *aws.SyncPlan // Sync waiting to be reviewed
+	syncPlanIndex           int
 }
 
 type instancesLoadedMsg struct {
@@ -134,6 +257,13 @@ type instancesLoadedMsg struct {
 	err       error
 }
 
+// instancesProgressMsg reports how many instances have been loaded while
+// DescribeInstances is still paging
+type instancesProgressMsg struct {
+	loaded  int
+	updates <-chan tea.Msg
+}
+
 type instanceDetailsLoadedMsg struct {
 	details *aws.InstanceDetails
 	err     error
@@ -144,8 +274,8 @@ type instanceStatusLoadedMsg struct {
 	err    error
 }
 
-type instanceMetricsLoadedMsg struct {
-	metrics *aws.InstanceMetrics
+type instanceMetricHistoryLoadedMsg struct {
+	history *aws.InstanceMetricHistory
 	err     error
 }
 
@@ -169,11 +299,40 @@ type objectsLoadedMsg struct {
 	err    error
 }
 
+type restoreStatusesLoadedMsg struct {
+	bucket   string
+	statuses map[string]aws.ObjectRestoreStatus
+	err      error
+}
+
 type objectDetailsLoadedMsg struct {
 	details *aws.S3ObjectDetails
 	err     error
 }
 
+type previewLoadedMsg struct {
+	preview *preview.Object
+	page    *preview.Page
+	back    bool // Whether the page was reached by going back
+	err     error
+}
+
+type objectVersionsLoadedMsg struct {
+	key      string
+	versions []aws.S3ObjectVersion
+	err      error
+}
+
+type versionDiffLoadedMsg struct {
+	diff string
+	err  error
+}
+
+type versionActionCompletedMsg struct {
+	action string
+	err    error
+}
+
 type fileOperationCompletedMsg struct {
 	operation string
 	err       error
@@ -183,8 +342,10 @@ type tickMsg struct{}
 
 type bulkActionCompletedMsg struct {
 	action       string
+	resource     string // "instances" or "buckets"
 	successCount int
 	failureCount int
+	firstErr     error
 }
 
 type s3ActionCompletedMsg struct {
@@ -192,11 +353,53 @@ type s3ActionCompletedMsg struct {
 	err    error
 }
 
+// s3SelectionExpandedMsg carries the objects under the folders and keys an
+// operation was started on
+type s3SelectionExpandedMsg struct {
+	op      string
+	objects []aws.S3Object
+	confirm string // Text to type to confirm a destructive operation
+	err     error
+}
+
+// s3BatchProgressMsg reports how many objects an operation has handled
+type s3BatchProgressMsg struct {
+	done    int
+	total   int
+	updates <-chan tea.Msg
+}
+
+type s3SearchProgressMsg struct {
+	id      int
+	scanned int
+	matched int
+	updates <-chan tea.Msg
+}
+
+type s3SearchCompletedMsg struct {
+	id     int
+	terms  string
+	result *aws.S3SearchResult
+	err    error
+}
+
+type s3BatchCompletedMsg struct {
+	op     string
+	result *aws.BatchResult
+	urls   string // Presigned URLs, one "key<TAB>url" line per object
+}
+
 type presignedURLGeneratedMsg struct {
 	url string
 	err error
 }
 
+type presignedUploadMsg struct {
+	title   string
+	snippet string // curl command or HTML form that performs the upload
+	err     error
+}
+
 type bucketPolicyLoadedMsg struct {
 	policy string
 	err    error
@@ -207,6 +410,99 @@ type bucketVersioningLoadedMsg struct {
 	err        error
 }
 
+type alarmsLoadedMsg struct {
+	alarms []aws.Alarm
+	err    error
+}
+
+type alarmHistoryLoadedMsg struct {
+	alarmName string
+	history   []aws.AlarmHistoryItem
+	err       error
+}
+
+type alarmActionCompletedMsg struct {
+	action    string
+	alarmName string
+	err       error
+}
+
+type logGroupsLoadedMsg struct {
+	groups []aws.LogGroup
+	err    error
+}
+
+type logStreamsLoadedMsg struct {
+	groupName string
+	streams   []aws.LogStream
+	err       error
+}
+
+type logTailTickMsg struct {
+	tail *aws.LogTail
+}
+
+type logEventsLoadedMsg struct {
+	tail   *aws.LogTail
+	events []aws.LogEvent
+	err    error
+}
+
+type clusterLogStreamsLoadedMsg struct {
+	clusterName string
+	streams     []string
+	err         error
+}
+
+type insightsQueryStartedMsg struct {
+	queryID string
+	err     error
+}
+
+type insightsTickMsg struct {
+	queryID string
+}
+
+type insightsResultsLoadedMsg struct {
+	result *aws.InsightsQueryResult
+	err    error
+}
+
+type savedQueriesLoadedMsg struct {
+	queries []aws.SavedQuery
+	err     error
+}
+
+type querySavedMsg struct {
+	name string
+	err  error
+}
+
+type portForwardTickMsg struct{}
+
+type transferTickMsg struct{}
+
+type syncPlannedMsg struct {
+	plan *aws.SyncPlan
+	err  error
+}
+
+type syncExecutedMsg struct {
+	plan *aws.SyncPlan
+	err  error
+}
+
+type portForwardActionCompletedMsg struct {
+	action string
+	info   aws.PortForwardInfo
+	err    error
+}
+
+type portForwardsRestoredMsg struct {
+	count int
+	err   error
+}
+
 type launchSSMSessionMsg struct {
 	instanceID string
 	region     string
@@ -217,6 +513,8 @@ type ssmRestoreInfo struct {
 	accountID      string
 	accountName    string
 	region         string
+	ec2Filter      aws.InstanceFilter
+	ec2View        string
 }
 
 type s3RestoreInfo struct {
@@ -230,9 +528,24 @@ type s3RestoreInfo struct {
 
 type eksClustersLoadedMsg struct {
 	clusters []aws.EKSCluster
+	updates  <-chan tea.Msg // Delivers eksClusterDescribedMsg as details arrive
 	err      error
 }
 
+// eksClusterDescribedMsg carries the details of one cluster after the basic
+// cluster list has already been shown
+type eksClusterDescribedMsg struct {
+	cluster aws.EKSCluster
+	updates <-chan tea.Msg
+}
+
+// eksClustersProgressMsg reports how many clusters have been loaded while
+// ListClusters is still paging
+type eksClustersProgressMsg struct {
+	loaded  int
+	updates <-chan tea.Msg
+}
+
 type eksClusterDetailsLoadedMsg struct {
 	details    *aws.EKSClusterDetails
 	nodeGroups []aws.EKSNodeGroup
@@ -260,6 +573,7 @@ type accountSwitchedMsg struct {
 
 type kubeconfigUpdatedMsg struct {
 	clusterName string
+	contextName string
 	err         error
 }
 
@@ -296,11 +610,45 @@ func initialModel(cfg *config.Config) model {
 	deleteInput.CharLimit = 256
 	deleteInput.Width = 80
 
+	// Alarm state input
+	alarmStateInput := textinput.New()
+	alarmStateInput.Placeholder = "ALARM, OK or INSUFFICIENT_DATA"
+	alarmStateInput.CharLimit = 32
+	alarmStateInput.Width = 40
+
+	// Log tail filter pattern input
+	logFilterInput := textinput.New()
+	logFilterInput.Placeholder = "filter pattern, e.g. ERROR or \"GET /api\""
+	logFilterInput.CharLimit = 256
+	logFilterInput.Width = 60
+
+	// Logs Insights query and saved query name inputs
+	insightsQueryInput := textinput.New()
+	insightsQueryInput.SetValue(defaultInsightsQuery)
+	insightsQueryInput.CharLimit = 2048
+	insightsQueryInput.Width = 90
+	insightsNameInput := textinput.New()
+	insightsNameInput.Placeholder = "query name"
+	insightsNameInput.CharLimit = 255
+	insightsNameInput.Width = 40
+
+	// S3 upload path input
+	s3UploadInput := textinput.New()
+	s3UploadInput.Placeholder = "path/to/local/file"
+	s3UploadInput.CharLimit = 1024
+	s3UploadInput.Width = 60
+
+	// Port forward spec input
+	portForwardInput := textinput.New()
+	portForwardInput.Placeholder = "[localPort:][host:]remotePort"
+	portForwardInput.CharLimit = 256
+	portForwardInput.Width = 60
+
 	// Load auth config if available
 	authConfig, _ := aws.LoadAuthConfig()
 
 	// Determine starting screen
-	startScreen := ec2Screen
+	startScreen := screenFromConfig(cfg)
 	shouldLoad := false
 	if authConfig == nil {
 		startScreen = authMethodScreen
@@ -321,21 +669,64 @@ func initialModel(cfg *config.Config) model {
 		ssoURLInput:          ssoInput,
 		profileInput:         profileInput,
 		deleteConfirmInput:   deleteInput,
+		portForwardInput:     portForwardInput,
+		s3UploadInput:        s3UploadInput,
+		alarmStateInput:      alarmStateInput,
+		logFilterInput:       logFilterInput,
+		insightsQueryInput:   insightsQueryInput,
+		insightsNameInput:    insightsNameInput,
 		authConfig:           authConfig,
 		configuringSSO:       false,
 		configuringProfile:   false,
 		selectedAuthMethod:   0,
 		filtering:            false,
 		ec2SelectedInstances: make(map[string]bool),
+		s3SelectedObjects:    make(map[string]bool),
+		s3SelectedBuckets:    make(map[string]bool),
 		autoRefresh:          false,
-		autoRefreshInterval:  30, // Default 30 seconds
+		autoRefreshInterval:  cfg.RefreshInterval,
+		keymap:               cfg.Keymap(),
 		vimState:             vim.NewState(),
-		pageSize:             20, // Default page size for ctrl+d/ctrl+u
+		pageSize:             cfg.PageSize,
+	}
+}
+
+// screenFromConfig returns the configured default screen
+func screenFromConfig(cfg *config.Config) screen {
+	switch cfg.DefaultScreen {
+	case config.ScreenS3:
+		return s3Screen
+	case config.ScreenEKS:
+		return eksScreen
+	default:
+		return ec2Screen
+	}
+}
+
+// loadDefaultScreen switches to the configured default screen and loads it
+func (m *model) loadDefaultScreen() tea.Cmd {
+	m.currentScreen = screenFromConfig(m.config)
+	return m.loadCurrentScreen()
+}
+
+// loadCurrentScreen loads the data for the current main screen, falling back
+// to EC2 instances for any other screen
+func (m model) loadCurrentScreen() tea.Cmd {
+	switch m.currentScreen {
+	case s3Screen:
+		return m.loadS3Buckets
+	case eksScreen:
+		return m.loadEKSClusters
+	default:
+		return m.loadEC2Instances
 	}
 }
 
 func (m model) Init() tea.Cmd {
 	// If we need to restore S3 state after editing, trigger the load
+	if m.s3NeedRestore && m.currentScreen == s3Screen && m.awsClient != nil {
+		return m.loadS3Buckets
+	}
 	if m.s3NeedRestore && m.s3CurrentBucket != "" && m.awsClient != nil {
 		return m.loadS3Objects(m.s3CurrentBucket, m.s3CurrentPrefix, nil)
 	}
@@ -445,9 +836,15 @@ func (m model) switchToSSOAccount(account aws.SSOAccount, region string) tea.Cmd
 }
 
 func (m model) loadEC2Instances() tea.Msg {
-	ctx := context.Background()
-	instances, err := m.awsClient.ListInstances(ctx)
-	return instancesLoadedMsg{instances: instances, err: err}
+	updates := make(chan tea.Msg, 1)
+	go func() {
+		ctx := context.Background()
+		instances, err := m.awsClient.ListFilteredInstances(ctx, m.ec2Filter, func(loaded int) {
+			sendProgress(updates, instancesProgressMsg{loaded: loaded, updates: updates})
+		})
+		updates <- instancesLoadedMsg{instances: instances, err: err}
+	}()
+	return <-updates
 }
 
 func (m model) loadS3Buckets() tea.Msg {
@@ -457,9 +854,61 @@ func (m model) loadS3Buckets() tea.Msg {
 }
 
 func (m model) loadEKSClusters() tea.Msg {
-	ctx := context.Background()
-	clusters, err := m.awsClient.ListEKSClusters(ctx)
-	return eksClustersLoadedMsg{clusters: clusters, err: err}
+	updates := make(chan tea.Msg, 1)
+	go func() {
+		ctx := context.Background()
+		defer close(updates)
+
+		names, err := m.awsClient.ListEKSClusterNames(ctx, func(loaded int) {
+			sendProgress(updates, eksClustersProgressMsg{loaded: loaded, updates: updates})
+		})
+		if err != nil {
+			updates <- eksClustersLoadedMsg{err: err}
+			return
+		}
+
+		// Show the basic rows first, then fill in details as they arrive
+		updates <- eksClustersLoadedMsg{clusters: m.awsClient.BasicEKSClusters(names), updates: updates}
+		m.awsClient.DescribeEKSClusters(ctx, names, m.eksDescribeOptions(), func(_ int, cluster aws.EKSCluster) {
+			updates <- eksClusterDescribedMsg{cluster: cluster, updates: updates}
+		})
+	}()
+	return <-updates
+}
+
+// eksDescribeOptions returns the cluster describe settings from the config
+func (m model) eksDescribeOptions() aws.EKSDescribeOptions {
+	opts := aws.DefaultEKSDescribeOptions()
+	if m.config != nil {
+		if m.config.EKSConcurrency > 0 {
+			opts.Concurrency = m.config.EKSConcurrency
+		}
+		if m.config.EKSDescribeTimeout > 0 {
+			opts.Timeout = time.Duration(m.config.EKSDescribeTimeout) * time.Second
+		}
+	}
+	return opts
+}
+
+// sendProgress delivers a paging progress update without blocking the
+// listing; if the UI hasn't consumed the previous update yet it is dropped
+func sendProgress(updates chan tea.Msg, msg tea.Msg) {
+	select {
+	case updates <- msg:
+	default:
+	}
+}
+
+// waitForUpdate waits for the next message from a background listing,
+// returning nil once the listing has finished and closed the channel
+func waitForUpdate(updates <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-updates
+		if !ok {
+			return nil
+		}
+		return msg
+	}
 }
 
 func (m model) loadEKSClusterDetails(clusterName string) tea.Cmd {
@@ -533,8 +982,13 @@ func (m model) loadEKSClusterDetails(clusterName string) tea.Cmd {
 func (m model) updateKubeconfig(clusterName string, clusterRegion string) tea.Cmd {
 	return func() tea.Msg {
 		ctx := context.Background()
-		err := m.awsClient.UpdateKubeconfig(ctx, clusterName, clusterRegion, m.ssoCredentials)
-		return kubeconfigUpdatedMsg{clusterName: clusterName, err: err}
+		opts := aws.KubeconfigOptionsFromConfig(m.config, clusterName)
+		opts.Region = clusterRegion
+		if m.authConfig != nil && m.authConfig.Method == aws.AuthMethodProfile {
+			opts.Profile = m.authConfig.ProfileName
+		}
+		contextName, err := m.awsClient.UpdateKubeconfig(ctx, clusterName, opts)
+		return kubeconfigUpdatedMsg{clusterName: clusterName, contextName: contextName, err: err}
 	}
 }
 
@@ -546,6 +1000,25 @@ func (m model) loadS3Objects(bucket, prefix string, continuationToken *string) t
 	}
 }
 
+// loadS3RestoreStatuses fetches the restore status of the archived objects
+// among objects, or returns nil if there are none
+func (m model) loadS3RestoreStatuses(objects []aws.S3Object) tea.Cmd {
+	var archived []aws.S3Object
+	for _, obj := range objects {
+		if !obj.IsFolder && aws.IsArchiveStorageClass(obj.StorageClass) {
+			archived = append(archived, obj)
+		}
+	}
+	if len(archived) == 0 {
+		return nil
+	}
+	client, bucket := m.awsClient, m.s3CurrentBucket
+	return func() tea.Msg {
+		statuses, err := client.GetRestoreStatuses(context.Background(), bucket, archived)
+		return restoreStatusesLoadedMsg{bucket: bucket, statuses: statuses, err: err}
+	}
+}
+
 func (m model) loadS3ObjectDetails(bucket, key string) tea.Cmd {
 	return func() tea.Msg {
 		ctx := context.Background()
@@ -554,140 +1027,684 @@ func (m model) loadS3ObjectDetails(bucket, key string) tea.Cmd {
 	}
 }
 
-func (m model) downloadS3Object(bucket, key, localPath string) tea.Cmd {
+// loadS3PreviewPage renders the page of the object preview starting at pos
+func (m model) loadS3PreviewPage(pos int64, back bool) tea.Cmd {
+	obj := m.s3Preview
 	return func() tea.Msg {
-		ctx := context.Background()
-		err := m.awsClient.DownloadObject(ctx, bucket, key, localPath)
-		return fileOperationCompletedMsg{operation: "download", err: err}
+		page, err := obj.Page(pos)
+		return previewLoadedMsg{preview: obj, page: page, back: back, err: err}
 	}
 }
 
-func (m model) uploadS3Object(bucket, key, localPath string) tea.Cmd {
+// maxDiffSize is the largest object version that is read to be diffed
+const maxDiffSize = 1 << 20
+
+// loadObjectVersions lists the versions and delete markers of an object
+func (m model) loadObjectVersions(bucket, key string) tea.Cmd {
 	return func() tea.Msg {
 		ctx := context.Background()
-		err := m.awsClient.UploadObject(ctx, bucket, key, localPath)
-		return fileOperationCompletedMsg{operation: "upload", err: err}
+		versions, err := m.awsClient.GetObjectHistory(ctx, bucket, key)
+		return objectVersionsLoadedMsg{key: key, versions: versions, err: err}
 	}
 }
 
-func (m model) deleteS3Object(bucket, key string) tea.Cmd {
+// diffObjectVersions diffs two versions of a text object. A delete marker
+// diffs as an empty file.
+func (m model) diffObjectVersions(bucket, key string, older, newer aws.S3ObjectVersion) tea.Cmd {
 	return func() tea.Msg {
 		ctx := context.Background()
-		err := m.awsClient.DeleteObject(ctx, bucket, key)
-		return s3ActionCompletedMsg{action: "delete object", err: err}
+		var contents [2][]byte
+		for i, version := range []aws.S3ObjectVersion{older, newer} {
+			if version.IsDeleteMarker {
+				continue
+			}
+			data, err := m.awsClient.ReadObjectVersion(ctx, bucket, key, version.VersionId, maxDiffSize)
+			if err != nil {
+				return versionDiffLoadedMsg{err: err}
+			}
+			if !preview.IsText(data) {
+				return versionDiffLoadedMsg{err: fmt.Errorf("version %s is not text", version.VersionId)}
+			}
+			contents[i] = data
+		}
+		d := diff.Diff(older.VersionId, contents[0], newer.VersionId, contents[1])
+		if d == nil {
+			return versionDiffLoadedMsg{err: fmt.Errorf("versions %s and %s are identical", older.VersionId, newer.VersionId)}
+		}
+		return versionDiffLoadedMsg{diff: string(d)}
 	}
 }
 
-func (m model) deleteS3Bucket(bucket string) tea.Cmd {
+// restoreObjectVersion copies a version back on top of its object
+func (m model) restoreObjectVersion(bucket, key string, version aws.S3ObjectVersion) tea.Cmd {
 	return func() tea.Msg {
 		ctx := context.Background()
-		err := m.awsClient.DeleteBucket(ctx, bucket)
-		return s3ActionCompletedMsg{action: "delete bucket", err: err}
+		err := m.awsClient.RestoreObjectVersion(ctx, bucket, key, version.VersionId, version.Size)
+		return versionActionCompletedMsg{action: fmt.Sprintf("Restored version %s of %s", version.VersionId, key), err: err}
 	}
 }
 
-func (m model) createS3Bucket(bucket, region string) tea.Cmd {
+// removeDeleteMarker deletes a delete marker, which undeletes the object
+// if the marker was its latest version
+func (m model) removeDeleteMarker(bucket, key string, version aws.S3ObjectVersion) tea.Cmd {
 	return func() tea.Msg {
 		ctx := context.Background()
-		err := m.awsClient.CreateBucket(ctx, bucket, region)
-		return s3ActionCompletedMsg{action: "create bucket", err: err}
+		err := m.awsClient.DeleteObjectVersion(ctx, bucket, key, version.VersionId)
+		action := fmt.Sprintf("Removed delete marker %s", version.VersionId)
+		if version.IsLatest {
+			action = fmt.Sprintf("Undeleted %s", key)
+		}
+		return versionActionCompletedMsg{action: action, err: err}
 	}
 }
 
-func (m model) copyS3Object(sourceBucket, sourceKey, destBucket, destKey string) tea.Cmd {
-	return func() tea.Msg {
-		ctx := context.Background()
-		err := m.awsClient.CopyObject(ctx, sourceBucket, sourceKey, destBucket, destKey)
-		return s3ActionCompletedMsg{action: "copy object", err: err}
-	}
+// openObjectVersions shows the versions screen for an object
+func (m *model) openObjectVersions(key string) tea.Cmd {
+	if m.currentScreen != s3VersionsScreen {
+		m.s3VersionsReturn = m.currentScreen
+	}
+	m.clearSearch()
+	m.currentScreen = s3VersionsScreen
+	m.s3VersionsKey = key
+	m.s3Versions = nil
+	m.s3VersionIndex = 0
+	m.s3VersionMark = ""
+	m.s3VersionDiff = ""
+	m.viewportOffset = 0
+	m.loading = true
+	return m.loadObjectVersions(m.s3CurrentBucket, key)
 }
 
-func (m model) generatePresignedURL(bucket, key string, expiration int) tea.Cmd {
-	return func() tea.Msg {
-		ctx := context.Background()
-		url, err := m.awsClient.GeneratePresignedURL(ctx, bucket, key, expiration)
-		return presignedURLGeneratedMsg{url: url, err: err}
+// selectedObjectVersion returns the version under the cursor on the
+// versions screen
+func (m model) selectedObjectVersion() (aws.S3ObjectVersion, bool) {
+	if m.currentScreen != s3VersionsScreen || m.s3VersionDiff != "" || m.s3VersionIndex >= len(m.s3Versions) {
+		return aws.S3ObjectVersion{}, false
 	}
+	return m.s3Versions[m.s3VersionIndex], true
 }
 
-func (m model) loadBucketPolicy(bucket string) tea.Cmd {
+// closeObjectVersions goes back from the versions screen, reloading the
+// object or listing since a restore or undelete may have changed it
+func (m *model) closeObjectVersions() tea.Cmd {
+	deleted := len(m.s3Versions) > 0 && m.s3Versions[0].IsDeleteMarker
+	m.currentScreen = m.s3VersionsReturn
+	m.s3Versions = nil
+	m.s3VersionDiff = ""
+	m.viewportOffset = 0
+	m.loading = true
+	if m.currentScreen == s3ObjectDetailsScreen && !deleted {
+		return m.loadS3ObjectDetails(m.s3CurrentBucket, m.s3VersionsKey)
+	}
+	m.currentScreen = s3BrowseScreen
+	m.s3ObjectDetails = nil
+	return m.loadS3Objects(m.s3CurrentBucket, m.s3CurrentPrefix, nil)
+}
+
+func (m model) uploadS3Object(bucket, key, localPath string) tea.Cmd {
 	return func() tea.Msg {
 		ctx := context.Background()
-		policy, err := m.awsClient.GetBucketPolicy(ctx, bucket)
-		return bucketPolicyLoadedMsg{policy: policy, err: err}
+		err := m.awsClient.UploadObject(ctx, bucket, key, localPath)
+		return fileOperationCompletedMsg{operation: "upload", err: err}
 	}
 }
 
-func (m model) loadBucketVersioning(bucket string) tea.Cmd {
+func (m model) deleteS3Object(bucket, key string) tea.Cmd {
 	return func() tea.Msg {
 		ctx := context.Background()
-		versioning, err := m.awsClient.GetBucketVersioning(ctx, bucket)
-		return bucketVersioningLoadedMsg{versioning: versioning, err: err}
+		err := m.awsClient.DeleteObject(ctx, bucket, key)
+		return s3ActionCompletedMsg{action: "delete object", err: err}
 	}
 }
 
-func (m model) loadEC2InstanceDetails(instanceID string) tea.Cmd {
-	return func() tea.Msg {
-		ctx := context.Background()
-		details, err := m.awsClient.GetInstanceDetails(ctx, instanceID)
-		return instanceDetailsLoadedMsg{details: details, err: err}
+// s3VisibleObjects returns the objects listed in the browser, narrowed by
+// the active search
+func (m model) s3VisibleObjects() []aws.S3Object {
+	if len(m.s3FilteredObjects) > 0 {
+		return m.s3FilteredObjects
 	}
+	return m.s3Objects
 }
 
-func (m model) loadInstanceStatus(instanceID string) tea.Cmd {
-	return func() tea.Msg {
-		ctx := context.Background()
-		status, err := m.awsClient.GetInstanceStatus(ctx, instanceID)
-		return instanceStatusLoadedMsg{status: status, err: err}
+// s3VisibleBuckets returns the listed buckets, narrowed by the active search
+func (m model) s3VisibleBuckets() []aws.Bucket {
+	if len(m.s3FilteredBuckets) > 0 {
+		return m.s3FilteredBuckets
 	}
+	return m.s3Buckets
 }
 
-func (m model) loadInstanceMetrics(instanceID string) tea.Cmd {
-	return func() tea.Msg {
-		ctx := context.Background()
-		metrics, err := m.awsClient.GetInstanceMetrics(ctx, instanceID)
-		return instanceMetricsLoadedMsg{metrics: metrics, err: err}
+// s3SelectionKeys returns the keys an S3 operation applies to: the
+// selected objects and folders, or the highlighted one if none are
+// selected
+func (m model) s3SelectionKeys() []string {
+	if m.currentScreen != s3BrowseScreen {
+		return nil
 	}
+	if len(m.s3SelectedObjects) > 0 {
+		keys := make([]string, 0, len(m.s3SelectedObjects))
+		for key := range m.s3SelectedObjects {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return keys
+	}
+	objects := m.s3VisibleObjects()
+	if m.s3ObjectSelectedIndex < len(objects) {
+		return []string{objects[m.s3ObjectSelectedIndex].Key}
+	}
+	return nil
 }
 
-func (m model) loadSSMStatus(instanceID string) tea.Cmd {
-	return func() tea.Msg {
-		ctx := context.Background()
-		status, err := m.awsClient.CheckSSMConnectivity(ctx, instanceID)
-		return ssmStatusLoadedMsg{status: status, err: err}
+// ec2SelectionIDs returns the instances an EC2 operation applies to: the
+// selected instances, or the highlighted or open one if none are selected
+func (m model) ec2SelectionIDs() []string {
+	switch m.currentScreen {
+	case ec2Screen:
+		if len(m.ec2SelectedInstances) > 0 {
+			ids := make([]string, 0, len(m.ec2SelectedInstances))
+			for id := range m.ec2SelectedInstances {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+			return ids
+		}
+		instances := m.ec2Instances
+		if len(m.ec2FilteredInstances) > 0 {
+			instances = m.ec2FilteredInstances
+		}
+		if m.ec2SelectedIndex < len(instances) {
+			return []string{instances[m.ec2SelectedIndex].ID}
+		}
+	case ec2DetailsScreen:
+		if m.ec2InstanceDetails != nil {
+			return []string{m.ec2InstanceDetails.ID}
+		}
 	}
+	return nil
 }
 
-func (m model) performInstanceAction(action string, instanceID string) tea.Cmd {
-	return func() tea.Msg {
-		ctx := context.Background()
-		var err error
+// applyEC2Filter reloads the instance list with a server-side filter, from
+// the named view if there is one
+func (m *model) applyEC2Filter(filter aws.InstanceFilter, view string) tea.Cmd {
+	m.ec2Filter = filter
+	m.ec2View = view
+	m.ec2SelectedInstances = make(map[string]bool)
+	m.ec2SelectedIndex = 0
+	m.clearSearch()
+	m.currentScreen = ec2Screen
+	m.viewportOffset = 0
+	m.loading = true
+	if filter.Empty() {
+		m.statusMessage = "EC2 filter cleared"
+	} else {
+		m.statusMessage = "Filtering instances: " + filter.String()
+	}
+	return m.loadEC2Instances
+}
 
-		switch action {
-		case "start":
-			err = m.awsClient.StartInstance(ctx, instanceID)
-		case "stop":
-			err = m.awsClient.StopInstance(ctx, instanceID)
-		case "reboot":
-			err = m.awsClient.RebootInstance(ctx, instanceID)
-		case "terminate":
-			err = m.awsClient.TerminateInstance(ctx, instanceID)
+// s3RowNames returns the names listed on the S3 screen: bucket names, or
+// object and folder names below the current prefix
+func (m model) s3RowNames() []string {
+	var names []string
+	switch m.currentScreen {
+	case s3Screen:
+		for _, bucket := range m.s3VisibleBuckets() {
+			names = append(names, bucket.Name)
+		}
+	case s3BrowseScreen:
+		for _, obj := range m.s3VisibleObjects() {
+			names = append(names, strings.TrimPrefix(obj.Key, m.s3CurrentPrefix))
 		}
+	}
+	return names
+}
 
-		return instanceActionCompletedMsg{action: action, err: err}
+// s3Cursor returns the highlighted row of the S3 screen
+func (m model) s3Cursor() int {
+	if m.currentScreen == s3Screen {
+		return m.s3SelectedIndex
 	}
+	return m.s3ObjectSelectedIndex
 }
 
-func (m model) performBulkAction(action string, instanceIDs []string) tea.Cmd {
-	return func() tea.Msg {
-		ctx := context.Background()
-		successCount := 0
-		failureCount := 0
+// s3RowSelected reports whether a listed bucket or object is selected
+func (m model) s3RowSelected(i int) bool {
+	switch m.currentScreen {
+	case s3Screen:
+		buckets := m.s3VisibleBuckets()
+		return i < len(buckets) && m.s3SelectedBuckets[buckets[i].Name]
+	case s3BrowseScreen:
+		objects := m.s3VisibleObjects()
+		return i < len(objects) && m.s3SelectedObjects[objects[i].Key]
+	}
+	return false
+}
 
-		for _, instanceID := range instanceIDs {
-			var err error
-			switch action {
-			case "start":
+// setS3RowSelected selects or deselects a listed bucket or object
+func (m *model) setS3RowSelected(i int, selected bool) {
+	switch m.currentScreen {
+	case s3Screen:
+		buckets := m.s3VisibleBuckets()
+		if i >= len(buckets) {
+			return
+		}
+		if selected {
+			m.s3SelectedBuckets[buckets[i].Name] = true
+		} else {
+			delete(m.s3SelectedBuckets, buckets[i].Name)
+		}
+	case s3BrowseScreen:
+		objects := m.s3VisibleObjects()
+		if i >= len(objects) {
+			return
+		}
+		if selected {
+			m.s3SelectionRoot = m.s3CurrentBucket + "/" + m.s3CurrentPrefix
+			m.s3SelectedObjects[objects[i].Key] = true
+		} else {
+			delete(m.s3SelectedObjects, objects[i].Key)
+		}
+	}
+}
+
+// s3SelectedCount returns how many rows are selected on the S3 screen
+func (m model) s3SelectedCount() int {
+	if m.currentScreen == s3Screen {
+		return len(m.s3SelectedBuckets)
+	}
+	return len(m.s3SelectedObjects)
+}
+
+// selectS3Range starts a range selection on the highlighted row, or, if
+// one was started, selects every row from there to the highlighted row
+func (m *model) selectS3Range() {
+	cursor := m.s3Cursor()
+	if !m.s3RangeActive {
+		m.s3RangeActive = true
+		m.s3RangeAnchor = cursor
+		m.statusMessage = "Range select: move to the last row and press V again"
+		return
+	}
+
+	m.s3RangeActive = false
+	from, to := m.s3RangeAnchor, cursor
+	if from > to {
+		from, to = to, from
+	}
+	for i := from; i <= to; i++ {
+		m.setS3RowSelected(i, true)
+	}
+	m.statusMessage = fmt.Sprintf("Selected %d rows (%d selected)", to-from+1, m.s3SelectedCount())
+}
+
+// inS3Range reports whether a row is inside the range selection in
+// progress
+func (m model) inS3Range(i int) bool {
+	if !m.s3RangeActive {
+		return false
+	}
+	from, to := m.s3RangeAnchor, m.s3Cursor()
+	if from > to {
+		from, to = to, from
+	}
+	return i >= from && i <= to
+}
+
+// selectS3Matching selects or deselects the listed rows whose name matches
+// a glob such as "*.log", returning how many matched
+func (m *model) selectS3Matching(pattern string, selected bool) (int, error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return 0, err
+	}
+	matched := 0
+	for i, name := range m.s3RowNames() {
+		if ok, _ := filepath.Match(pattern, strings.TrimSuffix(name, "/")); ok {
+			m.setS3RowSelected(i, selected)
+			matched++
+		}
+	}
+	return matched, nil
+}
+
+// clearS3Selection deselects every bucket and object
+func (m *model) clearS3Selection() {
+	m.s3SelectedObjects = make(map[string]bool)
+	m.s3SelectedBuckets = make(map[string]bool)
+	m.s3RangeActive = false
+}
+
+// startS3Batch lists every object under keys for op; the operation starts,
+// or asks for confirmation, once the listing arrives
+func (m *model) startS3Batch(op string, keys []string) tea.Cmd {
+	if m.s3BatchTotal > 0 {
+		m.statusMessage = "Wait for the running S3 operation to finish"
+		return nil
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	if op == "download" && m.transfers == nil {
+		m.statusMessage = "Transfers are not available"
+		return nil
+	}
+
+	// Typing the name of a single object or folder confirms it; a selection
+	// is confirmed by its object count once it is known
+	confirm := ""
+	if len(keys) == 1 {
+		confirm = keys[0]
+	}
+
+	client, bucket := m.awsClient, m.s3CurrentBucket
+	m.s3BatchOp = op
+	m.s3BatchRoot = m.s3CurrentPrefix
+	m.statusMessage = fmt.Sprintf("Listing objects to %s...", op)
+	return func() tea.Msg {
+		objects, err := client.ExpandS3Keys(context.Background(), bucket, keys)
+		if confirm == "" {
+			confirm = strconv.Itoa(len(objects))
+		}
+		return s3SelectionExpandedMsg{op: op, objects: objects, confirm: confirm, err: err}
+	}
+}
+
+// runS3Batch runs the pending operation in the background, reporting its
+// progress
+func (m *model) runS3Batch() tea.Cmd {
+	op, bucket, root := m.s3BatchOp, m.s3CurrentBucket, m.s3BatchRoot
+	objects, destBucket, destPrefix := m.s3BatchObjects, m.s3BatchDestBucket, m.s3BatchDestPrefix
+	storageClass, tags := m.s3BatchStorageClass, m.s3BatchTags
+	restoreTier, restoreDays := m.s3BatchRestoreTier, m.s3BatchRestoreDays
+	m.s3BatchObjects = nil
+	m.s3BatchDone = 0
+	m.s3BatchTotal = len(objects)
+	m.s3BatchResult = nil
+
+	client := m.awsClient
+	updates := make(chan tea.Msg, 1)
+	go func() {
+		ctx := context.Background()
+		progress := func(done, total int) {
+			sendProgress(updates, s3BatchProgressMsg{done: done, total: total, updates: updates})
+		}
+		var result *aws.BatchResult
+		switch op {
+		case "delete":
+			result = client.DeleteObjects(ctx, bucket, objects, progress)
+		case "copy":
+			result = client.CopyObjects(ctx, bucket, root, objects, destBucket, destPrefix, progress)
+		case "move":
+			result = client.MoveObjects(ctx, bucket, root, objects, destBucket, destPrefix, progress)
+		case "change storage class":
+			result = client.ChangeStorageClass(ctx, bucket, objects, storageClass, progress)
+		case "tag":
+			result = client.AddObjectTags(ctx, bucket, objects, tags, progress)
+		case "restore":
+			result = client.RestoreObjects(ctx, bucket, objects, restoreTier, restoreDays, progress)
+		case "presign":
+			var urls []aws.PresignedObject
+			urls, result = client.PresignObjects(ctx, bucket, objects, 3600)
+			updates <- s3BatchCompletedMsg{op: op, result: result, urls: formatPresignedURLs(urls)}
+			return
+		}
+		updates <- s3BatchCompletedMsg{op: op, result: result}
+	}()
+	return waitForUpdate(updates)
+}
+
+// startS3Search searches every object below the current prefix in the
+// background, reporting how many have been scanned
+func (m *model) startS3Search(terms []string) tea.Cmd {
+	q, err := aws.ParseS3SearchQuery(terms, time.Now())
+	if err != nil {
+		m.statusMessage = err.Error()
+		return nil
+	}
+	m.stopS3Search()
+	ctx, cancel := context.WithCancel(context.Background())
+	m.s3SearchCancel = cancel
+	m.s3SearchScanned = 0
+	m.s3SearchMatched = 0
+	m.loading = true
+	m.statusMessage = ""
+
+	id, text := m.s3SearchID, strings.Join(terms, " ")
+	client, bucket, prefix := m.awsClient, m.s3CurrentBucket, m.s3CurrentPrefix
+	updates := make(chan tea.Msg, 1)
+	go func() {
+		result, err := client.SearchObjects(ctx, bucket, prefix, q, func(scanned, matched int) {
+			sendProgress(updates, s3SearchProgressMsg{id: id, scanned: scanned, matched: matched, updates: updates})
+		})
+		// Nothing reads the updates of a superseded search, so don't wait
+		// for room once it has been cancelled
+		select {
+		case updates <- s3SearchCompletedMsg{id: id, terms: text, result: result, err: err}:
+		case <-ctx.Done():
+		}
+	}()
+	return waitForUpdate(updates)
+}
+
+// stopS3Search cancels the running search, if any, and makes its messages
+// stale
+func (m *model) stopS3Search() {
+	m.s3SearchID++
+	if m.s3SearchCancel != nil {
+		m.s3SearchCancel()
+		m.s3SearchCancel = nil
+		m.loading = false
+	}
+}
+
+// reloadS3Objects reloads the listing being shown, running the search
+// again if it shows search results
+func (m *model) reloadS3Objects() tea.Cmd {
+	if m.s3Search != "" {
+		return m.startS3Search(strings.Fields(m.s3Search))
+	}
+	m.loading = true
+	return m.loadS3Objects(m.s3CurrentBucket, m.s3CurrentPrefix, nil)
+}
+
+// parseS3Destination splits "s3://bucket/prefix" or "bucket/prefix" into a
+// bucket and a folder prefix ending in "/"
+func parseS3Destination(dest string) (string, string, bool) {
+	bucket, prefix, _ := strings.Cut(strings.TrimPrefix(dest, "s3://"), "/")
+	if bucket == "" {
+		return "", "", false
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return bucket, prefix, true
+}
+
+// parseS3Tags parses KEY=VALUE arguments into tags
+func parseS3Tags(args []string) (map[string]string, error) {
+	tags := make(map[string]string)
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("expected KEY=VALUE, got %q", arg)
+		}
+		tags[key] = value
+	}
+	return tags, nil
+}
+
+// formatPresignedURLs lists presigned URLs, one "key<TAB>url" line per
+// object. They carry credentials, so they are only shown and copied to the
+// clipboard, never written to disk.
+func formatPresignedURLs(urls []aws.PresignedObject) string {
+	lines := make([]string, len(urls))
+	for i, u := range urls {
+		lines[i] = u.Key + "\t" + u.URL
+	}
+	return strings.Join(lines, "\n")
+}
+
+// maxPresignedLines is how much of a presigned upload or list of URLs is
+// shown; y copies all of it
+const maxPresignedLines = 20
+
+func (m model) deleteS3Bucket(bucket string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		err := m.awsClient.DeleteBucket(ctx, bucket)
+		return s3ActionCompletedMsg{action: "delete bucket", err: err}
+	}
+}
+
+// deleteS3Buckets deletes the selected buckets, which must be empty
+func (m model) deleteS3Buckets(names []string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		msg := bulkActionCompletedMsg{action: "delete", resource: "buckets"}
+		for _, name := range names {
+			if err := m.awsClient.DeleteBucket(ctx, name); err != nil {
+				msg.failureCount++
+				if msg.firstErr == nil {
+					msg.firstErr = fmt.Errorf("%s: %w", name, err)
+				}
+			} else {
+				msg.successCount++
+			}
+		}
+		return msg
+	}
+}
+
+func (m model) createS3Bucket(bucket, region string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		err := m.awsClient.CreateBucket(ctx, bucket, region)
+		return s3ActionCompletedMsg{action: "create bucket", err: err}
+	}
+}
+
+func (m model) copyS3Object(sourceBucket, sourceKey, destBucket, destKey string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		err := m.awsClient.CopyObject(ctx, sourceBucket, sourceKey, destBucket, destKey)
+		return s3ActionCompletedMsg{action: "copy object", err: err}
+	}
+}
+
+func (m model) generatePresignedURL(bucket, key string, expiration int) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		url, err := m.awsClient.GeneratePresignedURL(ctx, bucket, key, expiration)
+		return presignedURLGeneratedMsg{url: url, err: err}
+	}
+}
+
+// presignPut presigns a PUT of key and returns the curl command for it
+func (m model) presignPut(bucket, key string, opts aws.PresignPutOptions) tea.Cmd {
+	return func() tea.Msg {
+		put, err := m.awsClient.PresignPutObject(context.Background(), bucket, key, opts)
+		if err != nil {
+			return presignedUploadMsg{err: err}
+		}
+		title := fmt.Sprintf("Presigned PUT of s3://%s/%s (expires %s)", bucket, key, put.Expires.Format("2006-01-02 15:04"))
+		return presignedUploadMsg{title: title, snippet: put.CurlCommand()}
+	}
+}
+
+// presignPost signs a POST policy for uploads below opts.KeyPrefix and
+// returns a curl command for it, or an HTML form when asForm is set
+func (m model) presignPost(bucket string, opts aws.PresignPostOptions, asForm bool) tea.Cmd {
+	return func() tea.Msg {
+		post, err := m.awsClient.PresignPostPolicy(context.Background(), bucket, opts)
+		if err != nil {
+			return presignedUploadMsg{err: err}
+		}
+		title := fmt.Sprintf("Presigned POST to s3://%s/%s* (expires %s)", bucket, opts.KeyPrefix, post.Expires.Format("2006-01-02 15:04"))
+		if asForm {
+			return presignedUploadMsg{title: title, snippet: post.HTMLForm()}
+		}
+		return presignedUploadMsg{title: title, snippet: post.CurlCommand()}
+	}
+}
+
+func (m model) loadBucketPolicy(bucket string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		policy, err := m.awsClient.GetBucketPolicy(ctx, bucket)
+		return bucketPolicyLoadedMsg{policy: policy, err: err}
+	}
+}
+
+func (m model) loadBucketVersioning(bucket string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		versioning, err := m.awsClient.GetBucketVersioning(ctx, bucket)
+		return bucketVersioningLoadedMsg{versioning: versioning, err: err}
+	}
+}
+
+func (m model) loadEC2InstanceDetails(instanceID string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		details, err := m.awsClient.GetInstanceDetails(ctx, instanceID)
+		return instanceDetailsLoadedMsg{details: details, err: err}
+	}
+}
+
+func (m model) loadInstanceStatus(instanceID string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		status, err := m.awsClient.GetInstanceStatus(ctx, instanceID)
+		return instanceStatusLoadedMsg{status: status, err: err}
+	}
+}
+
+func (m model) loadInstanceMetricHistory(instanceID string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		history, err := m.awsClient.GetInstanceMetricHistory(ctx, instanceID, aws.MetricRanges[m.ec2MetricRange])
+		return instanceMetricHistoryLoadedMsg{history: history, err: err}
+	}
+}
+
+func (m model) loadSSMStatus(instanceID string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		status, err := m.awsClient.CheckSSMConnectivity(ctx, instanceID)
+		return ssmStatusLoadedMsg{status: status, err: err}
+	}
+}
+
+func (m model) performInstanceAction(action string, instanceID string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		var err error
+
+		switch action {
+		case "start":
+			err = m.awsClient.StartInstance(ctx, instanceID)
+		case "stop":
+			err = m.awsClient.StopInstance(ctx, instanceID)
+		case "reboot":
+			err = m.awsClient.RebootInstance(ctx, instanceID)
+		case "terminate":
+			err = m.awsClient.TerminateInstance(ctx, instanceID)
+		}
+
+		return instanceActionCompletedMsg{action: action, err: err}
+	}
+}
+
+func (m model) performBulkAction(action string, instanceIDs []string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		successCount := 0
+		failureCount := 0
+
+		for _, instanceID := range instanceIDs {
+			var err error
+			switch action {
+			case "start":
 				err = m.awsClient.StartInstance(ctx, instanceID)
 			case "stop":
 				err = m.awsClient.StopInstance(ctx, instanceID)
@@ -697,23 +1714,398 @@ func (m model) performBulkAction(action string, instanceIDs []string) tea.Cmd {
 				err = m.awsClient.TerminateInstance(ctx, instanceID)
 			}
 
-			if err != nil {
-				failureCount++
-			} else {
-				successCount++
+			if err != nil {
+				failureCount++
+			} else {
+				successCount++
+			}
+		}
+
+		return bulkActionCompletedMsg{
+			action:       action,
+			resource:     "instances",
+			successCount: successCount,
+			failureCount: failureCount,
+		}
+	}
+}
+
+func (m model) loadAlarms() tea.Msg {
+	alarms, err := m.awsClient.ListAlarms(context.Background())
+	return alarmsLoadedMsg{alarms: alarms, err: err}
+}
+
+func (m model) loadAlarmHistory(alarmName string) tea.Cmd {
+	return func() tea.Msg {
+		history, err := m.awsClient.GetAlarmHistory(context.Background(), alarmName)
+		return alarmHistoryLoadedMsg{alarmName: alarmName, history: history, err: err}
+	}
+}
+
+// toggleAlarmActions enables or disables an alarm's actions
+func (m model) toggleAlarmActions(alarm aws.Alarm) tea.Cmd {
+	return func() tea.Msg {
+		action := "enable actions"
+		if alarm.ActionsEnabled {
+			action = "disable actions"
+		}
+		err := m.awsClient.SetAlarmActionsEnabled(context.Background(), alarm.Name, !alarm.ActionsEnabled)
+		return alarmActionCompletedMsg{action: action, alarmName: alarm.Name, err: err}
+	}
+}
+
+// setAlarmState sets an alarm's state to test its actions
+func (m model) setAlarmState(alarmName, state string) tea.Cmd {
+	return func() tea.Msg {
+		err := m.awsClient.SetAlarmState(context.Background(), alarmName, state, "Set from lazyaws for testing")
+		return alarmActionCompletedMsg{action: "set state to " + state, alarmName: alarmName, err: err}
+	}
+}
+
+// selectedAlarm returns the alarm highlighted in the list or shown in details
+func (m model) selectedAlarm() (aws.Alarm, bool) {
+	switch m.currentScreen {
+	case alarmDetailsScreen:
+		if m.alarmDetails != nil {
+			return *m.alarmDetails, true
+		}
+	case alarmsScreen:
+		alarms := m.alarms
+		if len(m.alarmsFiltered) > 0 {
+			alarms = m.alarmsFiltered
+		}
+		if m.alarmSelectedIndex < len(alarms) {
+			return alarms[m.alarmSelectedIndex], true
+		}
+	}
+	return aws.Alarm{}, false
+}
+
+// Log tailing and Insights polling
+const (
+	logTailInterval  = 2 * time.Second
+	logTailBacklog   = 5 * time.Minute // History shown when a tail starts
+	logTailMaxEvents = 2000            // Oldest events are dropped beyond this
+	insightsInterval = time.Second
+)
+
+// defaultInsightsQuery is the query the Insights editor starts with
+const defaultInsightsQuery = "fields @timestamp, @message | sort @timestamp desc | limit 100"
+
+func (m model) loadLogGroups() tea.Msg {
+	groups, err := m.awsClient.ListLogGroups(context.Background(), "")
+	return logGroupsLoadedMsg{groups: groups, err: err}
+}
+
+func (m model) loadLogStreams(groupName string) tea.Cmd {
+	return func() tea.Msg {
+		streams, err := m.awsClient.ListLogStreams(context.Background(), groupName)
+		return logStreamsLoadedMsg{groupName: groupName, streams: streams, err: err}
+	}
+}
+
+// startLogTail switches to the tail screen following groupName, or only
+// streamNames of it when given
+func (m *model) startLogTail(groupName string, streamNames []string, filterPattern string) tea.Cmd {
+	if m.currentScreen != logTailScreen {
+		m.logReturnScreen = m.currentScreen
+	}
+	m.logTail = m.awsClient.NewLogTail(groupName, streamNames, filterPattern, logTailBacklog)
+	m.logEvents = nil
+	m.logEventIndex = 0
+	m.logTailPaused = false
+	m.currentScreen = logTailScreen
+	m.viewportOffset = 0
+	return pollLogTail(m.logTail)
+}
+
+// pollLogTail fetches the events that arrived since the tail's last poll
+func pollLogTail(tail *aws.LogTail) tea.Cmd {
+	return func() tea.Msg {
+		events, err := tail.Poll(context.Background())
+		return logEventsLoadedMsg{tail: tail, events: events, err: err}
+	}
+}
+
+// logTailTickCmd schedules the next poll of a tail
+func logTailTickCmd(tail *aws.LogTail) tea.Cmd {
+	return tea.Tick(logTailInterval, func(t time.Time) tea.Msg {
+		return logTailTickMsg{tail: tail}
+	})
+}
+
+// loadClusterLogStreams finds the control plane streams of a cluster's
+// enabled log types
+func (m model) loadClusterLogStreams(clusterName string, logTypes []string) tea.Cmd {
+	return func() tea.Msg {
+		streams, err := m.awsClient.GetClusterLogStreams(context.Background(), clusterName, logTypes)
+		return clusterLogStreamsLoadedMsg{clusterName: clusterName, streams: streams, err: err}
+	}
+}
+
+// openInsights switches to the Insights screen querying groupNames
+func (m *model) openInsights(groupNames []string) tea.Cmd {
+	if m.currentScreen != logInsightsScreen {
+		m.logReturnScreen = m.currentScreen
+	}
+	m.insightsGroups = groupNames
+	m.currentScreen = logInsightsScreen
+	m.viewportOffset = 0
+	if m.savedQueries == nil {
+		return m.loadSavedQueries
+	}
+	return nil
+}
+
+func (m model) runInsightsQuery() tea.Cmd {
+	groups := m.insightsGroups
+	query := m.insightsQueryInput.Value()
+	window := aws.MetricRanges[m.insightsRange].Duration
+	return func() tea.Msg {
+		queryID, err := m.awsClient.StartInsightsQuery(context.Background(), groups, query, window)
+		return insightsQueryStartedMsg{queryID: queryID, err: err}
+	}
+}
+
+func (m model) loadInsightsResults(queryID string) tea.Cmd {
+	return func() tea.Msg {
+		result, err := m.awsClient.GetInsightsQueryResults(context.Background(), queryID)
+		return insightsResultsLoadedMsg{result: result, err: err}
+	}
+}
+
+// insightsTickCmd schedules the next results poll of a running query
+func insightsTickCmd(queryID string) tea.Cmd {
+	return tea.Tick(insightsInterval, func(t time.Time) tea.Msg {
+		return insightsTickMsg{queryID: queryID}
+	})
+}
+
+func (m model) stopInsightsQuery(queryID string) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.awsClient.StopInsightsQuery(context.Background(), queryID); err != nil {
+			return insightsResultsLoadedMsg{err: err}
+		}
+		result, err := m.awsClient.GetInsightsQueryResults(context.Background(), queryID)
+		return insightsResultsLoadedMsg{result: result, err: err}
+	}
+}
+
+func (m model) loadSavedQueries() tea.Msg {
+	queries, err := m.awsClient.ListSavedQueries(context.Background())
+	return savedQueriesLoadedMsg{queries: queries, err: err}
+}
+
+func (m model) saveInsightsQuery(name string) tea.Cmd {
+	groups := m.insightsGroups
+	query := m.insightsQueryInput.Value()
+	return func() tea.Msg {
+		_, err := m.awsClient.SaveQuery(context.Background(), name, query, groups)
+		return querySavedMsg{name: name, err: err}
+	}
+}
+
+// selectedLogGroup returns the group highlighted in the log groups list
+func (m model) selectedLogGroup() (aws.LogGroup, bool) {
+	groups := m.logGroups
+	if len(m.logGroupsFiltered) > 0 {
+		groups = m.logGroupsFiltered
+	}
+	if m.currentScreen == logGroupsScreen && m.logGroupIndex < len(groups) {
+		return groups[m.logGroupIndex], true
+	}
+	return aws.LogGroup{}, false
+}
+
+// startPortForward starts a managed forward through instanceID
+func (m model) startPortForward(opts aws.PortForwardOptions) tea.Cmd {
+	return func() tea.Msg {
+		info, err := m.portForwards.Start(m.awsClient, opts)
+		return portForwardActionCompletedMsg{action: "start", info: info, err: err}
+	}
+}
+
+// portForwardAction stops, restarts, duplicates or removes a managed forward
+func (m model) portForwardAction(action string, id int) tea.Cmd {
+	return func() tea.Msg {
+		var info aws.PortForwardInfo
+		var err error
+		switch action {
+		case "stop":
+			err = m.portForwards.Stop(id)
+		case "restart":
+			info, err = m.portForwards.Restart(id)
+		case "duplicate":
+			info, err = m.portForwards.Duplicate(id)
+		case "remove":
+			err = m.portForwards.Remove(id)
+		}
+		return portForwardActionCompletedMsg{action: action, info: info, err: err}
+	}
+}
+
+// restorePortForwards starts the saved forwards for the current client
+func (m model) restorePortForwards() tea.Msg {
+	if m.portForwards == nil || m.awsClient == nil {
+		return nil
+	}
+	count, err := m.portForwards.Restore(m.awsClient)
+	return portForwardsRestoredMsg{count: count, err: err}
+}
+
+// portForwardTickCmd refreshes the port forwards panel every second
+func portForwardTickCmd() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+		return portForwardTickMsg{}
+	})
+}
+
+// refreshPortForwards takes a new snapshot of the managed forwards
+func (m *model) refreshPortForwards() {
+	if m.portForwards == nil {
+		return
+	}
+	m.portForwardList = m.portForwards.List()
+	if m.portForwardIndex >= len(m.portForwardList) {
+		m.portForwardIndex = len(m.portForwardList) - 1
+	}
+	if m.portForwardIndex < 0 {
+		m.portForwardIndex = 0
+	}
+}
+
+// queueTransfer adds an upload or download to the transfer queue
+func (m *model) queueTransfer(req aws.TransferRequest) {
+	if m.transfers == nil {
+		m.statusMessage = "Transfers are not available"
+		return
+	}
+	m.transfers.Enqueue(m.awsClient, req)
+	verb := "Downloading"
+	if req.Kind == aws.TransferUpload {
+		verb = "Uploading"
+	}
+	m.statusMessage = fmt.Sprintf("%s %s in the background (:transfers)", verb, filepath.Base(req.LocalPath))
+}
+
+// parseSyncArgs parses the arguments of :sync, "up|down DIR" followed by
+// --delete, --exclude GLOB and --include GLOB
+func parseSyncArgs(args []string) (up bool, dir string, opts aws.SyncOptions, err error) {
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--delete":
+			opts.Delete = true
+		case "--exclude", "--include":
+			if i+1 >= len(args) {
+				return false, "", opts, fmt.Errorf("%s needs a pattern", args[i])
 			}
+			opts.Filters = append(opts.Filters, aws.SyncFilter{Pattern: args[i+1], Exclude: args[i] == "--exclude"})
+			i++
+		default:
+			positional = append(positional, args[i])
 		}
+	}
+	if len(positional) != 2 || (positional[0] != "up" && positional[0] != "down") {
+		return false, "", opts, fmt.Errorf("usage: :sync up|down DIR [--delete] [--exclude GLOB] [--include GLOB]")
+	}
 
-		return bulkActionCompletedMsg{
-			action:       action,
-			successCount: successCount,
-			failureCount: failureCount,
+	dir = positional[1]
+	if strings.HasPrefix(dir, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, dir[2:])
+		}
+	}
+	return positional[0] == "up", dir, opts, nil
+}
+
+// planSync compares the current S3 prefix with a local directory
+func (m model) planSync(up bool, dir string, opts aws.SyncOptions) tea.Cmd {
+	bucket, prefix := m.s3CurrentBucket, m.s3CurrentPrefix
+	return func() tea.Msg {
+		ctx := context.Background()
+		var plan *aws.SyncPlan
+		var err error
+		if up {
+			plan, err = m.awsClient.PlanSyncLocalToS3(ctx, dir, bucket, prefix, opts)
+		} else {
+			plan, err = m.awsClient.PlanSyncS3ToLocal(ctx, bucket, prefix, dir, opts)
 		}
+		return syncPlannedMsg{plan: plan, err: err}
+	}
+}
+
+// executeSync queues the copies of a reviewed plan and runs its deletes
+func (m model) executeSync(plan *aws.SyncPlan) tea.Cmd {
+	return func() tea.Msg {
+		err := m.awsClient.ExecuteSyncPlan(context.Background(), plan, m.transfers)
+		return syncExecutedMsg{plan: plan, err: err}
+	}
+}
+
+// transferTickCmd refreshes the transfer queue twice a second
+func transferTickCmd() tea.Cmd {
+	return tea.Tick(500*time.Millisecond, func(t time.Time) tea.Msg {
+		return transferTickMsg{}
+	})
+}
+
+// refreshTransfers takes a new snapshot of the transfer queue
+func (m *model) refreshTransfers() {
+	if m.transfers == nil {
+		return
+	}
+	m.transferList = m.transfers.List()
+	m.transferTotals = m.transfers.Totals()
+	if m.transferIndex >= len(m.transferList) {
+		m.transferIndex = len(m.transferList) - 1
+	}
+	if m.transferIndex < 0 {
+		m.transferIndex = 0
+	}
+}
+
+// selectedTransfer returns the transfer highlighted in the queue
+func (m model) selectedTransfer() (aws.TransferInfo, bool) {
+	if m.currentScreen != transfersScreen || m.transferIndex >= len(m.transferList) {
+		return aws.TransferInfo{}, false
+	}
+	return m.transferList[m.transferIndex], true
+}
+
+// transferAction pauses, resumes, cancels, retries or removes a transfer
+func (m *model) transferAction(action string, id int) {
+	var err error
+	switch action {
+	case "pause":
+		err = m.transfers.Pause(id)
+	case "resume":
+		err = m.transfers.Resume(id)
+	case "cancel":
+		err = m.transfers.Cancel(id)
+	case "retry":
+		err = m.transfers.Retry(id)
+	case "remove":
+		err = m.transfers.Remove(id)
+	}
+	if err != nil {
+		m.statusMessage = fmt.Sprintf("Transfer %s failed: %v", action, err)
+	} else {
+		m.statusMessage = fmt.Sprintf("Transfer %d: %s", id, action)
+	}
+	m.refreshTransfers()
+}
+
+// selectedPortForward returns the forward highlighted in the panel
+func (m model) selectedPortForward() (aws.PortForwardInfo, bool) {
+	if m.currentScreen != portForwardsScreen || m.portForwardIndex >= len(m.portForwardList) {
+		return aws.PortForwardInfo{}, false
 	}
+	return m.portForwardList[m.portForwardIndex], true
 }
 
-func tickCmd() tea.Cmd {
-	return tea.Tick(30*time.Second, func(t time.Time) tea.Msg {
+func (m model) tickCmd() tea.Cmd {
+	return tea.Tick(time.Duration(m.autoRefreshInterval)*time.Second, func(t time.Time) tea.Msg {
 		return tickMsg{}
 	})
 }
@@ -744,7 +2136,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						return m, nil
 					}
 					m.authConfig = authConfig
-					m.currentScreen = ec2Screen
+					m.currentScreen = screenFromConfig(m.config)
 					m.loading = true
 					return m, m.initAWSClient
 				case 1: // AWS Profile
@@ -757,127 +2149,328 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return m, nil
 				}
 			case "esc":
-				return m, tea.Quit
+				return m, tea.Quit
+			}
+		}
+		return m, nil
+	}
+
+	// Handle AWS Profile configuration
+	if m.configuringProfile && m.currentScreen == authProfileScreen {
+		switch msg := msg.(type) {
+		case tea.KeyMsg:
+			switch msg.String() {
+			case "enter":
+				profileName := m.profileInput.Value()
+				if profileName == "" {
+					profileName = "default"
+				}
+				if err := aws.ValidateProfileName(profileName); err != nil {
+					m.statusMessage = fmt.Sprintf("Invalid profile name: %v", err)
+					return m, nil
+				}
+				// Save profile config
+				authConfig := &aws.AuthConfig{
+					Method:      aws.AuthMethodProfile,
+					ProfileName: profileName,
+				}
+				if err := aws.SaveAuthConfig(authConfig); err != nil {
+					m.statusMessage = fmt.Sprintf("Failed to save config: %v", err)
+					return m, nil
+				}
+				m.authConfig = authConfig
+				m.configuringProfile = false
+				m.currentScreen = screenFromConfig(m.config)
+				m.loading = true
+				m.statusMessage = fmt.Sprintf("Using AWS profile: %s", profileName)
+				return m, m.initAWSClient
+			case "esc":
+				// Go back to auth method selection
+				m.currentScreen = authMethodScreen
+				m.configuringProfile = false
+				return m, nil
+			}
+		}
+		var cmd tea.Cmd
+		m.profileInput, cmd = m.profileInput.Update(msg)
+		return m, cmd
+	}
+
+	// Handle SSO URL configuration
+	if m.configuringSSO && m.currentScreen == ssoConfigScreen {
+		switch msg := msg.(type) {
+		case tea.KeyMsg:
+			switch msg.String() {
+			case "enter":
+				ssoURL := m.ssoURLInput.Value()
+				if err := aws.ValidateSSOStartURL(ssoURL); err != nil {
+					m.statusMessage = fmt.Sprintf("Invalid SSO URL: %v", err)
+					return m, nil
+				}
+				// Save SSO config
+				authConfig := &aws.AuthConfig{
+					Method:      aws.AuthMethodSSO,
+					SSOStartURL: ssoURL,
+					SSORegion:   aws.DefaultSSORegion,
+				}
+				if err := aws.SaveAuthConfig(authConfig); err != nil {
+					m.statusMessage = fmt.Sprintf("Failed to save config: %v", err)
+					return m, nil
+				}
+				m.authConfig = authConfig
+				m.configuringSSO = false
+				m.currentScreen = ec2Screen
+				m.loading = true
+				m.statusMessage = "SSO configuration saved"
+				return m, m.initAWSClient
+			case "esc":
+				// Go back to auth method selection
+				m.currentScreen = authMethodScreen
+				m.configuringSSO = false
+				return m, nil
+			}
+		}
+		var cmd tea.Cmd
+		m.ssoURLInput, cmd = m.ssoURLInput.Update(msg)
+		return m, cmd
+	}
+
+	// Handle S3 delete confirmation dialog
+	if m.s3ConfirmDelete {
+		switch msg := msg.(type) {
+		case tea.KeyMsg:
+			switch msg.String() {
+			case "enter":
+				// Check if typed value matches the item to delete
+				if m.deleteConfirmInput.Value() == m.s3DeleteKey {
+					m.loading = true
+					m.s3ConfirmDelete = false
+					m.deleteConfirmInput.SetValue("")
+					if m.s3DeleteTarget == "object" {
+						return m, m.deleteS3Object(m.s3CurrentBucket, m.s3DeleteKey)
+					} else if m.s3DeleteTarget == "batch" {
+						// Progress shows under the listing instead
+						m.loading = false
+						m.s3DeleteTarget = ""
+						return m, m.runS3Batch()
+					} else if m.s3DeleteTarget == "bucket" {
+						return m, m.deleteS3Bucket(m.s3DeleteKey)
+					} else if m.s3DeleteTarget == "buckets" {
+						m.s3DeleteTarget = ""
+						names := make([]string, 0, len(m.s3SelectedBuckets))
+						for name := range m.s3SelectedBuckets {
+							names = append(names, name)
+						}
+						sort.Strings(names)
+						return m, m.deleteS3Buckets(names)
+					}
+				} else {
+					m.statusMessage = "Name doesn't match - delete cancelled"
+					m.s3ConfirmDelete = false
+					m.s3DeleteTarget = ""
+					m.s3DeleteKey = ""
+					m.deleteConfirmInput.SetValue("")
+					return m, nil
+				}
+			case "esc":
+				m.s3ConfirmDelete = false
+				m.s3DeleteTarget = ""
+				m.s3DeleteKey = ""
+				m.deleteConfirmInput.SetValue("")
+				m.statusMessage = "Delete cancelled"
+				return m, nil
+			}
+		}
+		var cmd tea.Cmd
+		m.deleteConfirmInput, cmd = m.deleteConfirmInput.Update(msg)
+		return m, cmd
+	}
+
+	// Handle log tail filter prompt
+	if m.logFilterPrompt {
+		switch msg := msg.(type) {
+		case tea.KeyMsg:
+			switch msg.String() {
+			case "enter":
+				pattern := strings.TrimSpace(m.logFilterInput.Value())
+				m.logFilterPrompt = false
+				m.logFilterInput.Blur()
+				if m.logTail == nil {
+					return m, nil
+				}
+				m.statusMessage = "Tail restarted"
+				if pattern != "" {
+					m.statusMessage = fmt.Sprintf("Tail restarted with filter %s", pattern)
+				}
+				return m, m.startLogTail(m.logTail.GroupName, m.logTail.StreamNames, pattern)
+			case "esc":
+				m.logFilterPrompt = false
+				m.logFilterInput.Blur()
+				m.statusMessage = "Filter unchanged"
+				return m, nil
+			}
+		}
+		var cmd tea.Cmd
+		m.logFilterInput, cmd = m.logFilterInput.Update(msg)
+		return m, cmd
+	}
+
+	// Handle Insights query editor
+	if m.insightsEditing {
+		switch msg := msg.(type) {
+		case tea.KeyMsg:
+			switch msg.String() {
+			case "enter":
+				m.insightsEditing = false
+				m.insightsQueryInput.Blur()
+				if strings.TrimSpace(m.insightsQueryInput.Value()) == "" {
+					m.statusMessage = "Query is empty"
+					return m, nil
+				}
+				m.insightsResult = nil
+				m.statusMessage = "Starting query..."
+				return m, m.runInsightsQuery()
+			case "esc":
+				m.insightsEditing = false
+				m.insightsQueryInput.Blur()
+				return m, nil
+			}
+		}
+		var cmd tea.Cmd
+		m.insightsQueryInput, cmd = m.insightsQueryInput.Update(msg)
+		return m, cmd
+	}
+
+	// Handle saved query name prompt
+	if m.insightsSavePrompt {
+		switch msg := msg.(type) {
+		case tea.KeyMsg:
+			switch msg.String() {
+			case "enter":
+				name := strings.TrimSpace(m.insightsNameInput.Value())
+				m.insightsSavePrompt = false
+				m.insightsNameInput.SetValue("")
+				m.insightsNameInput.Blur()
+				if name == "" {
+					m.statusMessage = "Query name is empty"
+					return m, nil
+				}
+				return m, m.saveInsightsQuery(name)
+			case "esc":
+				m.insightsSavePrompt = false
+				m.insightsNameInput.SetValue("")
+				m.insightsNameInput.Blur()
+				m.statusMessage = "Save cancelled"
+				return m, nil
 			}
 		}
-		return m, nil
+		var cmd tea.Cmd
+		m.insightsNameInput, cmd = m.insightsNameInput.Update(msg)
+		return m, cmd
 	}
 
-	// Handle AWS Profile configuration
-	if m.configuringProfile && m.currentScreen == authProfileScreen {
+	// Handle alarm state prompt
+	if m.alarmStatePrompt {
 		switch msg := msg.(type) {
 		case tea.KeyMsg:
 			switch msg.String() {
 			case "enter":
-				profileName := m.profileInput.Value()
-				if profileName == "" {
-					profileName = "default"
-				}
-				if err := aws.ValidateProfileName(profileName); err != nil {
-					m.statusMessage = fmt.Sprintf("Invalid profile name: %v", err)
+				state := strings.ToUpper(strings.TrimSpace(m.alarmStateInput.Value()))
+				m.alarmStatePrompt = false
+				m.alarmStateInput.SetValue("")
+				m.alarmStateInput.Blur()
+				if state != "ALARM" && state != "OK" && state != "INSUFFICIENT_DATA" {
+					m.statusMessage = fmt.Sprintf("Invalid alarm state %q", state)
 					return m, nil
 				}
-				// Save profile config
-				authConfig := &aws.AuthConfig{
-					Method:      aws.AuthMethodProfile,
-					ProfileName: profileName,
-				}
-				if err := aws.SaveAuthConfig(authConfig); err != nil {
-					m.statusMessage = fmt.Sprintf("Failed to save config: %v", err)
+				if m.alarmDetails == nil {
 					return m, nil
 				}
-				m.authConfig = authConfig
-				m.configuringProfile = false
-				m.currentScreen = ec2Screen
-				m.loading = true
-				m.statusMessage = fmt.Sprintf("Using AWS profile: %s", profileName)
-				return m, m.initAWSClient
+				return m, m.setAlarmState(m.alarmDetails.Name, state)
 			case "esc":
-				// Go back to auth method selection
-				m.currentScreen = authMethodScreen
-				m.configuringProfile = false
+				m.alarmStatePrompt = false
+				m.alarmStateInput.SetValue("")
+				m.alarmStateInput.Blur()
+				m.statusMessage = "Set alarm state cancelled"
 				return m, nil
 			}
 		}
 		var cmd tea.Cmd
-		m.profileInput, cmd = m.profileInput.Update(msg)
+		m.alarmStateInput, cmd = m.alarmStateInput.Update(msg)
 		return m, cmd
 	}
 
-	// Handle SSO URL configuration
-	if m.configuringSSO && m.currentScreen == ssoConfigScreen {
+	// Handle S3 upload path prompt
+	if m.s3UploadPrompt {
 		switch msg := msg.(type) {
 		case tea.KeyMsg:
 			switch msg.String() {
 			case "enter":
-				ssoURL := m.ssoURLInput.Value()
-				if err := aws.ValidateSSOStartURL(ssoURL); err != nil {
-					m.statusMessage = fmt.Sprintf("Invalid SSO URL: %v", err)
-					return m, nil
+				path := strings.TrimSpace(m.s3UploadInput.Value())
+				m.s3UploadPrompt = false
+				m.s3UploadInput.SetValue("")
+				m.s3UploadInput.Blur()
+				if strings.HasPrefix(path, "~/") {
+					if home, err := os.UserHomeDir(); err == nil {
+						path = filepath.Join(home, path[2:])
+					}
 				}
-				// Save SSO config
-				authConfig := &aws.AuthConfig{
-					Method:      aws.AuthMethodSSO,
-					SSOStartURL: ssoURL,
-					SSORegion:   aws.DefaultSSORegion,
+				info, err := os.Stat(path)
+				if err != nil {
+					m.statusMessage = fmt.Sprintf("Cannot upload: %v", err)
+					return m, nil
 				}
-				if err := aws.SaveAuthConfig(authConfig); err != nil {
-					m.statusMessage = fmt.Sprintf("Failed to save config: %v", err)
+				if info.IsDir() {
+					m.statusMessage = fmt.Sprintf("Cannot upload %s: it is a directory", path)
 					return m, nil
 				}
-				m.authConfig = authConfig
-				m.configuringSSO = false
-				m.currentScreen = ec2Screen
-				m.loading = true
-				m.statusMessage = "SSO configuration saved"
-				return m, m.initAWSClient
+				m.queueTransfer(aws.TransferRequest{
+					Kind:      aws.TransferUpload,
+					Bucket:    m.s3CurrentBucket,
+					Key:       m.s3CurrentPrefix + filepath.Base(path),
+					LocalPath: path,
+					Size:      info.Size(),
+				})
+				return m, nil
 			case "esc":
-				// Go back to auth method selection
-				m.currentScreen = authMethodScreen
-				m.configuringSSO = false
+				m.s3UploadPrompt = false
+				m.s3UploadInput.SetValue("")
+				m.s3UploadInput.Blur()
+				m.statusMessage = "Upload cancelled"
 				return m, nil
 			}
 		}
 		var cmd tea.Cmd
-		m.ssoURLInput, cmd = m.ssoURLInput.Update(msg)
+		m.s3UploadInput, cmd = m.s3UploadInput.Update(msg)
 		return m, cmd
 	}
 
-	// Handle S3 delete confirmation dialog
-	if m.s3ConfirmDelete {
+	// Handle port forward spec prompt
+	if m.portForwardPrompt {
 		switch msg := msg.(type) {
 		case tea.KeyMsg:
 			switch msg.String() {
 			case "enter":
-				// Check if typed value matches the item to delete
-				if m.deleteConfirmInput.Value() == m.s3DeleteKey {
-					m.loading = true
-					m.s3ConfirmDelete = false
-					m.deleteConfirmInput.SetValue("")
-					if m.s3DeleteTarget == "object" {
-						return m, m.deleteS3Object(m.s3CurrentBucket, m.s3DeleteKey)
-					} else if m.s3DeleteTarget == "bucket" {
-						return m, m.deleteS3Bucket(m.s3DeleteKey)
-					}
-				} else {
-					m.statusMessage = "Name doesn't match - delete cancelled"
-					m.s3ConfirmDelete = false
-					m.s3DeleteTarget = ""
-					m.s3DeleteKey = ""
-					m.deleteConfirmInput.SetValue("")
+				opts, err := aws.ParsePortForwardSpec(m.portForwardInstance, m.portForwardInput.Value())
+				m.portForwardPrompt = false
+				m.portForwardInput.SetValue("")
+				m.portForwardInput.Blur()
+				if err != nil {
+					m.statusMessage = fmt.Sprintf("Invalid port forward: %v", err)
 					return m, nil
 				}
+				m.statusMessage = fmt.Sprintf("Starting port forward to %s...", m.portForwardInstance)
+				return m, m.startPortForward(opts)
 			case "esc":
-				m.s3ConfirmDelete = false
-				m.s3DeleteTarget = ""
-				m.s3DeleteKey = ""
-				m.deleteConfirmInput.SetValue("")
-				m.statusMessage = "Delete cancelled"
+				m.portForwardPrompt = false
+				m.portForwardInput.SetValue("")
+				m.portForwardInput.Blur()
+				m.statusMessage = "Port forward cancelled"
 				return m, nil
 			}
 		}
 		var cmd tea.Cmd
-		m.deleteConfirmInput, cmd = m.deleteConfirmInput.Update(msg)
+		m.portForwardInput, cmd = m.portForwardInput.Update(msg)
 		return m, cmd
 	}
 
@@ -991,32 +2584,428 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.eksClusters = nil
 		m.clearSearch()
 		if m.autoRefresh {
-			return m, tea.Batch(m.loadEC2Instances, tickCmd())
+			return m, tea.Batch(m.loadCurrentScreen(), m.tickCmd(), m.restorePortForwards)
 		}
-		return m, m.loadEC2Instances
+		return m, tea.Batch(m.loadCurrentScreen(), m.restorePortForwards)
 
 	case tickMsg:
 		// Auto-refresh EC2 instances if enabled and on EC2 screen
 		if m.autoRefresh && m.currentScreen == ec2Screen {
-			return m, tea.Batch(m.loadEC2Instances, tickCmd())
+			return m, tea.Batch(m.loadEC2Instances, m.tickCmd())
+		}
+		return m, m.tickCmd()
+
+	case alarmsLoadedMsg:
+		m.loading = false
+		m.err = msg.err
+		if msg.err == nil {
+			m.alarms = msg.alarms
+			if m.alarmSelectedIndex >= len(m.alarms) {
+				m.alarmSelectedIndex = 0
+			}
+			// Keep the details pane in sync with the refreshed alarm
+			if m.alarmDetails != nil {
+				for i := range m.alarms {
+					if m.alarms[i].Name == m.alarmDetails.Name {
+						alarm := m.alarms[i]
+						m.alarmDetails = &alarm
+					}
+				}
+			}
+		}
+		return m, nil
+
+	case alarmHistoryLoadedMsg:
+		if m.alarmDetails == nil || m.alarmDetails.Name != msg.alarmName {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Failed to load alarm history: %v", msg.err)
+			return m, nil
+		}
+		m.alarmHistory = msg.history
+		return m, nil
+
+	case alarmActionCompletedMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Failed to %s for %s: %v", msg.action, msg.alarmName, msg.err)
+			return m, nil
+		}
+		m.statusMessage = fmt.Sprintf("Alarm %s: %s", msg.alarmName, msg.action)
+		cmds := []tea.Cmd{m.loadAlarms}
+		if m.alarmDetails != nil && m.alarmDetails.Name == msg.alarmName {
+			cmds = append(cmds, m.loadAlarmHistory(msg.alarmName))
+		}
+		return m, tea.Batch(cmds...)
+
+	case logGroupsLoadedMsg:
+		m.loading = false
+		m.err = msg.err
+		if msg.err == nil {
+			m.logGroups = msg.groups
+			if m.logGroupIndex >= len(m.logGroups) {
+				m.logGroupIndex = 0
+			}
+		}
+		return m, nil
+
+	case logStreamsLoadedMsg:
+		m.loading = false
+		m.err = msg.err
+		if msg.err == nil && msg.groupName == m.logGroupName {
+			m.logStreams = msg.streams
+			if m.logStreamIndex >= len(m.logStreams) {
+				m.logStreamIndex = 0
+			}
+		}
+		return m, nil
+
+	case logTailTickMsg:
+		// A tail stops polling once it's replaced or its screen is left
+		if msg.tail != m.logTail || m.currentScreen != logTailScreen {
+			return m, nil
+		}
+		if m.logTailPaused {
+			return m, logTailTickCmd(msg.tail)
+		}
+		return m, pollLogTail(msg.tail)
+
+	case logEventsLoadedMsg:
+		if msg.tail != m.logTail {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Tail failed: %v", msg.err)
+		} else if len(msg.events) > 0 {
+			// Keep following the newest event unless the user scrolled up
+			following := len(m.logEvents) == 0 || m.logEventIndex == len(m.logEvents)-1
+			m.logEvents = append(m.logEvents, msg.events...)
+			if dropped := len(m.logEvents) - logTailMaxEvents; dropped > 0 {
+				m.logEvents = m.logEvents[dropped:]
+				m.logEventIndex = max(0, m.logEventIndex-dropped)
+			}
+			if following {
+				m.logEventIndex = len(m.logEvents) - 1
+			}
+		}
+		if m.currentScreen != logTailScreen {
+			return m, nil
+		}
+		return m, logTailTickCmd(msg.tail)
+
+	case clusterLogStreamsLoadedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Failed to load control plane logs: %v", msg.err)
+			return m, nil
+		}
+		if len(msg.streams) == 0 {
+			m.statusMessage = fmt.Sprintf("No control plane log streams in %s yet", aws.EKSControlPlaneLogGroup(msg.clusterName))
+			return m, nil
+		}
+		return m, m.startLogTail(aws.EKSControlPlaneLogGroup(msg.clusterName), msg.streams, "")
+
+	case insightsQueryStartedMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Query failed: %v", msg.err)
+			return m, nil
+		}
+		m.insightsQueryID = msg.queryID
+		m.insightsRowIndex = 0
+		m.statusMessage = "Query running..."
+		return m, m.loadInsightsResults(msg.queryID)
+
+	case insightsTickMsg:
+		if msg.queryID != m.insightsQueryID {
+			return m, nil
+		}
+		return m, m.loadInsightsResults(msg.queryID)
+
+	case insightsResultsLoadedMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Query failed: %v", msg.err)
+			return m, nil
+		}
+		if msg.result.QueryID != m.insightsQueryID {
+			return m, nil
+		}
+		m.insightsResult = msg.result
+		if m.insightsRowIndex >= len(msg.result.Rows) {
+			m.insightsRowIndex = 0
+		}
+		if !msg.result.Done() {
+			return m, insightsTickCmd(msg.result.QueryID)
+		}
+		m.statusMessage = fmt.Sprintf("Query %s: %d rows", strings.ToLower(msg.result.Status), len(msg.result.Rows))
+		return m, nil
+
+	case savedQueriesLoadedMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Failed to load saved queries: %v", msg.err)
+			return m, nil
+		}
+		m.savedQueries = msg.queries
+		m.savedQueryIndex = -1
+		return m, nil
+
+	case querySavedMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Failed to save query %s: %v", msg.name, msg.err)
+			return m, nil
+		}
+		m.statusMessage = fmt.Sprintf("Saved query %s", msg.name)
+		return m, m.loadSavedQueries
+
+	case s3SelectionExpandedMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Failed to list objects: %v", msg.err)
+			return m, nil
+		}
+		if len(msg.objects) == 0 {
+			m.statusMessage = fmt.Sprintf("No objects to %s", msg.op)
+			return m, nil
+		}
+		m.s3BatchObjects = msg.objects
+		var size int64
+		for _, obj := range msg.objects {
+			size += obj.Size
+		}
+
+		switch msg.op {
+		case "download":
+			queued, unsafe := m.awsClient.QueueDownloads(m.transfers, m.s3CurrentBucket, m.s3BatchRoot, msg.objects, ".")
+			m.s3BatchObjects = nil
+			m.s3SelectedObjects = make(map[string]bool)
+			m.statusMessage = fmt.Sprintf("Downloading %d objects (%s) into the current directory (:transfers)", queued, formatBytes(size))
+			if len(unsafe) > 0 {
+				m.statusMessage += fmt.Sprintf("; skipped %d keys that would be written outside it: %s", len(unsafe), strings.Join(unsafe, ", "))
+			}
+			return m, nil
+		case "copy":
+			m.statusMessage = fmt.Sprintf("Copying %d objects to s3://%s/%s...", len(msg.objects), m.s3BatchDestBucket, m.s3BatchDestPrefix)
+			return m, m.runS3Batch()
+		case "change storage class":
+			m.statusMessage = fmt.Sprintf("Changing %d objects (%s) to %s...", len(msg.objects), formatBytes(size), m.s3BatchStorageClass)
+			return m, m.runS3Batch()
+		case "tag":
+			m.statusMessage = fmt.Sprintf("Tagging %d objects...", len(msg.objects))
+			return m, m.runS3Batch()
+		case "restore":
+			m.statusMessage = fmt.Sprintf("Requesting a %s restore of %d objects for %d days...", m.s3BatchRestoreTier, len(msg.objects), m.s3BatchRestoreDays)
+			return m, m.runS3Batch()
+		case "presign":
+			m.statusMessage = fmt.Sprintf("Presigning %d objects...", len(msg.objects))
+			return m, m.runS3Batch()
+		}
+
+		// Deletes and moves remove the source objects, so confirm them first
+		m.s3ConfirmDelete = true
+		m.s3DeleteTarget = "batch"
+		m.s3DeleteKey = msg.confirm
+		m.deleteConfirmInput.SetValue("")
+		m.deleteConfirmInput.Focus()
+		if msg.op == "move" {
+			m.statusMessage = fmt.Sprintf("Type %s to confirm moving %d objects (%s) to s3://%s/%s",
+				msg.confirm, len(msg.objects), formatBytes(size), m.s3BatchDestBucket, m.s3BatchDestPrefix)
+		} else {
+			m.statusMessage = fmt.Sprintf("Type %s to confirm deleting %d objects (%s)", msg.confirm, len(msg.objects), formatBytes(size))
+		}
+		return m, nil
+
+	case s3BatchProgressMsg:
+		m.s3BatchDone = msg.done
+		m.s3BatchTotal = msg.total
+		return m, waitForUpdate(msg.updates)
+
+	case s3SearchProgressMsg:
+		if msg.id != m.s3SearchID {
+			return m, nil
+		}
+		m.s3SearchScanned = msg.scanned
+		m.s3SearchMatched = msg.matched
+		return m, waitForUpdate(msg.updates)
+
+	case s3SearchCompletedMsg:
+		if msg.id != m.s3SearchID {
+			return m, nil
+		}
+		m.s3SearchCancel = nil
+		m.loading = false
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Search failed: %v", msg.err)
+			return m, nil
+		}
+		result := msg.result
+		m.s3Search = msg.terms
+		m.s3Objects = result.Objects
+		m.s3FilteredObjects = nil
+		m.vimState.LastSearch = ""
+		m.vimState.SearchResults = []int{}
+		m.s3NextContinuationToken = nil
+		m.s3IsTruncated = false
+		m.s3ObjectSelectedIndex = 0
+		m.s3RangeActive = false
+		m.viewportOffset = 0
+		if m.s3SelectionRoot != m.s3CurrentBucket+"/"+m.s3CurrentPrefix {
+			m.s3SelectedObjects = make(map[string]bool)
+		}
+		m.statusMessage = fmt.Sprintf("Found %d matches in %d objects", len(result.Objects), result.Scanned)
+		if result.Truncated {
+			m.statusMessage += fmt.Sprintf(", stopped at %d - narrow the search to see the rest", len(result.Objects))
+		}
+		m.s3RestoreStatus = make(map[string]aws.ObjectRestoreStatus)
+		return m, m.loadS3RestoreStatuses(result.Objects)
+
+	case restoreStatusesLoadedMsg:
+		if msg.bucket != m.s3CurrentBucket {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Failed to get restore status: %v", msg.err)
+		}
+		if m.s3RestoreStatus == nil {
+			m.s3RestoreStatus = make(map[string]aws.ObjectRestoreStatus)
+		}
+		for key, status := range msg.statuses {
+			m.s3RestoreStatus[key] = status
+		}
+		return m, nil
+
+	case s3BatchCompletedMsg:
+		m.s3BatchTotal = 0
+		result := msg.result
+		verb := map[string]string{
+			"delete":               "Deleted",
+			"copy":                 "Copied",
+			"move":                 "Moved",
+			"change storage class": "Changed the storage class of",
+			"tag":                  "Tagged",
+			"restore":              "Requested a restore of",
+			"presign":              "Presigned",
+		}[msg.op]
+		m.statusMessage = fmt.Sprintf("%s %d of %d objects (%s)", verb, result.Succeeded, result.Total, formatBytes(result.Bytes))
+		if len(result.Failures) > 0 {
+			m.s3BatchResult = result
+			m.statusMessage += fmt.Sprintf(", %d failed", len(result.Failures))
+		}
+		if msg.urls != "" {
+			m.s3PresignedUpload = msg.urls
+			m.s3PresignedUploadTitle = fmt.Sprintf("Presigned URLs of %d objects (valid for 1 hour)", result.Succeeded)
+			m.statusMessage += ", press y to copy the URLs"
+		}
+		m.s3SelectedObjects = make(map[string]bool)
+		if m.currentScreen == s3BrowseScreen {
+			return m, m.reloadS3Objects()
+		}
+		if m.currentScreen == s3ObjectDetailsScreen && msg.op == "restore" && m.s3ObjectDetails != nil {
+			return m, m.loadS3ObjectDetails(m.s3CurrentBucket, m.s3ObjectDetails.Key)
+		}
+		return m, nil
+
+	case syncPlannedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Sync failed: %v", msg.err)
+			return m, nil
+		}
+		if len(msg.plan.Actions) == 0 {
+			m.statusMessage = fmt.Sprintf("%s is already in sync (%d files unchanged)", msg.plan.Destination, msg.plan.Unchanged)
+			if len(msg.plan.Unsafe) > 0 {
+				m.statusMessage += fmt.Sprintf("; skipped %d keys that would be written outside it: %s", len(msg.plan.Unsafe), strings.Join(msg.plan.Unsafe, ", "))
+			}
+			return m, nil
+		}
+		m.syncPlan = msg.plan
+		m.syncPlanIndex = 0
+		if m.currentScreen != syncPlanScreen {
+			m.previousScreen = m.currentScreen
+		}
+		m.currentScreen = syncPlanScreen
+		m.viewportOffset = 0
+		m.statusMessage = "Review the sync and press Enter to run it"
+		return m, nil
+
+	case syncExecutedMsg:
+		copies, deletes, _ := msg.plan.Summary()
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Sync: %d copies queued, %v", copies, msg.err)
+		} else {
+			m.statusMessage = fmt.Sprintf("Sync: %d copies queued, %d deleted", copies, deletes)
+		}
+		if m.currentScreen == s3BrowseScreen {
+			return m, m.reloadS3Objects()
+		}
+		return m, nil
+
+	case transferTickMsg:
+		// The tick stops once the queue is left
+		if m.currentScreen != transfersScreen {
+			return m, nil
+		}
+		m.refreshTransfers()
+		return m, transferTickCmd()
+
+	case portForwardTickMsg:
+		// The tick stops once the panel is left
+		if m.currentScreen != portForwardsScreen {
+			return m, nil
+		}
+		m.refreshPortForwards()
+		return m, portForwardTickCmd()
+
+	case portForwardActionCompletedMsg:
+		m.refreshPortForwards()
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Port forward %s failed: %v", msg.action, msg.err)
+			return m, nil
+		}
+		switch msg.action {
+		case "start", "duplicate", "restart":
+			def := msg.info.Definition
+			m.statusMessage = fmt.Sprintf("Forwarding localhost:%d to %s via %s", def.LocalPort, portForwardRemote(def), def.InstanceID)
+		case "stop":
+			m.statusMessage = "Port forward stopped"
+		case "remove":
+			m.statusMessage = "Port forward removed"
+		}
+		return m, nil
+
+	case portForwardsRestoredMsg:
+		m.refreshPortForwards()
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Failed to restore port forwards: %v", msg.err)
+		} else if msg.count > 0 {
+			m.statusMessage = fmt.Sprintf("Restored %d saved port forwards", msg.count)
 		}
-		return m, tickCmd()
+		return m, nil
 
 	case bulkActionCompletedMsg:
 		m.loading = false
 		m.showingConfirm = false
 		if msg.failureCount > 0 {
 			m.statusMessage = fmt.Sprintf("Bulk %s: %d succeeded, %d failed", msg.action, msg.successCount, msg.failureCount)
+			if msg.firstErr != nil {
+				m.statusMessage += fmt.Sprintf(" (%v)", msg.firstErr)
+			}
 		} else {
-			m.statusMessage = fmt.Sprintf("Bulk %s: %d instances succeeded", msg.action, msg.successCount)
+			m.statusMessage = fmt.Sprintf("Bulk %s: %d %s succeeded", msg.action, msg.successCount, msg.resource)
+		}
+		if msg.resource == "buckets" {
+			m.s3SelectedBuckets = make(map[string]bool)
+			return m, m.loadS3Buckets
 		}
 		// Clear selections
 		m.ec2SelectedInstances = make(map[string]bool)
 		// Refresh instances list
 		return m, m.loadEC2Instances
 
+	case instancesProgressMsg:
+		m.ec2LoadedCount = msg.loaded
+		return m, waitForUpdate(msg.updates)
+
 	case instancesLoadedMsg:
 		m.loading = false
+		m.ec2LoadedCount = 0
 		m.err = msg.err
 		if msg.err == nil {
 			m.ec2Instances = msg.instances
@@ -1029,12 +3018,13 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.err = msg.err
 		if msg.err == nil {
 			m.ec2InstanceDetails = msg.details
+			m.ec2MetricHistory = nil
 			m.currentScreen = ec2DetailsScreen
 			// Load additional information for details view
 			instanceID := msg.details.ID
 			return m, tea.Batch(
 				m.loadInstanceStatus(instanceID),
-				m.loadInstanceMetrics(instanceID),
+				m.loadInstanceMetricHistory(instanceID),
 				m.loadSSMStatus(instanceID),
 			)
 		}
@@ -1046,9 +3036,14 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
-	case instanceMetricsLoadedMsg:
-		if msg.err == nil {
-			m.ec2InstanceMetrics = msg.metrics
+	case instanceMetricHistoryLoadedMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Failed to load metrics: %v", msg.err)
+			return m, nil
+		}
+		// Ignore history for an instance that is no longer shown
+		if m.ec2InstanceDetails != nil && msg.history.InstanceID == m.ec2InstanceDetails.ID {
+			m.ec2MetricHistory = msg.history
 		}
 		return m, nil
 
@@ -1078,15 +3073,37 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case eksClustersProgressMsg:
+		m.eksLoadedCount = msg.loaded
+		return m, waitForUpdate(msg.updates)
+
 	case eksClustersLoadedMsg:
 		m.loading = false
+		m.eksLoadedCount = 0
 		m.err = msg.err
 		if msg.err == nil {
 			m.eksClusters = msg.clusters
 			m.eksSelectedIndex = 0
 		}
+		if msg.updates != nil {
+			return m, waitForUpdate(msg.updates)
+		}
 		return m, nil
 
+	case eksClusterDescribedMsg:
+		// Replace the placeholder row in both the full and the filtered view
+		for i := range m.eksClusters {
+			if m.eksClusters[i].Name == msg.cluster.Name {
+				m.eksClusters[i] = msg.cluster
+			}
+		}
+		for i := range m.eksFilteredClusters {
+			if m.eksFilteredClusters[i].Name == msg.cluster.Name {
+				m.eksFilteredClusters[i] = msg.cluster
+			}
+		}
+		return m, waitForUpdate(msg.updates)
+
 	case eksClusterDetailsLoadedMsg:
 		m.loading = false
 		m.err = msg.err
@@ -1104,7 +3121,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.err != nil {
 			m.statusMessage = fmt.Sprintf("Error updating kubeconfig: %v", msg.err)
 		} else {
-			m.statusMessage = fmt.Sprintf("Updated kubeconfig for cluster: %s", msg.clusterName)
+			m.statusMessage = fmt.Sprintf("Updated kubeconfig for cluster %s (context: %s)", msg.clusterName, msg.contextName)
 		}
 		return m, nil
 
@@ -1152,22 +3169,30 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.clearSearch()
 		// Clear any previous errors
 		m.err = nil
-		// Switch to EC2 screen and load instances
-		m.currentScreen = ec2Screen
+		// Switch to the default screen and load it
 		m.viewportOffset = 0
 		m.loading = true // Show loading state
 		m.statusMessage = fmt.Sprintf("Switched to account: %s", msg.accountName)
-		return m, m.loadEC2Instances
+		return m, tea.Batch(m.loadDefaultScreen(), m.restorePortForwards)
 
 	case objectsLoadedMsg:
 		m.loading = false
 		m.err = msg.err
 		if msg.err == nil {
+			m.stopS3Search()
+			m.s3Search = ""
 			m.s3Objects = msg.result.Objects
 			m.s3NextContinuationToken = msg.result.NextContinuationToken
 			m.s3IsTruncated = msg.result.IsTruncated
 			m.s3ObjectSelectedIndex = 0
+			m.s3RangeActive = false
+			// Selections are kept across pages of the same listing only
+			if m.s3SelectionRoot != m.s3CurrentBucket+"/"+m.s3CurrentPrefix {
+				m.s3SelectedObjects = make(map[string]bool)
+			}
+			m.s3RestoreStatus = make(map[string]aws.ObjectRestoreStatus)
 			m.currentScreen = s3BrowseScreen
+			return m, m.loadS3RestoreStatuses(m.s3Objects)
 		}
 		return m, nil
 
@@ -1177,6 +3202,82 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.err == nil {
 			m.s3ObjectDetails = msg.details
 			m.currentScreen = s3ObjectDetailsScreen
+			if !msg.details.Restore.Readable() {
+				m.s3Preview = nil
+				m.s3PreviewPage = nil
+				m.s3PreviewHistory = nil
+				m.s3PreviewErr = fmt.Errorf("the object is archived in %s, restore it with :restore first", msg.details.StorageClass)
+				m.s3PreviewLoading = false
+				return m, nil
+			}
+			// Preview the object with ranged reads rather than downloading it
+			reader := m.awsClient.NewObjectRangeReader(context.Background(), m.s3CurrentBucket, msg.details.Key, msg.details.ETag, msg.details.Size)
+			m.s3Preview = preview.New(reader, msg.details.Size, msg.details.Key, msg.details.ContentType)
+			m.s3PreviewPage = nil
+			m.s3PreviewHistory = nil
+			m.s3PreviewErr = nil
+			m.s3PreviewLoading = true
+			return m, m.loadS3PreviewPage(0, false)
+		}
+		return m, nil
+
+	case previewLoadedMsg:
+		// Ignore pages of an object no longer being viewed
+		if msg.preview != m.s3Preview {
+			return m, nil
+		}
+		m.s3PreviewLoading = false
+		m.s3PreviewErr = msg.err
+		if msg.err != nil {
+			return m, nil
+		}
+		if msg.back && len(m.s3PreviewHistory) > 0 {
+			m.s3PreviewHistory = m.s3PreviewHistory[:len(m.s3PreviewHistory)-1]
+		} else if !msg.back && m.s3PreviewPage != nil {
+			m.s3PreviewHistory = append(m.s3PreviewHistory, m.s3PreviewPage.Start)
+		}
+		m.s3PreviewPage = msg.page
+		return m, nil
+
+	case objectVersionsLoadedMsg:
+		m.loading = false
+		// Ignore versions of an object no longer being viewed
+		if m.currentScreen != s3VersionsScreen || msg.key != m.s3VersionsKey {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error loading versions: %v", msg.err)
+			return m, nil
+		}
+		m.s3Versions = msg.versions
+		if m.s3VersionIndex >= len(m.s3Versions) {
+			m.s3VersionIndex = 0
+		}
+		return m, nil
+
+	case versionDiffLoadedMsg:
+		m.loading = false
+		if m.currentScreen != s3VersionsScreen {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Diff failed: %v", msg.err)
+			return m, nil
+		}
+		m.s3VersionDiff = msg.diff
+		m.viewportOffset = 0
+		return m, nil
+
+	case versionActionCompletedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+		} else {
+			m.statusMessage = msg.action
+		}
+		if m.currentScreen == s3VersionsScreen {
+			m.loading = true
+			return m, m.loadObjectVersions(m.s3CurrentBucket, m.s3VersionsKey)
 		}
 		return m, nil
 
@@ -1201,7 +3302,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.s3DeleteTarget == "bucket" {
 			return m, m.loadS3Buckets
 		} else if m.s3DeleteTarget == "object" {
-			return m, m.loadS3Objects(m.s3CurrentBucket, m.s3CurrentPrefix, nil)
+			return m, m.reloadS3Objects()
 		}
 		return m, nil
 
@@ -1226,6 +3327,17 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case presignedUploadMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error presigning upload: %v", msg.err)
+			return m, nil
+		}
+		m.s3PresignedUpload = msg.snippet
+		m.s3PresignedUploadTitle = msg.title
+		m.statusMessage = "Press y to copy it to the clipboard"
+		return m, nil
+
 	case bucketPolicyLoadedMsg:
 		m.loading = false
 		if msg.err != nil {
@@ -1259,10 +3371,20 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, tea.Quit
 
 	case tea.KeyMsg:
-		switch msg.String() {
+		switch m.keymap.Resolve(msg.String()) {
 		case "ctrl+c", "q":
 			// Don't quit if we're in help screen, go back instead
-			if m.currentScreen == helpScreen {
+			if m.currentScreen == syncPlanScreen {
+				m.currentScreen = m.previousScreen
+				m.syncPlan = nil
+				m.statusMessage = "Sync cancelled"
+				m.viewportOffset = 0
+				return m, nil
+			}
+			if m.currentScreen == s3VersionsScreen {
+				return m, m.closeObjectVersions()
+			}
+			if m.currentScreen == helpScreen || m.currentScreen == portForwardsScreen || m.currentScreen == transfersScreen {
 				m.currentScreen = m.previousScreen
 				m.viewportOffset = 0
 				return m, nil
@@ -1283,6 +3405,20 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.s3ObjectDetails = nil
 				m.viewportOffset = 0
 				return m, nil
+			} else if m.currentScreen == alarmDetailsScreen {
+				m.currentScreen = alarmsScreen
+				m.alarmDetails = nil
+				m.alarmHistory = nil
+				m.viewportOffset = 0
+				return m, nil
+			} else if m.currentScreen == logStreamsScreen {
+				m.currentScreen = logGroupsScreen
+				m.viewportOffset = 0
+				return m, nil
+			} else if m.currentScreen == logTailScreen || m.currentScreen == logInsightsScreen {
+				m.currentScreen = m.logReturnScreen
+				m.viewportOffset = 0
+				return m, nil
 			}
 			return m, tea.Quit
 		case "esc":
@@ -1293,6 +3429,27 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.viewportOffset = 0
 				return m, nil
 			}
+			if m.currentScreen == portForwardsScreen || m.currentScreen == transfersScreen {
+				m.currentScreen = m.previousScreen
+				m.viewportOffset = 0
+				return m, nil
+			}
+			if m.currentScreen == syncPlanScreen {
+				m.currentScreen = m.previousScreen
+				m.syncPlan = nil
+				m.statusMessage = "Sync cancelled"
+				m.viewportOffset = 0
+				return m, nil
+			}
+			if m.currentScreen == s3VersionsScreen {
+				// Close the diff first, then the versions
+				if m.s3VersionDiff != "" {
+					m.s3VersionDiff = ""
+					m.viewportOffset = 0
+					return m, nil
+				}
+				return m, m.closeObjectVersions()
+			}
 			if m.currentScreen == regionScreen {
 				// Go back to previous screen without changing region
 				m.currentScreen = m.previousScreen
@@ -1300,6 +3457,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.statusMessage = "Region selection cancelled"
 				return m, nil
 			}
+			if m.s3RangeActive {
+				m.s3RangeActive = false
+				m.statusMessage = "Range selection cancelled"
+				return m, nil
+			}
 			if m.s3ShowingInfo {
 				m.s3ShowingInfo = false
 				m.s3InfoType = ""
@@ -1308,6 +3470,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.s3PresignedURL = ""
 				return m, nil
 			}
+			if m.s3PresignedUpload != "" {
+				m.s3PresignedUpload = ""
+				m.s3PresignedUploadTitle = ""
+				m.statusMessage = "Presigned upload cleared"
+				return m, nil
+			}
 			// Clear presigned URL if showing
 			if m.s3PresignedURL != "" {
 				m.s3PresignedURL = ""
@@ -1321,9 +3489,24 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.ec2FilteredInstances = nil
 				m.s3FilteredBuckets = nil
 				m.s3FilteredObjects = nil
+				m.alarmsFiltered = nil
+				m.logGroupsFiltered = nil
 				m.statusMessage = "Search cleared"
 				return m, nil
 			}
+			// Stop a bucket-wide search, or leave its results for the
+			// folder it searched
+			if m.currentScreen == s3BrowseScreen && m.s3SearchCancel != nil {
+				m.stopS3Search()
+				m.statusMessage = "Search cancelled"
+				return m, nil
+			}
+			if m.currentScreen == s3BrowseScreen && m.s3Search != "" {
+				m.s3Search = ""
+				m.loading = true
+				m.viewportOffset = 0
+				return m, m.loadS3Objects(m.s3CurrentBucket, m.s3CurrentPrefix, nil)
+			}
 			if m.currentScreen == ec2DetailsScreen {
 				m.currentScreen = ec2Screen
 				m.ec2InstanceDetails = nil
@@ -1365,6 +3548,20 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.eksNodeGroups = nil
 				m.eksAddons = nil
 				return m, nil
+			} else if m.currentScreen == alarmDetailsScreen {
+				m.currentScreen = alarmsScreen
+				m.alarmDetails = nil
+				m.alarmHistory = nil
+				m.viewportOffset = 0
+				return m, nil
+			} else if m.currentScreen == logStreamsScreen {
+				m.currentScreen = logGroupsScreen
+				m.viewportOffset = 0
+				return m, nil
+			} else if m.currentScreen == logTailScreen || m.currentScreen == logInsightsScreen {
+				m.currentScreen = m.logReturnScreen
+				m.viewportOffset = 0
+				return m, nil
 			}
 		case "k", "up", "j", "down", "g", "G", "ctrl+g", "ctrl+u", "ctrl+d", "ctrl+b", "ctrl+f", "pgup", "pgdown":
 			// VIM-style navigation
@@ -1464,6 +3661,74 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						return m, m.loadS3ObjectDetails(m.s3CurrentBucket, selectedObject.Key)
 					}
 				}
+			} else if m.currentScreen == logGroupsScreen {
+				// List the streams of the group
+				if group, ok := m.selectedLogGroup(); ok {
+					m.logGroupName = group.Name
+					m.logStreams = nil
+					m.logStreamIndex = 0
+					m.currentScreen = logStreamsScreen
+					m.viewportOffset = 0
+					m.loading = true
+					return m, m.loadLogStreams(group.Name)
+				}
+			} else if m.currentScreen == logStreamsScreen {
+				// Tail the selected stream
+				if m.logStreamIndex < len(m.logStreams) {
+					return m, m.startLogTail(m.logGroupName, []string{m.logStreams[m.logStreamIndex].Name}, "")
+				}
+			} else if m.currentScreen == logInsightsScreen {
+				// Run the query
+				m.insightsResult = nil
+				m.statusMessage = "Starting query..."
+				return m, m.runInsightsQuery()
+			} else if version, ok := m.selectedObjectVersion(); ok {
+				// Diff the version against the marked one, or else the one
+				// before it
+				other := -1
+				for i, v := range m.s3Versions {
+					if v.VersionId == m.s3VersionMark && i != m.s3VersionIndex {
+						other = i
+					}
+				}
+				if other < 0 && m.s3VersionMark == version.VersionId {
+					m.statusMessage = "Move to another version to diff it against the marked one"
+					return m, nil
+				}
+				if other < 0 && m.s3VersionIndex+1 < len(m.s3Versions) {
+					other = m.s3VersionIndex + 1
+				}
+				if other < 0 {
+					m.statusMessage = "No earlier version to diff against"
+					return m, nil
+				}
+				// The list is newest first
+				older, newer := m.s3Versions[other], version
+				if other < m.s3VersionIndex {
+					older, newer = newer, older
+				}
+				m.loading = true
+				m.statusMessage = fmt.Sprintf("Diffing %s against %s...", newer.VersionId, older.VersionId)
+				return m, m.diffObjectVersions(m.s3CurrentBucket, m.s3VersionsKey, older, newer)
+			} else if m.currentScreen == syncPlanScreen && m.syncPlan != nil {
+				// Run the reviewed sync and follow its copies in the queue
+				plan := m.syncPlan
+				m.syncPlan = nil
+				m.clearSearch()
+				m.currentScreen = transfersScreen
+				m.viewportOffset = 0
+				m.statusMessage = "Starting sync..."
+				m.refreshTransfers()
+				return m, tea.Batch(m.executeSync(plan), transferTickCmd())
+			} else if m.currentScreen == alarmsScreen {
+				// Show alarm details and state history
+				if alarm, ok := m.selectedAlarm(); ok {
+					m.alarmDetails = &alarm
+					m.alarmHistory = nil
+					m.currentScreen = alarmDetailsScreen
+					m.viewportOffset = 0
+					return m, m.loadAlarmHistory(alarm.Name)
+				}
 			} else if m.currentScreen == eksScreen {
 				// View EKS cluster details - use filtered list if active
 				clusters := m.eksClusters
@@ -1526,15 +3791,39 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		case "r":
 			// Refresh current view
-			if m.currentScreen == ec2Screen {
+			if m.currentScreen == portForwardsScreen {
+				m.refreshPortForwards()
+				return m, nil
+			} else if m.currentScreen == transfersScreen {
+				m.refreshTransfers()
+				return m, nil
+			} else if m.currentScreen == s3VersionsScreen {
+				m.s3VersionDiff = ""
+				m.loading = true
+				return m, m.loadObjectVersions(m.s3CurrentBucket, m.s3VersionsKey)
+			} else if m.currentScreen == alarmsScreen {
+				m.loading = true
+				return m, m.loadAlarms
+			} else if m.currentScreen == logGroupsScreen {
+				m.loading = true
+				return m, m.loadLogGroups
+			} else if m.currentScreen == logStreamsScreen {
+				m.loading = true
+				return m, m.loadLogStreams(m.logGroupName)
+			} else if m.currentScreen == logInsightsScreen {
+				m.insightsResult = nil
+				m.statusMessage = "Starting query..."
+				return m, m.runInsightsQuery()
+			} else if m.currentScreen == alarmDetailsScreen && m.alarmDetails != nil {
+				return m, tea.Batch(m.loadAlarms, m.loadAlarmHistory(m.alarmDetails.Name))
+			} else if m.currentScreen == ec2Screen {
 				m.loading = true
 				return m, m.loadEC2Instances
 			} else if m.currentScreen == s3Screen {
 				m.loading = true
 				return m, m.loadS3Buckets
 			} else if m.currentScreen == s3BrowseScreen {
-				m.loading = true
-				return m, m.loadS3Objects(m.s3CurrentBucket, m.s3CurrentPrefix, nil)
+				return m, m.reloadS3Objects()
 			} else if m.currentScreen == eksScreen {
 				m.loading = true
 				return m, m.loadEKSClusters
@@ -1572,6 +3861,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					// Store cluster info for k9s launch
 					m.ssmInstanceID = "k9s" // Reuse this field as a flag
 					m.ssmRegion = selectedCluster.Region
+					m.k9sCluster = selectedCluster.Name
 					m.statusMessage = fmt.Sprintf("Launching k9s for %s...", selectedCluster.Name)
 					return m, tea.Quit
 				}
@@ -1579,10 +3869,17 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Launch k9s from details screen
 				m.ssmInstanceID = "k9s" // Reuse this field as a flag
 				m.ssmRegion = m.eksClusterDetails.Region
+				m.k9sCluster = m.eksClusterDetails.Name
 				m.statusMessage = fmt.Sprintf("Launching k9s for %s...", m.eksClusterDetails.Name)
 				return m, tea.Quit
 			}
 		case "backspace", "h":
+			// Go back from a group's streams to the log groups
+			if m.currentScreen == logStreamsScreen {
+				m.currentScreen = logGroupsScreen
+				m.viewportOffset = 0
+				return m, nil
+			}
 			// Go up one level in S3 browser
 			if m.currentScreen == s3BrowseScreen {
 				if m.s3CurrentPrefix == "" {
@@ -1606,6 +3903,13 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		case "e":
+			// Edit the Insights query
+			if m.currentScreen == logInsightsScreen {
+				m.insightsEditing = true
+				m.insightsQueryInput.Focus()
+				m.insightsQueryInput.CursorEnd()
+				return m, textinput.Blink
+			}
 			// Edit selected S3 object in $EDITOR
 			if m.currentScreen == s3BrowseScreen {
 				// Use filtered list if active
@@ -1628,9 +3932,33 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.statusMessage = fmt.Sprintf("Opening %s in editor...", m.s3ObjectDetails.Key)
 				return m, tea.Quit
 			}
+		case "E":
+			// Edit the tags of the selected instances
+			if ids := m.ec2SelectionIDs(); len(ids) > 0 {
+				m.ec2EditTagInstances = ids
+				m.statusMessage = "Opening tags in editor..."
+				return m, tea.Quit
+			}
+			// Edit the storage class, content type, metadata, tags and ACL
+			// of the selection or the open object
+			var keys []string
+			if m.currentScreen == s3BrowseScreen {
+				keys = m.s3SelectionKeys()
+			} else if m.currentScreen == s3ObjectDetailsScreen && m.s3ObjectDetails != nil {
+				keys = []string{m.s3ObjectDetails.Key}
+			}
+			if len(keys) > 0 {
+				m.s3EditBucket = m.s3CurrentBucket
+				m.s3EditPropertyKeys = keys
+				m.statusMessage = "Opening properties in editor..."
+				return m, tea.Quit
+			}
 		case "d":
 			// Download selected S3 object
-			if m.currentScreen == s3BrowseScreen {
+			if m.currentScreen == s3BrowseScreen && len(m.s3SelectedObjects) > 0 {
+				// Download every selected object and folder
+				return m, m.startS3Batch("download", m.s3SelectionKeys())
+			} else if m.currentScreen == s3BrowseScreen {
 				// Use filtered list if active
 				objects := m.s3Objects
 				if len(m.s3FilteredObjects) > 0 {
@@ -1638,6 +3966,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				if len(objects) > 0 && m.s3ObjectSelectedIndex < len(objects) {
 					selectedObject := objects[m.s3ObjectSelectedIndex]
+					if selectedObject.IsFolder {
+						// Download everything under the folder
+						return m, m.startS3Batch("download", m.s3SelectionKeys())
+					}
 					if !selectedObject.IsFolder {
 						// Extract just the filename from the key
 						fileName := selectedObject.Key
@@ -1645,9 +3977,14 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 							parts := strings.Split(fileName, "/")
 							fileName = parts[len(parts)-1]
 						}
-						m.loading = true
-						m.statusMessage = fmt.Sprintf("Downloading %s...", fileName)
-						return m, m.downloadS3Object(m.s3CurrentBucket, selectedObject.Key, fileName)
+						m.queueTransfer(aws.TransferRequest{
+							Kind:      aws.TransferDownload,
+							Bucket:    m.s3CurrentBucket,
+							Key:       selectedObject.Key,
+							LocalPath: fileName,
+							Size:      selectedObject.Size,
+						})
+						return m, nil
 					}
 				}
 			} else if m.currentScreen == s3ObjectDetailsScreen && m.s3ObjectDetails != nil {
@@ -1657,20 +3994,56 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					parts := strings.Split(fileName, "/")
 					fileName = parts[len(parts)-1]
 				}
-				m.loading = true
-				m.statusMessage = fmt.Sprintf("Downloading %s...", fileName)
-				return m, m.downloadS3Object(m.s3CurrentBucket, m.s3ObjectDetails.Key, fileName)
+				m.queueTransfer(aws.TransferRequest{
+					Kind:      aws.TransferDownload,
+					Bucket:    m.s3CurrentBucket,
+					Key:       m.s3ObjectDetails.Key,
+					LocalPath: fileName,
+					Size:      m.s3ObjectDetails.Size,
+				})
+				return m, nil
 			}
 		case "u":
-			// Upload file to S3 (prompt for file path)
-			// For now, we'll just show a message that upload requires file path
-			// In a full implementation, we'd add a text input for the file path
+			// Upload a local file into the current prefix
 			if m.currentScreen == s3BrowseScreen {
-				m.statusMessage = "Upload: Feature requires interactive file picker (coming soon)"
+				m.s3UploadPrompt = true
+				m.s3UploadInput.Focus()
+				return m, textinput.Blink
 			}
 		case "D":
+			// Remove a delete marker, which undeletes the object if it is
+			// the latest version
+			if version, ok := m.selectedObjectVersion(); ok {
+				if !version.IsDeleteMarker {
+					m.statusMessage = "Only delete markers can be removed here"
+					return m, nil
+				}
+				m.loading = true
+				return m, m.removeDeleteMarker(m.s3CurrentBucket, m.s3VersionsKey, version)
+			}
+			// Remove a port forward and its saved definition
+			if pf, ok := m.selectedPortForward(); ok {
+				return m, m.portForwardAction("remove", pf.ID)
+			}
+			// Remove a finished transfer from the queue
+			if t, ok := m.selectedTransfer(); ok {
+				m.transferAction("remove", t.ID)
+				return m, nil
+			}
 			// Delete S3 object or bucket
-			if m.currentScreen == s3BrowseScreen {
+			if m.currentScreen == s3BrowseScreen && len(m.s3SelectedObjects) > 0 {
+				// Delete every selected object and folder
+				return m, m.startS3Batch("delete", m.s3SelectionKeys())
+			} else if m.currentScreen == s3Screen && len(m.s3SelectedBuckets) > 0 {
+				count := strconv.Itoa(len(m.s3SelectedBuckets))
+				m.s3ConfirmDelete = true
+				m.s3DeleteTarget = "buckets"
+				m.s3DeleteKey = count
+				m.deleteConfirmInput.SetValue("")
+				m.deleteConfirmInput.Focus()
+				m.statusMessage = fmt.Sprintf("Type %s to confirm deleting %s buckets (buckets must be empty!)", count, count)
+				return m, nil
+			} else if m.currentScreen == s3BrowseScreen {
 				// Use filtered list if active
 				objects := m.s3Objects
 				if len(m.s3FilteredObjects) > 0 {
@@ -1678,6 +4051,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				if len(objects) > 0 && m.s3ObjectSelectedIndex < len(objects) {
 					selectedObject := objects[m.s3ObjectSelectedIndex]
+					if selectedObject.IsFolder {
+						// Delete everything under the folder
+						return m, m.startS3Batch("delete", m.s3SelectionKeys())
+					}
 					if !selectedObject.IsFolder {
 						m.s3ConfirmDelete = true
 						m.s3DeleteTarget = "object"
@@ -1706,8 +4083,30 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 		case "p":
+			// Pause or resume a transfer
+			if t, ok := m.selectedTransfer(); ok {
+				if t.State == aws.TransferPaused {
+					m.transferAction("resume", t.ID)
+				} else {
+					m.transferAction("pause", t.ID)
+				}
+				return m, nil
+			}
+			// Pause or resume the log tail
+			if m.currentScreen == logTailScreen {
+				m.logTailPaused = !m.logTailPaused
+				if m.logTailPaused {
+					m.statusMessage = "Tail paused"
+				} else {
+					m.statusMessage = "Tail resumed"
+				}
+				return m, nil
+			}
 			// Generate presigned URL or view bucket policy
-			if m.currentScreen == s3BrowseScreen {
+			if m.currentScreen == s3BrowseScreen && len(m.s3SelectedObjects) > 0 {
+				// Write URLs for every selected object to a file
+				return m, m.startS3Batch("presign", m.s3SelectionKeys())
+			} else if m.currentScreen == s3BrowseScreen {
 				// Use filtered list if active
 				objects := m.s3Objects
 				if len(m.s3FilteredObjects) > 0 {
@@ -1737,6 +4136,16 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, m.generatePresignedURL(m.s3CurrentBucket, m.s3ObjectDetails.Key, 3600)
 			}
 		case "v":
+			// List the versions of an object
+			if m.currentScreen == s3ObjectDetailsScreen && m.s3ObjectDetails != nil {
+				return m, m.openObjectVersions(m.s3ObjectDetails.Key)
+			}
+			if m.currentScreen == s3BrowseScreen {
+				objects := m.s3VisibleObjects()
+				if m.s3ObjectSelectedIndex < len(objects) && !objects[m.s3ObjectSelectedIndex].IsFolder {
+					return m, m.openObjectVersions(objects[m.s3ObjectSelectedIndex].Key)
+				}
+			}
 			// View bucket versioning
 			if m.currentScreen == s3Screen {
 				// Use filtered list if active
@@ -1751,6 +4160,13 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 		case "f":
+			// Filter the log tail with a CloudWatch Logs filter pattern
+			if m.currentScreen == logTailScreen && m.logTail != nil {
+				m.logFilterPrompt = true
+				m.logFilterInput.SetValue(m.logTail.FilterPattern)
+				m.logFilterInput.Focus()
+				return m, textinput.Blink
+			}
 			// Only filter on EC2 list screen
 			if m.currentScreen == ec2Screen {
 				m.filtering = true
@@ -1758,6 +4174,23 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 		case " ":
+			// Mark the version to diff against
+			if version, ok := m.selectedObjectVersion(); ok {
+				if m.s3VersionMark == version.VersionId {
+					m.s3VersionMark = ""
+				} else {
+					m.s3VersionMark = version.VersionId
+					m.statusMessage = fmt.Sprintf("Marked %s, press Enter on another version to diff", version.VersionId)
+				}
+				return m, nil
+			}
+			// Toggle bucket or object selection
+			if m.currentScreen == s3Screen || m.currentScreen == s3BrowseScreen {
+				if cursor := m.s3Cursor(); cursor < len(m.s3RowNames()) {
+					m.setS3RowSelected(cursor, !m.s3RowSelected(cursor))
+				}
+				return m, nil
+			}
 			// Toggle instance selection (space bar)
 			if m.currentScreen == ec2Screen {
 				// Use filtered list if active
@@ -1775,26 +4208,68 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return m, nil
 				}
 			}
+		case "V":
+			// Select a range of buckets or objects
+			if (m.currentScreen == s3Screen || m.currentScreen == s3BrowseScreen) && len(m.s3RowNames()) > 0 {
+				m.selectS3Range()
+				return m, nil
+			}
+		case "]":
+			// Page forward through the object preview
+			if m.currentScreen == s3ObjectDetailsScreen && !m.s3PreviewLoading && m.s3PreviewPage != nil && m.s3PreviewPage.Next >= 0 {
+				m.s3PreviewLoading = true
+				return m, m.loadS3PreviewPage(m.s3PreviewPage.Next, false)
+			}
+		case "[":
+			// Page back through the object preview
+			if m.currentScreen == s3ObjectDetailsScreen && !m.s3PreviewLoading && len(m.s3PreviewHistory) > 0 {
+				m.s3PreviewLoading = true
+				return m, m.loadS3PreviewPage(m.s3PreviewHistory[len(m.s3PreviewHistory)-1], true)
+			}
 		case "a":
 			// Toggle auto-refresh
 			if m.currentScreen == ec2Screen {
 				m.autoRefresh = !m.autoRefresh
 				if m.autoRefresh {
-					m.statusMessage = "Auto-refresh enabled (30s)"
-					return m, tickCmd()
+					m.statusMessage = fmt.Sprintf("Auto-refresh enabled (%ds)", m.autoRefreshInterval)
+					return m, m.tickCmd()
 				} else {
 					m.statusMessage = "Auto-refresh disabled"
 				}
 				return m, nil
 			}
 		case "x":
+			// Clear completed transfers from the queue
+			if m.currentScreen == transfersScreen && m.transfers != nil {
+				m.statusMessage = fmt.Sprintf("Cleared %d completed transfers", m.transfers.ClearDone())
+				m.refreshTransfers()
+				return m, nil
+			}
 			// Clear all selections
 			if m.currentScreen == ec2Screen {
 				m.ec2SelectedInstances = make(map[string]bool)
 				m.statusMessage = "Cleared all selections"
 				return m, nil
 			}
+			if m.currentScreen == s3Screen || m.currentScreen == s3BrowseScreen {
+				m.clearS3Selection()
+				m.statusMessage = "Cleared all selections"
+				return m, nil
+			}
 		case "y":
+			// Duplicate a port forward on a new local port
+			if pf, ok := m.selectedPortForward(); ok {
+				return m, m.portForwardAction("duplicate", pf.ID)
+			}
+			// Copy the presigned upload snippet
+			if m.s3PresignedUpload != "" && m.currentScreen == s3BrowseScreen {
+				if err := clipboard.WriteAll(m.s3PresignedUpload); err != nil {
+					m.statusMessage = fmt.Sprintf("Couldn't copy to the clipboard: %v", err)
+				} else {
+					m.statusMessage = "Copied to clipboard"
+				}
+				return m, nil
+			}
 			// Copy instance ID or IP to clipboard
 			if m.currentScreen == ec2Screen && len(m.ec2Instances) > 0 {
 				instance := m.ec2Instances[m.ec2SelectedIndex]
@@ -1814,6 +4289,24 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 		case "s":
+			// Restart a port forward
+			if pf, ok := m.selectedPortForward(); ok {
+				return m, m.portForwardAction("restart", pf.ID)
+			}
+			// Retry a failed or cancelled transfer
+			if t, ok := m.selectedTransfer(); ok {
+				m.transferAction("retry", t.ID)
+				return m, nil
+			}
+			// Save the Insights query
+			if m.currentScreen == logInsightsScreen {
+				m.insightsSavePrompt = true
+				if m.savedQueryIndex >= 0 && m.savedQueryIndex < len(m.savedQueries) {
+					m.insightsNameInput.SetValue(m.savedQueries[m.savedQueryIndex].Name)
+				}
+				m.insightsNameInput.Focus()
+				return m, textinput.Blink
+			}
 			// Start instance (single or bulk)
 			if m.currentScreen == ec2Screen && len(m.ec2SelectedInstances) > 0 {
 				// Bulk action
@@ -1837,6 +4330,19 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 		case "S":
+			// Stop a port forward
+			if pf, ok := m.selectedPortForward(); ok {
+				return m, m.portForwardAction("stop", pf.ID)
+			}
+			// Cancel a transfer
+			if t, ok := m.selectedTransfer(); ok {
+				m.transferAction("cancel", t.ID)
+				return m, nil
+			}
+			// Stop the running Insights query
+			if m.currentScreen == logInsightsScreen && m.insightsResult != nil && !m.insightsResult.Done() {
+				return m, m.stopInsightsQuery(m.insightsQueryID)
+			}
 			// Stop instance (single or bulk)
 			if m.currentScreen == ec2Screen && len(m.ec2SelectedInstances) > 0 {
 				// Bulk action
@@ -1862,6 +4368,20 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 		case "R":
+			// Restore an object version by copying it on top
+			if version, ok := m.selectedObjectVersion(); ok {
+				if version.IsDeleteMarker {
+					m.statusMessage = "A delete marker can't be restored, press D to remove it"
+					return m, nil
+				}
+				if version.IsLatest {
+					m.statusMessage = fmt.Sprintf("Version %s is already the current one", version.VersionId)
+					return m, nil
+				}
+				m.loading = true
+				m.statusMessage = fmt.Sprintf("Restoring version %s...", version.VersionId)
+				return m, m.restoreObjectVersion(m.s3CurrentBucket, m.s3VersionsKey, version)
+			}
 			// Reboot instance (single or bulk)
 			if m.currentScreen == ec2Screen && len(m.ec2SelectedInstances) > 0 {
 				// Bulk action
@@ -1869,47 +4389,170 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				for id := range m.ec2SelectedInstances {
 					instanceIDs = append(instanceIDs, id)
 				}
-				m.showingConfirm = true
-				m.confirmAction = "reboot"
-				m.confirmInstanceID = fmt.Sprintf("%d instances", len(instanceIDs))
+				m.showingConfirm = true
+				m.confirmAction = "reboot"
+				m.confirmInstanceID = fmt.Sprintf("%d instances", len(instanceIDs))
+				return m, nil
+			}
+			var instanceID string
+			if m.currentScreen == ec2Screen && len(m.ec2Instances) > 0 {
+				instanceID = m.ec2Instances[m.ec2SelectedIndex].ID
+			} else if m.currentScreen == ec2DetailsScreen && m.ec2InstanceDetails != nil {
+				instanceID = m.ec2InstanceDetails.ID
+			}
+			if instanceID != "" {
+				m.showingConfirm = true
+				m.confirmAction = "reboot"
+				m.confirmInstanceID = instanceID
+				return m, nil
+			}
+		case "t":
+			// Tail a whole log group
+			if group, ok := m.selectedLogGroup(); ok {
+				return m, m.startLogTail(group.Name, nil, "")
+			} else if m.currentScreen == logStreamsScreen {
+				return m, m.startLogTail(m.logGroupName, nil, "")
+			}
+			// Terminate instance (single or bulk)
+			if m.currentScreen == ec2Screen && len(m.ec2SelectedInstances) > 0 {
+				// Bulk action
+				var instanceIDs []string
+				for id := range m.ec2SelectedInstances {
+					instanceIDs = append(instanceIDs, id)
+				}
+				m.showingConfirm = true
+				m.confirmAction = "terminate"
+				m.confirmInstanceID = fmt.Sprintf("%d instances", len(instanceIDs))
+				return m, nil
+			}
+			var instanceID string
+			if m.currentScreen == ec2Screen && len(m.ec2Instances) > 0 {
+				instanceID = m.ec2Instances[m.ec2SelectedIndex].ID
+			} else if m.currentScreen == ec2DetailsScreen && m.ec2InstanceDetails != nil {
+				instanceID = m.ec2InstanceDetails.ID
+			}
+			if instanceID != "" {
+				m.showingConfirm = true
+				m.confirmAction = "terminate"
+				m.confirmInstanceID = instanceID
+				return m, nil
+			}
+		case "m":
+			// Chart the next metric
+			if m.currentScreen == ec2DetailsScreen {
+				m.ec2MetricIndex = (m.ec2MetricIndex + 1) % len(aws.InstanceMetricList)
+				return m, nil
+			}
+		case "M":
+			// Chart the previous metric
+			if m.currentScreen == ec2DetailsScreen {
+				m.ec2MetricIndex = (m.ec2MetricIndex + len(aws.InstanceMetricList) - 1) % len(aws.InstanceMetricList)
+				return m, nil
+			}
+		case "w":
+			// Cycle the Insights query window
+			if m.currentScreen == logInsightsScreen {
+				m.insightsRange = (m.insightsRange + 1) % len(aws.MetricRanges)
+				m.statusMessage = fmt.Sprintf("Query window: last %s", aws.MetricRanges[m.insightsRange].Label)
+				return m, nil
+			}
+			// Cycle the metric chart range and reload
+			if m.currentScreen == ec2DetailsScreen && m.ec2InstanceDetails != nil {
+				m.ec2MetricRange = (m.ec2MetricRange + 1) % len(aws.MetricRanges)
+				m.statusMessage = fmt.Sprintf("Loading metrics for the last %s...", aws.MetricRanges[m.ec2MetricRange].Label)
+				return m, m.loadInstanceMetricHistory(m.ec2InstanceDetails.ID)
+			}
+		case "+", "-":
+			// Change how many transfers run at once
+			if m.currentScreen == transfersScreen && m.transfers != nil {
+				n := m.transfers.Concurrency() + 1
+				if msg.String() == "-" {
+					n = m.transfers.Concurrency() - 1
+				}
+				m.transfers.SetConcurrency(n)
+				m.statusMessage = fmt.Sprintf("Running up to %d transfers at once", m.transfers.Concurrency())
+				return m, nil
+			}
+		case "I":
+			// Query a log group, or a cluster's control plane logs, with Insights
+			if group, ok := m.selectedLogGroup(); ok {
+				return m, m.openInsights([]string{group.Name})
+			} else if m.currentScreen == logStreamsScreen || m.currentScreen == logTailScreen {
+				groupName := m.logGroupName
+				if m.currentScreen == logTailScreen && m.logTail != nil {
+					groupName = m.logTail.GroupName
+				}
+				return m, m.openInsights([]string{groupName})
+			} else if m.currentScreen == eksDetailsScreen && m.eksClusterDetails != nil {
+				return m, m.openInsights([]string{aws.EKSControlPlaneLogGroup(m.eksClusterDetails.Name)})
+			}
+		case "L":
+			// Tail a cluster's control plane logs
+			if m.currentScreen == eksDetailsScreen && m.eksClusterDetails != nil {
+				if len(m.eksClusterDetails.EnabledLogTypes) == 0 {
+					m.statusMessage = fmt.Sprintf("Control plane logging is disabled for %s", m.eksClusterDetails.Name)
+					return m, nil
+				}
+				m.loading = true
+				return m, m.loadClusterLogStreams(m.eksClusterDetails.Name, m.eksClusterDetails.EnabledLogTypes)
+			}
+			// Load the next saved query into the Insights editor
+			if m.currentScreen == logInsightsScreen {
+				if len(m.savedQueries) == 0 {
+					m.statusMessage = "No saved queries"
+					return m, nil
+				}
+				m.savedQueryIndex = (m.savedQueryIndex + 1) % len(m.savedQueries)
+				saved := m.savedQueries[m.savedQueryIndex]
+				m.insightsQueryInput.SetValue(saved.Query)
+				if len(saved.LogGroups) > 0 {
+					m.insightsGroups = saved.LogGroups
+				}
+				m.statusMessage = fmt.Sprintf("Loaded saved query %s", saved.Name)
 				return m, nil
 			}
-			var instanceID string
-			if m.currentScreen == ec2Screen && len(m.ec2Instances) > 0 {
-				instanceID = m.ec2Instances[m.ec2SelectedIndex].ID
-			} else if m.currentScreen == ec2DetailsScreen && m.ec2InstanceDetails != nil {
-				instanceID = m.ec2InstanceDetails.ID
+		case "A":
+			// Enable or disable the actions of an alarm
+			if alarm, ok := m.selectedAlarm(); ok {
+				return m, m.toggleAlarmActions(alarm)
 			}
-			if instanceID != "" {
-				m.showingConfirm = true
-				m.confirmAction = "reboot"
-				m.confirmInstanceID = instanceID
-				return m, nil
+		case "T":
+			// Set an alarm's state to test its actions
+			if m.currentScreen == alarmDetailsScreen && m.alarmDetails != nil {
+				m.alarmStatePrompt = true
+				m.alarmStateInput.Focus()
+				return m, textinput.Blink
 			}
-		case "t":
-			// Terminate instance (single or bulk)
-			if m.currentScreen == ec2Screen && len(m.ec2SelectedInstances) > 0 {
-				// Bulk action
-				var instanceIDs []string
-				for id := range m.ec2SelectedInstances {
-					instanceIDs = append(instanceIDs, id)
+		case "o":
+			// Jump to the EC2 instance or EKS cluster an alarm watches
+			if alarm, ok := m.selectedAlarm(); ok {
+				switch alarm.Resource.Kind {
+				case aws.AlarmResourceEC2Instance:
+					m.loading = true
+					m.viewportOffset = 0
+					return m, m.loadEC2InstanceDetails(alarm.Resource.ID)
+				case aws.AlarmResourceEKSCluster:
+					m.loading = true
+					m.viewportOffset = 0
+					return m, m.loadEKSClusterDetails(alarm.Resource.ID)
+				default:
+					m.statusMessage = fmt.Sprintf("Alarm %s doesn't watch an EC2 instance or EKS cluster", alarm.Name)
+					return m, nil
 				}
-				m.showingConfirm = true
-				m.confirmAction = "terminate"
-				m.confirmInstanceID = fmt.Sprintf("%d instances", len(instanceIDs))
-				return m, nil
 			}
+		case "P":
+			// Forward a port through the selected instance
 			var instanceID string
 			if m.currentScreen == ec2Screen && len(m.ec2Instances) > 0 {
 				instanceID = m.ec2Instances[m.ec2SelectedIndex].ID
 			} else if m.currentScreen == ec2DetailsScreen && m.ec2InstanceDetails != nil {
 				instanceID = m.ec2InstanceDetails.ID
 			}
-			if instanceID != "" {
-				m.showingConfirm = true
-				m.confirmAction = "terminate"
-				m.confirmInstanceID = instanceID
-				return m, nil
+			if instanceID != "" && m.portForwards != nil {
+				m.portForwardPrompt = true
+				m.portForwardInstance = instanceID
+				m.portForwardInput.Focus()
+				return m, textinput.Blink
 			}
 		case "C":
 			// Launch SSM session (only in details view with SSM connected)
@@ -1941,12 +4584,15 @@ func (m *model) clearSearch() {
 	m.s3FilteredObjects = nil
 	m.ssoFilteredAccounts = nil
 	m.eksFilteredClusters = nil
+	m.alarmsFiltered = nil
+	m.logGroupsFiltered = nil
 }
 
 // Helper functions for VIM navigation
 func (m *model) handleVimNavigation(action vim.NavigationAction) {
 	// For detail screens, handle viewport scrolling instead of item navigation
-	if m.currentScreen == ec2DetailsScreen || m.currentScreen == s3ObjectDetailsScreen || m.currentScreen == eksDetailsScreen {
+	if m.currentScreen == ec2DetailsScreen || m.currentScreen == s3ObjectDetailsScreen || m.currentScreen == eksDetailsScreen || m.currentScreen == alarmDetailsScreen ||
+		(m.currentScreen == s3VersionsScreen && m.s3VersionDiff != "") {
 		m.handleDetailViewScroll(action)
 		return
 	}
@@ -2011,6 +4657,51 @@ func (m *model) handleVimNavigation(action vim.NavigationAction) {
 			listLength = len(m.eksClusters)
 		}
 		currentIndex = m.eksSelectedIndex
+	case alarmsScreen:
+		if len(m.alarmsFiltered) > 0 {
+			listLength = len(m.alarmsFiltered)
+		} else if m.vimState.LastSearch != "" {
+			// Search active but no results
+			return
+		} else {
+			listLength = len(m.alarms)
+		}
+		currentIndex = m.alarmSelectedIndex
+	case logGroupsScreen:
+		if len(m.logGroupsFiltered) > 0 {
+			listLength = len(m.logGroupsFiltered)
+		} else if m.vimState.LastSearch != "" {
+			// Search active but no results
+			return
+		} else {
+			listLength = len(m.logGroups)
+		}
+		currentIndex = m.logGroupIndex
+	case logStreamsScreen:
+		listLength = len(m.logStreams)
+		currentIndex = m.logStreamIndex
+	case logTailScreen:
+		listLength = len(m.logEvents)
+		currentIndex = m.logEventIndex
+	case logInsightsScreen:
+		if m.insightsResult != nil {
+			listLength = len(m.insightsResult.Rows)
+		}
+		currentIndex = m.insightsRowIndex
+	case portForwardsScreen:
+		listLength = len(m.portForwardList)
+		currentIndex = m.portForwardIndex
+	case transfersScreen:
+		listLength = len(m.transferList)
+		currentIndex = m.transferIndex
+	case syncPlanScreen:
+		if m.syncPlan != nil {
+			listLength = len(m.syncPlan.Actions)
+		}
+		currentIndex = m.syncPlanIndex
+	case s3VersionsScreen:
+		listLength = len(m.s3Versions)
+		currentIndex = m.s3VersionIndex
 	default:
 		return // No navigation for other screens
 	}
@@ -2088,6 +4779,42 @@ func (m *model) setSelectedIndex(index int) {
 		if index >= 0 && index < len(m.eksClusters) {
 			m.eksSelectedIndex = index
 		}
+	case alarmsScreen:
+		if index >= 0 && index < len(m.alarms) {
+			m.alarmSelectedIndex = index
+		}
+	case logGroupsScreen:
+		if index >= 0 && index < len(m.logGroups) {
+			m.logGroupIndex = index
+		}
+	case logStreamsScreen:
+		if index >= 0 && index < len(m.logStreams) {
+			m.logStreamIndex = index
+		}
+	case logTailScreen:
+		if index >= 0 && index < len(m.logEvents) {
+			m.logEventIndex = index
+		}
+	case logInsightsScreen:
+		if m.insightsResult != nil && index >= 0 && index < len(m.insightsResult.Rows) {
+			m.insightsRowIndex = index
+		}
+	case portForwardsScreen:
+		if index >= 0 && index < len(m.portForwardList) {
+			m.portForwardIndex = index
+		}
+	case transfersScreen:
+		if index >= 0 && index < len(m.transferList) {
+			m.transferIndex = index
+		}
+	case syncPlanScreen:
+		if m.syncPlan != nil && index >= 0 && index < len(m.syncPlan.Actions) {
+			m.syncPlanIndex = index
+		}
+	case s3VersionsScreen:
+		if index >= 0 && index < len(m.s3Versions) {
+			m.s3VersionIndex = index
+		}
 	}
 }
 
@@ -2125,6 +4852,14 @@ func (m *model) applyVimSearch() {
 		for _, cluster := range m.eksClusters {
 			searchItems = append(searchItems, strings.ToLower(cluster.Name+" "+cluster.Version+" "+cluster.Status+" "+cluster.Region))
 		}
+	case alarmsScreen:
+		for _, alarm := range m.alarms {
+			searchItems = append(searchItems, strings.ToLower(alarm.Name+" "+alarm.State+" "+alarm.Type+" "+alarm.Resource.String()+" "+alarm.MetricName))
+		}
+	case logGroupsScreen:
+		for _, group := range m.logGroups {
+			searchItems = append(searchItems, strings.ToLower(group.Name))
+		}
 	default:
 		return
 	}
@@ -2160,6 +4895,16 @@ func (m *model) applyVimSearch() {
 			for _, idx := range m.vimState.SearchResults {
 				m.eksFilteredClusters = append(m.eksFilteredClusters, m.eksClusters[idx])
 			}
+		case alarmsScreen:
+			m.alarmsFiltered = make([]aws.Alarm, 0, len(m.vimState.SearchResults))
+			for _, idx := range m.vimState.SearchResults {
+				m.alarmsFiltered = append(m.alarmsFiltered, m.alarms[idx])
+			}
+		case logGroupsScreen:
+			m.logGroupsFiltered = make([]aws.LogGroup, 0, len(m.vimState.SearchResults))
+			for _, idx := range m.vimState.SearchResults {
+				m.logGroupsFiltered = append(m.logGroupsFiltered, m.logGroups[idx])
+			}
 		}
 
 		// Reset selection to first filtered result
@@ -2179,6 +4924,10 @@ func (m *model) applyVimSearch() {
 			m.s3FilteredObjects = []aws.S3Object{}
 		case eksScreen:
 			m.eksFilteredClusters = []aws.EKSCluster{}
+		case alarmsScreen:
+			m.alarmsFiltered = []aws.Alarm{}
+		case logGroupsScreen:
+			m.logGroupsFiltered = []aws.LogGroup{}
 		}
 	}
 }
@@ -2213,11 +4962,29 @@ func (m *model) executeVimCommand(commandStr string) tea.Cmd {
 		} else if m.currentScreen == s3Screen {
 			return m.loadS3Buckets
 		} else if m.currentScreen == s3BrowseScreen {
-			return m.loadS3Objects(m.s3CurrentBucket, m.s3CurrentPrefix, nil)
+			return m.reloadS3Objects()
+		} else if m.currentScreen == alarmsScreen {
+			return m.loadAlarms
+		} else if m.currentScreen == logGroupsScreen {
+			return m.loadLogGroups
+		} else if m.currentScreen == logStreamsScreen {
+			return m.loadLogStreams(m.logGroupName)
 		}
 
 	case vim.CmdSelectAll:
-		// Select all instances (EC2 only)
+		// Select all instances, or the buckets and objects matching a glob
+		if m.currentScreen == s3Screen || m.currentScreen == s3BrowseScreen {
+			pattern := "*"
+			if len(cmd.Args) > 0 {
+				pattern = cmd.Args[0]
+			}
+			matched, err := m.selectS3Matching(pattern, true)
+			if err != nil {
+				m.statusMessage = fmt.Sprintf("Invalid pattern %q: %v", pattern, err)
+				return nil
+			}
+			m.statusMessage = fmt.Sprintf("Selected %d matching %s (%d selected)", matched, pattern, m.s3SelectedCount())
+		}
 		if m.currentScreen == ec2Screen {
 			for _, inst := range m.ec2Instances {
 				m.ec2SelectedInstances[inst.ID] = true
@@ -2226,7 +4993,20 @@ func (m *model) executeVimCommand(commandStr string) tea.Cmd {
 		}
 
 	case vim.CmdDeselectAll:
-		// Deselect all instances
+		// Deselect all instances, or the buckets and objects matching a glob
+		if m.currentScreen == s3Screen || m.currentScreen == s3BrowseScreen {
+			if len(cmd.Args) == 0 {
+				m.clearS3Selection()
+				m.statusMessage = "Cleared all selections"
+				return nil
+			}
+			matched, err := m.selectS3Matching(cmd.Args[0], false)
+			if err != nil {
+				m.statusMessage = fmt.Sprintf("Invalid pattern %q: %v", cmd.Args[0], err)
+				return nil
+			}
+			m.statusMessage = fmt.Sprintf("Deselected %d matching %s (%d selected)", matched, cmd.Args[0], m.s3SelectedCount())
+		}
 		if m.currentScreen == ec2Screen {
 			m.ec2SelectedInstances = make(map[string]bool)
 			m.statusMessage = "Cleared all selections"
@@ -2280,6 +5060,364 @@ func (m *model) executeVimCommand(commandStr string) tea.Cmd {
 		}
 		m.statusMessage = "Switched to EKS"
 
+	case vim.CmdAlarms:
+		// Switch to CloudWatch alarms
+		m.clearSearch() // Clear search when switching screens
+		m.currentScreen = alarmsScreen
+		m.viewportOffset = 0
+		if len(m.alarms) == 0 {
+			m.loading = true
+			return m.loadAlarms
+		}
+		m.statusMessage = "Switched to CloudWatch alarms"
+
+	case vim.CmdLogs:
+		// Switch to CloudWatch Logs
+		m.clearSearch() // Clear search when switching screens
+		m.currentScreen = logGroupsScreen
+		m.viewportOffset = 0
+		if len(m.logGroups) == 0 {
+			m.loading = true
+			return m.loadLogGroups
+		}
+		m.statusMessage = "Switched to CloudWatch Logs"
+
+	case vim.CmdInsights:
+		// Query the current log group with Logs Insights
+		groups := m.insightsGroups
+		if m.logTail != nil {
+			groups = []string{m.logTail.GroupName}
+		}
+		if group, ok := m.selectedLogGroup(); ok {
+			groups = []string{group.Name}
+		}
+		if len(groups) == 0 {
+			m.statusMessage = "Select a log group to query first (:logs)"
+			return nil
+		}
+		return m.openInsights(groups)
+
+	case vim.CmdPortForwards, "portforwards":
+		// Show the port forwards panel
+		if m.portForwards == nil {
+			m.statusMessage = "Port forwards are not available"
+			return nil
+		}
+		if m.currentScreen != portForwardsScreen {
+			m.previousScreen = m.currentScreen
+		}
+		m.clearSearch()
+		m.currentScreen = portForwardsScreen
+		m.viewportOffset = 0
+		m.refreshPortForwards()
+		return portForwardTickCmd()
+
+	case vim.CmdCopy, vim.CmdMove:
+		// Copy or move the selection server-side
+		if m.currentScreen != s3BrowseScreen {
+			m.statusMessage = "Open a bucket first (:s3)"
+			return nil
+		}
+		op := "copy"
+		if cmd.Name == vim.CmdMove {
+			op = "move"
+		}
+		if len(cmd.Args) != 1 {
+			m.statusMessage = fmt.Sprintf("usage: :%s s3://bucket/prefix", cmd.Name)
+			return nil
+		}
+		destBucket, destPrefix, ok := parseS3Destination(cmd.Args[0])
+		if !ok {
+			m.statusMessage = fmt.Sprintf("Invalid destination %q", cmd.Args[0])
+			return nil
+		}
+		m.s3BatchDestBucket = destBucket
+		m.s3BatchDestPrefix = destPrefix
+		return m.startS3Batch(op, m.s3SelectionKeys())
+
+	case vim.CmdStorageClass:
+		// Change the storage class of the selection
+		if m.currentScreen != s3BrowseScreen {
+			m.statusMessage = "Open a bucket first (:s3)"
+			return nil
+		}
+		if len(cmd.Args) != 1 {
+			m.statusMessage = "usage: :storageclass STANDARD|STANDARD_IA|ONEZONE_IA|INTELLIGENT_TIERING|GLACIER_IR|GLACIER|DEEP_ARCHIVE"
+			return nil
+		}
+		storageClass, err := aws.ParseStorageClass(cmd.Args[0])
+		if err != nil {
+			m.statusMessage = err.Error()
+			return nil
+		}
+		m.s3BatchStorageClass = storageClass
+		return m.startS3Batch("change storage class", m.s3SelectionKeys())
+
+	case vim.CmdTag:
+		// Add tags to the selection
+		if m.currentScreen != s3BrowseScreen {
+			m.statusMessage = "Open a bucket first (:s3)"
+			return nil
+		}
+		if len(cmd.Args) == 0 {
+			m.statusMessage = "usage: :tag KEY=VALUE [KEY=VALUE...]"
+			return nil
+		}
+		tags, err := parseS3Tags(cmd.Args)
+		if err != nil {
+			m.statusMessage = err.Error()
+			return nil
+		}
+		m.s3BatchTags = tags
+		return m.startS3Batch("tag", m.s3SelectionKeys())
+
+	case vim.CmdRestore:
+		// Restore the archived objects in the selection, or the open object
+		keys := m.s3SelectionKeys()
+		if m.currentScreen == s3ObjectDetailsScreen && m.s3ObjectDetails != nil {
+			keys = []string{m.s3ObjectDetails.Key}
+		} else if m.currentScreen != s3BrowseScreen {
+			m.statusMessage = "Open a bucket first (:s3)"
+			return nil
+		}
+		tier, days := aws.DefaultRestoreTier, aws.DefaultRestoreDays
+		for _, arg := range cmd.Args {
+			if n, err := strconv.Atoi(arg); err == nil {
+				if n < 1 {
+					m.statusMessage = "A restore lasts at least 1 day"
+					return nil
+				}
+				days = n
+				continue
+			}
+			var err error
+			if tier, err = aws.ParseRestoreTier(arg); err != nil {
+				m.statusMessage = "usage: :restore [Expedited|Standard|Bulk] [DAYS]"
+				return nil
+			}
+		}
+		m.s3BatchRestoreTier = tier
+		m.s3BatchRestoreDays = int32(days)
+		return m.startS3Batch("restore", keys)
+
+	case vim.CmdPresign:
+		// Presign an upload to the current folder
+		if m.currentScreen != s3BrowseScreen {
+			m.statusMessage = "Open a bucket first (:s3)"
+			return nil
+		}
+		usage := "usage: :presign put KEY [type=T] [sha256=S] [expires=1h] | :presign post|form [SUBFOLDER] [min=SIZE] [max=SIZE] [expires=1h]"
+		if len(cmd.Args) == 0 {
+			m.statusMessage = usage
+			return nil
+		}
+		switch cmd.Args[0] {
+		case "put":
+			if len(cmd.Args) < 2 || strings.Contains(cmd.Args[1], "=") {
+				m.statusMessage = "usage: :presign put KEY [type=T] [sha256=S] [expires=1h]"
+				return nil
+			}
+			opts, err := aws.ParsePresignPutOptions(cmd.Args[2:])
+			if err != nil {
+				m.statusMessage = err.Error()
+				return nil
+			}
+			m.loading = true
+			return m.presignPut(m.s3CurrentBucket, m.s3CurrentPrefix+cmd.Args[1], opts)
+		case "post", "form":
+			terms := cmd.Args[1:]
+			prefix := m.s3CurrentPrefix
+			if len(terms) > 0 && !strings.Contains(terms[0], "=") {
+				prefix += strings.TrimSuffix(terms[0], "/") + "/"
+				terms = terms[1:]
+			}
+			opts, err := aws.ParsePresignPostOptions(terms)
+			if err != nil {
+				m.statusMessage = err.Error()
+				return nil
+			}
+			opts.KeyPrefix = prefix
+			m.loading = true
+			return m.presignPost(m.s3CurrentBucket, opts, cmd.Args[0] == "form")
+		}
+		m.statusMessage = usage
+		return nil
+
+	case vim.CmdTagSet:
+		// Apply a tag template to the selected instances, through the tag form
+		names := m.config.TagTemplateNames()
+		if len(cmd.Args) != 1 {
+			if len(names) == 0 {
+				m.statusMessage = "No tag templates, add tag_templates to ~/.lazyaws/config.yaml"
+			} else {
+				m.statusMessage = "usage: :tagset " + strings.Join(names, "|")
+			}
+			return nil
+		}
+		if _, ok := m.config.TagTemplates[cmd.Args[0]]; !ok {
+			m.statusMessage = fmt.Sprintf("Unknown tag template %q", cmd.Args[0])
+			return nil
+		}
+		ids := m.ec2SelectionIDs()
+		if len(ids) == 0 {
+			m.statusMessage = "Select instances first (:ec2)"
+			return nil
+		}
+		m.ec2EditTagInstances = ids
+		m.ec2EditTagTemplate = cmd.Args[0]
+		m.statusMessage = "Opening tags in editor..."
+		return tea.Quit
+
+	case vim.CmdFilter:
+		// Filter the instance list server-side, or clear the filter
+		filter, err := aws.ParseInstanceFilter(cmd.Args)
+		if err != nil {
+			m.statusMessage = err.Error()
+			return nil
+		}
+		return m.applyEC2Filter(filter, "")
+
+	case vim.CmdView:
+		// Load, save or remove a saved EC2 filter
+		usage := "usage: :view NAME | :view save NAME | :view rm NAME"
+		switch {
+		case len(cmd.Args) == 0:
+			if names := m.ec2Views.Names(); len(names) > 0 {
+				m.statusMessage = "Views: " + strings.Join(names, ", ")
+			} else {
+				m.statusMessage = "No saved views, use :filter then :view save NAME"
+			}
+		case len(cmd.Args) == 1:
+			filter, err := m.ec2Views.Get(cmd.Args[0])
+			if err != nil {
+				m.statusMessage = err.Error()
+				return nil
+			}
+			return m.applyEC2Filter(filter, cmd.Args[0])
+		case len(cmd.Args) == 2 && cmd.Args[0] == "save":
+			if m.ec2Filter.Empty() {
+				m.statusMessage = "No filter to save, use :filter TERMS first"
+				return nil
+			}
+			if err := m.ec2Views.Save(cmd.Args[1], m.ec2Filter); err != nil {
+				m.statusMessage = err.Error()
+				return nil
+			}
+			m.ec2View = cmd.Args[1]
+			m.statusMessage = fmt.Sprintf("Saved view %s: %s", cmd.Args[1], m.ec2Filter.String())
+		case len(cmd.Args) == 2 && cmd.Args[0] == "rm":
+			if err := m.ec2Views.Delete(cmd.Args[1]); err != nil {
+				m.statusMessage = err.Error()
+				return nil
+			}
+			if m.ec2View == cmd.Args[1] {
+				m.ec2View = ""
+			}
+			m.statusMessage = "Removed view " + cmd.Args[1]
+		default:
+			m.statusMessage = usage
+		}
+		return nil
+
+	case vim.CmdVersions:
+		// List the versions of an object, which also reaches deleted ones
+		if m.currentScreen == s3ObjectDetailsScreen && m.s3ObjectDetails != nil && len(cmd.Args) == 0 {
+			return m.openObjectVersions(m.s3ObjectDetails.Key)
+		}
+		if m.currentScreen != s3BrowseScreen {
+			m.statusMessage = "Open a bucket first (:s3)"
+			return nil
+		}
+		if len(cmd.Args) > 1 {
+			m.statusMessage = "usage: :versions [KEY]"
+			return nil
+		}
+		if len(cmd.Args) == 1 {
+			return m.openObjectVersions(m.s3CurrentPrefix + cmd.Args[0])
+		}
+		objects := m.s3VisibleObjects()
+		if m.s3ObjectSelectedIndex >= len(objects) || objects[m.s3ObjectSelectedIndex].IsFolder {
+			m.statusMessage = "usage: :versions [KEY]"
+			return nil
+		}
+		return m.openObjectVersions(objects[m.s3ObjectSelectedIndex].Key)
+
+	case vim.CmdFind:
+		// Search the whole bucket below the current folder
+		if m.currentScreen != s3BrowseScreen {
+			m.statusMessage = "Open a bucket to search first (:s3)"
+			return nil
+		}
+		if len(cmd.Args) == 0 {
+			m.statusMessage = "usage: :find [GLOB|re:REGEX] [size>10MB] [modified<7d] [class=GLACIER] [tag:K=V]"
+			return nil
+		}
+		return m.startS3Search(cmd.Args)
+
+	case vim.CmdEdit:
+		// Edit a configuration document of the highlighted or open bucket
+		bucket := m.s3CurrentBucket
+		if m.currentScreen == s3Screen {
+			buckets := m.s3VisibleBuckets()
+			bucket = ""
+			if m.s3SelectedIndex < len(buckets) {
+				bucket = buckets[m.s3SelectedIndex].Name
+			}
+		} else if m.currentScreen != s3BrowseScreen && m.currentScreen != s3ObjectDetailsScreen {
+			bucket = ""
+		}
+		if bucket == "" {
+			m.statusMessage = "Select a bucket first (:s3)"
+			return nil
+		}
+		if len(cmd.Args) != 1 {
+			m.statusMessage = "usage: :edit " + strings.Join(aws.BucketConfigKinds, "|")
+			return nil
+		}
+		kind, err := aws.ParseBucketConfigKind(cmd.Args[0])
+		if err != nil {
+			m.statusMessage = err.Error()
+			return nil
+		}
+		m.s3EditBucket = bucket
+		m.s3EditConfigKind = kind
+		m.statusMessage = fmt.Sprintf("Opening %s of %s in editor...", kind, bucket)
+		return tea.Quit
+
+	case vim.CmdSync:
+		// Compare the current prefix with a local directory
+		if m.currentScreen != s3BrowseScreen {
+			m.statusMessage = "Open a bucket to sync first (:s3)"
+			return nil
+		}
+		if m.transfers == nil {
+			m.statusMessage = "Transfers are not available"
+			return nil
+		}
+		up, dir, opts, err := parseSyncArgs(cmd.Args)
+		if err != nil {
+			m.statusMessage = err.Error()
+			return nil
+		}
+		m.loading = true
+		m.statusMessage = "Comparing files..."
+		return m.planSync(up, dir, opts)
+
+	case vim.CmdTransfers:
+		// Show the transfer queue
+		if m.transfers == nil {
+			m.statusMessage = "Transfers are not available"
+			return nil
+		}
+		if m.currentScreen != transfersScreen {
+			m.previousScreen = m.currentScreen
+		}
+		m.clearSearch()
+		m.currentScreen = transfersScreen
+		m.viewportOffset = 0
+		m.refreshTransfers()
+		return transferTickCmd()
+
 	case vim.CmdAccount, "acc":
 		// Switch to account selection screen
 		// Only works with SSO auth method
@@ -2440,7 +5578,7 @@ func (m model) View() string {
 	// Content area
 	contentStyle := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("8")).
+		BorderForeground(lipgloss.Color(theme.Muted)).
 		Padding(1, 2).
 		Width(m.width - 2) // Full width minus small margin
 
@@ -2479,10 +5617,30 @@ func (m model) View() string {
 		content = m.renderS3Browse()
 	case s3ObjectDetailsScreen:
 		content = m.renderS3ObjectDetails()
+	case s3VersionsScreen:
+		content = m.renderS3Versions()
 	case eksScreen:
 		content = m.renderEKS()
 	case eksDetailsScreen:
 		content = m.renderEKSDetails()
+	case alarmsScreen:
+		content = m.renderAlarms()
+	case alarmDetailsScreen:
+		content = m.renderAlarmDetails()
+	case logGroupsScreen:
+		content = m.renderLogGroups()
+	case logStreamsScreen:
+		content = m.renderLogStreams()
+	case logTailScreen:
+		content = m.renderLogTail()
+	case logInsightsScreen:
+		content = m.renderLogInsights()
+	case portForwardsScreen:
+		content = m.renderPortForwards()
+	case transfersScreen:
+		content = m.renderTransfers()
+	case syncPlanScreen:
+		content = m.renderSyncPlan()
 	case helpScreen:
 		content = m.renderHelp()
 	}
@@ -2496,18 +5654,68 @@ func (m model) View() string {
 	// Show delete confirmation input
 	if m.s3ConfirmDelete {
 		confirmStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("1")).
+			Foreground(lipgloss.Color(theme.Error)).
 			Bold(true)
 		s += "\n" + confirmStyle.Render("DELETE CONFIRMATION")
 		s += "\n" + m.deleteConfirmInput.View()
-		s += "\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("Press ESC to cancel")
+		s += "\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render("Press ESC to cancel")
+	}
+
+	// Show log tail filter input
+	if m.logFilterPrompt {
+		promptStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color(theme.Warning)).
+			Bold(true)
+		s += "\n" + promptStyle.Render("FILTER PATTERN (empty shows every event)")
+		s += "\n" + m.logFilterInput.View()
+		s += "\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render("Press ESC to cancel")
+	}
+
+	// Show saved query name input
+	if m.insightsSavePrompt {
+		promptStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color(theme.Warning)).
+			Bold(true)
+		s += "\n" + promptStyle.Render("SAVE QUERY AS")
+		s += "\n" + m.insightsNameInput.View()
+		s += "\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render("Press ESC to cancel")
+	}
+
+	// Show alarm state input
+	if m.alarmStatePrompt && m.alarmDetails != nil {
+		promptStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color(theme.Warning)).
+			Bold(true)
+		s += "\n" + promptStyle.Render("SET STATE of "+m.alarmDetails.Name+" (until its next evaluation)")
+		s += "\n" + m.alarmStateInput.View()
+		s += "\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render("Press ESC to cancel")
+	}
+
+	// Show S3 upload path input
+	if m.s3UploadPrompt {
+		promptStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color(theme.Accent)).
+			Bold(true)
+		s += "\n" + promptStyle.Render("UPLOAD TO s3://"+m.s3CurrentBucket+"/"+m.s3CurrentPrefix)
+		s += "\n" + m.s3UploadInput.View()
+		s += "\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render("Press ESC to cancel")
+	}
+
+	// Show port forward spec input
+	if m.portForwardPrompt {
+		promptStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color(theme.Accent)).
+			Bold(true)
+		s += "\n" + promptStyle.Render("PORT FORWARD via "+m.portForwardInstance)
+		s += "\n" + m.portForwardInput.View()
+		s += "\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render("e.g. 80, 8080:80 or 15432:db.internal:5432 - press ESC to cancel")
 	}
 
 	// Show VIM mode indicator
 	if m.vimState.Mode == vim.SearchMode {
 		searchStyle := lipgloss.NewStyle().
 			Bold(true).
-			Foreground(lipgloss.Color("3")).
+			Foreground(lipgloss.Color(theme.Warning)).
 			Background(lipgloss.Color("0"))
 		s += "\n" + searchStyle.Render("/"+m.vimState.SearchQuery)
 	} else if m.vimState.Mode == vim.CommandMode {
@@ -2529,14 +5737,14 @@ func (m model) View() string {
 	if m.showingConfirm {
 		confirmStyle := lipgloss.NewStyle().
 			Bold(true).
-			Foreground(lipgloss.Color("3")).
+			Foreground(lipgloss.Color(theme.Warning)).
 			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("3")).
+			BorderForeground(lipgloss.Color(theme.Warning)).
 			Padding(1, 2)
 
 		actionText := m.confirmAction
 		if m.confirmAction == "terminate" {
-			actionText = lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Bold(true).Render("TERMINATE")
+			actionText = lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Error)).Bold(true).Render("TERMINATE")
 		}
 
 		confirmMsg := fmt.Sprintf("Are you sure you want to %s instance %s?\n\n(y)es / (n)o",
@@ -2561,19 +5769,33 @@ func (m model) View() string {
 			infoContent = "Bucket Versioning:\n\n" + m.s3BucketVersioning
 		}
 		s += "\n" + infoStyle.Render(infoContent)
-		s += "\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("Press ESC to close")
+		s += "\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render("Press ESC to close")
+	}
+
+	// Show the presigned upload unwrapped, so it can be selected in the terminal too
+	if m.s3PresignedUpload != "" && m.currentScreen == s3BrowseScreen {
+		labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Success)).Bold(true)
+		snippetStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Header))
+		s += "\n" + labelStyle.Render(m.s3PresignedUploadTitle)
+		snippet := strings.Split(m.s3PresignedUpload, "\n")
+		if len(snippet) > maxPresignedLines {
+			more := len(snippet) - maxPresignedLines
+			snippet = append(snippet[:maxPresignedLines], fmt.Sprintf("... %d more lines, y copies them all", more))
+		}
+		s += "\n" + snippetStyle.Render(strings.Join(snippet, "\n"))
+		s += "\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render("y: copy • ESC: close")
 	}
 
 	// Show presigned URL (only on non-macOS platforms, since macOS auto-copies)
 	if m.s3PresignedURL != "" && runtime.GOOS != "darwin" {
-		labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Bold(true)
-		urlStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("255"))
+		labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Success)).Bold(true)
+		urlStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Header))
 		s += "\n" + labelStyle.Render("Presigned URL (1 hour): ") + urlStyle.Render(m.s3PresignedURL)
 	}
 
 	// Show status message
 	if m.statusMessage != "" {
-		statusStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
+		statusStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning))
 		s += "\n" + statusStyle.Render(m.statusMessage)
 	}
 
@@ -2587,9 +5809,9 @@ func (m model) View() string {
 func (m model) renderK9sHeader() string {
 	// K9s color scheme
 	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("220"))                 // Yellow/orange
-	valueStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("255"))                 // White
-	keyHintKeyStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("201")).Bold(true) // Magenta
-	keyHintActionStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))           // Gray
+	valueStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Header))                 // White
+	keyHintKeyStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Search)).Bold(true) // Magenta
+	keyHintActionStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted))           // Gray
 
 	// Left side: Context information
 	var leftSide strings.Builder
@@ -2624,12 +5846,42 @@ func (m model) renderK9sHeader() string {
 	case s3ObjectDetailsScreen:
 		serviceName = "S3"
 		viewName = "Object Details"
+	case s3VersionsScreen:
+		serviceName = "S3"
+		viewName = "Object Versions"
 	case eksScreen:
 		serviceName = "EKS"
 		viewName = "Clusters"
 	case eksDetailsScreen:
 		serviceName = "EKS"
 		viewName = "Cluster Details"
+	case alarmsScreen:
+		serviceName = "CloudWatch"
+		viewName = "Alarms"
+	case alarmDetailsScreen:
+		serviceName = "CloudWatch"
+		viewName = "Alarm Details"
+	case logGroupsScreen:
+		serviceName = "CloudWatch"
+		viewName = "Log Groups"
+	case logStreamsScreen:
+		serviceName = "CloudWatch"
+		viewName = "Log Streams"
+	case logTailScreen:
+		serviceName = "CloudWatch"
+		viewName = "Log Tail"
+	case logInsightsScreen:
+		serviceName = "CloudWatch"
+		viewName = "Logs Insights"
+	case portForwardsScreen:
+		serviceName = "SSM"
+		viewName = "Port Forwards"
+	case transfersScreen:
+		serviceName = "S3"
+		viewName = "Transfers"
+	case syncPlanScreen:
+		serviceName = "S3"
+		viewName = "Sync Plan"
 	}
 
 	leftSide.WriteString(labelStyle.Render("Service: ") + valueStyle.Render(serviceName) + "\n")
@@ -2664,6 +5916,7 @@ func (m model) renderK9sHeader() string {
 			keyHintKeyStyle.Render("<S>") + " " + keyHintActionStyle.Render("Stop"),
 			keyHintKeyStyle.Render("<R>") + " " + keyHintActionStyle.Render("Reboot"),
 			keyHintKeyStyle.Render("<t>") + " " + keyHintActionStyle.Render("Terminate"),
+			keyHintKeyStyle.Render("<P>") + " " + keyHintActionStyle.Render("Port Forward"),
 			keyHintKeyStyle.Render("<:>") + " " + keyHintActionStyle.Render("Command"),
 			keyHintKeyStyle.Render("</>") + " " + keyHintActionStyle.Render("Search"),
 		}
@@ -2673,6 +5926,9 @@ func (m model) renderK9sHeader() string {
 			keyHintKeyStyle.Render("<S>") + " " + keyHintActionStyle.Render("Stop"),
 			keyHintKeyStyle.Render("<R>") + " " + keyHintActionStyle.Render("Reboot"),
 			keyHintKeyStyle.Render("<t>") + " " + keyHintActionStyle.Render("Terminate"),
+			keyHintKeyStyle.Render("<P>") + " " + keyHintActionStyle.Render("Port Forward"),
+			keyHintKeyStyle.Render("<m/M>") + " " + keyHintActionStyle.Render("Metric"),
+			keyHintKeyStyle.Render("<w>") + " " + keyHintActionStyle.Render("Range"),
 			keyHintKeyStyle.Render("<esc>") + " " + keyHintActionStyle.Render("Back"),
 		}
 		if m.ec2SSMStatus != nil && m.ec2SSMStatus.Connected {
@@ -2681,6 +5937,7 @@ func (m model) renderK9sHeader() string {
 	case s3Screen:
 		keyHints = []string{
 			keyHintKeyStyle.Render("<enter>") + " " + keyHintActionStyle.Render("Browse"),
+			keyHintKeyStyle.Render("<space>") + " " + keyHintActionStyle.Render("Select"),
 			keyHintKeyStyle.Render("<D>") + " " + keyHintActionStyle.Render("Delete"),
 			keyHintKeyStyle.Render("<p>") + " " + keyHintActionStyle.Render("Policy"),
 			keyHintKeyStyle.Render("<v>") + " " + keyHintActionStyle.Render("Versioning"),
@@ -2693,6 +5950,8 @@ func (m model) renderK9sHeader() string {
 			keyHintKeyStyle.Render("<e>") + " " + keyHintActionStyle.Render("Edit"),
 			keyHintKeyStyle.Render("<d>") + " " + keyHintActionStyle.Render("Download"),
 			keyHintKeyStyle.Render("<D>") + " " + keyHintActionStyle.Render("Delete"),
+			keyHintKeyStyle.Render("<space>") + " " + keyHintActionStyle.Render("Select"),
+			keyHintKeyStyle.Render("<V>") + " " + keyHintActionStyle.Render("Range"),
 			keyHintKeyStyle.Render("<h>") + " " + keyHintActionStyle.Render("Up"),
 			keyHintKeyStyle.Render("<:>") + " " + keyHintActionStyle.Render("Command"),
 			keyHintKeyStyle.Render("</>") + " " + keyHintActionStyle.Render("Search"),
@@ -2702,6 +5961,16 @@ func (m model) renderK9sHeader() string {
 			keyHintKeyStyle.Render("<e>") + " " + keyHintActionStyle.Render("Edit"),
 			keyHintKeyStyle.Render("<d>") + " " + keyHintActionStyle.Render("Download"),
 			keyHintKeyStyle.Render("<p>") + " " + keyHintActionStyle.Render("Presigned URL"),
+			keyHintKeyStyle.Render("<]/[>") + " " + keyHintActionStyle.Render("Preview Page"),
+			keyHintKeyStyle.Render("<v>") + " " + keyHintActionStyle.Render("Versions"),
+			keyHintKeyStyle.Render("<esc>") + " " + keyHintActionStyle.Render("Back"),
+		}
+	case s3VersionsScreen:
+		keyHints = []string{
+			keyHintKeyStyle.Render("<enter>") + " " + keyHintActionStyle.Render("Diff"),
+			keyHintKeyStyle.Render("<space>") + " " + keyHintActionStyle.Render("Mark"),
+			keyHintKeyStyle.Render("<R>") + " " + keyHintActionStyle.Render("Restore"),
+			keyHintKeyStyle.Render("<D>") + " " + keyHintActionStyle.Render("Remove Marker"),
 			keyHintKeyStyle.Render("<esc>") + " " + keyHintActionStyle.Render("Back"),
 		}
 	case eksScreen:
@@ -2710,11 +5979,81 @@ func (m model) renderK9sHeader() string {
 			keyHintKeyStyle.Render("<K>") + " " + keyHintActionStyle.Render("Update Kubeconfig"),
 			keyHintKeyStyle.Render("<:>") + " " + keyHintActionStyle.Render("Command"),
 		}
-	case eksDetailsScreen:
+	case eksDetailsScreen:
+		keyHints = []string{
+			keyHintKeyStyle.Render("<K>") + " " + keyHintActionStyle.Render("Update Kubeconfig"),
+			keyHintKeyStyle.Render("<esc>") + " " + keyHintActionStyle.Render("Back"),
+		}
+	case alarmsScreen:
+		keyHints = []string{
+			keyHintKeyStyle.Render("<enter>") + " " + keyHintActionStyle.Render("Details"),
+			keyHintKeyStyle.Render("<A>") + " " + keyHintActionStyle.Render("Toggle Actions"),
+			keyHintKeyStyle.Render("<o>") + " " + keyHintActionStyle.Render("Open Resource"),
+			keyHintKeyStyle.Render("<r>") + " " + keyHintActionStyle.Render("Refresh"),
+			keyHintKeyStyle.Render("<:>") + " " + keyHintActionStyle.Render("Command"),
+			keyHintKeyStyle.Render("</>") + " " + keyHintActionStyle.Render("Search"),
+		}
+	case alarmDetailsScreen:
+		keyHints = []string{
+			keyHintKeyStyle.Render("<A>") + " " + keyHintActionStyle.Render("Toggle Actions"),
+			keyHintKeyStyle.Render("<T>") + " " + keyHintActionStyle.Render("Set State"),
+			keyHintKeyStyle.Render("<o>") + " " + keyHintActionStyle.Render("Open Resource"),
+			keyHintKeyStyle.Render("<esc>") + " " + keyHintActionStyle.Render("Back"),
+		}
+	case logGroupsScreen:
+		keyHints = []string{
+			keyHintKeyStyle.Render("<enter>") + " " + keyHintActionStyle.Render("Streams"),
+			keyHintKeyStyle.Render("<t>") + " " + keyHintActionStyle.Render("Tail"),
+			keyHintKeyStyle.Render("<I>") + " " + keyHintActionStyle.Render("Insights"),
+			keyHintKeyStyle.Render("<r>") + " " + keyHintActionStyle.Render("Refresh"),
+			keyHintKeyStyle.Render("</>") + " " + keyHintActionStyle.Render("Search"),
+		}
+	case logStreamsScreen:
+		keyHints = []string{
+			keyHintKeyStyle.Render("<enter>") + " " + keyHintActionStyle.Render("Tail Stream"),
+			keyHintKeyStyle.Render("<t>") + " " + keyHintActionStyle.Render("Tail Group"),
+			keyHintKeyStyle.Render("<I>") + " " + keyHintActionStyle.Render("Insights"),
+			keyHintKeyStyle.Render("<esc>") + " " + keyHintActionStyle.Render("Back"),
+		}
+	case logTailScreen:
+		keyHints = []string{
+			keyHintKeyStyle.Render("<p>") + " " + keyHintActionStyle.Render("Pause/Resume"),
+			keyHintKeyStyle.Render("<f>") + " " + keyHintActionStyle.Render("Filter"),
+			keyHintKeyStyle.Render("<G>") + " " + keyHintActionStyle.Render("Follow"),
+			keyHintKeyStyle.Render("<I>") + " " + keyHintActionStyle.Render("Insights"),
+			keyHintKeyStyle.Render("<esc>") + " " + keyHintActionStyle.Render("Back"),
+		}
+	case logInsightsScreen:
+		keyHints = []string{
+			keyHintKeyStyle.Render("<e>") + " " + keyHintActionStyle.Render("Edit Query"),
+			keyHintKeyStyle.Render("<enter>") + " " + keyHintActionStyle.Render("Run"),
+			keyHintKeyStyle.Render("<w>") + " " + keyHintActionStyle.Render("Window"),
+			keyHintKeyStyle.Render("<L>") + " " + keyHintActionStyle.Render("Saved Queries"),
+			keyHintKeyStyle.Render("<s>") + " " + keyHintActionStyle.Render("Save"),
+			keyHintKeyStyle.Render("<S>") + " " + keyHintActionStyle.Render("Stop"),
+		}
+	case portForwardsScreen:
 		keyHints = []string{
-			keyHintKeyStyle.Render("<K>") + " " + keyHintActionStyle.Render("Update Kubeconfig"),
+			keyHintKeyStyle.Render("<s>") + " " + keyHintActionStyle.Render("Restart"),
+			keyHintKeyStyle.Render("<S>") + " " + keyHintActionStyle.Render("Stop"),
+			keyHintKeyStyle.Render("<y>") + " " + keyHintActionStyle.Render("Duplicate"),
+			keyHintKeyStyle.Render("<D>") + " " + keyHintActionStyle.Render("Remove"),
 			keyHintKeyStyle.Render("<esc>") + " " + keyHintActionStyle.Render("Back"),
 		}
+	case transfersScreen:
+		keyHints = []string{
+			keyHintKeyStyle.Render("<p>") + " " + keyHintActionStyle.Render("Pause/Resume"),
+			keyHintKeyStyle.Render("<S>") + " " + keyHintActionStyle.Render("Cancel"),
+			keyHintKeyStyle.Render("<s>") + " " + keyHintActionStyle.Render("Retry"),
+			keyHintKeyStyle.Render("<D>") + " " + keyHintActionStyle.Render("Remove"),
+			keyHintKeyStyle.Render("<x>") + " " + keyHintActionStyle.Render("Clear Done"),
+			keyHintKeyStyle.Render("<+/->") + " " + keyHintActionStyle.Render("Concurrency"),
+		}
+	case syncPlanScreen:
+		keyHints = []string{
+			keyHintKeyStyle.Render("<enter>") + " " + keyHintActionStyle.Render("Run Sync"),
+			keyHintKeyStyle.Render("<esc>") + " " + keyHintActionStyle.Render("Cancel"),
+		}
 	}
 
 	// ASCII art logo (simplified version for lazyaws)
@@ -2832,10 +6171,17 @@ func (m model) renderK9sBreadcrumb() string {
 	var breadcrumbs []string
 
 	switch m.currentScreen {
-	case ec2Screen:
+	case ec2Screen, ec2DetailsScreen:
 		breadcrumbs = []string{"<ec2>", "<instances>"}
-	case ec2DetailsScreen:
-		breadcrumbs = []string{"<ec2>", "<instances>", "<details>"}
+		if m.ec2View != "" {
+			breadcrumbs = append(breadcrumbs, "<view: "+m.ec2View+">")
+		}
+		if !m.ec2Filter.Empty() {
+			breadcrumbs = append(breadcrumbs, "<filter: "+m.ec2Filter.String()+">")
+		}
+		if m.currentScreen == ec2DetailsScreen {
+			breadcrumbs = append(breadcrumbs, "<details>")
+		}
 	case s3Screen:
 		breadcrumbs = []string{"<s3>", "<buckets>"}
 	case s3BrowseScreen:
@@ -2844,9 +6190,14 @@ func (m model) renderK9sBreadcrumb() string {
 			if m.s3CurrentPrefix != "" {
 				breadcrumbs = append(breadcrumbs, "<"+m.s3CurrentPrefix+">")
 			}
+			if m.s3Search != "" {
+				breadcrumbs = append(breadcrumbs, "<find: "+m.s3Search+">")
+			}
 		}
 	case s3ObjectDetailsScreen:
 		breadcrumbs = []string{"<s3>", "<object>", "<details>"}
+	case s3VersionsScreen:
+		breadcrumbs = []string{"<s3>", "<" + m.s3CurrentBucket + ">", "<" + m.s3VersionsKey + ">", "<versions>"}
 	case eksScreen:
 		breadcrumbs = []string{"<eks>", "<clusters>"}
 	case eksDetailsScreen:
@@ -2855,6 +6206,32 @@ func (m model) renderK9sBreadcrumb() string {
 		} else {
 			breadcrumbs = []string{"<eks>", "<clusters>", "<details>"}
 		}
+	case alarmsScreen:
+		breadcrumbs = []string{"<cloudwatch>", "<alarms>"}
+	case alarmDetailsScreen:
+		if m.alarmDetails != nil {
+			breadcrumbs = []string{"<cloudwatch>", "<alarms>", "<" + m.alarmDetails.Name + ">"}
+		} else {
+			breadcrumbs = []string{"<cloudwatch>", "<alarms>", "<details>"}
+		}
+	case logGroupsScreen:
+		breadcrumbs = []string{"<cloudwatch>", "<logs>"}
+	case logStreamsScreen:
+		breadcrumbs = []string{"<cloudwatch>", "<logs>", "<" + m.logGroupName + ">"}
+	case logTailScreen:
+		if m.logTail != nil {
+			breadcrumbs = []string{"<cloudwatch>", "<logs>", "<" + m.logTail.GroupName + ">", "<tail>"}
+		} else {
+			breadcrumbs = []string{"<cloudwatch>", "<logs>", "<tail>"}
+		}
+	case logInsightsScreen:
+		breadcrumbs = []string{"<cloudwatch>", "<logs>", "<insights>"}
+	case portForwardsScreen:
+		breadcrumbs = []string{"<ssm>", "<port-forwards>"}
+	case transfersScreen:
+		breadcrumbs = []string{"<s3>", "<transfers>"}
+	case syncPlanScreen:
+		breadcrumbs = []string{"<s3>", "<sync>"}
 	}
 
 	var result strings.Builder
@@ -2869,11 +6246,11 @@ func (m model) renderK9sBreadcrumb() string {
 }
 
 func (m model) renderAuthMethodSelection() string {
-	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("51"))
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(theme.Accent))
 	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("220"))
-	instructionStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
-	selectedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("0")).Background(lipgloss.Color("51")).Bold(true)
-	normalStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("255"))
+	instructionStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted))
+	selectedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.SelectedText)).Background(lipgloss.Color(theme.Accent)).Bold(true)
+	normalStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Header))
 
 	var content strings.Builder
 
@@ -2917,10 +6294,10 @@ func (m model) renderAuthMethodSelection() string {
 }
 
 func (m model) renderProfileConfig() string {
-	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("51"))
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(theme.Accent))
 	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("220"))
-	instructionStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
-	errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	instructionStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted))
+	errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Error))
 
 	var content strings.Builder
 
@@ -2946,10 +6323,10 @@ func (m model) renderProfileConfig() string {
 }
 
 func (m model) renderSSOConfig() string {
-	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("51"))
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(theme.Accent))
 	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("220"))
-	instructionStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
-	errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	instructionStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted))
+	errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Error))
 
 	var content strings.Builder
 
@@ -2979,15 +6356,15 @@ func (m model) renderSSOConfig() string {
 func (m model) renderAccountSelection() string {
 	title := lipgloss.NewStyle().Bold(true).Render("AWS Account Selection")
 	if m.vimState.LastSearch != "" {
-		title += lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Render(fmt.Sprintf(" [search: %s]", m.vimState.LastSearch))
+		title += lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render(fmt.Sprintf(" [search: %s]", m.vimState.LastSearch))
 	}
 
 	if m.loading {
-		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Render("Loading accounts...")
+		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render("Loading accounts...")
 	}
 
 	if m.err != nil {
-		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Error))
 		return title + "\n\n" + errorStyle.Render(fmt.Sprintf("Error: %v", m.err))
 	}
 
@@ -3002,9 +6379,9 @@ func (m model) renderAccountSelection() string {
 
 	if len(accounts) == 0 {
 		if m.vimState.LastSearch != "" {
-			return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("No accounts match your search")
+			return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render("No accounts match your search")
 		}
-		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("No accounts found")
+		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render("No accounts found")
 	}
 
 	// Ensure selected item is visible and get viewport range
@@ -3015,10 +6392,10 @@ func (m model) renderAccountSelection() string {
 	var content strings.Builder
 
 	// Title with count - k9s style
-	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("51")).Bold(true) // Cyan
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Accent)).Bold(true) // Cyan
 	searchInfo := ""
 	if m.vimState.LastSearch != "" {
-		searchInfo = lipgloss.NewStyle().Foreground(lipgloss.Color("201")).Render("(" + m.vimState.LastSearch + ")")
+		searchInfo = lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Search)).Render("(" + m.vimState.LastSearch + ")")
 	}
 	tableTitle := fmt.Sprintf("AWS-Accounts%s[%d]", searchInfo, len(accounts))
 	titleText := titleStyle.Render(tableTitle)
@@ -3036,7 +6413,7 @@ func (m model) renderAccountSelection() string {
 	content.WriteString(" " + strings.Repeat("─", dashesWidth) + "\n")
 
 	// Table header - k9s uses uppercase and symbols
-	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("255")).Underline(true)
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(theme.Header)).Underline(true)
 	content.WriteString(headerStyle.Render(fmt.Sprintf("%-30s %-20s %-35s %-30s",
 		"ACCOUNT NAME", "ACCOUNT ID", "ROLE", "EMAIL")) + "\n")
 
@@ -3058,13 +6435,13 @@ func (m model) renderAccountSelection() string {
 				row += " "
 			}
 			selectedStyle := lipgloss.NewStyle().
-				Background(lipgloss.Color("51")).
-				Foreground(lipgloss.Color("0")).
+				Background(lipgloss.Color(theme.Accent)).
+				Foreground(lipgloss.Color(theme.SelectedText)).
 				Bold(true)
 			content.WriteString(selectedStyle.Render(row) + "\n")
 		} else {
 			// Normal row
-			normalStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("255"))
+			normalStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Header))
 			content.WriteString(normalStyle.Render(row) + "\n")
 		}
 	}
@@ -3072,7 +6449,7 @@ func (m model) renderAccountSelection() string {
 	// Add scroll indicators
 	if start > 0 || end < len(accounts) {
 		scrollInfo := fmt.Sprintf("\n[Showing %d-%d of %d | Use j/k or ↓/↑ to navigate]", start+1, end, len(accounts))
-		content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(scrollInfo))
+		content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render(scrollInfo))
 	}
 
 	return content.String()
@@ -3082,7 +6459,7 @@ func (m model) renderRegionSelection() string {
 	title := lipgloss.NewStyle().Bold(true).Render("AWS Region Selection")
 
 	if m.config == nil || len(m.config.Regions) == 0 {
-		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("No regions configured")
+		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render("No regions configured")
 	}
 
 	regions := m.config.Regions
@@ -3095,7 +6472,7 @@ func (m model) renderRegionSelection() string {
 	var content strings.Builder
 
 	// Title with count - k9s style
-	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("51")).Bold(true) // Cyan
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Accent)).Bold(true) // Cyan
 	tableTitle := fmt.Sprintf("AWS-Regions[%d]", len(regions))
 	titleText := titleStyle.Render(tableTitle)
 
@@ -3112,7 +6489,7 @@ func (m model) renderRegionSelection() string {
 	content.WriteString(" " + strings.Repeat("─", dashesWidth) + "\n")
 
 	// Table header - k9s uses uppercase and symbols
-	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("255")).Underline(true)
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(theme.Header)).Underline(true)
 	content.WriteString(headerStyle.Render(fmt.Sprintf("%-30s %-40s",
 		"REGION", "STATUS")) + "\n")
 
@@ -3138,13 +6515,13 @@ func (m model) renderRegionSelection() string {
 				row += " "
 			}
 			selectedStyle := lipgloss.NewStyle().
-				Background(lipgloss.Color("51")).
-				Foreground(lipgloss.Color("0")).
+				Background(lipgloss.Color(theme.Accent)).
+				Foreground(lipgloss.Color(theme.SelectedText)).
 				Bold(true)
 			content.WriteString(selectedStyle.Render(row) + "\n")
 		} else {
 			// Normal row
-			normalStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("255"))
+			normalStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Header))
 			content.WriteString(normalStyle.Render(row) + "\n")
 		}
 	}
@@ -3152,10 +6529,10 @@ func (m model) renderRegionSelection() string {
 	// Add scroll indicators
 	if start > 0 || end < len(regions) {
 		scrollInfo := fmt.Sprintf("\n[Showing %d-%d of %d | Use j/k or ↓/↑ to navigate | Enter to select | ESC to cancel]", start+1, end, len(regions))
-		content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(scrollInfo))
+		content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render(scrollInfo))
 	} else {
 		helpText := "\n[Use j/k or ↓/↑ to navigate | Enter to select | ESC to cancel]"
-		content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(helpText))
+		content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render(helpText))
 	}
 
 	return content.String()
@@ -3164,18 +6541,22 @@ func (m model) renderRegionSelection() string {
 func (m model) renderEC2() string {
 	title := lipgloss.NewStyle().Bold(true).Render("EC2 Instances")
 	if m.filter != "" {
-		title += lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(fmt.Sprintf(" (filtered by: %s)", m.filter))
+		title += lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render(fmt.Sprintf(" (filtered by: %s)", m.filter))
 	}
 	if m.vimState.LastSearch != "" {
-		title += lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Render(fmt.Sprintf(" [search: %s]", m.vimState.LastSearch))
+		title += lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render(fmt.Sprintf(" [search: %s]", m.vimState.LastSearch))
 	}
 
 	if m.loading {
-		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Render("Loading instances...")
+		loadingText := "Loading instances..."
+		if m.ec2LoadedCount > 0 {
+			loadingText = fmt.Sprintf("Loading instances... (%d loaded)", m.ec2LoadedCount)
+		}
+		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render(loadingText)
 	}
 
 	if m.err != nil {
-		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Error))
 		return title + "\n\n" + errorStyle.Render(fmt.Sprintf("Error: %v", m.err))
 	}
 
@@ -3211,7 +6592,11 @@ func (m model) renderEC2() string {
 	}
 
 	if len(filteredInstances) == 0 {
-		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("No instances found")
+		empty := "No instances found"
+		if !m.ec2Filter.Empty() {
+			empty = "No instances match the filter (:filter to clear it)"
+		}
+		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render(empty)
 	}
 
 	// Ensure selected item is visible and get viewport range
@@ -3222,10 +6607,10 @@ func (m model) renderEC2() string {
 	var content strings.Builder
 
 	// Title with count - k9s style
-	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("51")).Bold(true) // Cyan
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Accent)).Bold(true) // Cyan
 	searchInfo := ""
 	if m.vimState.LastSearch != "" {
-		searchInfo = lipgloss.NewStyle().Foreground(lipgloss.Color("201")).Render("(" + m.vimState.LastSearch + ")")
+		searchInfo = lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Search)).Render("(" + m.vimState.LastSearch + ")")
 	}
 	tableTitle := fmt.Sprintf("EC2-Instances%s[%d]", searchInfo, len(filteredInstances))
 	titleText := titleStyle.Render(tableTitle)
@@ -3244,7 +6629,7 @@ func (m model) renderEC2() string {
 	content.WriteString(" " + strings.Repeat("─", dashesWidth) + "\n")
 
 	// Table header - k9s uses uppercase and symbols
-	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("255")).Underline(true)
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(theme.Header)).Underline(true)
 	content.WriteString(headerStyle.Render(fmt.Sprintf("%-1s  %-20s %-30s %-15s %-15s %-15s",
 		"✓", "INSTANCE ID", "NAME", "STATE", "TYPE", "IP")) + "\n")
 
@@ -3292,8 +6677,7 @@ func (m model) renderEC2() string {
 				row += " "
 			}
 			// Use ANSI codes directly to avoid lipgloss adding extra width
-			// \x1b[48;5;51m = cyan background, \x1b[38;5;0m = black foreground, \x1b[1m = bold, \x1b[0m = reset
-			row = "\x1b[48;5;51m\x1b[38;5;0m\x1b[1m" + row + "\x1b[0m"
+			row = selectedRowPrefix() + row + "\x1b[0m"
 		}
 
 		content.WriteString(row + "\n")
@@ -3306,10 +6690,10 @@ func (m model) renderEC2() string {
 	content.WriteString(scrollInfo)
 
 	if selectedCount > 0 {
-		content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Render(fmt.Sprintf(" | Selected: %d", selectedCount)))
+		content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Success)).Render(fmt.Sprintf(" | Selected: %d", selectedCount)))
 	}
 	if m.autoRefresh {
-		content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Render(" | Auto-refresh: ON"))
+		content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render(" | Auto-refresh: ON"))
 	}
 
 	return content.String()
@@ -3319,16 +6703,16 @@ func (m model) renderEC2Details() string {
 	title := lipgloss.NewStyle().Bold(true).Render("EC2 Instance Details")
 
 	if m.loading {
-		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Render("Loading instance details...")
+		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render("Loading instance details...")
 	}
 
 	if m.err != nil {
-		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Error))
 		return title + "\n\n" + errorStyle.Render(fmt.Sprintf("Error: %v", m.err))
 	}
 
 	if m.ec2InstanceDetails == nil {
-		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("No instance details available")
+		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render("No instance details available")
 	}
 
 	details := m.ec2InstanceDetails
@@ -3337,7 +6721,7 @@ func (m model) renderEC2Details() string {
 
 	// Section styling
 	sectionStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("6"))
-	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted))
 	valueStyle := lipgloss.NewStyle()
 
 	// Basic Information
@@ -3452,18 +6836,18 @@ func (m model) renderEC2Details() string {
 		content.WriteString(sectionStyle.Render("Health Status") + "\n")
 
 		// System status
-		systemStatusColor := lipgloss.Color("1") // Red by default
+		systemStatusColor := lipgloss.Color(theme.Error) // Red by default
 		if m.ec2InstanceStatus.SystemStatusOk {
-			systemStatusColor = lipgloss.Color("2") // Green
+			systemStatusColor = lipgloss.Color(theme.Success) // Green
 		}
 		systemStatusStyle := lipgloss.NewStyle().Foreground(systemStatusColor)
 		content.WriteString(labelStyle.Render("  System Status:   ") +
 			systemStatusStyle.Render(m.ec2InstanceStatus.SystemStatus) + "\n")
 
 		// Instance status
-		instanceStatusColor := lipgloss.Color("1") // Red by default
+		instanceStatusColor := lipgloss.Color(theme.Error) // Red by default
 		if m.ec2InstanceStatus.InstanceStatusOk {
-			instanceStatusColor = lipgloss.Color("2") // Green
+			instanceStatusColor = lipgloss.Color(theme.Success) // Green
 		}
 		instanceStatusStyle := lipgloss.NewStyle().Foreground(instanceStatusColor)
 		content.WriteString(labelStyle.Render("  Instance Status: ") +
@@ -3485,20 +6869,11 @@ func (m model) renderEC2Details() string {
 	}
 
 	// CloudWatch Metrics
-	if m.ec2InstanceMetrics != nil {
-		content.WriteString(sectionStyle.Render("CloudWatch Metrics (Last 5 Minutes)") + "\n")
-		content.WriteString(labelStyle.Render("  CPU Utilization: ") +
-			valueStyle.Render(fmt.Sprintf("%.2f%%", m.ec2InstanceMetrics.CPUUtilization)) + "\n")
-		content.WriteString(labelStyle.Render("  Network In:      ") +
-			valueStyle.Render(fmt.Sprintf("%.2f MB", m.ec2InstanceMetrics.NetworkIn/1024/1024)) + "\n")
-		content.WriteString(labelStyle.Render("  Network Out:     ") +
-			valueStyle.Render(fmt.Sprintf("%.2f MB", m.ec2InstanceMetrics.NetworkOut/1024/1024)) + "\n")
-		if m.ec2InstanceMetrics.DiskReadBytes > 0 || m.ec2InstanceMetrics.DiskWriteBytes > 0 {
-			content.WriteString(labelStyle.Render("  Disk Read:       ") +
-				valueStyle.Render(fmt.Sprintf("%.2f MB", m.ec2InstanceMetrics.DiskReadBytes/1024/1024)) + "\n")
-			content.WriteString(labelStyle.Render("  Disk Write:      ") +
-				valueStyle.Render(fmt.Sprintf("%.2f MB", m.ec2InstanceMetrics.DiskWriteBytes/1024/1024)) + "\n")
-		}
+	if m.ec2MetricHistory != nil {
+		history := m.ec2MetricHistory
+		content.WriteString(sectionStyle.Render(fmt.Sprintf("CloudWatch Metrics (Last %s, %s period)",
+			history.Range.Label, time.Duration(history.Period)*time.Second)) + "\n")
+		content.WriteString(m.renderMetricCharts(history))
 		content.WriteString("\n")
 	}
 
@@ -3506,7 +6881,7 @@ func (m model) renderEC2Details() string {
 	if m.ec2SSMStatus != nil {
 		content.WriteString(sectionStyle.Render("Systems Manager (SSM)") + "\n")
 		if m.ec2SSMStatus.Connected {
-			connectStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+			connectStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Success))
 			content.WriteString(labelStyle.Render("  Status:          ") +
 				connectStyle.Render("Connected") + "\n")
 			content.WriteString(labelStyle.Render("  Ping Status:     ") +
@@ -3528,7 +6903,7 @@ func (m model) renderEC2Details() string {
 			content.WriteString(labelStyle.Render("  ") +
 				hintStyle.Render("Press 'C' to open SSM session in new terminal") + "\n")
 		} else {
-			disconnectStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+			disconnectStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Error))
 			content.WriteString(labelStyle.Render("  Status:          ") +
 				disconnectStyle.Render("Not Connected") + "\n")
 			content.WriteString(labelStyle.Render("  Note:            ") +
@@ -3561,18 +6936,158 @@ func (m model) renderEC2Details() string {
 	return m.renderWithViewport(content.String())
 }
 
+// renderMetricCharts renders a sparkline row per metric and a braille chart
+// of the selected metric with min/avg/max/p99 overlays
+func (m model) renderMetricCharts(history *aws.InstanceMetricHistory) string {
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted))
+	selectedStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(theme.Accent))
+	sparkStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Accent))
+
+	const sparkWidth = 40
+	const chartWidth = 60
+	const chartHeight = 8
+
+	var content strings.Builder
+	for i, metric := range history.Metrics {
+		averages := metric.Stats[aws.MetricStatAverage]
+		lo, hi := chart.Bounds(averages)
+		latest := "-"
+		for j := len(averages) - 1; j >= 0; j-- {
+			if !math.IsNaN(averages[j]) {
+				latest = formatMetricValue(metric.Metric.Unit, averages[j])
+				break
+			}
+		}
+
+		marker, label := "  ", labelStyle.Render(fmt.Sprintf("%-20s", metric.Metric.Label))
+		if i == m.ec2MetricIndex {
+			marker, label = selectedStyle.Render("▶ "), selectedStyle.Render(fmt.Sprintf("%-20s", metric.Metric.Label))
+		}
+		spark := strings.Repeat(" ", sparkWidth)
+		if len(averages) > 0 {
+			spark = sparkStyle.Render(chart.Sparkline(averages, sparkWidth, math.Min(0, lo), hi))
+		}
+		content.WriteString(marker + label + " " + spark + " " + latest + "\n")
+	}
+
+	if m.ec2MetricIndex >= len(history.Metrics) {
+		return content.String()
+	}
+	selected := history.Metrics[m.ec2MetricIndex]
+	if len(selected.Timestamps) == 0 {
+		content.WriteString("\n" + labelStyle.Render(fmt.Sprintf("  No %s datapoints in the last %s", selected.Metric.Label, history.Range.Label)) + "\n")
+		return content.String()
+	}
+
+	// Overlays are drawn in order, so the average line stays on top
+	overlays := []struct {
+		stat  string
+		label string
+		color string
+	}{
+		{aws.MetricStatMinimum, "min", theme.Muted},
+		{aws.MetricStatMaximum, "max", theme.Warning},
+		{aws.MetricStatP99, "p99", theme.Error},
+		{aws.MetricStatAverage, "avg", theme.Accent},
+	}
+
+	var series []chart.Series
+	var all [][]float64
+	var legend []string
+	for _, overlay := range overlays {
+		values := selected.Stats[overlay.stat]
+		style := lipgloss.NewStyle().Foreground(lipgloss.Color(overlay.color))
+		series = append(series, chart.Series{Values: values, Style: style})
+		all = append(all, values)
+
+		// Summarize each overlay over the whole range
+		summary := "-"
+		if lo, hi := chart.Bounds(values); len(values) > 0 {
+			switch overlay.stat {
+			case aws.MetricStatMinimum:
+				summary = formatMetricValue(selected.Metric.Unit, lo)
+			case aws.MetricStatAverage:
+				summary = formatMetricValue(selected.Metric.Unit, meanOf(values))
+			default:
+				summary = formatMetricValue(selected.Metric.Unit, hi)
+			}
+		}
+		legend = append(legend, style.Render("━ "+overlay.label+" "+summary))
+	}
+
+	lo, hi := chart.Bounds(all...)
+	lo = math.Min(0, lo)
+	if selected.Metric.Unit == "Percent" && hi < 100 {
+		hi = 100
+	}
+
+	// Y axis labels on the top and bottom rows
+	axisWidth := 10
+	content.WriteString("\n")
+	for row, line := range chart.Braille(series, chartWidth, chartHeight, lo, hi) {
+		axis := ""
+		switch row {
+		case 0:
+			axis = formatMetricValue(selected.Metric.Unit, hi)
+		case chartHeight - 1:
+			axis = formatMetricValue(selected.Metric.Unit, lo)
+		}
+		content.WriteString(labelStyle.Render(fmt.Sprintf("  %*s ┤", axisWidth, axis)) + line + "\n")
+	}
+
+	// Time axis: start and end of the range
+	start := history.Start.Local().Format("Jan 02 15:04")
+	end := history.End.Local().Format("Jan 02 15:04")
+	gap := chartWidth - len(start) - len(end)
+	if gap < 1 {
+		gap = 1
+	}
+	content.WriteString(labelStyle.Render(fmt.Sprintf("  %*s  %s%s%s", axisWidth, "", start, strings.Repeat(" ", gap), end)) + "\n")
+	content.WriteString("  " + strings.Join(legend, "  ") + "\n")
+	content.WriteString(labelStyle.Render("  m/M: switch metric  w: range (1h/6h/24h/7d)") + "\n")
+
+	return content.String()
+}
+
+// formatMetricValue formats a metric value for its unit
+func formatMetricValue(unit string, value float64) string {
+	switch unit {
+	case "Percent":
+		return fmt.Sprintf("%.1f%%", value)
+	case "Bytes":
+		return formatBytes(int64(value))
+	default:
+		return fmt.Sprintf("%.0f", value)
+	}
+}
+
+// meanOf averages the non-NaN values
+func meanOf(values []float64) float64 {
+	sum, count := 0.0, 0
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			sum += v
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return sum / float64(count)
+}
+
 func (m model) renderS3() string {
 	title := lipgloss.NewStyle().Bold(true).Render("S3 Buckets")
 	if m.vimState.LastSearch != "" {
-		title += lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Render(fmt.Sprintf(" [search: %s]", m.vimState.LastSearch))
+		title += lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render(fmt.Sprintf(" [search: %s]", m.vimState.LastSearch))
 	}
 
 	if m.loading {
-		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Render("Loading buckets...")
+		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render("Loading buckets...")
 	}
 
 	if m.err != nil {
-		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Error))
 		return title + "\n\n" + errorStyle.Render(fmt.Sprintf("Error: %v", m.err))
 	}
 
@@ -3587,9 +7102,9 @@ func (m model) renderS3() string {
 
 	if len(buckets) == 0 {
 		if m.vimState.LastSearch != "" {
-			return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("No buckets match your search")
+			return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render("No buckets match your search")
 		}
-		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("No buckets found")
+		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render("No buckets found")
 	}
 
 	// Ensure selected item is visible and get viewport range
@@ -3600,10 +7115,10 @@ func (m model) renderS3() string {
 	var content strings.Builder
 
 	// Title with count - k9s style
-	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("51")).Bold(true) // Cyan
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Accent)).Bold(true) // Cyan
 	searchInfo := ""
 	if m.vimState.LastSearch != "" {
-		searchInfo = lipgloss.NewStyle().Foreground(lipgloss.Color("201")).Render("(" + m.vimState.LastSearch + ")")
+		searchInfo = lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Search)).Render("(" + m.vimState.LastSearch + ")")
 	}
 	tableTitle := fmt.Sprintf("S3-Buckets%s[%d]", searchInfo, len(buckets))
 	titleText := titleStyle.Render(tableTitle)
@@ -3621,9 +7136,9 @@ func (m model) renderS3() string {
 	content.WriteString(" " + strings.Repeat("─", dashesWidth) + "\n")
 
 	// Table header
-	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("255")).Underline(true)
-	content.WriteString(headerStyle.Render(fmt.Sprintf("%-40s %-25s %-20s",
-		"BUCKET NAME", "CREATION DATE", "REGION")) + "\n")
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(theme.Header)).Underline(true)
+	content.WriteString(headerStyle.Render(fmt.Sprintf("%-1s  %-40s %-25s %-20s",
+		"✓", "BUCKET NAME", "CREATION DATE", "REGION")) + "\n")
 
 	// Build table rows (only visible items)
 	for i := start; i < end; i++ {
@@ -3639,7 +7154,8 @@ func (m model) renderS3() string {
 		}
 
 		// Highlight selected row
-		row := fmt.Sprintf("%-40s %-25s %-20s",
+		row := fmt.Sprintf("%-1s  %-40s %-25s %-20s",
+			m.s3RowMark(i),
 			truncate(bucket.Name, 40),
 			creationDate,
 			region,
@@ -3649,7 +7165,7 @@ func (m model) renderS3() string {
 			// Highlight the selected row - k9s style with cyan background
 			// Use ANSI codes directly to avoid lipgloss adding extra width
 			// \x1b[K clears to end of line with background color
-			row = "\x1b[48;5;51m\x1b[38;5;0m\x1b[1m" + row + "\x1b[K\x1b[0m"
+			row = selectedRowPrefix() + row + "\x1b[K\x1b[0m"
 		}
 
 		content.WriteString(row + "\n")
@@ -3657,15 +7173,41 @@ func (m model) renderS3() string {
 
 	// Show scroll position and total
 	content.WriteString(fmt.Sprintf("\nShowing %d-%d of %d buckets", start+1, end, len(buckets)))
+	content.WriteString(m.renderS3SelectionInfo())
 
 	return content.String()
 }
 
+// s3RowMark fills the selection column of an S3 row: a check mark when
+// the row is selected and a dot inside a range selection in progress
+func (m model) s3RowMark(i int) string {
+	if m.s3RowSelected(i) {
+		return "✓"
+	}
+	if m.inS3Range(i) {
+		return "•"
+	}
+	return " "
+}
+
+// renderS3SelectionInfo shows how many rows are selected after the scroll
+// position
+func (m model) renderS3SelectionInfo() string {
+	var info string
+	if count := m.s3SelectedCount(); count > 0 {
+		info += lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Success)).Render(fmt.Sprintf(" | Selected: %d", count))
+	}
+	if m.s3RangeActive {
+		info += lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render(fmt.Sprintf(" | Range from row %d", m.s3RangeAnchor+1))
+	}
+	return info
+}
+
 func (m model) renderS3Browse() string {
 	// Build breadcrumb
 	breadcrumbStyle := lipgloss.NewStyle().Bold(true)
 	bucketStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
-	separatorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	separatorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted))
 
 	var breadcrumbs strings.Builder
 	breadcrumbs.WriteString(bucketStyle.Render(m.s3CurrentBucket))
@@ -3681,16 +7223,23 @@ func (m model) renderS3Browse() string {
 	}
 
 	title := breadcrumbStyle.Render("S3 Browser: ") + breadcrumbs.String()
+	if m.s3Search != "" {
+		title += lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Search)).Render(fmt.Sprintf(" [find: %s]", m.s3Search))
+	}
 	if m.vimState.LastSearch != "" {
-		title += lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Render(fmt.Sprintf(" [search: %s]", m.vimState.LastSearch))
+		title += lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render(fmt.Sprintf(" [search: %s]", m.vimState.LastSearch))
 	}
 
+	if m.s3SearchCancel != nil {
+		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render(
+			fmt.Sprintf("Searching... %d objects scanned, %d matches (ESC to cancel)", m.s3SearchScanned, m.s3SearchMatched))
+	}
 	if m.loading {
-		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Render("Loading objects...")
+		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render("Loading objects...")
 	}
 
 	if m.err != nil {
-		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Error))
 		return title + "\n\n" + errorStyle.Render(fmt.Sprintf("Error: %v", m.err))
 	}
 
@@ -3704,10 +7253,10 @@ func (m model) renderS3Browse() string {
 	}
 
 	if len(objects) == 0 {
-		if m.vimState.LastSearch != "" {
-			return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("No objects match your search")
+		if m.vimState.LastSearch != "" || m.s3Search != "" {
+			return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render("No objects match your search")
 		}
-		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("No objects found (empty folder)")
+		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render("No objects found (empty folder)")
 	}
 
 	// Ensure selected item is visible and get viewport range
@@ -3718,10 +7267,10 @@ func (m model) renderS3Browse() string {
 	var content strings.Builder
 
 	// Title with count - k9s style
-	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("51")).Bold(true) // Cyan
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Accent)).Bold(true) // Cyan
 	searchInfo := ""
 	if m.vimState.LastSearch != "" {
-		searchInfo = lipgloss.NewStyle().Foreground(lipgloss.Color("201")).Render("(" + m.vimState.LastSearch + ")")
+		searchInfo = lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Search)).Render("(" + m.vimState.LastSearch + ")")
 	}
 
 	// Breadcrumb for location
@@ -3730,7 +7279,11 @@ func (m model) renderS3Browse() string {
 		bucketPath += "/" + strings.TrimSuffix(m.s3CurrentPrefix, "/")
 	}
 
-	tableTitle := fmt.Sprintf("S3-Objects(%s)%s[%d]", bucketPath, searchInfo, len(objects))
+	listing := "S3-Objects"
+	if m.s3Search != "" {
+		listing = "S3-Find"
+	}
+	tableTitle := fmt.Sprintf("%s(%s)%s[%d]", listing, bucketPath, searchInfo, len(objects))
 	titleText := titleStyle.Render(tableTitle)
 
 	// Center the title with dashes on both sides
@@ -3746,9 +7299,9 @@ func (m model) renderS3Browse() string {
 	content.WriteString(" " + strings.Repeat("─", dashesWidth) + "\n")
 
 	// Table header
-	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("255")).Underline(true)
-	content.WriteString(headerStyle.Render(fmt.Sprintf("%-6s %-50s %-15s %-25s %-20s",
-		"TYPE", "NAME", "SIZE", "LAST MODIFIED", "STORAGE CLASS")) + "\n")
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(theme.Header)).Underline(true)
+	content.WriteString(headerStyle.Render(fmt.Sprintf("%-1s  %-6s %-50s %-15s %-25s %-20s %s",
+		"✓", "TYPE", "NAME", "SIZE", "LAST MODIFIED", "STORAGE CLASS", "RESTORE")) + "\n")
 
 	// Build table rows (only visible items)
 	for i := start; i < end; i++ {
@@ -3786,7 +7339,8 @@ func (m model) renderS3Browse() string {
 		}
 
 		// Highlight selected row
-		row := fmt.Sprintf("%-6s %-50s %-15s %-25s %-20s",
+		row := fmt.Sprintf("%-1s  %-6s %-50s %-15s %-25s %-20s",
+			m.s3RowMark(i),
 			typeIcon,
 			truncate(name, 50),
 			size,
@@ -3794,11 +7348,19 @@ func (m model) renderS3Browse() string {
 			truncate(storageClass, 20),
 		)
 
+		// Archived objects show whether they can be read
+		restore := ""
+		if status, ok := m.s3RestoreStatus[obj.Key]; ok {
+			restore = restoreLabel(status)
+		}
+
 		if i == m.s3ObjectSelectedIndex {
 			// Highlight the selected row - k9s style with cyan background
 			// Use ANSI codes directly to avoid lipgloss adding extra width
 			// \x1b[K clears to end of line with background color
-			row = "\x1b[48;5;51m\x1b[38;5;0m\x1b[1m" + row + "\x1b[K\x1b[0m"
+			row = selectedRowPrefix() + row + " " + restore + "\x1b[K\x1b[0m"
+		} else if restore != "" {
+			row += " " + restoreStyle(m.s3RestoreStatus[obj.Key]).Render(restore)
 		}
 
 		content.WriteString(row + "\n")
@@ -3807,12 +7369,61 @@ func (m model) renderS3Browse() string {
 	// Pagination and scroll info
 	content.WriteString(fmt.Sprintf("\nShowing %d-%d of %d objects", start+1, end, len(objects)))
 	if m.s3IsTruncated {
-		content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Render(" (more available - press 'n' for next page)"))
+		content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render(" (more available - press 'n' for next page)"))
+	}
+	content.WriteString(m.renderS3SelectionInfo())
+
+	// Progress of a running delete, copy or move
+	if m.s3BatchTotal > 0 {
+		content.WriteString("\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render(
+			fmt.Sprintf("%s: %s %d/%d objects", m.s3BatchOp, progressBar(int64(m.s3BatchDone), int64(m.s3BatchTotal), 30), m.s3BatchDone, m.s3BatchTotal)))
+	}
+
+	// Objects the last operation failed on
+	if m.s3BatchResult != nil {
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Error))
+		failures := m.s3BatchResult.Failures
+		content.WriteString("\n\n" + errorStyle.Render(fmt.Sprintf("%s failed for %d of %d objects:", m.s3BatchOp, len(failures), m.s3BatchResult.Total)))
+		for i, failure := range failures {
+			if i == 5 {
+				content.WriteString("\n" + errorStyle.Render(fmt.Sprintf("  ... and %d more", len(failures)-i)))
+				break
+			}
+			content.WriteString("\n" + errorStyle.Render(fmt.Sprintf("  %s: %v", truncate(failure.Key, 50), failure.Err)))
+		}
 	}
 
 	return content.String()
 }
 
+// restoreLabel describes how far an archived object is from being readable
+func restoreLabel(status aws.ObjectRestoreStatus) string {
+	switch status.State {
+	case aws.RestoreInProgress:
+		return "restoring"
+	case aws.RestoreAvailable:
+		if status.Expiry.IsZero() {
+			return "restored"
+		}
+		return "restored until " + status.Expiry.Local().Format("2006-01-02")
+	}
+	if status.Archived {
+		return "archived"
+	}
+	return ""
+}
+
+// restoreStyle colours a restore label by whether the object is readable
+func restoreStyle(status aws.ObjectRestoreStatus) lipgloss.Style {
+	switch {
+	case status.Readable():
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Success))
+	case status.State == aws.RestoreInProgress:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning))
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted))
+}
+
 func formatBytes(bytes int64) string {
 	const unit = 1024
 	if bytes < unit {
@@ -3830,16 +7441,16 @@ func (m model) renderS3ObjectDetails() string {
 	title := lipgloss.NewStyle().Bold(true).Render("S3 Object Details")
 
 	if m.loading {
-		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Render("Loading object details...")
+		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render("Loading object details...")
 	}
 
 	if m.err != nil {
-		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Error))
 		return title + "\n\n" + errorStyle.Render(fmt.Sprintf("Error: %v", m.err))
 	}
 
 	if m.s3ObjectDetails == nil {
-		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("No object details available")
+		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render("No object details available")
 	}
 
 	details := m.s3ObjectDetails
@@ -3848,7 +7459,7 @@ func (m model) renderS3ObjectDetails() string {
 
 	// Section styling
 	sectionStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("6"))
-	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted))
 	valueStyle := lipgloss.NewStyle()
 
 	// Basic Information
@@ -3860,6 +7471,9 @@ func (m model) renderS3ObjectDetails() string {
 		content.WriteString(labelStyle.Render("  Last Modified:   ") + valueStyle.Render(details.LastModified) + "\n")
 	}
 	content.WriteString(labelStyle.Render("  Storage Class:   ") + valueStyle.Render(details.StorageClass) + "\n")
+	if details.Restore.Archived {
+		content.WriteString(labelStyle.Render("  Restore:         ") + restoreStyle(details.Restore).Render(restoreLabel(details.Restore)) + "\n")
+	}
 	if details.ContentType != "" {
 		content.WriteString(labelStyle.Render("  Content Type:    ") + valueStyle.Render(details.ContentType) + "\n")
 	}
@@ -3886,25 +7500,194 @@ func (m model) renderS3ObjectDetails() string {
 		content.WriteString("\n")
 	}
 
+	// Preview
+	content.WriteString(sectionStyle.Render("Preview") + "\n")
+	content.WriteString(m.renderS3Preview() + "\n")
+
 	// Actions hint
-	hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Italic(true)
-	content.WriteString(hintStyle.Render("Press 'd' to download this file") + "\n")
+	hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Italic(true)
+	content.WriteString(hintStyle.Render("Press ']' and '[' to page through the preview, 'd' to download this file, 'E' to edit its properties") + "\n")
 
 	return m.renderWithViewport(content.String())
 }
 
+// previewStyles colours the preview's highlighted spans
+func previewStyles() map[preview.Class]lipgloss.Style {
+	color := func(c string) lipgloss.Style { return lipgloss.NewStyle().Foreground(lipgloss.Color(c)) }
+	return map[preview.Class]lipgloss.Style{
+		preview.Plain:   lipgloss.NewStyle(),
+		preview.Key:     color(theme.Accent),
+		preview.String:  color(theme.Success),
+		preview.Number:  color(theme.Warning),
+		preview.Keyword: color(theme.Header).Bold(true),
+		preview.Comment: color(theme.Muted).Italic(true),
+		preview.Punct:   color(theme.Muted),
+		preview.Muted:   color(theme.Muted),
+	}
+}
+
+// renderS3Preview renders the current page of the object preview
+func (m model) renderS3Preview() string {
+	mutedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted))
+	page := m.s3PreviewPage
+	switch {
+	case m.s3PreviewErr != nil:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Error)).Render(fmt.Sprintf("  Preview failed: %v", m.s3PreviewErr))
+	case page == nil && m.s3PreviewLoading:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render("  Loading preview...")
+	case page == nil:
+		return ""
+	}
+
+	var content strings.Builder
+	position := fmt.Sprintf("%s %d-%d", page.Unit, page.Start, page.End)
+	if page.Total >= 0 {
+		position += fmt.Sprintf(" of %d", page.Total)
+	}
+	info := fmt.Sprintf("  %s, page %d, %s", page.Format, len(m.s3PreviewHistory)+1, position)
+	if m.s3PreviewLoading {
+		info += " (loading...)"
+	}
+	content.WriteString(mutedStyle.Render(info) + "\n")
+	if len(page.Lines) == 0 {
+		content.WriteString(mutedStyle.Render("  (empty)") + "\n")
+	}
+
+	styles := previewStyles()
+	for _, line := range page.Lines {
+		content.WriteString("  ")
+		for _, span := range line {
+			content.WriteString(styles[span.Class].Render(span.Text))
+		}
+		content.WriteString("\n")
+	}
+	return content.String()
+}
+
+// renderDiff colours a unified diff line by line
+func renderDiff(unified string) string {
+	var content strings.Builder
+	for _, line := range strings.Split(strings.TrimSuffix(unified, "\n"), "\n") {
+		style := lipgloss.NewStyle()
+		switch {
+		case strings.HasPrefix(line, "diff "), strings.HasPrefix(line, "--- "), strings.HasPrefix(line, "+++ "):
+			style = style.Bold(true).Foreground(lipgloss.Color(theme.Header))
+		case strings.HasPrefix(line, "@@"):
+			style = style.Foreground(lipgloss.Color(theme.Accent))
+		case strings.HasPrefix(line, "+"):
+			style = style.Foreground(lipgloss.Color(theme.Success))
+		case strings.HasPrefix(line, "-"):
+			style = style.Foreground(lipgloss.Color(theme.Error))
+		case strings.HasPrefix(line, "\\"):
+			style = style.Foreground(lipgloss.Color(theme.Muted))
+		}
+		content.WriteString(style.Render(line) + "\n")
+	}
+	return content.String()
+}
+
+func (m model) renderS3Versions() string {
+	var content strings.Builder
+	mutedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted))
+	location := m.s3CurrentBucket + "/" + m.s3VersionsKey
+
+	// A diff between two versions replaces the list until ESC
+	if m.s3VersionDiff != "" {
+		content.WriteString(lipgloss.NewStyle().Bold(true).Render("Version Diff: ") + location + "\n\n")
+		content.WriteString(renderDiff(m.s3VersionDiff))
+		content.WriteString("\n" + mutedStyle.Italic(true).Render("Press ESC to go back to the versions"))
+		return m.renderWithViewport(content.String())
+	}
+
+	title := lipgloss.NewStyle().Bold(true).Render("Object Versions: ") + location
+	if m.loading && len(m.s3Versions) == 0 {
+		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render("Loading versions...")
+	}
+	versions := m.s3Versions
+	if len(versions) == 0 {
+		return title + "\n\n" + mutedStyle.Render("No versions found")
+	}
+
+	m.ensureVisible(m.s3VersionIndex, len(versions))
+	start, end := m.getVisibleRange(len(versions))
+
+	// Title with count - k9s style
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Accent)).Bold(true)
+	tableTitle := fmt.Sprintf("Versions(%s)[%d]", truncate(location, 60), len(versions))
+	dashesWidth := (100 - len(tableTitle) - 2) / 2
+	if dashesWidth < 1 {
+		dashesWidth = 1
+	}
+	content.WriteString(strings.Repeat("─", dashesWidth) + " ")
+	content.WriteString(titleStyle.Render(tableTitle))
+	content.WriteString(" " + strings.Repeat("─", dashesWidth) + "\n")
+
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(theme.Header)).Underline(true)
+	content.WriteString(headerStyle.Render(fmt.Sprintf("%-1s  %-34s %-7s %-15s %-22s %-15s",
+		"✓", "VERSION ID", "LATEST", "SIZE", "LAST MODIFIED", "STORAGE CLASS")) + "\n")
+
+	for i := start; i < end; i++ {
+		version := versions[i]
+
+		mark := " "
+		if version.VersionId == m.s3VersionMark {
+			mark = "✓"
+		}
+		latest := ""
+		if version.IsLatest {
+			latest = "yes"
+		}
+		size, storageClass := formatBytes(version.Size), version.StorageClass
+		if version.IsDeleteMarker {
+			size, storageClass = "-", "(delete marker)"
+		}
+
+		row := fmt.Sprintf("%-1s  %-34s %-7s %-15s %-22s %-15s",
+			mark,
+			truncate(version.VersionId, 34),
+			latest,
+			size,
+			version.LastModified,
+			storageClass,
+		)
+
+		if i == m.s3VersionIndex {
+			row = selectedRowPrefix() + row + "\x1b[K\x1b[0m"
+		} else if version.IsDeleteMarker {
+			row = lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Error)).Render(row)
+		}
+
+		content.WriteString(row + "\n")
+	}
+
+	content.WriteString(fmt.Sprintf("\nShowing %d-%d of %d versions", start+1, end, len(versions)))
+	if m.s3VersionMark != "" {
+		content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Success)).Render(" | Diffing against " + m.s3VersionMark))
+	}
+	if versions[0].IsDeleteMarker {
+		content.WriteString("\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render(
+			"The object is deleted - press D on the latest delete marker to undelete it"))
+	}
+
+	return content.String()
+}
+
 func (m model) renderEKS() string {
 	title := lipgloss.NewStyle().Bold(true).Render("EKS Clusters")
 	if m.vimState.LastSearch != "" {
-		title += lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Render(fmt.Sprintf(" [search: %s]", m.vimState.LastSearch))
+		title += lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render(fmt.Sprintf(" [search: %s]", m.vimState.LastSearch))
 	}
 
 	if m.loading {
-		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Render("Loading clusters...")
+		loadingText := "Loading clusters..."
+		if m.eksLoadedCount > 0 {
+			loadingText = fmt.Sprintf("Loading clusters... (%d loaded)", m.eksLoadedCount)
+		}
+		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render(loadingText)
 	}
 
 	if m.err != nil {
-		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Error))
 		return title + "\n\n" + errorStyle.Render(fmt.Sprintf("Error: %v", m.err))
 	}
 
@@ -3919,9 +7702,9 @@ func (m model) renderEKS() string {
 
 	if len(clusters) == 0 {
 		if m.vimState.LastSearch != "" {
-			return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("No clusters match your search")
+			return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render("No clusters match your search")
 		}
-		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("No EKS clusters found")
+		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render("No EKS clusters found")
 	}
 
 	// Ensure selected item is visible and get viewport range
@@ -3932,10 +7715,10 @@ func (m model) renderEKS() string {
 	var content strings.Builder
 
 	// Title with count - k9s style
-	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("51")).Bold(true) // Cyan
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Accent)).Bold(true) // Cyan
 	searchInfo := ""
 	if m.vimState.LastSearch != "" {
-		searchInfo = lipgloss.NewStyle().Foreground(lipgloss.Color("201")).Render("(" + m.vimState.LastSearch + ")")
+		searchInfo = lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Search)).Render("(" + m.vimState.LastSearch + ")")
 	}
 	tableTitle := fmt.Sprintf("EKS-Clusters%s[%d]", searchInfo, len(clusters))
 	titleText := titleStyle.Render(tableTitle)
@@ -3953,7 +7736,7 @@ func (m model) renderEKS() string {
 	content.WriteString(" " + strings.Repeat("─", dashesWidth) + "\n")
 
 	// Table header - k9s uses uppercase and symbols
-	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("255")).Underline(true)
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(theme.Header)).Underline(true)
 	content.WriteString(headerStyle.Render(fmt.Sprintf("%-30s %-15s %-15s %-10s %-30s",
 		"CLUSTER NAME", "VERSION", "STATUS", "NODES", "REGION")) + "\n")
 
@@ -3961,12 +7744,18 @@ func (m model) renderEKS() string {
 	for i := start; i < end; i++ {
 		cluster := clusters[i]
 
+		// Details are filled in as they arrive; show placeholders until then
+		version, status, nodes := cluster.Version, cluster.Status, fmt.Sprintf("%d", cluster.NodeCount)
+		if !cluster.DetailsLoaded {
+			version, status, nodes = "-", "loading...", "-"
+		}
+
 		// Build row with proper spacing
-		row := fmt.Sprintf("%-30s %-15s %-15s %-10d %-30s",
+		row := fmt.Sprintf("%-30s %-15s %-15s %-10s %-30s",
 			truncate(cluster.Name, 30),
-			cluster.Version,
-			cluster.Status,
-			cluster.NodeCount,
+			version,
+			status,
+			nodes,
 			cluster.Region,
 		)
 
@@ -3975,34 +7764,337 @@ func (m model) renderEKS() string {
 			for len(row) < 98 {
 				row += " "
 			}
-			row = "\x1b[48;5;51m\x1b[38;5;0m\x1b[1m" + row + "\x1b[0m"
+			row = selectedRowPrefix() + row + "\x1b[0m"
+		}
+
+		content.WriteString(row + "\n")
+	}
+
+	// Footer info
+	content.WriteString("\n")
+	footerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	content.WriteString(footerStyle.Render(fmt.Sprintf("Showing %d-%d of %d clusters", start+1, end, len(clusters))))
+
+	return content.String()
+}
+
+func (m model) renderPortForwards() string {
+	var content strings.Builder
+
+	forwards := m.portForwardList
+	if len(forwards) == 0 {
+		title := lipgloss.NewStyle().Bold(true).Render("Port Forwards")
+		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render("No port forwards - press P on an EC2 instance to start one")
+	}
+
+	m.ensureVisible(m.portForwardIndex, len(forwards))
+	start, end := m.getVisibleRange(len(forwards))
+
+	// Title with count - k9s style
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Accent)).Bold(true)
+	tableTitle := fmt.Sprintf("Port-Forwards[%d]", len(forwards))
+	dashesWidth := (100 - len(tableTitle) - 2) / 2
+	content.WriteString(strings.Repeat("─", dashesWidth) + " ")
+	content.WriteString(titleStyle.Render(tableTitle))
+	content.WriteString(" " + strings.Repeat("─", dashesWidth) + "\n")
+
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(theme.Header)).Underline(true)
+	content.WriteString(headerStyle.Render(fmt.Sprintf("%-4s %-20s %-26s %-6s %-8s %-9s %-10s %-10s %-5s",
+		"ID", "INSTANCE", "REMOTE", "LOCAL", "STATUS", "UPTIME", "SENT", "RECEIVED", "CONNS")) + "\n")
+
+	for i := start; i < end; i++ {
+		pf := forwards[i]
+		def := pf.Definition
+
+		status, uptime := "Stopped", "-"
+		if pf.Running {
+			status = "Running"
+			uptime = formatUptime(time.Since(pf.Stats.StartedAt))
+		} else if pf.Err != nil {
+			status = "Failed"
+		}
+		local := "-"
+		if def.LocalPort != 0 {
+			local = fmt.Sprintf("%d", def.LocalPort)
+		}
+
+		row := fmt.Sprintf("%-4d %-20s %-26s %-6s %-8s %-9s %-10s %-10s %-5d",
+			pf.ID,
+			def.InstanceID,
+			truncate(portForwardRemote(def), 26),
+			local,
+			status,
+			uptime,
+			formatBytes(pf.Stats.BytesSent),
+			formatBytes(pf.Stats.BytesReceived),
+			pf.Stats.Connections,
+		)
+
+		if i == m.portForwardIndex {
+			for len(row) < 98 {
+				row += " "
+			}
+			row = selectedRowPrefix() + row + "\x1b[0m"
+		} else if status == "Failed" {
+			row = lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Error)).Render(row)
+		}
+
+		content.WriteString(row + "\n")
+	}
+
+	// Explain why the selected forward failed
+	if m.portForwardIndex < len(forwards) {
+		pf := forwards[m.portForwardIndex]
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Error))
+		if pf.Err != nil {
+			content.WriteString("\n" + errorStyle.Render(fmt.Sprintf("Error: %v", pf.Err)) + "\n")
+		} else if pf.Running && pf.Stats.LastError != nil {
+			content.WriteString("\n" + errorStyle.Render(fmt.Sprintf("Last connection failed: %v", pf.Stats.LastError)) + "\n")
+		}
+	}
+
+	content.WriteString("\n")
+	footerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	content.WriteString(footerStyle.Render(fmt.Sprintf("Showing %d-%d of %d port forwards", start+1, end, len(forwards))))
+
+	return content.String()
+}
+
+func (m model) renderTransfers() string {
+	var content strings.Builder
+
+	transfers := m.transferList
+	if len(transfers) == 0 {
+		title := lipgloss.NewStyle().Bold(true).Render("Transfers")
+		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render("No transfers - press d on an S3 object or u in a bucket to start one")
+	}
+
+	m.ensureVisible(m.transferIndex, len(transfers))
+	start, end := m.getVisibleRange(len(transfers))
+
+	// Aggregate progress across every transfer in the queue
+	totals := m.transferTotals
+	summary := fmt.Sprintf("%s %s / %s", progressBar(totals.Bytes, totals.Total, 30), formatBytes(totals.Bytes), formatBytes(totals.Total))
+	if totals.Running > 0 {
+		summary += fmt.Sprintf("  %s/s  ETA %s", formatBytes(int64(totals.Throughput)), formatTransferETA(totals.ETA))
+	}
+	content.WriteString(summary + "\n")
+	content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render(
+		fmt.Sprintf("%d running, %d queued, %d paused, %d restoring, %d done, %d failed", totals.Running, totals.Queued, totals.Paused, totals.Restoring, totals.Done, totals.Failed)) + "\n\n")
+
+	// Title with count - k9s style
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Accent)).Bold(true)
+	tableTitle := fmt.Sprintf("Transfers[%d]", len(transfers))
+	dashesWidth := (100 - len(tableTitle) - 2) / 2
+	content.WriteString(strings.Repeat("─", dashesWidth) + " ")
+	content.WriteString(titleStyle.Render(tableTitle))
+	content.WriteString(" " + strings.Repeat("─", dashesWidth) + "\n")
+
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(theme.Header)).Underline(true)
+	content.WriteString(headerStyle.Render(fmt.Sprintf("%-4s %-4s %-9s %-36s %-17s %-10s %-7s",
+		"ID", "KIND", "STATE", "OBJECT", "PROGRESS", "RATE", "ETA")) + "\n")
+
+	for i := start; i < end; i++ {
+		t := transfers[i]
+
+		kind := "DOWN"
+		if t.Request.Kind == aws.TransferUpload {
+			kind = "UP"
+		}
+		rate, eta := "-", "-"
+		if t.State == aws.TransferRunning {
+			rate = formatBytes(int64(t.Throughput)) + "/s"
+			eta = formatTransferETA(t.ETA)
+		}
+
+		row := fmt.Sprintf("%-4d %-4s %-9s %-36s %-17s %-10s %-7s",
+			t.ID,
+			kind,
+			t.State,
+			truncate(t.Request.Object(), 36),
+			progressBar(t.Bytes, t.Total, 10),
+			rate,
+			eta,
+		)
+
+		if i == m.transferIndex {
+			for len(row) < 98 {
+				row += " "
+			}
+			row = selectedRowPrefix() + row + "\x1b[0m"
+		} else if t.State == aws.TransferFailed {
+			row = lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Error)).Render(row)
+		} else if t.State == aws.TransferRestoring {
+			row = lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render(row)
+		}
+
+		content.WriteString(row + "\n")
+	}
+
+	// Show where the selected transfer goes and why it failed
+	if m.transferIndex < len(transfers) {
+		t := transfers[m.transferIndex]
+		mutedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted))
+		detail := fmt.Sprintf("%s <-> %s", t.Request.LocalPath, t.Request.Object())
+		if t.Resumed > 0 {
+			detail += fmt.Sprintf(" (resumed %s from an earlier upload)", formatBytes(t.Resumed))
+		}
+		content.WriteString("\n" + mutedStyle.Render(detail) + "\n")
+		if t.State == aws.TransferRestoring {
+			content.WriteString(mutedStyle.Render("Waiting for the object to be restored from its archive; the download starts by itself once it is") + "\n")
+		}
+		if t.Err != nil {
+			errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Error))
+			content.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", t.Err)) + "\n")
+		}
+	}
+
+	content.WriteString("\n")
+	footerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	content.WriteString(footerStyle.Render(fmt.Sprintf("Showing %d-%d of %d transfers, up to %d at once", start+1, end, len(transfers), m.transfers.Concurrency())))
+
+	return content.String()
+}
+
+func (m model) renderSyncPlan() string {
+	var content strings.Builder
+
+	plan := m.syncPlan
+	if plan == nil || len(plan.Actions) == 0 {
+		title := lipgloss.NewStyle().Bold(true).Render("Sync Plan")
+		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render("Nothing to sync")
+	}
+
+	actions := plan.Actions
+	m.ensureVisible(m.syncPlanIndex, len(actions))
+	start, end := m.getVisibleRange(len(actions))
+
+	copies, deletes, bytes := plan.Summary()
+	mutedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted))
+	content.WriteString(fmt.Sprintf("%s -> %s\n", plan.Source, plan.Destination))
+	content.WriteString(mutedStyle.Render(fmt.Sprintf("%d to copy (%s), %d to delete, %d unchanged, %d excluded",
+		copies, formatBytes(bytes), deletes, plan.Unchanged, plan.Excluded)) + "\n")
+	if len(plan.Unsafe) > 0 {
+		warningStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Error))
+		content.WriteString(warningStyle.Render(fmt.Sprintf("Skipping %d keys that would be written outside %s: %s",
+			len(plan.Unsafe), plan.Destination, truncate(strings.Join(plan.Unsafe, ", "), 120))) + "\n")
+	}
+	content.WriteString("\n")
+
+	// Title with count - k9s style
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Accent)).Bold(true)
+	tableTitle := fmt.Sprintf("Sync-Plan[%d]", len(actions))
+	dashesWidth := (100 - len(tableTitle) - 2) / 2
+	content.WriteString(strings.Repeat("─", dashesWidth) + " ")
+	content.WriteString(titleStyle.Render(tableTitle))
+	content.WriteString(" " + strings.Repeat("─", dashesWidth) + "\n")
+
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(theme.Header)).Underline(true)
+	content.WriteString(headerStyle.Render(fmt.Sprintf("  %-9s %-56s %-10s %-16s",
+		"ACTION", "PATH", "SIZE", "REASON")) + "\n")
+
+	for i := start; i < end; i++ {
+		action := actions[i]
+
+		// Diff markers: + new file, ~ changed file, - deleted file
+		marker, color := "~", theme.Warning
+		if action.Kind == aws.SyncDelete {
+			marker, color = "-", theme.Error
+		} else if action.Reason == aws.SyncReasonNew {
+			marker, color = "+", theme.Success
+		}
+
+		row := fmt.Sprintf("%s %-9s %-56s %-10s %-16s",
+			marker,
+			action.Kind,
+			truncate(action.Path, 56),
+			formatBytes(action.Size),
+			action.Reason,
+		)
+
+		if i == m.syncPlanIndex {
+			for len(row) < 98 {
+				row += " "
+			}
+			row = selectedRowPrefix() + row + "\x1b[0m"
+		} else {
+			row = lipgloss.NewStyle().Foreground(lipgloss.Color(color)).Render(row)
 		}
 
-		content.WriteString(row + "\n")
+		content.WriteString(row + "\n")
+	}
+
+	// Show exactly what the selected action does
+	if m.syncPlanIndex < len(actions) {
+		content.WriteString("\n" + mutedStyle.Render(actions[m.syncPlanIndex].String()) + "\n")
+	}
+
+	content.WriteString("\n")
+	footerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	content.WriteString(footerStyle.Render(fmt.Sprintf("Showing %d-%d of %d changes - nothing is changed until you press Enter", start+1, end, len(actions))))
+
+	return content.String()
+}
+
+// progressBar draws done out of total as a bar of width cells and a percentage
+func progressBar(done, total int64, width int) string {
+	percent := 0.0
+	if total > 0 {
+		percent = float64(done) / float64(total)
+	}
+	if percent > 1 {
+		percent = 1
+	}
+	filled := int(percent * float64(width))
+	return "[" + strings.Repeat("#", filled) + strings.Repeat(".", width-filled) + "]" + fmt.Sprintf("%4.0f%%", percent*100)
+}
+
+// formatTransferETA formats the time a transfer has left, "-" when unknown
+func formatTransferETA(eta time.Duration) string {
+	if eta <= 0 {
+		return "-"
 	}
+	return formatUptime(eta)
+}
 
-	// Footer info
-	content.WriteString("\n")
-	footerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
-	content.WriteString(footerStyle.Render(fmt.Sprintf("Showing %d-%d of %d clusters", start+1, end, len(clusters))))
+// portForwardRemote formats the host and port a forward connects to
+func portForwardRemote(def aws.PortForwardDefinition) string {
+	host := def.RemoteHost
+	if host == "" {
+		host = "localhost"
+	}
+	return fmt.Sprintf("%s:%d", host, def.RemotePort)
+}
 
-	return content.String()
+// formatUptime formats a duration as its two largest units, e.g. "1h05m"
+func formatUptime(d time.Duration) string {
+	d = d.Round(time.Second)
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd%02dh", int(d.Hours())/24, int(d.Hours())%24)
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	default:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
 }
 
 func (m model) renderEKSDetails() string {
 	title := lipgloss.NewStyle().Bold(true).Render("EKS Cluster Details")
 
 	if m.loading {
-		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Render("Loading cluster details...")
+		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render("Loading cluster details...")
 	}
 
 	if m.err != nil {
-		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Error))
 		return title + "\n\n" + errorStyle.Render(fmt.Sprintf("Error: %v", m.err))
 	}
 
 	if m.eksClusterDetails == nil {
-		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("No cluster details available")
+		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render("No cluster details available")
 	}
 
 	details := m.eksClusterDetails
@@ -4011,7 +8103,7 @@ func (m model) renderEKSDetails() string {
 
 	// Section styling
 	sectionStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("6"))
-	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted))
 	valueStyle := lipgloss.NewStyle()
 
 	// Basic Information
@@ -4079,7 +8171,7 @@ func (m model) renderEKSDetails() string {
 		content.WriteString(sectionStyle.Render(fmt.Sprintf("Node Groups (%d)", len(m.eksNodeGroups))) + "\n")
 
 		// Table header
-		headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("255")).Underline(true)
+		headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(theme.Header)).Underline(true)
 		content.WriteString("  " + headerStyle.Render(fmt.Sprintf("%-25s %-15s %-20s %-15s",
 			"NAME", "STATUS", "INSTANCE TYPES", "SIZE (D/Min/Max)")) + "\n")
 
@@ -4105,7 +8197,7 @@ func (m model) renderEKSDetails() string {
 		content.WriteString(sectionStyle.Render(fmt.Sprintf("Add-ons (%d)", len(m.eksAddons))) + "\n")
 
 		// Table header
-		headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("255")).Underline(true)
+		headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(theme.Header)).Underline(true)
 		content.WriteString("  " + headerStyle.Render(fmt.Sprintf("%-25s %-15s %-15s",
 			"NAME", "VERSION", "STATUS")) + "\n")
 
@@ -4133,37 +8225,518 @@ func (m model) renderEKSDetails() string {
 			}
 		}
 	}
-
-	return m.renderWithViewport(content.String())
+
+	return m.renderWithViewport(content.String())
+}
+
+func (m model) renderAlarms() string {
+	title := lipgloss.NewStyle().Bold(true).Render("CloudWatch Alarms")
+	if m.vimState.LastSearch != "" {
+		title += lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render(fmt.Sprintf(" [search: %s]", m.vimState.LastSearch))
+	}
+
+	if m.loading {
+		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render("Loading alarms...")
+	}
+
+	if m.err != nil {
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Error))
+		return title + "\n\n" + errorStyle.Render(fmt.Sprintf("Error: %v", m.err))
+	}
+
+	// Use filtered alarms if VIM search is active, otherwise use all alarms
+	alarms := m.alarms
+	if len(m.alarmsFiltered) > 0 {
+		alarms = m.alarmsFiltered
+	} else if m.vimState.LastSearch != "" {
+		// Search is active but no results
+		alarms = []aws.Alarm{}
+	}
+
+	if len(alarms) == 0 {
+		if m.vimState.LastSearch != "" {
+			return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render("No alarms match your search")
+		}
+		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render("No CloudWatch alarms found")
+	}
+
+	m.ensureVisible(m.alarmSelectedIndex, len(alarms))
+	start, end := m.getVisibleRange(len(alarms))
+
+	var content strings.Builder
+
+	// Title with count - k9s style
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Accent)).Bold(true)
+	searchInfo := ""
+	if m.vimState.LastSearch != "" {
+		searchInfo = lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Search)).Render("(" + m.vimState.LastSearch + ")")
+	}
+	tableTitle := fmt.Sprintf("Alarms%s[%d]", searchInfo, len(alarms))
+	dashesWidth := (100 - len(tableTitle) - 2) / 2
+	if dashesWidth < 1 {
+		dashesWidth = 1
+	}
+	content.WriteString(strings.Repeat("─", dashesWidth) + " ")
+	content.WriteString(titleStyle.Render(tableTitle))
+	content.WriteString(" " + strings.Repeat("─", dashesWidth) + "\n")
+
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(theme.Header)).Underline(true)
+	content.WriteString(headerStyle.Render(fmt.Sprintf("%-30s %-9s %-17s %-7s %-25s %s",
+		"NAME", "TYPE", "STATE", "ACTIONS", "RESOURCE", "REASON")) + "\n")
+
+	for i := start; i < end; i++ {
+		alarm := alarms[i]
+
+		actions := "on"
+		if !alarm.ActionsEnabled {
+			actions = "off"
+		}
+		resource := alarm.Resource.String()
+		if resource == "" {
+			resource = "-"
+		}
+		state := fmt.Sprintf("%-17s", alarm.State)
+
+		if i == m.alarmSelectedIndex {
+			row := fmt.Sprintf("%-30s %-9s %s %-7s %-25s %s",
+				truncate(alarm.Name, 30), alarm.Type, state, actions, truncate(resource, 25), truncate(alarm.StateReason, 40))
+			for len(row) < 98 {
+				row += " "
+			}
+			content.WriteString(selectedRowPrefix() + row + "\x1b[0m\n")
+			continue
+		}
+
+		content.WriteString(fmt.Sprintf("%-30s %-9s %s %-7s %-25s %s\n",
+			truncate(alarm.Name, 30), alarm.Type, getAlarmStateStyle(alarm.State).Render(state), actions, truncate(resource, 25), truncate(alarm.StateReason, 40)))
+	}
+
+	content.WriteString("\n")
+	footerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	content.WriteString(footerStyle.Render(fmt.Sprintf("Showing %d-%d of %d alarms", start+1, end, len(alarms))))
+
+	return content.String()
+}
+
+func (m model) renderAlarmDetails() string {
+	title := lipgloss.NewStyle().Bold(true).Render("Alarm Details")
+
+	if m.alarmDetails == nil {
+		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render("No alarm details available")
+	}
+
+	alarm := m.alarmDetails
+	var content strings.Builder
+	content.WriteString(title + "\n\n")
+
+	sectionStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("6"))
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted))
+	valueStyle := lipgloss.NewStyle()
+
+	actions := "Enabled"
+	if !alarm.ActionsEnabled {
+		actions = "Disabled"
+	}
+
+	content.WriteString(sectionStyle.Render("Alarm Information") + "\n")
+	content.WriteString(labelStyle.Render("  Name:            ") + valueStyle.Render(alarm.Name) + "\n")
+	content.WriteString(labelStyle.Render("  Type:            ") + valueStyle.Render(alarm.Type) + "\n")
+	content.WriteString(labelStyle.Render("  State:           ") + getAlarmStateStyle(alarm.State).Render(alarm.State) + "\n")
+	if !alarm.StateUpdated.IsZero() {
+		content.WriteString(labelStyle.Render("  Since:           ") + valueStyle.Render(alarm.StateUpdated.Local().Format("2006-01-02 15:04:05")) + "\n")
+	}
+	if alarm.StateReason != "" {
+		content.WriteString(labelStyle.Render("  Reason:          ") + valueStyle.Render(alarm.StateReason) + "\n")
+	}
+	content.WriteString(labelStyle.Render("  Actions:         ") + valueStyle.Render(actions) + "\n")
+	if alarm.Description != "" {
+		content.WriteString(labelStyle.Render("  Description:     ") + valueStyle.Render(alarm.Description) + "\n")
+	}
+	if alarm.ARN != "" {
+		content.WriteString(labelStyle.Render("  ARN:             ") + valueStyle.Render(alarm.ARN) + "\n")
+	}
+	content.WriteString("\n")
+
+	if alarm.Type == aws.AlarmTypeComposite {
+		content.WriteString(sectionStyle.Render("Rule") + "\n")
+		content.WriteString("  " + valueStyle.Render(alarm.AlarmRule) + "\n\n")
+	} else {
+		content.WriteString(sectionStyle.Render("Metric") + "\n")
+		if alarm.Namespace != "" {
+			content.WriteString(labelStyle.Render("  Namespace:       ") + valueStyle.Render(alarm.Namespace) + "\n")
+		}
+		if alarm.MetricName != "" {
+			content.WriteString(labelStyle.Render("  Metric:          ") + valueStyle.Render(alarm.MetricName) + "\n")
+		}
+		var names []string
+		for name := range alarm.Dimensions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			content.WriteString(labelStyle.Render("  Dimension:       ") + valueStyle.Render(name+" = "+alarm.Dimensions[name]) + "\n")
+		}
+		if alarm.Resource.Kind != "" {
+			content.WriteString(labelStyle.Render("  Resource:        ") + valueStyle.Render(alarm.Resource.String()+" (press o to open)") + "\n")
+		}
+		content.WriteString("\n")
+	}
+
+	content.WriteString(sectionStyle.Render(fmt.Sprintf("State History (%d)", len(m.alarmHistory))) + "\n")
+	if len(m.alarmHistory) == 0 {
+		content.WriteString(labelStyle.Render("  No state transitions recorded") + "\n")
+		return content.String()
+	}
+
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(theme.Header)).Underline(true)
+	content.WriteString("  " + headerStyle.Render(fmt.Sprintf("%-19s %-17s %-17s %s",
+		"TIME", "FROM", "TO", "REASON")) + "\n")
+	for _, item := range m.alarmHistory {
+		reason := item.Reason
+		if reason == "" {
+			reason = item.Summary
+		}
+		content.WriteString("  " + fmt.Sprintf("%-19s %s %s %s",
+			item.Timestamp.Local().Format("2006-01-02 15:04:05"),
+			getAlarmStateStyle(item.OldState).Render(fmt.Sprintf("%-17s", item.OldState)),
+			getAlarmStateStyle(item.NewState).Render(fmt.Sprintf("%-17s", item.NewState)),
+			truncate(reason, 60),
+		) + "\n")
+	}
+
+	return content.String()
+}
+
+// renderLogTableTitle renders a k9s style table title centered between dashes
+func renderLogTableTitle(tableTitle string) string {
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Accent)).Bold(true)
+	dashesWidth := (100 - lipgloss.Width(tableTitle) - 2) / 2
+	if dashesWidth < 1 {
+		dashesWidth = 1
+	}
+	return strings.Repeat("─", dashesWidth) + " " + titleStyle.Render(tableTitle) + " " + strings.Repeat("─", dashesWidth) + "\n"
+}
+
+func (m model) renderLogGroups() string {
+	title := lipgloss.NewStyle().Bold(true).Render("CloudWatch Log Groups")
+	if m.vimState.LastSearch != "" {
+		title += lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render(fmt.Sprintf(" [search: %s]", m.vimState.LastSearch))
+	}
+
+	if m.loading {
+		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render("Loading log groups...")
+	}
+
+	if m.err != nil {
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Error))
+		return title + "\n\n" + errorStyle.Render(fmt.Sprintf("Error: %v", m.err))
+	}
+
+	// Use filtered groups if VIM search is active, otherwise use all groups
+	groups := m.logGroups
+	if len(m.logGroupsFiltered) > 0 {
+		groups = m.logGroupsFiltered
+	} else if m.vimState.LastSearch != "" {
+		// Search is active but no results
+		groups = []aws.LogGroup{}
+	}
+
+	if len(groups) == 0 {
+		if m.vimState.LastSearch != "" {
+			return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render("No log groups match your search")
+		}
+		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render("No log groups found")
+	}
+
+	m.ensureVisible(m.logGroupIndex, len(groups))
+	start, end := m.getVisibleRange(len(groups))
+
+	var content strings.Builder
+
+	searchInfo := ""
+	if m.vimState.LastSearch != "" {
+		searchInfo = lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Search)).Render("(" + m.vimState.LastSearch + ")")
+	}
+	content.WriteString(renderLogTableTitle(fmt.Sprintf("Log Groups%s[%d]", searchInfo, len(groups))))
+
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(theme.Header)).Underline(true)
+	content.WriteString(headerStyle.Render(fmt.Sprintf("%-60s %-10s %-10s %s", "NAME", "STORED", "RETENTION", "CREATED")) + "\n")
+
+	for i := start; i < end; i++ {
+		group := groups[i]
+
+		retention := "never"
+		if group.RetentionDays > 0 {
+			retention = fmt.Sprintf("%dd", group.RetentionDays)
+		}
+		created := "-"
+		if !group.CreationTime.IsZero() {
+			created = group.CreationTime.Format("2006-01-02")
+		}
+		row := fmt.Sprintf("%-60s %-10s %-10s %s", truncate(group.Name, 60), formatBytes(group.StoredBytes), retention, created)
+
+		if i == m.logGroupIndex {
+			for len(row) < 98 {
+				row += " "
+			}
+			content.WriteString(selectedRowPrefix() + row + "\x1b[0m\n")
+			continue
+		}
+		content.WriteString(row + "\n")
+	}
+
+	content.WriteString("\n")
+	footerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	content.WriteString(footerStyle.Render(fmt.Sprintf("Showing %d-%d of %d log groups", start+1, end, len(groups))))
+
+	return content.String()
+}
+
+func (m model) renderLogStreams() string {
+	title := lipgloss.NewStyle().Bold(true).Render("Log Streams: " + m.logGroupName)
+
+	if m.loading {
+		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render("Loading log streams...")
+	}
+
+	if m.err != nil {
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Error))
+		return title + "\n\n" + errorStyle.Render(fmt.Sprintf("Error: %v", m.err))
+	}
+
+	if len(m.logStreams) == 0 {
+		return title + "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render("No log streams found")
+	}
+
+	m.ensureVisible(m.logStreamIndex, len(m.logStreams))
+	start, end := m.getVisibleRange(len(m.logStreams))
+
+	var content strings.Builder
+	content.WriteString(renderLogTableTitle(fmt.Sprintf("Streams[%d]", len(m.logStreams))))
+
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(theme.Header)).Underline(true)
+	content.WriteString(headerStyle.Render(fmt.Sprintf("%-60s %-20s %s", "NAME", "LAST EVENT", "FIRST EVENT")) + "\n")
+
+	for i := start; i < end; i++ {
+		stream := m.logStreams[i]
+
+		last, first := "-", "-"
+		if !stream.LastEventTime.IsZero() {
+			last = stream.LastEventTime.Local().Format("2006-01-02 15:04:05")
+		}
+		if !stream.FirstEventTime.IsZero() {
+			first = stream.FirstEventTime.Local().Format("2006-01-02 15:04:05")
+		}
+		row := fmt.Sprintf("%-60s %-20s %s", truncate(stream.Name, 60), last, first)
+
+		if i == m.logStreamIndex {
+			for len(row) < 98 {
+				row += " "
+			}
+			content.WriteString(selectedRowPrefix() + row + "\x1b[0m\n")
+			continue
+		}
+		content.WriteString(row + "\n")
+	}
+
+	content.WriteString("\n")
+	footerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	content.WriteString(footerStyle.Render(fmt.Sprintf("Showing %d-%d of %d most recent streams", start+1, end, len(m.logStreams))))
+
+	return content.String()
+}
+
+func (m model) renderLogTail() string {
+	if m.logTail == nil {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render("No log tail running")
+	}
+
+	var content strings.Builder
+
+	status := "following"
+	if m.logTailPaused {
+		status = "paused"
+	}
+	tableTitle := fmt.Sprintf("Tail %s[%s]", m.logTail.GroupName, status)
+	content.WriteString(renderLogTableTitle(tableTitle))
+
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted))
+	streams := "all"
+	if len(m.logTail.StreamNames) == 1 {
+		streams = m.logTail.StreamNames[0]
+	} else if len(m.logTail.StreamNames) > 1 {
+		streams = fmt.Sprintf("%d streams", len(m.logTail.StreamNames))
+	}
+	filter := m.logTail.FilterPattern
+	if filter == "" {
+		filter = "none"
+	}
+	content.WriteString(labelStyle.Render("Streams: ") + streams + labelStyle.Render("   Filter: ") + filter + "\n\n")
+
+	if len(m.logEvents) == 0 {
+		content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render("Waiting for events..."))
+		return content.String()
+	}
+
+	m.ensureVisible(m.logEventIndex, len(m.logEvents))
+	start, end := m.getVisibleRange(len(m.logEvents))
+
+	timeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted))
+	streamStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+	for i := start; i < end; i++ {
+		event := m.logEvents[i]
+		timestamp := event.Timestamp.Local().Format("15:04:05.000")
+		message := truncate(strings.ReplaceAll(event.Message, "\n", " "), 120)
+
+		if i == m.logEventIndex {
+			row := fmt.Sprintf("%s %-24s %s", timestamp, truncate(event.StreamName, 24), message)
+			for len(row) < 98 {
+				row += " "
+			}
+			content.WriteString(selectedRowPrefix() + row + "\x1b[0m\n")
+			continue
+		}
+		content.WriteString(timeStyle.Render(timestamp) + " " + streamStyle.Render(fmt.Sprintf("%-24s", truncate(event.StreamName, 24))) + " " + message + "\n")
+	}
+
+	content.WriteString("\n")
+	footerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	content.WriteString(footerStyle.Render(fmt.Sprintf("Showing %d-%d of %d events", start+1, end, len(m.logEvents))))
+
+	return content.String()
+}
+
+func (m model) renderLogInsights() string {
+	var content strings.Builder
+
+	sectionStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("6"))
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted))
+
+	content.WriteString(sectionStyle.Render("Logs Insights") + "\n")
+	content.WriteString(labelStyle.Render("  Log Groups:      ") + strings.Join(m.insightsGroups, ", ") + "\n")
+	content.WriteString(labelStyle.Render("  Window:          ") + "last " + aws.MetricRanges[m.insightsRange].Label + "\n")
+	if m.savedQueryIndex >= 0 && m.savedQueryIndex < len(m.savedQueries) {
+		content.WriteString(labelStyle.Render("  Saved Query:     ") + m.savedQueries[m.savedQueryIndex].Name + "\n")
+	}
+	if m.insightsEditing {
+		content.WriteString(labelStyle.Render("  Query:           ") + m.insightsQueryInput.View() + "\n")
+	} else {
+		content.WriteString(labelStyle.Render("  Query:           ") + m.insightsQueryInput.Value() + "\n")
+	}
+	content.WriteString("\n")
+
+	result := m.insightsResult
+	if result == nil {
+		if m.insightsQueryID == "" {
+			content.WriteString(labelStyle.Render("Press enter to run the query"))
+		} else {
+			content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render("Running query..."))
+		}
+		return content.String()
+	}
+
+	status := result.Status
+	if !result.Done() {
+		status = lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render(status)
+	}
+	content.WriteString(labelStyle.Render("  Status:          ") + status + "\n")
+	content.WriteString(labelStyle.Render("  Records:         ") + fmt.Sprintf("%.0f matched, %.0f scanned (%s)", result.RecordsMatched, result.RecordsScanned, formatBytes(int64(result.BytesScanned))) + "\n\n")
+
+	if len(result.Rows) == 0 {
+		content.WriteString(labelStyle.Render("No results"))
+		return content.String()
+	}
+
+	// Fixed width columns for every field except the last, which gets the rest
+	const columnWidth = 24
+	widths := make([]int, len(result.Fields))
+	for i := range result.Fields {
+		widths[i] = columnWidth
+	}
+	if n := len(widths); n > 0 {
+		widths[n-1] = max(columnWidth, 98-columnWidth*(n-1))
+	}
+	formatRow := func(values []string) string {
+		cells := make([]string, len(values))
+		for i, value := range values {
+			if i < len(values)-1 {
+				cells[i] = fmt.Sprintf("%-*s", widths[i], truncate(value, widths[i]))
+			} else {
+				cells[i] = truncate(value, widths[i])
+			}
+		}
+		return strings.Join(cells, " ")
+	}
+
+	m.ensureVisible(m.insightsRowIndex, len(result.Rows))
+	start, end := m.getVisibleRange(len(result.Rows))
+
+	content.WriteString(renderLogTableTitle(fmt.Sprintf("Results[%d]", len(result.Rows))))
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(theme.Header)).Underline(true)
+	content.WriteString(headerStyle.Render(formatRow(result.Fields)) + "\n")
+
+	for i := start; i < end; i++ {
+		values := make([]string, len(result.Fields))
+		for j, field := range result.Fields {
+			values[j] = strings.ReplaceAll(result.Rows[i][field], "\n", " ")
+		}
+		row := formatRow(values)
+
+		if i == m.insightsRowIndex {
+			for len(row) < 98 {
+				row += " "
+			}
+			content.WriteString(selectedRowPrefix() + row + "\x1b[0m\n")
+			continue
+		}
+		content.WriteString(row + "\n")
+	}
+
+	content.WriteString("\n")
+	footerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	content.WriteString(footerStyle.Render(fmt.Sprintf("Showing %d-%d of %d rows", start+1, end, len(result.Rows))))
+
+	return content.String()
+}
+
+func getAlarmStateStyle(state string) lipgloss.Style {
+	switch state {
+	case "ALARM":
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Error))
+	case "OK":
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Success))
+	default:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning))
+	}
 }
 
 func getEKSStatusStyle(status string) lipgloss.Style {
 	switch strings.ToUpper(status) {
 	case "ACTIVE":
-		return lipgloss.NewStyle().Foreground(lipgloss.Color("2")) // Green
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Success)) // Green
 	case "CREATING", "UPDATING":
-		return lipgloss.NewStyle().Foreground(lipgloss.Color("3")) // Yellow
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)) // Yellow
 	case "DELETING":
-		return lipgloss.NewStyle().Foreground(lipgloss.Color("1")) // Red
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Error)) // Red
 	case "FAILED":
-		return lipgloss.NewStyle().Foreground(lipgloss.Color("1")) // Red
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Error)) // Red
 	default:
-		return lipgloss.NewStyle().Foreground(lipgloss.Color("8")) // Gray
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)) // Gray
 	}
 }
 
 func getStateStyle(state string) lipgloss.Style {
 	switch state {
 	case "running":
-		return lipgloss.NewStyle().Foreground(lipgloss.Color("2")) // Green
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Success)) // Green
 	case "stopped":
-		return lipgloss.NewStyle().Foreground(lipgloss.Color("3")) // Yellow
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)) // Yellow
 	case "terminated", "terminating":
-		return lipgloss.NewStyle().Foreground(lipgloss.Color("1")) // Red
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Error)) // Red
 	case "pending", "stopping":
 		return lipgloss.NewStyle().Foreground(lipgloss.Color("4")) // Blue
 	default:
-		return lipgloss.NewStyle().Foreground(lipgloss.Color("8")) // Gray
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)) // Gray
 	}
 }
 
@@ -4176,7 +8749,7 @@ func truncate(s string, max int) string {
 
 func (m model) renderHelp() string {
 	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("6"))
-	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("3"))
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(theme.Warning))
 
 	help := titleStyle.Render("LazyAWS - Keyboard Shortcuts") + "\n\n"
 
@@ -4193,7 +8766,29 @@ func (m model) renderHelp() string {
 	help += "  :help       Show help\n"
 	help += "  :ec2/s3/eks Switch service\n"
 	help += "  :account    Switch account\n"
-	help += "  :region     Switch region\n\n"
+	help += "  :region     Switch region\n"
+	help += "  :alarms     CloudWatch alarms\n"
+	help += "  :logs       CloudWatch Logs\n"
+	help += "  :insights   Logs Insights\n"
+	help += "  :pf         Port forwards\n"
+	help += "  :transfers  S3 transfer queue\n"
+	help += "  :sync up|down DIR  Sync the S3 prefix with DIR\n"
+	help += "              [--delete] [--exclude GLOB] [--include GLOB]\n"
+	help += "  :cp DEST    Copy object/folder to s3://bucket/prefix\n"
+	help += "  :mv DEST    Move object/folder (typed confirmation)\n"
+	help += "  :storageclass CLASS  Change storage class\n"
+	help += "  :tag K=V    Add tags to objects\n"
+	help += "  :restore [TIER] [DAYS]  Restore archived objects\n"
+	help += "  :versions [KEY]  Versions of an object, even a deleted one\n"
+	help += "  :presign put KEY [type=T] [sha256=S] [expires=1h]\n"
+	help += "              Presigned upload URL as a curl command (y copies)\n"
+	help += "  :presign post|form [DIR] [min=SIZE] [max=SIZE] [expires=1h]\n"
+	help += "              POST policy for uploads to the folder, as curl or HTML\n"
+	help += "  :find TERMS Search the bucket below the current folder\n"
+	help += "              GLOB|re:REGEX size>10MB modified<7d class=C tag:K=V\n"
+	help += "  :edit KIND  Edit bucket policy/cors/lifecycle/encryption/\n"
+	help += "              public-access-block/tagging in $EDITOR\n"
+	help += "  :sa/:da [GLOB]  Select/deselect all or matching\n\n"
 
 	help += headerStyle.Render("Search") + "\n"
 	help += "  /           Search\n"
@@ -4203,14 +8798,58 @@ func (m model) renderHelp() string {
 	help += "  s/S         Start/stop\n"
 	help += "  r/t         Reboot/terminate\n"
 	help += "  c           Connect SSM\n"
+	help += "  P           Port forward\n"
+	help += "  m/M         Next/prev metric chart\n"
+	help += "  w           Metric range (1h/6h/24h/7d)\n"
 	help += "  9           Launch k9s\n"
+	help += "  E           Edit tags of the instance or selection\n"
+	help += "  :tagset NAME  Apply a tag template from the settings\n"
+	help += "  :filter TERMS  Filter instances server-side (state=, tag:K=V, vpc=, type=)\n"
+	help += "  :view [save|rm] NAME  Load, save or remove a saved filter\n"
 	help += "  Space       Multi-select\n\n"
 
+	help += headerStyle.Render("Alarms") + "\n"
+	help += "  A           Enable/disable actions\n"
+	help += "  T           Set state for testing (details)\n"
+	help += "  o           Open watched instance/cluster\n\n"
+
+	help += headerStyle.Render("Logs") + "\n"
+	help += "  t           Tail group (streams: Enter tails one)\n"
+	help += "  I           Logs Insights on group\n"
+	help += "  p / f       Pause tail / filter pattern\n"
+	help += "  e / Enter   Edit / run Insights query\n"
+	help += "  w           Insights window: 1h, 6h, 24h, 7d\n"
+	help += "  L / s       Next saved query / save query\n"
+	help += "  L           Tail control plane logs (EKS details)\n\n"
+
+	help += headerStyle.Render("Port Forwards") + "\n"
+	help += "  s/S         Restart/stop\n"
+	help += "  y           Duplicate on a new port\n"
+	help += "  D           Remove\n\n"
+
+	help += headerStyle.Render("Transfers") + "\n"
+	help += "  p           Pause/resume\n"
+	help += "  S / s       Cancel / retry\n"
+	help += "  D / x       Remove / clear done\n"
+	help += "  + / -       More/fewer at once\n\n"
+
 	help += headerStyle.Render("S3") + "\n"
 	help += "  e           Edit file in $EDITOR\n"
+	help += "  E           Edit storage class, content type, metadata,\n"
+	help += "              tags and ACL of the object or selection\n"
 	help += "  d           Delete\n"
 	help += "  u           Presigned URL\n"
-	help += "  p/v         Policy/versioning\n\n"
+	help += "  p/v         Policy/versioning\n"
+	help += "  Space / V   Select / select range\n"
+	help += "  x           Clear selection\n"
+	help += "  ] / [       Next/previous preview page\n"
+	help += "  v           Object versions (objects)\n\n"
+
+	help += headerStyle.Render("Object Versions") + "\n"
+	help += "  Enter       Diff with marked or previous version\n"
+	help += "  Space       Mark version to diff against\n"
+	help += "  R           Restore version on top\n"
+	help += "  D           Remove delete marker (undelete)\n\n"
 
 	help += "Press ESC or q to close"
 
@@ -4248,15 +8887,14 @@ func editS3File(m *model) error {
 	}
 	modTimeBefore := statBefore.ModTime()
 
-	// Get editor from environment, default to vi
-	editor := os.Getenv("EDITOR")
-	if editor == "" {
-		editor = "vi"
-	}
+	// Get editor from the config or environment, default to vi.
+	// The editor may include arguments, e.g. "code --wait"
+	editor := m.config.GetEditor()
+	editorArgs := strings.Fields(editor)
 
 	// Open the file in the editor
 	fmt.Printf("Opening in %s...\n", editor)
-	editorCmd := exec.Command(editor, tmpPath)
+	editorCmd := exec.Command(editorArgs[0], append(editorArgs[1:], tmpPath)...)
 	editorCmd.Stdin = os.Stdin
 	editorCmd.Stdout = os.Stdout
 	editorCmd.Stderr = os.Stderr
@@ -4291,10 +8929,448 @@ func editS3File(m *model) error {
 	return nil
 }
 
+// editBucketConfig opens a bucket configuration document in the editor,
+// then shows what changed and applies it once confirmed. An empty document
+// removes the configuration.
+func editBucketConfig(m *model) error {
+	ctx := context.Background()
+	bucket, kind := m.s3EditBucket, m.s3EditConfigKind
+
+	fmt.Printf("Fetching %s of s3://%s...\n", kind, bucket)
+	current, err := m.awsClient.GetBucketConfig(ctx, bucket, kind)
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp("", fmt.Sprintf("lazyaws-%s-*.%s.json", bucket, kind))
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+	_, err = tmpFile.WriteString(current)
+	tmpFile.Close()
+	if err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if current == "" {
+		fmt.Printf("s3://%s has no %s, save a document to add one\n", bucket, kind)
+	}
+
+	editor := m.config.GetEditor()
+	editorArgs := strings.Fields(editor)
+	for {
+		fmt.Printf("Opening in %s...\n", editor)
+		editorCmd := exec.Command(editorArgs[0], append(editorArgs[1:], tmpPath)...)
+		editorCmd.Stdin = os.Stdin
+		editorCmd.Stdout = os.Stdout
+		editorCmd.Stderr = os.Stderr
+		if err := editorCmd.Run(); err != nil {
+			return fmt.Errorf("editor exited with error: %w", err)
+		}
+
+		data, err := os.ReadFile(tmpPath)
+		if err != nil {
+			return fmt.Errorf("failed to read temp file: %w", err)
+		}
+		edited := string(data)
+		if strings.TrimSpace(edited) == strings.TrimSpace(current) {
+			fmt.Printf("%s not modified, nothing to apply\n", kind)
+			fmt.Println("Press Enter to return to lazyaws...")
+			fmt.Scanln()
+			return nil
+		}
+
+		// Let a mistake be fixed without losing the edit
+		if err := aws.ValidateBucketConfig(kind, edited); err != nil {
+			fmt.Printf("Invalid %s: %v\n", kind, err)
+			if confirmPrompt("Edit again? [Y/n] ", true) {
+				continue
+			}
+			fmt.Println("Changes discarded")
+			fmt.Println("Press Enter to return to lazyaws...")
+			fmt.Scanln()
+			return nil
+		}
+
+		fmt.Println()
+		if strings.TrimSpace(edited) == "" {
+			fmt.Printf("The document is empty: this removes the %s of s3://%s\n", kind, bucket)
+		} else {
+			unified := diff.Diff("current/"+kind, []byte(current), "edited/"+kind, data)
+			fmt.Print(renderDiff(string(unified)))
+		}
+		fmt.Println()
+		if !confirmPrompt(fmt.Sprintf("Apply to s3://%s? [y/N] ", bucket), false) {
+			fmt.Println("Changes discarded")
+			fmt.Println("Press Enter to return to lazyaws...")
+			fmt.Scanln()
+			return nil
+		}
+
+		fmt.Printf("Applying %s to s3://%s...\n", kind, bucket)
+		if err := m.awsClient.PutBucketConfig(ctx, bucket, kind, edited); err != nil {
+			return err
+		}
+		fmt.Printf("Bucket %s updated successfully!\n", kind)
+		fmt.Println("Press Enter to return to lazyaws...")
+		fmt.Scanln()
+		return nil
+	}
+}
+
+// editObjectProperties opens the properties of the objects to edit as a
+// form in the editor, then shows what changes on each object and applies
+// it once confirmed
+func editObjectProperties(m *model) error {
+	ctx := context.Background()
+	bucket := m.s3EditBucket
+
+	fmt.Printf("Reading properties from s3://%s...\n", bucket)
+	objects, err := m.awsClient.ExpandS3Keys(ctx, bucket, m.s3EditPropertyKeys)
+	if err != nil {
+		return err
+	}
+	props, err := m.awsClient.GetObjectProperties(ctx, bucket, objects, func(done, total int) {
+		fmt.Printf("\r%d/%d objects", done, total)
+	})
+	fmt.Println()
+	if err != nil {
+		return err
+	}
+	if len(props) == 0 {
+		fmt.Println("No objects to edit")
+		fmt.Println("Press Enter to return to lazyaws...")
+		fmt.Scanln()
+		return nil
+	}
+
+	original := aws.NewObjectPropertiesForm(props)
+	current := original.Marshal()
+	tmpFile, err := os.CreateTemp("", "lazyaws-properties-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+	_, err = tmpFile.WriteString(current)
+	tmpFile.Close()
+	if err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if len(props) > 1 {
+		fmt.Printf("Editing %d objects: fields showing %s differ between them and are kept unless you change them\n", len(props), aws.FormVaries)
+	}
+	if original.ACL != "" {
+		fmt.Printf("Remove a metadata or tag key to remove it. ACL is one of %s\n", strings.Join(aws.ObjectACLs(), ", "))
+	} else {
+		fmt.Println("Remove a metadata or tag key to remove it. The bucket has ACLs disabled")
+	}
+
+	editor := m.config.GetEditor()
+	editorArgs := strings.Fields(editor)
+	for {
+		fmt.Printf("Opening in %s...\n", editor)
+		editorCmd := exec.Command(editorArgs[0], append(editorArgs[1:], tmpPath)...)
+		editorCmd.Stdin = os.Stdin
+		editorCmd.Stdout = os.Stdout
+		editorCmd.Stderr = os.Stderr
+		if err := editorCmd.Run(); err != nil {
+			return fmt.Errorf("editor exited with error: %w", err)
+		}
+
+		data, err := os.ReadFile(tmpPath)
+		if err != nil {
+			return fmt.Errorf("failed to read temp file: %w", err)
+		}
+		if strings.TrimSpace(string(data)) == strings.TrimSpace(current) {
+			fmt.Println("Properties not modified, nothing to apply")
+			fmt.Println("Press Enter to return to lazyaws...")
+			fmt.Scanln()
+			return nil
+		}
+
+		// Let a mistake be fixed without losing the edit
+		edited, err := aws.ParseObjectPropertiesForm(string(data))
+		var changes []aws.ObjectPropertyChange
+		if err == nil {
+			changes, err = aws.PlanObjectPropertyChanges(props, original, edited)
+		}
+		if err != nil {
+			fmt.Printf("Invalid properties: %v\n", err)
+			if confirmPrompt("Edit again? [Y/n] ", true) {
+				continue
+			}
+			fmt.Println("Changes discarded")
+			fmt.Println("Press Enter to return to lazyaws...")
+			fmt.Scanln()
+			return nil
+		}
+		if len(changes) == 0 {
+			fmt.Println("The edit changes none of the objects")
+			fmt.Println("Press Enter to return to lazyaws...")
+			fmt.Scanln()
+			return nil
+		}
+
+		fmt.Println()
+		fmt.Print(renderPropertyChanges(bucket, changes))
+		fmt.Println()
+		if !confirmPrompt(fmt.Sprintf("Apply to %d objects in s3://%s? [y/N] ", len(changes), bucket), false) {
+			fmt.Println("Changes discarded")
+			fmt.Println("Press Enter to return to lazyaws...")
+			fmt.Scanln()
+			return nil
+		}
+
+		result := m.awsClient.ApplyObjectPropertyChanges(ctx, bucket, changes, func(done, total int) {
+			fmt.Printf("\rUpdating %d/%d objects", done, total)
+		})
+		fmt.Println()
+		if err := result.Err(); err != nil {
+			return err
+		}
+		fmt.Printf("Updated %d objects successfully!\n", result.Succeeded)
+		fmt.Println("Press Enter to return to lazyaws...")
+		fmt.Scanln()
+		return nil
+	}
+}
+
+// editInstanceTags opens the tags of the instances to edit as a form in the
+// editor, filled in from a tag template if one was picked, then shows what
+// changes on each instance and applies it once confirmed
+func editInstanceTags(m *model) error {
+	ctx := context.Background()
+
+	fmt.Printf("Reading tags of %d instances...\n", len(m.ec2EditTagInstances))
+	instances, err := m.awsClient.GetInstanceTags(ctx, m.ec2EditTagInstances)
+	if err != nil {
+		return err
+	}
+
+	original := aws.NewInstanceTagsForm(instances)
+	current := original.Marshal()
+	form := aws.NewInstanceTagsForm(instances)
+	if m.ec2EditTagTemplate != "" {
+		form.ApplyTemplate(m.config.TagTemplates[m.ec2EditTagTemplate])
+		fmt.Printf("Tag template %s is filled in, save to review the changes\n", m.ec2EditTagTemplate)
+	}
+	tmpFile, err := os.CreateTemp("", "lazyaws-tags-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+	_, err = tmpFile.WriteString(form.Marshal())
+	tmpFile.Close()
+	if err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if len(instances) > 1 {
+		fmt.Printf("Editing %d instances: tags showing %s differ between them and are kept unless you change them\n", len(instances), aws.FormVaries)
+	}
+	fmt.Println("Remove a tag key to remove it. Set PropagateToVolumes or PropagateToNetworkInterfaces to copy the tags to attached volumes or network interfaces")
+
+	editor := m.config.GetEditor()
+	editorArgs := strings.Fields(editor)
+	for {
+		fmt.Printf("Opening in %s...\n", editor)
+		editorCmd := exec.Command(editorArgs[0], append(editorArgs[1:], tmpPath)...)
+		editorCmd.Stdin = os.Stdin
+		editorCmd.Stdout = os.Stdout
+		editorCmd.Stderr = os.Stderr
+		if err := editorCmd.Run(); err != nil {
+			return fmt.Errorf("editor exited with error: %w", err)
+		}
+
+		data, err := os.ReadFile(tmpPath)
+		if err != nil {
+			return fmt.Errorf("failed to read temp file: %w", err)
+		}
+		if strings.TrimSpace(string(data)) == strings.TrimSpace(current) {
+			fmt.Println("Tags not modified, nothing to apply")
+			fmt.Println("Press Enter to return to lazyaws...")
+			fmt.Scanln()
+			return nil
+		}
+
+		// Let a mistake be fixed without losing the edit
+		edited, err := aws.ParseInstanceTagsForm(string(data))
+		var changes []aws.InstanceTagChange
+		if err == nil {
+			changes, err = aws.PlanInstanceTagChanges(instances, original, edited)
+		}
+		if err != nil {
+			fmt.Printf("Invalid tags: %v\n", err)
+			if confirmPrompt("Edit again? [Y/n] ", true) {
+				continue
+			}
+			fmt.Println("Changes discarded")
+			fmt.Println("Press Enter to return to lazyaws...")
+			fmt.Scanln()
+			return nil
+		}
+		if len(changes) == 0 {
+			fmt.Println("The edit changes none of the instances")
+			fmt.Println("Press Enter to return to lazyaws...")
+			fmt.Scanln()
+			return nil
+		}
+
+		fmt.Println()
+		fmt.Print(renderInstanceTagChanges(changes))
+		fmt.Println()
+		if !confirmPrompt(fmt.Sprintf("Apply to %d instances? [y/N] ", len(changes)), false) {
+			fmt.Println("Changes discarded")
+			fmt.Println("Press Enter to return to lazyaws...")
+			fmt.Scanln()
+			return nil
+		}
+
+		err = m.awsClient.ApplyInstanceTagChanges(ctx, changes, func(done, total int) {
+			fmt.Printf("\rTagging %d/%d instances", done, total)
+		})
+		fmt.Println()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Updated the tags of %d instances successfully!\n", len(changes))
+		fmt.Println("Press Enter to return to lazyaws...")
+		fmt.Scanln()
+		return nil
+	}
+}
+
+// renderInstanceTagChanges lists what an edit of instance tags changes,
+// coloured like a diff
+func renderInstanceTagChanges(changes []aws.InstanceTagChange) string {
+	const shown = 20
+	var content strings.Builder
+	for i, change := range changes {
+		if i == shown {
+			content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render(fmt.Sprintf("... and %d more instances", len(changes)-shown)) + "\n")
+			break
+		}
+		title := change.Instance.InstanceID
+		if change.Instance.Name != "" {
+			title += " (" + change.Instance.Name + ")"
+		}
+		content.WriteString(lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(theme.Header)).Render(title) + "\n")
+		for _, line := range change.Describe() {
+			content.WriteString("  " + changeLineStyle(line).Render(line) + "\n")
+		}
+	}
+	return content.String()
+}
+
+// changeLineStyle colours a line of a change description by its marker
+func changeLineStyle(line string) lipgloss.Style {
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning))
+	switch line[0] {
+	case '+':
+		style = style.Foreground(lipgloss.Color(theme.Success))
+	case '-':
+		style = style.Foreground(lipgloss.Color(theme.Error))
+	}
+	return style
+}
+
+// renderPropertyChanges lists what an edit of object properties changes,
+// coloured like a diff
+func renderPropertyChanges(bucket string, changes []aws.ObjectPropertyChange) string {
+	const shown = 20
+	var content strings.Builder
+	copies := 0
+	for i, change := range changes {
+		if change.Copies() {
+			copies++
+		}
+		if i >= shown {
+			continue
+		}
+		content.WriteString(lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(theme.Header)).Render(fmt.Sprintf("s3://%s/%s", bucket, change.Before.Key)) + "\n")
+		for _, line := range change.Describe() {
+			content.WriteString("  " + changeLineStyle(line).Render(line) + "\n")
+		}
+	}
+	mutedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted))
+	if len(changes) > shown {
+		content.WriteString(mutedStyle.Render(fmt.Sprintf("... and %d more objects", len(changes)-shown)) + "\n")
+	}
+	if copies > 0 {
+		content.WriteString(mutedStyle.Render(fmt.Sprintf("%d objects are copied onto themselves, which makes a new version in a versioned bucket", copies)) + "\n")
+	}
+	return content.String()
+}
+
+// confirmPrompt asks a yes/no question on the terminal, returning def when
+// the answer is empty
+func confirmPrompt(prompt string, def bool) bool {
+	fmt.Print(prompt)
+	var answer string
+	fmt.Scanln(&answer)
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "":
+		return def
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
+
 func main() {
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		fmt.Printf("Error loading config: %v", err)
+		fmt.Printf("Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	theme = cfg.GetTheme()
+
+	// Run a CLI command instead of the TUI when one is given
+	if cli.IsCommand(os.Args[1:]) {
+		os.Exit(cli.NewApp(cfg).Run(context.Background(), os.Args[1:]))
+	}
+
+	// Port forwards run in the background for the whole process, across TUI
+	// restarts
+	portForwardsPath, err := aws.GetPortForwardsPath()
+	if err != nil {
+		fmt.Printf("Error locating port forwards: %v\n", err)
+		os.Exit(1)
+	}
+	portForwards, err := aws.NewPortForwardManager(context.Background(), portForwardsPath)
+	if err != nil {
+		fmt.Printf("Error loading port forwards: %v\n", err)
+		os.Exit(1)
+	}
+	defer portForwards.StopAll()
+
+	// S3 transfers also keep running across TUI restarts, and continue
+	// the multipart uploads an earlier run left unfinished
+	transfers := aws.NewTransferManager(context.Background(), cfg.TransferConcurrency, nil)
+	uploadJournalPath, err := aws.GetUploadJournalPath()
+	if err != nil {
+		fmt.Printf("Error locating upload journal: %v\n", err)
+		os.Exit(1)
+	}
+	uploadJournal, err := aws.LoadUploadJournal(uploadJournalPath)
+	if err != nil {
+		fmt.Printf("Error loading upload journal: %v\n", err)
+		os.Exit(1)
+	}
+	transfers.SetUploadJournal(uploadJournal)
+
+	// Saved EC2 views are shared by every TUI run
+	ec2ViewsPath, err := aws.GetInstanceViewsPath()
+	if err != nil {
+		fmt.Printf("Error locating EC2 views: %v\n", err)
+		os.Exit(1)
+	}
+	ec2Views, err := aws.LoadInstanceViews(ec2ViewsPath)
+	if err != nil {
+		fmt.Printf("Error loading EC2 views: %v\n", err)
 		os.Exit(1)
 	}
 
@@ -4304,6 +9380,9 @@ func main() {
 	var savedClient *aws.Client
 	for {
 		m := initialModel(cfg)
+		m.portForwards = portForwards
+		m.transfers = transfers
+		m.ec2Views = ec2Views
 
 		// Restore S3 state if we're coming back from editing
 		if s3Restore != nil {
@@ -4328,6 +9407,8 @@ func main() {
 		if ssmRestore != nil {
 			m.currentScreen = ec2Screen
 			m.ec2NeedRestore = true
+			m.ec2Filter = ssmRestore.ec2Filter
+			m.ec2View = ssmRestore.ec2View
 			m.loading = true
 			// Restore the AWS client so we don't need to re-auth
 			if savedClient != nil {
@@ -4345,6 +9426,7 @@ func main() {
 		finalModel, err := p.Run()
 		if err != nil {
 			fmt.Printf("Error: %v", err)
+			portForwards.StopAll()
 			os.Exit(1)
 		}
 
@@ -4355,8 +9437,8 @@ func main() {
 			break
 		}
 
-		// Handle S3 file editing
-		if finalM.s3EditBucket != "" && finalM.s3EditKey != "" {
+		// Handle S3 file and bucket configuration editing
+		if finalM.s3EditBucket != "" && (finalM.s3EditKey != "" || finalM.s3EditConfigKind != "" || len(finalM.s3EditPropertyKeys) > 0) {
 			// Save current S3 state for restoration
 			s3Restore = &s3RestoreInfo{
 				bucket:         finalM.s3CurrentBucket,
@@ -4369,8 +9451,18 @@ func main() {
 			// Save AWS client to avoid re-authentication
 			savedClient = finalM.awsClient
 
-			if err := editS3File(&finalM); err != nil {
-				fmt.Printf("Error editing S3 file: %v\n", err)
+			what := "S3 file"
+			if finalM.s3EditConfigKind != "" {
+				what = "bucket " + finalM.s3EditConfigKind
+				err = editBucketConfig(&finalM)
+			} else if len(finalM.s3EditPropertyKeys) > 0 {
+				what = "object properties"
+				err = editObjectProperties(&finalM)
+			} else {
+				err = editS3File(&finalM)
+			}
+			if err != nil {
+				fmt.Printf("Error editing %s: %v\n", what, err)
 				fmt.Println("Press Enter to return to lazyaws...")
 				fmt.Scanln()
 			}
@@ -4381,6 +9473,27 @@ func main() {
 		// Clear S3 restore state if we're not editing
 		s3Restore = nil
 
+		// Handle EC2 tag editing, coming back to the instance list
+		if len(finalM.ec2EditTagInstances) > 0 {
+			ssmRestore = &ssmRestoreInfo{
+				ssoCredentials: finalM.ssoCredentials,
+				accountID:      finalM.currentAccountID,
+				accountName:    finalM.currentAccountName,
+				region:         finalM.awsClient.GetRegion(),
+				ec2Filter:      finalM.ec2Filter,
+				ec2View:        finalM.ec2View,
+			}
+			// Save AWS client to avoid re-authentication
+			savedClient = finalM.awsClient
+
+			if err := editInstanceTags(&finalM); err != nil {
+				fmt.Printf("Error editing instance tags: %v\n", err)
+				fmt.Println("Press Enter to return to lazyaws...")
+				fmt.Scanln()
+			}
+			continue
+		}
+
 		// Handle k9s launch
 		if finalM.ssmInstanceID == "k9s" {
 			// Save current state for restoration after k9s session
@@ -4389,43 +9502,43 @@ func main() {
 				accountID:      finalM.currentAccountID,
 				accountName:    finalM.currentAccountName,
 				region:         finalM.ssmRegion,
+				ec2Filter:      finalM.ec2Filter,
+				ec2View:        finalM.ec2View,
 			}
 			// Save AWS client to avoid re-authentication
 			savedClient = finalM.awsClient
 
+			// Point kubeconfig at the cluster. The user entry calls the
+			// configured exec plugin, which is lazyaws itself by default, so
+			// k9s doesn't need the aws CLI.
+			kubeOpts := aws.KubeconfigOptionsFromConfig(cfg, finalM.k9sCluster)
+			kubeOpts.Region = finalM.ssmRegion
+			if finalM.authConfig != nil && finalM.authConfig.Method == aws.AuthMethodProfile {
+				kubeOpts.Profile = finalM.authConfig.ProfileName
+			}
+			contextName, err := finalM.awsClient.UpdateKubeconfig(context.Background(), finalM.k9sCluster, kubeOpts)
+			if err != nil {
+				fmt.Printf("Failed to update kubeconfig for %s: %v\n", finalM.k9sCluster, err)
+				fmt.Println("Press Enter to return to lazyaws...")
+				fmt.Scanln()
+				continue
+			}
+
 			// Launch k9s in the current terminal
 			fmt.Printf("Launching k9s...\n")
 			fmt.Printf("Type ':lazyaws' in k9s command mode to return to LazyAWS\n\n")
 
-			// Create the k9s command
-			k9sCmd := exec.Command("k9s")
+			k9sCmd := exec.Command("k9s", "--context", contextName)
+			k9sCmd.Env = os.Environ()
 
-			// If using SSO credentials, pass them as environment variables
+			// The exec plugin inherits k9s's environment, so SSO credentials
+			// are passed as environment variables
 			if finalM.ssoCredentials != nil {
-				k9sCmd.Env = os.Environ()
 				k9sCmd.Env = append(k9sCmd.Env,
 					fmt.Sprintf("AWS_ACCESS_KEY_ID=%s", finalM.ssoCredentials.AccessKeyID),
 					fmt.Sprintf("AWS_SECRET_ACCESS_KEY=%s", finalM.ssoCredentials.SecretAccessKey),
 					fmt.Sprintf("AWS_SESSION_TOKEN=%s", finalM.ssoCredentials.SessionToken),
 				)
-			} else {
-				// Use profile
-				k9sCmd.Env = os.Environ()
-				// Verificar se há profile configurado no ambiente
-				hasProfile := false
-				for _, env := range k9sCmd.Env {
-					if strings.HasPrefix(env, "AWS_PROFILE=") {
-						hasProfile = true
-						break
-					}
-				}
-				
-				if !hasProfile {
-					fmt.Printf("Não foi possível efetuar o login, profile incorreto ou não encontrado\n")
-					fmt.Println("Press Enter to return to lazyaws...")
-					fmt.Scanln()
-					continue
-				}
 			}
 
 			// Start the command with a PTY to properly handle signals
@@ -4485,6 +9598,8 @@ func main() {
 			accountID:      finalM.currentAccountID,
 			accountName:    finalM.currentAccountName,
 			region:         finalM.ssmRegion,
+			ec2Filter:      finalM.ec2Filter,
+			ec2View:        finalM.ec2View,
 		}
 		// Save AWS client to avoid re-authentication
 		savedClient = finalM.awsClient
@@ -4492,57 +9607,14 @@ func main() {
 		// Launch SSM session in the current terminal
 		fmt.Printf("Connecting to instance %s via SSM...\n", finalM.ssmInstanceID)
 
-		// Create the SSM command
-		ssmCmd := exec.Command("aws", "ssm", "start-session", "--target", finalM.ssmInstanceID, "--region", finalM.ssmRegion)
-
-		// If using SSO credentials, pass them as environment variables to AWS CLI
-		if finalM.ssoCredentials != nil {
-			ssmCmd.Env = os.Environ()
-			ssmCmd.Env = append(ssmCmd.Env,
-				fmt.Sprintf("AWS_ACCESS_KEY_ID=%s", finalM.ssoCredentials.AccessKeyID),
-				fmt.Sprintf("AWS_SECRET_ACCESS_KEY=%s", finalM.ssoCredentials.SecretAccessKey),
-				fmt.Sprintf("AWS_SESSION_TOKEN=%s", finalM.ssoCredentials.SessionToken),
-			)
-		}
-
-		// Start the command with a PTY to properly handle signals
-		ptmx, err := pty.Start(ssmCmd)
-		if err != nil {
-			fmt.Printf("Failed to start SSM session: %v\n", err)
+		// Run the session in-process, bridged to this terminal
+		if err := finalM.awsClient.RunSSMShell(context.Background(), finalM.ssmInstanceID, os.Stdin, os.Stdout); err != nil {
+			fmt.Printf("\r\nSSM session failed: %v\n", err)
 			fmt.Println("Press Enter to return to lazyaws...")
 			fmt.Scanln()
 			continue
 		}
 
-		// Handle terminal resize signals
-		ch := make(chan os.Signal, 1)
-		signal.Notify(ch, syscall.SIGWINCH)
-		go func() {
-			for range ch {
-				if err := pty.InheritSize(os.Stdin, ptmx); err != nil {
-					fmt.Printf("Error resizing pty: %v\n", err)
-				}
-			}
-		}()
-		ch <- syscall.SIGWINCH // Initial resize
-
-		// Set stdin in raw mode
-		oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
-		if err != nil {
-			fmt.Printf("Failed to set raw mode: %v\n", err)
-			ptmx.Close()
-			continue
-		}
-
-		// Copy stdin/stdout
-		go func() { io.Copy(ptmx, os.Stdin) }()
-		io.Copy(os.Stdout, ptmx)
-
-		// Restore terminal
-		term.Restore(int(os.Stdin.Fd()), oldState)
-		signal.Stop(ch)
-		close(ch)
-
 		fmt.Println("\nReturning to lazyaws...")
 		// Loop continues and restarts the TUI
 	}